@@ -0,0 +1,1503 @@
+// Control-plane API of oqbridge. The migration daemon (oqbridge-migrate)
+// serves MigrationService on migration.grpc_listen, the proxy (oqbridge)
+// serves RoutingService on server.grpc_listen. Both also serve the standard
+// grpc.health.v1.Health service.
+//
+// Regenerate the Go code with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	  api/oqbridge/v1/control.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.28.3
+// source: api/oqbridge/v1/control.proto
+
+package oqbridgev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TriggerMigrationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerMigrationRequest) Reset() {
+	*x = TriggerMigrationRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerMigrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerMigrationRequest) ProtoMessage() {}
+
+func (x *TriggerMigrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerMigrationRequest.ProtoReflect.Descriptor instead.
+func (*TriggerMigrationRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+type TriggerMigrationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerMigrationResponse) Reset() {
+	*x = TriggerMigrationResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerMigrationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerMigrationResponse) ProtoMessage() {}
+
+func (x *TriggerMigrationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerMigrationResponse.ProtoReflect.Descriptor instead.
+func (*TriggerMigrationResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TriggerMigrationResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+type PauseMigrationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Also cancel the run in progress. Its indices keep their checkpoints
+	// and resume where they stopped on the next run.
+	CancelRunning bool `protobuf:"varint,1,opt,name=cancel_running,json=cancelRunning,proto3" json:"cancel_running,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseMigrationRequest) Reset() {
+	*x = PauseMigrationRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseMigrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseMigrationRequest) ProtoMessage() {}
+
+func (x *PauseMigrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseMigrationRequest.ProtoReflect.Descriptor instead.
+func (*PauseMigrationRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PauseMigrationRequest) GetCancelRunning() bool {
+	if x != nil {
+		return x.CancelRunning
+	}
+	return false
+}
+
+type PauseMigrationResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether a run in progress was cancelled.
+	CancelledRun  bool `protobuf:"varint,1,opt,name=cancelled_run,json=cancelledRun,proto3" json:"cancelled_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseMigrationResponse) Reset() {
+	*x = PauseMigrationResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseMigrationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseMigrationResponse) ProtoMessage() {}
+
+func (x *PauseMigrationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseMigrationResponse.ProtoReflect.Descriptor instead.
+func (*PauseMigrationResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PauseMigrationResponse) GetCancelledRun() bool {
+	if x != nil {
+		return x.CancelledRun
+	}
+	return false
+}
+
+type ResumeMigrationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeMigrationRequest) Reset() {
+	*x = ResumeMigrationRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeMigrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeMigrationRequest) ProtoMessage() {}
+
+func (x *ResumeMigrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeMigrationRequest.ProtoReflect.Descriptor instead.
+func (*ResumeMigrationRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{4}
+}
+
+type ResumeMigrationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeMigrationResponse) Reset() {
+	*x = ResumeMigrationResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeMigrationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeMigrationResponse) ProtoMessage() {}
+
+func (x *ResumeMigrationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeMigrationResponse.ProtoReflect.Descriptor instead.
+func (*ResumeMigrationResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{5}
+}
+
+type GetMigrationStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMigrationStatusRequest) Reset() {
+	*x = GetMigrationStatusRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMigrationStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMigrationStatusRequest) ProtoMessage() {}
+
+func (x *GetMigrationStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMigrationStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetMigrationStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{6}
+}
+
+type GetMigrationStatusResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Paused bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	// The run in progress, if any.
+	Running *RunningMigration `protobuf:"bytes,2,opt,name=running,proto3" json:"running,omitempty"`
+	// The last run this daemon finished since it started, if any.
+	LastRun       *MigrationRun            `protobuf:"bytes,3,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	Sources       []*SourceMigrationStatus `protobuf:"bytes,4,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMigrationStatusResponse) Reset() {
+	*x = GetMigrationStatusResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMigrationStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMigrationStatusResponse) ProtoMessage() {}
+
+func (x *GetMigrationStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMigrationStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetMigrationStatusResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetMigrationStatusResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *GetMigrationStatusResponse) GetRunning() *RunningMigration {
+	if x != nil {
+		return x.Running
+	}
+	return nil
+}
+
+func (x *GetMigrationStatusResponse) GetLastRun() *MigrationRun {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+func (x *GetMigrationStatusResponse) GetSources() []*SourceMigrationStatus {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type RunningMigration struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// What started the run: "schedule" or "trigger".
+	Trigger       string `protobuf:"bytes,2,opt,name=trigger,proto3" json:"trigger,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunningMigration) Reset() {
+	*x = RunningMigration{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunningMigration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunningMigration) ProtoMessage() {}
+
+func (x *RunningMigration) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunningMigration.ProtoReflect.Descriptor instead.
+func (*RunningMigration) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RunningMigration) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RunningMigration) GetTrigger() string {
+	if x != nil {
+		return x.Trigger
+	}
+	return ""
+}
+
+// MigrationRun is the summary of a run, as printed by "oqbridge-migrate
+// --once".
+type MigrationRun struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// success, nothing_to_do, partial_failure or failed.
+	Outcome           string                 `protobuf:"bytes,1,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	StartedAt         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	DocumentsMigrated int64                  `protobuf:"varint,4,opt,name=documents_migrated,json=documentsMigrated,proto3" json:"documents_migrated,omitempty"`
+	Indices           []*IndexRunResult      `protobuf:"bytes,5,rep,name=indices,proto3" json:"indices,omitempty"`
+	// Run-level failure, e.g. Quickwit not ready.
+	Error         string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MigrationRun) Reset() {
+	*x = MigrationRun{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationRun) ProtoMessage() {}
+
+func (x *MigrationRun) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationRun.ProtoReflect.Descriptor instead.
+func (*MigrationRun) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MigrationRun) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *MigrationRun) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *MigrationRun) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *MigrationRun) GetDocumentsMigrated() int64 {
+	if x != nil {
+		return x.DocumentsMigrated
+	}
+	return 0
+}
+
+func (x *MigrationRun) GetIndices() []*IndexRunResult {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *MigrationRun) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type IndexRunResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index string                 `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	// migration.sources entry, if configured.
+	Source string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	// migrated, failed or skipped.
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Why the index was skipped.
+	Reason            string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	DocumentsMigrated int64  `protobuf:"varint,5,opt,name=documents_migrated,json=documentsMigrated,proto3" json:"documents_migrated,omitempty"`
+	Error             string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *IndexRunResult) Reset() {
+	*x = IndexRunResult{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexRunResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexRunResult) ProtoMessage() {}
+
+func (x *IndexRunResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexRunResult.ProtoReflect.Descriptor instead.
+func (*IndexRunResult) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IndexRunResult) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *IndexRunResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *IndexRunResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *IndexRunResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *IndexRunResult) GetDocumentsMigrated() int64 {
+	if x != nil {
+		return x.DocumentsMigrated
+	}
+	return 0
+}
+
+func (x *IndexRunResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SourceMigrationStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// migration.sources or tiers entry; empty for opensearch.
+	Name    string                  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Indices []*IndexMigrationStatus `protobuf:"bytes,2,rep,name=indices,proto3" json:"indices,omitempty"`
+	// Why the state of the source could not be read.
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceMigrationStatus) Reset() {
+	*x = SourceMigrationStatus{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceMigrationStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceMigrationStatus) ProtoMessage() {}
+
+func (x *SourceMigrationStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceMigrationStatus.ProtoReflect.Descriptor instead.
+func (*SourceMigrationStatus) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SourceMigrationStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SourceMigrationStatus) GetIndices() []*IndexMigrationStatus {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *SourceMigrationStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type IndexMigrationStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index string                 `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	// Upper bound of the last successful migration, if any.
+	MigratedBefore *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=migrated_before,json=migratedBefore,proto3" json:"migrated_before,omitempty"`
+	// The stored checkpoint, if any.
+	Checkpoint *MigrationCheckpoint `protobuf:"bytes,3,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	// The most recent recorded run, if any.
+	LastRun       *RecordedRun `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	TotalMigrated int64        `protobuf:"varint,5,opt,name=total_migrated,json=totalMigrated,proto3" json:"total_migrated,omitempty"`
+	// The lock on the index, if one is held.
+	Lock          *MigrationLock `protobuf:"bytes,6,opt,name=lock,proto3" json:"lock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexMigrationStatus) Reset() {
+	*x = IndexMigrationStatus{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexMigrationStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexMigrationStatus) ProtoMessage() {}
+
+func (x *IndexMigrationStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexMigrationStatus.ProtoReflect.Descriptor instead.
+func (*IndexMigrationStatus) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *IndexMigrationStatus) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *IndexMigrationStatus) GetMigratedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MigratedBefore
+	}
+	return nil
+}
+
+func (x *IndexMigrationStatus) GetCheckpoint() *MigrationCheckpoint {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+func (x *IndexMigrationStatus) GetLastRun() *RecordedRun {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+func (x *IndexMigrationStatus) GetTotalMigrated() int64 {
+	if x != nil {
+		return x.TotalMigrated
+	}
+	return 0
+}
+
+func (x *IndexMigrationStatus) GetLock() *MigrationLock {
+	if x != nil {
+		return x.Lock
+	}
+	return nil
+}
+
+type MigrationCheckpoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Completed     bool                   `protobuf:"varint,1,opt,name=completed,proto3" json:"completed,omitempty"`
+	TotalDocs     int64                  `protobuf:"varint,2,opt,name=total_docs,json=totalDocs,proto3" json:"total_docs,omitempty"`
+	Migrated      int64                  `protobuf:"varint,3,opt,name=migrated,proto3" json:"migrated,omitempty"`
+	Cutoff        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=cutoff,proto3" json:"cutoff,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MigrationCheckpoint) Reset() {
+	*x = MigrationCheckpoint{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationCheckpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationCheckpoint) ProtoMessage() {}
+
+func (x *MigrationCheckpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationCheckpoint.ProtoReflect.Descriptor instead.
+func (*MigrationCheckpoint) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MigrationCheckpoint) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *MigrationCheckpoint) GetTotalDocs() int64 {
+	if x != nil {
+		return x.TotalDocs
+	}
+	return 0
+}
+
+func (x *MigrationCheckpoint) GetMigrated() int64 {
+	if x != nil {
+		return x.Migrated
+	}
+	return 0
+}
+
+func (x *MigrationCheckpoint) GetCutoff() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Cutoff
+	}
+	return nil
+}
+
+func (x *MigrationCheckpoint) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type RecordedRun struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Status            string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	DocumentsMigrated int64                  `protobuf:"varint,3,opt,name=documents_migrated,json=documentsMigrated,proto3" json:"documents_migrated,omitempty"`
+	Error             string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RecordedRun) Reset() {
+	*x = RecordedRun{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordedRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordedRun) ProtoMessage() {}
+
+func (x *RecordedRun) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordedRun.ProtoReflect.Descriptor instead.
+func (*RecordedRun) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RecordedRun) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RecordedRun) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RecordedRun) GetDocumentsMigrated() int64 {
+	if x != nil {
+		return x.DocumentsMigrated
+	}
+	return 0
+}
+
+func (x *RecordedRun) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type MigrationLock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Owner         string                 `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	AcquiredAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=acquired_at,json=acquiredAt,proto3" json:"acquired_at,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MigrationLock) Reset() {
+	*x = MigrationLock{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationLock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationLock) ProtoMessage() {}
+
+func (x *MigrationLock) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationLock.ProtoReflect.Descriptor instead.
+func (*MigrationLock) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *MigrationLock) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *MigrationLock) GetAcquiredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AcquiredAt
+	}
+	return nil
+}
+
+func (x *MigrationLock) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type GetIndexRoutingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         string                 `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetIndexRoutingRequest) Reset() {
+	*x = GetIndexRoutingRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIndexRoutingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIndexRoutingRequest) ProtoMessage() {}
+
+func (x *GetIndexRoutingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIndexRoutingRequest.ProtoReflect.Descriptor instead.
+func (*GetIndexRoutingRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetIndexRoutingRequest) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+type GetIndexRoutingResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Index          string                 `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	TimestampField string                 `protobuf:"bytes,2,opt,name=timestamp_field,json=timestampField,proto3" json:"timestamp_field,omitempty"`
+	// Days the index keeps in the hot OpenSearch cluster.
+	HotDays int32 `protobuf:"varint,3,opt,name=hot_days,json=hotDays,proto3" json:"hot_days,omitempty"`
+	// Days after which Quickwit deletes the documents; 0 keeps them.
+	ColdDays int32 `protobuf:"varint,4,opt,name=cold_days,json=coldDays,proto3" json:"cold_days,omitempty"`
+	// The Quickwit index and quickwit_clusters entry holding its cold data.
+	QuickwitIndex   string `protobuf:"bytes,5,opt,name=quickwit_index,json=quickwitIndex,proto3" json:"quickwit_index,omitempty"`
+	QuickwitCluster string `protobuf:"bytes,6,opt,name=quickwit_cluster,json=quickwitCluster,proto3" json:"quickwit_cluster,omitempty"`
+	DualWrite       bool   `protobuf:"varint,7,opt,name=dual_write,json=dualWrite,proto3" json:"dual_write,omitempty"`
+	LateWrites      bool   `protobuf:"varint,8,opt,name=late_writes,json=lateWrites,proto3" json:"late_writes,omitempty"`
+	// Where the documents live, youngest first.
+	Segments      []*RoutingSegment `protobuf:"bytes,9,rep,name=segments,proto3" json:"segments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetIndexRoutingResponse) Reset() {
+	*x = GetIndexRoutingResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIndexRoutingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIndexRoutingResponse) ProtoMessage() {}
+
+func (x *GetIndexRoutingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIndexRoutingResponse.ProtoReflect.Descriptor instead.
+func (*GetIndexRoutingResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetIndexRoutingResponse) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *GetIndexRoutingResponse) GetTimestampField() string {
+	if x != nil {
+		return x.TimestampField
+	}
+	return ""
+}
+
+func (x *GetIndexRoutingResponse) GetHotDays() int32 {
+	if x != nil {
+		return x.HotDays
+	}
+	return 0
+}
+
+func (x *GetIndexRoutingResponse) GetColdDays() int32 {
+	if x != nil {
+		return x.ColdDays
+	}
+	return 0
+}
+
+func (x *GetIndexRoutingResponse) GetQuickwitIndex() string {
+	if x != nil {
+		return x.QuickwitIndex
+	}
+	return ""
+}
+
+func (x *GetIndexRoutingResponse) GetQuickwitCluster() string {
+	if x != nil {
+		return x.QuickwitCluster
+	}
+	return ""
+}
+
+func (x *GetIndexRoutingResponse) GetDualWrite() bool {
+	if x != nil {
+		return x.DualWrite
+	}
+	return false
+}
+
+func (x *GetIndexRoutingResponse) GetLateWrites() bool {
+	if x != nil {
+		return x.LateWrites
+	}
+	return false
+}
+
+func (x *GetIndexRoutingResponse) GetSegments() []*RoutingSegment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+// RoutingSegment is the time range of an index's documents that one
+// backend holds.
+type RoutingSegment struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "opensearch", a tiers entry or "quickwit".
+	Backend string `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Unset for the oldest document.
+	From *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// Unset for now.
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoutingSegment) Reset() {
+	*x = RoutingSegment{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoutingSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoutingSegment) ProtoMessage() {}
+
+func (x *RoutingSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoutingSegment.ProtoReflect.Descriptor instead.
+func (*RoutingSegment) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RoutingSegment) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *RoutingSegment) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *RoutingSegment) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type RouteSearchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Indices as in the search path; empty searches all indices.
+	Indices []string `protobuf:"bytes,1,rep,name=indices,proto3" json:"indices,omitempty"`
+	// Search request body. Only the range on the timestamp field matters;
+	// without one every backend is searched.
+	Query         []byte `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteSearchRequest) Reset() {
+	*x = RouteSearchRequest{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteSearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteSearchRequest) ProtoMessage() {}
+
+func (x *RouteSearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteSearchRequest.ProtoReflect.Descriptor instead.
+func (*RouteSearchRequest) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RouteSearchRequest) GetIndices() []string {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *RouteSearchRequest) GetQuery() []byte {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+type RouteSearchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// hot_only, cold_only, both or tiered.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// Backends searched: "opensearch", tiers entries and "quickwit".
+	Backends      []string `protobuf:"bytes,2,rep,name=backends,proto3" json:"backends,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteSearchResponse) Reset() {
+	*x = RouteSearchResponse{}
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteSearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteSearchResponse) ProtoMessage() {}
+
+func (x *RouteSearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_oqbridge_v1_control_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteSearchResponse.ProtoReflect.Descriptor instead.
+func (*RouteSearchResponse) Descriptor() ([]byte, []int) {
+	return file_api_oqbridge_v1_control_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RouteSearchResponse) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *RouteSearchResponse) GetBackends() []string {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
+var File_api_oqbridge_v1_control_proto protoreflect.FileDescriptor
+
+const file_api_oqbridge_v1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapi/oqbridge/v1/control.proto\x12\voqbridge.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x19\n" +
+	"\x17TriggerMigrationRequest\"U\n" +
+	"\x18TriggerMigrationResponse\x129\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\">\n" +
+	"\x15PauseMigrationRequest\x12%\n" +
+	"\x0ecancel_running\x18\x01 \x01(\bR\rcancelRunning\"=\n" +
+	"\x16PauseMigrationResponse\x12#\n" +
+	"\rcancelled_run\x18\x01 \x01(\bR\fcancelledRun\"\x18\n" +
+	"\x16ResumeMigrationRequest\"\x19\n" +
+	"\x17ResumeMigrationResponse\"\x1b\n" +
+	"\x19GetMigrationStatusRequest\"\xe1\x01\n" +
+	"\x1aGetMigrationStatusResponse\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\x127\n" +
+	"\arunning\x18\x02 \x01(\v2\x1d.oqbridge.v1.RunningMigrationR\arunning\x124\n" +
+	"\blast_run\x18\x03 \x01(\v2\x19.oqbridge.v1.MigrationRunR\alastRun\x12<\n" +
+	"\asources\x18\x04 \x03(\v2\".oqbridge.v1.SourceMigrationStatusR\asources\"g\n" +
+	"\x10RunningMigration\x129\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12\x18\n" +
+	"\atrigger\x18\x02 \x01(\tR\atrigger\"\x9e\x02\n" +
+	"\fMigrationRun\x12\x18\n" +
+	"\aoutcome\x18\x01 \x01(\tR\aoutcome\x129\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12-\n" +
+	"\x12documents_migrated\x18\x04 \x01(\x03R\x11documentsMigrated\x125\n" +
+	"\aindices\x18\x05 \x03(\v2\x1b.oqbridge.v1.IndexRunResultR\aindices\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"\xb3\x01\n" +
+	"\x0eIndexRunResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12-\n" +
+	"\x12documents_migrated\x18\x05 \x01(\x03R\x11documentsMigrated\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"~\n" +
+	"\x15SourceMigrationStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12;\n" +
+	"\aindices\x18\x02 \x03(\v2!.oqbridge.v1.IndexMigrationStatusR\aindices\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xbf\x02\n" +
+	"\x14IndexMigrationStatus\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\x12C\n" +
+	"\x0fmigrated_before\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x0emigratedBefore\x12@\n" +
+	"\n" +
+	"checkpoint\x18\x03 \x01(\v2 .oqbridge.v1.MigrationCheckpointR\n" +
+	"checkpoint\x123\n" +
+	"\blast_run\x18\x04 \x01(\v2\x18.oqbridge.v1.RecordedRunR\alastRun\x12%\n" +
+	"\x0etotal_migrated\x18\x05 \x01(\x03R\rtotalMigrated\x12.\n" +
+	"\x04lock\x18\x06 \x01(\v2\x1a.oqbridge.v1.MigrationLockR\x04lock\"\xdd\x01\n" +
+	"\x13MigrationCheckpoint\x12\x1c\n" +
+	"\tcompleted\x18\x01 \x01(\bR\tcompleted\x12\x1d\n" +
+	"\n" +
+	"total_docs\x18\x02 \x01(\x03R\ttotalDocs\x12\x1a\n" +
+	"\bmigrated\x18\x03 \x01(\x03R\bmigrated\x122\n" +
+	"\x06cutoff\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06cutoff\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xa5\x01\n" +
+	"\vRecordedRun\x129\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12-\n" +
+	"\x12documents_migrated\x18\x03 \x01(\x03R\x11documentsMigrated\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\x9d\x01\n" +
+	"\rMigrationLock\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\x12;\n" +
+	"\vacquired_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"acquiredAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\".\n" +
+	"\x16GetIndexRoutingRequest\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\"\xdb\x02\n" +
+	"\x17GetIndexRoutingResponse\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\x12'\n" +
+	"\x0ftimestamp_field\x18\x02 \x01(\tR\x0etimestampField\x12\x19\n" +
+	"\bhot_days\x18\x03 \x01(\x05R\ahotDays\x12\x1b\n" +
+	"\tcold_days\x18\x04 \x01(\x05R\bcoldDays\x12%\n" +
+	"\x0equickwit_index\x18\x05 \x01(\tR\rquickwitIndex\x12)\n" +
+	"\x10quickwit_cluster\x18\x06 \x01(\tR\x0fquickwitCluster\x12\x1d\n" +
+	"\n" +
+	"dual_write\x18\a \x01(\bR\tdualWrite\x12\x1f\n" +
+	"\vlate_writes\x18\b \x01(\bR\n" +
+	"lateWrites\x127\n" +
+	"\bsegments\x18\t \x03(\v2\x1b.oqbridge.v1.RoutingSegmentR\bsegments\"\x86\x01\n" +
+	"\x0eRoutingSegment\x12\x18\n" +
+	"\abackend\x18\x01 \x01(\tR\abackend\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"D\n" +
+	"\x12RouteSearchRequest\x12\x18\n" +
+	"\aindices\x18\x01 \x03(\tR\aindices\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\fR\x05query\"I\n" +
+	"\x13RouteSearchResponse\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x1a\n" +
+	"\bbackends\x18\x02 \x03(\tR\bbackends2\x93\x03\n" +
+	"\x10MigrationService\x12_\n" +
+	"\x10TriggerMigration\x12$.oqbridge.v1.TriggerMigrationRequest\x1a%.oqbridge.v1.TriggerMigrationResponse\x12Y\n" +
+	"\x0ePauseMigration\x12\".oqbridge.v1.PauseMigrationRequest\x1a#.oqbridge.v1.PauseMigrationResponse\x12\\\n" +
+	"\x0fResumeMigration\x12#.oqbridge.v1.ResumeMigrationRequest\x1a$.oqbridge.v1.ResumeMigrationResponse\x12e\n" +
+	"\x12GetMigrationStatus\x12&.oqbridge.v1.GetMigrationStatusRequest\x1a'.oqbridge.v1.GetMigrationStatusResponse2\xc0\x01\n" +
+	"\x0eRoutingService\x12\\\n" +
+	"\x0fGetIndexRouting\x12#.oqbridge.v1.GetIndexRoutingRequest\x1a$.oqbridge.v1.GetIndexRoutingResponse\x12P\n" +
+	"\vRouteSearch\x12\x1f.oqbridge.v1.RouteSearchRequest\x1a .oqbridge.v1.RouteSearchResponseB9Z7github.com/leonunix/oqbridge/api/oqbridge/v1;oqbridgev1b\x06proto3"
+
+var (
+	file_api_oqbridge_v1_control_proto_rawDescOnce sync.Once
+	file_api_oqbridge_v1_control_proto_rawDescData []byte
+)
+
+func file_api_oqbridge_v1_control_proto_rawDescGZIP() []byte {
+	file_api_oqbridge_v1_control_proto_rawDescOnce.Do(func() {
+		file_api_oqbridge_v1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_oqbridge_v1_control_proto_rawDesc), len(file_api_oqbridge_v1_control_proto_rawDesc)))
+	})
+	return file_api_oqbridge_v1_control_proto_rawDescData
+}
+
+var file_api_oqbridge_v1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_api_oqbridge_v1_control_proto_goTypes = []any{
+	(*TriggerMigrationRequest)(nil),    // 0: oqbridge.v1.TriggerMigrationRequest
+	(*TriggerMigrationResponse)(nil),   // 1: oqbridge.v1.TriggerMigrationResponse
+	(*PauseMigrationRequest)(nil),      // 2: oqbridge.v1.PauseMigrationRequest
+	(*PauseMigrationResponse)(nil),     // 3: oqbridge.v1.PauseMigrationResponse
+	(*ResumeMigrationRequest)(nil),     // 4: oqbridge.v1.ResumeMigrationRequest
+	(*ResumeMigrationResponse)(nil),    // 5: oqbridge.v1.ResumeMigrationResponse
+	(*GetMigrationStatusRequest)(nil),  // 6: oqbridge.v1.GetMigrationStatusRequest
+	(*GetMigrationStatusResponse)(nil), // 7: oqbridge.v1.GetMigrationStatusResponse
+	(*RunningMigration)(nil),           // 8: oqbridge.v1.RunningMigration
+	(*MigrationRun)(nil),               // 9: oqbridge.v1.MigrationRun
+	(*IndexRunResult)(nil),             // 10: oqbridge.v1.IndexRunResult
+	(*SourceMigrationStatus)(nil),      // 11: oqbridge.v1.SourceMigrationStatus
+	(*IndexMigrationStatus)(nil),       // 12: oqbridge.v1.IndexMigrationStatus
+	(*MigrationCheckpoint)(nil),        // 13: oqbridge.v1.MigrationCheckpoint
+	(*RecordedRun)(nil),                // 14: oqbridge.v1.RecordedRun
+	(*MigrationLock)(nil),              // 15: oqbridge.v1.MigrationLock
+	(*GetIndexRoutingRequest)(nil),     // 16: oqbridge.v1.GetIndexRoutingRequest
+	(*GetIndexRoutingResponse)(nil),    // 17: oqbridge.v1.GetIndexRoutingResponse
+	(*RoutingSegment)(nil),             // 18: oqbridge.v1.RoutingSegment
+	(*RouteSearchRequest)(nil),         // 19: oqbridge.v1.RouteSearchRequest
+	(*RouteSearchResponse)(nil),        // 20: oqbridge.v1.RouteSearchResponse
+	(*timestamppb.Timestamp)(nil),      // 21: google.protobuf.Timestamp
+}
+var file_api_oqbridge_v1_control_proto_depIdxs = []int32{
+	21, // 0: oqbridge.v1.TriggerMigrationResponse.started_at:type_name -> google.protobuf.Timestamp
+	8,  // 1: oqbridge.v1.GetMigrationStatusResponse.running:type_name -> oqbridge.v1.RunningMigration
+	9,  // 2: oqbridge.v1.GetMigrationStatusResponse.last_run:type_name -> oqbridge.v1.MigrationRun
+	11, // 3: oqbridge.v1.GetMigrationStatusResponse.sources:type_name -> oqbridge.v1.SourceMigrationStatus
+	21, // 4: oqbridge.v1.RunningMigration.started_at:type_name -> google.protobuf.Timestamp
+	21, // 5: oqbridge.v1.MigrationRun.started_at:type_name -> google.protobuf.Timestamp
+	21, // 6: oqbridge.v1.MigrationRun.completed_at:type_name -> google.protobuf.Timestamp
+	10, // 7: oqbridge.v1.MigrationRun.indices:type_name -> oqbridge.v1.IndexRunResult
+	12, // 8: oqbridge.v1.SourceMigrationStatus.indices:type_name -> oqbridge.v1.IndexMigrationStatus
+	21, // 9: oqbridge.v1.IndexMigrationStatus.migrated_before:type_name -> google.protobuf.Timestamp
+	13, // 10: oqbridge.v1.IndexMigrationStatus.checkpoint:type_name -> oqbridge.v1.MigrationCheckpoint
+	14, // 11: oqbridge.v1.IndexMigrationStatus.last_run:type_name -> oqbridge.v1.RecordedRun
+	15, // 12: oqbridge.v1.IndexMigrationStatus.lock:type_name -> oqbridge.v1.MigrationLock
+	21, // 13: oqbridge.v1.MigrationCheckpoint.cutoff:type_name -> google.protobuf.Timestamp
+	21, // 14: oqbridge.v1.MigrationCheckpoint.updated_at:type_name -> google.protobuf.Timestamp
+	21, // 15: oqbridge.v1.RecordedRun.started_at:type_name -> google.protobuf.Timestamp
+	21, // 16: oqbridge.v1.MigrationLock.acquired_at:type_name -> google.protobuf.Timestamp
+	21, // 17: oqbridge.v1.MigrationLock.expires_at:type_name -> google.protobuf.Timestamp
+	18, // 18: oqbridge.v1.GetIndexRoutingResponse.segments:type_name -> oqbridge.v1.RoutingSegment
+	21, // 19: oqbridge.v1.RoutingSegment.from:type_name -> google.protobuf.Timestamp
+	21, // 20: oqbridge.v1.RoutingSegment.to:type_name -> google.protobuf.Timestamp
+	0,  // 21: oqbridge.v1.MigrationService.TriggerMigration:input_type -> oqbridge.v1.TriggerMigrationRequest
+	2,  // 22: oqbridge.v1.MigrationService.PauseMigration:input_type -> oqbridge.v1.PauseMigrationRequest
+	4,  // 23: oqbridge.v1.MigrationService.ResumeMigration:input_type -> oqbridge.v1.ResumeMigrationRequest
+	6,  // 24: oqbridge.v1.MigrationService.GetMigrationStatus:input_type -> oqbridge.v1.GetMigrationStatusRequest
+	16, // 25: oqbridge.v1.RoutingService.GetIndexRouting:input_type -> oqbridge.v1.GetIndexRoutingRequest
+	19, // 26: oqbridge.v1.RoutingService.RouteSearch:input_type -> oqbridge.v1.RouteSearchRequest
+	1,  // 27: oqbridge.v1.MigrationService.TriggerMigration:output_type -> oqbridge.v1.TriggerMigrationResponse
+	3,  // 28: oqbridge.v1.MigrationService.PauseMigration:output_type -> oqbridge.v1.PauseMigrationResponse
+	5,  // 29: oqbridge.v1.MigrationService.ResumeMigration:output_type -> oqbridge.v1.ResumeMigrationResponse
+	7,  // 30: oqbridge.v1.MigrationService.GetMigrationStatus:output_type -> oqbridge.v1.GetMigrationStatusResponse
+	17, // 31: oqbridge.v1.RoutingService.GetIndexRouting:output_type -> oqbridge.v1.GetIndexRoutingResponse
+	20, // 32: oqbridge.v1.RoutingService.RouteSearch:output_type -> oqbridge.v1.RouteSearchResponse
+	27, // [27:33] is the sub-list for method output_type
+	21, // [21:27] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_api_oqbridge_v1_control_proto_init() }
+func file_api_oqbridge_v1_control_proto_init() {
+	if File_api_oqbridge_v1_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_oqbridge_v1_control_proto_rawDesc), len(file_api_oqbridge_v1_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_api_oqbridge_v1_control_proto_goTypes,
+		DependencyIndexes: file_api_oqbridge_v1_control_proto_depIdxs,
+		MessageInfos:      file_api_oqbridge_v1_control_proto_msgTypes,
+	}.Build()
+	File_api_oqbridge_v1_control_proto = out.File
+	file_api_oqbridge_v1_control_proto_goTypes = nil
+	file_api_oqbridge_v1_control_proto_depIdxs = nil
+}
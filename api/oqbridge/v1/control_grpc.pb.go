@@ -0,0 +1,424 @@
+// Control-plane API of oqbridge. The migration daemon (oqbridge-migrate)
+// serves MigrationService on migration.grpc_listen, the proxy (oqbridge)
+// serves RoutingService on server.grpc_listen. Both also serve the standard
+// grpc.health.v1.Health service.
+//
+// Regenerate the Go code with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	  api/oqbridge/v1/control.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: api/oqbridge/v1/control.proto
+
+package oqbridgev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MigrationService_TriggerMigration_FullMethodName   = "/oqbridge.v1.MigrationService/TriggerMigration"
+	MigrationService_PauseMigration_FullMethodName     = "/oqbridge.v1.MigrationService/PauseMigration"
+	MigrationService_ResumeMigration_FullMethodName    = "/oqbridge.v1.MigrationService/ResumeMigration"
+	MigrationService_GetMigrationStatus_FullMethodName = "/oqbridge.v1.MigrationService/GetMigrationStatus"
+)
+
+// MigrationServiceClient is the client API for MigrationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MigrationService starts, pauses and inspects the migration runs of one
+// oqbridge-migrate daemon.
+type MigrationServiceClient interface {
+	// TriggerMigration starts a run of every source now, like the schedule
+	// does, and returns once it started. It fails with FAILED_PRECONDITION
+	// while migration is paused or a run is in progress.
+	TriggerMigration(ctx context.Context, in *TriggerMigrationRequest, opts ...grpc.CallOption) (*TriggerMigrationResponse, error)
+	// PauseMigration makes the daemon skip scheduled and triggered runs until
+	// ResumeMigration. The pause is not persisted across restarts.
+	PauseMigration(ctx context.Context, in *PauseMigrationRequest, opts ...grpc.CallOption) (*PauseMigrationResponse, error)
+	// ResumeMigration lifts a pause. Runs skipped while paused are not
+	// caught up; the next scheduled run migrates what they would have.
+	ResumeMigration(ctx context.Context, in *ResumeMigrationRequest, opts ...grpc.CallOption) (*ResumeMigrationResponse, error)
+	// GetMigrationStatus returns the run state of the daemon and the stored
+	// migration state of every index, as "oqbridge-migrate status" prints.
+	GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error)
+}
+
+type migrationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMigrationServiceClient(cc grpc.ClientConnInterface) MigrationServiceClient {
+	return &migrationServiceClient{cc}
+}
+
+func (c *migrationServiceClient) TriggerMigration(ctx context.Context, in *TriggerMigrationRequest, opts ...grpc.CallOption) (*TriggerMigrationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerMigrationResponse)
+	err := c.cc.Invoke(ctx, MigrationService_TriggerMigration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *migrationServiceClient) PauseMigration(ctx context.Context, in *PauseMigrationRequest, opts ...grpc.CallOption) (*PauseMigrationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PauseMigrationResponse)
+	err := c.cc.Invoke(ctx, MigrationService_PauseMigration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *migrationServiceClient) ResumeMigration(ctx context.Context, in *ResumeMigrationRequest, opts ...grpc.CallOption) (*ResumeMigrationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeMigrationResponse)
+	err := c.cc.Invoke(ctx, MigrationService_ResumeMigration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *migrationServiceClient) GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMigrationStatusResponse)
+	err := c.cc.Invoke(ctx, MigrationService_GetMigrationStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MigrationServiceServer is the server API for MigrationService service.
+// All implementations must embed UnimplementedMigrationServiceServer
+// for forward compatibility.
+//
+// MigrationService starts, pauses and inspects the migration runs of one
+// oqbridge-migrate daemon.
+type MigrationServiceServer interface {
+	// TriggerMigration starts a run of every source now, like the schedule
+	// does, and returns once it started. It fails with FAILED_PRECONDITION
+	// while migration is paused or a run is in progress.
+	TriggerMigration(context.Context, *TriggerMigrationRequest) (*TriggerMigrationResponse, error)
+	// PauseMigration makes the daemon skip scheduled and triggered runs until
+	// ResumeMigration. The pause is not persisted across restarts.
+	PauseMigration(context.Context, *PauseMigrationRequest) (*PauseMigrationResponse, error)
+	// ResumeMigration lifts a pause. Runs skipped while paused are not
+	// caught up; the next scheduled run migrates what they would have.
+	ResumeMigration(context.Context, *ResumeMigrationRequest) (*ResumeMigrationResponse, error)
+	// GetMigrationStatus returns the run state of the daemon and the stored
+	// migration state of every index, as "oqbridge-migrate status" prints.
+	GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error)
+	mustEmbedUnimplementedMigrationServiceServer()
+}
+
+// UnimplementedMigrationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMigrationServiceServer struct{}
+
+func (UnimplementedMigrationServiceServer) TriggerMigration(context.Context, *TriggerMigrationRequest) (*TriggerMigrationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerMigration not implemented")
+}
+func (UnimplementedMigrationServiceServer) PauseMigration(context.Context, *PauseMigrationRequest) (*PauseMigrationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseMigration not implemented")
+}
+func (UnimplementedMigrationServiceServer) ResumeMigration(context.Context, *ResumeMigrationRequest) (*ResumeMigrationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeMigration not implemented")
+}
+func (UnimplementedMigrationServiceServer) GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMigrationStatus not implemented")
+}
+func (UnimplementedMigrationServiceServer) mustEmbedUnimplementedMigrationServiceServer() {}
+func (UnimplementedMigrationServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeMigrationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MigrationServiceServer will
+// result in compilation errors.
+type UnsafeMigrationServiceServer interface {
+	mustEmbedUnimplementedMigrationServiceServer()
+}
+
+func RegisterMigrationServiceServer(s grpc.ServiceRegistrar, srv MigrationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMigrationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MigrationService_ServiceDesc, srv)
+}
+
+func _MigrationService_TriggerMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerMigrationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).TriggerMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_TriggerMigration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).TriggerMigration(ctx, req.(*TriggerMigrationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MigrationService_PauseMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseMigrationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).PauseMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_PauseMigration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).PauseMigration(ctx, req.(*PauseMigrationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MigrationService_ResumeMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeMigrationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).ResumeMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_ResumeMigration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).ResumeMigration(ctx, req.(*ResumeMigrationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MigrationService_GetMigrationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMigrationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).GetMigrationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_GetMigrationStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).GetMigrationStatus(ctx, req.(*GetMigrationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MigrationService_ServiceDesc is the grpc.ServiceDesc for MigrationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MigrationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "oqbridge.v1.MigrationService",
+	HandlerType: (*MigrationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TriggerMigration",
+			Handler:    _MigrationService_TriggerMigration_Handler,
+		},
+		{
+			MethodName: "PauseMigration",
+			Handler:    _MigrationService_PauseMigration_Handler,
+		},
+		{
+			MethodName: "ResumeMigration",
+			Handler:    _MigrationService_ResumeMigration_Handler,
+		},
+		{
+			MethodName: "GetMigrationStatus",
+			Handler:    _MigrationService_GetMigrationStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/oqbridge/v1/control.proto",
+}
+
+const (
+	RoutingService_GetIndexRouting_FullMethodName = "/oqbridge.v1.RoutingService/GetIndexRouting"
+	RoutingService_RouteSearch_FullMethodName     = "/oqbridge.v1.RoutingService/RouteSearch"
+)
+
+// RoutingServiceClient is the client API for RoutingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RoutingService answers where the proxy keeps and searches the data of
+// indices under its current configuration.
+type RoutingServiceClient interface {
+	// GetIndexRouting returns the retention settings that apply to an index
+	// and the time ranges each backend holds.
+	GetIndexRouting(ctx context.Context, in *GetIndexRoutingRequest, opts ...grpc.CallOption) (*GetIndexRoutingResponse, error)
+	// RouteSearch returns the backends a search of indices with the given
+	// query would be sent to.
+	RouteSearch(ctx context.Context, in *RouteSearchRequest, opts ...grpc.CallOption) (*RouteSearchResponse, error)
+}
+
+type routingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRoutingServiceClient(cc grpc.ClientConnInterface) RoutingServiceClient {
+	return &routingServiceClient{cc}
+}
+
+func (c *routingServiceClient) GetIndexRouting(ctx context.Context, in *GetIndexRoutingRequest, opts ...grpc.CallOption) (*GetIndexRoutingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetIndexRoutingResponse)
+	err := c.cc.Invoke(ctx, RoutingService_GetIndexRouting_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) RouteSearch(ctx context.Context, in *RouteSearchRequest, opts ...grpc.CallOption) (*RouteSearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RouteSearchResponse)
+	err := c.cc.Invoke(ctx, RoutingService_RouteSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoutingServiceServer is the server API for RoutingService service.
+// All implementations must embed UnimplementedRoutingServiceServer
+// for forward compatibility.
+//
+// RoutingService answers where the proxy keeps and searches the data of
+// indices under its current configuration.
+type RoutingServiceServer interface {
+	// GetIndexRouting returns the retention settings that apply to an index
+	// and the time ranges each backend holds.
+	GetIndexRouting(context.Context, *GetIndexRoutingRequest) (*GetIndexRoutingResponse, error)
+	// RouteSearch returns the backends a search of indices with the given
+	// query would be sent to.
+	RouteSearch(context.Context, *RouteSearchRequest) (*RouteSearchResponse, error)
+	mustEmbedUnimplementedRoutingServiceServer()
+}
+
+// UnimplementedRoutingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRoutingServiceServer struct{}
+
+func (UnimplementedRoutingServiceServer) GetIndexRouting(context.Context, *GetIndexRoutingRequest) (*GetIndexRoutingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIndexRouting not implemented")
+}
+func (UnimplementedRoutingServiceServer) RouteSearch(context.Context, *RouteSearchRequest) (*RouteSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RouteSearch not implemented")
+}
+func (UnimplementedRoutingServiceServer) mustEmbedUnimplementedRoutingServiceServer() {}
+func (UnimplementedRoutingServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRoutingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RoutingServiceServer will
+// result in compilation errors.
+type UnsafeRoutingServiceServer interface {
+	mustEmbedUnimplementedRoutingServiceServer()
+}
+
+func RegisterRoutingServiceServer(s grpc.ServiceRegistrar, srv RoutingServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRoutingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RoutingService_ServiceDesc, srv)
+}
+
+func _RoutingService_GetIndexRouting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIndexRoutingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).GetIndexRouting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoutingService_GetIndexRouting_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).GetIndexRouting(ctx, req.(*GetIndexRoutingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_RouteSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouteSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).RouteSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoutingService_RouteSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).RouteSearch(ctx, req.(*RouteSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RoutingService_ServiceDesc is the grpc.ServiceDesc for RoutingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RoutingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "oqbridge.v1.RoutingService",
+	HandlerType: (*RoutingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetIndexRouting",
+			Handler:    _RoutingService_GetIndexRouting_Handler,
+		},
+		{
+			MethodName: "RouteSearch",
+			Handler:    _RoutingService_RouteSearch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/oqbridge/v1/control.proto",
+}
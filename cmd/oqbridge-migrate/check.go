@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/preflight"
+)
+
+// runCheck implements "oqbridge-migrate check": validate the configuration,
+// parse the cron schedules and probe Vault and both backends, including every
+// migration source. It exits 1 if any check failed.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	configPath := fs.String("config", "oqbridge.yaml", "path to configuration file (.yaml, .json or .toml); empty to configure from environment variables only")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	return check(*configPath, nil, *asJSON)
+}
+
+func check(configPath string, overrides config.Overrides, asJSON bool) int {
+	report := preflight.Run(context.Background(), configPath, preflight.Options{Migration: true, UserAgent: userAgent(), Overrides: overrides})
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
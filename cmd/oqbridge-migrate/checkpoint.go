@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+const checkpointUsage = `usage: oqbridge-migrate checkpoint <action> [flags]
+
+actions:
+  list                          list checkpoints and watermarks for all indices
+  show <index>                  print the stored state of one index as JSON
+  reset [-watermark] <index>    delete the checkpoint (and optionally the watermark)
+`
+
+// runCheckpoint implements "oqbridge-migrate checkpoint list|show|reset".
+func runCheckpoint(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, checkpointUsage)
+		return 1
+	}
+	action, args := args[0], args[1:]
+
+	fs, common := newFlagSet("checkpoint " + action)
+	resetWatermark := fs.Bool("watermark", false, "reset: also delete the watermark, re-migrating all data older than the cutoff")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return fail("creating OpenSearch HTTP client: %v", err)
+	}
+	store, err := newCheckpointStore(cfg, osClient)
+	if err != nil {
+		return fail("opening checkpoint store: %v", err)
+	}
+	admin, ok := store.(migration.CheckpointAdmin)
+	if !ok {
+		return fail("checkpoint store %T does not support administration", store)
+	}
+
+	switch action {
+	case "list":
+		states, err := admin.List()
+		if err != nil {
+			return fail("listing checkpoints: %v", err)
+		}
+		printCheckpointTable(states)
+		return 0
+
+	case "show":
+		if fs.NArg() != 1 {
+			return fail("checkpoint show requires exactly one index")
+		}
+		st, err := admin.State(fs.Arg(0))
+		if err != nil {
+			return fail("reading state: %v", err)
+		}
+		if st == nil {
+			return fail("no checkpoint or watermark stored for %s", fs.Arg(0))
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(st)
+		return 0
+
+	case "reset":
+		if fs.NArg() != 1 {
+			return fail("checkpoint reset requires exactly one index")
+		}
+		index := fs.Arg(0)
+		if err := admin.Reset(index, *resetWatermark); err != nil {
+			return fail("resetting %s: %v", index, err)
+		}
+		if *resetWatermark {
+			fmt.Printf("reset checkpoint and watermark for %s\n", index)
+		} else {
+			fmt.Printf("reset checkpoint for %s\n", index)
+		}
+		return 0
+
+	default:
+		fmt.Fprint(os.Stderr, checkpointUsage)
+		return 1
+	}
+}
+
+func printCheckpointTable(states []migration.IndexState) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tWATERMARK\tCHECKPOINT\tSLICES DONE\tMIGRATED\tUPDATED")
+	for _, st := range states {
+		watermark, status, slices, migrated, updated := "-", "-", "-", "-", "-"
+		if st.Watermark != nil {
+			watermark = st.Watermark.MigratedBefore.Format(time.RFC3339)
+			updated = st.Watermark.UpdatedAt.Format(time.RFC3339)
+		}
+		if cp := st.Checkpoint; cp != nil {
+			status = "in progress"
+			if cp.Completed {
+				status = "completed"
+			}
+			slices = fmt.Sprint(len(cp.SlicesDone))
+			migrated = fmt.Sprint(cp.Migrated)
+			if st.Watermark == nil || cp.UpdatedAt.After(st.Watermark.UpdatedAt) {
+				updated = cp.UpdatedAt.Format(time.RFC3339)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", st.Index, watermark, status, slices, migrated, updated)
+	}
+	w.Flush()
+}
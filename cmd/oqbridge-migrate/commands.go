@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+)
+
+// subcommands are administrative commands run as
+// "oqbridge-migrate <command> [action] [flags]". Each returns the exit code.
+var subcommands = map[string]func(args []string) int{
+	"check":          runCheck,
+	"checkpoint":     runCheckpoint,
+	"export":         runExport,
+	"lock":           runLock,
+	"print-defaults": runPrintDefaults,
+	"retention":      runRetention,
+	"secret":         runSecret,
+	"state":          runState,
+	"status":         runStatus,
+	"verify":         runVerify,
+}
+
+// loadCommandConfig loads the configuration for an administrative command,
+// including credentials from Vault if configured. Commands are short-lived,
+// so the credentials are not refreshed. Logs go to stderr so stdout carries
+// only the command output. The -source flag selects the migration.sources
+// entry the command operates on.
+func loadCommandConfig(flags commonFlags) (*config.Config, error) {
+	cfg, err := config.Load(*flags.configPath)
+	if err != nil {
+		return nil, err
+	}
+	util.SetupLoggerOutput(cfg.Logging.Level, os.Stderr)
+	cfg.SetDefaultUserAgent(userAgent())
+	if _, err := vault.Load(context.Background(), cfg); err != nil {
+		return nil, err
+	}
+	source := *flags.source
+	if source == "" {
+		source = cfg.SourceNames()[0]
+	}
+	return cfg.ForSource(source)
+}
+
+// newCheckpointStore returns the configured checkpoint store: a local
+// directory when migration.checkpoint_dir is set, OpenSearch otherwise.
+func newCheckpointStore(cfg *config.Config, osClient *http.Client) (migration.CheckpointStore, error) {
+	if cfg.Migration.CheckpointDir != "" {
+		return migration.NewLocalCheckpointStore(cfg.Migration.CheckpointDir)
+	}
+	return migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient), nil
+}
+
+// commonFlags are the flags shared by the administrative commands.
+type commonFlags struct {
+	configPath *string
+	source     *string
+}
+
+// newColdBackend creates a Quickwit backend for quickwit and for each
+// quickwit_clusters entry, prepared by setup if non-nil, and routes indices
+// between them. The quickwit backend is returned too, for Vault.
+func newColdBackend(cfg *config.Config, compress bool, setup func(*backend.Quickwit, config.QuickwitConfig)) (*backend.QuickwitRouter, *backend.Quickwit, error) {
+	newQuickwit := func(key string, qc config.QuickwitConfig) (*backend.Quickwit, error) {
+		client, err := util.NewQuickwitClient(qc)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s HTTP client: %w", key, err)
+		}
+		q := backend.NewQuickwit(qc.URL, qc.Username, qc.Password, compress, client)
+		q.SetAuth(qc.Auth.BearerToken, qc.Auth.Headers)
+		if setup != nil {
+			setup(q, qc)
+		}
+		return q, nil
+	}
+	def, err := newQuickwit("Quickwit", cfg.Quickwit)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusters := make(map[string]*backend.Quickwit, len(cfg.QuickwitClusters))
+	for _, c := range cfg.QuickwitClusters {
+		if clusters[c.Name], err = newQuickwit("Quickwit cluster "+c.Name, c.Quickwit); err != nil {
+			return nil, nil, err
+		}
+	}
+	return backend.NewQuickwitRouter(def, clusters, cfg.QuickwitClusterForIndex), def, nil
+}
+
+// newFlagSet creates a flag set for "<command> <action>" with the shared
+// -config and -source flags.
+func newFlagSet(name string) (*flag.FlagSet, commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return fs, commonFlags{
+		configPath: fs.String("config", "oqbridge.yaml", "path to configuration file (.yaml, .json or .toml); empty to configure from environment variables only"),
+		source:     fs.String("source", "", "migration.sources entry to operate on (default: the first configured source)"),
+	}
+}
+
+// fail prints an error for an administrative command and returns exit code 1.
+func fail(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	return 1
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/control"
+	"github.com/leonunix/oqbridge/internal/dashboard"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+)
+
+// sourceReader reads the stored migration state of one source for the
+// dashboard and the gRPC API.
+type sourceReader struct {
+	name    string
+	states  migration.CheckpointAdmin
+	metrics *migration.OpenSearchMetricsStore
+	locks   *backend.OpenSearchLock
+}
+
+// newSourceReaders returns a sourceReader for every source. Like
+// newSourceMigrator, they follow Vault credentials for the opensearch
+// cluster only.
+func newSourceReaders(cfg *config.Config, secrets *vault.Source) ([]sourceReader, error) {
+	var readers []sourceReader
+	for _, name := range cfg.SourceNames() {
+		scfg, err := cfg.ForSource(name)
+		if err != nil {
+			return nil, err
+		}
+		osClient, err := util.NewOpenSearchClient(scfg.OpenSearch)
+		if err != nil {
+			return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+		}
+		store, err := newCheckpointStore(scfg, osClient)
+		if err != nil {
+			return nil, fmt.Errorf("opening checkpoint store: %w", err)
+		}
+		admin, ok := store.(migration.CheckpointAdmin)
+		if !ok {
+			return nil, fmt.Errorf("checkpoint store %T does not support listing", store)
+		}
+		r := sourceReader{
+			name:    name,
+			states:  admin,
+			metrics: migration.NewOpenSearchMetricsStore(scfg.OpenSearch.URL, scfg.OpenSearch.Username, scfg.OpenSearch.Password, osClient),
+			locks:   backend.NewOpenSearchLock(scfg.OpenSearch.URL, scfg.OpenSearch.Username, scfg.OpenSearch.Password, osClient),
+		}
+		if secrets != nil && scfg.Source() == "" {
+			secrets.ShareOpenSearch(r.metrics, r.locks)
+			if s, ok := store.(vault.CredentialSetter); ok {
+				secrets.ShareOpenSearch(s)
+			}
+		}
+		readers = append(readers, r)
+	}
+	return readers, nil
+}
+
+// read returns the state of the source's indices.
+func (r sourceReader) read(ctx context.Context) ([]migration.IndexStatus, error) {
+	return migration.ReadStatus(ctx, r.states, r.metrics, r.locks)
+}
+
+// newDashboardStatus returns the status the migrate daemon shows on the
+// dashboard: the state and recent runs of the indices of every source, and
+// where their data lives according to the current configuration.
+func newDashboardStatus(readers []sourceReader, current func() *config.Config) func(ctx context.Context) *dashboard.Status {
+	return func(ctx context.Context) *dashboard.Status {
+		cfg := current()
+		st := &dashboard.Status{Service: "oqbridge-migrate"}
+		indices := make(map[string]bool)
+		for _, r := range readers {
+			rows, err := r.read(ctx)
+			if err != nil {
+				st.Errors = append(st.Errors, fmt.Sprintf("source %s: %v", r.name, err))
+				continue
+			}
+			st.Sources = append(st.Sources, dashboard.SourceStatus{Name: r.name, Indices: rows})
+			for _, row := range rows {
+				indices[row.Index] = true
+			}
+			runs, err := r.metrics.Recent(ctx, cfg.Dashboard.History)
+			if err != nil {
+				st.Errors = append(st.Errors, fmt.Sprintf("source %s: reading migration history: %v", r.name, err))
+			}
+			st.History = append(st.History, runs...)
+		}
+		sort.Slice(st.History, func(i, j int) bool { return st.History[i].StartedAt.After(st.History[j].StartedAt) })
+		st.History = st.History[:min(len(st.History), cfg.Dashboard.History)]
+
+		now := time.Now()
+		for index := range indices {
+			st.Layout = append(st.Layout, dashboard.Layout(cfg, index, now))
+		}
+		sort.Slice(st.Layout, func(i, j int) bool { return st.Layout[i].Index < st.Layout[j].Index })
+		return st
+	}
+}
+
+// sourceStates returns the state of every source for the gRPC API.
+func sourceStates(readers []sourceReader) func(ctx context.Context) []control.SourceState {
+	return func(ctx context.Context) []control.SourceState {
+		states := make([]control.SourceState, 0, len(readers))
+		for _, r := range readers {
+			rows, err := r.read(ctx)
+			states = append(states, control.SourceState{Name: r.name, Indices: rows, Err: err})
+		}
+		return states
+	}
+}
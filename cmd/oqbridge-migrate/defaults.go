@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// runPrintDefaults implements "oqbridge-migrate print-defaults": print every
+// configuration key with its default value and description, as a starting
+// point for a configuration file.
+func runPrintDefaults(args []string) int {
+	fs := flag.NewFlagSet("print-defaults", flag.ExitOnError)
+	fs.Parse(args)
+	if err := config.WriteDefaults(os.Stdout); err != nil {
+		return fail("%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/export"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// runExport implements "oqbridge-migrate export".
+func runExport(args []string) int {
+	fs, common := newFlagSet("export")
+	var indices stringList
+	fs.Var(&indices, "index", "export this Quickwit index (repeatable)")
+	pattern := fs.String("pattern", "", "export the Quickwit indices matching this pattern")
+	fromFlag := fs.String("from", "", "first day to export (RFC3339, YYYY-MM-DD or now-400d); required")
+	toFlag := fs.String("to", "", "day after the last one to export; default today, so only whole days are exported")
+	asJSON := fs.Bool("json", false, "print the manifests as JSON")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if len(indices) == 0 && *pattern == "" {
+		return fail("no indices to export: pass -index or -pattern")
+	}
+	if *fromFlag == "" {
+		return fail("-from is required")
+	}
+	from, err := util.ParseTimeExpression(*fromFlag)
+	if err != nil {
+		return fail("-from: %v", err)
+	}
+	to := time.Now()
+	if *toFlag != "" {
+		if to, err = util.ParseTimeExpression(*toFlag); err != nil {
+			return fail("-to: %v", err)
+		}
+	}
+	if !cfg.DaysAgo(from, 0).Before(cfg.DaysAgo(to, 0)) {
+		return fail("-from must be at least a day before -to")
+	}
+
+	cold, _, err := newColdBackend(cfg, false, nil)
+	if err != nil {
+		return fail("%v", err)
+	}
+	store, err := export.NewS3FromConfig(cfg.Export)
+	if err != nil {
+		return fail("%v", err)
+	}
+	ctx := context.Background()
+	if *pattern != "" {
+		all, err := cold.ListIndices(ctx)
+		if err != nil {
+			return fail("listing quickwit indices: %v", err)
+		}
+		for _, index := range all {
+			if util.MatchWildcard(*pattern, index) {
+				indices = append(indices, index)
+			}
+		}
+	}
+
+	exporter := export.NewExporter(cfg, cold, store)
+	var manifests []*export.Manifest
+	code := 0
+	for _, index := range indices {
+		m, err := exporter.Export(ctx, index, from, to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", index, err)
+			code = 1
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(manifests)
+		return code
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tFROM\tTO\tDOCS\tFILES\tSIZE")
+	for _, m := range manifests {
+		var size int64
+		for _, f := range m.Files {
+			size += f.SizeBytes
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%.1f MiB\n", m.Index, m.From.Format(time.DateOnly), m.To.Format(time.DateOnly),
+			m.Documents, len(m.Files), float64(size)/(1<<20))
+	}
+	w.Flush()
+	return code
+}
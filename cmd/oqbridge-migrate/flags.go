@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// window is an explicit migration window from --from/--to.
+type window struct {
+	from, to time.Time
+}
+
+// parseWindow parses the --from/--to flags. It returns nil when neither is
+// set. A missing --to defaults to the regular cutoff (now minus
+// migrateAfterDays); a missing --from leaves the window unbounded below.
+// Plain dates start at midnight in loc (retention.timezone).
+func parseWindow(from, to string, migrateAfterDays int, loc *time.Location) (*window, error) {
+	if from == "" && to == "" {
+		return nil, nil
+	}
+	w := &window{to: time.Now().UTC().AddDate(0, 0, -migrateAfterDays)}
+	var err error
+	if from != "" {
+		if w.from, err = util.ParseTimeExpressionIn(from, loc); err != nil {
+			return nil, fmt.Errorf("--from: %w", err)
+		}
+	}
+	if to != "" {
+		if w.to, err = util.ParseTimeExpressionIn(to, loc); err != nil {
+			return nil, fmt.Errorf("--to: %w", err)
+		}
+	}
+	if !w.from.IsZero() && !w.from.Before(w.to) {
+		return nil, fmt.Errorf("--from (%s) must be before --to (%s)", w.from.Format(time.RFC3339), w.to.Format(time.RFC3339))
+	}
+	return w, nil
+}
@@ -2,32 +2,114 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
 	"github.com/leonunix/oqbridge/internal/backend"
 	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/control"
+	"github.com/leonunix/oqbridge/internal/dashboard"
 	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/notify"
 	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
 
 	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc"
 )
 
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+// userAgent identifies oqbridge-migrate in backend request logs.
+func userAgent() string {
+	return "oqbridge-migrate/" + version
+}
+
+// Exit codes for --once runs. 1 is reserved for startup errors and 2 for
+// flag parsing errors.
+const (
+	exitSuccess        = 0
+	exitNothingToDo    = 3
+	exitPartialFailure = 4
+	exitAllFailed      = 5
+)
+
+// exitCode maps a run outcome to the process exit code.
+func exitCode(outcome string) int {
+	switch outcome {
+	case migration.OutcomeNothingToDo:
+		return exitNothingToDo
+	case migration.OutcomePartialFailure:
+		return exitPartialFailure
+	case migration.OutcomeFailed:
+		return exitAllFailed
+	default:
+		return exitSuccess
+	}
+}
+
 func main() {
-	configPath := flag.String("config", "oqbridge.yaml", "path to configuration file")
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
+	configPath := flag.String("config", "oqbridge.yaml", "path to configuration file (.yaml, .json or .toml); empty to configure from environment variables only")
 	once := flag.Bool("once", false, "run migration once and exit (ignore schedule)")
+	var indices stringList
+	flag.Var(&indices, "index", "migrate this index instead of migration.indices (repeatable)")
+	pattern := flag.String("pattern", "", "migrate indices matching this pattern instead of migration.indices")
+	fromFlag := flag.String("from", "", "with --once, migrate from this time (RFC3339, YYYY-MM-DD or now-30d), ignoring the watermark")
+	toFlag := flag.String("to", "", "with --once, migrate up to this time (exclusive), ignoring migrate_after_days")
+	validateConfig := flag.Bool("validate-config", false, "validate the configuration, probe both backends and exit (same as the check command)")
+	overrides := config.Overrides{}
+	overrides.Flag(flag.CommandLine, "profile", "profile", "entry of profiles to apply, e.g. prod")
+	overrides.Flag(flag.CommandLine, "retention-days", "retention.days", "days of data kept in OpenSearch (e.g. 30 or 30d)")
+	overrides.Flag(flag.CommandLine, "workers", "migration.workers", "parallel sliced scroll workers per index")
+	overrides.Flag(flag.CommandLine, "schedule", "migration.schedule", "cron schedule of migration runs")
+	overrides.Flag(flag.CommandLine, "listen", "migration.metrics_listen", "address serving Prometheus metrics at /metrics")
 	flag.Parse()
 
-	cfg, err := config.Load(*configPath)
+	if *validateConfig {
+		os.Exit(check(*configPath, overrides, false))
+	}
+
+	cfg, err := config.LoadWithOverrides(*configPath, overrides)
 	if err != nil {
 		slog.Error("failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	watcher := config.NewWatcher(*configPath, cfg)
+	cfg.SetDefaultUserAgent(userAgent())
+
+	if *once {
+		// Keep stdout for the final JSON run summary.
+		util.SetupLoggerOutput(cfg.Logging.Level, os.Stderr)
+	} else {
+		util.SetupLogger(cfg.Logging.Level)
+	}
 
-	util.SetupLogger(cfg.Logging.Level)
+	// Command-line selections replace the configured indices for this
+	// invocation, including after a configuration reload.
+	if len(indices) > 0 || *pattern != "" {
+		override := append([]string(nil), indices...)
+		if *pattern != "" {
+			override = append(override, *pattern)
+		}
+		slog.Info("overriding migration.indices from command line", "configured", cfg.Migration.Indices, "indices", override)
+		overrideIndices(cfg, override)
+		watcher.OnReload(func(cfg *config.Config) { overrideIndices(cfg, override) })
+	}
 
 	slog.Info("oqbridge-migrate starting",
 		"opensearch", cfg.OpenSearch.URL,
@@ -37,54 +119,157 @@ func main() {
 		"batch_size", cfg.Migration.BatchSize,
 		"compress", cfg.Migration.Compress,
 		"indices", cfg.Migration.Indices,
+		"sources", len(cfg.Migration.Sources),
+		"profile", cfg.Profile,
 	)
 
-	osClient, err := util.NewHTTPClient(cfg.OpenSearch.TLSConfig)
+	secrets, err := vault.Load(context.Background(), cfg)
 	if err != nil {
-		slog.Error("failed to create OpenSearch HTTP client", "error", err)
+		slog.Error("failed to load credentials from vault", "error", err)
 		os.Exit(1)
 	}
-	qwClient, err := util.NewHTTPClient(cfg.Quickwit.TLSConfig)
+
+	// Migrate by the retention changed through the proxy's
+	// _oqbridge/retention, switching together with the proxies.
+	var retentionSync *migration.RetentionSync
+	if cfg.Retention.Runtime.Enabled {
+		osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+		if err != nil {
+			slog.Error("failed to create OpenSearch HTTP client", "error", err)
+			os.Exit(1)
+		}
+		store := migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+		secrets.ShareOpenSearch(store)
+		retentionSync = migration.NewRetentionSync(store, cfg.Retention.Runtime, watcher)
+		if err := retentionSync.Sync(context.Background()); err != nil {
+			slog.Warn("failed to load the runtime retention, starting with the configured one", "error", err)
+		}
+		cfg = watcher.Config()
+	}
+
+	window, err := parseWindow(*fromFlag, *toFlag, cfg.Migration.MigrateAfterDays, cfg.Location())
 	if err != nil {
-		slog.Error("failed to create Quickwit HTTP client", "error", err)
+		slog.Error("invalid migration window", "error", err)
+		os.Exit(1)
+	}
+	if window != nil && !*once {
+		slog.Error("--from and --to require --once")
 		os.Exit(1)
 	}
 
-	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
-	cold := backend.NewQuickwit(cfg.Quickwit.URL, cfg.Quickwit.Username, cfg.Quickwit.Password, cfg.Migration.Compress, qwClient)
+	indexSettings := func(index string) backend.IndexSettings {
+		s := watcher.Config().QuickwitIndexSettingsForIndex(index)
+		return backend.IndexSettings{
+			CommitTimeoutSecs:  s.CommitTimeoutSecs,
+			SplitNumDocsTarget: s.SplitNumDocsTarget,
+			MergePolicy: backend.MergePolicy{
+				Type:             s.MergePolicy.Type,
+				MergeFactor:      s.MergePolicy.MergeFactor,
+				MaxMergeFactor:   s.MergePolicy.MaxMergeFactor,
+				MaturationPeriod: s.MergePolicy.MaturationPeriod,
+			},
+			DefaultSearchFields: s.DefaultSearchFields,
+		}
+	}
+	ingestOptions := func(index string) backend.IngestOptions {
+		s := watcher.Config().MigrationSettingsForIndex(index)
+		return backend.IngestOptions{Compress: s.Compress, TempDir: s.TempDir}
+	}
+	cold, defaultCold, err := newColdBackend(cfg, cfg.Migration.Compress, func(q *backend.Quickwit, qc config.QuickwitConfig) {
+		q.SetIngestMode(qc.IngestAPI, qc.IngestCommit)
+		q.SetTempDir(cfg.Migration.TempDir)
+		q.SetIngestOptions(ingestOptions)
+		q.SetIndexSettings(indexSettings)
+	})
+	if err != nil {
+		slog.Error("failed to create Quickwit backend", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Quickwit.IngestAPI != "v1" || cfg.Quickwit.IngestCommit != "auto" {
+		slog.Info("quickwit ingest mode", "api", cfg.Quickwit.IngestAPI, "commit", cfg.Quickwit.IngestCommit)
+	}
 	if cfg.Migration.TempDir != "" {
-		cold.SetTempDir(cfg.Migration.TempDir)
 		slog.Info("migration staging via disk", "temp_dir", cfg.Migration.TempDir)
 	}
+	for _, c := range cfg.QuickwitClusters {
+		slog.Info("quickwit cluster", "name", c.Name, "url", c.Quickwit.URL, "indices", c.Indices)
+	}
+	if len(cfg.Migration.IndexOverrides) > 0 {
+		slog.Info("per-index migration overrides configured", "patterns", len(cfg.Migration.IndexOverrides))
+	}
+	if len(cfg.Migration.Rules) > 0 {
+		slog.Info("migration rules configured", "rules", len(cfg.Migration.Rules))
+	}
 
-	lock := backend.NewOpenSearchLock(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
-	cpStore := migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
-	metricsStore := migration.NewOpenSearchMetricsStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	if secrets != nil {
+		secrets.ShareQuickwit(defaultCold)
+	}
+	// ingest_api "auto" follows the version each cluster reports; a single
+	// run only needs it once.
+	detectInterval := cfg.Quickwit.DetectInterval
+	if *once {
+		detectInterval = 0
+	}
+	cold.WatchFeatures(context.Background(), detectInterval)
+	if window != nil {
+		slog.Info("migrating explicit window, watermarks and checkpoints untouched",
+			"from", window.from.Format(time.RFC3339), "to", window.to.Format(time.RFC3339))
+	}
 
-	migrator, err := migration.NewMigrator(cfg, hot, cold, cpStore,
-		migration.WithDistLock(lock),
-		migration.WithMetricsRecorder(metricsStore),
-	)
-	if err != nil {
-		slog.Error("failed to initialize migrator", "error", err)
-		os.Exit(1)
+	var migrators []sourceMigrator
+	for _, name := range cfg.SourceNames() {
+		scfg, err := cfg.ForSource(name)
+		if err != nil {
+			slog.Error("invalid migration source", "source", name, "error", err)
+			os.Exit(1)
+		}
+		migrator, err := newSourceMigrator(scfg, cold, secrets, window)
+		if err != nil {
+			slog.Error("failed to initialize migrator", "source", name, "error", err)
+			os.Exit(1)
+		}
+		migrators = append(migrators, sourceMigrator{name: name, migrator: migrator})
 	}
+	if secrets != nil {
+		go secrets.Run(context.Background())
+	}
+
+	notifier := notify.New(cfg.Notifications)
 
 	if *once {
-		// Run once and exit.
-		if err := migrator.MigrateAll(context.Background()); err != nil {
+		// Run once, print the summary and exit with a code describing the outcome.
+		report, err := migrateSources(context.Background(), migrators)
+		if err != nil {
 			slog.Error("migration failed", "error", err)
-			os.Exit(1)
+		} else {
+			slog.Info("migration completed, exiting")
 		}
-		slog.Info("migration completed, exiting")
-		return
+		notifyRun(context.Background(), notifier, report)
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			slog.Error("failed to write run summary", "error", err)
+		}
+		os.Exit(exitCode(report.Outcome))
 	}
 
-	// Run on a cron schedule.
+	// Run on a cron schedule. The runner also serves runs triggered through
+	// the gRPC API and skips both while paused.
+	runner := control.NewRunner(func(ctx context.Context) (*migration.RunReport, error) {
+		report, err := migrateSources(ctx, migrators)
+		notifyRun(context.Background(), notifier, report)
+		return report, err
+	})
 	c := cron.New()
 	_, err = c.AddFunc(cfg.Migration.Schedule, func() {
 		slog.Info("scheduled migration starting")
-		if err := migrator.MigrateAll(context.Background()); err != nil {
+		_, err := runner.Run(context.Background(), control.TriggerSchedule)
+		switch {
+		case errors.Is(err, control.ErrPaused):
+			slog.Info("migration paused, skipping scheduled run")
+			return
+		case errors.Is(err, control.ErrRunning):
+			slog.Warn("migration already in progress, skipping scheduled run")
+			return
+		case err != nil:
 			slog.Error("scheduled migration failed", "error", err)
 			return
 		}
@@ -95,6 +280,163 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cfg.Retention.Enforce.Enabled {
+		enforcer := migration.NewRetentionEnforcer(cfg, cold)
+		watcher.OnReload(enforcer.SetConfig)
+		_, err = c.AddFunc(cfg.Retention.Enforce.Schedule, func() {
+			slog.Info("scheduled retention enforcement starting", "dry_run", cfg.Retention.Enforce.DryRun)
+			report, err := enforcer.Enforce(context.Background())
+			if err != nil {
+				slog.Error("retention enforcement failed", "error", err)
+				return
+			}
+			slog.Info("retention enforcement completed", "indices", len(report.Indices), "errors", report.Errors)
+		})
+		if err != nil {
+			slog.Error("invalid retention enforcement schedule", "schedule", cfg.Retention.Enforce.Schedule, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("cold retention enforcement enabled", "schedule", cfg.Retention.Enforce.Schedule, "dry_run", cfg.Retention.Enforce.DryRun)
+	}
+
+	if cfg.Retention.TTL.Enabled {
+		enforcers, err := newTTLEnforcers(cfg, secrets)
+		if err != nil {
+			slog.Error("failed to initialize ttl enforcement", "error", err)
+			os.Exit(1)
+		}
+		watcher.OnReload(func(cfg *config.Config) {
+			for name, e := range enforcers {
+				if scfg, err := cfg.ForSource(name); err == nil {
+					e.SetConfig(scfg)
+				}
+			}
+		})
+		_, err = c.AddFunc(cfg.Retention.TTL.Schedule, func() {
+			slog.Info("scheduled ttl enforcement starting", "dry_run", cfg.Retention.TTL.DryRun)
+			report, err := enforceTTL(context.Background(), enforcers)
+			if err != nil {
+				slog.Error("ttl enforcement failed", "error", err)
+				return
+			}
+			slog.Info("ttl enforcement completed", "indices", len(report.Indices), "errors", report.Errors)
+		})
+		if err != nil {
+			slog.Error("invalid ttl enforcement schedule", "schedule", cfg.Retention.TTL.Schedule, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("ttl enforcement enabled", "schedule", cfg.Retention.TTL.Schedule, "dry_run", cfg.Retention.TTL.DryRun, "max_dropped", cfg.Retention.TTL.MaxDropped)
+	}
+
+	if cfg.Migration.Reconcile.Enabled {
+		reconcilers := make(map[string]*migration.Reconciler)
+		for _, name := range cfg.SourceNames() {
+			scfg, _ := cfg.ForSource(name)
+			r, err := newSourceReconciler(scfg, cold, secrets)
+			if err != nil {
+				slog.Error("failed to initialize reconciler", "source", name, "error", err)
+				os.Exit(1)
+			}
+			reconcilers[name] = r
+		}
+		watcher.OnReload(func(cfg *config.Config) {
+			for name, r := range reconcilers {
+				if scfg, err := cfg.ForSource(name); err == nil {
+					r.SetConfig(scfg)
+				}
+			}
+		})
+		_, err = c.AddFunc(cfg.Migration.Reconcile.Schedule, func() {
+			slog.Info("scheduled boundary reconciliation starting")
+			report, err := reconcileSources(context.Background(), reconcilers)
+			notifyReconcile(context.Background(), notifier, report)
+			if err != nil {
+				slog.Error("boundary reconciliation failed", "error", err)
+				return
+			}
+			slog.Info("boundary reconciliation completed", "days", len(report.Days), "mismatches", report.Mismatches, "errors", report.Errors)
+		})
+		if err != nil {
+			slog.Error("invalid reconciliation schedule", "schedule", cfg.Migration.Reconcile.Schedule, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("boundary reconciliation enabled", "schedule", cfg.Migration.Reconcile.Schedule, "days", cfg.Migration.Reconcile.Days)
+	}
+
+	if cfg.Migration.StateBackup.Enabled {
+		backups := make(map[string]*migration.StateBackup)
+		for _, name := range cfg.SourceNames() {
+			scfg, _ := cfg.ForSource(name)
+			b, err := newStateBackup(scfg, secrets)
+			if err != nil {
+				slog.Error("failed to initialize state backup", "source", name, "error", err)
+				os.Exit(1)
+			}
+			backups[name] = b
+		}
+		_, err = c.AddFunc(cfg.Migration.StateBackup.Schedule, func() {
+			for name, b := range backups {
+				if _, err := b.Backup(context.Background()); err != nil {
+					slog.Error("state backup failed", "source", name, "error", err)
+				}
+			}
+		})
+		if err != nil {
+			slog.Error("invalid state backup schedule", "schedule", cfg.Migration.StateBackup.Schedule, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("state backups enabled", "schedule", cfg.Migration.StateBackup.Schedule,
+			"repository", cfg.Migration.StateBackup.Repository, "dir", cfg.Migration.StateBackup.Dir, "keep", cfg.Migration.StateBackup.Keep)
+	}
+
+	var readers []sourceReader
+	if (cfg.Dashboard.Enabled && cfg.Migration.MetricsListen != "") || cfg.Migration.GRPCListen != "" {
+		readers, err = newSourceReaders(cfg, secrets)
+		if err != nil {
+			slog.Error("failed to open migration state", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var metricsServer *http.Server
+	if cfg.Migration.MetricsListen != "" {
+		var extra map[string]http.Handler
+		if cfg.Dashboard.Enabled {
+			status := newDashboardStatus(readers, watcher.Config)
+			extra = map[string]http.Handler{"/ui/": http.StripPrefix("/ui", dashboard.Handler(cfg.Dashboard, status))}
+			slog.Info("dashboard enabled", "addr", cfg.Migration.MetricsListen, "path", "/ui/")
+		}
+		metricsServer = util.ServeMetrics(cfg.Migration.MetricsListen, extra)
+	} else if cfg.Dashboard.Enabled {
+		slog.Warn("dashboard.enabled has no effect without migration.metrics_listen")
+	}
+
+	var grpcServer *grpc.Server
+	if cfg.Migration.GRPCListen != "" {
+		grpcServer = control.NewServer(cfg.GRPC.Token)
+		oqbridgev1.RegisterMigrationServiceServer(grpcServer, control.NewMigrationService(runner, sourceStates(readers)))
+		control.Serve(cfg.Migration.GRPCListen, grpcServer)
+	}
+
+	// Apply edits to the configuration file without a restart.
+	watcher.OnReload(func(cfg *config.Config) {
+		util.SetupLogger(cfg.Logging.Level)
+		for _, sm := range migrators {
+			scfg, err := cfg.ForSource(sm.name)
+			if err != nil {
+				slog.Error("invalid migration source after reload", "source", sm.name, "error", err)
+				continue
+			}
+			sm.migrator.SetConfig(scfg)
+		}
+	})
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go watcher.Run(context.Background(), hup)
+	if retentionSync != nil {
+		go retentionSync.Run(context.Background())
+	}
+
 	c.Start()
 	slog.Info("migration scheduler started", "schedule", cfg.Migration.Schedule)
 
@@ -104,7 +446,15 @@ func main() {
 	<-stop
 
 	slog.Info("shutting down...")
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
 	ctx := c.Stop()
 	<-ctx.Done()
+	if metricsServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		metricsServer.Shutdown(shutdownCtx)
+		cancel()
+	}
 	slog.Info("oqbridge-migrate stopped")
 }
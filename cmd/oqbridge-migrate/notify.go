@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/notify"
+)
+
+// notifyRun sends run_failed and lock_contention events for a migration run.
+func notifyRun(ctx context.Context, n *notify.Notifier, report *migration.RunReport) {
+	if n == nil || report == nil {
+		return
+	}
+	var failed, locked []notify.Field
+	for _, res := range report.Indices {
+		switch {
+		case res.Status == migration.IndexStatusFailed:
+			failed = append(failed, notify.Field{Name: res.Index, Value: res.Error})
+		case res.Status == migration.IndexStatusSkipped && res.Reason == migration.ReasonLockHeld:
+			locked = append(locked, notify.Field{Name: res.Index, Value: res.Reason})
+		}
+	}
+
+	if report.Outcome == migration.OutcomeFailed || report.Outcome == migration.OutcomePartialFailure {
+		summary := fmt.Sprintf("%d of %d indices failed; %d documents migrated in %.0fs.",
+			len(failed), len(report.Indices), report.Migrated, report.DurationSec)
+		if report.Error != "" && len(failed) == 0 {
+			summary = report.Error
+		}
+		n.Notify(ctx, notify.Event{
+			Kind:    notify.EventRunFailed,
+			Title:   "Migration run " + report.Outcome,
+			Summary: summary,
+			Fields:  failed,
+		})
+	}
+	if len(locked) > 0 {
+		n.Notify(ctx, notify.Event{
+			Kind:    notify.EventLockContention,
+			Title:   "Migration skipped locked indices",
+			Summary: fmt.Sprintf("%d indices were skipped because another instance holds their lock. Check `oqbridge-migrate lock list` if this persists.", len(locked)),
+			Fields:  locked,
+		})
+	}
+}
+
+// notifyVerify sends a verify_mismatch event when a verification found
+// differences or errors.
+func notifyVerify(ctx context.Context, n *notify.Notifier, report *migration.VerifyReport) {
+	if n == nil || report.Mismatches+report.Errors == 0 {
+		return
+	}
+	var fields []notify.Field
+	for _, r := range report.Indices {
+		switch r.Status {
+		case migration.VerifyStatusCountMismatch:
+			fields = append(fields, notify.Field{Name: r.Index, Value: fmt.Sprintf("opensearch=%d quickwit=%d", r.HotCount, r.ColdCount)})
+		case migration.VerifyStatusSampleMismatch:
+			fields = append(fields, notify.Field{Name: r.Index, Value: fmt.Sprintf("%d of %d sampled documents missing", r.SamplesMissing, r.SamplesChecked)})
+		case migration.VerifyStatusError:
+			fields = append(fields, notify.Field{Name: r.Index, Value: r.Error})
+		}
+	}
+	n.Notify(ctx, notify.Event{
+		Kind:    notify.EventVerifyMismatch,
+		Title:   "Migration verification found differences",
+		Summary: fmt.Sprintf("%d of %d indices mismatched, %d errors.", report.Mismatches, len(report.Indices), report.Errors),
+		Fields:  fields,
+	})
+}
+
+// notifyReconcile sends a reconcile_mismatch event when a reconciliation
+// pass found days whose counts diverge.
+func notifyReconcile(ctx context.Context, n *notify.Notifier, report *migration.ReconcileReport) {
+	if n == nil || report.Mismatches+report.Errors == 0 {
+		return
+	}
+	var fields []notify.Field
+	for _, r := range report.Days {
+		name := r.Index + " " + r.From.Format(time.DateOnly)
+		if r.Source != "" {
+			name = r.Source + "/" + name
+		}
+		switch r.Status {
+		case migration.ReconcileStatusMissing, migration.ReconcileStatusSurplus:
+			fields = append(fields, notify.Field{Name: name, Value: fmt.Sprintf("%s: opensearch=%d sink=%d", r.Status, r.HotCount, r.ColdCount)})
+		case migration.ReconcileStatusDecreased:
+			fields = append(fields, notify.Field{Name: name, Value: fmt.Sprintf("quickwit dropped from %d to %d documents", r.PrevColdCount, r.ColdCount)})
+		case migration.ReconcileStatusError:
+			fields = append(fields, notify.Field{Name: name, Value: r.Error})
+		}
+	}
+	n.Notify(ctx, notify.Event{
+		Kind:    notify.EventReconcileMismatch,
+		Title:   "Boundary reconciliation found differences",
+		Summary: fmt.Sprintf("%d of %d days diverged, %d errors. Run `oqbridge-migrate verify` on the affected indices for details.", report.Mismatches, len(report.Days), report.Errors),
+		Fields:  fields,
+	})
+}
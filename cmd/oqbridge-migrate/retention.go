@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+const retentionUsage = `usage: oqbridge-migrate retention <action> [flags]
+
+actions:
+  enforce [-dry-run] [-json]    delete Quickwit indices and splits older than
+                                their cold retention period
+  apply [-dry-run]              set the retention policy of each Quickwit index
+                                to its configured cold retention period
+  ttl [-dry-run] [-json]        delete OpenSearch documents of migration.rules
+                                with action "delete" older than their
+                                delete_after_days, without archiving them
+`
+
+// runRetention implements "oqbridge-migrate retention enforce|apply|ttl".
+func runRetention(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, retentionUsage)
+		return 1
+	}
+	action, args := args[0], args[1:]
+
+	fs, common := newFlagSet("retention " + action)
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing anything")
+	asJSON := fs.Bool("json", false, "enforce, ttl: print the report as JSON")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if *dryRun {
+		cfg.Retention.Enforce.DryRun = true
+		cfg.Retention.TTL.DryRun = true
+	}
+	if action == "ttl" {
+		return runTTL(cfg, *asJSON)
+	}
+	cold, _, err := newColdBackend(cfg, false, nil)
+	if err != nil {
+		return fail("%v", err)
+	}
+	enforcer := migration.NewRetentionEnforcer(cfg, cold)
+	ctx := context.Background()
+
+	switch action {
+	case "enforce":
+		report, err := enforcer.Enforce(ctx)
+		if err != nil {
+			return fail("%v", err)
+		}
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			printRetentionTable(report)
+		}
+		if report.Errors > 0 {
+			return 1
+		}
+		return 0
+
+	case "apply":
+		indices, err := enforcer.Indices(ctx)
+		if err != nil {
+			return fail("%v", err)
+		}
+		code := 0
+		for _, index := range indices {
+			days := cfg.ColdDaysForIndex(index)
+			policy := fmt.Sprintf("%d days", days)
+			if days <= 0 {
+				policy = "none"
+			}
+			if *dryRun {
+				fmt.Printf("would set %s retention to %s\n", index, policy)
+				continue
+			}
+			if err := cold.UpdateRetention(ctx, index, days); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", index, err)
+				code = 1
+				continue
+			}
+			fmt.Printf("set %s retention to %s\n", index, policy)
+		}
+		return code
+
+	default:
+		fmt.Fprint(os.Stderr, retentionUsage)
+		return 1
+	}
+}
+
+// runTTL runs one TTL pass over every source, whether or not
+// retention.ttl.enabled is set.
+func runTTL(cfg *config.Config, asJSON bool) int {
+	enforcers, err := newTTLEnforcers(cfg, nil)
+	if err != nil {
+		return fail("%v", err)
+	}
+	report, err := enforceTTL(context.Background(), enforcers)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printTTLTable(report)
+	}
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printTTLTable(report *migration.TTLReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tINDEX\tDELETE AFTER\tCUTOFF\tACTION\tDOCS")
+	deleted := 0
+	for _, r := range report.Indices {
+		action := r.Action
+		if r.Error != "" {
+			action += ": " + r.Error
+		}
+		if r.Action == migration.TTLActionDropIndex || r.Action == migration.TTLActionDeleteDocs {
+			deleted++
+		}
+		source := r.Source
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%dd\t%s\t%s\t%d\n", source, r.Index, r.DeleteAfterDays, r.Cutoff.Format("2006-01-02T15:04:05Z"), action, r.Docs)
+	}
+	w.Flush()
+	verb := "pruned"
+	if report.DryRun {
+		verb = "would be pruned (dry run)"
+	}
+	fmt.Printf("\n%d indices, %d %s, %d errors\n", len(report.Indices), deleted, verb, report.Errors)
+}
+
+func printRetentionTable(report *migration.RetentionReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tCOLD DAYS\tCUTOFF\tACTION\tSPLITS\tDOCS")
+	deleted := 0
+	for _, r := range report.Indices {
+		action := r.Action
+		if r.Error != "" {
+			action += ": " + r.Error
+		}
+		if r.Action == migration.RetentionActionDeleteIndex || r.Action == migration.RetentionActionDeleteSplits {
+			deleted++
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%d\n", r.Index, r.ColdDays, r.Cutoff.Format("2006-01-02T15:04:05Z"), action, r.Splits, r.Docs)
+	}
+	w.Flush()
+	verb := "pruned"
+	if report.DryRun {
+		verb = "would be pruned (dry run)"
+	}
+	fmt.Printf("\n%d indices, %d %s, %d errors\n", len(report.Indices), deleted, verb, report.Errors)
+}
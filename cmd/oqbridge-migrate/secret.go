@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+const secretUsage = `usage: oqbridge-migrate secret <action> [flags]
+
+actions:
+  keygen                        print a new key for encryption.key_file
+  encrypt -key-file <path>      read a secret from stdin and print it encrypted,
+                                to be used in place of the plaintext value
+`
+
+// runSecret implements "oqbridge-migrate secret keygen|encrypt".
+func runSecret(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, secretUsage)
+		return 1
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("secret "+action, flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "encrypt: file holding the key (encryption.key_file)")
+	fs.Parse(args)
+
+	switch action {
+	case "keygen":
+		key, err := config.GenerateKey()
+		if err != nil {
+			return fail("generating key: %v", err)
+		}
+		fmt.Println(key)
+		return 0
+
+	case "encrypt":
+		if *keyFile == "" {
+			return fail("secret encrypt requires -key-file")
+		}
+		key, err := config.ReadKeyFile(*keyFile)
+		if err != nil {
+			return fail("%v", err)
+		}
+		// A single line, so the secret can be piped or typed without a
+		// trailing newline ending up in it.
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fail("reading secret from stdin: %v", err)
+		}
+		value, err := config.EncryptSecret(key, strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return fail("encrypting: %v", err)
+		}
+		fmt.Println(value)
+		return 0
+
+	default:
+		fmt.Fprint(os.Stderr, secretUsage)
+		return 1
+	}
+}
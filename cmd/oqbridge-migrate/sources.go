@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+)
+
+// sourceMigrator is the migrator for one migration source.
+type sourceMigrator struct {
+	name     string
+	migrator *migration.Migrator
+}
+
+// newSourceMigrator builds the migrator for the source selected in cfg (see
+// config.ForSource), with its own OpenSearch client, checkpoint store,
+// distributed lock and metrics store. It writes into the next entry of
+// tiers if there is one, into cold otherwise. Vault credentials only cover
+// the top-level opensearch cluster, so secrets is ignored for named sources.
+func newSourceMigrator(cfg *config.Config, cold *backend.QuickwitRouter, secrets *vault.Source, w *window) (*migration.Migrator, error) {
+	log := slog.Default()
+	if cfg.Source() != "" {
+		log = log.With("source", cfg.Source())
+		log.Info("migration source", "opensearch", cfg.OpenSearch.URL, "indices", cfg.Migration.Indices)
+	}
+
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+
+	sink, counter, err := newSink(cfg, cold)
+	if err != nil {
+		return nil, err
+	}
+	next := cfg.NextTier()
+	if next != nil {
+		log.Info("migrating into tier", "tier", next.Name, "opensearch", next.OpenSearch.URL, "migrate_after_days", cfg.Migration.MigrateAfterDays)
+	}
+
+	lock := backend.NewOpenSearchLock(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	cpStore, err := newCheckpointStore(cfg, osClient)
+	if err != nil {
+		return nil, fmt.Errorf("opening checkpoint store: %w", err)
+	}
+	if cfg.Migration.CheckpointDir != "" {
+		log.Info("storing checkpoints locally", "checkpoint_dir", cfg.Migration.CheckpointDir)
+	}
+	metricsStore := migration.NewOpenSearchMetricsStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+
+	// Follow credential rotation in Vault for the lifetime of the process.
+	if secrets != nil && cfg.Source() == "" {
+		secrets.ShareOpenSearch(hot, lock, metricsStore)
+		if s, ok := cpStore.(vault.CredentialSetter); ok {
+			secrets.ShareOpenSearch(s)
+		}
+	}
+
+	opts := []migration.MigratorOption{
+		migration.WithDistLock(lock),
+		migration.WithLockTTL(cfg.Migration.LockTTL),
+		migration.WithMetricsRecorder(metricsStore),
+		migration.WithPreCount(hot),
+	}
+	if next == nil {
+		opts = append(opts, migration.WithColdHealthCheck(cold), migration.WithColdStats(cold))
+	}
+	if cfg.Migration.HealthGate.Enabled {
+		opts = append(opts, migration.WithClusterHealth(hot))
+		log.Info("opensearch health gating enabled",
+			"max_status", cfg.Migration.HealthGate.MaxStatus,
+			"max_pending_tasks", cfg.Migration.HealthGate.MaxPendingTasks,
+			"max_heap_percent", cfg.Migration.HealthGate.MaxHeapPercent,
+		)
+	}
+
+	if cfg.Migration.Dedup {
+		opts = append(opts, migration.WithDedup(hot, counter))
+		log.Info("pre-ingest dedup check enabled")
+	}
+
+	if cfg.Migration.Snapshot.Enabled {
+		opts = append(opts, migration.WithSnapshotSource(hot))
+		log.Info("migrating from snapshot repository",
+			"repository", cfg.Migration.Snapshot.Repository,
+			"snapshot", cfg.Migration.Snapshot.Name,
+			"mode", cfg.Migration.Snapshot.Mode,
+		)
+	}
+
+	// migration.rollups is read for each index, so rollups added by a
+	// reload apply from the next run.
+	opts = append(opts, migration.WithRollups(hot))
+
+	if w != nil {
+		opts = append(opts, migration.WithWindow(w.from, w.to))
+	}
+
+	return migration.NewMigrator(cfg, hot, sink, cpStore, opts...)
+}
+
+// newSink returns what migrating with cfg writes into: the OpenSearch
+// cluster of the next entry of tiers if there is one, cold otherwise.
+func newSink(cfg *config.Config, cold *backend.QuickwitRouter) (migration.ColdClient, migration.RangeCounter, error) {
+	next := cfg.NextTier()
+	if next == nil {
+		return cold, cold, nil
+	}
+	client, err := util.NewOpenSearchClient(next.OpenSearch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating OpenSearch HTTP client for tier %s: %w", next.Name, err)
+	}
+	tier := backend.NewOpenSearch(next.OpenSearch.URL, next.OpenSearch.Username, next.OpenSearch.Password, client)
+	return tier, tier, nil
+}
+
+// newSourceReconciler builds the boundary reconciler for the source
+// selected in cfg, which compares it with what it migrates into. Like
+// newSourceMigrator, it follows Vault credentials for the opensearch
+// cluster only.
+func newSourceReconciler(cfg *config.Config, cold *backend.QuickwitRouter, secrets *vault.Source) (*migration.Reconciler, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	_, counter, err := newSink(cfg, cold)
+	if err != nil {
+		return nil, err
+	}
+	cpStore, err := newCheckpointStore(cfg, osClient)
+	if err != nil {
+		return nil, fmt.Errorf("opening checkpoint store: %w", err)
+	}
+	if secrets != nil && cfg.Source() == "" {
+		secrets.ShareOpenSearch(hot)
+		if s, ok := cpStore.(vault.CredentialSetter); ok {
+			secrets.ShareOpenSearch(s)
+		}
+	}
+	return migration.NewReconciler(cfg, hot, counter, cpStore), nil
+}
+
+// newSourceTTL builds the TTL enforcer for the source selected in cfg, with
+// its own OpenSearch client.
+func newSourceTTL(cfg *config.Config, secrets *vault.Source) (*migration.TTLEnforcer, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	if secrets != nil && cfg.Source() == "" {
+		secrets.ShareOpenSearch(hot)
+	}
+	return migration.NewTTLEnforcer(cfg, hot), nil
+}
+
+// newTTLEnforcers builds a TTL enforcer for each source, keyed by name.
+func newTTLEnforcers(cfg *config.Config, secrets *vault.Source) (map[string]*migration.TTLEnforcer, error) {
+	enforcers := make(map[string]*migration.TTLEnforcer)
+	for _, name := range cfg.SourceNames() {
+		scfg, _ := cfg.ForSource(name)
+		e, err := newSourceTTL(scfg, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", name, err)
+		}
+		enforcers[name] = e
+	}
+	return enforcers, nil
+}
+
+// enforceTTL runs a TTL pass of each source in turn and combines the
+// results into a single report.
+func enforceTTL(ctx context.Context, enforcers map[string]*migration.TTLEnforcer) (*migration.TTLReport, error) {
+	report := &migration.TTLReport{Indices: []migration.TTLResult{}}
+	var errs []error
+	for name, e := range enforcers {
+		r, err := e.Enforce(ctx)
+		if err != nil {
+			if name != "" {
+				err = fmt.Errorf("source %s: %w", name, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		report.DryRun = r.DryRun
+		report.Indices = append(report.Indices, r.Indices...)
+		report.Errors += r.Errors
+	}
+	return report, errors.Join(errs...)
+}
+
+// reconcileSources runs a reconciliation pass of each source in turn and
+// combines the results into a single report.
+func reconcileSources(ctx context.Context, reconcilers map[string]*migration.Reconciler) (*migration.ReconcileReport, error) {
+	report := &migration.ReconcileReport{Days: []migration.ReconcileResult{}}
+	var errs []error
+	for name, r := range reconcilers {
+		rr, err := r.Reconcile(ctx)
+		if err != nil {
+			if name != "" {
+				err = fmt.Errorf("source %s: %w", name, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		report.Days = append(report.Days, rr.Days...)
+		report.Mismatches += rr.Mismatches
+		report.Errors += rr.Errors
+	}
+	return report, errors.Join(errs...)
+}
+
+// migrateSources runs each source's migration in turn and combines the
+// results into a single report.
+func migrateSources(ctx context.Context, migrators []sourceMigrator) (*migration.RunReport, error) {
+	reports := make([]*migration.RunReport, 0, len(migrators))
+	var errs []error
+	for _, sm := range migrators {
+		report, err := sm.migrator.MigrateAllWithReport(ctx)
+		reports = append(reports, report)
+		if err != nil {
+			if sm.name != "" {
+				err = fmt.Errorf("source %s: %w", sm.name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return migration.CombineReports(reports), errors.Join(errs...)
+}
+
+// overrideIndices replaces the indices to migrate, on every source when
+// migration.sources is configured.
+func overrideIndices(cfg *config.Config, indices []string) {
+	if len(cfg.Migration.Sources) == 0 {
+		cfg.Migration.Indices = indices
+		return
+	}
+	for i := range cfg.Migration.Sources {
+		cfg.Migration.Sources[i].Indices = indices
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+)
+
+const stateUsage = `usage: oqbridge-migrate state <action> [flags]
+
+actions:
+  backup                        back up the state indices of the source
+  list                          list the backups of the source
+  restore [<backup>]            replace the state indices with a backup
+                                (default: the newest); stop the migrate
+                                daemons of the source first
+`
+
+// runState implements "oqbridge-migrate state backup|list|restore".
+func runState(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, stateUsage)
+		return 1
+	}
+	action, args := args[0], args[1:]
+
+	fs, common := newFlagSet("state " + action)
+	repository := fs.String("repository", "", "snapshot repository to use instead of migration.state_backup")
+	dir := fs.String("dir", "", "directory of JSON backups to use instead of migration.state_backup")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if *repository != "" || *dir != "" {
+		if *repository != "" && *dir != "" {
+			return fail("-repository and -dir are mutually exclusive")
+		}
+		cfg.Migration.StateBackup.Repository, cfg.Migration.StateBackup.Dir = *repository, *dir
+	}
+	if cfg.Migration.StateBackup.Repository == "" && cfg.Migration.StateBackup.Dir == "" {
+		return fail("no backup location: set migration.state_backup.repository or .dir, or pass -repository or -dir")
+	}
+	backups, err := newStateBackup(cfg, nil)
+	if err != nil {
+		return fail("%v", err)
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "backup":
+		info, err := backups.Backup(ctx)
+		if err != nil && info == nil {
+			return fail("backing up state: %v", err)
+		}
+		fmt.Printf("created backup %s\n", info.Name)
+		if err != nil {
+			return fail("%v", err)
+		}
+		return 0
+
+	case "list":
+		list, err := backups.List(ctx)
+		if err != nil {
+			return fail("listing backups: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BACKUP\tCREATED\tINDICES")
+		for _, b := range list {
+			indices := "-"
+			if len(b.Indices) > 0 {
+				indices = strings.Join(b.Indices, ",")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", b.Name, b.CreatedAt.Format(time.RFC3339), indices)
+		}
+		w.Flush()
+		return 0
+
+	case "restore":
+		if fs.NArg() > 1 {
+			return fail("state restore takes at most one backup name")
+		}
+		info, err := backups.Restore(ctx, fs.Arg(0))
+		if err != nil {
+			return fail("restoring state: %v", err)
+		}
+		fmt.Printf("restored state from %s (created %s)\n", info.Name, info.CreatedAt.Format(time.RFC3339))
+		return 0
+
+	default:
+		fmt.Fprint(os.Stderr, stateUsage)
+		return 1
+	}
+}
+
+// newStateBackup creates the state backup of the source selected in cfg.
+// Like newSourceMigrator, it follows Vault credentials for the opensearch
+// cluster only.
+func newStateBackup(cfg *config.Config, secrets *vault.Source) (*migration.StateBackup, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	if secrets != nil && cfg.Source() == "" {
+		secrets.ShareOpenSearch(hot)
+	}
+	return migration.NewStateBackup(cfg, hot), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// runStatus implements "oqbridge-migrate status".
+func runStatus(args []string) int {
+	fs, common := newFlagSet("status")
+	asJSON := fs.Bool("json", false, "print the status as JSON")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return fail("creating OpenSearch HTTP client: %v", err)
+	}
+	store, err := newCheckpointStore(cfg, osClient)
+	if err != nil {
+		return fail("opening checkpoint store: %v", err)
+	}
+	admin, ok := store.(migration.CheckpointAdmin)
+	if !ok {
+		return fail("checkpoint store %T does not support listing", store)
+	}
+	metrics := migration.NewOpenSearchMetricsStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	lock := backend.NewOpenSearchLock(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	ctx := context.Background()
+
+	rows, err := migration.ReadStatus(ctx, admin, metrics, lock)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rows)
+		return 0
+	}
+	printStatusTable(rows, time.Now())
+	return 0
+}
+
+func printStatusTable(rows []migration.IndexStatus, now time.Time) {
+	// Cost columns are shown once any run has been priced.
+	costs := slices.ContainsFunc(rows, func(r migration.IndexStatus) bool {
+		return r.Runs != nil && r.Runs.LastRun != nil && r.Runs.LastRun.CostCurrency != ""
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "INDEX\tWATERMARK\tCHECKPOINT\tLAST RUN\tOUTCOME\tLAST MIGRATED\tTOTAL MIGRATED\tLOCK"
+	if costs {
+		header += "\tSTORAGE/MONTH (HOT/COLD)\tEGRESS"
+	}
+	fmt.Fprintln(w, header)
+	for _, r := range rows {
+		watermark, checkpoint := "-", "-"
+		if st := r.State; st != nil {
+			if st.Watermark != nil {
+				watermark = st.Watermark.MigratedBefore.Format(time.RFC3339)
+			}
+			if cp := st.Checkpoint; cp != nil {
+				checkpoint = "completed"
+				if !cp.Completed {
+					checkpoint = fmt.Sprintf("in progress (%d docs)", cp.Migrated)
+				}
+			}
+		}
+		lastRun, outcome, lastMigrated, total := "-", "-", "-", "-"
+		if r.Runs != nil {
+			total = fmt.Sprint(r.Runs.TotalMigrated)
+			if last := r.Runs.LastRun; last != nil {
+				lastRun = last.StartedAt.Format(time.RFC3339)
+				outcome = last.Status
+				lastMigrated = fmt.Sprint(last.DocumentsMigrated)
+			}
+		}
+		lockState := "-"
+		if l := r.Lock; l != nil {
+			lockState = "held by " + l.Owner
+			if l.Expired(now) {
+				lockState = "expired (" + l.Owner + ")"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+			r.Index, watermark, checkpoint, lastRun, outcome, lastMigrated, total, lockState)
+		if costs {
+			storage, egress := "-", "-"
+			if r.Runs != nil && r.Runs.LastRun != nil && r.Runs.LastRun.CostCurrency != "" {
+				last := r.Runs.LastRun
+				storage = fmt.Sprintf("%.2f / %.2f %s", last.SourceMonthlyCost, last.ColdMonthlyCost, last.CostCurrency)
+				egress = fmt.Sprintf("%.2f %s", r.Runs.EgressCost, last.CostCurrency)
+			}
+			fmt.Fprintf(w, "\t%s\t%s", storage, egress)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/notify"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// exitVerifyMismatch is returned by verify when any index does not reconcile.
+const exitVerifyMismatch = 6
+
+// runVerify implements "oqbridge-migrate verify".
+func runVerify(args []string) int {
+	fs, common := newFlagSet("verify")
+	var indices stringList
+	fs.Var(&indices, "index", "verify this index instead of migration.indices (repeatable)")
+	pattern := fs.String("pattern", "", "verify indices matching this pattern instead of migration.indices")
+	fromFlag := fs.String("from", "", "start of the window (RFC3339, YYYY-MM-DD or now-30d); default unbounded")
+	toFlag := fs.String("to", "", "end of the window (exclusive); default each index's watermark")
+	samples := fs.Int("samples", 0, "randomly sample and hash this many documents per index")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(common)
+	if err != nil {
+		return fail("%v", err)
+	}
+	patterns := cfg.Migration.Indices
+	if len(indices) > 0 || *pattern != "" {
+		patterns = append([]string(nil), indices...)
+		if *pattern != "" {
+			patterns = append(patterns, *pattern)
+		}
+	}
+	if len(patterns) == 0 {
+		return fail("no indices to verify: set migration.indices or pass -index/-pattern")
+	}
+
+	var opts migration.VerifyOptions
+	opts.Samples = *samples
+	if *fromFlag != "" {
+		if opts.From, err = util.ParseTimeExpression(*fromFlag); err != nil {
+			return fail("-from: %v", err)
+		}
+	}
+	if *toFlag != "" {
+		if opts.To, err = util.ParseTimeExpression(*toFlag); err != nil {
+			return fail("-to: %v", err)
+		}
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.From.Before(opts.To) {
+		return fail("-from must be before -to")
+	}
+
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return fail("creating OpenSearch HTTP client: %v", err)
+	}
+	cold, _, err := newColdBackend(cfg, false, nil)
+	if err != nil {
+		return fail("%v", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	store, err := newCheckpointStore(cfg, osClient)
+	if err != nil {
+		return fail("opening checkpoint store: %v", err)
+	}
+
+	report, err := migration.NewVerifier(cfg, hot, cold, store).Verify(context.Background(), patterns, opts)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printVerifyTable(report)
+	}
+	notifyVerify(context.Background(), notify.New(cfg.Notifications), report)
+
+	switch {
+	case report.Errors > 0:
+		return 1
+	case report.Mismatches > 0:
+		return exitVerifyMismatch
+	default:
+		return 0
+	}
+}
+
+func printVerifyTable(report *migration.VerifyReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tFROM\tTO\tOPENSEARCH\tQUICKWIT\tDIFF\tSAMPLES\tQUICKWIT SIZE\tSTATUS")
+	for _, r := range report.Indices {
+		if r.Status == migration.VerifyStatusNotMigrated || (r.Status == migration.VerifyStatusError && r.To.IsZero()) {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\t-\t%s\n", r.Index, verifyStatusText(r))
+			continue
+		}
+		samples := "-"
+		if r.SamplesChecked > 0 {
+			samples = fmt.Sprintf("%d/%d", r.SamplesChecked-r.SamplesMissing, r.SamplesChecked)
+		}
+		size := "-"
+		if r.ColdSplits > 0 {
+			size = fmt.Sprintf("%.1f MiB (%d splits)", float64(r.ColdSizeBytes)/(1<<20), r.ColdSplits)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%+d\t%s\t%s\t%s\n", r.Index,
+			r.From.Format(time.RFC3339), r.To.Format(time.RFC3339),
+			r.HotCount, r.ColdCount, r.ColdCount-r.HotCount, samples, size, verifyStatusText(r))
+	}
+	w.Flush()
+	fmt.Printf("\n%d indices, %d mismatched, %d errors\n", len(report.Indices), report.Mismatches, report.Errors)
+}
+
+func verifyStatusText(r migration.VerifyResult) string {
+	if r.Error != "" {
+		return r.Status + ": " + r.Error
+	}
+	return r.Status
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/bench"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+)
+
+// headerList is a repeatable "Name: value" flag.
+type headerList []string
+
+func (h *headerList) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerList) Set(v string) error {
+	if !strings.Contains(v, ":") {
+		return fmt.Errorf("header %q is not in \"Name: value\" form", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+// runBench replays a mix of hot, cold and both searches against the proxy
+// or the backends and prints their latency per routing class. It exits
+// with 1 if a search class regressed against -baseline.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	configPath := fs.String("config", "oqbridge.yaml", "path to configuration file (.yaml, .json or .toml); empty to configure from environment variables only")
+	target := fs.String("target", "proxy", `what to search: "proxy", or "backends" to search OpenSearch and Quickwit directly with the service accounts`)
+	proxyURL := fs.String("url", "", "URL of the proxy (default: server.listen on localhost)")
+	user := fs.String("user", "", "user name for basic authentication against the proxy")
+	password := fs.String("password", "", "password for basic authentication against the proxy")
+	var headers headerList
+	fs.Var(&headers, "header", `header sent to the proxy, as "Name: value" (repeatable)`)
+	index := fs.String("index", "logs-*", "index searched by the generated queries")
+	queriesPath := fs.String("queries", "", "file of queries to replay instead of the generated ones, one JSON object per line: {\"class\":\"cold\",\"index\":\"logs-*\",\"body\":{...}}")
+	mix := fs.String("mix", "hot=1,cold=1,both=1", "relative number of searches per routing class")
+	concurrency := fs.Int("concurrency", 4, "searches in flight at once")
+	requests := fs.Int("requests", 0, "searches to run in total (default: run for -duration)")
+	duration := fs.Duration("duration", 30*time.Second, "how long to run when -requests is not set")
+	timeout := fs.Duration("timeout", 60*time.Second, "timeout of each search")
+	jsonOut := fs.Bool("json", false, "print the report as JSON, e.g. to keep it as a -baseline")
+	baselinePath := fs.String("baseline", "", "JSON report of an earlier run to compare against")
+	tolerance := fs.Float64("tolerance", 0.2, "how much slower than -baseline a class may get, as a fraction")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return benchFail("loading configuration: %v", err)
+	}
+	util.SetupLoggerOutput(cfg.Logging.Level, os.Stderr)
+	cfg.SetDefaultUserAgent("oqbridge/" + version)
+
+	weights, err := parseMix(*mix)
+	if err != nil {
+		return benchFail("-mix: %v", err)
+	}
+	queries := bench.DefaultQueries(cfg, *index, time.Now())
+	if *queriesPath != "" {
+		f, err := os.Open(*queriesPath)
+		if err != nil {
+			return benchFail("%v", err)
+		}
+		queries, err = bench.ReadQueries(f)
+		f.Close()
+		if err != nil {
+			return benchFail("reading %s: %v", *queriesPath, err)
+		}
+	}
+	var baseline *bench.Report
+	if *baselinePath != "" {
+		b, err := os.ReadFile(*baselinePath)
+		if err != nil {
+			return benchFail("%v", err)
+		}
+		if err := json.Unmarshal(b, &baseline); err != nil {
+			return benchFail("reading %s: %v", *baselinePath, err)
+		}
+	}
+
+	var (
+		search bench.Target
+		name   string
+	)
+	switch *target {
+	case "proxy":
+		name = *proxyURL
+		if name == "" {
+			name = "http://" + listenHost(cfg.Server.Listen)
+		}
+		search = proxySearch(strings.TrimSuffix(name, "/"), *user, *password, headers)
+	case "backends":
+		if search, err = backendSearch(cfg); err != nil {
+			return benchFail("%v", err)
+		}
+		name = "the backends"
+	default:
+		return benchFail(`-target must be "proxy" or "backends", got %q`, *target)
+	}
+	timed := func(ctx context.Context, q bench.Query) error {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		return search(ctx, q)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	opts := bench.Options{Mix: weights, Concurrency: *concurrency, Requests: *requests, Duration: *duration}
+	report, err := bench.Run(ctx, queries, opts, timed)
+	if err != nil {
+		return benchFail("%v", err)
+	}
+	report.Target = name
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	if baseline != nil {
+		regressions := report.Compare(baseline, *tolerance)
+		for _, r := range regressions {
+			fmt.Fprintf(os.Stderr, "regression: %s\n", r)
+		}
+		if len(regressions) > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseMix parses "hot=6,cold=3,both=1".
+func parseMix(s string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		class, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(weight)
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not class=weight", part)
+		}
+		if class != bench.ClassHot && class != bench.ClassCold && class != bench.ClassBoth {
+			return nil, fmt.Errorf("unknown class %q", class)
+		}
+		weights[class] = n
+	}
+	return weights, nil
+}
+
+// listenHost turns a listen address such as ":9200" into "localhost:9200".
+func listenHost(listen string) string {
+	if strings.HasPrefix(listen, ":") {
+		return "localhost" + listen
+	}
+	return listen
+}
+
+// proxySearch searches the proxy at base as a client would.
+func proxySearch(base, user, password string, headers headerList) bench.Target {
+	client := &http.Client{}
+	return func(ctx context.Context, q bench.Query) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/"+q.Index+"/_search", bytes.NewReader(q.Body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for _, h := range headers {
+			name, value, _ := strings.Cut(h, ":")
+			req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// backendSearch searches OpenSearch for hot queries, Quickwit for cold
+// ones and both at once for the rest, without the proxy, to tell its
+// overhead from the backends' latency.
+func backendSearch(cfg *config.Config) (bench.Target, error) {
+	secrets, err := vault.Load(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("loading credentials from vault: %w", err)
+	}
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	def, err := newQuickwit(cfg.Quickwit, config.FaultConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("creating Quickwit HTTP client: %w", err)
+	}
+	clusters := make(map[string]*backend.Quickwit, len(cfg.QuickwitClusters))
+	for _, c := range cfg.QuickwitClusters {
+		if clusters[c.Name], err = newQuickwit(c.Quickwit, config.FaultConfig{}); err != nil {
+			return nil, fmt.Errorf("creating Quickwit HTTP client for %s: %w", c.Name, err)
+		}
+	}
+	if secrets != nil {
+		secrets.ShareOpenSearch(hot)
+		secrets.ShareQuickwit(def)
+	}
+	cold := backend.NewQuickwitRouter(def, clusters, cfg.QuickwitClusterForIndex)
+
+	searchHot := func(ctx context.Context, q bench.Query) error {
+		_, err := hot.Search(ctx, q.Index, q.Body)
+		return err
+	}
+	searchCold := func(ctx context.Context, q bench.Query) error {
+		_, err := cold.Search(ctx, cfg.QuickwitIndexForIndex(q.Index), q.Body)
+		return err
+	}
+	return func(ctx context.Context, q bench.Query) error {
+		switch q.Class {
+		case bench.ClassHot:
+			return searchHot(ctx, q)
+		case bench.ClassCold:
+			return searchCold(ctx, q)
+		}
+		var (
+			wg      sync.WaitGroup
+			hotErr  error
+			coldErr error
+		)
+		wg.Add(2)
+		go func() { defer wg.Done(); hotErr = searchHot(ctx, q) }()
+		go func() { defer wg.Done(); coldErr = searchCold(ctx, q) }()
+		wg.Wait()
+		return errors.Join(hotErr, coldErr)
+	}, nil
+}
+
+// benchFail prints an error for the bench command and returns exit code 1.
+func benchFail(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	return 1
+}
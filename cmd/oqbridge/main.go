@@ -10,63 +10,237 @@ import (
 	"syscall"
 	"time"
 
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
 	"github.com/leonunix/oqbridge/internal/backend"
 	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/control"
+	"github.com/leonunix/oqbridge/internal/dashboard"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/preflight"
 	"github.com/leonunix/oqbridge/internal/proxy"
 	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+
+	"google.golang.org/grpc"
 )
 
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	configPath := flag.String("config", "oqbridge.yaml", "path to configuration file")
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	configPath := flag.String("config", "oqbridge.yaml", "path to configuration file (.yaml, .json or .toml); empty to configure from environment variables only")
+	validateConfig := flag.Bool("validate-config", false, "validate the configuration, probe both backends and exit")
+	printDefaults := flag.Bool("print-defaults", false, "print every configuration key with its default value and exit")
+	overrides := config.Overrides{}
+	overrides.Flag(flag.CommandLine, "profile", "profile", "entry of profiles to apply, e.g. prod")
+	overrides.Flag(flag.CommandLine, "listen", "server.listen", "address to listen on")
+	overrides.Flag(flag.CommandLine, "retention-days", "retention.days", "days of data kept in OpenSearch (e.g. 30 or 30d)")
 	flag.Parse()
 
-	cfg, err := config.Load(*configPath)
+	if *printDefaults {
+		if err := config.WriteDefaults(os.Stdout); err != nil {
+			slog.Error("failed to write default configuration", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *validateConfig {
+		report := preflight.Run(context.Background(), *configPath, preflight.Options{UserAgent: "oqbridge/" + version, Overrides: overrides})
+		report.WriteText(os.Stdout)
+		if !report.OK() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.LoadWithOverrides(*configPath, overrides)
 	if err != nil {
 		slog.Error("failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	watcher := config.NewWatcher(*configPath, cfg)
 
 	util.SetupLogger(cfg.Logging.Level)
+	cfg.SetDefaultUserAgent("oqbridge/" + version)
 
 	slog.Info("oqbridge proxy starting",
 		"listen", cfg.Server.Listen,
 		"opensearch", cfg.OpenSearch.URL,
 		"quickwit", cfg.Quickwit.URL,
 		"retention_days", cfg.Retention.Days,
+		"profile", cfg.Profile,
 	)
 
-	osClient, err := util.NewHTTPClient(cfg.OpenSearch.TLSConfig)
+	secrets, err := vault.Load(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to load credentials from vault", "error", err)
+		os.Exit(1)
+	}
+
+	// chaos injects faults in front of the backends, above retries, so
+	// each one reaches the proxy's own error handling.
+	var hotFaults, coldFaults config.FaultConfig
+	if cfg.Chaos.Enabled {
+		hotFaults, coldFaults = cfg.Chaos.Hot, cfg.Chaos.Cold
+		slog.Warn("chaos enabled: injecting faults into backend requests; do not use in production",
+			"hot", hotFaults, "cold", coldFaults)
+	}
+
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
 	if err != nil {
 		slog.Error("failed to create OpenSearch HTTP client", "error", err)
 		os.Exit(1)
 	}
-	qwClient, err := util.NewHTTPClient(cfg.Quickwit.TLSConfig)
+	osClient.Transport = util.NewFaultTransport(osClient.Transport, "opensearch", hotFaults)
+	hotBackend := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	defaultCold, err := newQuickwit(cfg.Quickwit, coldFaults)
 	if err != nil {
 		slog.Error("failed to create Quickwit HTTP client", "error", err)
 		os.Exit(1)
 	}
+	if cfg.Quickwit.SearchAPI != backend.SearchAPIPassthrough {
+		slog.Info("quickwit search api", "mode", cfg.Quickwit.SearchAPI)
+	}
+	clusters := make(map[string]*backend.Quickwit, len(cfg.QuickwitClusters))
+	for _, c := range cfg.QuickwitClusters {
+		if clusters[c.Name], err = newQuickwit(c.Quickwit, coldFaults); err != nil {
+			slog.Error("failed to create Quickwit HTTP client", "cluster", c.Name, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("quickwit cluster", "name", c.Name, "url", c.Quickwit.URL, "indices", c.Indices)
+	}
+	coldBackend := backend.NewQuickwitRouter(defaultCold, clusters, cfg.QuickwitClusterForIndex)
 
-	hotBackend := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
-	coldBackend := backend.NewQuickwit(cfg.Quickwit.URL, cfg.Quickwit.Username, cfg.Quickwit.Password, false, qwClient)
+	// _oqbridge/stats reports when indices were last migrated from the
+	// state index oqbridge-migrate keeps in OpenSearch; local checkpoint
+	// files are out of its reach.
+	var watermarks *migration.OpenSearchCheckpointStore
+	if cfg.Migration.CheckpointDir == "" {
+		watermarks = migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	}
+	// _oqbridge/timeline lists the runs oqbridge-migrate records there.
+	runs := migration.NewOpenSearchMetricsStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	// Retention changed through _oqbridge/retention is kept in the state
+	// index even with migration.checkpoint_dir, as every instance reads it.
+	var retentionStore *migration.OpenSearchCheckpointStore
+	if cfg.Retention.Runtime.Enabled {
+		retentionStore = migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	}
+
+	if secrets != nil {
+		secrets.ShareOpenSearch(hotBackend, runs)
+		if watermarks != nil {
+			secrets.ShareOpenSearch(watermarks)
+		}
+		if retentionStore != nil {
+			secrets.ShareOpenSearch(retentionStore)
+		}
+		secrets.ShareQuickwit(defaultCold)
+		go secrets.Run(context.Background())
+	}
+
+	// Start with the retention in effect on the other instances.
+	var retentionSync *migration.RetentionSync
+	if retentionStore != nil {
+		retentionSync = migration.NewRetentionSync(retentionStore, cfg.Retention.Runtime, watcher)
+		if err := retentionSync.Sync(context.Background()); err != nil {
+			slog.Warn("failed to load the runtime retention, starting with the configured one", "error", err)
+		}
+		cfg = watcher.Config()
+	}
+	// Capabilities and search_api "auto" follow the version each cluster
+	// reports.
+	coldBackend.WatchFeatures(context.Background(), cfg.Quickwit.DetectInterval)
 
-	// Build a custom transport for the reverse proxy (shares TLS settings with OpenSearch).
-	osTransport, err := util.NewTLSTransport(cfg.OpenSearch.TLSConfig)
+	// Build a custom transport for the reverse proxy (shares TLS and signing settings with OpenSearch).
+	osTransport, err := util.NewOpenSearchTransport(cfg.OpenSearch)
 	if err != nil {
-		slog.Error("failed to create OpenSearch TLS transport", "error", err)
+		slog.Error("failed to create OpenSearch transport", "error", err)
 		os.Exit(1)
 	}
+	osTransport = util.NewFaultTransport(osTransport, "opensearch", hotFaults)
+	if cfg.OpenSearch.SigV4.Enabled {
+		slog.Warn("signing OpenSearch requests with AWS SigV4; client credentials are not forwarded, so every request runs as the signing AWS identity",
+			"service", cfg.OpenSearch.SigV4.Service)
+	}
+
+	var opts []proxy.Option
+	for _, t := range cfg.Tiers {
+		client, err := util.NewOpenSearchClient(t.OpenSearch)
+		if err != nil {
+			slog.Error("failed to create OpenSearch HTTP client", "tier", t.Name, "error", err)
+			os.Exit(1)
+		}
+		client.Transport = util.NewFaultTransport(client.Transport, "opensearch", hotFaults)
+		opts = append(opts, proxy.WithTier(t.Name, backend.NewOpenSearch(t.OpenSearch.URL, t.OpenSearch.Username, t.OpenSearch.Password, client)))
+		slog.Info("opensearch tier", "name", t.Name, "url", t.OpenSearch.URL, "days", t.Days, "on_error", t.OnError)
+	}
+
+	for _, rc := range cfg.RemoteClusters {
+		if rc.QuickwitCluster != "" {
+			opts = append(opts, proxy.WithRemoteCluster(rc.Name, clusters[rc.QuickwitCluster]))
+		}
+		slog.Info("remote cluster", "name", rc.Name, "quickwit_cluster", rc.QuickwitCluster, "index_prefix", rc.IndexPrefix)
+	}
+
+	if watermarks != nil {
+		opts = append(opts, proxy.WithWatermarks(watermarks))
+	}
+	opts = append(opts, proxy.WithMigrationRuns(runs))
+	if retentionSync != nil {
+		opts = append(opts, proxy.WithRetentionScheduler(retentionSync))
+	}
 
-	p, err := proxy.New(cfg, hotBackend, coldBackend, osTransport)
+	p, err := proxy.New(cfg, hotBackend, coldBackend, osTransport, opts...)
 	if err != nil {
 		slog.Error("failed to initialize proxy", "error", err)
 		os.Exit(1)
 	}
 
+	// Apply edits to the configuration file without a restart.
+	watcher.OnReload(func(cfg *config.Config) {
+		util.SetupLogger(cfg.Logging.Level)
+		p.SetConfig(cfg)
+	})
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go watcher.Run(context.Background(), hup)
+	if retentionSync != nil {
+		go retentionSync.Run(context.Background())
+	}
+
 	server := &http.Server{
 		Addr:    cfg.Server.Listen,
 		Handler: p,
 	}
 
+	var metricsServer *http.Server
+	if cfg.Server.MetricsListen != "" {
+		var extra map[string]http.Handler
+		if cfg.Dashboard.Enabled {
+			extra = map[string]http.Handler{"/ui/": http.StripPrefix("/ui", dashboard.Handler(cfg.Dashboard, p.DashboardStatus))}
+			slog.Info("dashboard enabled", "addr", cfg.Server.MetricsListen, "path", "/ui/")
+		}
+		metricsServer = util.ServeMetrics(cfg.Server.MetricsListen, extra)
+	} else if cfg.Dashboard.Enabled {
+		slog.Warn("dashboard.enabled has no effect without server.metrics_listen")
+	}
+
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCListen != "" {
+		grpcServer = control.NewServer(cfg.GRPC.Token)
+		oqbridgev1.RegisterRoutingServiceServer(grpcServer, control.NewRoutingService(p))
+		control.Serve(cfg.Server.GRPCListen, grpcServer)
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
@@ -86,6 +260,32 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("server shutdown error", "error", err)
 	}
+	if err := p.Close(ctx); err != nil {
+		slog.Error("dual-write queue not fully sent to quickwit", "error", err)
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(ctx)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
 
 	slog.Info("oqbridge stopped")
 }
+
+// newQuickwit creates the search client of a Quickwit cluster, injecting
+// faults into its requests.
+func newQuickwit(qc config.QuickwitConfig, faults config.FaultConfig) (*backend.Quickwit, error) {
+	client, err := util.NewQuickwitClient(qc)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = util.NewFaultTransport(client.Transport, "quickwit", faults)
+	q := backend.NewQuickwit(qc.URL, qc.Username, qc.Password, false, client)
+	q.SetAuth(qc.Auth.BearerToken, qc.Auth.Headers)
+	q.SetSearchAPI(qc.SearchAPI)
+	if qc.ListCacheTTL > 0 {
+		q.SetListCacheTTL(qc.ListCacheTTL)
+	}
+	return q, nil
+}
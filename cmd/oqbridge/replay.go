@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/capture"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/proxy"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// runReplay replays searches recorded by server.capture: it routes them
+// with -config and, given -url, runs them against a proxy to compare their
+// total hits. It exits with 1 if any search is routed differently, finds
+// other hits or fails.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := fs.String("config", "oqbridge.yaml", "configuration the searches are routed by (.yaml, .json or .toml); empty to configure from environment variables only")
+	capturePath := fs.String("capture", "", "file recorded by server.capture")
+	proxyURL := fs.String("url", "", "URL of the proxy to run the searches against and compare total hits; empty only compares routes")
+	baselineURL := fs.String("baseline-url", "", "URL of the proxy whose total hits are expected (default: the captured totals)")
+	user := fs.String("user", "", "user name for basic authentication against the proxies")
+	password := fs.String("password", "", "password for basic authentication against the proxies")
+	var headers headerList
+	fs.Var(&headers, "header", `header sent to the proxies, as "Name: value" (repeatable)`)
+	timeout := fs.Duration("timeout", 60*time.Second, "timeout of each search")
+	jsonOut := fs.Bool("json", false, "print the differences as JSON")
+	fs.Parse(args)
+
+	if *capturePath == "" {
+		return benchFail("-capture is required")
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return benchFail("loading configuration: %v", err)
+	}
+	util.SetupLoggerOutput(cfg.Logging.Level, os.Stderr)
+	cfg.SetDefaultUserAgent("oqbridge/" + version)
+
+	// The proxy only routes here; its backends are never contacted.
+	cfg.Server.Capture.Enabled = false
+	cold := backend.NewQuickwitRouter(backend.NewQuickwit(cfg.Quickwit.URL, "", "", false, nil), nil, cfg.QuickwitClusterForIndex)
+	var opts []proxy.Option
+	for _, t := range cfg.Tiers {
+		opts = append(opts, proxy.WithTier(t.Name, backend.NewOpenSearch(t.OpenSearch.URL, "", "", nil)))
+	}
+	p, err := proxy.New(cfg, backend.NewOpenSearch(cfg.OpenSearch.URL, "", "", nil), cold, nil, opts...)
+	if err != nil {
+		return benchFail("%v", err)
+	}
+	defer p.Close(context.Background())
+
+	ro := capture.ReplayOptions{
+		Route: func(rec capture.Record) string {
+			route, _ := p.RouteSearchAt(rec.Indices, rec.Body, rec.Time)
+			return route
+		},
+	}
+	if *proxyURL != "" {
+		ro.Search = replaySearch(strings.TrimSuffix(*proxyURL, "/"), *user, *password, headers, *timeout)
+	}
+	if *baselineURL != "" {
+		ro.Baseline = replaySearch(strings.TrimSuffix(*baselineURL, "/"), *user, *password, headers, *timeout)
+	}
+
+	f, err := os.Open(*capturePath)
+	if err != nil {
+		return benchFail("%v", err)
+	}
+	defer f.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	res, err := capture.Replay(ctx, f, ro)
+	if err != nil {
+		return benchFail("replaying %s: %v", *capturePath, err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+	} else {
+		res.WriteText(os.Stdout)
+	}
+	if len(res.Diffs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// replaySearch runs captured searches against the proxy at base and
+// returns their total hits.
+func replaySearch(base, user, password string, headers headerList, timeout time.Duration) func(context.Context, capture.Record) (int64, error) {
+	client := &http.Client{Timeout: timeout}
+	return func(ctx context.Context, rec capture.Record) (int64, error) {
+		u := base + rec.Path
+		if rec.Query != "" {
+			u += "?" + rec.Query
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(rec.Body))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for _, h := range headers {
+			name, value, _ := strings.Cut(h, ":")
+			req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode >= 300 {
+			return 0, fmt.Errorf("status %d", resp.StatusCode)
+		}
+		total, ok := capture.Total(resp.Header, body)
+		if !ok {
+			return 0, fmt.Errorf("response has no hits.total")
+		}
+		return total, nil
+	}
+}
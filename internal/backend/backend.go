@@ -28,6 +28,9 @@ type HitsTotal struct {
 	Relation string `json:"relation"`
 }
 
+// rangeLayout formats timestamps in range queries sent to either backend.
+const rangeLayout = "2006-01-02T15:04:05.000Z07:00"
+
 // ScrollResult contains a batch of documents from a scroll operation.
 type ScrollResult struct {
 	ScrollID string
@@ -35,20 +38,58 @@ type ScrollResult struct {
 	Total    int
 }
 
-// Backend defines the interface for search backends (OpenSearch, Quickwit).
+// Capabilities reports which optional features a backend supports, so the
+// proxy and migrator can adapt to it instead of switching on its type.
+type Capabilities struct {
+	SupportsScroll       bool // implements Scroller
+	SupportsAggregations bool // Search returns aggregation results
+	SupportsSort         bool // Search honors an explicit sort
+	SupportsMultiSearch  bool // implements MultiSearcher
+}
+
+// Backend is the set of operations every search backend (OpenSearch,
+// Quickwit) provides. Optional operations are separate interfaces,
+// advertised through Capabilities.
 type Backend interface {
+	// Name returns the backend name for logging purposes.
+	Name() string
+
+	// Capabilities reports the optional features of the backend as it is
+	// currently configured.
+	Capabilities() Capabilities
+
 	// Search executes a search query against the given index.
 	Search(ctx context.Context, index string, body []byte) (*SearchResponse, error)
 
-	// Scroll initiates or continues a scroll query for bulk reading.
-	Scroll(ctx context.Context, index string, body []byte, scrollID string) (*ScrollResult, error)
-
-	// ClearScroll releases server-side scroll resources.
-	ClearScroll(ctx context.Context, scrollID string) error
+	// Count returns the number of documents in index matching the query of
+	// body.
+	Count(ctx context.Context, index string, body []byte) (int64, error)
 
 	// BulkIngest sends a batch of documents to the backend.
 	BulkIngest(ctx context.Context, index string, docs []json.RawMessage) error
+}
 
-	// Name returns the backend name for logging purposes.
-	Name() string
+// Scroller reads a whole index in batches. Backends whose Capabilities
+// report SupportsScroll implement it.
+type Scroller interface {
+	// SlicedScroll initiates (empty scrollID) or continues a scroll query,
+	// optionally restricted to one slice of the index.
+	SlicedScroll(ctx context.Context, index string, body []byte, scrollID string, slice *SlicedScrollConfig) (*ScrollResult, error)
+
+	// ClearScroll releases server-side scroll resources.
+	ClearScroll(ctx context.Context, scrollID string) error
+}
+
+// MultiSearcher runs one search body against several indices in a single
+// request. Backends whose Capabilities report SupportsMultiSearch implement
+// it.
+type MultiSearcher interface {
+	MultiSearch(ctx context.Context, indices []string, body []byte) ([]*SearchResponse, error)
 }
+
+var (
+	_ Backend       = (*OpenSearch)(nil)
+	_ Scroller      = (*OpenSearch)(nil)
+	_ Backend       = (*Quickwit)(nil)
+	_ MultiSearcher = (*Quickwit)(nil)
+)
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer caps the size of buffers kept for reuse, so a single
+// oversized batch doesn't pin its memory for the life of the process.
+const maxPooledBuffer = 64 << 20
+
+// ndjsonPool recycles the buffers that in-memory ingest bodies are built in.
+// Consecutive batches are similar in size, so reuse avoids regrowing a
+// multi-megabyte buffer for every batch.
+var ndjsonPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	buf := ndjsonPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	ndjsonPool.Put(buf)
+}
+
+// pooledBody is a request body backed by a pooled buffer. The buffer goes
+// back to the pool only when the transport closes the body, since the
+// transport may still be reading it after Do returns.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{buf: buf}
+}
+
+func (p *pooledBody) Read(b []byte) (int, error) { return p.buf.Read(b) }
+
+// Len reports the unread length so the request can carry a Content-Length.
+func (p *pooledBody) Len() int { return p.buf.Len() }
+
+func (p *pooledBody) Close() error {
+	p.once.Do(func() { putBuffer(p.buf) })
+	return nil
+}
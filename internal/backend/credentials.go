@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Credentials are the service account credentials a client authenticates
+// with. They can be replaced while requests are in flight, so clients that
+// share one Credentials pick up rotated secrets together.
+type Credentials struct {
+	mu          sync.RWMutex
+	username    string
+	password    string
+	bearerToken string
+}
+
+// NewCredentials returns basic auth credentials. An empty username sends
+// requests without authentication.
+func NewCredentials(username, password string) *Credentials {
+	return &Credentials{username: username, password: password}
+}
+
+// SetBasic replaces the basic auth username and password.
+func (c *Credentials) SetBasic(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.username = username
+	c.password = password
+}
+
+// SetBearerToken replaces the bearer token. A non-empty token takes the
+// place of basic auth.
+func (c *Credentials) SetBearerToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bearerToken = token
+}
+
+// Apply sets the Authorization header of req from the current credentials.
+func (c *Credentials) Apply(req *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+}
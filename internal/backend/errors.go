@@ -23,3 +23,29 @@ func (e *HTTPStatusError) Error() string {
 	}
 	return fmt.Sprintf("http %s returned status %d: %s", e.URL, e.StatusCode, e.Body)
 }
+
+// BulkItemError describes one document rejected by a _bulk request.
+type BulkItemError struct {
+	Position int    // position of the document in the submitted batch
+	Status   int    // per-item HTTP status
+	Type     string // OpenSearch error type, e.g. "mapper_parsing_exception"
+	Reason   string
+}
+
+// BulkPartialError is returned when a _bulk request succeeds at the HTTP
+// level but OpenSearch rejects some of its documents. The remaining
+// documents were indexed.
+type BulkPartialError struct {
+	Index  string
+	Total  int
+	Failed []BulkItemError
+}
+
+func (e *BulkPartialError) Error() string {
+	if e == nil || len(e.Failed) == 0 {
+		return "<nil>"
+	}
+	first := e.Failed[0]
+	return fmt.Sprintf("bulk ingest into %s: %d of %d documents failed, first at position %d: status %d %s: %s",
+		e.Index, len(e.Failed), e.Total, first.Position, first.Status, first.Type, first.Reason)
+}
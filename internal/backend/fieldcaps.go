@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// FieldCaps is a response of the _field_caps API: the types of the fields
+// of some indices and how they can be used.
+type FieldCaps struct {
+	Indices []string                              `json:"indices"`
+	Fields  map[string]map[string]FieldCapability `json:"fields"`
+}
+
+// FieldCapability describes a field of one type. Indices lists the indices
+// the field has this type in when it has several types.
+type FieldCapability struct {
+	Type                   string   `json:"type"`
+	MetadataField          bool     `json:"metadata_field,omitempty"`
+	Searchable             bool     `json:"searchable"`
+	Aggregatable           bool     `json:"aggregatable"`
+	Indices                []string `json:"indices,omitempty"`
+	NonSearchableIndices   []string `json:"non_searchable_indices,omitempty"`
+	NonAggregatableIndices []string `json:"non_aggregatable_indices,omitempty"`
+}
+
+// FieldCaps calls the _field_caps API at path, e.g. "/logs-*/_field_caps",
+// forwarding the given incoming headers. If incomingHeader is nil, falls
+// back to service account credentials.
+func (o *OpenSearch) FieldCaps(ctx context.Context, path, rawQuery string, incomingHeader http.Header) (*FieldCaps, error) {
+	u := o.baseURL + path
+	if rawQuery != "" {
+		u += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating field caps request: %w", err)
+	}
+	if incomingHeader != nil {
+		copyIncomingHeaders(req.Header, incomingHeader)
+	} else {
+		o.setAuth(req)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing field caps request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading field caps response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, URL: u, Body: string(respBody)}
+	}
+
+	var caps FieldCaps
+	if err := json.Unmarshal(respBody, &caps); err != nil {
+		return nil, fmt.Errorf("decoding field caps response: %w", err)
+	}
+	return &caps, nil
+}
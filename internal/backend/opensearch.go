@@ -9,15 +9,16 @@ import (
 	"log/slog"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// OpenSearch implements the Backend interface for OpenSearch.
+// OpenSearch implements the Backend and Scroller interfaces for OpenSearch.
 type OpenSearch struct {
-	baseURL  string
-	username string
-	password string
-	client   *http.Client
+	baseURL string
+	creds   *Credentials
+	client  *http.Client
 }
 
 // NewOpenSearch creates a new OpenSearch backend client.
@@ -27,23 +28,71 @@ func NewOpenSearch(baseURL, username, password string, httpClient *http.Client)
 		httpClient = &http.Client{}
 	}
 	return &OpenSearch{
-		baseURL:  baseURL,
-		username: username,
-		password: password,
-		client:   httpClient,
+		baseURL: baseURL,
+		creds:   NewCredentials(username, password),
+		client:  httpClient,
 	}
 }
 
+// SetCredentials replaces the service account credentials with c, which may
+// be shared with other clients and updated while in use.
+func (o *OpenSearch) SetCredentials(c *Credentials) {
+	o.creds = c
+}
+
+// Credentials returns the service account credentials.
+func (o *OpenSearch) Credentials() *Credentials {
+	return o.creds
+}
+
 func (o *OpenSearch) Name() string { return "opensearch" }
 
+// Capabilities reports scroll, aggregation and sort support. Multi-index
+// searches are issued as one OpenSearch request with a comma-separated
+// index list, so MultiSearch is not needed.
+func (o *OpenSearch) Capabilities() Capabilities {
+	return Capabilities{
+		SupportsScroll:       true,
+		SupportsAggregations: true,
+		SupportsSort:         true,
+	}
+}
+
+// AuthInfo identifies the user of a request, as reported by the security
+// plugin.
+type AuthInfo struct {
+	UserName     string   `json:"user_name"`
+	Roles        []string `json:"roles"`
+	BackendRoles []string `json:"backend_roles"`
+}
+
 // Authenticate validates the given credentials against OpenSearch's _security/authinfo.
 // All incoming headers (Authorization, x-proxy-user, etc.) are forwarded so that both
 // basic auth and proxy auth modes work. Returns nil if auth succeeds, error otherwise.
 func (o *OpenSearch) Authenticate(ctx context.Context, incomingHeader http.Header) error {
+	_, err := o.authinfo(ctx, incomingHeader)
+	return err
+}
+
+// AuthInfo authenticates like Authenticate and returns the user's roles.
+func (o *OpenSearch) AuthInfo(ctx context.Context, incomingHeader http.Header) (*AuthInfo, error) {
+	b, err := o.authinfo(ctx, incomingHeader)
+	if err != nil {
+		return nil, err
+	}
+	var info AuthInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("decoding authinfo response: %w", err)
+	}
+	return &info, nil
+}
+
+// authinfo returns the body of _security/authinfo for incomingHeader.
+func (o *OpenSearch) authinfo(ctx context.Context, incomingHeader http.Header) ([]byte, error) {
 	endpoint := o.baseURL + "/_plugins/_security/authinfo"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("creating auth request: %w", err)
+		return nil, fmt.Errorf("creating auth request: %w", err)
 	}
 	if incomingHeader != nil {
 		copyIncomingHeaders(req.Header, incomingHeader)
@@ -51,19 +100,86 @@ func (o *OpenSearch) Authenticate(ctx context.Context, incomingHeader http.Heade
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("auth request failed: %w", err)
+		return nil, fmt.Errorf("auth request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	b, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(resp.Body)
-		return &HTTPStatusError{
+		return nil, &HTTPStatusError{
 			StatusCode: resp.StatusCode,
 			URL:        endpoint,
 			Body:       string(b),
 		}
 	}
-	return nil
+	return b, nil
+}
+
+// Info returns the body of OpenSearch's "/" endpoint, with its cluster
+// name and version, as the user of incomingHeader sees it.
+func (o *OpenSearch) Info(ctx context.Context, incomingHeader http.Header) (map[string]any, error) {
+	endpoint := o.baseURL + "/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating info request: %w", err)
+	}
+	if incomingHeader != nil {
+		copyIncomingHeaders(req.Header, incomingHeader)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("info request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        endpoint,
+			Body:       string(b),
+		}
+	}
+	var info map[string]any
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("decoding info response: %w", err)
+	}
+	return info, nil
+}
+
+// GetAs sends a GET request for path, e.g. "/logs/_mapping", and returns
+// the response body. If incomingHeader is non-nil, its headers are
+// forwarded; otherwise the service account is used.
+func (o *OpenSearch) GetAs(ctx context.Context, path, rawQuery string, incomingHeader http.Header) ([]byte, error) {
+	u := o.baseURL + path
+	if rawQuery != "" {
+		u += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if incomingHeader != nil {
+		copyIncomingHeaders(req.Header, incomingHeader)
+	} else {
+		o.setAuth(req)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, URL: u, Body: string(respBody)}
+	}
+	return respBody, nil
 }
 
 func (o *OpenSearch) Search(ctx context.Context, index string, body []byte) (*SearchResponse, error) {
@@ -337,6 +453,171 @@ func (o *OpenSearch) BulkIngest(ctx context.Context, index string, docs []json.R
 	}
 	defer resp.Body.Close()
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading bulk response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	return parseBulkResponse(index, len(docs), respBody)
+}
+
+// BulkIndexHits writes search hits into index under their original _id,
+// replacing documents with the same ID, and refreshes the index. Unlike
+// BulkIngest, which lets OpenSearch assign IDs to migrated documents, it
+// restores documents other code looks up by ID.
+func (o *OpenSearch) BulkIndexHits(ctx context.Context, index string, hits []json.RawMessage) error {
+	var buf bytes.Buffer
+	for _, raw := range hits {
+		var hit struct {
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		}
+		if err := json.Unmarshal(raw, &hit); err != nil {
+			return fmt.Errorf("parsing hit: %w", err)
+		}
+		if hit.ID == "" || len(hit.Source) == 0 {
+			return fmt.Errorf("hit without _id or _source")
+		}
+		action, _ := json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": index, "_id": hit.ID},
+		})
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(hit.Source)
+		buf.WriteByte('\n')
+	}
+
+	url := fmt.Sprintf("%s/_bulk?refresh=true", o.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return fmt.Errorf("creating bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing bulk request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading bulk response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	return parseBulkResponse(index, len(hits), respBody)
+}
+
+// parseBulkResponse returns a *BulkPartialError if a _bulk response reports
+// per-item failures. _bulk answers 200 even when every item failed.
+func parseBulkResponse(index string, total int, body []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	partial := &BulkPartialError{Index: index, Total: total}
+	for i, item := range result.Items {
+		// Each item has a single key named after its action ("index").
+		for _, r := range item {
+			if r.Error == nil && r.Status < 300 {
+				continue
+			}
+			itemErr := BulkItemError{Position: i, Status: r.Status}
+			if r.Error != nil {
+				itemErr.Type, itemErr.Reason = r.Error.Type, r.Error.Reason
+			}
+			partial.Failed = append(partial.Failed, itemErr)
+		}
+	}
+	if len(partial.Failed) == 0 {
+		return nil
+	}
+	slog.Warn("opensearch rejected documents in bulk request",
+		"index", index, "failed", len(partial.Failed), "total", total,
+		"first_type", partial.Failed[0].Type, "first_reason", partial.Failed[0].Reason)
+	return partial
+}
+
+// IndexExists reports whether index exists, so OpenSearch can receive
+// migrated documents like Quickwit.
+func (o *OpenSearch) IndexExists(ctx context.Context, index string) (bool, error) {
+	url := fmt.Sprintf("%s/%s", o.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("creating index exists request: %w", err)
+	}
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("executing index exists request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode >= 400 {
+		return false, &HTTPStatusError{StatusCode: resp.StatusCode, URL: url}
+	}
+	return true, nil
+}
+
+// CreateIndex creates index with timestampField mapped as a date; other
+// fields are mapped dynamically or by the cluster's index templates.
+// retentionDays is ignored: OpenSearch indices are dropped by ISM policies.
+func (o *OpenSearch) CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				timestampField: map[string]string{"type": "date"},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling index settings: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s", o.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating create index request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing create index request: %w", err)
+	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode >= 400 {
 		respBody, _ := io.ReadAll(resp.Body)
 		return &HTTPStatusError{
@@ -348,6 +629,30 @@ func (o *OpenSearch) BulkIngest(ctx context.Context, index string, docs []json.R
 	return nil
 }
 
+// IndexMappings returns the mappings of index.
+func (o *OpenSearch) IndexMappings(ctx context.Context, index string) (json.RawMessage, error) {
+	var resp map[string]struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := o.getJSON(ctx, "/"+index+"/_mapping", &resp); err != nil {
+		return nil, fmt.Errorf("fetching mappings of %s: %w", index, err)
+	}
+	return resp[index].Mappings, nil
+}
+
+// CreateIndexWithMappings creates index with the given mappings, e.g. as
+// returned by IndexMappings.
+func (o *OpenSearch) CreateIndexWithMappings(ctx context.Context, index string, mappings json.RawMessage) error {
+	body, err := json.Marshal(map[string]json.RawMessage{"mappings": mappings})
+	if err != nil {
+		return fmt.Errorf("marshaling index settings: %w", err)
+	}
+	if err := o.sendJSON(ctx, http.MethodPut, "/"+index, body, nil); err != nil {
+		return fmt.Errorf("creating index %s: %w", index, err)
+	}
+	return nil
+}
+
 // DeleteByQuery deletes documents matching the given query from the index.
 func (o *OpenSearch) DeleteByQuery(ctx context.Context, index string, body []byte) error {
 	url := fmt.Sprintf("%s/%s/_delete_by_query", o.baseURL, index)
@@ -375,6 +680,161 @@ func (o *OpenSearch) DeleteByQuery(ctx context.Context, index string, body []byt
 	return nil
 }
 
+// CountRange returns the number of documents in index whose tsField lies in
+// the inclusive range [from, to], using the service account.
+func (o *OpenSearch) CountRange(ctx context.Context, index, tsField string, from, to time.Time) (int64, error) {
+	body, err := rangeCountBody(tsField, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return o.Count(ctx, index, body)
+}
+
+// Count returns the number of documents in index matching the query in body,
+// using the _count API and the service account. An empty body counts every
+// document. Only the body's "query" is sent, so a search body can be passed
+// as-is.
+func (o *OpenSearch) Count(ctx context.Context, index string, body []byte) (int64, error) {
+	body, err := countBody(body)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := o.CountRaw(ctx, "/"+index+"/_count", "", body, nil)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// CountResponse is the response of the _count API.
+type CountResponse struct {
+	Count  int64           `json:"count"`
+	Shards json.RawMessage `json:"_shards,omitempty"`
+}
+
+// CountRaw executes a _count request against an explicit path and query
+// string, e.g. "/{index}/_count", sending body as-is. If incomingHeader is
+// non-nil, its headers are forwarded to the backend like in SearchRaw;
+// otherwise the service account is used.
+func (o *OpenSearch) CountRaw(ctx context.Context, path, rawQuery string, body []byte, incomingHeader http.Header) (*CountResponse, error) {
+	u := o.baseURL + path
+	if rawQuery != "" {
+		u += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating count request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if incomingHeader != nil {
+		copyIncomingHeaders(req.Header, incomingHeader)
+	} else {
+		o.setAuth(req)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing count request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading count response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        u,
+			Body:       string(respBody),
+		}
+	}
+
+	var result CountResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decoding count response: %w", err)
+	}
+	return &result, nil
+}
+
+// countBody reduces a search or count body to its query, which is all the
+// _count API accepts. An empty body or one without a query matches all
+// documents.
+func countBody(body []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return []byte(`{}`), nil
+	}
+	var req struct {
+		Query json.RawMessage `json:"query"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("decoding count body: %w", err)
+	}
+	if len(req.Query) == 0 {
+		return []byte(`{}`), nil
+	}
+	return json.Marshal(map[string]json.RawMessage{"query": req.Query})
+}
+
+// rangeCountBody returns a count body matching the documents whose tsField
+// lies in the inclusive range [from, to].
+func rangeCountBody(tsField string, from, to time.Time) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				tsField: map[string]string{
+					"gte": from.UTC().Format(rangeLayout),
+					"lte": to.UTC().Format(rangeLayout),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling count query: %w", err)
+	}
+	return body, nil
+}
+
+// SampleRange returns the _source of up to n randomly chosen documents in
+// index whose tsField lies in the inclusive range [from, to].
+func (o *OpenSearch) SampleRange(ctx context.Context, index, tsField string, from, to time.Time, n int) ([]json.RawMessage, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"size": n,
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": map[string]interface{}{
+					"range": map[string]interface{}{
+						tsField: map[string]string{
+							"gte": from.UTC().Format(rangeLayout),
+							"lte": to.UTC().Format(rangeLayout),
+						},
+					},
+				},
+				"random_score": map[string]interface{}{},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling sample query: %w", err)
+	}
+	resp, err := o.Search(ctx, index, body)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]json.RawMessage, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		var h struct {
+			Source json.RawMessage `json:"_source"`
+		}
+		if err := json.Unmarshal(hit, &h); err != nil {
+			return nil, fmt.Errorf("parsing sample hit: %w", err)
+		}
+		docs = append(docs, h.Source)
+	}
+	return docs, nil
+}
+
 // opensearchSystemPrefixes lists index name prefixes that are managed by
 // OpenSearch itself (security, query insights, etc.) and should never be
 // migrated to Quickwit.
@@ -395,12 +855,24 @@ func isSystemIndex(name string) bool {
 	return false
 }
 
+// IndexInfo describes a concrete index as reported by _cat/indices.
+// DocsCount and StoreSizeBytes are -1 when OpenSearch does not report them,
+// as for closed indices.
+type IndexInfo struct {
+	Name           string
+	Health         string // "green", "yellow" or "red"; empty for closed indices.
+	CreationDate   time.Time
+	DocsCount      int64
+	StoreSizeBytes int64
+}
+
 // ResolveIndices expands a wildcard pattern (e.g., "logs-*" or "*") to
-// concrete index names by querying OpenSearch's _cat/indices API.
-// Internal indices (starting with ".") and OpenSearch system indices
+// concrete indices by querying OpenSearch's _cat/indices API, returning each
+// index's health, creation date, document count and store size sorted by
+// name. Internal indices (starting with ".") and OpenSearch system indices
 // (security-auditlog-*, top_queries-*, etc.) are filtered out.
-func (o *OpenSearch) ResolveIndices(ctx context.Context, pattern string) ([]string, error) {
-	url := fmt.Sprintf("%s/_cat/indices/%s?format=json&h=index", o.baseURL, pattern)
+func (o *OpenSearch) ResolveIndices(ctx context.Context, pattern string) ([]IndexInfo, error) {
+	url := fmt.Sprintf("%s/_cat/indices/%s?format=json&bytes=b&h=index,health,creation.date,docs.count,store.size", o.baseURL, pattern)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating resolve indices request: %w", err)
@@ -426,26 +898,169 @@ func (o *OpenSearch) ResolveIndices(ctx context.Context, pattern string) ([]stri
 		}
 	}
 
+	// _cat reports every column as a string, or null for closed indices.
 	var entries []struct {
-		Index string `json:"index"`
+		Index        string  `json:"index"`
+		Health       *string `json:"health"`
+		CreationDate *string `json:"creation.date"`
+		DocsCount    *string `json:"docs.count"`
+		StoreSize    *string `json:"store.size"`
 	}
 	if err := json.Unmarshal(respBody, &entries); err != nil {
 		return nil, fmt.Errorf("decoding resolve indices response: %w", err)
 	}
 
-	var indices []string
+	var indices []IndexInfo
 	for _, e := range entries {
 		if isSystemIndex(e.Index) {
 			continue
 		}
-		indices = append(indices, e.Index)
+		info := IndexInfo{
+			Name:           e.Index,
+			DocsCount:      catInt(e.DocsCount),
+			StoreSizeBytes: catInt(e.StoreSize),
+		}
+		if e.Health != nil {
+			info.Health = *e.Health
+		}
+		if ms := catInt(e.CreationDate); ms > 0 {
+			info.CreationDate = time.UnixMilli(ms).UTC()
+		}
+		indices = append(indices, info)
 	}
-	sort.Strings(indices)
+	sort.Slice(indices, func(i, j int) bool { return indices[i].Name < indices[j].Name })
 	return indices, nil
 }
 
-func (o *OpenSearch) setAuth(req *http.Request) {
-	if o.username != "" {
-		req.SetBasicAuth(o.username, o.password)
+// catInt parses a numeric _cat column, returning -1 if it is missing.
+func catInt(v *string) int64 {
+	if v == nil {
+		return -1
+	}
+	n, err := strconv.ParseInt(*v, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// ClusterHealth summarizes OpenSearch cluster status and resource pressure.
+type ClusterHealth struct {
+	Status             string // "green", "yellow" or "red".
+	PendingTasks       int    // Number of cluster-level changes not yet executed.
+	MaxHeapUsedPercent int    // Highest JVM heap usage across all nodes.
+}
+
+// ClusterHealth reports the cluster status, pending task count and the highest
+// JVM heap usage across nodes using the service account.
+func (o *OpenSearch) ClusterHealth(ctx context.Context) (*ClusterHealth, error) {
+	var health struct {
+		Status       string `json:"status"`
+		PendingTasks int    `json:"number_of_pending_tasks"`
+	}
+	if err := o.getJSON(ctx, "/_cluster/health", &health); err != nil {
+		return nil, fmt.Errorf("fetching cluster health: %w", err)
+	}
+
+	var stats struct {
+		Nodes map[string]struct {
+			JVM struct {
+				Mem struct {
+					HeapUsedPercent int `json:"heap_used_percent"`
+				} `json:"mem"`
+			} `json:"jvm"`
+		} `json:"nodes"`
+	}
+	if err := o.getJSON(ctx, "/_nodes/stats/jvm", &stats); err != nil {
+		return nil, fmt.Errorf("fetching node stats: %w", err)
+	}
+
+	result := &ClusterHealth{
+		Status:       health.Status,
+		PendingTasks: health.PendingTasks,
+	}
+	for _, n := range stats.Nodes {
+		result.MaxHeapUsedPercent = max(result.MaxHeapUsedPercent, n.JVM.Mem.HeapUsedPercent)
+	}
+	return result, nil
+}
+
+// getJSON performs a GET request with the service account and decodes the
+// JSON response into out.
+func (o *OpenSearch) getJSON(ctx context.Context, path string, out interface{}) error {
+	url := o.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// sendJSON sends body (nil for none) with the service account and decodes
+// the JSON response into out unless it is nil.
+func (o *OpenSearch) sendJSON(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	url := o.baseURL + path
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
 	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (o *OpenSearch) setAuth(req *http.Request) {
+	o.creds.Apply(req)
 }
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// GetAliases returns every alias in the cluster mapped to the sorted names
+// of the indices it points at. Aliases on internal and system indices are
+// omitted.
+func (o *OpenSearch) GetAliases(ctx context.Context) (map[string][]string, error) {
+	var rows []struct {
+		Alias string `json:"alias"`
+		Index string `json:"index"`
+	}
+	if err := o.getJSON(ctx, "/_cat/aliases?format=json&h=alias,index", &rows); err != nil {
+		return nil, fmt.Errorf("listing aliases: %w", err)
+	}
+	aliases := make(map[string][]string)
+	for _, r := range rows {
+		if isSystemIndex(r.Alias) || isSystemIndex(r.Index) {
+			continue
+		}
+		aliases[r.Alias] = append(aliases[r.Alias], r.Index)
+	}
+	for _, indices := range aliases {
+		sort.Strings(indices)
+	}
+	return aliases, nil
+}
+
+// ResolveAlias returns the sorted names of the indices alias points at, or
+// nil if no alias of that name exists.
+func (o *OpenSearch) ResolveAlias(ctx context.Context, alias string) ([]string, error) {
+	// The response is keyed by backing index.
+	var resp map[string]struct {
+		Aliases map[string]struct{} `json:"aliases"`
+	}
+	if err := o.getJSON(ctx, "/_alias/"+alias, &resp); err != nil {
+		var httpErr *HTTPStatusError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("resolving alias %s: %w", alias, err)
+	}
+	var indices []string
+	for index, entry := range resp {
+		if _, ok := entry.Aliases[alias]; ok {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenSearch_GetAliases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/aliases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[
+			{"alias":"logs","index":"logs-b"},
+			{"alias":".kibana","index":".kibana_1"},
+			{"alias":"logs","index":"logs-a"},
+			{"alias":"recent","index":"logs-b"}
+		]`))
+	}))
+	defer srv.Close()
+
+	got, err := NewOpenSearch(srv.URL, "", "", nil).GetAliases(context.Background())
+	if err != nil {
+		t.Fatalf("GetAliases: %v", err)
+	}
+	want := map[string][]string{"logs": {"logs-a", "logs-b"}, "recent": {"logs-b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetAliases=%v, want %v", got, want)
+	}
+}
+
+func TestOpenSearch_ResolveAlias(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_alias/logs":
+			w.Write([]byte(`{"logs-b":{"aliases":{"logs":{}}},"logs-a":{"aliases":{"logs":{"is_write_index":false}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"alias [missing] missing","status":404}`))
+		}
+	}))
+	defer srv.Close()
+	os := NewOpenSearch(srv.URL, "", "", nil)
+
+	got, err := os.ResolveAlias(context.Background(), "logs")
+	if err != nil {
+		t.Fatalf("ResolveAlias: %v", err)
+	}
+	if want := []string{"logs-a", "logs-b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ResolveAlias=%v, want %v", got, want)
+	}
+
+	got, err = os.ResolveAlias(context.Background(), "missing")
+	if err != nil || got != nil {
+		t.Fatalf("ResolveAlias(missing)=%v, %v; want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// GetDoc decodes the source of document id of index into out with the
+// service account. It returns false if the document or the index does not
+// exist.
+func (o *OpenSearch) GetDoc(ctx context.Context, index, id string, out any) (bool, error) {
+	var resp struct {
+		Found  bool            `json:"found"`
+		Source json.RawMessage `json:"_source"`
+	}
+	if err := o.getJSON(ctx, "/"+index+"/_doc/"+url.PathEscape(id), &resp); err != nil {
+		var httpErr *HTTPStatusError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting %s/%s: %w", index, id, err)
+	}
+	if !resp.Found {
+		return false, nil
+	}
+	if err := json.Unmarshal(resp.Source, out); err != nil {
+		return false, fmt.Errorf("decoding %s/%s: %w", index, id, err)
+	}
+	return true, nil
+}
+
+// UpdateDoc sends body, an _update request such as a script with an
+// upsert, for document id of index with the service account, retrying on
+// version conflicts, and decodes the updated source into out unless it is
+// nil. The index is created by OpenSearch if it does not exist.
+func (o *OpenSearch) UpdateDoc(ctx context.Context, index, id string, body []byte, out any) error {
+	var resp struct {
+		Get struct {
+			Source json.RawMessage `json:"_source"`
+		} `json:"get"`
+	}
+	path := "/" + index + "/_update/" + url.PathEscape(id) + "?retry_on_conflict=5&_source=true"
+	if err := o.sendJSON(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return fmt.Errorf("updating %s/%s: %w", index, id, err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Get.Source, out); err != nil {
+		return fmt.Errorf("decoding %s/%s: %w", index, id, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenSearch_GetDoc(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/state/_doc/alice:2026-01-02":
+			w.Write([]byte(`{"_id":"alice:2026-01-02","found":true,"_source":{"n":3}}`))
+		case "/state/_doc/bob":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"_id":"bob","found":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+		}
+	}))
+	defer srv.Close()
+	o := NewOpenSearch(srv.URL, "", "", nil)
+
+	var doc struct {
+		N int `json:"n"`
+	}
+	found, err := o.GetDoc(context.Background(), "state", "alice:2026-01-02", &doc)
+	if err != nil || !found || doc.N != 3 {
+		t.Fatalf("GetDoc = %v, %v, doc %+v, want found with n 3", found, err, doc)
+	}
+	for _, tt := range []struct{ index, id string }{{"state", "bob"}, {"missing", "bob"}} {
+		if found, err := o.GetDoc(context.Background(), tt.index, tt.id, &doc); err != nil || found {
+			t.Errorf("GetDoc(%s, %s) = %v, %v, want not found", tt.index, tt.id, found, err)
+		}
+	}
+}
+
+func TestOpenSearch_UpdateDoc(t *testing.T) {
+	var gotPath, gotQuery, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotPath, gotQuery, gotBody = r.URL.Path, r.URL.RawQuery, string(b)
+		w.Write([]byte(`{"_id":"alice","result":"updated","get":{"found":true,"_source":{"n":4}}}`))
+	}))
+	defer srv.Close()
+
+	var doc struct {
+		N int `json:"n"`
+	}
+	body := `{"script":{"source":"ctx._source.n += 1"},"upsert":{"n":1}}`
+	if err := NewOpenSearch(srv.URL, "", "", nil).UpdateDoc(context.Background(), "state", "alice", []byte(body), &doc); err != nil {
+		t.Fatalf("UpdateDoc: %v", err)
+	}
+	if gotPath != "/state/_update/alice" || !strings.Contains(gotQuery, "_source=true") || !strings.Contains(gotQuery, "retry_on_conflict=") {
+		t.Errorf("request = %s?%s, want /state/_update/alice returning the source with retries", gotPath, gotQuery)
+	}
+	if gotBody != body {
+		t.Errorf("body = %s, want %s", gotBody, body)
+	}
+	if doc.N != 4 {
+		t.Errorf("updated source n = %d, want 4", doc.N)
+	}
+}
@@ -9,20 +9,21 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"sort"
 	"time"
 )
 
-const lockIndex = ".oqbridge-locks"
+// LockIndex is the index holding the migration locks.
+const LockIndex = ".oqbridge-locks"
 
 // OpenSearchLock implements distributed locking using OpenSearch documents.
 // It uses op_type=create for atomic lock acquisition and optimistic
 // concurrency control (_seq_no + _primary_term) for safe expired-lock cleanup.
 type OpenSearchLock struct {
-	baseURL  string
-	username string
-	password string
-	client   *http.Client
-	owner    string
+	baseURL string
+	creds   *Credentials
+	client  *http.Client
+	owner   string
 }
 
 // NewOpenSearchLock creates a new OpenSearchLock.
@@ -36,8 +37,7 @@ func NewOpenSearchLock(baseURL, username, password string, httpClient *http.Clie
 	owner := fmt.Sprintf("%s-%d", hostname, os.Getpid())
 	return &OpenSearchLock{
 		baseURL:  baseURL,
-		username: username,
-		password: password,
+		creds:    NewCredentials(username, password),
 		client:   httpClient,
 		owner:    owner,
 	}
@@ -71,7 +71,7 @@ func (l *OpenSearchLock) Acquire(ctx context.Context, key string, ttl time.Durat
 
 // Release releases the lock for the given key.
 func (l *OpenSearchLock) Release(ctx context.Context, key string) error {
-	url := fmt.Sprintf("%s/%s/_doc/%s?refresh=true", l.baseURL, lockIndex, key)
+	url := fmt.Sprintf("%s/%s/_doc/%s?refresh=true", l.baseURL, LockIndex, key)
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("creating release request: %w", err)
@@ -83,15 +83,89 @@ func (l *OpenSearchLock) Release(ctx context.Context, key string) error {
 		return fmt.Errorf("executing release request: %w", err)
 	}
 	defer resp.Body.Close()
-	io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
 
 	// 404 is fine — lock may have already been released or expired.
 	if resp.StatusCode >= 400 && resp.StatusCode != 404 {
-		return fmt.Errorf("lock release failed: status=%d", resp.StatusCode)
+		return fmt.Errorf("lock release: %w", &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
 
+// LockInfo describes a held migration lock.
+type LockInfo struct {
+	Key        string    `json:"key"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lock's TTL has passed at now. Expired locks are
+// removed by the next instance that tries to acquire them.
+func (i LockInfo) Expired(now time.Time) bool {
+	return now.After(i.ExpiresAt)
+}
+
+// List returns every lock document, sorted by key. A missing lock index
+// means no locks have been taken yet and is not an error.
+func (l *OpenSearchLock) List(ctx context.Context) ([]LockInfo, error) {
+	url := fmt.Sprintf("%s/%s/_search", l.baseURL, LockIndex)
+	body := []byte(`{"size":10000,"query":{"match_all":{}}}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating list request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	l.setAuth(req)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing list request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("listing locks: %w", &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string  `json:"_id"`
+				Source lockDoc `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parsing list response: %w", err)
+	}
+	locks := make([]LockInfo, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		locks = append(locks, LockInfo{
+			Key:        hit.ID,
+			Owner:      hit.Source.Owner,
+			AcquiredAt: hit.Source.AcquiredAt,
+			ExpiresAt:  hit.Source.ExpiresAt,
+		})
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Key < locks[j].Key })
+	return locks, nil
+}
+
 // indexMissingError is returned when the lock index does not exist.
 type indexMissingError struct {
 	msg string
@@ -116,7 +190,7 @@ func (l *OpenSearchLock) tryCreate(ctx context.Context, key string, ttl time.Dur
 		return false, fmt.Errorf("marshaling lock doc: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/%s/_doc/%s?op_type=create&refresh=true", l.baseURL, lockIndex, key)
+	url := fmt.Sprintf("%s/%s/_doc/%s?op_type=create&refresh=true", l.baseURL, LockIndex, key)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
 	if err != nil {
 		return false, fmt.Errorf("creating lock request: %w", err)
@@ -137,9 +211,13 @@ func (l *OpenSearchLock) tryCreate(ctx context.Context, key string, ttl time.Dur
 		return false, nil
 	case resp.StatusCode == 404:
 		// Index does not exist.
-		return false, &indexMissingError{msg: fmt.Sprintf("lock index %s does not exist", lockIndex)}
+		return false, &indexMissingError{msg: fmt.Sprintf("lock index %s does not exist", LockIndex)}
 	case resp.StatusCode >= 400:
-		return false, fmt.Errorf("lock acquire failed: status=%d body=%s", resp.StatusCode, string(respBody))
+		return false, fmt.Errorf("lock acquire: %w", &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	default:
 		return true, nil
 	}
@@ -148,7 +226,7 @@ func (l *OpenSearchLock) tryCreate(ctx context.Context, key string, ttl time.Dur
 // cleanupExpired checks if the lock for key is expired and deletes it using
 // optimistic concurrency control to avoid races with other instances.
 func (l *OpenSearchLock) cleanupExpired(ctx context.Context, key string) error {
-	url := fmt.Sprintf("%s/%s/_doc/%s", l.baseURL, lockIndex, key)
+	url := fmt.Sprintf("%s/%s/_doc/%s", l.baseURL, LockIndex, key)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
@@ -170,7 +248,11 @@ func (l *OpenSearchLock) cleanupExpired(ctx context.Context, key string) error {
 		return nil
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("get lock failed: status=%d", resp.StatusCode)
+		return fmt.Errorf("get lock: %w", &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 
 	var result struct {
@@ -194,7 +276,7 @@ func (l *OpenSearchLock) cleanupExpired(ctx context.Context, key string) error {
 			"expired_at", result.Source.ExpiresAt,
 		)
 		delURL := fmt.Sprintf("%s/%s/_doc/%s?if_seq_no=%d&if_primary_term=%d&refresh=true",
-			l.baseURL, lockIndex, key, result.SeqNo, result.PrimTerm)
+			l.baseURL, LockIndex, key, result.SeqNo, result.PrimTerm)
 		delReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, delURL, nil)
 		if err != nil {
 			return err
@@ -214,7 +296,7 @@ func (l *OpenSearchLock) cleanupExpired(ctx context.Context, key string) error {
 
 // ensureIndex creates the lock index if it doesn't exist.
 func (l *OpenSearchLock) ensureIndex(ctx context.Context) error {
-	url := fmt.Sprintf("%s/%s", l.baseURL, lockIndex)
+	url := fmt.Sprintf("%s/%s", l.baseURL, LockIndex)
 	body := []byte(`{"settings":{"number_of_shards":1,"number_of_replicas":1}}`)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
 	if err != nil {
@@ -236,13 +318,21 @@ func (l *OpenSearchLock) ensureIndex(ctx context.Context) error {
 		return nil
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("creating lock index: status=%d body=%s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("creating lock index: %w", &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
 
+// SetCredentials makes the lock authenticate with c, so rotated service
+// account credentials also apply to lock renewals.
+func (l *OpenSearchLock) SetCredentials(c *Credentials) {
+	l.creds = c
+}
+
 func (l *OpenSearchLock) setAuth(req *http.Request) {
-	if l.username != "" {
-		req.SetBasicAuth(l.username, l.password)
-	}
+	l.creds.Apply(req)
 }
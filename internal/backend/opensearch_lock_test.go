@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -224,3 +225,70 @@ func TestOpenSearchLock_Auth(t *testing.T) {
 		t.Fatalf("auth: got %s/%s, want admin/secret", gotUser, gotPass)
 	}
 }
+
+func TestOpenSearchLock_List(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/.oqbridge-locks/_search" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[
+			{"_id":"logs-b","_source":{"owner":"host-b-2","acquired_at":"2026-01-01T00:00:00Z","expires_at":"2026-01-01T02:00:00Z"}},
+			{"_id":"logs-a","_source":{"owner":"host-a-1","acquired_at":"2026-01-01T01:00:00Z","expires_at":"2026-01-01T03:00:00Z"}}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	lock := NewOpenSearchLock(srv.URL, "", "", srv.Client())
+	locks, err := lock.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(locks) != 2 || locks[0].Key != "logs-a" || locks[1].Key != "logs-b" {
+		t.Fatalf("locks=%+v, want logs-a and logs-b", locks)
+	}
+	if locks[0].Owner != "host-a-1" {
+		t.Fatalf("owner=%s, want host-a-1", locks[0].Owner)
+	}
+	at := time.Date(2026, 1, 1, 2, 30, 0, 0, time.UTC)
+	if locks[0].Expired(at) || !locks[1].Expired(at) {
+		t.Fatalf("Expired at %v: logs-a=%v logs-b=%v, want false/true", at, locks[0].Expired(at), locks[1].Expired(at))
+	}
+}
+
+func TestOpenSearchLock_List_IndexMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	lock := NewOpenSearchLock(srv.URL, "", "", srv.Client())
+	locks, err := lock.List(context.Background())
+	if err != nil || len(locks) != 0 {
+		t.Fatalf("List=%v err=%v, want empty", locks, err)
+	}
+}
+
+func TestOpenSearchLock_Acquire_HTTPStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"no permissions for [indices:data/write/index]"}`))
+	}))
+	defer srv.Close()
+
+	lock := NewOpenSearchLock(srv.URL, "", "", srv.Client())
+	_, err := lock.Acquire(context.Background(), "logs", time.Hour)
+	var httpErr *HTTPStatusError
+	if !asHTTPStatusError(err, &httpErr) {
+		t.Fatalf("expected *HTTPStatusError, got %T: %v", err, err)
+	}
+	if httpErr.StatusCode != http.StatusForbidden || !strings.Contains(httpErr.Body, "no permissions") {
+		t.Fatalf("httpErr=%+v", httpErr)
+	}
+}
@@ -0,0 +1,214 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotInfo describes a completed snapshot in a repository.
+type SnapshotInfo struct {
+	Name      string
+	StartTime time.Time
+	Indices   []string
+}
+
+// Snapshot looks up a snapshot in repo. An empty name selects the most
+// recent snapshot whose state is SUCCESS.
+func (o *OpenSearch) Snapshot(ctx context.Context, repo, name string) (*SnapshotInfo, error) {
+	target := name
+	if target == "" {
+		target = "_all"
+	}
+	snapshots, err := o.ListSnapshots(ctx, repo, target)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no successful snapshot %q found in repository %s", target, repo)
+	}
+	return &snapshots[len(snapshots)-1], nil
+}
+
+// ListSnapshots returns the snapshots in repo matching pattern (a name,
+// wildcard or "_all") whose state is SUCCESS, oldest first.
+func (o *OpenSearch) ListSnapshots(ctx context.Context, repo, pattern string) ([]SnapshotInfo, error) {
+	var resp struct {
+		Snapshots []struct {
+			Snapshot          string   `json:"snapshot"`
+			State             string   `json:"state"`
+			StartTimeInMillis int64    `json:"start_time_in_millis"`
+			Indices           []string `json:"indices"`
+		} `json:"snapshots"`
+	}
+	path := fmt.Sprintf("/_snapshot/%s/%s", repo, pattern)
+	if err := o.getJSON(ctx, path, &resp); err != nil {
+		return nil, fmt.Errorf("fetching snapshot %s/%s: %w", repo, pattern, err)
+	}
+
+	var snapshots []SnapshotInfo
+	for _, s := range resp.Snapshots {
+		if s.State == "SUCCESS" {
+			snapshots = append(snapshots, SnapshotInfo{Name: s.Snapshot, StartTime: time.UnixMilli(s.StartTimeInMillis).UTC(), Indices: s.Indices})
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].StartTime.Before(snapshots[j].StartTime) })
+	return snapshots, nil
+}
+
+// CreateSnapshot snapshots indices into repo as name and waits for the
+// snapshot to finish. Indices that do not exist are skipped; the cluster
+// state is not included.
+func (o *OpenSearch) CreateSnapshot(ctx context.Context, repo, name string, indices []string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"indices":              strings.Join(indices, ","),
+		"ignore_unavailable":   true,
+		"include_global_state": false,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling snapshot request: %w", err)
+	}
+	var resp struct {
+		Snapshot struct {
+			State string `json:"state"`
+		} `json:"snapshot"`
+	}
+	path := fmt.Sprintf("/_snapshot/%s/%s?wait_for_completion=true", repo, name)
+	if err := o.sendJSON(ctx, http.MethodPut, path, payload, &resp); err != nil {
+		return fmt.Errorf("creating snapshot %s/%s: %w", repo, name, err)
+	}
+	if resp.Snapshot.State != "SUCCESS" {
+		return fmt.Errorf("snapshot %s/%s finished in state %s", repo, name, resp.Snapshot.State)
+	}
+	return nil
+}
+
+// DeleteSnapshot deletes a snapshot from repo.
+func (o *OpenSearch) DeleteSnapshot(ctx context.Context, repo, name string) error {
+	if err := o.sendJSON(ctx, http.MethodDelete, fmt.Sprintf("/_snapshot/%s/%s", repo, name), nil, nil); err != nil {
+		return fmt.Errorf("deleting snapshot %s/%s: %w", repo, name, err)
+	}
+	return nil
+}
+
+// RestoreSnapshotIndices restores indices from a snapshot under their own
+// names and waits for the restore to finish. Restore refuses to overwrite
+// open indices, so they must be deleted first. Indices missing from the
+// snapshot are skipped.
+func (o *OpenSearch) RestoreSnapshotIndices(ctx context.Context, repo, snapshot string, indices []string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"indices":              strings.Join(indices, ","),
+		"ignore_unavailable":   true,
+		"include_global_state": false,
+		"include_aliases":      false,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling restore request: %w", err)
+	}
+	path := fmt.Sprintf("/_snapshot/%s/%s/_restore?wait_for_completion=true", repo, snapshot)
+	if err := o.sendJSON(ctx, http.MethodPost, path, payload, nil); err != nil {
+		return fmt.Errorf("restoring snapshot %s/%s: %w", repo, snapshot, err)
+	}
+	return nil
+}
+
+// RestoreSnapshotIndex restores a single index from a snapshot under a new
+// name. With searchable set, the index is mounted as a searchable snapshot
+// (storage_type remote_snapshot) instead of being copied to local disk.
+// The restore runs asynchronously; use WaitForIndexHealth to wait for it.
+func (o *OpenSearch) RestoreSnapshotIndex(ctx context.Context, repo, snapshot, index, target string, searchable bool) error {
+	body := map[string]interface{}{
+		"indices":              index,
+		"include_global_state": false,
+		"include_aliases":      false,
+		"rename_pattern":       "^" + regexp.QuoteMeta(index) + "$",
+		"rename_replacement":   target,
+	}
+	if searchable {
+		body["storage_type"] = "remote_snapshot"
+	} else {
+		// The restored copy is read once and dropped; replicas would only add load.
+		body["index_settings"] = map[string]interface{}{"index.number_of_replicas": 0}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshaling restore request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/_snapshot/%s/%s/_restore", o.baseURL, repo, snapshot)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating restore request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing restore: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	return nil
+}
+
+// WaitForIndexHealth blocks until index reaches at least yellow health or
+// timeout elapses, which is how restore completion is observed.
+func (o *OpenSearch) WaitForIndexHealth(ctx context.Context, index string, timeout time.Duration) error {
+	var resp struct {
+		Status   string `json:"status"`
+		TimedOut bool   `json:"timed_out"`
+	}
+	path := fmt.Sprintf("/_cluster/health/%s?wait_for_status=yellow&timeout=%ds", index, int(timeout.Seconds()))
+	if err := o.getJSON(ctx, path, &resp); err != nil {
+		return fmt.Errorf("waiting for index %s: %w", index, err)
+	}
+	if resp.TimedOut {
+		return fmt.Errorf("index %s still %s after %s", index, resp.Status, timeout)
+	}
+	return nil
+}
+
+// DeleteIndex deletes an index. A missing index is not an error.
+func (o *OpenSearch) DeleteIndex(ctx context.Context, index string) error {
+	url := fmt.Sprintf("%s/%s", o.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating delete index request: %w", err)
+	}
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing delete index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenSearch_Snapshot_PicksLatestSuccessful(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_snapshot/archive/_all" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"snapshots":[
+			{"snapshot":"nightly-1","state":"SUCCESS","start_time_in_millis":1767225600000},
+			{"snapshot":"nightly-3","state":"IN_PROGRESS","start_time_in_millis":1767398400000},
+			{"snapshot":"nightly-2","state":"SUCCESS","start_time_in_millis":1767312000000}
+		]}`))
+	}))
+	defer srv.Close()
+
+	info, err := NewOpenSearch(srv.URL, "", "", nil).Snapshot(context.Background(), "archive", "")
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if info.Name != "nightly-2" {
+		t.Fatalf("snapshot=%s, want nightly-2", info.Name)
+	}
+	if want := time.UnixMilli(1767312000000).UTC(); !info.StartTime.Equal(want) {
+		t.Fatalf("start=%v, want %v", info.StartTime, want)
+	}
+}
+
+func TestOpenSearch_RestoreSnapshotIndex(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/_snapshot/archive/nightly/_restore" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"accepted":true}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenSearch(srv.URL, "", "", nil)
+	if err := client.RestoreSnapshotIndex(context.Background(), "archive", "nightly", "logs-2025.01.01", "tmp-logs", true); err != nil {
+		t.Fatalf("RestoreSnapshotIndex: %v", err)
+	}
+	if body["indices"] != "logs-2025.01.01" || body["rename_replacement"] != "tmp-logs" {
+		t.Fatalf("unexpected restore body: %v", body)
+	}
+	if body["rename_pattern"] != `^logs-2025\.01\.01$` {
+		t.Fatalf("rename_pattern=%v", body["rename_pattern"])
+	}
+	if body["storage_type"] != "remote_snapshot" {
+		t.Fatalf("storage_type=%v, want remote_snapshot", body["storage_type"])
+	}
+}
+
+func TestOpenSearch_WaitForIndexHealth_TimedOut(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait_for_status") != "yellow" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status":"red","timed_out":true}`))
+	}))
+	defer srv.Close()
+
+	err := NewOpenSearch(srv.URL, "", "", nil).WaitForIndexHealth(context.Background(), "tmp-logs", time.Second)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+}
+
+func TestOpenSearch_DeleteIndex_MissingIsNotError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if err := NewOpenSearch(srv.URL, "", "", nil).DeleteIndex(context.Background(), "tmp-logs"); err != nil {
+		t.Fatalf("DeleteIndex: %v", err)
+	}
+}
+
+func TestOpenSearch_CreateSnapshot(t *testing.T) {
+	var body map[string]interface{}
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/_snapshot/backups/state-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query = r.URL.RawQuery
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"snapshot":{"snapshot":"state-1","state":"PARTIAL"}}`))
+	}))
+	defer srv.Close()
+
+	err := NewOpenSearch(srv.URL, "", "", nil).CreateSnapshot(context.Background(), "backups", "state-1", []string{".a", ".b"})
+	if err == nil {
+		t.Fatal("CreateSnapshot succeeded for a partial snapshot")
+	}
+	if query != "wait_for_completion=true" || body["indices"] != ".a,.b" || body["ignore_unavailable"] != true {
+		t.Fatalf("unexpected snapshot request %q: %v", query, body)
+	}
+}
+
+func TestOpenSearch_BulkIndexHits(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		w.Write([]byte(`{"errors":false}`))
+	}))
+	defer srv.Close()
+
+	hits := []json.RawMessage{json.RawMessage(`{"_index":".old","_id":"watermark-logs","_source":{"index":"logs"}}`)}
+	if err := NewOpenSearch(srv.URL, "", "", nil).BulkIndexHits(context.Background(), ".state", hits); err != nil {
+		t.Fatalf("BulkIndexHits: %v", err)
+	}
+	want := []string{`{"index":{"_id":"watermark-logs","_index":".state"}}`, `{"index":"logs"}`}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("bulk body = %q, want %q", lines, want)
+	}
+}
@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Task describes a task running on the OpenSearch cluster.
+type Task struct {
+	ID           string // "node:id", as accepted by GetTask and CancelTask.
+	Node         string
+	Action       string // e.g. "indices:data/write/delete/byquery".
+	Description  string
+	StartTime    time.Time
+	RunningTime  time.Duration
+	Cancellable  bool
+	Cancelled    bool
+	ParentTaskID string
+	Headers      map[string]string // includes X-Opaque-Id when the request set one.
+	Status       json.RawMessage   // action-specific progress, e.g. a bulk-by-scroll status.
+}
+
+// TaskResult is the state of a single task. Once Completed, Response or
+// Error holds its outcome.
+type TaskResult struct {
+	Completed bool
+	Task      Task
+	Response  json.RawMessage
+	Error     json.RawMessage
+}
+
+// taskEntry is a task as encoded by the _tasks API.
+type taskEntry struct {
+	Node               string            `json:"node"`
+	ID                 int64             `json:"id"`
+	Action             string            `json:"action"`
+	Description        string            `json:"description"`
+	StartTimeInMillis  int64             `json:"start_time_in_millis"`
+	RunningTimeInNanos int64             `json:"running_time_in_nanos"`
+	Cancellable        bool              `json:"cancellable"`
+	Cancelled          bool              `json:"cancelled"`
+	ParentTaskID       string            `json:"parent_task_id"`
+	Headers            map[string]string `json:"headers"`
+	Status             json.RawMessage   `json:"status"`
+}
+
+func (e taskEntry) task() Task {
+	return Task{
+		ID:           fmt.Sprintf("%s:%d", e.Node, e.ID),
+		Node:         e.Node,
+		Action:       e.Action,
+		Description:  e.Description,
+		StartTime:    time.UnixMilli(e.StartTimeInMillis).UTC(),
+		RunningTime:  time.Duration(e.RunningTimeInNanos),
+		Cancellable:  e.Cancellable,
+		Cancelled:    e.Cancelled,
+		ParentTaskID: e.ParentTaskID,
+		Headers:      e.Headers,
+		Status:       e.Status,
+	}
+}
+
+// ListTasks returns the tasks currently running on the cluster whose action
+// matches actions, a comma-separated list of wildcard patterns (empty for
+// all), ordered by start time.
+func (o *OpenSearch) ListTasks(ctx context.Context, actions string) ([]Task, error) {
+	q := url.Values{"detailed": {"true"}, "group_by": {"none"}}
+	if actions != "" {
+		q.Set("actions", actions)
+	}
+	var resp struct {
+		Tasks []taskEntry `json:"tasks"`
+	}
+	if err := o.getJSON(ctx, "/_tasks?"+q.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("listing tasks: %w", err)
+	}
+	tasks := make([]Task, 0, len(resp.Tasks))
+	for _, e := range resp.Tasks {
+		tasks = append(tasks, e.task())
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartTime.Before(tasks[j].StartTime) })
+	return tasks, nil
+}
+
+// GetTask returns the state of the task with the given "node:id" identifier,
+// including its result if it has completed and was stored by the cluster.
+func (o *OpenSearch) GetTask(ctx context.Context, id string) (*TaskResult, error) {
+	var resp struct {
+		Completed bool            `json:"completed"`
+		Task      taskEntry       `json:"task"`
+		Response  json.RawMessage `json:"response"`
+		Error     json.RawMessage `json:"error"`
+	}
+	if err := o.getJSON(ctx, "/_tasks/"+id, &resp); err != nil {
+		return nil, fmt.Errorf("fetching task %s: %w", id, err)
+	}
+	return &TaskResult{
+		Completed: resp.Completed,
+		Task:      resp.Task.task(),
+		Response:  resp.Response,
+		Error:     resp.Error,
+	}, nil
+}
+
+// CancelTask asks the cluster to cancel the task with the given "node:id"
+// identifier. Cancellation is asynchronous; poll GetTask to observe it.
+func (o *OpenSearch) CancelTask(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s/_tasks/%s/_cancel", o.baseURL, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating cancel task request: %w", err)
+	}
+	o.setAuth(req)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing cancel task: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading cancel task response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+
+	// A task that cannot be cancelled is reported in a 200 response.
+	var result struct {
+		NodeFailures []json.RawMessage `json:"node_failures"`
+		TaskFailures []json.RawMessage `json:"task_failures"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("decoding cancel task response: %w", err)
+	}
+	if len(result.TaskFailures) > 0 {
+		return fmt.Errorf("cancelling task %s: %s", id, result.TaskFailures[0])
+	}
+	if len(result.NodeFailures) > 0 {
+		return fmt.Errorf("cancelling task %s: %s", id, result.NodeFailures[0])
+	}
+	return nil
+}
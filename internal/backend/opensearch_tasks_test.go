@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenSearch_ListTasks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/_tasks" || q.Get("detailed") != "true" || q.Get("group_by") != "none" || q.Get("actions") != "*byquery" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"tasks":[
+			{"node":"n2","id":7,"action":"indices:data/write/delete/byquery","start_time_in_millis":1700000002000,
+			 "running_time_in_nanos":1500000000,"cancellable":true,"headers":{"X-Opaque-Id":"oqbridge"},"status":{"deleted":10}},
+			{"node":"n1","id":42,"action":"indices:data/write/update/byquery","start_time_in_millis":1700000001000,
+			 "running_time_in_nanos":2000000000,"cancellable":true,"cancelled":true,"headers":{}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	tasks, err := NewOpenSearch(srv.URL, "", "", nil).ListTasks(context.Background(), "*byquery")
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("ListTasks returned %d tasks, want 2", len(tasks))
+	}
+	if tasks[0].ID != "n1:42" || !tasks[0].Cancelled || tasks[0].RunningTime != 2*time.Second {
+		t.Fatalf("tasks[0]=%+v, want n1:42 cancelled after 2s", tasks[0])
+	}
+	got := tasks[1]
+	if got.ID != "n2:7" || got.Headers["X-Opaque-Id"] != "oqbridge" || string(got.Status) != `{"deleted":10}` {
+		t.Fatalf("tasks[1]=%+v", got)
+	}
+	if !got.StartTime.Equal(time.UnixMilli(1700000002000)) {
+		t.Fatalf("StartTime=%v", got.StartTime)
+	}
+}
+
+func TestOpenSearch_GetTask(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_tasks/n1:42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"completed":true,"task":{"node":"n1","id":42,"action":"indices:data/write/delete/byquery"},
+			"response":{"deleted":100,"failures":[]}}`))
+	}))
+	defer srv.Close()
+
+	res, err := NewOpenSearch(srv.URL, "", "", nil).GetTask(context.Background(), "n1:42")
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if !res.Completed || res.Task.ID != "n1:42" || !strings.Contains(string(res.Response), `"deleted":100`) || res.Error != nil {
+		t.Fatalf("GetTask=%+v", res)
+	}
+}
+
+func TestOpenSearch_CancelTask(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/_tasks/n1:42/_cancel":
+			w.Write([]byte(`{"nodes":{}}`))
+		case "/_tasks/n1:43/_cancel":
+			w.Write([]byte(`{"node_failures":[],"task_failures":[{"task_id":43,"node_id":"n1","reason":{"type":"illegal_argument_exception"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	os := NewOpenSearch(srv.URL, "", "", nil)
+
+	if err := os.CancelTask(context.Background(), "n1:42"); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	if err := os.CancelTask(context.Background(), "n1:43"); err == nil || !strings.Contains(err.Error(), "illegal_argument_exception") {
+		t.Fatalf("CancelTask with task failure: got %v", err)
+	}
+	var httpErr *HTTPStatusError
+	if err := os.CancelTask(context.Background(), "n9:1"); !asHTTPStatusError(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("CancelTask unknown task: got %v, want 404 HTTPStatusError", err)
+	}
+}
@@ -9,8 +9,32 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
+func TestOpenSearch_AuthInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic dTpw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"user_name":"u","backend_roles":["analysts"],"roles":["all_access","own_index"]}`))
+	}))
+	defer srv.Close()
+
+	os := NewOpenSearch(srv.URL, "", "", nil)
+	info, err := os.AuthInfo(context.Background(), http.Header{"Authorization": {"Basic dTpw"}})
+	if err != nil {
+		t.Fatalf("AuthInfo: %v", err)
+	}
+	if info.UserName != "u" || len(info.Roles) != 2 || info.BackendRoles[0] != "analysts" {
+		t.Errorf("AuthInfo = %+v", info)
+	}
+	if _, err := os.AuthInfo(context.Background(), nil); err == nil {
+		t.Error("AuthInfo without credentials succeeded")
+	}
+}
+
 func TestOpenSearch_Authenticate_StatusCodes(t *testing.T) {
 	const token = "Basic dTpw" // u:p
 
@@ -183,6 +207,206 @@ func TestOpenSearch_SlicedScroll_InsertsSliceClause(t *testing.T) {
 	}
 }
 
+func TestOpenSearch_ClusterHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "svc" || p != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/_cluster/health":
+			w.Write([]byte(`{"status":"yellow","number_of_pending_tasks":7}`))
+		case "/_nodes/stats/jvm":
+			w.Write([]byte(`{"nodes":{"a":{"jvm":{"mem":{"heap_used_percent":41}}},"b":{"jvm":{"mem":{"heap_used_percent":88}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	h, err := NewOpenSearch(srv.URL, "svc", "pw", nil).ClusterHealth(context.Background())
+	if err != nil {
+		t.Fatalf("ClusterHealth: %v", err)
+	}
+	if h.Status != "yellow" || h.PendingTasks != 7 || h.MaxHeapUsedPercent != 88 {
+		t.Fatalf("ClusterHealth=%+v, want status=yellow pending=7 heap=88", h)
+	}
+}
+
+func TestOpenSearch_ClusterHealth_Non2xxReturnsHTTPStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"master_not_discovered_exception"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenSearch(srv.URL, "", "", nil).ClusterHealth(context.Background())
+	var httpErr *HTTPStatusError
+	if !asHTTPStatusError(err, &httpErr) {
+		t.Fatalf("expected HTTPStatusError, got %T: %v", err, err)
+	}
+	if httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("StatusCode=%d, want %d", httpErr.StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestOpenSearch_Count(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/logs/_count" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, string(b))
+		w.Write([]byte(`{"count":42,"_shards":{"total":1,"successful":1,"failed":0}}`))
+	}))
+	defer srv.Close()
+	os := NewOpenSearch(srv.URL, "", "", nil)
+
+	// A search body is reduced to its query; _count rejects size and sort.
+	n, err := os.Count(context.Background(), "logs", []byte(`{"size":0,"sort":["_doc"],"query":{"term":{"status":500}}}`))
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if n != 42 {
+		t.Fatalf("count=%d, want 42", n)
+	}
+	if _, err := os.Count(context.Background(), "logs", nil); err != nil {
+		t.Fatalf("Count with empty body: %v", err)
+	}
+	want := []string{`{"query":{"term":{"status":500}}}`, `{}`}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("request bodies=%q, want %q", got, want)
+	}
+}
+
+func TestOpenSearch_ResolveIndices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/indices/logs-*" || r.URL.Query().Get("bytes") != "b" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"index":"logs-b","health":"yellow","creation.date":"1700000000000","docs.count":"0","store.size":"208"},
+			{"index":".kibana","health":"green","creation.date":"1700000000000","docs.count":"5","store.size":"100"},
+			{"index":"logs-a","health":"green","creation.date":"1600000000000","docs.count":"1200","store.size":"524288"},
+			{"index":"logs-closed","health":null,"creation.date":"1600000000000","docs.count":null,"store.size":null}
+		]`))
+	}))
+	defer srv.Close()
+
+	got, err := NewOpenSearch(srv.URL, "", "", nil).ResolveIndices(context.Background(), "logs-*")
+	if err != nil {
+		t.Fatalf("ResolveIndices: %v", err)
+	}
+	want := []IndexInfo{
+		{Name: "logs-a", Health: "green", CreationDate: time.UnixMilli(1600000000000).UTC(), DocsCount: 1200, StoreSizeBytes: 524288},
+		{Name: "logs-b", Health: "yellow", CreationDate: time.UnixMilli(1700000000000).UTC(), DocsCount: 0, StoreSizeBytes: 208},
+		{Name: "logs-closed", CreationDate: time.UnixMilli(1600000000000).UTC(), DocsCount: -1, StoreSizeBytes: -1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ResolveIndices=%+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ResolveIndices[%d]=%+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOpenSearch_CountRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logs/_count" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(b), `"gte":"2026-01-01T00:00:00.000Z"`) || !strings.Contains(string(b), `"lte":"2026-01-02T00:00:00.500Z"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(b)
+			return
+		}
+		w.Write([]byte(`{"count":42}`))
+	}))
+	defer srv.Close()
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 2, 0, 0, 0, 500_000_000, time.UTC)
+	n, err := NewOpenSearch(srv.URL, "", "", nil).CountRange(context.Background(), "logs", "@timestamp", from, to)
+	if err != nil {
+		t.Fatalf("CountRange: %v", err)
+	}
+	if n != 42 {
+		t.Fatalf("count=%d, want 42", n)
+	}
+}
+
+func TestOpenSearch_SampleRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/logs/_search" || !strings.Contains(string(b), `"random_score":{}`) || !strings.Contains(string(b), `"size":2`) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(b)
+			return
+		}
+		w.Write([]byte(`{"hits":{"total":{"value":5},"hits":[{"_id":"1","_source":{"msg":"a"}},{"_id":"2","_source":{"msg":"b"}}]}}`))
+	}))
+	defer srv.Close()
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs, err := NewOpenSearch(srv.URL, "", "", nil).SampleRange(context.Background(), "logs", "@timestamp", from, from.Add(time.Hour), 2)
+	if err != nil {
+		t.Fatalf("SampleRange: %v", err)
+	}
+	if len(docs) != 2 || string(docs[0]) != `{"msg":"a"}` {
+		t.Fatalf("docs=%s, want the two _source objects", docs)
+	}
+}
+
+func TestOpenSearch_BulkIngest_PartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"took":3,"errors":true,"items":[
+			{"index":{"_index":"logs","status":201,"result":"created"}},
+			{"index":{"_index":"logs","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [n]"}}},
+			{"index":{"_index":"logs","status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	docs := []json.RawMessage{[]byte(`{"n":1}`), []byte(`{"n":"x"}`), []byte(`{"n":3}`)}
+	err := NewOpenSearch(srv.URL, "", "", nil).BulkIngest(context.Background(), "logs", docs)
+
+	var partial *BulkPartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *BulkPartialError, got %T: %v", err, err)
+	}
+	if partial.Total != 3 || len(partial.Failed) != 2 {
+		t.Fatalf("partial=%+v, want 2 of 3 failed", partial)
+	}
+	first := partial.Failed[0]
+	if first.Position != 1 || first.Status != 400 || first.Type != "mapper_parsing_exception" {
+		t.Fatalf("first failure=%+v", first)
+	}
+	if partial.Failed[1].Position != 2 || partial.Failed[1].Status != 429 {
+		t.Fatalf("second failure=%+v", partial.Failed[1])
+	}
+}
+
+func TestOpenSearch_BulkIngest_NoErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"took":3,"errors":false,"items":[{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	docs := []json.RawMessage{[]byte(`{"n":1}`)}
+	if err := NewOpenSearch(srv.URL, "", "", nil).BulkIngest(context.Background(), "logs", docs); err != nil {
+		t.Fatalf("BulkIngest: %v", err)
+	}
+}
+
 func asHTTPStatusError(err error, target **HTTPStatusError) bool {
 	if err == nil {
 		return false
@@ -194,3 +418,34 @@ func asHTTPStatusError(err error, target **HTTPStatusError) bool {
 	*target = httpErr
 	return true
 }
+
+func TestOpenSearch_IndexExistsAndCreateIndex(t *testing.T) {
+	var created string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/logs-old":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut && r.URL.Path == "/logs-new":
+			b, _ := io.ReadAll(r.Body)
+			created = string(b)
+			w.Write([]byte(`{"acknowledged":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	os := NewOpenSearch(srv.URL, "", "", nil)
+
+	if ok, err := os.IndexExists(context.Background(), "logs-old"); err != nil || !ok {
+		t.Fatalf("IndexExists(logs-old) = %v, %v", ok, err)
+	}
+	if ok, err := os.IndexExists(context.Background(), "logs-new"); err != nil || ok {
+		t.Fatalf("IndexExists(logs-new) = %v, %v", ok, err)
+	}
+	if err := os.CreateIndex(context.Background(), "logs-new", "ts", 90); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if want := `{"mappings":{"properties":{"ts":{"type":"date"}}}}`; created != want {
+		t.Fatalf("create body=%s, want %s", created, want)
+	}
+}
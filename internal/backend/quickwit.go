@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
@@ -10,18 +11,49 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Quickwit implements the Backend interface for Quickwit.
+// Search API modes for the cold leg.
+const (
+	SearchAPIPassthrough = "passthrough" // send the Elasticsearch DSL body to the native endpoint as is
+	SearchAPINative      = "native"      // translate to Quickwit's native search request
+	SearchAPIElastic     = "elastic"     // use the Elasticsearch-compatible _elastic endpoints
+)
+
+// Quickwit implements the Backend and MultiSearcher interfaces for Quickwit.
 // Quickwit provides an Elasticsearch-compatible search API at /{index}/_search.
 type Quickwit struct {
 	baseURL  string
-	username string
-	password string
+	creds    *Credentials
 	client   *http.Client
 	compress bool   // Enable gzip compression for ingest requests.
 	tempDir  string // When non-empty, stage ingest payloads on disk instead of in memory.
+
+	headers map[string]string // Static headers added to every request.
+
+	ingestAPI    string // "v1", "v2" or IngestAPIAuto; selects the ingest endpoint.
+	ingestCommit string // commit query parameter; empty or "auto" omits it.
+	searchAPI    string // one of the SearchAPI* modes; selects how Search builds its request.
+
+	features atomic.Pointer[QuickwitFeatures] // last detected by DetectFeatures; nil until then
+
+	ingestOptions func(index string) IngestOptions // optional per-index override of compress/tempDir
+	indexSettings func(index string) IndexSettings // optional per-index settings for CreateIndex
+
+	listCacheTTL time.Duration // how long ListIndices results are reused; 0 disables caching
+	listMu       sync.Mutex
+	listCache    []string
+	listFetched  time.Time
+}
+
+// IngestOptions controls how BulkIngest stages and encodes a payload.
+type IngestOptions struct {
+	Compress bool   // Gzip the request body.
+	TempDir  string // Stage the payload in this directory instead of memory when non-empty.
 }
 
 // NewQuickwit creates a new Quickwit backend client.
@@ -31,14 +63,30 @@ func NewQuickwit(baseURL, username, password string, compress bool, httpClient *
 		httpClient = &http.Client{}
 	}
 	return &Quickwit{
-		baseURL:  baseURL,
-		username: username,
-		password: password,
-		client:   httpClient,
-		compress: compress,
+		baseURL:   baseURL,
+		creds:     NewCredentials(username, password),
+		client:    httpClient,
+		compress:  compress,
+		ingestAPI: "v1",
+		searchAPI: SearchAPIPassthrough,
 	}
 }
 
+// SetAuth configures a bearer token and static headers for Quickwit
+// deployments behind an authenticating gateway. Both are sent on every
+// request; a non-empty token takes the place of basic auth.
+func (q *Quickwit) SetAuth(bearerToken string, headers map[string]string) {
+	q.creds.SetBearerToken(bearerToken)
+	q.headers = headers
+}
+
+// SetCredentials replaces the service account credentials, including a
+// bearer token set by SetAuth, with c, which may be shared with other
+// clients and updated while in use.
+func (q *Quickwit) SetCredentials(c *Credentials) {
+	q.creds = c
+}
+
 // SetTempDir configures a directory for staging ingest payloads on disk.
 // When set, BulkIngest writes NDJSON (and optional gzip) to temporary files
 // instead of in-memory buffers, reducing memory usage for large batches.
@@ -46,10 +94,89 @@ func (q *Quickwit) SetTempDir(dir string) {
 	q.tempDir = dir
 }
 
+// SetIngestMode selects the ingest API version ("v1", "v2" or
+// IngestAPIAuto) and the commit behavior ("auto", "wait_for" or "force"). With wait_for or force, each
+// ingest request returns only once its documents are searchable, trading
+// throughput for a guarantee that a completed batch is visible.
+func (q *Quickwit) SetIngestMode(api, commit string) {
+	q.ingestAPI = api
+	q.ingestCommit = commit
+}
+
+// SetSearchAPI selects how Search queries Quickwit. SearchAPIPassthrough
+// sends the Elasticsearch body unchanged; SearchAPINative translates it into
+// a native search request and rejects constructs it cannot express;
+// SearchAPIElastic sends it to the Elasticsearch-compatible _elastic endpoint.
+// SearchAPIAuto picks elastic or passthrough by the detected features.
+func (q *Quickwit) SetSearchAPI(api string) {
+	q.searchAPI = api
+}
+
+// SearchAPI returns the configured search API mode.
+func (q *Quickwit) SearchAPI() string {
+	return q.searchAPI
+}
+
+// SetIngestOptions installs a per-index resolver for ingest settings. When
+// set, it replaces the client-wide compress and temp dir for every
+// BulkIngest call.
+func (q *Quickwit) SetIngestOptions(resolve func(index string) IngestOptions) {
+	q.ingestOptions = resolve
+}
+
+// SetIndexSettings installs a per-index resolver for the indexing and search
+// settings CreateIndex writes into new indices. Without one, new indices get
+// a 60 second commit timeout and Quickwit defaults for everything else.
+func (q *Quickwit) SetIndexSettings(resolve func(index string) IndexSettings) {
+	q.indexSettings = resolve
+}
+
+// SetListCacheTTL makes ListIndices reuse its result for ttl, so wildcard
+// resolution does not hit the metastore on every query. CreateIndex and
+// DeleteIndex invalidate the cache; changes made by other processes become
+// visible within ttl. A ttl of 0 disables caching.
+func (q *Quickwit) SetListCacheTTL(ttl time.Duration) {
+	q.listMu.Lock()
+	defer q.listMu.Unlock()
+	q.listCacheTTL = ttl
+	q.listCache = nil
+}
+
 func (q *Quickwit) Name() string { return "quickwit" }
 
+// Capabilities depends on the search API mode: every mode sends sort and
+// aggregations to Quickwit, but only the _elastic endpoints offer
+// MultiSearch. Once DetectFeatures has run, sort and aggregations are only
+// reported if the cluster's version has them. Quickwit has no scroll API.
+func (q *Quickwit) Capabilities() Capabilities {
+	caps := Capabilities{
+		SupportsAggregations: true,
+		SupportsSort:         true,
+		SupportsMultiSearch:  q.effectiveSearchAPI() == SearchAPIElastic,
+	}
+	if f := q.features.Load(); f != nil {
+		caps.SupportsAggregations, caps.SupportsSort = f.Aggregations, f.Sort
+	}
+	return caps
+}
+
 func (q *Quickwit) Search(ctx context.Context, index string, body []byte) (*SearchResponse, error) {
+	mode := q.effectiveSearchAPI()
+	native := mode == SearchAPINative
+	if native {
+		req, err := translateNativeSearch(body)
+		if err != nil {
+			return nil, fmt.Errorf("translating search to native query: %w", err)
+		}
+		if body, err = json.Marshal(req); err != nil {
+			return nil, fmt.Errorf("marshaling native search request: %w", err)
+		}
+	}
+
 	url := fmt.Sprintf("%s/api/v1/%s/search", q.baseURL, index)
+	if mode == SearchAPIElastic {
+		url = fmt.Sprintf("%s/api/v1/_elastic/%s/_search", q.baseURL, index)
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("creating search request: %w", err)
@@ -77,6 +204,9 @@ func (q *Quickwit) Search(ctx context.Context, index string, body []byte) (*Sear
 		}
 	}
 
+	if native {
+		return nativeToSearchResponse(index, respBody)
+	}
 	var result SearchResponse
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("decoding search response: %w", err)
@@ -84,131 +214,138 @@ func (q *Quickwit) Search(ctx context.Context, index string, body []byte) (*Sear
 	return &result, nil
 }
 
-func (q *Quickwit) Scroll(_ context.Context, _ string, _ []byte, _ string) (*ScrollResult, error) {
-	return nil, fmt.Errorf("quickwit does not support scroll API")
-}
-
-func (q *Quickwit) ClearScroll(_ context.Context, _ string) error {
-	return nil
-}
-
 func (q *Quickwit) BulkIngest(ctx context.Context, index string, docs []json.RawMessage) error {
-	if q.tempDir != "" {
-		return q.bulkIngestViaDisk(ctx, index, docs)
+	opts := IngestOptions{Compress: q.compress, TempDir: q.tempDir}
+	if q.ingestOptions != nil {
+		opts = q.ingestOptions(index)
+	}
+	if opts.TempDir != "" {
+		return q.bulkIngestViaDisk(ctx, index, docs, opts)
 	}
-	return q.bulkIngestInMemory(ctx, index, docs)
+	return q.bulkIngestInMemory(ctx, index, docs, opts)
 }
 
-// bulkIngestInMemory stages the NDJSON payload entirely in memory.
-func (q *Quickwit) bulkIngestInMemory(ctx context.Context, index string, docs []json.RawMessage) error {
-	// Build NDJSON body.
-	var raw bytes.Buffer
-	for _, doc := range docs {
-		var docMap map[string]json.RawMessage
-		if err := json.Unmarshal(doc, &docMap); err == nil {
-			if src, ok := docMap["_source"]; ok {
-				raw.Write(src)
-				raw.WriteByte('\n')
-				continue
-			}
+// bulkIngestInMemory sends the NDJSON payload without staging it on disk.
+// Uncompressed payloads are built in a pooled buffer; compressed payloads
+// are streamed through gzip straight into the request body, so only the
+// compressed bytes in flight are ever held in memory.
+func (q *Quickwit) bulkIngestInMemory(ctx context.Context, index string, docs []json.RawMessage, opts IngestOptions) error {
+	if !opts.Compress {
+		raw := getBuffer()
+		if err := writeNDJSON(raw, docs); err != nil {
+			putBuffer(raw)
+			return fmt.Errorf("building ndjson: %w", err)
 		}
-		raw.Write(doc)
-		raw.WriteByte('\n')
+		return q.sendIngest(ctx, index, newPooledBody(raw), "")
 	}
 
-	var body io.Reader = &raw
-	contentEncoding := ""
+	// Gzip compress (significant savings for 200GB+ daily transfers).
+	pr, pw := io.Pipe()
+	defer pr.Close() // unblocks the writer if the request never reads the body
+	go func() {
+		gz, err := gzip.NewWriterLevel(pw, gzip.BestSpeed)
+		if err == nil {
+			if err = writeNDJSON(gz, docs); err == nil {
+				err = gz.Close()
+			}
+		}
+		pw.CloseWithError(err)
+	}()
 
-	// Gzip compress if enabled (significant savings for 200GB+ daily transfers).
-	if q.compress {
-		var compressed bytes.Buffer
-		gz, err := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
-		if err != nil {
+	return q.sendIngest(ctx, index, pr, "gzip")
+}
+
+// bulkIngestViaDisk stages the NDJSON payload to a temporary file on disk,
+// reducing memory usage for large batches. With compression enabled the
+// file is written through gzip, so the payload is staged once, compressed.
+func (q *Quickwit) bulkIngestViaDisk(ctx context.Context, index string, docs []json.RawMessage, opts IngestOptions) error {
+	pattern, contentEncoding := "oqbridge-ingest-*.ndjson", ""
+	if opts.Compress {
+		pattern, contentEncoding = "oqbridge-ingest-*.ndjson.gz", "gzip"
+	}
+	f, err := os.CreateTemp(opts.TempDir, pattern)
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	var w io.Writer = bw
+	var gz *gzip.Writer
+	if opts.Compress {
+		if gz, err = gzip.NewWriterLevel(bw, gzip.BestSpeed); err != nil {
 			return fmt.Errorf("gzip init: %w", err)
 		}
-		if _, err := gz.Write(raw.Bytes()); err != nil {
-			return fmt.Errorf("gzip compression: %w", err)
-		}
+		w = gz
+	}
+	if err := writeNDJSON(w, docs); err != nil {
+		return fmt.Errorf("writing to temp file: %w", err)
+	}
+	if gz != nil {
 		if err := gz.Close(); err != nil {
 			return fmt.Errorf("gzip close: %w", err)
 		}
-		body = &compressed
-		contentEncoding = "gzip"
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flushing temp file: %w", err)
 	}
 
-	return q.sendIngest(ctx, index, body, contentEncoding)
-}
-
-// bulkIngestViaDisk stages the NDJSON payload to a temporary file on disk,
-// reducing memory usage for large batches.
-func (q *Quickwit) bulkIngestViaDisk(ctx context.Context, index string, docs []json.RawMessage) error {
-	// Write NDJSON to a temp file.
-	ndjsonFile, err := os.CreateTemp(q.tempDir, "oqbridge-ingest-*.ndjson")
+	info, err := f.Stat()
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return fmt.Errorf("sizing staged file: %w", err)
 	}
-	tmpPath := ndjsonFile.Name()
-	defer os.Remove(tmpPath)
 
+	return q.sendIngest(ctx, index, io.NewSectionReader(f, 0, info.Size()), contentEncoding)
+}
+
+// writeNDJSON writes one line per document, unwrapping "_source" when a
+// document is a full search hit.
+func writeNDJSON(w io.Writer, docs []json.RawMessage) error {
 	for _, doc := range docs {
+		line := doc
 		var docMap map[string]json.RawMessage
 		if err := json.Unmarshal(doc, &docMap); err == nil {
 			if src, ok := docMap["_source"]; ok {
-				if _, err := ndjsonFile.Write(src); err != nil {
-					ndjsonFile.Close()
-					return fmt.Errorf("writing to temp file: %w", err)
-				}
-				if _, err := ndjsonFile.Write([]byte{'\n'}); err != nil {
-					ndjsonFile.Close()
-					return fmt.Errorf("writing newline to temp file: %w", err)
-				}
-				continue
+				line = src
 			}
 		}
-		if _, err := ndjsonFile.Write(doc); err != nil {
-			ndjsonFile.Close()
-			return fmt.Errorf("writing to temp file: %w", err)
+		if _, err := w.Write(line); err != nil {
+			return err
 		}
-		if _, err := ndjsonFile.Write([]byte{'\n'}); err != nil {
-			ndjsonFile.Close()
-			return fmt.Errorf("writing newline to temp file: %w", err)
+		if _, err := w.Write([]byte{'\n'}); err != nil {
+			return err
 		}
 	}
-	if err := ndjsonFile.Close(); err != nil {
-		return fmt.Errorf("closing temp file: %w", err)
-	}
-
-	contentEncoding := ""
-	uploadPath := tmpPath
-
-	// Gzip compress the temp file if enabled.
-	if q.compress {
-		gzPath := tmpPath + ".gz"
-		defer os.Remove(gzPath)
-
-		if err := gzipFile(tmpPath, gzPath); err != nil {
-			return fmt.Errorf("gzip compression: %w", err)
-		}
-		uploadPath = gzPath
-		contentEncoding = "gzip"
-	}
+	return nil
+}
 
-	f, err := os.Open(uploadPath)
-	if err != nil {
-		return fmt.Errorf("opening staged file: %w", err)
+// ingestURL returns the ingest endpoint for index under the configured
+// API version and commit mode.
+func (q *Quickwit) ingestURL(index string) string {
+	url := fmt.Sprintf("%s/api/%s/%s/ingest", q.baseURL, q.effectiveIngestAPI(), index)
+	if q.ingestCommit != "" && q.ingestCommit != "auto" {
+		url += "?commit=" + q.ingestCommit
 	}
-	defer f.Close()
-
-	return q.sendIngest(ctx, index, f, contentEncoding)
+	return url
 }
 
 // sendIngest sends an ingest request to Quickwit.
 func (q *Quickwit) sendIngest(ctx context.Context, index string, body io.Reader, contentEncoding string) error {
-	url := fmt.Sprintf("%s/api/v1/%s/ingest", q.baseURL, index)
+	url := q.ingestURL(index)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return fmt.Errorf("creating ingest request: %w", err)
 	}
+	// Give sized bodies a Content-Length; streamed bodies go out chunked.
+	if req.ContentLength == 0 {
+		switch b := body.(type) {
+		case interface{ Len() int }:
+			req.ContentLength = int64(b.Len())
+		case interface{ Size() int64 }:
+			req.ContentLength = b.Size()
+		}
+	}
 	req.Header.Set("Content-Type", "application/x-ndjson")
 	if contentEncoding != "" {
 		req.Header.Set("Content-Encoding", contentEncoding)
@@ -232,36 +369,180 @@ func (q *Quickwit) sendIngest(ctx context.Context, index string, body io.Reader,
 	return nil
 }
 
-// gzipFile compresses src to dst using gzip.
-func gzipFile(src, dst string) error {
-	in, err := os.Open(src)
+func (q *Quickwit) setAuth(req *http.Request) {
+	for name, value := range q.headers {
+		req.Header.Set(name, value)
+	}
+	q.creds.Apply(req)
+}
+
+// Health verifies that the Quickwit node is ready and that the cluster has
+// live metastore and indexer services, which are required for ingest.
+// Clusters that do not expose /api/v1/cluster (older versions) are only
+// checked for readiness.
+func (q *Quickwit) Health(ctx context.Context) error {
+	readyURL := q.baseURL + "/health/readyz"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, readyURL, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("creating readiness request: %w", err)
 	}
-	defer in.Close()
+	q.setAuth(req)
 
-	out, err := os.Create(filepath.Clean(dst))
+	resp, err := q.client.Do(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("executing readiness request: %w", err)
 	}
-	defer out.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        readyURL,
+			Body:       string(respBody),
+		}
+	}
+
+	clusterURL := q.baseURL + "/api/v1/cluster"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, clusterURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating cluster request: %w", err)
+	}
+	q.setAuth(req)
+
+	resp, err = q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing cluster request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading cluster response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        clusterURL,
+			Body:       string(respBody),
+		}
+	}
+
+	var cluster struct {
+		ReadyNodes []struct {
+			EnabledServices []string `json:"enabled_services"`
+		} `json:"ready_nodes"`
+	}
+	if err := json.Unmarshal(respBody, &cluster); err != nil {
+		return fmt.Errorf("decoding cluster response: %w", err)
+	}
+	services := make(map[string]bool)
+	for _, n := range cluster.ReadyNodes {
+		for _, s := range n.EnabledServices {
+			services[s] = true
+		}
+	}
+	for _, required := range []string{"metastore", "indexer"} {
+		if !services[required] {
+			return fmt.Errorf("no ready quickwit node runs the %s service", required)
+		}
+	}
+	return nil
+}
+
+// CountRange returns the number of documents in index whose tsField lies in
+// the inclusive range [from, to], using Count.
+func (q *Quickwit) CountRange(ctx context.Context, index, tsField string, from, to time.Time) (int64, error) {
+	body, err := rangeCountBody(tsField, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return q.Count(ctx, index, body)
+}
 
-	gz, err := gzip.NewWriterLevel(out, gzip.BestSpeed)
+// Count returns the number of documents in index matching the Elasticsearch
+// query in body, through the configured search API with no hits requested.
+// An empty body counts every document.
+func (q *Quickwit) Count(ctx context.Context, index string, body []byte) (int64, error) {
+	body, err := countBody(body)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	var search map[string]json.RawMessage
+	if err := json.Unmarshal(body, &search); err != nil {
+		return 0, fmt.Errorf("decoding count body: %w", err)
+	}
+	search["size"] = json.RawMessage(`0`)
+	search["track_total_hits"] = json.RawMessage(`true`)
+	if body, err = json.Marshal(search); err != nil {
+		return 0, fmt.Errorf("marshaling count search: %w", err)
 	}
 
-	if _, err := io.Copy(gz, in); err != nil {
-		gz.Close()
-		return err
+	resp, err := q.Search(ctx, index, body)
+	if err != nil {
+		return 0, err
 	}
-	return gz.Close()
+	return int64(resp.Hits.Total.Value), nil
 }
 
-func (q *Quickwit) setAuth(req *http.Request) {
-	if q.username != "" {
-		req.SetBasicAuth(q.username, q.password)
+// SearchRange returns up to maxHits documents from index whose tsField lies
+// in the inclusive range [from, to].
+func (q *Quickwit) SearchRange(ctx context.Context, index, tsField string, from, to time.Time, maxHits int) ([]json.RawMessage, error) {
+	result, err := q.searchRange(ctx, index, tsField, from, to, maxHits)
+	if err != nil {
+		return nil, err
 	}
+	return result.Hits, nil
+}
+
+type nativeSearchResult struct {
+	NumHits int64             `json:"num_hits"`
+	Hits    []json.RawMessage `json:"hits"`
+}
+
+// searchRange runs a native time range search on index.
+func (q *Quickwit) searchRange(ctx context.Context, index, tsField string, from, to time.Time, maxHits int) (*nativeSearchResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":    fmt.Sprintf("%s:[%s TO %s]", tsField, from.UTC().Format(rangeLayout), to.UTC().Format(rangeLayout)),
+		"max_hits": maxHits,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling range query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/%s/search", q.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating range request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	q.setAuth(req)
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing range request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading range response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+
+	var result nativeSearchResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decoding range response: %w", err)
+	}
+	return &result, nil
 }
 
 // IndexExists checks if an index exists in Quickwit.
@@ -293,8 +574,34 @@ func (q *Quickwit) IndexExists(ctx context.Context, index string) (bool, error)
 	return true, nil
 }
 
-// ListIndices returns all index IDs from Quickwit.
+// ListIndices returns all index IDs from Quickwit, from the cache if one is
+// configured with SetListCacheTTL and still fresh. Quickwit returns the whole
+// list in one response; there is no page to follow.
 func (q *Quickwit) ListIndices(ctx context.Context) ([]string, error) {
+	q.listMu.Lock()
+	defer q.listMu.Unlock()
+	if q.listCacheTTL > 0 && q.listCache != nil && time.Since(q.listFetched) < q.listCacheTTL {
+		return slices.Clone(q.listCache), nil
+	}
+	indices, err := q.listIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if q.listCacheTTL > 0 {
+		q.listCache, q.listFetched = indices, time.Now()
+	}
+	return slices.Clone(indices), nil
+}
+
+// invalidateListCache drops the cached index list after an index is created
+// or deleted.
+func (q *Quickwit) invalidateListCache() {
+	q.listMu.Lock()
+	q.listCache = nil
+	q.listMu.Unlock()
+}
+
+func (q *Quickwit) listIndices(ctx context.Context) ([]string, error) {
 	url := fmt.Sprintf("%s/api/v1/indexes", q.baseURL)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -341,8 +648,13 @@ func (q *Quickwit) ListIndices(ctx context.Context) ([]string, error) {
 
 // CreateIndex creates a new index in Quickwit with dynamic schema mode.
 // If retentionDays > 0, a retention policy is set so Quickwit automatically
-// deletes data older than the specified number of days.
+// deletes data older than the specified number of days. Indexing and search
+// settings come from the SetIndexSettings resolver, if any.
 func (q *Quickwit) CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error {
+	settings := IndexSettings{CommitTimeoutSecs: defaultCommitTimeoutSecs}
+	if q.indexSettings != nil {
+		settings = q.indexSettings(index)
+	}
 	indexConfig := map[string]interface{}{
 		"version":  "0.8",
 		"index_id": index,
@@ -359,16 +671,16 @@ func (q *Quickwit) CreateIndex(ctx context.Context, index string, timestampField
 				},
 			},
 		},
-		"indexing_settings": map[string]interface{}{
-			"commit_timeout_secs": 60,
-		},
+		"indexing_settings": settings.indexingSettings(),
+	}
+	if len(settings.DefaultSearchFields) > 0 {
+		indexConfig["search_settings"] = map[string]interface{}{
+			"default_search_fields": settings.DefaultSearchFields,
+		}
 	}
 
 	if retentionDays > 0 {
-		indexConfig["retention"] = map[string]interface{}{
-			"period":   fmt.Sprintf("%d days", retentionDays),
-			"schedule": "daily",
-		}
+		indexConfig["retention"] = retentionPolicy(retentionDays)
 	}
 
 	body, err := json.Marshal(indexConfig)
@@ -398,5 +710,6 @@ func (q *Quickwit) CreateIndex(ctx context.Context, index string, timestampField
 			Body:       string(respBody),
 		}
 	}
+	q.invalidateListCache()
 	return nil
 }
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// msearchItem is one entry of an _msearch response: either a search response
+// or an error with its status.
+type msearchItem struct {
+	SearchResponse
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// MultiSearch runs the same search body against each of indices in a single
+// request to Quickwit's Elasticsearch-compatible _msearch endpoint. Responses
+// are returned in the order of indices. If any index fails, the first
+// failure is returned as an *HTTPStatusError.
+func (q *Quickwit) MultiSearch(ctx context.Context, indices []string, body []byte) ([]*SearchResponse, error) {
+	// Each search body must sit on a single NDJSON line.
+	var compact bytes.Buffer
+	if len(bytes.TrimSpace(body)) == 0 {
+		compact.WriteString("{}")
+	} else if err := json.Compact(&compact, body); err != nil {
+		return nil, fmt.Errorf("compacting search body: %w", err)
+	}
+
+	var payload bytes.Buffer
+	for _, index := range indices {
+		header, err := json.Marshal(map[string]string{"index": index})
+		if err != nil {
+			return nil, fmt.Errorf("marshaling msearch header: %w", err)
+		}
+		payload.Write(header)
+		payload.WriteByte('\n')
+		payload.Write(compact.Bytes())
+		payload.WriteByte('\n')
+	}
+
+	url := fmt.Sprintf("%s/api/v1/_elastic/_msearch", q.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("creating msearch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	q.setAuth(req)
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing msearch request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading msearch response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		slog.Error("quickwit msearch error", "status", resp.StatusCode, "body", string(respBody))
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+
+	var result struct {
+		Responses []msearchItem `json:"responses"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decoding msearch response: %w", err)
+	}
+	if len(result.Responses) != len(indices) {
+		return nil, fmt.Errorf("msearch returned %d responses for %d indices", len(result.Responses), len(indices))
+	}
+
+	out := make([]*SearchResponse, len(indices))
+	for i := range result.Responses {
+		item := &result.Responses[i]
+		if len(item.Error) > 0 && string(item.Error) != "null" {
+			status := item.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			return nil, fmt.Errorf("searching quickwit index %s: %w", indices[i], &HTTPStatusError{
+				StatusCode: status,
+				URL:        url,
+				Body:       string(item.Error),
+			})
+		}
+		out[i] = &item.SearchResponse
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuickwit_Search_Elastic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/_elastic/logs/_search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"took":4,"timed_out":false,"hits":{"total":{"value":1,"relation":"eq"},"max_score":1.5,"hits":[{"_score":1.5,"_source":{"msg":"a"}}]}}`))
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetSearchAPI(SearchAPIElastic)
+	resp, err := qw.Search(context.Background(), "logs", []byte(`{"query":{"match_all":{}}}`))
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if resp.Hits.Total.Value != 1 || resp.Hits.MaxScore == nil || *resp.Hits.MaxScore != 1.5 {
+		t.Fatalf("resp=%+v", resp)
+	}
+}
+
+func TestQuickwit_MultiSearch(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/_elastic/_msearch" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		w.Write([]byte(`{"responses":[
+			{"took":1,"hits":{"total":{"value":2,"relation":"eq"},"hits":[]},"status":200},
+			{"took":2,"hits":{"total":{"value":3,"relation":"eq"},"hits":[]},"status":200}
+		]}`))
+	}))
+	defer srv.Close()
+
+	body := []byte("{\n  \"query\": {\"match_all\": {}}\n}")
+	responses, err := NewQuickwit(srv.URL, "", "", false, nil).MultiSearch(context.Background(), []string{"a", "b"}, body)
+	if err != nil {
+		t.Fatalf("MultiSearch: %v", err)
+	}
+	want := []string{`{"index":"a"}`, `{"query":{"match_all":{}}}`, `{"index":"b"}`, `{"query":{"match_all":{}}}`}
+	if len(lines) != len(want) {
+		t.Fatalf("request lines=%q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d=%s, want %s", i, lines[i], want[i])
+		}
+	}
+	if len(responses) != 2 || responses[0].Hits.Total.Value != 2 || responses[1].Hits.Total.Value != 3 {
+		t.Fatalf("responses=%+v", responses)
+	}
+}
+
+func TestQuickwit_MultiSearch_ItemError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"responses":[
+			{"took":1,"hits":{"total":{"value":2,"relation":"eq"},"hits":[]},"status":200},
+			{"error":{"type":"index_not_found_exception","reason":"b"},"status":404}
+		]}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewQuickwit(srv.URL, "", "", false, nil).MultiSearch(context.Background(), []string{"a", "b"}, nil)
+	var httpErr *HTTPStatusError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 HTTPStatusError, got %v", err)
+	}
+	var body map[string]string
+	if json.Unmarshal([]byte(httpErr.Body), &body) != nil || body["type"] != "index_not_found_exception" {
+		t.Fatalf("error body=%s", httpErr.Body)
+	}
+}
@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modes that follow the features detected by DetectFeatures.
+const (
+	SearchAPIAuto = "auto" // SearchAPIElastic if the cluster has the _elastic endpoints, SearchAPIPassthrough otherwise
+	IngestAPIAuto = "auto" // "v2" if the cluster has the v2 ingest API, "v1" otherwise
+)
+
+// featureDetectTimeout bounds the detection QuickwitRouter.WatchFeatures
+// waits for at startup.
+const featureDetectTimeout = 10 * time.Second
+
+// QuickwitFeatures are the optional APIs of a Quickwit cluster, derived from
+// the version it reports.
+type QuickwitFeatures struct {
+	Version      string    `json:"version"`
+	Aggregations bool      `json:"aggregations"` // aggregations in search requests
+	Sort         bool      `json:"sort"`         // sort_by in search requests
+	ElasticAPI   bool      `json:"elastic_api"`  // _elastic _search and _msearch endpoints
+	IngestV2     bool      `json:"ingest_v2"`    // /api/v2/{index}/ingest
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// quickwitFeatureVersions are the first Quickwit releases offering each
+// feature.
+var quickwitFeatureVersions = struct{ aggregations, sort, elasticAPI, ingestV2 [2]int }{
+	aggregations: [2]int{0, 4},
+	sort:         [2]int{0, 4},
+	elasticAPI:   [2]int{0, 6},
+	ingestV2:     [2]int{0, 9},
+}
+
+// featuresForVersion returns the features of Quickwit version, e.g.
+// "0.8.2" or "0.9.0-rc1". A version that does not start with a major and
+// minor number, such as a development build, is assumed to have them all.
+func featuresForVersion(version string) QuickwitFeatures {
+	f := QuickwitFeatures{Version: version}
+	major, minor, ok := parseMajorMinor(version)
+	atLeast := func(v [2]int) bool {
+		return !ok || major > v[0] || (major == v[0] && minor >= v[1])
+	}
+	f.Aggregations = atLeast(quickwitFeatureVersions.aggregations)
+	f.Sort = atLeast(quickwitFeatureVersions.sort)
+	f.ElasticAPI = atLeast(quickwitFeatureVersions.elasticAPI)
+	f.IngestV2 = atLeast(quickwitFeatureVersions.ingestV2)
+	return f
+}
+
+func parseMajorMinor(version string) (major, minor int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// DetectFeatures reads the version of the cluster from /api/v1/version and
+// keeps the features it implies, which Capabilities and the "auto" search
+// and ingest modes follow from then on. On error the features detected
+// before are kept; if there are none, Capabilities reports sort and
+// aggregations and the auto modes use passthrough and v1.
+func (q *Quickwit) DetectFeatures(ctx context.Context) (*QuickwitFeatures, error) {
+	body, err := q.doIndexRequest(ctx, http.MethodGet, q.baseURL+"/api/v1/version", nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading quickwit version: %w", err)
+	}
+	var resp struct {
+		Build struct {
+			Version string `json:"version"`
+		} `json:"build"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing quickwit version: %w", err)
+	}
+	version := resp.Build.Version
+	if version == "" {
+		version = resp.Version
+	}
+	if version == "" {
+		return nil, fmt.Errorf("quickwit did not report its version")
+	}
+
+	f := featuresForVersion(version)
+	f.DetectedAt = time.Now().UTC()
+	if prev := q.features.Swap(&f); prev == nil || prev.Version != f.Version {
+		slog.Info("quickwit features detected", "url", q.baseURL, "version", f.Version,
+			"aggregations", f.Aggregations, "sort", f.Sort, "elastic_api", f.ElasticAPI, "ingest_v2", f.IngestV2,
+			"search_api", q.effectiveSearchAPI(), "ingest_api", q.effectiveIngestAPI())
+		if q.searchAPI == SearchAPIElastic && !f.ElasticAPI {
+			slog.Warn("quickwit.search_api is elastic, but this Quickwit version has no _elastic endpoints", "url", q.baseURL, "version", f.Version)
+		}
+		if q.ingestAPI == "v2" && !f.IngestV2 {
+			slog.Warn("quickwit.ingest_api is v2, but this Quickwit version has no v2 ingest API", "url", q.baseURL, "version", f.Version)
+		}
+	}
+	return &f, nil
+}
+
+// Features returns the features last detected by DetectFeatures, or nil if
+// they were never detected.
+func (q *Quickwit) Features() *QuickwitFeatures {
+	return q.features.Load()
+}
+
+// WatchFeatures runs DetectFeatures every interval until ctx is done, so
+// that an upgrade or downgrade of the cluster is followed without a
+// restart.
+func (q *Quickwit) WatchFeatures(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := q.DetectFeatures(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("detecting quickwit features failed", "url", q.baseURL, "error", err)
+			}
+		}
+	}
+}
+
+// effectiveSearchAPI resolves SearchAPIAuto against the detected features.
+// Until they are detected, auto behaves like SearchAPIPassthrough.
+func (q *Quickwit) effectiveSearchAPI() string {
+	if q.searchAPI != SearchAPIAuto {
+		return q.searchAPI
+	}
+	if f := q.features.Load(); f != nil && f.ElasticAPI {
+		return SearchAPIElastic
+	}
+	return SearchAPIPassthrough
+}
+
+// effectiveIngestAPI resolves IngestAPIAuto against the detected features.
+// Until they are detected, auto behaves like "v1".
+func (q *Quickwit) effectiveIngestAPI() string {
+	switch q.ingestAPI {
+	case "":
+		return "v1"
+	case IngestAPIAuto:
+		if f := q.features.Load(); f != nil && f.IngestV2 {
+			return "v2"
+		}
+		return "v1"
+	}
+	return q.ingestAPI
+}
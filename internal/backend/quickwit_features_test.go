@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeaturesForVersion(t *testing.T) {
+	tests := []struct {
+		version                                  string
+		aggregations, sort, elasticAPI, ingestV2 bool
+	}{
+		{"0.3.1", false, false, false, false},
+		{"0.5.0", true, true, false, false},
+		{"v0.8.2", true, true, true, false},
+		{"0.9.0-rc1", true, true, true, true},
+		{"1.0", true, true, true, true},
+		{"main", true, true, true, true},
+	}
+	for _, tt := range tests {
+		f := featuresForVersion(tt.version)
+		if f.Aggregations != tt.aggregations || f.Sort != tt.sort || f.ElasticAPI != tt.elasticAPI || f.IngestV2 != tt.ingestV2 {
+			t.Errorf("featuresForVersion(%q) = %+v", tt.version, f)
+		}
+	}
+}
+
+// fakeVersionedQuickwit serves /api/v1/version reporting *version and
+// records the paths of the other requests it receives.
+func fakeVersionedQuickwit(t *testing.T, version *string, paths *[]string) *Quickwit {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/version" {
+			json.NewEncoder(w).Encode(map[string]any{"build": map[string]string{"version": *version}})
+			return
+		}
+		*paths = append(*paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/ingest") {
+			w.Write([]byte(`{}`))
+			return
+		}
+		json.NewEncoder(w).Encode(SearchResponse{Hits: HitsResult{Total: HitsTotal{Relation: "eq"}}})
+	}))
+	t.Cleanup(srv.Close)
+	return NewQuickwit(srv.URL, "", "", false, srv.Client())
+}
+
+func TestQuickwit_DetectFeatures(t *testing.T) {
+	version := "0.3.0"
+	var paths []string
+	qw := fakeVersionedQuickwit(t, &version, &paths)
+	qw.SetSearchAPI(SearchAPIAuto)
+	qw.SetIngestMode(IngestAPIAuto, "")
+	ctx := context.Background()
+
+	if qw.Features() != nil {
+		t.Fatal("features set before detection")
+	}
+	if caps := qw.Capabilities(); !caps.SupportsAggregations || !caps.SupportsSort {
+		t.Fatalf("undetected capabilities %+v, want aggregations and sort", caps)
+	}
+
+	for _, tt := range []struct {
+		version                string
+		aggregations, multi    bool
+		searchPath, ingestPath string
+	}{
+		{"0.3.0", false, false, "/api/v1/logs/search", "/api/v1/logs/ingest"},
+		{"0.8.2", true, true, "/api/v1/_elastic/logs/_search", "/api/v1/logs/ingest"},
+		{"0.9.1", true, true, "/api/v1/_elastic/logs/_search", "/api/v2/logs/ingest"},
+	} {
+		version = tt.version
+		f, err := qw.DetectFeatures(ctx)
+		if err != nil {
+			t.Fatalf("DetectFeatures(%s): %v", tt.version, err)
+		}
+		if f.Version != tt.version || qw.Features().Version != tt.version || f.DetectedAt.IsZero() {
+			t.Fatalf("%s: features %+v", tt.version, f)
+		}
+		caps := qw.Capabilities()
+		if caps.SupportsAggregations != tt.aggregations || caps.SupportsMultiSearch != tt.multi {
+			t.Fatalf("%s: capabilities %+v", tt.version, caps)
+		}
+
+		paths = nil
+		if _, err := qw.Search(ctx, "logs", []byte(`{"query":{"match_all":{}}}`)); err != nil {
+			t.Fatalf("%s: Search: %v", tt.version, err)
+		}
+		if err := qw.BulkIngest(ctx, "logs", []json.RawMessage{[]byte(`{"a":1}`)}); err != nil {
+			t.Fatalf("%s: BulkIngest: %v", tt.version, err)
+		}
+		if len(paths) != 2 || paths[0] != tt.searchPath || paths[1] != tt.ingestPath {
+			t.Fatalf("%s: requested %v, want [%s %s]", tt.version, paths, tt.searchPath, tt.ingestPath)
+		}
+	}
+}
+
+func TestQuickwit_DetectFeatures_ExplicitModes(t *testing.T) {
+	version := "0.9.0"
+	var paths []string
+	qw := fakeVersionedQuickwit(t, &version, &paths)
+	qw.SetSearchAPI(SearchAPIPassthrough)
+	qw.SetIngestMode("v1", "")
+	ctx := context.Background()
+
+	if _, err := qw.DetectFeatures(ctx); err != nil {
+		t.Fatalf("DetectFeatures: %v", err)
+	}
+	if _, err := qw.Search(ctx, "logs", []byte(`{}`)); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if err := qw.BulkIngest(ctx, "logs", []json.RawMessage{[]byte(`{"a":1}`)}); err != nil {
+		t.Fatalf("BulkIngest: %v", err)
+	}
+	if want := []string{"/api/v1/logs/search", "/api/v1/logs/ingest"}; strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Fatalf("requested %v, want %v", paths, want)
+	}
+}
+
+func TestQuickwit_DetectFeatures_Error(t *testing.T) {
+	version := "0.9.0"
+	var paths []string
+	qw := fakeVersionedQuickwit(t, &version, &paths)
+	if _, err := qw.DetectFeatures(context.Background()); err != nil {
+		t.Fatalf("DetectFeatures: %v", err)
+	}
+
+	version = ""
+	if _, err := qw.DetectFeatures(context.Background()); err == nil {
+		t.Fatal("expected an error for a missing version")
+	}
+	if f := qw.Features(); f == nil || f.Version != "0.9.0" {
+		t.Fatalf("features %+v, want the ones detected before", f)
+	}
+}
+
+func TestQuickwitRouter_DetectFeatures(t *testing.T) {
+	defVersion, euVersion := "0.8.0", "0.9.0"
+	var paths []string
+	r := NewQuickwitRouter(
+		fakeVersionedQuickwit(t, &defVersion, &paths),
+		map[string]*Quickwit{"eu": fakeVersionedQuickwit(t, &euVersion, &paths)},
+		euRoute,
+	)
+	if err := r.DetectFeatures(context.Background()); err != nil {
+		t.Fatalf("DetectFeatures: %v", err)
+	}
+	if got := r.For("us-logs").Features().Version; got != "0.8.0" {
+		t.Fatalf("default cluster version %s", got)
+	}
+	if got := r.For("eu-logs").Features().Version; got != "0.9.0" {
+		t.Fatalf("eu cluster version %s", got)
+	}
+
+	euVersion = ""
+	err := r.DetectFeatures(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "quickwit cluster eu") {
+		t.Fatalf("err = %v, want one naming the eu cluster", err)
+	}
+}
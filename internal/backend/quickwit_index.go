@@ -0,0 +1,274 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SplitInfo describes a published Quickwit split.
+type SplitInfo struct {
+	ID      string
+	NumDocs int64
+	// TimeRangeEnd is the latest document timestamp in the split; zero if
+	// the index has no timestamp field.
+	TimeRangeEnd time.Time
+}
+
+// IndexStats summarizes the published data of a Quickwit index.
+type IndexStats struct {
+	Index             string     `json:"index"`
+	NumDocs           int64      `json:"num_docs"`
+	NumSplits         int64      `json:"num_splits"`
+	SizeBytes         int64      `json:"size_bytes"`         // storage used by published splits
+	UncompressedBytes int64      `json:"uncompressed_bytes"` // uncompressed size of the documents
+	MinTimestamp      *time.Time `json:"min_timestamp,omitempty"`
+	MaxTimestamp      *time.Time `json:"max_timestamp,omitempty"`
+}
+
+// IndexSettings are the indexing and search settings of an index created by
+// CreateIndex. Zero values leave the Quickwit default in place.
+type IndexSettings struct {
+	CommitTimeoutSecs   int
+	SplitNumDocsTarget  int
+	MergePolicy         MergePolicy
+	DefaultSearchFields []string
+}
+
+// MergePolicy is the merge_policy section of an index config. An empty Type
+// omits the section.
+type MergePolicy struct {
+	Type             string // "stable_log", "limit_merge" or "no_merge"
+	MergeFactor      int
+	MaxMergeFactor   int
+	MaturationPeriod string
+}
+
+// defaultCommitTimeoutSecs is used when no IndexSettings resolver is set.
+const defaultCommitTimeoutSecs = 60
+
+// indexingSettings returns the indexing_settings section of an index config.
+func (s IndexSettings) indexingSettings() map[string]interface{} {
+	out := map[string]interface{}{}
+	if s.CommitTimeoutSecs > 0 {
+		out["commit_timeout_secs"] = s.CommitTimeoutSecs
+	}
+	if s.SplitNumDocsTarget > 0 {
+		out["split_num_docs_target"] = s.SplitNumDocsTarget
+	}
+	if mp := s.MergePolicy; mp.Type != "" {
+		policy := map[string]interface{}{"type": mp.Type}
+		if mp.MergeFactor > 0 {
+			policy["merge_factor"] = mp.MergeFactor
+		}
+		if mp.MaxMergeFactor > 0 {
+			policy["max_merge_factor"] = mp.MaxMergeFactor
+		}
+		if mp.MaturationPeriod != "" {
+			policy["maturation_period"] = mp.MaturationPeriod
+		}
+		out["merge_policy"] = policy
+	}
+	return out
+}
+
+// retentionPolicy returns the retention section of an index config.
+func retentionPolicy(retentionDays int) map[string]interface{} {
+	return map[string]interface{}{
+		"period":   fmt.Sprintf("%d days", retentionDays),
+		"schedule": "daily",
+	}
+}
+
+// DescribeIndex returns document, split and storage statistics of an index.
+func (q *Quickwit) DescribeIndex(ctx context.Context, index string) (*IndexStats, error) {
+	url := fmt.Sprintf("%s/api/v1/indexes/%s/describe", q.baseURL, index)
+	respBody, err := q.doIndexRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("describing index: %w", err)
+	}
+
+	var desc struct {
+		NumPublishedSplits            int64  `json:"num_published_splits"`
+		SizePublishedSplits           int64  `json:"size_published_splits"`
+		NumPublishedDocs              int64  `json:"num_published_docs"`
+		SizePublishedDocsUncompressed int64  `json:"size_published_docs_uncompressed"`
+		MinTimestamp                  *int64 `json:"min_timestamp"`
+		MaxTimestamp                  *int64 `json:"max_timestamp"`
+	}
+	if err := json.Unmarshal(respBody, &desc); err != nil {
+		return nil, fmt.Errorf("decoding describe response: %w", err)
+	}
+
+	stats := &IndexStats{
+		Index:             index,
+		NumDocs:           desc.NumPublishedDocs,
+		NumSplits:         desc.NumPublishedSplits,
+		SizeBytes:         desc.SizePublishedSplits,
+		UncompressedBytes: desc.SizePublishedDocsUncompressed,
+	}
+	if desc.MinTimestamp != nil {
+		t := time.Unix(*desc.MinTimestamp, 0).UTC()
+		stats.MinTimestamp = &t
+	}
+	if desc.MaxTimestamp != nil {
+		t := time.Unix(*desc.MaxTimestamp, 0).UTC()
+		stats.MaxTimestamp = &t
+	}
+	return stats, nil
+}
+
+// DeleteIndex deletes an index and all of its splits from Quickwit.
+// Deleting an index that does not exist is not an error.
+func (q *Quickwit) DeleteIndex(ctx context.Context, index string) error {
+	url := fmt.Sprintf("%s/api/v1/indexes/%s", q.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating delete index request: %w", err)
+	}
+	q.setAuth(req)
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing delete index request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	q.invalidateListCache()
+	return nil
+}
+
+// UpdateRetention replaces the retention policy of an existing index.
+// A retentionDays of 0 removes the policy so data is kept forever. The
+// current index config is fetched and sent back with only the retention
+// section changed.
+func (q *Quickwit) UpdateRetention(ctx context.Context, index string, retentionDays int) error {
+	url := fmt.Sprintf("%s/api/v1/indexes/%s", q.baseURL, index)
+	respBody, err := q.doIndexRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("fetching index config: %w", err)
+	}
+	var metadata struct {
+		IndexConfig map[string]json.RawMessage `json:"index_config"`
+	}
+	if err := json.Unmarshal(respBody, &metadata); err != nil {
+		return fmt.Errorf("decoding index metadata: %w", err)
+	}
+	if metadata.IndexConfig == nil {
+		return fmt.Errorf("index metadata for %s has no index_config", index)
+	}
+
+	delete(metadata.IndexConfig, "retention")
+	if retentionDays > 0 {
+		policy, err := json.Marshal(retentionPolicy(retentionDays))
+		if err != nil {
+			return fmt.Errorf("marshaling retention policy: %w", err)
+		}
+		metadata.IndexConfig["retention"] = policy
+	}
+	body, err := json.Marshal(metadata.IndexConfig)
+	if err != nil {
+		return fmt.Errorf("marshaling index config: %w", err)
+	}
+	if _, err := q.doIndexRequest(ctx, http.MethodPut, url, body); err != nil {
+		return fmt.Errorf("updating index config: %w", err)
+	}
+	return nil
+}
+
+// ListSplits returns the published splits of an index.
+func (q *Quickwit) ListSplits(ctx context.Context, index string) ([]SplitInfo, error) {
+	url := fmt.Sprintf("%s/api/v1/indexes/%s/splits?split_states=Published", q.baseURL, index)
+	respBody, err := q.doIndexRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing splits: %w", err)
+	}
+
+	var result struct {
+		Splits []struct {
+			SplitID   string `json:"split_id"`
+			NumDocs   int64  `json:"num_docs"`
+			TimeRange *struct {
+				End int64 `json:"end"`
+			} `json:"time_range"`
+		} `json:"splits"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decoding splits response: %w", err)
+	}
+
+	splits := make([]SplitInfo, 0, len(result.Splits))
+	for _, s := range result.Splits {
+		info := SplitInfo{ID: s.SplitID, NumDocs: s.NumDocs}
+		if s.TimeRange != nil {
+			info.TimeRangeEnd = time.Unix(s.TimeRange.End, 0).UTC()
+		}
+		splits = append(splits, info)
+	}
+	return splits, nil
+}
+
+// MarkSplitsForDeletion marks splits for deletion. Quickwit's garbage
+// collector removes their files afterwards; marked splits are no longer searched.
+func (q *Quickwit) MarkSplitsForDeletion(ctx context.Context, index string, splitIDs []string) error {
+	if len(splitIDs) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string][]string{"split_ids": splitIDs})
+	if err != nil {
+		return fmt.Errorf("marshaling split ids: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/indexes/%s/splits/mark-for-deletion", q.baseURL, index)
+	if _, err := q.doIndexRequest(ctx, http.MethodPut, url, body); err != nil {
+		return fmt.Errorf("marking splits for deletion: %w", err)
+	}
+	return nil
+}
+
+// doIndexRequest sends an index management request and returns the
+// response body, or an *HTTPStatusError for a 4xx/5xx status.
+func (q *Quickwit) doIndexRequest(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	q.setAuth(req)
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	return respBody, nil
+}
@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DocMapping is the doc_mapping of a Quickwit index config.
+type DocMapping struct {
+	Mode           string         `json:"mode"`
+	TimestampField string         `json:"timestamp_field"`
+	FieldMappings  []FieldMapping `json:"field_mappings"`
+}
+
+// FieldMapping is a field declared in a doc mapping. Objects hold their
+// fields in FieldMappings.
+type FieldMapping struct {
+	Name          string          `json:"name"`
+	Type          string          `json:"type"`
+	Indexed       *bool           `json:"indexed,omitempty"`
+	Fast          json.RawMessage `json:"fast,omitempty"` // true, false or, for text, the fast field options
+	Tokenizer     string          `json:"tokenizer,omitempty"`
+	FieldMappings []FieldMapping  `json:"field_mappings,omitempty"`
+}
+
+// DocMapping returns the doc mapping of index. Fields added to an index in
+// dynamic mode are not part of it.
+func (q *Quickwit) DocMapping(ctx context.Context, index string) (*DocMapping, error) {
+	url := fmt.Sprintf("%s/api/v1/indexes/%s", q.baseURL, index)
+	respBody, err := q.doIndexRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading index metadata: %w", err)
+	}
+	var metadata struct {
+		IndexConfig struct {
+			DocMapping DocMapping `json:"doc_mapping"`
+		} `json:"index_config"`
+	}
+	if err := json.Unmarshal(respBody, &metadata); err != nil {
+		return nil, fmt.Errorf("decoding index metadata: %w", err)
+	}
+	return &metadata.IndexConfig.DocMapping, nil
+}
+
+// FieldCaps returns the field capabilities of index. Clusters with the
+// _elastic endpoints answer them from the fields of the index's splits,
+// which include dynamically added fields; otherwise, or if that fails with
+// 404, they are derived from the doc mapping.
+func (q *Quickwit) FieldCaps(ctx context.Context, index string) (*FieldCaps, error) {
+	if f := q.features.Load(); f == nil || f.ElasticAPI {
+		url := fmt.Sprintf("%s/api/v1/_elastic/%s/_field_caps?fields=*", q.baseURL, index)
+		respBody, err := q.doIndexRequest(ctx, http.MethodGet, url, nil)
+		if err == nil {
+			var caps FieldCaps
+			if err := json.Unmarshal(respBody, &caps); err != nil {
+				return nil, fmt.Errorf("decoding field caps response: %w", err)
+			}
+			return &caps, nil
+		}
+		var httpErr *HTTPStatusError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("reading field caps: %w", err)
+		}
+	}
+
+	mapping, err := q.DocMapping(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	caps := &FieldCaps{Indices: []string{index}, Fields: map[string]map[string]FieldCapability{}}
+	walkFieldMappings(mapping.FieldMappings, "", func(name string, fm FieldMapping) {
+		caps.Fields[name] = map[string]FieldCapability{fm.ESType(): {
+			Type:         fm.ESType(),
+			Searchable:   fm.Type != "object" && (fm.Indexed == nil || *fm.Indexed),
+			Aggregatable: fm.IsFast(),
+		}}
+	})
+	return caps, nil
+}
+
+// walkFieldMappings calls fn with the dotted name of every field in fields
+// and, recursively, in its objects.
+func walkFieldMappings(fields []FieldMapping, prefix string, fn func(name string, fm FieldMapping)) {
+	for _, fm := range fields {
+		name := prefix + fm.Name
+		fn(name, fm)
+		if fm.Type == "object" {
+			walkFieldMappings(fm.FieldMappings, name+".", fn)
+		}
+	}
+}
+
+// ESType returns the Elasticsearch type of the field: text fields with the
+// raw tokenizer are keywords, and arrays have the type of their elements.
+func (fm FieldMapping) ESType() string {
+	typ := strings.TrimSuffix(strings.TrimPrefix(fm.Type, "array<"), ">")
+	switch typ {
+	case "text":
+		if fm.Tokenizer == "raw" {
+			return "keyword"
+		}
+		return "text"
+	case "i64":
+		return "long"
+	case "u64":
+		return "unsigned_long"
+	case "f64":
+		return "double"
+	case "bool":
+		return "boolean"
+	case "datetime":
+		return "date"
+	case "bytes":
+		return "binary"
+	case "json", "object":
+		return "object"
+	}
+	return typ
+}
+
+// IsFast reports whether the field is a fast field, which Quickwit can sort
+// and aggregate on.
+func (fm FieldMapping) IsFast() bool {
+	fast := strings.TrimSpace(string(fm.Fast))
+	return fast != "" && fast != "false" && fast != "null"
+}
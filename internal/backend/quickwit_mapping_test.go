@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestQuickwit_FieldCaps(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/_elastic/logs/_field_caps":
+			w.Write([]byte(`{"indices":["logs"],"fields":{"host.name":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true}}}}`))
+		case "/api/v1/indexes/old":
+			w.Write([]byte(`{"index_config":{"index_id":"old","doc_mapping":{"mode":"dynamic","timestamp_field":"ts","field_mappings":[
+				{"name":"ts","type":"datetime","fast":true},
+				{"name":"msg","type":"text","tokenizer":"default"},
+				{"name":"tags","type":"array<text>","tokenizer":"raw","fast":{"normalizer":"raw"}},
+				{"name":"http","type":"object","field_mappings":[{"name":"status","type":"u64","indexed":false,"fast":true}]}]}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+
+	caps, err := qw.FieldCaps(context.Background(), "logs")
+	if err != nil {
+		t.Fatalf("FieldCaps(logs): %v", err)
+	}
+	if c := caps.Fields["host.name"]["keyword"]; !c.Searchable || !c.Aggregatable {
+		t.Errorf("FieldCaps(logs) = %+v", caps)
+	}
+
+	// Without the _elastic endpoint, the doc mapping tells the types.
+	caps, err = qw.FieldCaps(context.Background(), "old")
+	if err != nil {
+		t.Fatalf("FieldCaps(old): %v", err)
+	}
+	want := map[string]map[string]FieldCapability{
+		"ts":          {"date": {Type: "date", Searchable: true, Aggregatable: true}},
+		"msg":         {"text": {Type: "text", Searchable: true}},
+		"tags":        {"keyword": {Type: "keyword", Searchable: true, Aggregatable: true}},
+		"http":        {"object": {Type: "object"}},
+		"http.status": {"unsigned_long": {Type: "unsigned_long", Aggregatable: true}},
+	}
+	if !reflect.DeepEqual(caps.Fields, want) || !reflect.DeepEqual(caps.Indices, []string{"old"}) {
+		t.Errorf("FieldCaps(old) = %+v", caps)
+	}
+
+	if _, err := qw.FieldCaps(context.Background(), "missing"); err == nil {
+		t.Error("FieldCaps(missing) succeeded")
+	}
+}
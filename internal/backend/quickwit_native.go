@@ -0,0 +1,489 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nativeSearchRequest is the body of Quickwit's native search API
+// (POST /api/v1/{index}/search). List-valued parameters are comma-separated.
+type nativeSearchRequest struct {
+	Query         string          `json:"query"`
+	MaxHits       int             `json:"max_hits"`
+	StartOffset   int             `json:"start_offset,omitempty"`
+	SortBy        string          `json:"sort_by,omitempty"`
+	SnippetFields string          `json:"snippet_fields,omitempty"`
+	Aggs          json.RawMessage `json:"aggs,omitempty"`
+}
+
+// nativeSearchResponse is the subset of the native search response that is
+// converted back into an Elasticsearch-shaped SearchResponse.
+type nativeSearchResponse struct {
+	NumHits           int64             `json:"num_hits"`
+	Hits              []json.RawMessage `json:"hits"`
+	Snippets          []json.RawMessage `json:"snippets"`
+	ElapsedTimeMicros int64             `json:"elapsed_time_micros"`
+	Aggregations      json.RawMessage   `json:"aggregations"`
+}
+
+// ignoredSearchKeys are request options that do not change which documents
+// match and are dropped when translating to a native request.
+var ignoredSearchKeys = map[string]bool{
+	"_source":          true,
+	"stored_fields":    true,
+	"timeout":          true,
+	"track_total_hits": true,
+	"track_scores":     true,
+	"version":          true,
+}
+
+// translateNativeSearch converts an Elasticsearch search body into a native
+// Quickwit search request. Only a well-defined subset of the DSL is
+// accepted; anything else is rejected rather than interpreted loosely.
+func translateNativeSearch(body []byte) (*nativeSearchRequest, error) {
+	req := &nativeSearchRequest{Query: "*", MaxHits: 10}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return req, nil
+	}
+
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decoding search body: %w", err)
+	}
+
+	for key, v := range m {
+		var err error
+		switch key {
+		case "query":
+			req.Query, err = nativeQuery(v)
+		case "size":
+			req.MaxHits, err = nativeInt(key, v)
+		case "from":
+			req.StartOffset, err = nativeInt(key, v)
+		case "sort":
+			req.SortBy, err = nativeSort(v)
+		case "highlight":
+			req.SnippetFields, err = nativeSnippetFields(v)
+		case "aggs", "aggregations":
+			req.Aggs, err = json.Marshal(v)
+		default:
+			if !ignoredSearchKeys[key] {
+				err = fmt.Errorf("option %q is not supported by the native search API", key)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func nativeInt(key string, v interface{}) (int, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	i, err := n.Int64()
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return int(i), nil
+}
+
+// nativeQuery renders an Elasticsearch query clause in Quickwit's query language.
+func nativeQuery(v interface{}) (string, error) {
+	clause, ok := v.(map[string]interface{})
+	if !ok || len(clause) != 1 {
+		return "", fmt.Errorf("query clause must be an object with a single key")
+	}
+	for kind, params := range clause {
+		switch kind {
+		case "match_all":
+			return "*", nil
+		case "bool":
+			return nativeBool(params)
+		case "query_string":
+			return nativeQueryString(params)
+		case "exists":
+			p, _ := params.(map[string]interface{})
+			field, ok := p["field"].(string)
+			if !ok {
+				return "", fmt.Errorf("exists query requires a field")
+			}
+			return field + ":*", nil
+		case "range":
+			return nativeFieldQuery(kind, params, nativeRange)
+		case "term", "match_phrase":
+			return nativeFieldQuery(kind, params, func(field string, p interface{}) (string, error) {
+				val, err := nativeFieldValue(p, "value", "query")
+				if err != nil {
+					return "", err
+				}
+				return field + ":" + quoteNativeValue(val), nil
+			})
+		case "match":
+			return nativeFieldQuery(kind, params, nativeMatch)
+		case "terms":
+			return nativeFieldQuery(kind, params, func(field string, p interface{}) (string, error) {
+				vals, ok := p.([]interface{})
+				if !ok || len(vals) == 0 {
+					return "", fmt.Errorf("terms query on %q requires a non-empty array", field)
+				}
+				parts := make([]string, len(vals))
+				for i, val := range vals {
+					parts[i] = field + ":" + quoteNativeValue(val)
+				}
+				return "(" + strings.Join(parts, " OR ") + ")", nil
+			})
+		case "prefix":
+			return nativeFieldQuery(kind, params, func(field string, p interface{}) (string, error) {
+				val, err := nativeFieldValue(p, "value")
+				if err != nil {
+					return "", err
+				}
+				s, ok := val.(string)
+				if !ok || s == "" {
+					return "", fmt.Errorf("prefix query on %q requires a string value", field)
+				}
+				return field + ":" + escapeNativeTerm(s) + "*", nil
+			})
+		default:
+			return "", fmt.Errorf("query type %q is not supported by the native search API", kind)
+		}
+	}
+	return "", nil // unreachable
+}
+
+// nativeFieldQuery unwraps a {"<field>": <params>} clause.
+func nativeFieldQuery(kind string, params interface{}, render func(field string, p interface{}) (string, error)) (string, error) {
+	m, ok := params.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", fmt.Errorf("%s query must target exactly one field", kind)
+	}
+	for field, p := range m {
+		return render(field, p)
+	}
+	return "", nil // unreachable
+}
+
+// nativeFieldValue returns p itself, or the first of keys found in p if p is
+// the long form of a field query.
+func nativeFieldValue(p interface{}, keys ...string) (interface{}, error) {
+	obj, ok := p.(map[string]interface{})
+	if !ok {
+		return p, nil
+	}
+	for _, k := range keys {
+		if v, ok := obj[k]; ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("field query requires one of %v", keys)
+}
+
+func nativeBool(params interface{}) (string, error) {
+	p, ok := params.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("bool query must be an object")
+	}
+	if _, ok := p["minimum_should_match"]; ok {
+		return "", fmt.Errorf("bool minimum_should_match is not supported by the native search API")
+	}
+
+	var required, should, mustNot []string
+	for key, v := range p {
+		var dst *[]string
+		switch key {
+		case "must", "filter":
+			dst = &required
+		case "should":
+			dst = &should
+		case "must_not":
+			dst = &mustNot
+		case "boost", "_name":
+			continue
+		default:
+			return "", fmt.Errorf("bool option %q is not supported by the native search API", key)
+		}
+		clauses, ok := v.([]interface{})
+		if !ok {
+			clauses = []interface{}{v}
+		}
+		for _, c := range clauses {
+			q, err := nativeQuery(c)
+			if err != nil {
+				return "", err
+			}
+			*dst = append(*dst, "("+q+")")
+		}
+	}
+	// Keep output stable regardless of map iteration order.
+	sort.Strings(required)
+	sort.Strings(should)
+	sort.Strings(mustNot)
+
+	// As in Elasticsearch, should clauses only constrain the result when
+	// there is no must or filter clause.
+	if len(required) == 0 && len(should) > 0 {
+		required = append(required, "("+strings.Join(should, " OR ")+")")
+	}
+	if len(required) == 0 {
+		required = append(required, "*")
+	}
+	for _, q := range mustNot {
+		required = append(required, "NOT "+q)
+	}
+	return strings.Join(required, " AND "), nil
+}
+
+func nativeQueryString(params interface{}) (string, error) {
+	p, ok := params.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("query_string query must be an object")
+	}
+	q, ok := p["query"].(string)
+	if !ok {
+		return "", fmt.Errorf("query_string query requires a query")
+	}
+	if _, ok := p["default_field"]; ok {
+		return "", fmt.Errorf("query_string default_field is not supported by the native search API")
+	}
+	if _, ok := p["fields"]; ok {
+		return "", fmt.Errorf("query_string fields is not supported by the native search API")
+	}
+	if strings.TrimSpace(q) == "" {
+		return "*", nil
+	}
+	return "(" + q + ")", nil
+}
+
+func nativeMatch(field string, p interface{}) (string, error) {
+	val, err := nativeFieldValue(p, "query")
+	if err != nil {
+		return "", err
+	}
+	s, ok := val.(string)
+	if !ok {
+		return field + ":" + quoteNativeValue(val), nil
+	}
+	op := " OR "
+	if obj, ok := p.(map[string]interface{}); ok {
+		if o, _ := obj["operator"].(string); strings.EqualFold(o, "and") {
+			op = " AND "
+		}
+	}
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return "", fmt.Errorf("match query on %q requires a non-empty query", field)
+	}
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = field + ":" + quoteNativeValue(w)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, op) + ")", nil
+}
+
+// nativeRange renders a range clause as field:[from TO to], with "{" or "}"
+// marking an exclusive bound and "*" an open one.
+func nativeRange(field string, p interface{}) (string, error) {
+	obj, ok := p.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("range query on %q must be an object", field)
+	}
+	lower, upper := "[*", "*]"
+	for key, v := range obj {
+		var bound string
+		switch key {
+		case "gte", "gt", "lte", "lt":
+			s, err := rangeBound(field, v)
+			if err != nil {
+				return "", err
+			}
+			bound = s
+		case "format", "boost":
+			continue
+		default:
+			return "", fmt.Errorf("range option %q is not supported by the native search API", key)
+		}
+		switch key {
+		case "gte":
+			lower = "[" + bound
+		case "gt":
+			lower = "{" + bound
+		case "lte":
+			upper = bound + "]"
+		case "lt":
+			upper = bound + "}"
+		}
+	}
+	return fmt.Sprintf("%s:%s TO %s", field, lower, upper), nil
+}
+
+func rangeBound(field string, v interface{}) (string, error) {
+	var s string
+	switch val := v.(type) {
+	case json.Number:
+		s = val.String()
+	case string:
+		s = val
+	default:
+		return "", fmt.Errorf("range bound on %q must be a number or string", field)
+	}
+	if strings.HasPrefix(s, "now") || strings.Contains(s, "||") {
+		return "", fmt.Errorf("date math in range on %q is not supported by the native search API", field)
+	}
+	if s == "" || strings.ContainsAny(s, " []{}") {
+		return "", fmt.Errorf("invalid range bound %q on %q", s, field)
+	}
+	return s, nil
+}
+
+// quoteNativeValue renders a term value: numbers and booleans as is, strings
+// as a quoted phrase.
+func quoteNativeValue(v interface{}) string {
+	switch val := v.(type) {
+	case json.Number:
+		return val.String()
+	case bool:
+		return fmt.Sprint(val)
+	default:
+		s := fmt.Sprint(val)
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		return `"` + s + `"`
+	}
+}
+
+// escapeNativeTerm backslash-escapes query syntax characters in an unquoted term.
+func escapeNativeTerm(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\+-&|!(){}[]^"~*?:/ `, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// nativeSort converts an Elasticsearch sort into a sort_by list. Each field
+// carries an explicit "+" (ascending) or "-" (descending) prefix, since
+// Quickwit and Elasticsearch differ in their default order.
+func nativeSort(v interface{}) (string, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		items = []interface{}{v}
+	}
+	fields := make([]string, 0, len(items))
+	for _, item := range items {
+		var field, order string
+		switch s := item.(type) {
+		case string:
+			field = s
+		case map[string]interface{}:
+			if len(s) != 1 {
+				return "", fmt.Errorf("sort entry must name exactly one field")
+			}
+			for f, spec := range s {
+				field = f
+				switch o := spec.(type) {
+				case string:
+					order = o
+				case map[string]interface{}:
+					order, _ = o["order"].(string)
+				}
+			}
+		default:
+			return "", fmt.Errorf("unsupported sort entry %v", item)
+		}
+		if order == "" {
+			// Elasticsearch sorts _score descending and fields ascending by default.
+			order = "asc"
+			if field == "_score" {
+				order = "desc"
+			}
+		}
+		switch strings.ToLower(order) {
+		case "asc":
+			fields = append(fields, "+"+field)
+		case "desc":
+			fields = append(fields, "-"+field)
+		default:
+			return "", fmt.Errorf("invalid sort order %q for %q", order, field)
+		}
+	}
+	return strings.Join(fields, ","), nil
+}
+
+// nativeSnippetFields returns the highlighted fields as a snippet_fields list.
+func nativeSnippetFields(v interface{}) (string, error) {
+	h, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("highlight must be an object")
+	}
+	var names []string
+	switch fields := h["fields"].(type) {
+	case map[string]interface{}:
+		for name := range fields {
+			names = append(names, name)
+		}
+	case []interface{}:
+		for _, f := range fields {
+			if obj, ok := f.(map[string]interface{}); ok {
+				for name := range obj {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ","), nil
+}
+
+// nativeToSearchResponse converts a native search response into the
+// Elasticsearch shape used by the proxy. Hits carry no _score since the
+// native API does not return one; snippets are exposed as highlight.
+func nativeToSearchResponse(index string, body []byte) (*SearchResponse, error) {
+	var native nativeSearchResponse
+	if err := json.Unmarshal(body, &native); err != nil {
+		return nil, fmt.Errorf("decoding native search response: %w", err)
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("encoding index name: %w", err)
+	}
+
+	hits := make([]json.RawMessage, 0, len(native.Hits))
+	for i, doc := range native.Hits {
+		hit := map[string]json.RawMessage{"_index": indexJSON, "_source": doc}
+		if i < len(native.Snippets) {
+			switch s := string(bytes.TrimSpace(native.Snippets[i])); s {
+			case "", "{}", "null":
+			default:
+				hit["highlight"] = native.Snippets[i]
+			}
+		}
+		raw, err := json.Marshal(hit)
+		if err != nil {
+			return nil, fmt.Errorf("encoding hit: %w", err)
+		}
+		hits = append(hits, raw)
+	}
+
+	return &SearchResponse{
+		Took: int(native.ElapsedTimeMicros / 1000),
+		Hits: HitsResult{
+			Total: HitsTotal{Value: int(native.NumHits), Relation: "eq"},
+			Hits:  hits,
+		},
+		Aggregations: native.Aggregations,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranslateNativeSearch(t *testing.T) {
+	body := `{
+		"query": {"bool": {
+			"filter": [
+				{"term": {"level": "error"}},
+				{"range": {"ts": {"gte": "2026-01-01T00:00:00Z", "lt": "2026-01-02T00:00:00Z"}}}
+			],
+			"must_not": {"exists": {"field": "debug"}}
+		}},
+		"size": 20,
+		"from": 40,
+		"sort": [{"ts": {"order": "desc"}}, "_score"],
+		"highlight": {"fields": {"message": {}}},
+		"track_total_hits": true
+	}`
+	req, err := translateNativeSearch([]byte(body))
+	if err != nil {
+		t.Fatalf("translateNativeSearch: %v", err)
+	}
+	wantQuery := `(level:"error") AND (ts:[2026-01-01T00:00:00Z TO 2026-01-02T00:00:00Z}) AND NOT (debug:*)`
+	if req.Query != wantQuery {
+		t.Fatalf("query=%s, want %s", req.Query, wantQuery)
+	}
+	if req.MaxHits != 20 || req.StartOffset != 40 {
+		t.Fatalf("max_hits=%d start_offset=%d, want 20/40", req.MaxHits, req.StartOffset)
+	}
+	if req.SortBy != "-ts,-_score" {
+		t.Fatalf("sort_by=%s, want -ts,-_score", req.SortBy)
+	}
+	if req.SnippetFields != "message" {
+		t.Fatalf("snippet_fields=%s, want message", req.SnippetFields)
+	}
+}
+
+func TestTranslateNativeSearch_Queries(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{"match_all": {}}`, `*`},
+		{`{"match": {"msg": "disk full"}}`, `(msg:"disk" OR msg:"full")`},
+		{`{"match": {"msg": {"query": "disk full", "operator": "and"}}}`, `(msg:"disk" AND msg:"full")`},
+		{`{"match_phrase": {"msg": "say \"hi\""}}`, `msg:"say \"hi\""`},
+		{`{"terms": {"code": [500, 503]}}`, `(code:500 OR code:503)`},
+		{`{"prefix": {"host": "web-"}}`, `host:web\-*`},
+		{`{"range": {"code": {"gt": 499}}}`, `code:{499 TO *]`},
+		{`{"query_string": {"query": "status:500 AND host:a"}}`, `(status:500 AND host:a)`},
+		{`{"bool": {"should": [{"term": {"a": 1}}, {"term": {"b": 2}}]}}`, `((a:1) OR (b:2))`},
+		{`{"bool": {"must_not": [{"term": {"a": 1}}]}}`, `* AND NOT (a:1)`},
+	}
+	for _, tt := range tests {
+		req, err := translateNativeSearch([]byte(`{"query":` + tt.query + `}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if req.Query != tt.want {
+			t.Fatalf("%s: query=%s, want %s", tt.query, req.Query, tt.want)
+		}
+	}
+}
+
+func TestTranslateNativeSearch_Defaults(t *testing.T) {
+	req, err := translateNativeSearch(nil)
+	if err != nil {
+		t.Fatalf("translateNativeSearch: %v", err)
+	}
+	if req.Query != "*" || req.MaxHits != 10 {
+		t.Fatalf("req=%+v, want match-all with 10 hits", req)
+	}
+}
+
+func TestTranslateNativeSearch_Unsupported(t *testing.T) {
+	for _, body := range []string{
+		`{"query": {"fuzzy": {"msg": "x"}}}`,
+		`{"query": {"range": {"ts": {"gte": "now-1d"}}}}`,
+		`{"query": {"bool": {"should": [{"match_all": {}}], "minimum_should_match": 1}}}`,
+		`{"query": {"query_string": {"query": "x", "default_field": "msg"}}}`,
+		`{"search_after": [1]}`,
+		`{"size": -1}`,
+	} {
+		if _, err := translateNativeSearch([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestQuickwit_Search_Native(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logs/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"num_hits":7,"hits":[{"msg":"a"},{"msg":"b"}],"snippets":[{"msg":["<b>a</b>"]},{}],"elapsed_time_micros":3500}`))
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetSearchAPI(SearchAPINative)
+	resp, err := qw.Search(context.Background(), "logs", []byte(`{"query":{"term":{"msg":"a"}},"size":2,"highlight":{"fields":{"msg":{}}}}`))
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got["query"] != `msg:"a"` || got["max_hits"] != float64(2) || got["snippet_fields"] != "msg" {
+		t.Fatalf("native request=%v", got)
+	}
+	if resp.Hits.Total.Value != 7 || resp.Took != 3 || len(resp.Hits.Hits) != 2 {
+		t.Fatalf("resp=%+v, want 7 total, took 3, 2 hits", resp)
+	}
+	var hit struct {
+		Index     string              `json:"_index"`
+		Source    map[string]string   `json:"_source"`
+		Highlight map[string][]string `json:"highlight"`
+	}
+	if err := json.Unmarshal(resp.Hits.Hits[0], &hit); err != nil {
+		t.Fatalf("decoding hit: %v", err)
+	}
+	if hit.Index != "logs" || hit.Source["msg"] != "a" || hit.Highlight["msg"][0] != "<b>a</b>" {
+		t.Fatalf("hit=%s", resp.Hits.Hits[0])
+	}
+	if strings.Contains(string(resp.Hits.Hits[1]), "highlight") {
+		t.Fatalf("empty snippet should not produce highlight: %s", resp.Hits.Hits[1])
+	}
+}
+
+func TestQuickwit_Search_NativeRejectsBeforeRequest(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetSearchAPI(SearchAPINative)
+	if _, err := qw.Search(context.Background(), "logs", []byte(`{"query":{"fuzzy":{"msg":"x"}}}`)); err == nil {
+		t.Fatal("expected translation error")
+	}
+	if called {
+		t.Fatal("untranslatable query must not reach quickwit")
+	}
+}
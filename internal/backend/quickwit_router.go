@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// QuickwitRouter spreads cold indices over several Quickwit clusters. Each
+// call that names an index goes to the cluster that route selects for it;
+// calls that span indices ask every cluster. It implements the same
+// interfaces as Quickwit, so the proxy and the migrator can use either.
+type QuickwitRouter struct {
+	def      *Quickwit
+	clusters map[string]*Quickwit
+	names    []string // cluster names in a stable order, "" (def) first
+	route    func(index string) string
+}
+
+var (
+	_ Backend       = (*QuickwitRouter)(nil)
+	_ MultiSearcher = (*QuickwitRouter)(nil)
+)
+
+// NewQuickwitRouter returns a router over def and the named clusters. route
+// maps a Quickwit index name to a cluster name; an empty or unknown name
+// selects def.
+func NewQuickwitRouter(def *Quickwit, clusters map[string]*Quickwit, route func(index string) string) *QuickwitRouter {
+	r := &QuickwitRouter{def: def, clusters: map[string]*Quickwit{"": def}, route: route}
+	for name, q := range clusters {
+		if name != "" {
+			r.clusters[name] = q
+			r.names = append(r.names, name)
+		}
+	}
+	sort.Strings(r.names)
+	r.names = append([]string{""}, r.names...)
+	return r
+}
+
+// For returns the cluster holding index.
+func (r *QuickwitRouter) For(index string) *Quickwit {
+	if q, ok := r.clusters[r.route(index)]; ok {
+		return q
+	}
+	return r.def
+}
+
+func (r *QuickwitRouter) Name() string { return "quickwit" }
+
+// Capabilities reports the features every cluster supports.
+func (r *QuickwitRouter) Capabilities() Capabilities {
+	caps := r.def.Capabilities()
+	for _, q := range r.clusters {
+		c := q.Capabilities()
+		caps.SupportsScroll = caps.SupportsScroll && c.SupportsScroll
+		caps.SupportsAggregations = caps.SupportsAggregations && c.SupportsAggregations
+		caps.SupportsSort = caps.SupportsSort && c.SupportsSort
+		caps.SupportsMultiSearch = caps.SupportsMultiSearch && c.SupportsMultiSearch
+	}
+	return caps
+}
+
+func (r *QuickwitRouter) Search(ctx context.Context, index string, body []byte) (*SearchResponse, error) {
+	return r.For(index).Search(ctx, index, body)
+}
+
+func (r *QuickwitRouter) Count(ctx context.Context, index string, body []byte) (int64, error) {
+	return r.For(index).Count(ctx, index, body)
+}
+
+func (r *QuickwitRouter) BulkIngest(ctx context.Context, index string, docs []json.RawMessage) error {
+	return r.For(index).BulkIngest(ctx, index, docs)
+}
+
+// MultiSearch sends one multi-search request per cluster and returns the
+// responses in the order of indices.
+func (r *QuickwitRouter) MultiSearch(ctx context.Context, indices []string, body []byte) ([]*SearchResponse, error) {
+	groups := make(map[*Quickwit][]int)
+	var order []*Quickwit
+	for i, index := range indices {
+		q := r.For(index)
+		if _, ok := groups[q]; !ok {
+			order = append(order, q)
+		}
+		groups[q] = append(groups[q], i)
+	}
+	out := make([]*SearchResponse, len(indices))
+	for _, q := range order {
+		positions := groups[q]
+		names := make([]string, len(positions))
+		for j, i := range positions {
+			names[j] = indices[i]
+		}
+		responses, err := q.MultiSearch(ctx, names, body)
+		if err != nil {
+			return nil, err
+		}
+		if len(responses) != len(names) {
+			return nil, fmt.Errorf("multi-search returned %d responses for %d indices", len(responses), len(names))
+		}
+		for j, i := range positions {
+			out[i] = responses[j]
+		}
+	}
+	return out, nil
+}
+
+// Health checks every cluster, since any of them may receive ingest.
+func (r *QuickwitRouter) Health(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.names {
+		if err := r.clusters[name].Health(ctx); err != nil {
+			if name != "" {
+				err = fmt.Errorf("quickwit cluster %s: %w", name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DetectFeatures detects the features of every cluster; see
+// Quickwit.DetectFeatures.
+func (r *QuickwitRouter) DetectFeatures(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.names {
+		if _, err := r.clusters[name].DetectFeatures(ctx); err != nil {
+			if name != "" {
+				err = fmt.Errorf("quickwit cluster %s: %w", name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WatchFeatures detects the features of every cluster, waiting at most
+// featureDetectTimeout, then detects them again every interval in the
+// background until ctx is done. A non-positive interval detects them once.
+// Failures are logged; see Quickwit.DetectFeatures for what applies until
+// detection succeeds.
+func (r *QuickwitRouter) WatchFeatures(ctx context.Context, interval time.Duration) {
+	detectCtx, cancel := context.WithTimeout(ctx, featureDetectTimeout)
+	if err := r.DetectFeatures(detectCtx); err != nil {
+		slog.Warn("detecting quickwit features failed; search_api and ingest_api auto use passthrough and v1 until it succeeds", "error", err)
+	}
+	cancel()
+	if interval <= 0 {
+		return
+	}
+	for _, name := range r.names {
+		go r.clusters[name].WatchFeatures(ctx, interval)
+	}
+}
+
+func (r *QuickwitRouter) CountRange(ctx context.Context, index, tsField string, from, to time.Time) (int64, error) {
+	return r.For(index).CountRange(ctx, index, tsField, from, to)
+}
+
+func (r *QuickwitRouter) SearchRange(ctx context.Context, index, tsField string, from, to time.Time, maxHits int) ([]json.RawMessage, error) {
+	return r.For(index).SearchRange(ctx, index, tsField, from, to, maxHits)
+}
+
+func (r *QuickwitRouter) IndexExists(ctx context.Context, index string) (bool, error) {
+	return r.For(index).IndexExists(ctx, index)
+}
+
+// ListIndices lists the indices of every cluster. An index is only listed
+// from the cluster it routes to, so one left behind in another cluster, e.g.
+// after its pattern moved, is not reported twice or acted on by mistake.
+func (r *QuickwitRouter) ListIndices(ctx context.Context) ([]string, error) {
+	var all []string
+	for _, name := range r.names {
+		q := r.clusters[name]
+		indices, err := q.ListIndices(ctx)
+		if err != nil {
+			if name != "" {
+				err = fmt.Errorf("quickwit cluster %s: %w", name, err)
+			}
+			return nil, err
+		}
+		for _, index := range indices {
+			if r.For(index) == q {
+				all = append(all, index)
+			}
+		}
+	}
+	return all, nil
+}
+
+func (r *QuickwitRouter) CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error {
+	return r.For(index).CreateIndex(ctx, index, timestampField, retentionDays)
+}
+
+func (r *QuickwitRouter) DescribeIndex(ctx context.Context, index string) (*IndexStats, error) {
+	return r.For(index).DescribeIndex(ctx, index)
+}
+
+func (r *QuickwitRouter) FieldCaps(ctx context.Context, index string) (*FieldCaps, error) {
+	return r.For(index).FieldCaps(ctx, index)
+}
+
+func (r *QuickwitRouter) DocMapping(ctx context.Context, index string) (*DocMapping, error) {
+	return r.For(index).DocMapping(ctx, index)
+}
+
+func (r *QuickwitRouter) DeleteIndex(ctx context.Context, index string) error {
+	return r.For(index).DeleteIndex(ctx, index)
+}
+
+func (r *QuickwitRouter) UpdateRetention(ctx context.Context, index string, retentionDays int) error {
+	return r.For(index).UpdateRetention(ctx, index, retentionDays)
+}
+
+func (r *QuickwitRouter) ListSplits(ctx context.Context, index string) ([]SplitInfo, error) {
+	return r.For(index).ListSplits(ctx, index)
+}
+
+func (r *QuickwitRouter) MarkSplitsForDeletion(ctx context.Context, index string, splitIDs []string) error {
+	return r.For(index).MarkSplitsForDeletion(ctx, index, splitIDs)
+}
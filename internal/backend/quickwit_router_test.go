@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeQuickwitCluster serves the index list and multi-search of one cluster
+// and records the indices it was asked to ingest into or search.
+func fakeQuickwitCluster(t *testing.T, indices []string, got *[]string) *Quickwit {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/indexes":
+			var list []map[string]map[string]string
+			for _, index := range indices {
+				list = append(list, map[string]map[string]string{"index_config": {"index_id": index}})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.URL.Path == "/api/v1/_elastic/_msearch":
+			var responses []string
+			sc := bufio.NewScanner(r.Body)
+			for sc.Scan() {
+				var header struct {
+					Index string `json:"index"`
+				}
+				json.Unmarshal(sc.Bytes(), &header)
+				sc.Scan()
+				*got = append(*got, header.Index)
+				responses = append(responses, `{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_index":"`+header.Index+`","_source":{}}]},"status":200}`)
+			}
+			w.Write([]byte(`{"responses":[` + strings.Join(responses, ",") + `]}`))
+		case strings.HasSuffix(r.URL.Path, "/ingest"):
+			*got = append(*got, strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")[0])
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetSearchAPI(SearchAPIElastic)
+	return qw
+}
+
+func euRoute(index string) string {
+	if strings.HasPrefix(index, "eu-") {
+		return "eu"
+	}
+	return ""
+}
+
+func TestQuickwitRouter_BulkIngest(t *testing.T) {
+	var gotDefault, gotEU []string
+	r := NewQuickwitRouter(
+		fakeQuickwitCluster(t, nil, &gotDefault),
+		map[string]*Quickwit{"eu": fakeQuickwitCluster(t, nil, &gotEU)},
+		euRoute,
+	)
+	ctx := context.Background()
+	docs := []json.RawMessage{json.RawMessage(`{"msg":"a"}`)}
+	for _, index := range []string{"eu-logs", "us-logs"} {
+		if err := r.BulkIngest(ctx, index, docs); err != nil {
+			t.Fatalf("BulkIngest(%s): %v", index, err)
+		}
+	}
+	if !slices.Equal(gotEU, []string{"eu-logs"}) || !slices.Equal(gotDefault, []string{"us-logs"}) {
+		t.Fatalf("eu=%v default=%v", gotEU, gotDefault)
+	}
+}
+
+func TestQuickwitRouter_MultiSearch(t *testing.T) {
+	var gotDefault, gotEU []string
+	r := NewQuickwitRouter(
+		fakeQuickwitCluster(t, nil, &gotDefault),
+		map[string]*Quickwit{"eu": fakeQuickwitCluster(t, nil, &gotEU)},
+		euRoute,
+	)
+	indices := []string{"eu-a", "us-a", "eu-b"}
+	responses, err := r.MultiSearch(context.Background(), indices, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("MultiSearch: %v", err)
+	}
+	if !slices.Equal(gotEU, []string{"eu-a", "eu-b"}) || !slices.Equal(gotDefault, []string{"us-a"}) {
+		t.Fatalf("eu=%v default=%v", gotEU, gotDefault)
+	}
+	for i, resp := range responses {
+		if got := string(resp.Hits.Hits[0]); !strings.Contains(got, `"`+indices[i]+`"`) {
+			t.Errorf("responses[%d] = %s, want the hit of %s", i, got, indices[i])
+		}
+	}
+}
+
+func TestQuickwitRouter_ListIndices(t *testing.T) {
+	r := NewQuickwitRouter(
+		fakeQuickwitCluster(t, []string{"us-logs", "eu-old"}, new([]string)),
+		map[string]*Quickwit{"eu": fakeQuickwitCluster(t, []string{"eu-logs"}, new([]string))},
+		euRoute,
+	)
+	got, err := r.ListIndices(context.Background())
+	if err != nil {
+		t.Fatalf("ListIndices: %v", err)
+	}
+	// eu-old routes to the eu cluster, so its copy in the default cluster is not listed.
+	if want := []string{"us-logs", "eu-logs"}; !slices.Equal(got, want) {
+		t.Fatalf("ListIndices = %v, want %v", got, want)
+	}
+}
+
+func TestQuickwitRouter_Health(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`true`))
+	}))
+	defer up.Close()
+
+	r := NewQuickwitRouter(
+		NewQuickwit(up.URL, "", "", false, nil),
+		map[string]*Quickwit{"eu": NewQuickwit(down.URL, "", "", false, nil)},
+		euRoute,
+	)
+	err := r.Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "quickwit cluster eu") {
+		t.Fatalf("Health() = %v, want an error naming the eu cluster", err)
+	}
+}
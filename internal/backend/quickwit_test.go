@@ -8,8 +8,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestQuickwit_Search_Endpoint(t *testing.T) {
@@ -32,6 +34,20 @@ func TestQuickwit_Search_Endpoint(t *testing.T) {
 	}
 }
 
+func TestQuickwit_Capabilities(t *testing.T) {
+	qw := NewQuickwit("http://quickwit", "", "", false, nil)
+	for _, api := range []string{SearchAPIPassthrough, SearchAPINative, SearchAPIElastic} {
+		qw.SetSearchAPI(api)
+		caps := qw.Capabilities()
+		if caps.SupportsScroll || !caps.SupportsAggregations || !caps.SupportsSort {
+			t.Errorf("%s: unexpected capabilities %+v", api, caps)
+		}
+		if want := api == SearchAPIElastic; caps.SupportsMultiSearch != want {
+			t.Errorf("%s: SupportsMultiSearch=%v, want %v", api, caps.SupportsMultiSearch, want)
+		}
+	}
+}
+
 func TestQuickwit_BulkIngest_GzipCompression(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/v1/logs/ingest" {
@@ -79,6 +95,112 @@ func TestQuickwit_BulkIngest_GzipCompression(t *testing.T) {
 	}
 }
 
+func TestQuickwit_BulkIngest_ReusesBuffersAcrossBatches(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.ContentLength != int64(len(b)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	batches := [][]json.RawMessage{
+		{json.RawMessage(`{"_source":{"first":"a much longer document"}}`)},
+		{json.RawMessage(`{"_source":{"b":2}}`)},
+	}
+	for _, docs := range batches {
+		if err := qw.BulkIngest(context.Background(), "logs", docs); err != nil {
+			t.Fatalf("BulkIngest: %v", err)
+		}
+	}
+
+	// A recycled buffer must not leak bytes from the previous batch.
+	want := []string{"{\"first\":\"a much longer document\"}\n", "{\"b\":2}\n"}
+	if len(bodies) != 2 || bodies[0] != want[0] || bodies[1] != want[1] {
+		t.Fatalf("bodies=%q, want %q", bodies, want)
+	}
+}
+
+func TestQuickwit_BulkIngest_GzipStreamsBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A streamed body has no precomputed length.
+		if r.ContentLength != -1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(gz)
+		if string(b) != "{\"a\":1}\n" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", true, nil)
+	if err := qw.BulkIngest(context.Background(), "logs", []json.RawMessage{json.RawMessage(`{"a":1}`)}); err != nil {
+		t.Fatalf("BulkIngest: %v", err)
+	}
+}
+
+func TestQuickwit_BulkIngest_DiskStaging_GzipSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the compressed staging file should exist while uploading.
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".ndjson.gz") || r.ContentLength <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", true, nil)
+	qw.SetTempDir(dir)
+	if err := qw.BulkIngest(context.Background(), "logs", []json.RawMessage{json.RawMessage(`{"a":1}`)}); err != nil {
+		t.Fatalf("BulkIngest: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("staging files left behind: %v", entries)
+	}
+}
+
+func TestQuickwit_BulkIngest_PerIndexOptions(t *testing.T) {
+	encodings := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		encodings[r.URL.Path] = r.Header.Get("Content-Encoding")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", true, nil)
+	qw.SetIngestOptions(func(index string) IngestOptions {
+		return IngestOptions{Compress: index != "audit"}
+	})
+	docs := []json.RawMessage{json.RawMessage(`{"a":1}`)}
+	for _, index := range []string{"firewall", "audit"} {
+		if err := qw.BulkIngest(context.Background(), index, docs); err != nil {
+			t.Fatalf("BulkIngest(%s): %v", index, err)
+		}
+	}
+	if encodings["/api/v1/firewall/ingest"] != "gzip" || encodings["/api/v1/audit/ingest"] != "" {
+		t.Fatalf("encodings=%v, want gzip for firewall only", encodings)
+	}
+}
+
 func TestQuickwit_BulkIngest_DiskStaging(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/v1/logs/ingest" {
@@ -185,6 +307,204 @@ func TestQuickwit_Search_Non2xxReturnsHTTPStatusError(t *testing.T) {
 	}
 }
 
+func TestQuickwit_Health(t *testing.T) {
+	tests := []struct {
+		name     string
+		readyz   int
+		cluster  int
+		services string
+		wantErr  string
+	}{
+		{"healthy", http.StatusOK, http.StatusOK, `["metastore","indexer","searcher"]`, ""},
+		{"not ready", http.StatusServiceUnavailable, http.StatusOK, `["metastore","indexer"]`, "503"},
+		{"indexer missing", http.StatusOK, http.StatusOK, `["metastore","searcher"]`, "indexer service"},
+		{"metastore missing", http.StatusOK, http.StatusOK, `["indexer"]`, "metastore service"},
+		{"cluster api unavailable", http.StatusOK, http.StatusNotFound, ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/health/readyz":
+					w.WriteHeader(tt.readyz)
+					w.Write([]byte(`true`))
+				case "/api/v1/cluster":
+					w.WriteHeader(tt.cluster)
+					w.Write([]byte(`{"ready_nodes":[{"node_id":"n1","enabled_services":` + tt.services + `}]}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			err := NewQuickwit(srv.URL, "", "", false, nil).Health(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Health: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Health err=%v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuickwit_SetAuth(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "svc", "pw", false, nil)
+	if _, err := qw.Search(context.Background(), "logs", []byte(`{}`)); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if u, p, ok := (&http.Request{Header: got}).BasicAuth(); !ok || u != "svc" || p != "pw" {
+		t.Fatalf("expected basic auth svc/pw, got Authorization=%q", got.Get("Authorization"))
+	}
+
+	qw = NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetAuth("secret", map[string]string{"X-Tenant-ID": "acme"})
+	if _, err := qw.Search(context.Background(), "logs", []byte(`{}`)); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("Authorization=%q, want Bearer secret", got.Get("Authorization"))
+	}
+	if got.Get("X-Tenant-ID") != "acme" {
+		t.Fatalf("X-Tenant-ID=%q, want acme", got.Get("X-Tenant-ID"))
+	}
+}
+
+func TestQuickwit_CountRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/api/v1/_elastic/logs/_search":
+			b, _ := json.Marshal(body["query"])
+			if string(b) != `{"range":{"ts":{"gte":"2026-01-01T00:00:00.000Z","lte":"2026-01-02T00:00:00.000Z"}}}` || body["size"] != float64(0) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"hits":{"total":{"value":7,"relation":"eq"},"hits":[]}}`))
+		case "/api/v1/logs/search":
+			if body["query"] != "ts:[2026-01-01T00:00:00.000Z TO 2026-01-02T00:00:00.000Z]" || body["max_hits"] != float64(0) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"num_hits":7,"hits":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, api := range []string{SearchAPIElastic, SearchAPINative} {
+		qw := NewQuickwit(srv.URL, "", "", false, nil)
+		qw.SetSearchAPI(api)
+		n, err := qw.CountRange(context.Background(), "logs", "ts", from, to)
+		if err != nil {
+			t.Fatalf("%s CountRange: %v", api, err)
+		}
+		if n != 7 {
+			t.Fatalf("%s count=%d, want 7", api, n)
+		}
+	}
+}
+
+func TestQuickwit_Count(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/api/v1/_elastic/logs/_search":
+			if body["size"] != float64(0) || body["track_total_hits"] != true || body["sort"] != nil || body["query"] == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"hits":{"total":{"value":12,"relation":"eq"},"hits":[]}}`))
+		case "/api/v1/logs/search":
+			if body["query"] != "status:500" || body["max_hits"] != float64(0) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"num_hits":5,"hits":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	body := []byte(`{"query":{"term":{"status":500}},"size":20,"sort":[{"ts":"desc"}]}`)
+	for _, tt := range []struct {
+		api  string
+		want int64
+	}{{SearchAPIElastic, 12}, {SearchAPINative, 5}} {
+		qw := NewQuickwit(srv.URL, "", "", false, nil)
+		qw.SetSearchAPI(tt.api)
+		n, err := qw.Count(context.Background(), "logs", body)
+		if err != nil {
+			t.Fatalf("%s Count: %v", tt.api, err)
+		}
+		if n != tt.want {
+			t.Fatalf("%s count=%d, want %d", tt.api, n, tt.want)
+		}
+	}
+}
+
+func TestQuickwit_ListIndices_Cache(t *testing.T) {
+	var lists int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/indexes":
+			lists++
+			w.Write([]byte(`[{"index_config":{"index_id":"logs-a"}},{"index_config":{"index_id":"logs-b"}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/indexes":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	qw.SetListCacheTTL(time.Minute)
+	ctx := context.Background()
+
+	first, err := qw.ListIndices(ctx)
+	if err != nil {
+		t.Fatalf("ListIndices: %v", err)
+	}
+	first[0] = "mutated"
+	second, err := qw.ListIndices(ctx)
+	if err != nil {
+		t.Fatalf("ListIndices: %v", err)
+	}
+	if lists != 1 {
+		t.Fatalf("listed %d times within ttl, want 1", lists)
+	}
+	if second[0] != "logs-a" {
+		t.Fatalf("cached list was modified through a returned slice: %v", second)
+	}
+
+	if err := qw.CreateIndex(ctx, "logs-c", "ts", 0); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if _, err := qw.ListIndices(ctx); err != nil {
+		t.Fatalf("ListIndices: %v", err)
+	}
+	if lists != 2 {
+		t.Fatalf("listed %d times after CreateIndex, want 2", lists)
+	}
+}
+
 func TestQuickwit_IndexExists_Found(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/indexes/logs" {
@@ -255,6 +575,52 @@ func TestQuickwit_CreateIndex_Success(t *testing.T) {
 	}
 }
 
+func TestQuickwit_CreateIndex_IndexSettings(t *testing.T) {
+	var receivedBody map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	if err := qw.CreateIndex(context.Background(), "logs", "@timestamp", 0); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	indexing := receivedBody["indexing_settings"].(map[string]interface{})
+	if indexing["commit_timeout_secs"] != float64(60) || len(indexing) != 1 {
+		t.Fatalf("default indexing_settings=%v, want only commit_timeout_secs=60", indexing)
+	}
+	if _, ok := receivedBody["search_settings"]; ok {
+		t.Fatalf("search_settings should be omitted by default")
+	}
+
+	qw.SetIndexSettings(func(index string) IndexSettings {
+		return IndexSettings{
+			CommitTimeoutSecs:   30,
+			SplitNumDocsTarget:  20_000_000,
+			MergePolicy:         MergePolicy{Type: "stable_log", MergeFactor: 10, MaxMergeFactor: 12, MaturationPeriod: "48h"},
+			DefaultSearchFields: []string{"message"},
+		}
+	})
+	if err := qw.CreateIndex(context.Background(), "firewall", "@timestamp", 0); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	indexing = receivedBody["indexing_settings"].(map[string]interface{})
+	if indexing["commit_timeout_secs"] != float64(30) || indexing["split_num_docs_target"] != float64(20_000_000) {
+		t.Fatalf("indexing_settings=%v", indexing)
+	}
+	policy := indexing["merge_policy"].(map[string]interface{})
+	if policy["type"] != "stable_log" || policy["merge_factor"] != float64(10) ||
+		policy["max_merge_factor"] != float64(12) || policy["maturation_period"] != "48h" {
+		t.Fatalf("merge_policy=%v", policy)
+	}
+	search := receivedBody["search_settings"].(map[string]interface{})
+	if fields := search["default_search_fields"].([]interface{}); len(fields) != 1 || fields[0] != "message" {
+		t.Fatalf("default_search_fields=%v, want [message]", search["default_search_fields"])
+	}
+}
+
 func TestQuickwit_CreateIndex_Error(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -272,3 +638,176 @@ func TestQuickwit_CreateIndex_Error(t *testing.T) {
 		t.Fatalf("expected HTTPStatusError, got %T: %v", err, err)
 	}
 }
+
+func TestQuickwit_SearchRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["query"] != "ts:[2026-01-01T00:00:00.000Z TO 2026-01-01T00:00:00.000Z]" || body["max_hits"] != float64(10) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"num_hits":2,"hits":[{"msg":"a"},{"msg":"b"}]}`))
+	}))
+	defer srv.Close()
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs, err := NewQuickwit(srv.URL, "", "", false, nil).SearchRange(context.Background(), "logs", "ts", ts, ts, 10)
+	if err != nil {
+		t.Fatalf("SearchRange: %v", err)
+	}
+	if len(docs) != 2 || string(docs[1]) != `{"msg":"b"}` {
+		t.Fatalf("docs=%s, want both hits", docs)
+	}
+}
+
+func TestQuickwit_IngestMode(t *testing.T) {
+	var gotPath, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		w.Write([]byte(`{"num_docs_for_processing":1}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		api, commit         string
+		wantPath, wantQuery string
+	}{
+		{"", "", "/api/v1/logs/ingest", ""},
+		{"v1", "auto", "/api/v1/logs/ingest", ""},
+		{"v1", "wait_for", "/api/v1/logs/ingest", "commit=wait_for"},
+		{"v2", "force", "/api/v2/logs/ingest", "commit=force"},
+	}
+	for _, tt := range tests {
+		qw := NewQuickwit(srv.URL, "", "", false, srv.Client())
+		if tt.api != "" {
+			qw.SetIngestMode(tt.api, tt.commit)
+		}
+		if err := qw.BulkIngest(context.Background(), "logs", []json.RawMessage{[]byte(`{"a":1}`)}); err != nil {
+			t.Fatalf("BulkIngest(%s, %s): %v", tt.api, tt.commit, err)
+		}
+		if gotPath != tt.wantPath || gotQuery != tt.wantQuery {
+			t.Fatalf("api=%s commit=%s: got %s?%s, want %s?%s", tt.api, tt.commit, gotPath, gotQuery, tt.wantPath, tt.wantQuery)
+		}
+	}
+}
+
+func TestQuickwit_DeleteIndex(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	if err := qw.DeleteIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("DeleteIndex: %v", err)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/api/v1/indexes/logs" {
+		t.Fatalf("got %s %s, want DELETE /api/v1/indexes/logs", gotMethod, gotPath)
+	}
+	if err := qw.DeleteIndex(context.Background(), "missing"); err != nil {
+		t.Fatalf("DeleteIndex on missing index: %v", err)
+	}
+}
+
+func TestQuickwit_UpdateRetention(t *testing.T) {
+	var put map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/indexes/logs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"index_uid":"logs:01","index_config":{"version":"0.8","index_id":"logs","doc_mapping":{"mode":"dynamic"}}}`))
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&put)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	if err := NewQuickwit(srv.URL, "", "", false, nil).UpdateRetention(context.Background(), "logs", 90); err != nil {
+		t.Fatalf("UpdateRetention: %v", err)
+	}
+	retention, _ := put["retention"].(map[string]any)
+	if put["index_id"] != "logs" || put["doc_mapping"] == nil || retention["period"] != "90 days" {
+		t.Fatalf("put config=%v, want original config with 90 days retention", put)
+	}
+}
+
+func TestQuickwit_ListSplitsAndMarkForDeletion(t *testing.T) {
+	var marked map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/indexes/logs/splits":
+			if r.URL.Query().Get("split_states") != "Published" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"offset":0,"size":2,"splits":[
+				{"split_id":"a","num_docs":10,"time_range":{"start":1767225600,"end":1767312000}},
+				{"split_id":"b","num_docs":5,"time_range":null}
+			]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/indexes/logs/splits/mark-for-deletion":
+			json.NewDecoder(r.Body).Decode(&marked)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	splits, err := qw.ListSplits(context.Background(), "logs")
+	if err != nil {
+		t.Fatalf("ListSplits: %v", err)
+	}
+	if len(splits) != 2 || splits[0].ID != "a" || splits[0].NumDocs != 10 ||
+		!splits[0].TimeRangeEnd.Equal(time.Unix(1767312000, 0)) || !splits[1].TimeRangeEnd.IsZero() {
+		t.Fatalf("splits=%+v", splits)
+	}
+
+	if err := qw.MarkSplitsForDeletion(context.Background(), "logs", []string{"a"}); err != nil {
+		t.Fatalf("MarkSplitsForDeletion: %v", err)
+	}
+	if len(marked["split_ids"]) != 1 || marked["split_ids"][0] != "a" {
+		t.Fatalf("marked=%v, want [a]", marked)
+	}
+}
+
+func TestQuickwit_DescribeIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/indexes/logs/describe" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"index_id":"logs","num_published_splits":3,"size_published_splits":2048,
+			"num_published_docs":100,"size_published_docs_uncompressed":8192,
+			"timestamp_field_name":"ts","min_timestamp":1767225600,"max_timestamp":null}`))
+	}))
+	defer srv.Close()
+
+	qw := NewQuickwit(srv.URL, "", "", false, nil)
+	stats, err := qw.DescribeIndex(context.Background(), "logs")
+	if err != nil {
+		t.Fatalf("DescribeIndex: %v", err)
+	}
+	if stats.NumDocs != 100 || stats.NumSplits != 3 || stats.SizeBytes != 2048 || stats.UncompressedBytes != 8192 {
+		t.Fatalf("stats=%+v", stats)
+	}
+	if stats.MinTimestamp == nil || !stats.MinTimestamp.Equal(time.Unix(1767225600, 0)) || stats.MaxTimestamp != nil {
+		t.Fatalf("timestamps=%v/%v", stats.MinTimestamp, stats.MaxTimestamp)
+	}
+
+	_, err = qw.DescribeIndex(context.Background(), "missing")
+	var httpErr *HTTPStatusError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 HTTPStatusError, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package bench replays a mix of searches routed to the hot tier, the cold
+// tier or both against a target and reports their latency per routing
+// class, for "oqbridge bench".
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Routing classes of queries.
+const (
+	ClassHot  = "hot"
+	ClassCold = "cold"
+	ClassBoth = "both"
+)
+
+// Classes lists the routing classes in report order.
+var Classes = []string{ClassHot, ClassCold, ClassBoth}
+
+// Query is one search of a benchmark.
+type Query struct {
+	Class string          `json:"class"` // hot, cold or both
+	Index string          `json:"index"`
+	Body  json.RawMessage `json:"body"`
+}
+
+// Target runs one search.
+type Target func(ctx context.Context, q Query) error
+
+// Options controls a run.
+type Options struct {
+	Mix         map[string]int // relative number of searches of each class; classes without queries are skipped
+	Concurrency int            // searches in flight at once
+	Requests    int            // searches to run in total; 0 runs for Duration
+	Duration    time.Duration
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Target   string        `json:"target"`
+	Duration time.Duration `json:"duration"`
+	Classes  []ClassReport `json:"classes"`
+}
+
+// ClassReport summarizes the searches of one class. Latencies are of
+// successful searches, in milliseconds.
+type ClassReport struct {
+	Class    string  `json:"class"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	RPS      float64 `json:"rps"`
+	MeanMS   float64 `json:"mean_ms"`
+	P50MS    float64 `json:"p50_ms"`
+	P90MS    float64 `json:"p90_ms"`
+	P99MS    float64 `json:"p99_ms"`
+	MaxMS    float64 `json:"max_ms"`
+}
+
+// DefaultQueries returns one search per class of index, over time ranges
+// the proxy routes to OpenSearch only (the last hour), past the hot
+// retention period only (a day well before the cutoff) and across the
+// cutoff (from a day before it until now).
+func DefaultQueries(cfg *config.Config, index string, now time.Time) []Query {
+	field := cfg.TimestampFieldForIndex(index)
+	cutoff := cfg.DaysAgo(now, cfg.HotDaysForIndex(index))
+	query := func(class string, from, to time.Time) Query {
+		body, _ := json.Marshal(map[string]any{
+			"size": 10,
+			"query": map[string]any{"range": map[string]any{field: map[string]any{
+				"gte": from.UTC().Format(time.RFC3339),
+				"lt":  to.UTC().Format(time.RFC3339),
+			}}},
+		})
+		return Query{Class: class, Index: index, Body: body}
+	}
+	return []Query{
+		query(ClassHot, now.Add(-time.Hour), now),
+		query(ClassCold, cutoff.Add(-48*time.Hour), cutoff.Add(-24*time.Hour)),
+		query(ClassBoth, cutoff.Add(-24*time.Hour), now),
+	}
+}
+
+// ReadQueries reads queries from newline-delimited JSON, one Query per line.
+func ReadQueries(r io.Reader) ([]Query, error) {
+	var queries []Query
+	dec := json.NewDecoder(r)
+	for {
+		var q Query
+		if err := dec.Decode(&q); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("query %d: %w", len(queries)+1, err)
+		}
+		if !slices.Contains(Classes, q.Class) {
+			return nil, fmt.Errorf("query %d: class must be hot, cold or both, got %q", len(queries)+1, q.Class)
+		}
+		if q.Index == "" || len(q.Body) == 0 {
+			return nil, fmt.Errorf("query %d: index and body are required", len(queries)+1)
+		}
+		queries = append(queries, q)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries")
+	}
+	return queries, nil
+}
+
+// schedule returns the classes of consecutive searches: each class repeated
+// by its weight in opts.Mix, interleaved so a short run still covers every
+// class.
+func schedule(mix map[string]int, byClass map[string][]Query) []string {
+	weights := make(map[string]int)
+	for _, class := range Classes {
+		if mix[class] > 0 && len(byClass[class]) > 0 {
+			weights[class] = mix[class]
+		}
+	}
+	var order []string
+	for len(weights) > 0 {
+		for _, class := range Classes {
+			if weights[class] > 0 {
+				order = append(order, class)
+				if weights[class]--; weights[class] == 0 {
+					delete(weights, class)
+				}
+			}
+		}
+	}
+	return order
+}
+
+// Run searches target with queries as opts says until the requests are
+// done, the duration passed or ctx is done.
+func Run(ctx context.Context, queries []Query, opts Options, target Target) (*Report, error) {
+	byClass := make(map[string][]Query)
+	for _, q := range queries {
+		byClass[q.Class] = append(byClass[q.Class], q)
+	}
+	order := schedule(opts.Mix, byClass)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("the mix selects no class with queries")
+	}
+	if opts.Requests == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var (
+		next      atomic.Int64
+		mu        sync.Mutex
+		latencies = make(map[string][]time.Duration)
+		errs      = make(map[string]int)
+		wg        sync.WaitGroup
+	)
+	started := time.Now()
+	for range max(opts.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if opts.Requests > 0 && i >= opts.Requests {
+					return
+				}
+				class := order[i%len(order)]
+				qs := byClass[class]
+				q := qs[(i/len(order))%len(qs)]
+
+				start := time.Now()
+				err := target(ctx, q)
+				took := time.Since(start)
+				if err != nil && ctx.Err() != nil {
+					return // cut short by the end of the run
+				}
+				mu.Lock()
+				if err != nil {
+					errs[class]++
+				} else {
+					latencies[class] = append(latencies[class], took)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Duration: time.Since(started)}
+	for _, class := range Classes {
+		lat := latencies[class]
+		if len(lat) == 0 && errs[class] == 0 {
+			continue
+		}
+		cr := ClassReport{Class: class, Requests: len(lat) + errs[class], Errors: errs[class]}
+		cr.RPS = float64(cr.Requests) / report.Duration.Seconds()
+		if len(lat) > 0 {
+			sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+			var total time.Duration
+			for _, d := range lat {
+				total += d
+			}
+			cr.MeanMS = ms(total / time.Duration(len(lat)))
+			cr.P50MS = ms(percentile(lat, 50))
+			cr.P90MS = ms(percentile(lat, 90))
+			cr.P99MS = ms(percentile(lat, 99))
+			cr.MaxMS = ms(lat[len(lat)-1])
+		}
+		report.Classes = append(report.Classes, cr)
+	}
+	return report, nil
+}
+
+// percentile returns the p-th percentile of sorted by the nearest-rank
+// method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// WriteText writes the report as a table with one row per class.
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "benchmark of %s for %s\n\n", r.Target, r.Duration.Round(time.Millisecond))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tREQUESTS\tERRORS\tRPS\tMEAN\tP50\tP90\tP99\tMAX")
+	for _, c := range r.Classes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1fms\t%.1fms\t%.1fms\t%.1fms\t%.1fms\n",
+			c.Class, c.Requests, c.Errors, c.RPS, c.MeanMS, c.P50MS, c.P90MS, c.P99MS, c.MaxMS)
+	}
+	tw.Flush()
+}
+
+// Compare returns the regressions of r against baseline: classes whose
+// median or 99th percentile latency is more than tolerance (0.2 for 20%)
+// above the baseline's, or that failed searches the baseline did not.
+func (r *Report) Compare(baseline *Report, tolerance float64) []string {
+	var regressions []string
+	for _, c := range r.Classes {
+		i := slices.IndexFunc(baseline.Classes, func(b ClassReport) bool { return b.Class == c.Class })
+		if i < 0 {
+			continue
+		}
+		b := baseline.Classes[i]
+		for _, m := range []struct {
+			name      string
+			got, base float64
+		}{{"p50", c.P50MS, b.P50MS}, {"p99", c.P99MS, b.P99MS}} {
+			if m.base > 0 && m.got > m.base*(1+tolerance) {
+				regressions = append(regressions, fmt.Sprintf("%s: %s %.1fms is %.0f%% above the baseline's %.1fms", c.Class, m.name, m.got, (m.got/m.base-1)*100, m.base))
+			}
+		}
+		if c.Errors > 0 && b.Errors == 0 {
+			regressions = append(regressions, fmt.Sprintf("%s: %d searches failed, none in the baseline", c.Class, c.Errors))
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestDefaultQueries(t *testing.T) {
+	cfg := &config.Config{Retention: config.RetentionConfig{Days: 30, TimestampField: "ts"}}
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	queries := DefaultQueries(cfg, "logs-*", now)
+	if len(queries) != 3 {
+		t.Fatalf("DefaultQueries() = %d queries, want 3", len(queries))
+	}
+	for _, want := range []struct{ class, body string }{
+		{ClassHot, `"gte":"2026-03-15T11:00:00Z","lt":"2026-03-15T12:00:00Z"`},
+		{ClassCold, `"gte":"2026-02-11T00:00:00Z","lt":"2026-02-12T00:00:00Z"`},
+		{ClassBoth, `"gte":"2026-02-12T00:00:00Z","lt":"2026-03-15T12:00:00Z"`},
+	} {
+		var q *Query
+		for j := range queries {
+			if queries[j].Class == want.class {
+				q = &queries[j]
+			}
+		}
+		if q == nil || q.Index != "logs-*" || !strings.Contains(string(q.Body), `"ts":{`+want.body+`}`) {
+			t.Errorf("%s query = %+v, want range %s", want.class, q, want.body)
+		}
+	}
+}
+
+func TestReadQueries(t *testing.T) {
+	queries, err := ReadQueries(strings.NewReader(`{"class":"cold","index":"logs","body":{"size":0}}
+{"class":"hot","index":"logs","body":{}}`))
+	if err != nil || len(queries) != 2 || queries[0].Class != ClassCold || string(queries[0].Body) != `{"size":0}` {
+		t.Errorf("ReadQueries() = %+v, %v", queries, err)
+	}
+	for _, in := range []string{"", `{"class":"warm","index":"logs","body":{}}`, `{"class":"hot","body":{}}`} {
+		if _, err := ReadQueries(strings.NewReader(in)); err == nil {
+			t.Errorf("ReadQueries(%q) succeeded", in)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	queries := []Query{
+		{Class: ClassHot, Index: "a"},
+		{Class: ClassHot, Index: "b"},
+		{Class: ClassCold, Index: "c"},
+		{Class: ClassBoth, Index: "d"},
+	}
+	var (
+		mu   sync.Mutex
+		seen = map[string]int{}
+	)
+	target := func(_ context.Context, q Query) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[q.Index]++
+		if q.Class == ClassCold {
+			return errors.New("quickwit down")
+		}
+		return nil
+	}
+	opts := Options{Mix: map[string]int{ClassHot: 2, ClassCold: 1}, Concurrency: 3, Requests: 30}
+	report, err := Run(context.Background(), queries, opts, target)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if seen["a"] != 10 || seen["b"] != 10 || seen["c"] != 10 || seen["d"] != 0 {
+		t.Errorf("searches per index = %v, want 10 of each hot and cold query and no both", seen)
+	}
+	if len(report.Classes) != 2 {
+		t.Fatalf("classes = %+v, want hot and cold", report.Classes)
+	}
+	hot, cold := report.Classes[0], report.Classes[1]
+	if hot.Class != ClassHot || hot.Requests != 20 || hot.Errors != 0 || hot.MaxMS < hot.P50MS {
+		t.Errorf("hot = %+v", hot)
+	}
+	if cold.Class != ClassCold || cold.Requests != 10 || cold.Errors != 10 {
+		t.Errorf("cold = %+v", cold)
+	}
+
+	if _, err := Run(context.Background(), queries[:1], Options{Mix: map[string]int{ClassCold: 1}, Requests: 1}, target); err == nil {
+		t.Error("Run() accepted a mix without queries")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	if p := percentile(sorted, 50); p != 50*time.Millisecond {
+		t.Errorf("p50 = %v", p)
+	}
+	if p := percentile(sorted, 99); p != 99*time.Millisecond {
+		t.Errorf("p99 = %v", p)
+	}
+	if p := percentile(sorted[:1], 99); p != time.Millisecond {
+		t.Errorf("p99 of one = %v", p)
+	}
+}
+
+func TestReport_Compare(t *testing.T) {
+	baseline := &Report{Classes: []ClassReport{
+		{Class: ClassHot, P50MS: 10, P99MS: 40},
+		{Class: ClassCold, P50MS: 100, P99MS: 400},
+	}}
+	current := &Report{Classes: []ClassReport{
+		{Class: ClassHot, P50MS: 11, P99MS: 60, Errors: 2},
+		{Class: ClassCold, P50MS: 110, P99MS: 420},
+		{Class: ClassBoth, P50MS: 500, P99MS: 900},
+	}}
+	got := current.Compare(baseline, 0.2)
+	if len(got) != 2 || !strings.HasPrefix(got[0], "hot: p99 60.0ms is 50% above") || !strings.Contains(got[1], "2 searches failed") {
+		t.Errorf("Compare() = %q", got)
+	}
+
+	var b strings.Builder
+	current.Target = "http://localhost:9200"
+	current.WriteText(&b)
+	for _, want := range []string{"benchmark of http://localhost:9200", "CLASS", "cold"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteText() missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Package capture records the searches the proxy serves, with the route
+// they took and the total hits returned, and replays them against another
+// version or configuration for "oqbridge replay".
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// redacted replaces the string values of captured queries.
+const redacted = "redacted"
+
+// Record is one captured search.
+type Record struct {
+	Time     time.Time       `json:"time"` // when the search was routed; replays route it as of this time
+	Path     string          `json:"path"`
+	Query    string          `json:"query,omitempty"` // URL query string
+	Indices  []string        `json:"indices"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	Redacted bool            `json:"redacted,omitempty"` // values of Body and Query were replaced, so searching them finds other hits
+	Route    string          `json:"route"`              // "tiered" or a RouteTarget
+	Status   int             `json:"status"`
+	Total    *int64          `json:"total,omitempty"` // hits.total of the response, if it had one
+	TookMS   int64           `json:"took_ms"`
+}
+
+// Writer appends records to a file until it reaches its size limit.
+type Writer struct {
+	settings config.CaptureConfig
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	full bool
+}
+
+// Open opens settings.Path for appending records.
+func Open(settings config.CaptureConfig) (*Writer, error) {
+	f, err := os.OpenFile(settings.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening capture file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("opening capture file: %w", err)
+	}
+	return &Writer{settings: settings, f: f, size: fi.Size()}, nil
+}
+
+// Sample reports whether the next search should be recorded, by
+// sample_rate.
+func (w *Writer) Sample() bool {
+	return w.settings.SampleRate >= 1 || rand.Float64() < w.settings.SampleRate
+}
+
+// Write appends rec, redacting its values unless keep_values is set. Once
+// the file reached max_size_mb further records are dropped.
+func (w *Writer) Write(rec Record) error {
+	if !w.settings.KeepValues {
+		rec.Body = Sanitize(rec.Body)
+		rec.Query = SanitizeQuery(rec.Query)
+		rec.Redacted = true
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.full {
+		return nil
+	}
+	if w.size+int64(len(line)) > int64(w.settings.MaxSizeMB)<<20 {
+		w.full = true
+		slog.Warn("capture file reached server.capture.max_size_mb, no longer recording searches", "path", w.settings.Path)
+		return nil
+	}
+	n, err := w.f.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// Close closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// keptKeys name settings rather than searched values: their strings are
+// kept by Sanitize.
+var keptKeys = map[string]bool{
+	"field": true, "fields": true, "format": true, "time_zone": true, "order": true,
+	"operator": true, "default_operator": true, "minimum_should_match": true,
+	"score_mode": true, "interval": true, "calendar_interval": true, "fixed_interval": true,
+}
+
+// Sanitize replaces the strings of a search body with "redacted", except
+// in range clauses, which decide the route, in keptKeys and outside
+// query, post_filter and aggregations. Numbers, booleans and the shape of
+// the body are kept. A body that is not a JSON object is dropped.
+func Sanitize(body []byte) json.RawMessage {
+	var search map[string]any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&search); err != nil || search == nil {
+		return nil
+	}
+	for key, v := range search {
+		switch key {
+		case "query", "post_filter", "aggs", "aggregations", "knn", "rescore", "highlight", "suggest":
+			search[key] = redact(v)
+		}
+	}
+	out, err := json.Marshal(search)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			switch {
+			case key == "range":
+			case keptKeys[key] && !isObject(child):
+			default:
+				v[key] = redact(child)
+			}
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redact(child)
+		}
+		return v
+	case string:
+		return redacted
+	}
+	return v
+}
+
+func isObject(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+// SanitizeQuery redacts the q parameter, a query string search, of a URL
+// query string.
+func SanitizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	if values.Has("q") {
+		values.Set("q", redacted)
+	}
+	return values.Encode()
+}
+
+// Total returns hits.total of a search response, which may be gzipped as
+// header says.
+func Total(header http.Header, body []byte) (int64, bool) {
+	if header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return 0, false
+		}
+		// The proxy keeps only the start of large responses; the end of
+		// the stream missing is expected.
+		body, _ = io.ReadAll(zr)
+	}
+	// hits.total comes before the hits themselves, so it is read token by
+	// token from a body that may be cut short.
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if !enter(dec, "hits") || !enter(dec, "total") {
+		return 0, false
+	}
+	var total json.RawMessage
+	if dec.Decode(&total) != nil {
+		return 0, false
+	}
+	var n int64
+	if json.Unmarshal(total, &n) == nil {
+		return n, true
+	}
+	var obj struct {
+		Value *int64 `json:"value"`
+	}
+	if json.Unmarshal(total, &obj) == nil && obj.Value != nil {
+		return *obj.Value, true
+	}
+	return 0, false
+}
+
+// enter reads the next JSON object from dec up to the value of key.
+func enter(dec *json.Decoder, key string) bool {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return false
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil || tok == json.Delim('}') {
+			return false
+		}
+		if tok == key {
+			return true
+		}
+		var skip json.RawMessage
+		if dec.Decode(&skip) != nil {
+			return false
+		}
+	}
+}
+
+// Read calls fn with each record of a capture file and its line number.
+func Read(r io.Reader, fn func(line int, rec Record) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(line, rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
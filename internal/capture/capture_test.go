@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestSanitize(t *testing.T) {
+	body := `{"size":10,"sort":[{"@timestamp":"desc"}],"query":{"bool":{` +
+		`"must":[{"match":{"message":{"query":"card 4111","operator":"and"}}},{"term":{"user":"alice"}}],` +
+		`"filter":[{"range":{"@timestamp":{"gte":"now-7d","format":"strict_date_optional_time"}}},{"exists":{"field":"trace.id"}}]}},` +
+		`"aggs":{"by_host":{"terms":{"field":"host","size":5,"include":"web-.*"}}}}`
+	got := string(Sanitize([]byte(body)))
+	for _, want := range []string{
+		`"query":"redacted"`, `"operator":"and"`, `"user":"redacted"`,
+		`"range":{"@timestamp":{"format":"strict_date_optional_time","gte":"now-7d"}}`,
+		`"field":"trace.id"`, `"field":"host"`, `"size":5`, `"include":"redacted"`,
+		`"sort":[{"@timestamp":"desc"}]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Sanitize() = %s, missing %s", got, want)
+		}
+	}
+	for _, secret := range []string{"4111", "alice", "web-"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Sanitize() = %s, kept %q", got, secret)
+		}
+	}
+	if got := Sanitize(nil); got != nil {
+		t.Errorf("Sanitize(nil) = %s", got)
+	}
+	if got := SanitizeQuery("q=user:alice&size=5"); got != "q=redacted&size=5" {
+		t.Errorf("SanitizeQuery() = %q", got)
+	}
+}
+
+func TestTotal(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"took":3,"hits":{"total":{"value":42,"relation":"eq"},"hits":[{"_id":"1"}]}}`))
+	zw.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   int64
+		ok     bool
+	}{
+		{"object", http.Header{}, []byte(`{"took":1,"_shards":{"total":3},"hits":{"total":{"value":7},"hits":[]}}`), 7, true},
+		{"number", http.Header{}, []byte(`{"hits":{"total":9}}`), 9, true},
+		{"truncated", http.Header{}, []byte(`{"hits":{"max_score":1,"total":{"value":5},"hits":[{"_id":"1","_sou`), 5, true},
+		{"gzip", http.Header{"Content-Encoding": {"gzip"}}, gz.Bytes()[:gz.Len()-8], 42, true},
+		{"error", http.Header{}, []byte(`{"error":{"type":"x"},"status":400}`), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Total(tt.header, tt.body)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: Total() = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	w, err := Open(config.CaptureConfig{Path: path, SampleRate: 1, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	total := int64(3)
+	rec := Record{Time: time.Now(), Path: "/logs/_search", Indices: []string{"logs"}, Body: []byte(`{"query":{"term":{"user":"alice"}}}`), Route: "hot_only", Status: 200, Total: &total}
+	if err := w.Write(rec); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	rec.Body = []byte(`{"_source":"` + strings.Repeat("x", 1<<20) + `"}`)
+	w.Write(rec) // over max_size_mb: dropped
+	rec.Body = []byte(`{}`)
+	w.Write(rec) // the file is full
+	w.Close()
+
+	data, _ := os.ReadFile(path)
+	var got []Record
+	if err := Read(bytes.NewReader(data), func(_ int, r Record) error { got = append(got, r); return nil }); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Body) != `{"query":{"term":{"user":"redacted"}}}` || !got[0].Redacted || *got[0].Total != 3 {
+		t.Errorf("records = %+v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Kinds of differences found by a replay.
+const (
+	DiffRoute = "route" // the search is routed differently
+	DiffTotal = "total" // the search finds a different number of hits
+	DiffError = "error" // the search failed
+)
+
+// ReplayOptions controls a replay.
+type ReplayOptions struct {
+	// Route returns the route the new version or configuration takes for
+	// rec, as of rec.Time.
+	Route func(rec Record) string
+	// Search runs rec against the new version and returns its total hits.
+	// Nil only compares routes.
+	Search func(ctx context.Context, rec Record) (int64, error)
+	// Baseline runs rec against the old version. Nil compares with the
+	// captured total, which is only done for records captured with
+	// keep_values: a redacted search finds other hits than the original.
+	Baseline func(ctx context.Context, rec Record) (int64, error)
+}
+
+// Diff is a difference between a captured search and its replay.
+type Diff struct {
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Was  string `json:"was,omitempty"`
+	Now  string `json:"now"`
+}
+
+// Result is the outcome of a replay.
+type Result struct {
+	Searches int    `json:"searches"`
+	Diffs    []Diff `json:"diffs"`
+}
+
+// Replay replays the captured searches read from r as opts says.
+func Replay(ctx context.Context, r io.Reader, opts ReplayOptions) (*Result, error) {
+	res := &Result{}
+	err := Read(r, func(line int, rec Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res.Searches++
+		diff := func(kind, was, now string) {
+			res.Diffs = append(res.Diffs, Diff{Line: line, Kind: kind, Path: rec.Path, Was: was, Now: now})
+		}
+		if route := opts.Route(rec); route != rec.Route {
+			diff(DiffRoute, rec.Route, route)
+		}
+		if opts.Search == nil {
+			return nil
+		}
+		var want int64
+		switch {
+		case opts.Baseline != nil:
+			n, err := opts.Baseline(ctx, rec)
+			if err != nil {
+				diff(DiffError, "", "baseline: "+err.Error())
+				return nil
+			}
+			want = n
+		case rec.Total != nil && !rec.Redacted && rec.Status < 300:
+			want = *rec.Total
+		default:
+			return nil
+		}
+		got, err := opts.Search(ctx, rec)
+		if err != nil {
+			diff(DiffError, "", err.Error())
+			return nil
+		}
+		if got != want {
+			diff(DiffTotal, strconv.FormatInt(want, 10), strconv.FormatInt(got, 10))
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Count returns the number of differences of a kind.
+func (r *Result) Count(kind string) int {
+	n := 0
+	for _, d := range r.Diffs {
+		if d.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText writes a summary line and a table of the differences.
+func (r *Result) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "replayed %d searches: %d route changes, %d total changes, %d errors\n",
+		r.Searches, r.Count(DiffRoute), r.Count(DiffTotal), r.Count(DiffError))
+	if len(r.Diffs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tKIND\tPATH\tWAS\tNOW")
+	for _, d := range r.Diffs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", d.Line, d.Kind, d.Path, d.Was, d.Now)
+	}
+	tw.Flush()
+}
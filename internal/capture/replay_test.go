@@ -0,0 +1,69 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	capture := `{"path":"/a/_search","indices":["a"],"route":"hot_only","status":200,"total":5}
+{"path":"/b/_search","indices":["b"],"route":"both","status":200,"total":8}
+{"path":"/c/_search","indices":["c"],"route":"cold_only","status":200,"total":2,"redacted":true}
+
+{"path":"/d/_search","indices":["d"],"route":"cold_only","status":200,"total":1}
+`
+	withBaseline := false
+	opts := ReplayOptions{
+		Route: func(rec Record) string {
+			if rec.Indices[0] == "b" {
+				return "cold_only"
+			}
+			return rec.Route
+		},
+		Search: func(_ context.Context, rec Record) (int64, error) {
+			switch rec.Indices[0] {
+			case "a":
+				return 4, nil
+			case "c":
+				if !withBaseline {
+					t.Error("searched a redacted record without a baseline")
+				}
+			case "d":
+				return 0, errors.New("status 502")
+			}
+			return *rec.Total, nil
+		},
+	}
+	res, err := Replay(context.Background(), strings.NewReader(capture), opts)
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+	want := []Diff{
+		{Line: 1, Kind: DiffTotal, Path: "/a/_search", Was: "5", Now: "4"},
+		{Line: 2, Kind: DiffRoute, Path: "/b/_search", Was: "both", Now: "cold_only"},
+		{Line: 5, Kind: DiffError, Path: "/d/_search", Now: "status 502"},
+	}
+	if res.Searches != 4 || len(res.Diffs) != len(want) {
+		t.Fatalf("Replay() = %+v", res)
+	}
+	for i := range want {
+		if res.Diffs[i] != want[i] {
+			t.Errorf("diff %d = %+v, want %+v", i, res.Diffs[i], want[i])
+		}
+	}
+
+	withBaseline = true
+	opts.Baseline = func(context.Context, Record) (int64, error) { return 2, nil }
+	res, _ = Replay(context.Background(), strings.NewReader(capture), opts)
+	if res.Count(DiffTotal) != 2 {
+		t.Errorf("total changes against a baseline = %+v", res.Diffs)
+	}
+
+	var b strings.Builder
+	res.WriteText(&b)
+	if !strings.HasPrefix(b.String(), "replayed 4 searches: 1 route changes, 2 total changes, 1 errors") {
+		t.Errorf("WriteText() = %q", b.String())
+	}
+}
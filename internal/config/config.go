@@ -1,11 +1,23 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // retention.timezone must not depend on the zone files of the host or image
 
+	"github.com/leonunix/oqbridge/internal/expr"
+
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
@@ -16,40 +28,556 @@ type Config struct {
 	Server    ServerConfig    `koanf:"server"`
 	OpenSearch OpenSearchConfig `koanf:"opensearch"`
 	Quickwit  QuickwitConfig  `koanf:"quickwit"`
+	QuickwitClusters []QuickwitCluster `koanf:"quickwit_clusters"`
 	Retention RetentionConfig `koanf:"retention"`
+	Tiers     []TierConfig    `koanf:"tiers"` // OpenSearch clusters between opensearch (hot) and Quickwit (cold), youngest data first.
+	RemoteClusters []RemoteClusterConfig `koanf:"remote_clusters"` // Clusters searched with cross-cluster syntax ("cluster:index") whose cold data is in Quickwit.
+	RoutingRules []RoutingRule `koanf:"routing_rules"` // Searches sent to fixed tiers ahead of server.router; the first matching rule applies.
 	Migration MigrationConfig `koanf:"migration"`
+	DualWrite DualWriteConfig `koanf:"dual_write"`
+	LateWrites LateWritesConfig `koanf:"late_writes"`
+	Export    ExportConfig    `koanf:"export"` // Where "oqbridge-migrate export" writes Parquet copies of cold data.
+	Dashboard DashboardConfig `koanf:"dashboard"` // Web page of the daemons' state at /ui/ on their metrics listener.
+	Cost      CostConfig      `koanf:"cost"`      // Prices the storage and transfer costs recorded with migration metrics are estimated at.
+	GRPC      GRPCConfig      `koanf:"grpc"`      // Authentication of the gRPC control-plane API of both daemons.
+	Chaos     ChaosConfig     `koanf:"chaos"`     // Faults injected into the proxy's backend requests, for resilience tests.
+	Notifications NotificationsConfig `koanf:"notifications"`
+	Vault     VaultConfig     `koanf:"vault"`
+	Encryption EncryptionConfig `koanf:"encryption"`
 	Logging   LoggingConfig   `koanf:"logging"`
+	AllowUnknownKeys bool     `koanf:"allow_unknown_keys"` // Ignore keys that are not configuration settings instead of failing to load.
+	Profile   string          `koanf:"profile"`  // Entry of profiles overlaid on the rest of the configuration, e.g. "prod". Usually set with OQBRIDGE_PROFILE or -profile.
+	Profiles  map[string]map[string]any `koanf:"profiles"` // Named sets of keys, e.g. for dev, staging and prod, that override the rest of the configuration when selected.
+
+	sources   []string       // files and directories the configuration was read from
+	source    string         // migration.sources or tiers entry selected by ForSource
+	overrides Overrides      // command-line overrides the configuration was loaded with
+	location  *time.Location // retention.timezone, set by validate
 }
 
 type ServerConfig struct {
-	Listen string `koanf:"listen"`
+	Listen        string             `koanf:"listen"`
+	MetricsListen string             `koanf:"metrics_listen"` // Address serving Prometheus metrics at /metrics. Empty disables.
+	GRPCListen    string             `koanf:"grpc_listen"`    // Address serving the gRPC RoutingService and health checks. Empty disables.
+	ReverseProxy  ReverseProxyConfig `koanf:"reverse_proxy"`
+	PageCache     PageCacheConfig    `koanf:"page_cache"`
+	Rehydrate     RehydrateConfig    `koanf:"rehydrate"`
+	Tail          TailConfig         `koanf:"tail"`
+	Tenancy       TenancyConfig      `koanf:"tenancy"`
+	ColdQuota     ColdQuotaConfig    `koanf:"cold_quota"`
+	ColdFanout    ColdFanoutConfig   `koanf:"cold_fanout"`
+	ColdCache     ColdCacheConfig    `koanf:"cold_cache"`
+	Capture       CaptureConfig      `koanf:"capture"`
+	Compat        CompatConfig       `koanf:"compat"`
+	Failover      FailoverConfig     `koanf:"failover"`
+	Router        RouterConfig       `koanf:"router"`
+	Monitors      []MonitorConfig    `koanf:"monitors"` // Saved searches run across every tier on a schedule.
+	Warmups       []WarmupConfig     `koanf:"warmups"`  // Searches run on a schedule to prime cold_cache.
+}
+
+// MonitorConfig is a search the proxy runs on a schedule across every tier
+// the query's time range reaches, as if a client had sent it, and posts
+// to a webhook when its condition holds. OpenSearch alerting monitors
+// only see the hot tier; monitors looking further back belong here.
+type MonitorConfig struct {
+	Name           string         `koanf:"name"`             // Identifies the monitor in logs and webhook payloads (required).
+	Schedule       string         `koanf:"schedule"`         // Cron expression of the runs (required).
+	Indices        []string       `koanf:"indices"`          // Indices, aliases or patterns searched (required).
+	Query          map[string]any `koanf:"query"`            // Search body, e.g. with a range from "now-90d".
+	Value          string         `koanf:"value"`            // Dotted path of the number the condition tests in the search response.
+	Condition      string         `koanf:"condition"`        // Operator and number, e.g. "> 0" or "<= 100".
+	Always         bool           `koanf:"always"`           // Post every result, not only those meeting the condition.
+	WebhookURL     string         `koanf:"webhook_url"`      // Receives the results as JSON (required).
+	WebhookURLFile string         `koanf:"webhook_url_file"` // File holding webhook_url. Mutually exclusive with webhook_url.
+
+	op        string  // Condition's operator, set by validate
+	threshold float64 // Condition's number, set by validate
+}
+
+// Triggers reports whether value meets the monitor's condition.
+func (m MonitorConfig) Triggers(value float64) bool {
+	switch m.op {
+	case ">":
+		return value > m.threshold
+	case ">=":
+		return value >= m.threshold
+	case "<":
+		return value < m.threshold
+	case "<=":
+		return value <= m.threshold
+	case "==":
+		return value == m.threshold
+	}
+	return value != m.threshold
+}
+
+// RouterConfig selects the strategy that decides which tiers a search
+// reaches. Routers other than the built-in time_range are registered by
+// programs embedding the proxy.
+type RouterConfig struct {
+	Name    string            `koanf:"name"`    // Registered router name; "time_range" routes by the time range of the query.
+	Options map[string]string `koanf:"options"` // Settings of the router, passed to it as-is.
+}
+
+// PageCacheConfig keeps the merged, sorted hits of searches paged with
+// from/size across tiers, so later pages are served from memory instead of
+// querying every backend again for each page.
+type PageCacheConfig struct {
+	Enabled         bool          `koanf:"enabled"`
+	TTL             time.Duration `koanf:"ttl"`              // How long the hits of a search are kept after they were fetched.
+	MaxEntries      int           `koanf:"max_entries"`      // Most searches kept at once; the oldest is dropped first.
+	MaxHits         int           `koanf:"max_hits"`         // Hits fetched and kept per search; pages beyond them are not cached.
+	IdentityHeaders []string      `koanf:"identity_headers"` // Request headers identifying the client; cached hits are only served to requests with the same values.
+}
+
+// RehydrateConfig enables POST /_oqbridge/rehydrate, which copies a window
+// of a Quickwit index back into a new OpenSearch index for investigation.
+type RehydrateConfig struct {
+	Enabled     bool     `koanf:"enabled"`
+	AdminRoles  []string `koanf:"admin_roles"`  // OpenSearch security roles or backend roles allowed to rehydrate.
+	IndexPrefix string   `koanf:"index_prefix"` // Prepended to the names of the indices created, e.g. "rehydrated-logs-20260101-20260108".
+	MaxDocs     int64    `koanf:"max_docs"`     // Largest window accepted, in documents.
+	BatchSize   int      `koanf:"batch_size"`   // Documents per _bulk request to OpenSearch.
+}
+
+// TailConfig enables POST /_oqbridge/tail, which streams the documents
+// matching a query as they arrive, after those already stored since a
+// starting time.
+type TailConfig struct {
+	Enabled      bool          `koanf:"enabled"`
+	PollInterval time.Duration `koanf:"poll_interval"` // How often OpenSearch is searched for new documents.
+	PageSize     int           `koanf:"page_size"`     // Documents fetched per search.
+	MaxDuration  time.Duration `koanf:"max_duration"`  // Longest a stream stays open; clients reconnect from the last timestamp they saw.
+}
+
+// TenancyConfig lets several teams share the clusters through one proxy:
+// the users of each tenant only see and write indices whose names start
+// with the tenant's prefix.
+type TenancyConfig struct {
+	Enabled         bool           `koanf:"enabled"`
+	AdminRoles      []string       `koanf:"admin_roles"`      // OpenSearch security roles or backend roles whose users are not scoped to a tenant.
+	CacheTTL        time.Duration  `koanf:"cache_ttl"`        // How long the user of a client is remembered before asking OpenSearch again.
+	IdentityHeaders []string       `koanf:"identity_headers"` // Request headers identifying the client, for the cache.
+	Tenants         []TenantConfig `koanf:"tenants"`
+}
+
+// TenantConfig is a team whose users are scoped to the indices starting
+// with Prefix. A user belongs to the first tenant listing the user name or
+// one of the user's roles.
+type TenantConfig struct {
+	Name   string   `koanf:"name"`
+	Prefix string   `koanf:"prefix"` // Start of the names of the tenant's indices, e.g. "payments-".
+	Users  []string `koanf:"users"`  // OpenSearch user names.
+	Roles  []string `koanf:"roles"`  // OpenSearch security roles or backend roles.
+}
+
+// ColdQuotaConfig limits how much of the Quickwit searchers each user may
+// take per day. Usage is counted per user and day in retention.timezone,
+// kept in an OpenSearch index shared by every proxy, and searches beyond a
+// limit are refused with 429 until the next day.
+type ColdQuotaConfig struct {
+	Enabled         bool                `koanf:"enabled"`
+	ColdQuotaLimits `koanf:",squash"`   // Limits of the users no override matches.
+	Overrides       []ColdQuotaOverride `koanf:"overrides"`        // Limits of some users or roles; the first match wins.
+	ExemptRoles     []string            `koanf:"exempt_roles"`     // OpenSearch security roles or backend roles whose users are not counted.
+	Index           string              `koanf:"index"`            // OpenSearch index holding the usage.
+	FlushInterval   time.Duration       `koanf:"flush_interval"`   // How often usage is added to the index and the totals of other proxies read back.
+	CacheTTL        time.Duration       `koanf:"cache_ttl"`        // How long the user of a client is remembered before asking OpenSearch again.
+	IdentityHeaders []string            `koanf:"identity_headers"` // Request headers identifying the client, for the cache.
+}
+
+// ColdQuotaLimits are the daily limits of one user; 0 is unlimited.
+type ColdQuotaLimits struct {
+	Queries int64 `koanf:"queries"` // Searches reaching Quickwit; each entry of an _msearch counts.
+	Windows int64 `koanf:"windows"` // Days of cold data searched, summed over the searches.
+	Hits    int64 `koanf:"hits"`    // Hits returned by Quickwit.
+}
+
+// ColdQuotaOverride gives the users it lists, or the users holding one of
+// its roles, their own limits.
+type ColdQuotaOverride struct {
+	Users           []string `koanf:"users"` // OpenSearch user names.
+	Roles           []string `koanf:"roles"` // OpenSearch security roles or backend roles.
+	ColdQuotaLimits `koanf:",squash"`
+}
+
+// ColdFanoutConfig caps how many Quickwit indices the proxy searches at
+// once when a search resolves to several of them, e.g. a wildcard matching
+// daily indices.
+type ColdFanoutConfig struct {
+	MaxConcurrency int `koanf:"max_concurrency"` // Indices searched at once for one search; negative is unlimited.
+	MaxGlobal      int `koanf:"max_global"`      // Quickwit searches in flight across all searches; negative is unlimited.
+}
+
+// ColdCacheConfig keeps the responses of Quickwit indices to searches, so
+// that the same search of the same index is answered from memory until
+// the entry expires. Quickwit has no notion of users, and clients are
+// authenticated against OpenSearch before cold data is returned, so
+// entries are shared by every client.
+type ColdCacheConfig struct {
+	Enabled    bool          `koanf:"enabled"`
+	TTL        time.Duration `koanf:"ttl"`         // How long a response is kept after it was fetched.
+	MaxEntries int           `koanf:"max_entries"` // Most responses kept at once; the oldest is dropped first.
+	MaxHits    int           `koanf:"max_hits"`    // Responses with more hits are not kept.
+}
+
+// WarmupConfig is a search the proxy runs on a schedule ahead of the time
+// clients are known to send it, e.g. the standard dashboard time ranges
+// before the working day starts, so that its cold results are in
+// cold_cache and Quickwit's own caches when they do.
+type WarmupConfig struct {
+	Name     string         `koanf:"name"`     // Identifies the warm-up in logs (required).
+	Schedule string         `koanf:"schedule"` // Cron expression of the runs (required).
+	Indices  []string       `koanf:"indices"`  // Indices, aliases or patterns searched (required).
+	Query    map[string]any `koanf:"query"`    // Search body, as the clients send it.
+}
+
+// CaptureConfig records the searches the proxy serves, with the route it
+// took and the total hits returned, for "oqbridge replay" to check a new
+// version or configuration against.
+type CaptureConfig struct {
+	Enabled    bool    `koanf:"enabled"`
+	Path       string  `koanf:"path"`        // File the searches are appended to, one JSON object per line.
+	SampleRate float64 `koanf:"sample_rate"` // Fraction of searches recorded, from 0 to 1.
+	MaxSizeMB  int     `koanf:"max_size_mb"` // Recording stops once the file reaches this size.
+	KeepValues bool    `koanf:"keep_values"` // Record query strings as sent instead of replacing them with "redacted".
+}
+
+// CompatConfig makes the proxy acceptable to clients that check the
+// server before talking to it, such as Logstash and the Elasticsearch
+// language clients: the proxy answers "/" itself and marks every response,
+// including the merged searches and errors it generates, with the product
+// header those clients look for.
+type CompatConfig struct {
+	Enabled       bool   `koanf:"enabled"`
+	Version       string `koanf:"version"`        // version.number reported by "/", e.g. "7.10.2"; empty reports OpenSearch's.
+	Distribution  string `koanf:"distribution"`   // version.distribution reported by "/"; empty reports OpenSearch's.
+	ProductHeader string `koanf:"product_header"` // Value of the X-Elastic-Product header added to every response.
+}
+
+// FailoverConfig keeps cold data searchable while the hot cluster is down.
+// Clients are then authenticated against a local users file instead of
+// OpenSearch's security plugin, searches only reach the other tiers, and
+// every other request is refused.
+type FailoverConfig struct {
+	Enabled          bool          `koanf:"enabled"`
+	UsersFile        string        `koanf:"users_file"`        // Users allowed to search during failover, one "name:bcrypt-hash[:role,...]" per line (required).
+	CheckInterval    time.Duration `koanf:"check_interval"`    // How often OpenSearch is probed.
+	FailureThreshold int           `koanf:"failure_threshold"` // Consecutive failed probes before failover starts.
+}
+
+// RoutingRule sends the searches its condition matches to fixed tiers
+// instead of letting server.router decide.
+type RoutingRule struct {
+	Name  string `koanf:"name"`  // Shown in logs and errors.
+	When  string `koanf:"when"`  // Condition in the routing expression language, e.g. index matches "audit-*" && from < now - 365d.
+	Route string `koanf:"route"` // "hot", "cold", "all", a tiers entry, or "default" to leave the search to server.router.
+
+	when *expr.Expr // When, compiled by validate
+}
+
+// Condition returns the compiled When of a loaded configuration.
+func (r RoutingRule) Condition() *expr.Expr { return r.when }
+
+// RoutingRuleSchema lists what routing rule conditions can refer to:
+// the index or pattern searched, the user and roles of the client as
+// reported by OpenSearch, the bounds of the time range the search filters
+// on (null if unbounded), the current time and request headers.
+var RoutingRuleSchema = expr.Schema{
+	Vars: map[string]expr.Type{
+		"index": expr.String,
+		"user":  expr.String,
+		"roles": expr.List,
+		"from":  expr.Time,
+		"to":    expr.Time,
+		"now":   expr.Time,
+	},
+	Funcs: map[string]expr.Func{
+		"header": {Args: []expr.Type{expr.String}, Result: expr.String},
+	},
+}
+
+// ReverseProxyConfig tunes the proxy that passes non-search requests
+// through to OpenSearch.
+type ReverseProxyConfig struct {
+	FlushInterval      time.Duration `koanf:"flush_interval"`       // How often to flush responses to the client while copying; negative flushes after every write (0 = only streamed responses).
+	BufferSizeKB       int           `koanf:"buffer_size_kb"`       // Size of the pooled buffers responses are copied through.
+	RetryNonIdempotent bool          `koanf:"retry_non_idempotent"` // Let the transport replay non-idempotent requests that carry an Idempotency-Key header; when false, the Idempotency-Key and X-Idempotency-Key headers of such requests are removed before they reach OpenSearch.
 }
 
 type TLSConfig struct {
 	SkipVerify bool   `koanf:"tls_skip_verify"` // Skip TLS certificate verification (insecure, for dev/test).
 	CACert     string `koanf:"ca_cert"`          // Path to CA certificate file for self-signed certs.
+	ClientCert string `koanf:"client_cert"`      // Path to a PEM client certificate for mutual TLS. Reloaded when the file changes.
+	ClientKey  string `koanf:"client_key"`       // Path to the PEM private key of client_cert.
+
+	// Set by the Vault integration rather than the config file.
+	CACertPEM            []byte                                                     `koanf:"-"` // PEM CA certificates trusted in addition to ca_cert.
+	GetClientCertificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error) `koanf:"-"` // Supplies the client certificate instead of client_cert/client_key.
+}
+
+// TransportConfig tunes the HTTP connection pool of a backend client. Zero
+// values keep Go's defaults.
+type TransportConfig struct {
+	MaxIdleConns        int           `koanf:"max_idle_conns"`          // Idle connections kept across all hosts.
+	MaxIdleConnsPerHost int           `koanf:"max_idle_conns_per_host"` // Idle connections kept per host (Go default: 2).
+	MaxConnsPerHost     int           `koanf:"max_conns_per_host"`      // Cap on dialing, active and idle connections per host (0 = unlimited).
+	IdleConnTimeout     time.Duration `koanf:"idle_conn_timeout"`       // How long an idle connection is kept.
+	DialTimeout         time.Duration `koanf:"dial_timeout"`            // Timeout for establishing a TCP connection.
+	TLSHandshakeTimeout time.Duration `koanf:"tls_handshake_timeout"`
+}
+
+// RetryConfig controls retries of backend requests that fail with a
+// connection error or a 429, 502, 503 or 504 response. It applies to the
+// backend clients and the checkpoint, metrics and lock stores, not to
+// requests passed through by the reverse proxy.
+type RetryConfig struct {
+	MaxAttempts    int           `koanf:"max_attempts"`    // Attempts per request including the first; 1 disables retries.
+	InitialBackoff time.Duration `koanf:"initial_backoff"` // Delay before the first retry; doubles on each further retry, with jitter.
+	MaxBackoff     time.Duration `koanf:"max_backoff"`     // Cap on the delay between attempts, including one requested by Retry-After.
+	Methods        []string      `koanf:"methods"`         // HTTP methods that are safe to retry.
 }
 
 type OpenSearchConfig struct {
 	URL      string `koanf:"url"`
 	Username string `koanf:"username"`
 	Password string `koanf:"password"`
+	PasswordFile string `koanf:"password_file"` // File holding password, e.g. a mounted secret. Mutually exclusive with password.
+	SigV4    SigV4Config `koanf:"sigv4"` // Sign requests for Amazon OpenSearch Service instead of using basic auth.
+	Transport TransportConfig `koanf:"transport"`
+	Retry     RetryConfig     `koanf:"retry"`
+	UserAgent string            `koanf:"user_agent"` // User-Agent of requests to OpenSearch, including proxied ones. Empty uses "<binary>/<version>".
+	Headers   map[string]string `koanf:"headers"`    // Static headers added to every request to OpenSearch.
 	TLSConfig `koanf:",squash"`
 }
 
+// SigV4Config enables AWS Signature Version 4 signing of every OpenSearch
+// request. Credentials come from the default AWS chain: environment
+// variables, the shared config and credentials files, web identity (IRSA)
+// and the EC2/ECS instance role.
+type SigV4Config struct {
+	Enabled bool   `koanf:"enabled"`
+	Region  string `koanf:"region"`  // AWS region of the domain. Empty uses AWS_REGION or the shared config.
+	Service string `koanf:"service"` // "es" for OpenSearch Service domains, "aoss" for OpenSearch Serverless.
+	Profile string `koanf:"profile"` // Shared config profile. Empty uses AWS_PROFILE or the default profile.
+}
+
 type QuickwitConfig struct {
-	URL      string `koanf:"url"`
-	Username string `koanf:"username"`
-	Password string `koanf:"password"`
+	URL          string `koanf:"url"`
+	Username     string `koanf:"username"`
+	Password     string `koanf:"password"`
+	PasswordFile string `koanf:"password_file"` // File holding password, e.g. a mounted secret. Mutually exclusive with password.
+	IngestAPI    string `koanf:"ingest_api"`    // "v1" (/api/v1/{index}/ingest), "v2" (/api/v2/{index}/ingest) or "auto" (v2 if the cluster has it).
+	IngestCommit string `koanf:"ingest_commit"` // "auto", "wait_for" or "force": when ingested documents become searchable.
+	SearchAPI    string `koanf:"search_api"`    // "passthrough" (send the ES body as is), "native" (translate to Quickwit's query language), "elastic" (_elastic endpoints) or "auto" (elastic if the cluster has it).
+	IndexSettings QuickwitIndexSettings `koanf:"index_settings"` // Indexing and search settings of indices created by oqbridge-migrate.
+	Auth         QuickwitAuthConfig `koanf:"auth"`
+	Transport    TransportConfig    `koanf:"transport"`
+	Retry        RetryConfig        `koanf:"retry"`
+	UserAgent    string             `koanf:"user_agent"` // User-Agent of requests to Quickwit. Empty uses "<binary>/<version>".
+	Headers      map[string]string  `koanf:"headers"`    // Static headers added to every request to Quickwit.
+	ListCacheTTL time.Duration      `koanf:"list_cache_ttl"` // How long the proxy caches the index list used to resolve wildcards; negative disables.
+	DetectInterval time.Duration    `koanf:"detect_interval"` // How often the version of every cluster is read again to detect its features; negative only detects at startup. Top-level quickwit only.
 	TLSConfig `koanf:",squash"`
 }
 
+// QuickwitCluster is an additional Quickwit cluster holding the cold indices
+// that match its patterns, e.g. to keep EU data in an EU deployment. Indices
+// matching no cluster stay in quickwit.
+type QuickwitCluster struct {
+	Name     string         `koanf:"name"`
+	Indices  []string       `koanf:"indices"`  // Glob patterns of the Quickwit index names stored in this cluster.
+	Quickwit QuickwitConfig `koanf:"quickwit"` // Connection to the cluster; unset ingest and search modes inherit quickwit.
+}
+
+// QuickwitAuthConfig holds credentials for a Quickwit deployment behind an
+// authenticating gateway. They are sent on every Quickwit request made by the
+// proxy and by oqbridge-migrate.
+type QuickwitAuthConfig struct {
+	BearerToken string            `koanf:"bearer_token"` // Sent as "Authorization: Bearer <token>". Mutually exclusive with username/password.
+	BearerTokenFile string        `koanf:"bearer_token_file"` // File holding bearer_token. Mutually exclusive with bearer_token.
+	Headers     map[string]string `koanf:"headers"`      // Static headers added to every request, e.g. a tenant ID.
+}
+
+// QuickwitIndexSettings are the indexing and search settings applied when
+// oqbridge-migrate creates a Quickwit index. They only take effect at
+// creation; existing indices keep their settings.
+type QuickwitIndexSettings struct {
+	CommitTimeoutSecs   int                 `koanf:"commit_timeout_secs"`   // Maximum time a document waits before its split is committed.
+	SplitNumDocsTarget  int                 `koanf:"split_num_docs_target"` // Documents per split before it is considered mature (0 = Quickwit default).
+	MergePolicy         QuickwitMergePolicy `koanf:"merge_policy"`
+	DefaultSearchFields []string            `koanf:"default_search_fields"` // Fields searched by queries that do not name one.
+}
+
+// QuickwitMergePolicy selects how Quickwit merges splits. An empty Type
+// leaves the Quickwit default in place.
+type QuickwitMergePolicy struct {
+	Type             string `koanf:"type"`              // "stable_log", "limit_merge" or "no_merge".
+	MergeFactor      int    `koanf:"merge_factor"`      // Splits merged together in one operation.
+	MaxMergeFactor   int    `koanf:"max_merge_factor"`  // Upper bound on splits merged in one operation.
+	MaturationPeriod string `koanf:"maturation_period"` // Age after which a split is no longer merged, e.g. "48h".
+}
+
 type RetentionConfig struct {
-	Days           int               `koanf:"days"`
-	ColdDays       int               `koanf:"cold_days"`        // How long to keep data in Quickwit (0 = forever).
-	TimestampField string            `koanf:"timestamp_field"`
-	IndexFields    map[string]string `koanf:"index_fields"`
-	IndexColdDays  map[string]int    `koanf:"index_cold_days"`  // Per-index cold retention overrides (days). Supports exact names or glob patterns.
+	Days           int                    `koanf:"days"`
+	ColdDays       int                    `koanf:"cold_days"`         // How long to keep data in Quickwit (0 = forever).
+	TimestampField string                 `koanf:"timestamp_field"`
+	IndexFields    map[string]string      `koanf:"index_fields"`      // Per-index timestamp field overrides. Supports exact names or glob patterns.
+	IndexColdDays  map[string]int         `koanf:"index_cold_days"`   // Per-index cold retention overrides (days). Supports exact names or glob patterns.
+	IndexDays      map[string]int         `koanf:"index_days"`        // Per-index hot retention overrides (days) used to route queries. Supports exact names or glob patterns.
+	Timezone       string                 `koanf:"timezone"`          // IANA time zone in which daily indices roll over, e.g. "Europe/Berlin". Day boundaries and index name dates use it; unset, they are in UTC and the proxy cuts exactly the retention period before each search.
+	Enforce        ColdEnforceConfig      `koanf:"enforce"`           // Delete expired cold data from oqbridge-migrate instead of relying on Quickwit's retention policy.
+	TTL            TTLEnforceConfig       `koanf:"ttl"`               // Delete the documents of migration.rules with action "delete" from OpenSearch.
+	Runtime        RetentionRuntimeConfig `koanf:"runtime"`           // Change days and migration.migrate_after_days through the API.
+}
+
+// RetentionRuntimeConfig controls the retention API of the proxy,
+// /_oqbridge/retention, which changes retention.days and
+// migration.migrate_after_days without editing the configuration file. A
+// change is stored in the state index of the hot cluster and takes effect
+// on every proxy and oqbridge-migrate instance at the same time, delay
+// after it was made, so that queries are not routed by a cutoff that
+// migration has not reached yet.
+type RetentionRuntimeConfig struct {
+	Enabled      bool          `koanf:"enabled"`
+	AdminRoles   []string      `koanf:"admin_roles"`   // OpenSearch security roles or backend roles allowed to change retention.
+	SyncInterval time.Duration `koanf:"sync_interval"` // How often instances read the stored change.
+	Delay        time.Duration `koanf:"delay"`         // Time between a change and its taking effect. Must exceed sync_interval.
+}
+
+// TTLEnforceConfig controls the TTL job of oqbridge-migrate, which deletes
+// the documents of the indices of migration.rules with action "delete" from
+// OpenSearch once they are older than the rule's delete_after_days, without
+// archiving them.
+type TTLEnforceConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	Schedule   string `koanf:"schedule"`    // Cron schedule of the job in daemon mode.
+	DryRun     bool   `koanf:"dry_run"`     // Log what would be deleted without deleting anything.
+	MaxDropped int    `koanf:"max_dropped"` // Most indices dropped whole in one pass; the rest wait for the next (negative = unlimited).
+}
+
+// ColdEnforceConfig controls the retention enforcement job, which deletes
+// Quickwit indices and splits older than their cold retention period. Use it
+// when Quickwit's own retention policy is unavailable or disabled.
+type ColdEnforceConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	Schedule string `koanf:"schedule"` // Cron schedule of the job in daemon mode.
+	DryRun   bool   `koanf:"dry_run"`  // Log what would be deleted without deleting anything.
+}
+
+// DualWriteConfig makes the proxy mirror documents written to OpenSearch
+// through it into Quickwit, instead of the migration worker copying them
+// later. Indices mirrored this way are skipped by migration, and queries on
+// them that reach past the hot retention period are answered by Quickwit
+// alone.
+type DualWriteConfig struct {
+	Enabled       bool          `koanf:"enabled"`
+	Indices       []string      `koanf:"indices"`        // Index patterns whose new documents are mirrored. Matched against the index OpenSearch wrote to.
+	BufferDocs    int           `koanf:"buffer_docs"`    // Documents queued for Quickwit before drop_policy applies.
+	BatchSize     int           `koanf:"batch_size"`     // Most documents sent to Quickwit in one request.
+	FlushInterval time.Duration `koanf:"flush_interval"` // Longest time a queued document waits for its batch to fill.
+	MaxRetries    int           `koanf:"max_retries"`    // Retries of a failed batch before it is dropped.
+	RetryBackoff  time.Duration `koanf:"retry_backoff"`  // Wait before the first retry, doubled for each further one.
+	DropPolicy    string        `koanf:"drop_policy"`    // Document dropped when the queue is full: "newest" (the incoming one) or "oldest".
+}
+
+// TierConfig is an OpenSearch cluster that holds documents once they leave
+// the tier before it, e.g. a warm cluster on cheaper storage. The proxy
+// searches it for the days it covers; oqbridge-migrate moves documents into
+// it from the tier before and on to the next tier, or to Quickwit after the
+// last one.
+type TierConfig struct {
+	Name             string           `koanf:"name"` // Identifies the tier in logs, reports and the -source flag.
+	OpenSearch       OpenSearchConfig `koanf:"opensearch"`
+	Days             int              `koanf:"days"`               // Age in days at which documents leave this tier. Must exceed retention.days and the days of the tiers before.
+	MigrateAfterDays int              `koanf:"migrate_after_days"` // Move documents older than this many days to the next tier. Must be < days.
+	OnError          string           `koanf:"on_error"`           // When the tier fails during a search: "skip" merges the other tiers' results, "fail" fails the search.
+}
+
+// RemoteClusterConfig is a remote cluster of the hot OpenSearch cluster,
+// searched as "name:index", whose old documents were migrated to Quickwit
+// by an oqbridge-migrate of its own. The proxy leaves the remote indices of
+// a search to OpenSearch for recent data and searches their Quickwit
+// indices for older data; remote clusters not listed are only searched by
+// OpenSearch.
+type RemoteClusterConfig struct {
+	Name            string `koanf:"name"`             // Cluster alias as configured in OpenSearch's cluster.remote settings.
+	QuickwitCluster string `koanf:"quickwit_cluster"` // quickwit_clusters entry holding the cluster's Quickwit indices; empty for quickwit.
+	IndexPrefix     string `koanf:"index_prefix"`     // Prepended to the cluster's index names to get their Quickwit index names.
+	RetentionDays   int    `koanf:"retention_days"`   // Days the remote cluster keeps in OpenSearch (0 = retention.days).
+}
+
+// LateWritesConfig makes the proxy send documents of _bulk requests that
+// are already older than the hot retention period of their index, e.g. late
+// backfills, straight to Quickwit instead of indexing them into OpenSearch
+// only to migrate them later.
+type LateWritesConfig struct {
+	Enabled bool     `koanf:"enabled"`
+	Indices []string `koanf:"indices"` // Index patterns, matched against the index named in the bulk request, whose old documents go to Quickwit.
+}
+
+// DashboardConfig enables a read-only web page served by the proxy and the
+// migrate daemon next to /metrics, showing where each index's data lives,
+// how searches were routed and how migration is progressing.
+type DashboardConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Refresh time.Duration `koanf:"refresh"` // How often the page reloads its data.
+	History int           `koanf:"history"` // Most recent migration runs listed.
+}
+
+// CostConfig prices storage per GB and month in each kind of cluster, and
+// the documents migration moves out of a cluster, e.g. across regions or
+// from a cloud provider. oqbridge-migrate records the estimated costs of
+// every index with its run metrics. Prices of 0 leave costs unestimated.
+type CostConfig struct {
+	Currency    string             `koanf:"currency"`      // Shown with the estimates.
+	HotGBMonth  float64            `koanf:"hot_gb_month"`  // OpenSearch storage, including migration.sources.
+	TierGBMonth map[string]float64 `koanf:"tier_gb_month"` // Storage of tiers entries by name; tiers left out cost hot_gb_month.
+	ColdGBMonth float64            `koanf:"cold_gb_month"` // Quickwit storage, usually an object store.
+	EgressGB    float64            `koanf:"egress_gb"`     // Transfer of migrated documents.
+}
+
+// GRPCConfig secures the control-plane API the proxy serves on
+// server.grpc_listen and the migrate daemon on migration.grpc_listen.
+type GRPCConfig struct {
+	Token     string `koanf:"token"`      // Bearer token clients must send in the authorization metadata. Empty accepts every client.
+	TokenFile string `koanf:"token_file"` // File holding token. Mutually exclusive with token.
+}
+
+// ChaosConfig makes the proxy fail some of its requests to OpenSearch
+// (hot, including the clusters of tiers) and Quickwit (cold) on purpose,
+// to test its fallbacks and that failed authentication checks deny
+// access. Never enable it in production.
+type ChaosConfig struct {
+	Enabled bool        `koanf:"enabled"`
+	Hot     FaultConfig `koanf:"hot"`
+	Cold    FaultConfig `koanf:"cold"`
+}
+
+// FaultConfig sets the faults injected into the requests to one backend.
+// Rates are fractions of requests, from 0 to 1.
+type FaultConfig struct {
+	LatencyRate float64       `koanf:"latency_rate"` // Requests delayed by latency before they are sent.
+	Latency     time.Duration `koanf:"latency"`
+	ErrorRate   float64       `koanf:"error_rate"`   // Requests answered with error_status without reaching the backend.
+	ErrorStatus int           `koanf:"error_status"`
+	DropRate    float64       `koanf:"drop_rate"` // Requests failed with a connection reset.
+}
+
+// ExportConfig is the S3-compatible bucket that "oqbridge-migrate export"
+// writes cold data to as Parquet files, partitioned by index and day, so
+// it can still be queried with Athena or Trino after Quickwit deletes it.
+// Requests are signed with AWS SigV4; Google Cloud Storage works through
+// its S3-compatible endpoint with HMAC keys.
+type ExportConfig struct {
+	Bucket      string `koanf:"bucket"`
+	Prefix      string `koanf:"prefix"`        // Key prefix under which each index gets a table directory, e.g. "archive/".
+	Endpoint    string `koanf:"endpoint"`      // S3 API endpoint, e.g. "https://storage.googleapis.com". Empty uses AWS S3 in region.
+	Region      string `koanf:"region"`        // Signing region ("auto" for GCS). Empty uses AWS_REGION or the shared config.
+	Profile     string `koanf:"profile"`       // Shared config profile to load credentials from.
+	PathStyle   bool   `koanf:"path_style"`    // Put the bucket in the URL path instead of the host name, e.g. for MinIO.
+	Compression string `koanf:"compression"`   // Parquet compression: "snappy", "gzip", "zstd" or "none".
+	MaxFileDocs int    `koanf:"max_file_docs"` // Documents per Parquet file; each file is built in memory before it is uploaded.
 }
 
 type MigrationConfig struct {
@@ -58,28 +586,284 @@ type MigrationConfig struct {
 	MigrateAfterDays     int      `koanf:"migrate_after_days"`   // Migrate data older than this many days. Must be < retention.days.
 	BatchSize            int      `koanf:"batch_size"`
 	Workers              int      `koanf:"workers"`              // Number of parallel sliced scroll workers.
+	PipelineDepth        int      `koanf:"pipeline_depth"`       // Batches buffered between the read, transform and ingest stages of each worker.
+	MaxBufferedMB        int      `koanf:"max_buffered_mb"`      // Global cap on scrolled batch data held in memory across all workers (negative = unlimited).
 	Compress             bool     `koanf:"compress"`             // Gzip compress data sent to Quickwit.
 	DeleteAfterMigration bool     `koanf:"delete_after_migration"`
 	TempDir              string   `koanf:"temp_dir"`             // Directory for staging migration data on disk. Empty uses in-memory buffers.
+	CheckpointDir        string   `koanf:"checkpoint_dir"`       // Store checkpoints/watermarks in this local directory instead of OpenSearch (single instance only).
 	Indices              []string `koanf:"indices"`
+	HealthGate           HealthGateConfig `koanf:"health_gate"`
+	Dedup                bool     `koanf:"dedup"`                // Skip batches whose time span is already fully present in Quickwit.
+	Snapshot             SnapshotSourceConfig `koanf:"snapshot"`
+	IndexOverrides       map[string]IndexOverride `koanf:"index_overrides"` // Per-index tuning keyed by exact name or glob pattern.
+	Rules                []MigrationRule `koanf:"rules"`            // Per-pattern migration policy; the first rule matching an index applies.
+	Rollups              []RollupConfig  `koanf:"rollups"`          // Summaries of the migrated documents kept in OpenSearch.
+	Sources              []MigrationSource `koanf:"sources"`        // OpenSearch clusters to migrate from instead of opensearch and indices.
+	MetricsListen        string   `koanf:"metrics_listen"`       // Address serving Prometheus metrics at /metrics in scheduled mode. Empty disables.
+	GRPCListen           string   `koanf:"grpc_listen"`          // Address serving the gRPC MigrationService and health checks in scheduled mode. Empty disables.
+	LockTTL              time.Duration `koanf:"lock_ttl"`         // How long an index lock is held before another instance may take it over; keep above the longest index migration.
+	ScrollKeepAlive      time.Duration `koanf:"scroll_keep_alive"` // How long OpenSearch keeps a scroll context open between pages.
+	Reconcile            ReconcileConfig `koanf:"reconcile"`      // Compare daily document counts on both sides of each index's watermark.
+	StateBackup          StateBackupConfig `koanf:"state_backup"` // Back up the .oqbridge-* state indices of each source.
+}
+
+// ReconcileConfig controls the boundary reconciliation job of the migrate
+// daemon, which counts the documents of each day before an index's
+// watermark on both sides of the migration and alerts when they diverge,
+// e.g. after a failed ingest, a Quickwit retention period that is too short
+// or a manual deletion.
+type ReconcileConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	Schedule string `koanf:"schedule"` // Cron schedule of the job.
+	Days     int    `koanf:"days"`     // Days before each index's watermark to compare; keep within what OpenSearch still holds.
+}
+
+// StateBackupConfig controls backups of the indices in which the migrate
+// instances of a source share their state: watermarks and checkpoints,
+// locks and run metrics. Backups are OpenSearch snapshots in Repository,
+// or JSON files in Dir when no repository is set.
+type StateBackupConfig struct {
+	Enabled    bool   `koanf:"enabled"`    // Back up on Schedule in the migrate daemon; the state command works regardless.
+	Schedule   string `koanf:"schedule"`   // Cron schedule of the job.
+	Repository string `koanf:"repository"` // Registered snapshot repository of each source cluster.
+	Dir        string `koanf:"dir"`        // Directory of the JSON backups, one file per source and backup.
+	Keep       int    `koanf:"keep"`       // Newest backups kept per source; older ones are deleted after each backup.
+}
+
+// IndexOverride tunes migration for indices matching a pattern. Unset
+// fields inherit the global migration settings.
+type IndexOverride struct {
+	Workers   int    `koanf:"workers"`
+	BatchSize int    `koanf:"batch_size"`
+	Compress  *bool  `koanf:"compress"`
+	TempDir   string `koanf:"temp_dir"`
+	Quickwit  QuickwitIndexSettings `koanf:"quickwit"` // Overrides quickwit.index_settings for indices created from this pattern.
 }
 
+// MigrationSource is an OpenSearch cluster that oqbridge-migrate archives
+// into the shared Quickwit cluster. Each source keeps its own checkpoints,
+// watermarks and locks.
+type MigrationSource struct {
+	Name       string           `koanf:"name"` // Identifies the cluster in logs, metrics and the -source flag.
+	OpenSearch OpenSearchConfig `koanf:"opensearch"`
+	Indices    []string         `koanf:"indices"` // Index patterns to migrate from this cluster.
+}
+
+// MigrationRule sets what is migrated from the indices matching its
+// patterns, and when. Unset fields inherit the global migration settings.
+// Rules only apply to indices selected by migration.indices.
+//
+// A rule with action "delete" archives nothing: its indices are skipped by
+// migration and their documents deleted by retention.ttl once they are
+// delete_after_days old.
+type MigrationRule struct {
+	Indices              []string        `koanf:"indices"`            // Glob patterns of the indices the rule applies to.
+	Action               string          `koanf:"action"`             // "migrate" (default) or "delete".
+	DeleteAfterDays      int             `koanf:"delete_after_days"`  // Age at which action "delete" deletes documents.
+	MigrateAfterDays     int             `koanf:"migrate_after_days"` // Must be < retention.days.
+	DeleteAfterMigration *bool           `koanf:"delete_after_migration"`
+	Filter               map[string]any  `koanf:"filter"` // Query clause selecting the documents to migrate; others stay in OpenSearch.
+	Transforms           TransformConfig `koanf:"transforms"`
+	TargetIndex          string          `koanf:"target_index"` // Quickwit index to migrate into; "{index}" is replaced with the index name.
+}
+
+// RollupConfig summarizes the documents of the matching indices as they
+// are migrated: one document per Interval and combination of GroupBy
+// values, holding the document count and the statistics of each Metrics
+// field, is written into the OpenSearch index Target. Dashboards showing
+// long-term trends can search Target instead of fanning out to Quickwit.
+type RollupConfig struct {
+	Name     string        `koanf:"name"`     // Identifies the rollup; part of every summary document (required).
+	Indices  []string      `koanf:"indices"`  // Glob patterns of the indices summarized (required).
+	Target   string        `koanf:"target"`   // OpenSearch index of the summaries; defaults to "rollup-" and the name.
+	Interval time.Duration `koanf:"interval"` // Time bucket of a summary; must divide an hour.
+	GroupBy  []string      `koanf:"group_by"` // Keyword fields whose values are summarized separately.
+	Metrics  []string      `koanf:"metrics"`  // Numeric fields whose min, max, sum and count are kept.
+}
+
+// TransformConfig edits documents on their way to Quickwit. Field names
+// may be dotted paths into nested objects.
+type TransformConfig struct {
+	DropFields   []string          `koanf:"drop_fields"`
+	RenameFields map[string]string `koanf:"rename_fields"` // Old name to new name.
+}
+
+// IndexMigrationSettings are the effective migration settings for one index.
+type IndexMigrationSettings struct {
+	Workers   int
+	BatchSize int
+	Compress  bool
+	TempDir   string
+}
+
+// IndexMigrationPolicy is what is migrated from one index, and when, as set
+// by migration.rules.
+type IndexMigrationPolicy struct {
+	Delete               bool // action "delete": not migrated, deleted after DeleteAfterDays
+	DeleteAfterDays      int
+	MigrateAfterDays     int
+	DeleteAfterMigration bool
+	Filter               map[string]any // nil migrates every document in the window
+	Transforms           TransformConfig
+	TargetIndex          string // Quickwit index name
+}
+
+// SnapshotSourceConfig makes migration read from a snapshot repository
+// instead of scrolling the live index.
+type SnapshotSourceConfig struct {
+	Enabled        bool          `koanf:"enabled"`
+	Repository     string        `koanf:"repository"`      // Registered snapshot repository name.
+	Name           string        `koanf:"name"`            // Snapshot to read from. Empty picks the latest successful snapshot.
+	Mode           string        `koanf:"mode"`            // "restore" (copy to a temporary index) or "searchable" (mount as a searchable snapshot).
+	IndexPrefix    string        `koanf:"index_prefix"`    // Prefix for the temporary index the snapshot is restored into.
+	RestoreTimeout time.Duration `koanf:"restore_timeout"` // How long to wait for the restored index to become searchable.
+}
+
+// HealthGateConfig controls pausing migration while the OpenSearch cluster
+// is unhealthy or under resource pressure.
+type HealthGateConfig struct {
+	Enabled         bool          `koanf:"enabled"`
+	MaxStatus       string        `koanf:"max_status"`        // Worst acceptable cluster status: "green" or "yellow". Red always pauses.
+	MaxPendingTasks int           `koanf:"max_pending_tasks"` // Pause when pending cluster tasks exceed this (0 = no limit).
+	MaxHeapPercent  int           `koanf:"max_heap_percent"`  // Pause when any node's JVM heap usage exceeds this (0 = no limit).
+	Interval        time.Duration `koanf:"interval"`          // How often to re-check health during a run.
+	MaxPause        time.Duration `koanf:"max_pause"`         // Abort (keeping the checkpoint) after being paused this long. Must be below migration.scroll_keep_alive.
+}
+
+// NotificationsConfig configures alerts sent by oqbridge-migrate.
+type NotificationsConfig struct {
+	Slack SlackConfig `koanf:"slack"`
+	Email EmailConfig `koanf:"email"`
+}
+
+// SlackConfig posts notifications to a Slack incoming webhook.
+type SlackConfig struct {
+	WebhookURL string   `koanf:"webhook_url"` // Empty disables Slack notifications.
+	WebhookURLFile string `koanf:"webhook_url_file"` // File holding webhook_url. Mutually exclusive with webhook_url.
+	Events     []string `koanf:"events"`      // Event kinds to send. Empty sends all.
+}
+
+// EmailConfig sends notifications through an SMTP server.
+type EmailConfig struct {
+	SMTPHost string   `koanf:"smtp_host"` // Empty disables email notifications.
+	SMTPPort int      `koanf:"smtp_port"`
+	Username string   `koanf:"username"` // SMTP AUTH PLAIN credentials; empty sends without authentication.
+	Password string   `koanf:"password"`
+	PasswordFile string `koanf:"password_file"` // File holding password. Mutually exclusive with password.
+	From     string   `koanf:"from"`
+	To       []string `koanf:"to"`
+	Events   []string `koanf:"events"` // Event kinds to send. Empty sends all.
+}
+
+// VaultConfig fetches the OpenSearch and Quickwit service account
+// credentials, and optionally TLS material, from HashiCorp Vault instead of
+// the config file. Secrets are fetched at startup and re-read while the
+// process runs, so rotated credentials are picked up without a restart.
+type VaultConfig struct {
+	Address                 string        `koanf:"address"`     // Vault URL, e.g. "https://vault:8200". Empty disables Vault.
+	Namespace               string        `koanf:"namespace"`   // Vault Enterprise namespace.
+	AuthMethod              string        `koanf:"auth_method"` // "token", "kubernetes" or "approle".
+	AuthMount               string        `koanf:"auth_mount"`  // Mount path of the auth method. Defaults to the method name.
+	Token                   string        `koanf:"token"`       // Token for the token method. Empty uses VAULT_TOKEN.
+	TokenFile               string        `koanf:"token_file"`
+	Role                    string        `koanf:"role"`                       // Role for the kubernetes method.
+	ServiceAccountTokenFile string        `koanf:"service_account_token_file"` // JWT presented by the kubernetes method.
+	RoleID                  string        `koanf:"role_id"`                    // Role ID for the approle method.
+	SecretID                string        `koanf:"secret_id"`
+	SecretIDFile            string        `koanf:"secret_id_file"`
+	OpenSearchPath          string        `koanf:"opensearch_path"`  // Secret with the OpenSearch username/password and TLS material, e.g. "secret/data/oqbridge/opensearch".
+	QuickwitPath            string        `koanf:"quickwit_path"`    // Secret with the Quickwit username/password or bearer_token and TLS material.
+	RefreshInterval         time.Duration `koanf:"refresh_interval"` // How often secrets are re-read; leased secrets are also renewed before they expire.
+
+	TLSConfig `koanf:",squash"` // TLS settings for the connection to Vault.
+}
+
+// EncryptionConfig decrypts secrets stored encrypted in the config file, so
+// the file can be kept in Git. Values written by "oqbridge-migrate secret
+// encrypt" start with "enc:v1:" and may be used for any password, token or
+// webhook URL, inline or in its *_file.
+type EncryptionConfig struct {
+	KeyFile string `koanf:"key_file"` // File holding the base64 AES-256 key, as written by "oqbridge-migrate secret keygen".
+}
+
+// NotificationEvents lists the event kinds that can be selected in
+// notifications.*.events.
+var NotificationEvents = []string{"run_failed", "verify_mismatch", "lock_contention", "reconcile_mismatch"}
+
 type LoggingConfig struct {
 	Level string `koanf:"level"`
 }
 
-// Load reads configuration from the given YAML file path.
+// Load reads configuration from the given file path, overridden by any
+// OQBRIDGE_ environment variables (see EnvPrefix). The file format follows
+// the extension (see parserFor). A file may list other files or
+// directories under "include", which are merged first, in order, so the
+// including file overrides them. path may also be a directory, which is
+// read like an include of it, or empty to configure everything from the
+// environment. The entry of "profiles" named by "profile" is merged over
+// the files, below the environment.
 func Load(path string) (*Config, error) {
+	return LoadWithOverrides(path, nil)
+}
+
+// LoadWithOverrides is Load with overrides applied last, on top of the
+// files and the environment. A Watcher applies them again on each reload.
+func LoadWithOverrides(path string, overrides Overrides) (*Config, error) {
 	k := koanf.New(".")
 
-	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
-		return nil, fmt.Errorf("loading config from %s: %w", path, err)
+	var sources []string
+	if path != "" {
+		if err := loadPath(k, path, nil, &sources); err != nil {
+			return nil, err
+		}
+	}
+	// The environment and the command line are applied after the profile,
+	// but may select it, so they are read first.
+	top := koanf.New(".")
+	if err := top.Load(envProvider(), nil); err != nil {
+		return nil, fmt.Errorf("loading config from environment: %w", err)
+	}
+	for key, value := range overrides {
+		if err := top.Set(key, value); err != nil {
+			return nil, fmt.Errorf("overriding %s: %w", key, err)
+		}
+	}
+	if err := applyProfile(k, top); err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if err := k.Merge(top); err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	raw := k.Raw()
+	if err := normalizeDurations(raw, reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	for key, value := range raw {
+		if err := k.Set(key, value); err != nil {
+			return nil, fmt.Errorf("loading config: %w", err)
+		}
 	}
 
 	var cfg Config
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return nil, fmt.Errorf("unmarshaling config: %w", err)
 	}
+	if unknown := unknownKeys(k.Raw(), reflect.TypeOf(Config{}), ""); len(unknown) > 0 {
+		if !cfg.AllowUnknownKeys {
+			return nil, fmt.Errorf("unknown configuration keys: %s (check for typos, or set allow_unknown_keys: true to ignore them)", strings.Join(unknown, ", "))
+		}
+		slog.Warn("ignoring unknown configuration keys", "keys", unknown)
+	}
+	cfg.sources = sources
+	cfg.overrides = overrides
+
+	if err := readSecretFiles(&cfg); err != nil {
+		return nil, err
+	}
+	if err := decryptSecrets(&cfg); err != nil {
+		return nil, err
+	}
 
 	setDefaults(&cfg)
 
@@ -90,85 +874,1555 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
-// TimestampFieldForIndex returns the timestamp field name for the given index.
-// Falls back to the global default if no per-index override is configured.
-func (c *Config) TimestampFieldForIndex(index string) string {
-	if field, ok := c.Retention.IndexFields[index]; ok {
-		return field
+// configExtensions are the file extensions read from an included directory.
+var configExtensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// loadPath merges the configuration file at path, or every configuration
+// file in the directory at path in name order, into k. stack holds the
+// files being included, to reject include cycles; every file and directory
+// read is appended to sources.
+func loadPath(k *koanf.Koanf, path string, stack []string, sources *[]string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("loading config from %s: %w", path, err)
 	}
-	return c.Retention.TimestampField
+	if !fi.IsDir() {
+		return loadFile(k, path, stack, sources)
+	}
+
+	*sources = append(*sources, path)
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("loading config from %s: %w", path, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !slices.Contains(configExtensions, strings.ToLower(filepath.Ext(e.Name()))) {
+			continue
+		}
+		if err := loadFile(k, filepath.Join(path, e.Name()), stack, sources); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// ColdDaysForIndex returns the cold retention period (in days) for the given index.
-// It checks for an exact match first, then tries glob pattern matching,
-// and falls back to the global ColdDays default.
-func (c *Config) ColdDaysForIndex(index string) int {
-	if days, ok := c.Retention.IndexColdDays[index]; ok {
-		return days
+// loadFile merges the includes of the configuration file at path into k,
+// then the file itself. Relative includes are resolved against the file's
+// directory.
+func loadFile(k *koanf.Koanf, path string, stack []string, sources *[]string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("loading config from %s: %w", path, err)
 	}
-	for pattern, days := range c.Retention.IndexColdDays {
-		if matched, _ := filepath.Match(pattern, index); matched {
-			return days
+	if slices.Contains(stack, abs) {
+		return fmt.Errorf("loading config from %s: include cycle via %s", stack[0], path)
+	}
+	stack = append(stack, abs)
+
+	parser, err := parserFor(path)
+	if err != nil {
+		return err
+	}
+	fk := koanf.New(".")
+	if err := fk.Load(file.Provider(path), parser); err != nil {
+		return fmt.Errorf("loading config from %s: %w", path, err)
+	}
+	if fk.Exists("include") {
+		if _, ok := fk.Get("include").([]any); !ok {
+			return fmt.Errorf("loading config from %s: include must be a list of paths", path)
+		}
+		for _, inc := range fk.Strings("include") {
+			if !filepath.IsAbs(inc) {
+				inc = filepath.Join(filepath.Dir(path), inc)
+			}
+			if err := loadPath(k, inc, stack, sources); err != nil {
+				return err
+			}
 		}
+		fk.Delete("include")
 	}
-	return c.Retention.ColdDays
+
+	*sources = append(*sources, path)
+	return k.Merge(fk)
 }
 
-func setDefaults(cfg *Config) {
-	if cfg.Server.Listen == "" {
-		cfg.Server.Listen = ":9200"
+// Sources returns the configuration files and directories the configuration
+// was loaded from, includes first.
+func (c *Config) Sources() []string {
+	return c.sources
+}
+
+// parserFor selects the parser for a configuration file by its extension:
+// .json, .toml, or YAML for .yaml, .yml and files without an extension.
+func parserFor(path string) (koanf.Parser, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", "":
+		return yaml.Parser(), nil
+	case ".json":
+		return json.Parser(), nil
+	case ".toml":
+		return toml.Parser(), nil
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml, .json or .toml)", path, ext)
 	}
-	if cfg.Retention.Days <= 0 {
-		cfg.Retention.Days = 30
+}
+
+// SetDefaultUserAgent sets the User-Agent of every backend whose user_agent
+// is not configured. Binaries call it with their name and build version.
+func (c *Config) SetDefaultUserAgent(ua string) {
+	if c.OpenSearch.UserAgent == "" {
+		c.OpenSearch.UserAgent = ua
 	}
-	if cfg.Retention.TimestampField == "" {
-		cfg.Retention.TimestampField = "@timestamp"
+	for i := range c.Migration.Sources {
+		if c.Migration.Sources[i].OpenSearch.UserAgent == "" {
+			c.Migration.Sources[i].OpenSearch.UserAgent = ua
+		}
 	}
-	if cfg.Migration.BatchSize <= 0 {
-		cfg.Migration.BatchSize = 5000
+	for i := range c.Tiers {
+		if c.Tiers[i].OpenSearch.UserAgent == "" {
+			c.Tiers[i].OpenSearch.UserAgent = ua
+		}
 	}
-	if cfg.Migration.Workers <= 0 {
-		cfg.Migration.Workers = 4
+	if c.Quickwit.UserAgent == "" {
+		c.Quickwit.UserAgent = ua
 	}
-	if cfg.Migration.MigrateAfterDays <= 0 {
-		cfg.Migration.MigrateAfterDays = cfg.Retention.Days - 5
-		if cfg.Migration.MigrateAfterDays <= 0 {
-			// Ensure default is always less than Retention.Days to pass validation.
-			cfg.Migration.MigrateAfterDays = max(1, cfg.Retention.Days-1)
+	for i := range c.QuickwitClusters {
+		if c.QuickwitClusters[i].Quickwit.UserAgent == "" {
+			c.QuickwitClusters[i].Quickwit.UserAgent = ua
 		}
 	}
-	if cfg.Migration.Schedule == "" {
-		cfg.Migration.Schedule = "0 * * * *"
+}
+
+// QuickwitClusterForIndex returns the name of the quickwit_clusters entry
+// holding the Quickwit index, or "" for the quickwit cluster. The first
+// cluster with a matching pattern wins.
+func (c *Config) QuickwitClusterForIndex(index string) string {
+	for _, qc := range c.QuickwitClusters {
+		for _, pattern := range qc.Indices {
+			if matched, _ := filepath.Match(pattern, index); matched {
+				return qc.Name
+			}
+		}
 	}
-	if cfg.Logging.Level == "" {
-		cfg.Logging.Level = "info"
+	return ""
+}
+
+// SourceNames returns the names of the migration.sources entries, or an
+// empty name for the opensearch cluster followed by the names of the tiers
+// when none are configured. Pass each to ForSource.
+func (c *Config) SourceNames() []string {
+	if len(c.Migration.Sources) == 0 {
+		names := []string{""}
+		for _, t := range c.Tiers {
+			names = append(names, t.Name)
+		}
+		return names
 	}
+	names := make([]string, len(c.Migration.Sources))
+	for i, s := range c.Migration.Sources {
+		names[i] = s.Name
+	}
+	return names
 }
 
-func validate(cfg *Config) error {
-	if cfg.OpenSearch.URL == "" {
-		return fmt.Errorf("opensearch.url is required")
+// ForSource returns the configuration for migrating from the named
+// migration.sources entry: a copy with the source's connection and indices
+// in place of opensearch and migration.indices, and checkpoints kept in a
+// subdirectory of migration.checkpoint_dir named after the source. An empty
+// name returns c, whose opensearch cluster the proxy uses.
+//
+// A tier name returns the configuration for moving documents out of that
+// tier (see NextTier), after its migrate_after_days. migration.rules apply
+// to the move into Quickwit only: they are dropped for moves into a tier,
+// and the last tier's migrate_after_days replaces theirs.
+func (c *Config) ForSource(name string) (*Config, error) {
+	if i := slices.IndexFunc(c.Tiers, func(t TierConfig) bool { return t.Name == name }); i >= 0 {
+		return c.forTier(i), nil
 	}
-	if _, err := url.Parse(cfg.OpenSearch.URL); err != nil {
-		return fmt.Errorf("invalid opensearch.url: %w", err)
+	if name == "" {
+		if len(c.Tiers) == 0 {
+			return c, nil
+		}
+		sc := *c
+		sc.Migration.Rules = nil
+		return &sc, nil
+	}
+	i := slices.IndexFunc(c.Migration.Sources, func(s MigrationSource) bool { return s.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown migration source %q", name)
 	}
+	sc := *c
+	sc.OpenSearch = c.Migration.Sources[i].OpenSearch
+	sc.Migration.Indices = c.Migration.Sources[i].Indices
+	sc.Migration.Sources = nil
+	if c.Migration.CheckpointDir != "" {
+		sc.Migration.CheckpointDir = filepath.Join(c.Migration.CheckpointDir, name)
+	}
+	sc.source = name
+	return &sc, nil
+}
 
-	if cfg.Quickwit.URL == "" {
-		return fmt.Errorf("quickwit.url is required")
+func (c *Config) forTier(i int) *Config {
+	sc := *c
+	sc.OpenSearch = c.Tiers[i].OpenSearch
+	sc.Migration.MigrateAfterDays = c.Tiers[i].MigrateAfterDays
+	if i < len(c.Tiers)-1 {
+		sc.Migration.Rules = nil
+	} else {
+		sc.Migration.Rules = slices.Clone(c.Migration.Rules)
+		for j := range sc.Migration.Rules {
+			sc.Migration.Rules[j].MigrateAfterDays = 0
+		}
 	}
-	if _, err := url.Parse(cfg.Quickwit.URL); err != nil {
-		return fmt.Errorf("invalid quickwit.url: %w", err)
+	if c.Migration.CheckpointDir != "" {
+		sc.Migration.CheckpointDir = filepath.Join(c.Migration.CheckpointDir, c.Tiers[i].Name)
 	}
+	sc.source = c.Tiers[i].Name
+	return &sc
+}
 
-	if cfg.Migration.MigrateAfterDays >= cfg.Retention.Days {
-		return fmt.Errorf("migration.migrate_after_days (%d) must be less than retention.days (%d)", cfg.Migration.MigrateAfterDays, cfg.Retention.Days)
+// Source returns the name of the migration source or tier selected by
+// ForSource, or "" for the opensearch cluster.
+func (c *Config) Source() string {
+	return c.source
+}
+
+// NextTier returns the tier that migrating with c, as returned by
+// ForSource, moves documents into, or nil if they go to Quickwit.
+func (c *Config) NextTier() *TierConfig {
+	i := slices.IndexFunc(c.Tiers, func(t TierConfig) bool { return t.Name == c.source })
+	if i+1 < len(c.Tiers) {
+		return &c.Tiers[i+1]
 	}
+	return nil
+}
 
-	if cfg.Migration.TempDir != "" {
-		if err := os.MkdirAll(cfg.Migration.TempDir, 0755); err != nil {
-			return fmt.Errorf("migration.temp_dir %q: %w", cfg.Migration.TempDir, err)
+// StoragePrices returns the cost per GB and month of storing documents in
+// the cluster migrating with c reads from, and in the one it moves them
+// into: a tier, or Quickwit.
+func (c *Config) StoragePrices() (source, target float64) {
+	tier := func(name string) float64 {
+		if price, ok := c.Cost.TierGBMonth[name]; ok {
+			return price
 		}
+		return c.Cost.HotGBMonth
+	}
+	source = c.Cost.HotGBMonth
+	if slices.ContainsFunc(c.Tiers, func(t TierConfig) bool { return t.Name == c.source }) {
+		source = tier(c.source)
 	}
+	target = c.Cost.ColdGBMonth
+	if next := c.NextTier(); next != nil {
+		target = tier(next.Name)
+	}
+	return source, target
+}
 
-	return nil
+// TierDays returns the ages in days at which documents of index leave each
+// tier before Quickwit: its hot retention period, then the days of each
+// entry of tiers.
+func (c *Config) TierDays(index string) []int {
+	days := []int{c.HotDaysForIndex(index)}
+	for _, t := range c.Tiers {
+		days = append(days, t.Days)
+	}
+	return days
+}
+
+// RemoteClustersForIndex splits an index in cross-cluster syntax,
+// "cluster:index", and returns the remote_clusters entries the cluster part
+// names or matches, which may be none. ok is false for local indices.
+func (c *Config) RemoteClustersForIndex(index string) (clusters []RemoteClusterConfig, name string, ok bool) {
+	cluster, name, ok := strings.Cut(index, ":")
+	if !ok {
+		return nil, index, false
+	}
+	for _, rc := range c.RemoteClusters {
+		if matched, _ := filepath.Match(cluster, rc.Name); matched {
+			clusters = append(clusters, rc)
+		}
+	}
+	return clusters, name, true
+}
+
+// RemoteHotDays returns the days the remote cluster rc keeps in OpenSearch.
+func (c *Config) RemoteHotDays(rc RemoteClusterConfig) int {
+	if rc.RetentionDays > 0 {
+		return rc.RetentionDays
+	}
+	return c.Retention.Days
+}
+
+// TimestampFieldForIndex returns the timestamp field name for the given index.
+// It checks for an exact match first, then tries glob pattern matching,
+// and falls back to the global default timestamp field.
+func (c *Config) TimestampFieldForIndex(index string) string {
+	if field, ok := c.Retention.IndexFields[index]; ok {
+		return field
+	}
+	for pattern, field := range c.Retention.IndexFields {
+		if matched, _ := filepath.Match(pattern, index); matched {
+			return field
+		}
+	}
+	return c.Retention.TimestampField
+}
+
+// Location returns the time zone of retention.timezone, UTC if unset.
+func (c *Config) Location() *time.Location {
+	if c.location == nil {
+		return time.UTC
+	}
+	return c.location
+}
+
+// RoutingLocation returns the time zone in which the proxy's tier cutoffs
+// fall on midnight: that of retention.timezone, or nil if it is unset, in
+// which case the cutoffs lie exactly the retention period before the search.
+func (c *Config) RoutingLocation() *time.Location {
+	if c.Retention.Timezone == "" {
+		return nil
+	}
+	return c.Location()
+}
+
+// DaysAgo returns the start of the day, in retention.timezone, that lies
+// days before t. Cutoffs counted in days fall on these boundaries, so they
+// line up with daily indices rolling over at midnight in that time zone.
+func (c *Config) DaysAgo(t time.Time, days int) time.Time {
+	y, m, d := t.In(c.Location()).AddDate(0, 0, -days).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.Location())
+}
+
+// HotDaysForIndex returns how long (in days) the given index keeps data in
+// OpenSearch: an exact retention.index_days entry, else a matching glob
+// pattern, else retention.days.
+func (c *Config) HotDaysForIndex(index string) int {
+	if days, ok := c.Retention.IndexDays[index]; ok {
+		return days
+	}
+	for pattern, days := range c.Retention.IndexDays {
+		if matched, _ := filepath.Match(pattern, index); matched {
+			return days
+		}
+	}
+	return c.Retention.Days
+}
+
+// ColdDaysForIndex returns the cold retention period (in days) for the given index.
+// It checks for an exact match first, then tries glob pattern matching,
+// and falls back to the global ColdDays default.
+func (c *Config) ColdDaysForIndex(index string) int {
+	if days, ok := c.Retention.IndexColdDays[index]; ok {
+		return days
+	}
+	for pattern, days := range c.Retention.IndexColdDays {
+		if matched, _ := filepath.Match(pattern, index); matched {
+			return days
+		}
+	}
+	return c.Retention.ColdDays
+}
+
+// MigrationSettingsForIndex returns the effective workers, batch size,
+// compression and staging directory for the given index. An exact key in
+// migration.index_overrides wins; otherwise the longest matching glob
+// pattern is used, so "firewall-prod-*" beats "firewall-*".
+func (c *Config) MigrationSettingsForIndex(index string) IndexMigrationSettings {
+	s := IndexMigrationSettings{
+		Workers:   c.Migration.Workers,
+		BatchSize: c.Migration.BatchSize,
+		Compress:  c.Migration.Compress,
+		TempDir:   c.Migration.TempDir,
+	}
+
+	o, ok := c.indexOverride(index)
+	if !ok {
+		return s
+	}
+
+	if o.Workers > 0 {
+		s.Workers = o.Workers
+	}
+	if o.BatchSize > 0 {
+		s.BatchSize = o.BatchSize
+	}
+	if o.Compress != nil {
+		s.Compress = *o.Compress
+	}
+	if o.TempDir != "" {
+		s.TempDir = o.TempDir
+	}
+	return s
+}
+
+// QuickwitIndexSettingsForIndex returns the Quickwit index settings to use
+// when creating the given index: quickwit.index_settings, with any field set
+// in the matching migration.index_overrides entry taking precedence. A merge
+// policy is replaced as a whole when the override names a type.
+func (c *Config) QuickwitIndexSettingsForIndex(index string) QuickwitIndexSettings {
+	s := c.Quickwit.IndexSettings
+	o, ok := c.indexOverride(index)
+	if !ok {
+		return s
+	}
+
+	if o.Quickwit.CommitTimeoutSecs > 0 {
+		s.CommitTimeoutSecs = o.Quickwit.CommitTimeoutSecs
+	}
+	if o.Quickwit.SplitNumDocsTarget > 0 {
+		s.SplitNumDocsTarget = o.Quickwit.SplitNumDocsTarget
+	}
+	if o.Quickwit.MergePolicy.Type != "" {
+		s.MergePolicy = o.Quickwit.MergePolicy
+	}
+	if len(o.Quickwit.DefaultSearchFields) > 0 {
+		s.DefaultSearchFields = o.Quickwit.DefaultSearchFields
+	}
+	return s
+}
+
+// DualWriteIndex reports whether new documents of index are mirrored to
+// Quickwit by the proxy (dual_write).
+func (c *Config) DualWriteIndex(index string) bool {
+	if !c.DualWrite.Enabled {
+		return false
+	}
+	for _, pattern := range c.DualWrite.Indices {
+		if ok, _ := filepath.Match(pattern, index); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LateWriteIndex reports whether documents written to index that are older
+// than its hot retention period go straight to Quickwit (late_writes).
+func (c *Config) LateWriteIndex(index string) bool {
+	if !c.LateWrites.Enabled {
+		return false
+	}
+	for _, pattern := range c.LateWrites.Indices {
+		if ok, _ := filepath.Match(pattern, index); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrationPolicyForIndex returns the migration policy for the given index:
+// the first migration.rules entry with a matching pattern, with unset fields
+// taken from the global migration settings.
+func (c *Config) MigrationPolicyForIndex(index string) IndexMigrationPolicy {
+	p := IndexMigrationPolicy{
+		MigrateAfterDays:     c.Migration.MigrateAfterDays,
+		DeleteAfterMigration: c.Migration.DeleteAfterMigration,
+		TargetIndex:          c.QuickwitIndexForIndex(index),
+	}
+	r, ok := c.migrationRule(index)
+	if !ok {
+		return p
+	}
+	if r.MigrateAfterDays > 0 {
+		p.MigrateAfterDays = r.MigrateAfterDays
+	}
+	if r.DeleteAfterMigration != nil {
+		p.DeleteAfterMigration = *r.DeleteAfterMigration
+	}
+	p.Filter = r.Filter
+	p.Transforms = r.Transforms
+	p.Delete, p.DeleteAfterDays = r.Action == "delete", r.DeleteAfterDays
+	return p
+}
+
+// QuickwitIndexForIndex returns the name of the Quickwit index that holds
+// the migrated documents of the given index or index pattern.
+func (c *Config) QuickwitIndexForIndex(index string) string {
+	if r, ok := c.migrationRule(index); ok && r.TargetIndex != "" {
+		return strings.ReplaceAll(r.TargetIndex, "{index}", index)
+	}
+	return index
+}
+
+// migrationRule returns the first migration.rules entry with a pattern
+// matching index.
+func (c *Config) migrationRule(index string) (MigrationRule, bool) {
+	for _, r := range c.Migration.Rules {
+		for _, pattern := range r.Indices {
+			if matched, _ := filepath.Match(pattern, index); matched {
+				return r, true
+			}
+		}
+	}
+	return MigrationRule{}, false
+}
+
+// RollupsForIndex returns the migration.rollups entries summarizing index.
+func (c *Config) RollupsForIndex(index string) []RollupConfig {
+	var rollups []RollupConfig
+	for _, r := range c.Migration.Rollups {
+		for _, pattern := range r.Indices {
+			if matched, _ := filepath.Match(pattern, index); matched {
+				rollups = append(rollups, r)
+				break
+			}
+		}
+	}
+	return rollups
+}
+
+// RollupIndex reports whether index is the target of a migration.rollups
+// entry. Those indices only exist in OpenSearch.
+func (c *Config) RollupIndex(index string) bool {
+	for _, r := range c.Migration.Rollups {
+		if r.Target == index {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOverride returns the migration.index_overrides entry for index: an
+// exact key wins; otherwise the longest matching glob pattern is used.
+func (c *Config) indexOverride(index string) (IndexOverride, bool) {
+	if o, ok := c.Migration.IndexOverrides[index]; ok {
+		return o, true
+	}
+	var (
+		o    IndexOverride
+		ok   bool
+		best string
+	)
+	for pattern, candidate := range c.Migration.IndexOverrides {
+		if matched, _ := filepath.Match(pattern, index); matched && (len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best)) {
+			best, o, ok = pattern, candidate, true
+		}
+	}
+	return o, ok
+}
+
+func setDefaults(cfg *Config) {
+	if cfg.Server.Listen == "" {
+		cfg.Server.Listen = ":9200"
+	}
+	if cfg.Server.ReverseProxy.BufferSizeKB <= 0 {
+		cfg.Server.ReverseProxy.BufferSizeKB = 32
+	}
+	if cfg.Server.PageCache.TTL == 0 {
+		cfg.Server.PageCache.TTL = 5 * time.Minute
+	}
+	if cfg.Server.PageCache.MaxEntries == 0 {
+		cfg.Server.PageCache.MaxEntries = 100
+	}
+	if cfg.Server.PageCache.MaxHits == 0 {
+		cfg.Server.PageCache.MaxHits = 1000
+	}
+	if cfg.Server.PageCache.IdentityHeaders == nil {
+		cfg.Server.PageCache.IdentityHeaders = []string{"Authorization", "Cookie", "X-Proxy-User", "X-Proxy-Roles"}
+	}
+	if cfg.Server.Rehydrate.AdminRoles == nil {
+		cfg.Server.Rehydrate.AdminRoles = []string{"all_access"}
+	}
+	if cfg.Server.Rehydrate.IndexPrefix == "" {
+		cfg.Server.Rehydrate.IndexPrefix = "rehydrated-"
+	}
+	if cfg.Server.Rehydrate.MaxDocs == 0 {
+		cfg.Server.Rehydrate.MaxDocs = 10000000
+	}
+	if cfg.Server.Rehydrate.BatchSize == 0 {
+		cfg.Server.Rehydrate.BatchSize = 1000
+	}
+	if cfg.Server.Tail.PollInterval == 0 {
+		cfg.Server.Tail.PollInterval = 2 * time.Second
+	}
+	if cfg.Server.Tail.PageSize == 0 {
+		cfg.Server.Tail.PageSize = 500
+	}
+	if cfg.Server.Tail.MaxDuration == 0 {
+		cfg.Server.Tail.MaxDuration = time.Hour
+	}
+	if cfg.Server.Tenancy.AdminRoles == nil {
+		cfg.Server.Tenancy.AdminRoles = []string{"all_access"}
+	}
+	if cfg.Server.Tenancy.CacheTTL == 0 {
+		cfg.Server.Tenancy.CacheTTL = 30 * time.Second
+	}
+	if cfg.Server.Tenancy.IdentityHeaders == nil {
+		cfg.Server.Tenancy.IdentityHeaders = []string{"Authorization", "Cookie", "X-Proxy-User", "X-Proxy-Roles"}
+	}
+	if cfg.Server.ColdQuota.ExemptRoles == nil {
+		cfg.Server.ColdQuota.ExemptRoles = []string{"all_access"}
+	}
+	if cfg.Server.ColdQuota.Index == "" {
+		cfg.Server.ColdQuota.Index = ".oqbridge-cold-usage"
+	}
+	if cfg.Server.ColdQuota.FlushInterval == 0 {
+		cfg.Server.ColdQuota.FlushInterval = 10 * time.Second
+	}
+	if cfg.Server.ColdQuota.CacheTTL == 0 {
+		cfg.Server.ColdQuota.CacheTTL = 30 * time.Second
+	}
+	if cfg.Server.ColdQuota.IdentityHeaders == nil {
+		cfg.Server.ColdQuota.IdentityHeaders = []string{"Authorization", "Cookie", "X-Proxy-User", "X-Proxy-Roles"}
+	}
+	if cfg.Server.ColdFanout.MaxConcurrency == 0 {
+		cfg.Server.ColdFanout.MaxConcurrency = 8
+	}
+	if cfg.Server.ColdFanout.MaxGlobal == 0 {
+		cfg.Server.ColdFanout.MaxGlobal = 64
+	}
+	if cfg.Server.ColdCache.TTL == 0 {
+		cfg.Server.ColdCache.TTL = 30 * time.Minute
+	}
+	if cfg.Server.ColdCache.MaxEntries == 0 {
+		cfg.Server.ColdCache.MaxEntries = 1000
+	}
+	if cfg.Server.ColdCache.MaxHits == 0 {
+		cfg.Server.ColdCache.MaxHits = 1000
+	}
+	if cfg.Server.Capture.SampleRate == 0 {
+		cfg.Server.Capture.SampleRate = 1
+	}
+	if cfg.Server.Capture.MaxSizeMB == 0 {
+		cfg.Server.Capture.MaxSizeMB = 100
+	}
+	if cfg.Server.Failover.CheckInterval == 0 {
+		cfg.Server.Failover.CheckInterval = 10 * time.Second
+	}
+	if cfg.Server.Failover.FailureThreshold == 0 {
+		cfg.Server.Failover.FailureThreshold = 3
+	}
+	if cfg.Server.Compat.ProductHeader == "" {
+		cfg.Server.Compat.ProductHeader = "Elasticsearch"
+	}
+	if cfg.Server.Router.Name == "" {
+		cfg.Server.Router.Name = "time_range"
+	}
+	setOpenSearchDefaults(&cfg.OpenSearch)
+	for i := range cfg.Migration.Sources {
+		setOpenSearchDefaults(&cfg.Migration.Sources[i].OpenSearch)
+	}
+	for i := range cfg.Tiers {
+		setOpenSearchDefaults(&cfg.Tiers[i].OpenSearch)
+		if cfg.Tiers[i].OnError == "" {
+			cfg.Tiers[i].OnError = "skip"
+		}
+	}
+	setRetryDefaults(&cfg.Quickwit.Retry)
+	if cfg.Quickwit.IngestAPI == "" {
+		cfg.Quickwit.IngestAPI = "v1"
+	}
+	if cfg.Quickwit.IngestCommit == "" {
+		cfg.Quickwit.IngestCommit = "auto"
+	}
+	if cfg.Quickwit.SearchAPI == "" {
+		cfg.Quickwit.SearchAPI = "passthrough"
+	}
+	if cfg.Quickwit.ListCacheTTL == 0 {
+		cfg.Quickwit.ListCacheTTL = 30 * time.Second
+	}
+	if cfg.Quickwit.DetectInterval == 0 {
+		cfg.Quickwit.DetectInterval = 5 * time.Minute
+	}
+	if cfg.Quickwit.IndexSettings.CommitTimeoutSecs <= 0 {
+		cfg.Quickwit.IndexSettings.CommitTimeoutSecs = 60
+	}
+	for i := range cfg.QuickwitClusters {
+		qc := &cfg.QuickwitClusters[i].Quickwit
+		setRetryDefaults(&qc.Retry)
+		if qc.IngestAPI == "" {
+			qc.IngestAPI = cfg.Quickwit.IngestAPI
+		}
+		if qc.IngestCommit == "" {
+			qc.IngestCommit = cfg.Quickwit.IngestCommit
+		}
+		if qc.SearchAPI == "" {
+			qc.SearchAPI = cfg.Quickwit.SearchAPI
+		}
+		if qc.ListCacheTTL == 0 {
+			qc.ListCacheTTL = cfg.Quickwit.ListCacheTTL
+		}
+		qc.IndexSettings = cfg.Quickwit.IndexSettings
+	}
+	if cfg.Retention.Days <= 0 {
+		cfg.Retention.Days = 30
+	}
+	if cfg.Retention.TimestampField == "" {
+		cfg.Retention.TimestampField = "@timestamp"
+	}
+	if cfg.Retention.TTL.Schedule == "" {
+		cfg.Retention.TTL.Schedule = "45 3 * * *"
+	}
+	if cfg.Retention.TTL.MaxDropped == 0 {
+		cfg.Retention.TTL.MaxDropped = 10
+	}
+	if cfg.Retention.Enforce.Schedule == "" {
+		cfg.Retention.Enforce.Schedule = "30 3 * * *"
+	}
+	if cfg.Retention.Runtime.AdminRoles == nil {
+		cfg.Retention.Runtime.AdminRoles = []string{"all_access"}
+	}
+	if cfg.Retention.Runtime.SyncInterval == 0 {
+		cfg.Retention.Runtime.SyncInterval = 15 * time.Second
+	}
+	if cfg.Retention.Runtime.Delay == 0 {
+		cfg.Retention.Runtime.Delay = time.Minute
+	}
+	if cfg.Dashboard.Refresh <= 0 {
+		cfg.Dashboard.Refresh = 10 * time.Second
+	}
+	if cfg.Dashboard.History <= 0 {
+		cfg.Dashboard.History = 50
+	}
+	for i := range cfg.Server.Monitors {
+		m := &cfg.Server.Monitors[i]
+		if m.Value == "" {
+			m.Value = "hits.total.value"
+		}
+		if m.Condition == "" {
+			m.Condition = "> 0"
+		}
+	}
+	if cfg.Cost.Currency == "" {
+		cfg.Cost.Currency = "USD"
+	}
+	for _, fc := range []*FaultConfig{&cfg.Chaos.Hot, &cfg.Chaos.Cold} {
+		if fc.ErrorStatus == 0 {
+			fc.ErrorStatus = 503
+		}
+	}
+	if cfg.Export.Compression == "" {
+		cfg.Export.Compression = "snappy"
+	}
+	if cfg.Export.MaxFileDocs <= 0 {
+		cfg.Export.MaxFileDocs = 100000
+	}
+	if cfg.Migration.Reconcile.Schedule == "" {
+		cfg.Migration.Reconcile.Schedule = "0 5 * * *"
+	}
+	if cfg.Migration.Reconcile.Days <= 0 {
+		cfg.Migration.Reconcile.Days = 7
+	}
+	if cfg.Migration.StateBackup.Schedule == "" {
+		cfg.Migration.StateBackup.Schedule = "15 4 * * *"
+	}
+	for i := range cfg.Migration.Rollups {
+		r := &cfg.Migration.Rollups[i]
+		if r.Target == "" && r.Name != "" {
+			r.Target = "rollup-" + r.Name
+		}
+		if r.Interval == 0 {
+			r.Interval = time.Hour
+		}
+	}
+	if cfg.Migration.StateBackup.Keep <= 0 {
+		cfg.Migration.StateBackup.Keep = 7
+	}
+	if cfg.Migration.BatchSize <= 0 {
+		cfg.Migration.BatchSize = 5000
+	}
+	if cfg.Migration.Workers <= 0 {
+		cfg.Migration.Workers = 4
+	}
+	if cfg.Migration.PipelineDepth <= 0 {
+		cfg.Migration.PipelineDepth = 2
+	}
+	if cfg.Migration.MaxBufferedMB == 0 {
+		cfg.Migration.MaxBufferedMB = 512
+	}
+	if cfg.Migration.MigrateAfterDays <= 0 {
+		cfg.Migration.MigrateAfterDays = cfg.Retention.Days - 5
+		if cfg.Migration.MigrateAfterDays <= 0 {
+			// Ensure default is always less than Retention.Days to pass validation.
+			cfg.Migration.MigrateAfterDays = max(1, cfg.Retention.Days-1)
+		}
+	}
+	if cfg.Migration.Schedule == "" {
+		cfg.Migration.Schedule = "0 * * * *"
+	}
+	if cfg.Migration.HealthGate.MaxStatus == "" {
+		cfg.Migration.HealthGate.MaxStatus = "yellow"
+	}
+	if cfg.Migration.HealthGate.Interval <= 0 {
+		cfg.Migration.HealthGate.Interval = 30 * time.Second
+	}
+	if cfg.Migration.HealthGate.MaxPause <= 0 {
+		cfg.Migration.HealthGate.MaxPause = 5 * time.Minute
+	}
+	if cfg.Migration.LockTTL <= 0 {
+		cfg.Migration.LockTTL = 2 * time.Hour
+	}
+	if cfg.Migration.ScrollKeepAlive <= 0 {
+		cfg.Migration.ScrollKeepAlive = 10 * time.Minute
+	}
+	if cfg.Migration.Snapshot.Mode == "" {
+		cfg.Migration.Snapshot.Mode = "restore"
+	}
+	if cfg.Migration.Snapshot.IndexPrefix == "" {
+		cfg.Migration.Snapshot.IndexPrefix = "oqbridge-restore-"
+	}
+	if cfg.Migration.Snapshot.RestoreTimeout <= 0 {
+		cfg.Migration.Snapshot.RestoreTimeout = 30 * time.Minute
+	}
+	if cfg.Notifications.Email.SMTPPort <= 0 {
+		cfg.Notifications.Email.SMTPPort = 587
+	}
+	if cfg.Vault.Address != "" {
+		if cfg.Vault.AuthMethod == "" {
+			cfg.Vault.AuthMethod = "token"
+		}
+		if cfg.Vault.AuthMount == "" {
+			cfg.Vault.AuthMount = cfg.Vault.AuthMethod
+		}
+		if cfg.Vault.ServiceAccountTokenFile == "" {
+			cfg.Vault.ServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		}
+		if cfg.Vault.RefreshInterval <= 0 {
+			cfg.Vault.RefreshInterval = 5 * time.Minute
+		}
+	}
+	if cfg.DualWrite.BufferDocs <= 0 {
+		cfg.DualWrite.BufferDocs = 100000
+	}
+	if cfg.DualWrite.BatchSize <= 0 {
+		cfg.DualWrite.BatchSize = 1000
+	}
+	if cfg.DualWrite.FlushInterval <= 0 {
+		cfg.DualWrite.FlushInterval = time.Second
+	}
+	if cfg.DualWrite.MaxRetries == 0 {
+		cfg.DualWrite.MaxRetries = 5
+	}
+	if cfg.DualWrite.RetryBackoff <= 0 {
+		cfg.DualWrite.RetryBackoff = time.Second
+	}
+	if cfg.DualWrite.DropPolicy == "" {
+		cfg.DualWrite.DropPolicy = "newest"
+	}
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+}
+
+func setRetryDefaults(rc *RetryConfig) {
+	if rc.MaxAttempts == 0 {
+		rc.MaxAttempts = 3
+	}
+	if rc.InitialBackoff <= 0 {
+		rc.InitialBackoff = 200 * time.Millisecond
+	}
+	if rc.MaxBackoff <= 0 {
+		rc.MaxBackoff = 5 * time.Second
+	}
+	if len(rc.Methods) == 0 {
+		// Idempotent methods only: a retried POST may apply twice.
+		rc.Methods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+	}
+}
+
+func setOpenSearchDefaults(oc *OpenSearchConfig) {
+	setRetryDefaults(&oc.Retry)
+	if oc.SigV4.Service == "" {
+		oc.SigV4.Service = "es"
+	}
+}
+
+func validate(cfg *Config) error {
+	if err := validateOpenSearch("opensearch", cfg.OpenSearch); err != nil {
+		return err
+	}
+	if len(cfg.Migration.Sources) > 0 && len(cfg.Migration.Indices) > 0 {
+		return fmt.Errorf("migration.indices cannot be combined with migration.sources; list the indices of each source")
+	}
+	for i, s := range cfg.Migration.Sources {
+		key := fmt.Sprintf("migration.sources[%d]", i)
+		if s.Name == "" || strings.ContainsAny(s.Name, `/\`) || s.Name == "." || s.Name == ".." {
+			return fmt.Errorf("%s.name must be set and usable as a directory name, got %q", key, s.Name)
+		}
+		if slices.ContainsFunc(cfg.Migration.Sources[:i], func(o MigrationSource) bool { return o.Name == s.Name }) {
+			return fmt.Errorf("%s.name %q is not unique", key, s.Name)
+		}
+		if err := validateOpenSearch(key+".opensearch", s.OpenSearch); err != nil {
+			return err
+		}
+	}
+
+	if err := validateQuickwit("quickwit", cfg.Quickwit); err != nil {
+		return err
+	}
+	for i, qc := range cfg.QuickwitClusters {
+		key := fmt.Sprintf("quickwit_clusters[%d]", i)
+		if qc.Name == "" {
+			return fmt.Errorf("%s.name is required", key)
+		}
+		if slices.ContainsFunc(cfg.QuickwitClusters[:i], func(o QuickwitCluster) bool { return o.Name == qc.Name }) {
+			return fmt.Errorf("%s.name %q is not unique", key, qc.Name)
+		}
+		if len(qc.Indices) == 0 {
+			return fmt.Errorf("%s.indices must list at least one index pattern", key)
+		}
+		for _, pattern := range qc.Indices {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s.indices: invalid pattern %q: %w", key, pattern, err)
+			}
+		}
+		if err := validateQuickwit(key+".quickwit", qc.Quickwit); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Migration.MigrateAfterDays >= cfg.Retention.Days {
+		return fmt.Errorf("migration.migrate_after_days (%d) must be less than retention.days (%d)", cfg.Migration.MigrateAfterDays, cfg.Retention.Days)
+	}
+
+	if rt := cfg.Retention.Runtime; rt.Enabled {
+		if len(rt.AdminRoles) == 0 {
+			return fmt.Errorf("retention.runtime.admin_roles must not be empty")
+		}
+		if rt.SyncInterval <= 0 {
+			return fmt.Errorf("retention.runtime.sync_interval must be positive")
+		}
+		// Every instance must have read a change before it takes effect.
+		if rt.Delay <= rt.SyncInterval {
+			return fmt.Errorf("retention.runtime.delay (%s) must be greater than retention.runtime.sync_interval (%s)", rt.Delay, rt.SyncInterval)
+		}
+	}
+
+	loc, err := time.LoadLocation(cfg.Retention.Timezone)
+	if err != nil {
+		return fmt.Errorf("retention.timezone: %w", err)
+	}
+	cfg.location = loc
+
+	for pattern, days := range cfg.Retention.IndexDays {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("retention.index_days: invalid pattern %q: %w", pattern, err)
+		}
+		// Data must still be in OpenSearch when it becomes due for migration.
+		if after := cfg.MigrationPolicyForIndex(pattern).MigrateAfterDays; days <= after {
+			return fmt.Errorf("retention.index_days[%q] (%d) must be greater than the migrate_after_days of those indices (%d)", pattern, days, after)
+		}
+	}
+
+	if pc := cfg.Server.PageCache; pc.Enabled && (pc.TTL < 0 || pc.MaxEntries < 0 || pc.MaxHits < 0) {
+		return fmt.Errorf("server.page_cache: ttl, max_entries and max_hits must be positive")
+	}
+	if rh := cfg.Server.Rehydrate; rh.Enabled {
+		if len(rh.AdminRoles) == 0 {
+			return fmt.Errorf("server.rehydrate.admin_roles must not be empty")
+		}
+		if rh.IndexPrefix != strings.ToLower(rh.IndexPrefix) || strings.ContainsAny(rh.IndexPrefix[:1], "-_+") {
+			return fmt.Errorf("server.rehydrate.index_prefix %q is not a valid OpenSearch index name prefix", rh.IndexPrefix)
+		}
+		if rh.MaxDocs < 0 || rh.BatchSize < 0 {
+			return fmt.Errorf("server.rehydrate: max_docs and batch_size must be positive")
+		}
+	}
+	if t := cfg.Server.Tail; t.Enabled && (t.PollInterval < 0 || t.PageSize < 0 || t.MaxDuration < 0) {
+		return fmt.Errorf("server.tail: poll_interval, page_size and max_duration must be positive")
+	}
+	if cfg.Server.Tail.PageSize > 10000 {
+		return fmt.Errorf("server.tail.page_size must be at most 10000, Quickwit's max_hits limit")
+	}
+	if tn := cfg.Server.Tenancy; tn.Enabled {
+		if err := validateTenancy(tn); err != nil {
+			return err
+		}
+	}
+	if q := cfg.Server.ColdQuota; q.Enabled {
+		if err := validateColdQuota(q); err != nil {
+			return err
+		}
+	}
+	if c := cfg.Server.Capture; c.Enabled {
+		if c.Path == "" {
+			return fmt.Errorf("server.capture.path is required when capture is enabled")
+		}
+		if c.SampleRate < 0 || c.SampleRate > 1 {
+			return fmt.Errorf("server.capture.sample_rate must be between 0 and 1, got %g", c.SampleRate)
+		}
+		if c.MaxSizeMB < 0 {
+			return fmt.Errorf("server.capture.max_size_mb must be positive")
+		}
+	}
+
+	if len(cfg.Tiers) > 0 && len(cfg.Migration.Sources) > 0 {
+		return fmt.Errorf("tiers cannot be combined with migration.sources")
+	}
+	// Every index leaves the hot tier before the first tier's days.
+	prevKey, prevDays := "retention.days", cfg.Retention.Days
+	for pattern, days := range cfg.Retention.IndexDays {
+		if days > prevDays {
+			prevKey, prevDays = fmt.Sprintf("retention.index_days[%q]", pattern), days
+		}
+	}
+	for i, t := range cfg.Tiers {
+		key := fmt.Sprintf("tiers[%d]", i)
+		if t.Name == "" || strings.ContainsAny(t.Name, `/\`) || t.Name == "." || t.Name == ".." {
+			return fmt.Errorf("%s.name must be set and usable as a directory name, got %q", key, t.Name)
+		}
+		if slices.ContainsFunc(cfg.Tiers[:i], func(o TierConfig) bool { return o.Name == t.Name }) {
+			return fmt.Errorf("%s.name %q is not unique", key, t.Name)
+		}
+		if err := validateOpenSearch(key+".opensearch", t.OpenSearch); err != nil {
+			return err
+		}
+		if t.Days <= prevDays {
+			return fmt.Errorf("%s.days (%d) must be greater than %s (%d)", key, t.Days, prevKey, prevDays)
+		}
+		if t.MigrateAfterDays < 0 || t.MigrateAfterDays >= t.Days {
+			return fmt.Errorf("%s.migrate_after_days (%d) must be between 0 and days (%d)", key, t.MigrateAfterDays, t.Days)
+		}
+		if t.OnError != "skip" && t.OnError != "fail" {
+			return fmt.Errorf("%s.on_error must be \"skip\" or \"fail\", got %q", key, t.OnError)
+		}
+		prevKey, prevDays = key+".days", t.Days
+	}
+
+	for i, rc := range cfg.RemoteClusters {
+		key := fmt.Sprintf("remote_clusters[%d]", i)
+		if rc.Name == "" || strings.ContainsAny(rc.Name, ":,*?") {
+			return fmt.Errorf("%s.name must be set to a cluster alias, got %q", key, rc.Name)
+		}
+		if slices.ContainsFunc(cfg.RemoteClusters[:i], func(o RemoteClusterConfig) bool { return o.Name == rc.Name }) {
+			return fmt.Errorf("%s.name %q is not unique", key, rc.Name)
+		}
+		if rc.QuickwitCluster != "" && !slices.ContainsFunc(cfg.QuickwitClusters, func(qc QuickwitCluster) bool { return qc.Name == rc.QuickwitCluster }) {
+			return fmt.Errorf("%s.quickwit_cluster %q is not an entry of quickwit_clusters", key, rc.QuickwitCluster)
+		}
+		if rc.RetentionDays < 0 {
+			return fmt.Errorf("%s.retention_days must not be negative", key)
+		}
+	}
+
+	for i := range cfg.RoutingRules {
+		rule := &cfg.RoutingRules[i]
+		key := fmt.Sprintf("routing_rules[%d]", i)
+		if rule.Name != "" {
+			key = fmt.Sprintf("routing_rules[%s]", rule.Name)
+		}
+		if rule.When == "" {
+			return fmt.Errorf("%s.when is required", key)
+		}
+		var err error
+		if rule.when, err = expr.Compile(rule.When, RoutingRuleSchema); err != nil {
+			return fmt.Errorf("%s.when: %w", key, err)
+		}
+		switch {
+		case rule.Route == "hot" || rule.Route == "cold" || rule.Route == "all" || rule.Route == "default":
+		case slices.ContainsFunc(cfg.Tiers, func(t TierConfig) bool { return t.Name == rule.Route }):
+		default:
+			return fmt.Errorf("%s.route must be hot, cold, all, default or the name of a tiers entry, got %q", key, rule.Route)
+		}
+	}
+
+	monitors := make(map[string]bool)
+	for i := range cfg.Server.Monitors {
+		m := &cfg.Server.Monitors[i]
+		key := fmt.Sprintf("server.monitors[%d]", i)
+		if m.Name == "" {
+			return fmt.Errorf("%s.name is required", key)
+		}
+		if monitors[m.Name] {
+			return fmt.Errorf("server.monitors: duplicate name %q", m.Name)
+		}
+		monitors[m.Name] = true
+		key = fmt.Sprintf("server.monitors[%s]", m.Name)
+		if m.Schedule == "" || len(m.Indices) == 0 || m.WebhookURL == "" {
+			return fmt.Errorf("%s: schedule, indices and webhook_url are required", key)
+		}
+		op, number, _ := strings.Cut(strings.TrimSpace(m.Condition), " ")
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if !slices.Contains([]string{">", ">=", "<", "<=", "==", "!="}, op) || err != nil {
+			return fmt.Errorf("%s.condition must be an operator (>, >=, <, <=, ==, !=) and a number, got %q", key, m.Condition)
+		}
+		m.op, m.threshold = op, threshold
+	}
+	if c := cfg.Server.ColdCache; c.Enabled && (c.TTL < 0 || c.MaxEntries < 0 || c.MaxHits < 0) {
+		return fmt.Errorf("server.cold_cache: ttl, max_entries and max_hits must be positive")
+	}
+	if f := cfg.Server.Failover; f.Enabled {
+		if f.UsersFile == "" {
+			return fmt.Errorf("server.failover.users_file is required when failover is enabled")
+		}
+		if f.CheckInterval < 0 || f.FailureThreshold < 0 {
+			return fmt.Errorf("server.failover: check_interval and failure_threshold must be positive")
+		}
+	}
+	if v := cfg.Server.Compat.Version; v != "" && !isVersionNumber(v) {
+		return fmt.Errorf("server.compat.version must be a version number such as \"7.10.2\", got %q", v)
+	}
+	warmups := make(map[string]bool)
+	for i, w := range cfg.Server.Warmups {
+		if w.Name == "" {
+			return fmt.Errorf("server.warmups[%d].name is required", i)
+		}
+		if warmups[w.Name] {
+			return fmt.Errorf("server.warmups: duplicate name %q", w.Name)
+		}
+		warmups[w.Name] = true
+		if w.Schedule == "" || len(w.Indices) == 0 {
+			return fmt.Errorf("server.warmups[%s]: schedule and indices are required", w.Name)
+		}
+	}
+
+	if cfg.DualWrite.Enabled && len(cfg.DualWrite.Indices) == 0 {
+		return fmt.Errorf("dual_write.indices must list the indices to mirror when dual_write.enabled is set")
+	}
+	for _, pattern := range cfg.DualWrite.Indices {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("dual_write.indices: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.DualWrite.DropPolicy != "newest" && cfg.DualWrite.DropPolicy != "oldest" {
+		return fmt.Errorf("dual_write.drop_policy must be \"newest\" or \"oldest\", got %q", cfg.DualWrite.DropPolicy)
+	}
+	if cfg.DualWrite.MaxRetries < 0 {
+		return fmt.Errorf("dual_write.max_retries must not be negative")
+	}
+	switch cfg.Export.Compression {
+	case "snappy", "gzip", "zstd", "none":
+	default:
+		return fmt.Errorf("export.compression must be \"snappy\", \"gzip\", \"zstd\" or \"none\", got %q", cfg.Export.Compression)
+	}
+	if cfg.Export.Endpoint != "" {
+		if u, err := url.Parse(cfg.Export.Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("export.endpoint %q must be an http(s) URL", cfg.Export.Endpoint)
+		}
+	}
+
+	if cfg.LateWrites.Enabled && len(cfg.LateWrites.Indices) == 0 {
+		return fmt.Errorf("late_writes.indices must list the indices to route when late_writes.enabled is set")
+	}
+	for _, pattern := range cfg.LateWrites.Indices {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("late_writes.indices: invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	if cfg.Retention.Enforce.Enabled && cfg.Retention.ColdDays <= 0 && len(cfg.Retention.IndexColdDays) == 0 {
+		return fmt.Errorf("retention.enforce.enabled requires retention.cold_days or retention.index_cold_days")
+	}
+	if cfg.Retention.TTL.Enabled && !slices.ContainsFunc(cfg.Migration.Rules, func(r MigrationRule) bool { return r.Action == "delete" }) {
+		return fmt.Errorf("retention.ttl.enabled requires a migration.rules entry with action \"delete\"")
+	}
+
+	if cfg.Migration.ScrollKeepAlive < time.Second {
+		return fmt.Errorf("migration.scroll_keep_alive (%s) must be at least 1s", cfg.Migration.ScrollKeepAlive)
+	}
+	if cfg.Migration.HealthGate.Enabled && cfg.Migration.HealthGate.MaxPause >= cfg.Migration.ScrollKeepAlive {
+		return fmt.Errorf("migration.health_gate.max_pause (%s) must be less than migration.scroll_keep_alive (%s)", cfg.Migration.HealthGate.MaxPause, cfg.Migration.ScrollKeepAlive)
+	}
+
+	switch cfg.Migration.HealthGate.MaxStatus {
+	case "green", "yellow":
+	default:
+		return fmt.Errorf("migration.health_gate.max_status must be \"green\" or \"yellow\", got %q", cfg.Migration.HealthGate.MaxStatus)
+	}
+
+	if cfg.Migration.Snapshot.Enabled {
+		if cfg.Migration.Snapshot.Repository == "" {
+			return fmt.Errorf("migration.snapshot.repository is required when migration.snapshot.enabled is true")
+		}
+		switch cfg.Migration.Snapshot.Mode {
+		case "restore", "searchable":
+		default:
+			return fmt.Errorf("migration.snapshot.mode must be \"restore\" or \"searchable\", got %q", cfg.Migration.Snapshot.Mode)
+		}
+	}
+
+	if cfg.Chaos.Enabled {
+		for _, leg := range []struct {
+			key string
+			fc  FaultConfig
+		}{{"chaos.hot", cfg.Chaos.Hot}, {"chaos.cold", cfg.Chaos.Cold}} {
+			for _, rate := range []float64{leg.fc.LatencyRate, leg.fc.ErrorRate, leg.fc.DropRate} {
+				if rate < 0 || rate > 1 {
+					return fmt.Errorf("%s: rates must be between 0 and 1, got %g", leg.key, rate)
+				}
+			}
+			if leg.fc.Latency < 0 {
+				return fmt.Errorf("%s.latency must be positive", leg.key)
+			}
+			if leg.fc.ErrorStatus < 500 || leg.fc.ErrorStatus > 599 {
+				return fmt.Errorf("%s.error_status must be a 5xx status, got %d", leg.key, leg.fc.ErrorStatus)
+			}
+		}
+	}
+	for _, price := range []struct {
+		key   string
+		value float64
+	}{{"cost.hot_gb_month", cfg.Cost.HotGBMonth}, {"cost.cold_gb_month", cfg.Cost.ColdGBMonth}, {"cost.egress_gb", cfg.Cost.EgressGB}} {
+		if price.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %g", price.key, price.value)
+		}
+	}
+	for name, price := range cfg.Cost.TierGBMonth {
+		if !slices.ContainsFunc(cfg.Tiers, func(t TierConfig) bool { return t.Name == name }) {
+			return fmt.Errorf("cost.tier_gb_month: %q is not a tiers entry", name)
+		}
+		if price < 0 {
+			return fmt.Errorf("cost.tier_gb_month.%s must not be negative, got %g", name, price)
+		}
+	}
+	if cfg.Dashboard.Refresh < time.Second {
+		return fmt.Errorf("dashboard.refresh (%s) must be at least 1s", cfg.Dashboard.Refresh)
+	}
+
+	if b := cfg.Migration.StateBackup; b.Repository != "" && b.Dir != "" {
+		return fmt.Errorf("migration.state_backup.repository and migration.state_backup.dir are mutually exclusive")
+	} else if b.Enabled && b.Repository == "" && b.Dir == "" {
+		return fmt.Errorf("migration.state_backup.enabled requires migration.state_backup.repository or migration.state_backup.dir")
+	}
+
+	if cfg.Migration.TempDir != "" {
+		if err := os.MkdirAll(cfg.Migration.TempDir, 0755); err != nil {
+			return fmt.Errorf("migration.temp_dir %q: %w", cfg.Migration.TempDir, err)
+		}
+	}
+
+	for pattern, o := range cfg.Migration.IndexOverrides {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("migration.index_overrides: invalid pattern %q: %w", pattern, err)
+		}
+		if o.TempDir != "" {
+			if err := os.MkdirAll(o.TempDir, 0755); err != nil {
+				return fmt.Errorf("migration.index_overrides[%q].temp_dir %q: %w", pattern, o.TempDir, err)
+			}
+		}
+		if err := validateQuickwitIndexSettings(fmt.Sprintf("migration.index_overrides[%q].quickwit", pattern), o.Quickwit); err != nil {
+			return err
+		}
+	}
+
+	for i, r := range cfg.Migration.Rules {
+		key := fmt.Sprintf("migration.rules[%d]", i)
+		if len(r.Indices) == 0 {
+			return fmt.Errorf("%s.indices must list at least one pattern", key)
+		}
+		for _, pattern := range r.Indices {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s.indices: invalid pattern %q: %w", key, pattern, err)
+			}
+		}
+		switch r.Action {
+		case "", "migrate":
+			if r.DeleteAfterDays != 0 {
+				return fmt.Errorf("%s.delete_after_days requires action \"delete\"", key)
+			}
+		case "delete":
+			if r.DeleteAfterDays <= 0 {
+				return fmt.Errorf("%s.delete_after_days must be positive for action \"delete\"", key)
+			}
+			if r.MigrateAfterDays != 0 || r.DeleteAfterMigration != nil || r.Filter != nil || r.TargetIndex != "" ||
+				len(r.Transforms.DropFields) > 0 || len(r.Transforms.RenameFields) > 0 {
+				return fmt.Errorf("%s: action \"delete\" migrates nothing; remove its migration settings", key)
+			}
+		default:
+			return fmt.Errorf("%s.action must be \"migrate\" or \"delete\", got %q", key, r.Action)
+		}
+		if r.MigrateAfterDays < 0 || r.MigrateAfterDays >= cfg.Retention.Days {
+			return fmt.Errorf("%s.migrate_after_days (%d) must be between 0 and retention.days (%d)", key, r.MigrateAfterDays, cfg.Retention.Days)
+		}
+		if len(r.Filter) == 0 && r.Filter != nil {
+			return fmt.Errorf("%s.filter must not be empty", key)
+		}
+		for from, to := range r.Transforms.RenameFields {
+			if from == "" || to == "" {
+				return fmt.Errorf("%s.transforms.rename_fields: field names must not be empty", key)
+			}
+		}
+	}
+	rollups := make(map[string]bool)
+	for i, r := range cfg.Migration.Rollups {
+		if r.Name == "" {
+			return fmt.Errorf("migration.rollups[%d].name is required", i)
+		}
+		if rollups[r.Name] {
+			return fmt.Errorf("migration.rollups: duplicate name %q", r.Name)
+		}
+		rollups[r.Name] = true
+		key := fmt.Sprintf("migration.rollups[%s]", r.Name)
+		if len(r.Indices) == 0 {
+			return fmt.Errorf("%s.indices must list at least one pattern", key)
+		}
+		for _, pattern := range r.Indices {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s.indices: invalid pattern %q: %w", key, pattern, err)
+			}
+		}
+		// Summaries are only written for whole buckets, and the documents of
+		// the bucket a window ends in must still be in OpenSearch when the
+		// next window is summarized; delete_after_migration keeps the last
+		// hour of each window.
+		if r.Interval < time.Second || r.Interval > time.Hour || time.Hour%r.Interval != 0 || r.Interval%time.Second != 0 {
+			return fmt.Errorf("%s.interval must be whole seconds dividing an hour, got %s", key, r.Interval)
+		}
+		if slices.Contains(r.GroupBy, "") || slices.Contains(r.Metrics, "") {
+			return fmt.Errorf("%s: group_by and metrics must not contain empty field names", key)
+		}
+	}
+
+	if err := validateNotificationEvents("notifications.slack.events", cfg.Notifications.Slack.Events); err != nil {
+		return err
+	}
+	if email := cfg.Notifications.Email; email.SMTPHost != "" {
+		if email.From == "" || len(email.To) == 0 {
+			return fmt.Errorf("notifications.email.from and notifications.email.to are required when notifications.email.smtp_host is set")
+		}
+		if err := validateNotificationEvents("notifications.email.events", email.Events); err != nil {
+			return err
+		}
+	}
+
+	if err := validateVault(cfg); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateVault(cfg *Config) error {
+	v := cfg.Vault
+	if v.Address == "" {
+		return nil
+	}
+	if _, err := url.Parse(v.Address); err != nil {
+		return fmt.Errorf("invalid vault.address: %w", err)
+	}
+	switch v.AuthMethod {
+	case "token":
+	case "kubernetes":
+		if v.Role == "" {
+			return fmt.Errorf("vault.role is required for the kubernetes auth method")
+		}
+	case "approle":
+		if v.RoleID == "" || v.SecretID == "" {
+			return fmt.Errorf("vault.role_id and vault.secret_id are required for the approle auth method")
+		}
+	default:
+		return fmt.Errorf("vault.auth_method must be \"token\", \"kubernetes\" or \"approle\", got %q", v.AuthMethod)
+	}
+	if v.OpenSearchPath == "" && v.QuickwitPath == "" {
+		return fmt.Errorf("vault.opensearch_path or vault.quickwit_path is required when vault.address is set")
+	}
+	if v.OpenSearchPath != "" && cfg.OpenSearch.SigV4.Enabled {
+		return fmt.Errorf("vault.opensearch_path and opensearch.sigv4.enabled are mutually exclusive")
+	}
+	return validateClientCert("vault", v.TLSConfig)
+}
+
+// validateColdQuota checks that the limits are not negative and that every
+// override matches someone.
+func validateColdQuota(q ColdQuotaConfig) error {
+	if q.Queries < 0 || q.Windows < 0 || q.Hits < 0 {
+		return fmt.Errorf("server.cold_quota: queries, windows and hits must not be negative")
+	}
+	if q.FlushInterval < 0 || q.CacheTTL < 0 {
+		return fmt.Errorf("server.cold_quota: flush_interval and cache_ttl must be positive")
+	}
+	for i, o := range q.Overrides {
+		if len(o.Users) == 0 && len(o.Roles) == 0 {
+			return fmt.Errorf("server.cold_quota.overrides[%d]: users or roles is required", i)
+		}
+		if o.Queries < 0 || o.Windows < 0 || o.Hits < 0 {
+			return fmt.Errorf("server.cold_quota.overrides[%d]: queries, windows and hits must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateTenancy checks that every tenant has a valid prefix that no other
+// tenant's prefix starts with, so each index name belongs to one tenant.
+func validateTenancy(tn TenancyConfig) error {
+	if len(tn.Tenants) == 0 {
+		return fmt.Errorf("server.tenancy.tenants must not be empty")
+	}
+	if tn.CacheTTL < 0 {
+		return fmt.Errorf("server.tenancy.cache_ttl must be positive")
+	}
+	names := make(map[string]bool)
+	for i, t := range tn.Tenants {
+		key := fmt.Sprintf("server.tenancy.tenants[%d]", i)
+		if t.Name == "" {
+			return fmt.Errorf("%s.name is required", key)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("%s: duplicate tenant %q", key, t.Name)
+		}
+		names[t.Name] = true
+		if t.Prefix == "" || t.Prefix != strings.ToLower(t.Prefix) || strings.ContainsAny(t.Prefix[:1], "-_+.") || strings.ContainsAny(t.Prefix, `*?,/\:"<>| #`) {
+			return fmt.Errorf("%s.prefix %q is not a valid OpenSearch index name prefix", key, t.Prefix)
+		}
+		if len(t.Users) == 0 && len(t.Roles) == 0 {
+			return fmt.Errorf("%s: users or roles are required", key)
+		}
+		for _, other := range tn.Tenants[:i] {
+			if strings.HasPrefix(t.Prefix, other.Prefix) || strings.HasPrefix(other.Prefix, t.Prefix) {
+				return fmt.Errorf("%s.prefix %q overlaps the prefix %q of tenant %q", key, t.Prefix, other.Prefix, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateQuickwit checks the connection and mode settings of a Quickwit
+// cluster configured under key.
+func validateQuickwit(key string, qc QuickwitConfig) error {
+	if qc.URL == "" {
+		return fmt.Errorf("%s.url is required", key)
+	}
+	if _, err := url.Parse(qc.URL); err != nil {
+		return fmt.Errorf("invalid %s.url: %w", key, err)
+	}
+	if err := validateClientCert(key, qc.TLSConfig); err != nil {
+		return err
+	}
+	if err := validateTransport(key+".transport", qc.Transport); err != nil {
+		return err
+	}
+	if err := validateRetry(key+".retry", qc.Retry); err != nil {
+		return err
+	}
+	if err := validateStaticHeaders(key+".headers", qc.Headers); err != nil {
+		return err
+	}
+	switch qc.IngestAPI {
+	case "v1", "v2", "auto":
+	default:
+		return fmt.Errorf("%s.ingest_api must be \"v1\", \"v2\" or \"auto\", got %q", key, qc.IngestAPI)
+	}
+	switch qc.IngestCommit {
+	case "auto", "wait_for", "force":
+	default:
+		return fmt.Errorf("%s.ingest_commit must be \"auto\", \"wait_for\" or \"force\", got %q", key, qc.IngestCommit)
+	}
+	switch qc.SearchAPI {
+	case "passthrough", "native", "elastic", "auto":
+	default:
+		return fmt.Errorf("%s.search_api must be \"passthrough\", \"native\", \"elastic\" or \"auto\", got %q", key, qc.SearchAPI)
+	}
+	if err := validateQuickwitIndexSettings(key+".index_settings", qc.IndexSettings); err != nil {
+		return err
+	}
+	if qc.Auth.BearerToken != "" && qc.Username != "" {
+		return fmt.Errorf("%s.auth.bearer_token and %s.username are mutually exclusive", key, key)
+	}
+	if err := validateStaticHeaders(key+".auth.headers", qc.Auth.Headers); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateOpenSearch(key string, oc OpenSearchConfig) error {
+	if oc.URL == "" {
+		return fmt.Errorf("%s.url is required", key)
+	}
+	if _, err := url.Parse(oc.URL); err != nil {
+		return fmt.Errorf("invalid %s.url: %w", key, err)
+	}
+	if oc.SigV4.Enabled && oc.Username != "" {
+		return fmt.Errorf("%s.sigv4.enabled and %s.username are mutually exclusive", key, key)
+	}
+	if err := validateClientCert(key, oc.TLSConfig); err != nil {
+		return err
+	}
+	if err := validateTransport(key+".transport", oc.Transport); err != nil {
+		return err
+	}
+	if err := validateRetry(key+".retry", oc.Retry); err != nil {
+		return err
+	}
+	return validateStaticHeaders(key+".headers", oc.Headers)
+}
+
+func validateClientCert(key string, tc TLSConfig) error {
+	if (tc.ClientCert == "") != (tc.ClientKey == "") {
+		return fmt.Errorf("%s.client_cert and %s.client_key must be set together", key, key)
+	}
+	return nil
+}
+
+func validateTransport(key string, tc TransportConfig) error {
+	if tc.MaxIdleConns < 0 || tc.MaxIdleConnsPerHost < 0 || tc.MaxConnsPerHost < 0 {
+		return fmt.Errorf("%s: connection limits must not be negative", key)
+	}
+	if tc.IdleConnTimeout < 0 || tc.DialTimeout < 0 || tc.TLSHandshakeTimeout < 0 {
+		return fmt.Errorf("%s: timeouts must not be negative", key)
+	}
+	return nil
+}
+
+// validateStaticHeaders rejects credentials and headers managed by the
+// HTTP client among configured static headers.
+func validateStaticHeaders(key string, headers map[string]string) error {
+	for name := range headers {
+		switch strings.ToLower(name) {
+		case "authorization":
+			return fmt.Errorf("%s must not set Authorization; use the backend's credential settings", key)
+		case "host", "content-length", "user-agent":
+			return fmt.Errorf("%s must not set %s", key, name)
+		}
+	}
+	return nil
+}
+
+func validateRetry(key string, rc RetryConfig) error {
+	if rc.MaxAttempts < 1 {
+		return fmt.Errorf("%s.max_attempts must be at least 1, got %d", key, rc.MaxAttempts)
+	}
+	if rc.InitialBackoff > rc.MaxBackoff {
+		return fmt.Errorf("%s.initial_backoff (%s) must not exceed max_backoff (%s)", key, rc.InitialBackoff, rc.MaxBackoff)
+	}
+	return nil
+}
+
+func validateQuickwitIndexSettings(key string, s QuickwitIndexSettings) error {
+	if s.CommitTimeoutSecs < 0 || s.SplitNumDocsTarget < 0 {
+		return fmt.Errorf("%s: commit_timeout_secs and split_num_docs_target must not be negative", key)
+	}
+	mp := s.MergePolicy
+	switch mp.Type {
+	case "", "stable_log", "limit_merge":
+	case "no_merge":
+		if mp.MergeFactor != 0 || mp.MaxMergeFactor != 0 || mp.MaturationPeriod != "" {
+			return fmt.Errorf("%s.merge_policy: no_merge takes no other settings", key)
+		}
+	default:
+		return fmt.Errorf("%s.merge_policy.type must be \"stable_log\", \"limit_merge\" or \"no_merge\", got %q", key, mp.Type)
+	}
+	if mp.Type == "" && (mp.MergeFactor != 0 || mp.MaxMergeFactor != 0 || mp.MaturationPeriod != "") {
+		return fmt.Errorf("%s.merge_policy.type is required when other merge policy settings are set", key)
+	}
+	if mp.MergeFactor < 0 || mp.MaxMergeFactor < 0 {
+		return fmt.Errorf("%s.merge_policy: merge_factor and max_merge_factor must not be negative", key)
+	}
+	if mp.MergeFactor > 0 && mp.MaxMergeFactor > 0 && mp.MaxMergeFactor < mp.MergeFactor {
+		return fmt.Errorf("%s.merge_policy: max_merge_factor (%d) must be >= merge_factor (%d)", key, mp.MaxMergeFactor, mp.MergeFactor)
+	}
+	return nil
+}
+
+func validateNotificationEvents(key string, events []string) error {
+	for _, ev := range events {
+		if !slices.Contains(NotificationEvents, ev) {
+			return fmt.Errorf("%s: unknown event %q (valid: %v)", key, ev, NotificationEvents)
+		}
+	}
+	return nil
+}
+
+// isVersionNumber reports whether v is a major.minor.patch version of
+// decimal numbers.
+func isVersionNumber(v string) bool {
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
 }
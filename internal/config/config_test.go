@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_ValidConfig(t *testing.T) {
@@ -94,6 +98,56 @@ quickwit:
 	if cfg.Logging.Level != "info" {
 		t.Errorf("default Logging.Level = %q", cfg.Logging.Level)
 	}
+	if f := cfg.Server.ColdFanout; f.MaxConcurrency != 8 || f.MaxGlobal != 64 {
+		t.Errorf("default Server.ColdFanout = %+v, want 8 and 64", f)
+	}
+}
+
+func TestLoad_HealthGate(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  health_gate:
+    enabled: true
+    max_heap_percent: 85
+    interval: "15s"
+`
+	path := writeTempFile(t, content)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	hg := cfg.Migration.HealthGate
+	if !hg.Enabled || hg.MaxHeapPercent != 85 {
+		t.Errorf("HealthGate = %+v", hg)
+	}
+	if hg.Interval != 15*time.Second {
+		t.Errorf("HealthGate.Interval = %v, want 15s", hg.Interval)
+	}
+	if hg.MaxStatus != "yellow" || hg.MaxPause != 5*time.Minute {
+		t.Errorf("HealthGate defaults = %+v", hg)
+	}
+}
+
+func TestLoad_HealthGate_InvalidMaxStatus(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  health_gate:
+    max_status: "red"
+`
+	path := writeTempFile(t, content)
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for max_status=red")
+	}
 }
 
 func TestLoad_MissingOpenSearchURL(t *testing.T) {
@@ -165,6 +219,33 @@ func TestTimestampFieldForIndex(t *testing.T) {
 	}
 }
 
+func TestTimestampFieldForIndex_Glob(t *testing.T) {
+	cfg := &Config{
+		Retention: RetentionConfig{
+			TimestampField: "@timestamp",
+			IndexFields: map[string]string{
+				"logs-*":       "event_time",
+				"logs-special": "created_at",
+			},
+		},
+	}
+
+	tests := []struct {
+		index string
+		want  string
+	}{
+		{"logs-special", "created_at"},    // exact match
+		{"logs-2025.06.01", "event_time"}, // glob match
+		{"logs-*", "event_time"},          // pattern requested through the proxy
+		{"metrics-2025.06.01", "@timestamp"},
+	}
+	for _, tt := range tests {
+		if got := cfg.TimestampFieldForIndex(tt.index); got != tt.want {
+			t.Errorf("TimestampFieldForIndex(%s) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
 func TestColdDaysForIndex(t *testing.T) {
 	cfg := &Config{
 		Retention: RetentionConfig{
@@ -241,12 +322,2190 @@ retention:
 	}
 }
 
-func writeTempFile(t *testing.T, content string) string {
-	t.Helper()
-	dir := t.TempDir()
-	path := filepath.Join(dir, "config.yaml")
-	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		t.Fatal(err)
+func TestLoad_IndexDays(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+  index_days:
+    debug-*: 7
+    audit-*: 90
+migration:
+  migrate_after_days: 25
+`
+	if _, err := Load(writeTempFile(t, base)); err == nil {
+		t.Fatal("expected an error for debug-* leaving OpenSearch before it is migrated")
+	}
+
+	cfg, err := Load(writeTempFile(t, base+`  rules:
+    - indices: ["debug-*"]
+      migrate_after_days: 5
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for index, want := range map[string]int{"debug-2026.01.01": 7, "audit-2026.01.01": 90, "logs-2026.01.01": 30} {
+		if got := cfg.HotDaysForIndex(index); got != want {
+			t.Errorf("HotDaysForIndex(%s) = %d, want %d", index, got, want)
+		}
+	}
+}
+
+func TestLoad_Timezone(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Location() != time.UTC || cfg.RoutingLocation() != nil {
+		t.Errorf("unset timezone: location %v, routing location %v; want UTC and none", cfg.Location(), cfg.RoutingLocation())
+	}
+
+	cfg, err = Load(writeTempFile(t, base+`retention:
+  timezone: "Asia/Shanghai"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// 2026-03-10 20:00 UTC is already 2026-03-11 in Shanghai.
+	now := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	if got, want := cfg.DaysAgo(now, 7), time.Date(2026, 3, 3, 16, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("DaysAgo(7) = %v, want %v", got, want)
+	}
+
+	if _, err := Load(writeTempFile(t, base+`retention:
+  timezone: "Mars/Olympus"
+`)); err == nil || !strings.Contains(err.Error(), "retention.timezone") {
+		t.Fatalf("Load() error = %v, want an invalid retention.timezone", err)
+	}
+}
+
+func TestLoad_RetentionEnforce(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  enforce:
+    enabled: true
+`
+	if _, err := Load(writeTempFile(t, base)); err == nil {
+		t.Fatal("expected error when enforcement has no cold retention to enforce")
+	}
+
+	cfg, err := Load(writeTempFile(t, base+"  cold_days: 365\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Retention.Enforce.Enabled || cfg.Retention.Enforce.Schedule != "30 3 * * *" {
+		t.Errorf("enforce = %+v, want enabled with default schedule", cfg.Retention.Enforce)
+	}
+}
+
+func TestLoad_Reconcile(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  reconcile:
+    enabled: true
+    days: 2w
+notifications:
+  slack:
+    webhook_url: "https://hooks.slack.com/services/x"
+    events: ["reconcile_mismatch"]
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if r := cfg.Migration.Reconcile; !r.Enabled || r.Schedule != "0 5 * * *" || r.Days != 14 {
+		t.Errorf("reconcile = %+v, want enabled for 14 days on the default schedule", r)
+	}
+}
+
+func TestLoad_StateBackup(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  state_backup:
+`
+	cfg, err := Load(writeTempFile(t, base+"    enabled: true\n    repository: backups\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if b := cfg.Migration.StateBackup; b.Schedule != "15 4 * * *" || b.Keep != 7 {
+		t.Errorf("state_backup = %+v, want the default schedule and keep", b)
+	}
+
+	for name, extra := range map[string]string{
+		"no location":   "    enabled: true\n",
+		"two locations": "    repository: backups\n    dir: /var/backups\n",
+	} {
+		if _, err := Load(writeTempFile(t, base+extra)); err == nil {
+			t.Errorf("%s: Load() succeeded", name)
+		}
+	}
+}
+
+func TestLoad_Dashboard(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+dashboard:
+  enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if d := cfg.Dashboard; d.Refresh != 10*time.Second || d.History != 50 {
+		t.Errorf("dashboard = %+v, want the default refresh and history", d)
+	}
+	if _, err := Load(writeTempFile(t, base+"  refresh: 500ms\n")); err == nil {
+		t.Error("Load() accepted a refresh below 1s")
+	}
+}
+
+func TestLoad_Export(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+export:
+  bucket: "archive"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Export.Compression != "snappy" || cfg.Export.MaxFileDocs != 100000 {
+		t.Errorf("export = %+v, want snappy with 100000 documents per file", cfg.Export)
+	}
+	for _, extra := range []string{"  compression: lzo\n", "  endpoint: storage.googleapis.com\n"} {
+		if _, err := Load(writeTempFile(t, base+extra)); err == nil || !strings.Contains(err.Error(), "export.") {
+			t.Errorf("Load() with %q error = %v", extra, err)
+		}
+	}
+}
+
+func TestLoad_SnapshotSource(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  snapshot:
+    enabled: true
+    repository: "s3-archive"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	snap := cfg.Migration.Snapshot
+	if snap.Mode != "restore" || snap.IndexPrefix != "oqbridge-restore-" || snap.RestoreTimeout != 30*time.Minute {
+		t.Errorf("snapshot defaults = %+v", snap)
+	}
+}
+
+func TestLoad_SnapshotSource_RequiresRepository(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  snapshot:
+    enabled: true
+    mode: "searchable"
+`
+	if _, err := Load(writeTempFile(t, content)); err == nil {
+		t.Fatal("expected error for missing snapshot repository")
+	}
+}
+
+func TestLoad_IndexOverrides(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  workers: 4
+  batch_size: 5000
+  compress: true
+  index_overrides:
+    "firewall-*":
+      workers: 16
+      batch_size: 10000
+    "firewall-audit-*":
+      workers: 2
+      compress: false
+    "audit-2026":
+      batch_size: 500
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		index string
+		want  IndexMigrationSettings
+	}{
+		{"firewall-2026.01.01", IndexMigrationSettings{Workers: 16, BatchSize: 10000, Compress: true}},
+		{"firewall-audit-2026.01.01", IndexMigrationSettings{Workers: 2, BatchSize: 5000, Compress: false}},
+		{"audit-2026", IndexMigrationSettings{Workers: 4, BatchSize: 500, Compress: true}},
+		{"logs-2026", IndexMigrationSettings{Workers: 4, BatchSize: 5000, Compress: true}},
+	}
+	for _, tt := range tests {
+		if got := cfg.MigrationSettingsForIndex(tt.index); got != tt.want {
+			t.Errorf("MigrationSettingsForIndex(%s) = %+v, want %+v", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_QuickwitIndexSettings(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+  index_settings:
+    split_num_docs_target: 10000000
+    default_search_fields: ["message"]
+migration:
+  index_overrides:
+    "firewall-*":
+      workers: 16
+      quickwit:
+        commit_timeout_secs: 120
+        split_num_docs_target: 50000000
+        merge_policy:
+          type: "stable_log"
+          merge_factor: 10
+          max_merge_factor: 12
+          maturation_period: "48h"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	logs := cfg.QuickwitIndexSettingsForIndex("logs-2026.01.01")
+	if logs.CommitTimeoutSecs != 60 || logs.SplitNumDocsTarget != 10000000 || logs.MergePolicy.Type != "" {
+		t.Errorf("logs settings = %+v, want global defaults", logs)
+	}
+
+	fw := cfg.QuickwitIndexSettingsForIndex("firewall-2026.01.01")
+	if fw.CommitTimeoutSecs != 120 || fw.SplitNumDocsTarget != 50000000 {
+		t.Errorf("firewall settings = %+v, want overridden commit timeout and split target", fw)
+	}
+	if fw.MergePolicy != (QuickwitMergePolicy{Type: "stable_log", MergeFactor: 10, MaxMergeFactor: 12, MaturationPeriod: "48h"}) {
+		t.Errorf("firewall merge_policy = %+v", fw.MergePolicy)
+	}
+	if len(fw.DefaultSearchFields) != 1 || fw.DefaultSearchFields[0] != "message" {
+		t.Errorf("firewall default_search_fields = %v, want inherited [message]", fw.DefaultSearchFields)
+	}
+}
+
+func TestLoad_InvalidQuickwitIndexSettings(t *testing.T) {
+	for name, settings := range map[string]string{
+		"unknown merge policy": `
+  index_settings:
+    merge_policy:
+      type: "tiered"`,
+		"merge factor without type": `
+  index_settings:
+    merge_policy:
+      merge_factor: 10`,
+		"max below merge factor": `
+  index_settings:
+    merge_policy:
+      type: "limit_merge"
+      merge_factor: 10
+      max_merge_factor: 4`,
+	} {
+		content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"` + settings + "\n"
+		if _, err := Load(writeTempFile(t, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_QuickwitAuth(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base+`  auth:
+    bearer_token: "secret"
+    headers:
+      X-Tenant-ID: "acme"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.Auth.BearerToken != "secret" || cfg.Quickwit.Auth.Headers["X-Tenant-ID"] != "acme" {
+		t.Errorf("auth = %+v, want bearer token and X-Tenant-ID header", cfg.Quickwit.Auth)
+	}
+
+	if _, err := Load(writeTempFile(t, base+`  username: "svc"
+  auth:
+    bearer_token: "secret"
+`)); err == nil {
+		t.Error("expected error for bearer_token together with username")
+	}
+	if _, err := Load(writeTempFile(t, base+`  auth:
+    headers:
+      authorization: "Bearer x"
+`)); err == nil {
+		t.Error("expected error for Authorization in auth.headers")
+	}
+}
+
+func TestLoad_OpenSearchSigV4(t *testing.T) {
+	base := `
+quickwit:
+  url: "http://qw:7280"
+opensearch:
+  url: "https://search-logs.eu-west-1.es.amazonaws.com"
+  sigv4:
+    enabled: true
+    region: "eu-west-1"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenSearch.SigV4.Service != "es" {
+		t.Errorf("sigv4.service default = %q, want es", cfg.OpenSearch.SigV4.Service)
+	}
+	if _, err := Load(writeTempFile(t, base+"  username: \"admin\"\n")); err == nil {
+		t.Error("expected error for sigv4 together with username")
+	}
+}
+
+func TestLoad_RetryDefaults(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base+`  retry:
+    max_attempts: 5
+    methods: ["GET", "POST"]
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if r := cfg.OpenSearch.Retry; r.MaxAttempts != 3 || r.InitialBackoff != 200*time.Millisecond || r.MaxBackoff != 5*time.Second || len(r.Methods) != 5 {
+		t.Errorf("opensearch.retry = %+v, want defaults", r)
+	}
+	if r := cfg.Quickwit.Retry; r.MaxAttempts != 5 || len(r.Methods) != 2 {
+		t.Errorf("quickwit.retry = %+v, want 5 attempts for GET and POST", r)
+	}
+
+	if _, err := Load(writeTempFile(t, base+`  retry:
+    initial_backoff: 10s
+    max_backoff: 1s
+`)); err == nil {
+		t.Error("expected error for initial_backoff above max_backoff")
+	}
+}
+
+func TestLoad_UserAgentAndHeaders(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base+`  user_agent: "custom/1"
+  headers:
+    X-Gateway-Route: "quickwit"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cfg.SetDefaultUserAgent("oqbridge/dev")
+	if cfg.OpenSearch.UserAgent != "oqbridge/dev" || cfg.Quickwit.UserAgent != "custom/1" {
+		t.Errorf("user agents = %q / %q, want default for opensearch only", cfg.OpenSearch.UserAgent, cfg.Quickwit.UserAgent)
+	}
+	if cfg.Quickwit.Headers["X-Gateway-Route"] != "quickwit" {
+		t.Errorf("quickwit.headers = %v", cfg.Quickwit.Headers)
+	}
+
+	if _, err := Load(writeTempFile(t, base+`  headers:
+    Authorization: "Basic abc"
+`)); err == nil {
+		t.Error("expected error for Authorization in quickwit.headers")
+	}
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Notifications(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+notifications:
+  slack:
+    webhook_url: "https://hooks.slack.com/services/T/B/X"
+    events: ["run_failed"]
+  email:
+    smtp_host: "smtp.example.com"
+    from: "oqbridge@example.com"
+    to: ["oncall@example.com"]
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Notifications.Email.SMTPPort != 587 {
+		t.Errorf("smtp_port = %d, want 587", cfg.Notifications.Email.SMTPPort)
+	}
+	if len(cfg.Notifications.Slack.Events) != 1 || cfg.Notifications.Slack.Events[0] != "run_failed" {
+		t.Errorf("slack events = %v, want [run_failed]", cfg.Notifications.Slack.Events)
+	}
+}
+
+func TestLoad_Notifications_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown event": `
+notifications:
+  slack:
+    webhook_url: "https://hooks.slack.com/services/T/B/X"
+    events: ["run_exploded"]
+`,
+		"email without recipients": `
+notifications:
+  email:
+    smtp_host: "smtp.example.com"
+    from: "oqbridge@example.com"
+`,
+	}
+	for name, notifications := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+` + notifications
+			if _, err := Load(writeTempFile(t, content)); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
+
+func TestLoad_QuickwitIngestMode(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.IngestAPI != "v1" || cfg.Quickwit.IngestCommit != "auto" {
+		t.Errorf("ingest defaults = %q/%q, want v1/auto", cfg.Quickwit.IngestAPI, cfg.Quickwit.IngestCommit)
+	}
+
+	if _, err := Load(writeTempFile(t, base+"  ingest_api: \"v3\"\n")); err == nil {
+		t.Error("expected error for unknown ingest_api")
+	}
+	if _, err := Load(writeTempFile(t, base+"  ingest_commit: \"sometimes\"\n")); err == nil {
+		t.Error("expected error for unknown ingest_commit")
+	}
+}
+
+func TestLoad_QuickwitSearchAPI(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.SearchAPI != "passthrough" {
+		t.Errorf("search_api default = %q, want passthrough", cfg.Quickwit.SearchAPI)
+	}
+
+	cfg, err = Load(writeTempFile(t, base+"  search_api: \"native\"\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.SearchAPI != "native" {
+		t.Errorf("search_api = %q, want native", cfg.Quickwit.SearchAPI)
+	}
+	if _, err := Load(writeTempFile(t, base+"  search_api: \"elastic\"\n")); err != nil {
+		t.Errorf("Load() with search_api elastic error = %v", err)
+	}
+	if _, err := Load(writeTempFile(t, base+"  search_api: \"sql\"\n")); err == nil {
+		t.Error("expected error for unknown search_api")
+	}
+}
+
+func TestLoad_QuickwitFeatureDetection(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.DetectInterval != 5*time.Minute {
+		t.Errorf("detect_interval default = %v, want 5m", cfg.Quickwit.DetectInterval)
+	}
+
+	cfg, err = Load(writeTempFile(t, base+"  search_api: \"auto\"\n  ingest_api: \"auto\"\n  detect_interval: -1s\n"))
+	if err != nil {
+		t.Fatalf("Load() with auto modes error = %v", err)
+	}
+	if cfg.Quickwit.SearchAPI != "auto" || cfg.Quickwit.IngestAPI != "auto" || cfg.Quickwit.DetectInterval != -time.Second {
+		t.Errorf("quickwit = %q/%q/%v, want auto/auto/-1s", cfg.Quickwit.SearchAPI, cfg.Quickwit.IngestAPI, cfg.Quickwit.DetectInterval)
+	}
+}
+
+func TestLoad_QuickwitListCacheTTL(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.ListCacheTTL != 30*time.Second {
+		t.Errorf("list_cache_ttl default = %v, want 30s", cfg.Quickwit.ListCacheTTL)
+	}
+
+	cfg, err = Load(writeTempFile(t, base+"  list_cache_ttl: -1s\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Quickwit.ListCacheTTL >= 0 {
+		t.Errorf("list_cache_ttl = %v, want negative (disabled)", cfg.Quickwit.ListCacheTTL)
+	}
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	t.Setenv("OQBRIDGE_OPENSEARCH_PASSWORD", "from-env")
+	t.Setenv("OQBRIDGE_OPENSEARCH_TLS_SKIP_VERIFY", "true")
+	t.Setenv("OQBRIDGE_MIGRATION_BATCH_SIZE", "100")
+	t.Setenv("OQBRIDGE_MIGRATION_INDICES", "logs-*, metrics-*")
+	t.Setenv("OQBRIDGE_QUICKWIT_LIST_CACHE_TTL", "5s")
+	t.Setenv("OQBRIDGE_NOT_A_KEY", "ignored")
+
+	cfg, err := Load(writeTempFile(t, `
+opensearch:
+  url: "http://os:9200"
+  password: "from-file"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  batch_size: 5000
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenSearch.Password != "from-env" {
+		t.Errorf("opensearch.password = %q, want from-env", cfg.OpenSearch.Password)
+	}
+	if !cfg.OpenSearch.SkipVerify {
+		t.Error("opensearch.tls_skip_verify = false, want true")
+	}
+	if cfg.Migration.BatchSize != 100 {
+		t.Errorf("migration.batch_size = %d, want 100", cfg.Migration.BatchSize)
+	}
+	if want := []string{"logs-*", "metrics-*"}; !slices.Equal(cfg.Migration.Indices, want) {
+		t.Errorf("migration.indices = %v, want %v", cfg.Migration.Indices, want)
+	}
+	if cfg.Quickwit.ListCacheTTL != 5*time.Second {
+		t.Errorf("quickwit.list_cache_ttl = %v, want 5s", cfg.Quickwit.ListCacheTTL)
+	}
+	if cfg.Quickwit.URL != "http://qw:7280" {
+		t.Errorf("quickwit.url = %q, want value from file", cfg.Quickwit.URL)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	osPass := filepath.Join(dir, "os-password")
+	token := filepath.Join(dir, "qw-token")
+	os.WriteFile(osPass, []byte("s3cret\n"), 0o600)
+	os.WriteFile(token, []byte("tok"), 0o600)
+
+	base := `
+opensearch:
+  url: "http://os:9200"
+  password_file: "` + osPass + `"
+quickwit:
+  url: "http://qw:7280"
+  auth:
+    bearer_token_file: "` + token + `"
+grpc:
+  token_file: "` + token + `"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenSearch.Password != "s3cret" {
+		t.Errorf("opensearch.password = %q, want s3cret", cfg.OpenSearch.Password)
+	}
+	if cfg.Quickwit.Auth.BearerToken != "tok" {
+		t.Errorf("quickwit.auth.bearer_token = %q, want tok", cfg.Quickwit.Auth.BearerToken)
+	}
+	if cfg.GRPC.Token != "tok" {
+		t.Errorf("grpc.token = %q, want tok", cfg.GRPC.Token)
+	}
+
+	if _, err := Load(writeTempFile(t, strings.Replace(base, "password_file:", "password: \"inline\"\n  password_file:", 1))); err == nil {
+		t.Error("expected error when password and password_file are both set")
+	}
+	if _, err := Load(writeTempFile(t, strings.Replace(base, osPass, filepath.Join(dir, "missing"), 1))); err == nil {
+		t.Error("expected error for a missing password_file")
+	}
+}
+
+func TestLoad_EncryptedSecrets(t *testing.T) {
+	dir := t.TempDir()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(encoded+"\n"), 0o600)
+	key, err := ReadKeyFile(keyFile)
+	if err != nil {
+		t.Fatalf("ReadKeyFile() error = %v", err)
+	}
+	osPass, _ := EncryptSecret(key, "s3cret")
+	qwToken, _ := EncryptSecret(key, "tok")
+	tokenFile := filepath.Join(dir, "qw-token")
+	os.WriteFile(tokenFile, []byte(qwToken+"\n"), 0o600)
+
+	base := `
+opensearch:
+  url: "http://os:9200"
+  password: "` + osPass + `"
+quickwit:
+  url: "http://qw:7280"
+  auth:
+    bearer_token_file: "` + tokenFile + `"
+`
+	cfg, err := Load(writeTempFile(t, base+"encryption:\n  key_file: \""+keyFile+"\"\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenSearch.Password != "s3cret" || cfg.Quickwit.Auth.BearerToken != "tok" {
+		t.Errorf("opensearch.password = %q, quickwit.auth.bearer_token = %q", cfg.OpenSearch.Password, cfg.Quickwit.Auth.BearerToken)
+	}
+
+	if _, err := Load(writeTempFile(t, base)); err == nil || !strings.Contains(err.Error(), "encryption.key_file is not set") {
+		t.Errorf("Load() without key error = %v", err)
+	}
+	otherKey, _ := GenerateKey()
+	otherFile := filepath.Join(dir, "other")
+	os.WriteFile(otherFile, []byte(otherKey), 0o600)
+	if _, err := Load(writeTempFile(t, base+"encryption:\n  key_file: \""+otherFile+"\"\n")); err == nil || !strings.Contains(err.Error(), "decrypting opensearch.password") {
+		t.Errorf("Load() with the wrong key error = %v", err)
+	}
+}
+
+func TestLoad_Vault(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+vault:
+  address: "https://vault:8200"
+  token: "hvs.test"
+  opensearch_path: "secret/data/oqbridge/opensearch"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	v := cfg.Vault
+	if v.AuthMethod != "token" || v.AuthMount != "token" {
+		t.Errorf("auth_method/auth_mount = %q/%q, want token/token", v.AuthMethod, v.AuthMount)
+	}
+	if v.RefreshInterval != 5*time.Minute {
+		t.Errorf("refresh_interval = %v, want 5m", v.RefreshInterval)
+	}
+}
+
+func TestLoad_Vault_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown auth method": `
+vault:
+  address: "https://vault:8200"
+  auth_method: "ldap"
+  opensearch_path: "secret/data/os"
+`,
+		"kubernetes without role": `
+vault:
+  address: "https://vault:8200"
+  auth_method: "kubernetes"
+  opensearch_path: "secret/data/os"
+`,
+		"approle without secret_id": `
+vault:
+  address: "https://vault:8200"
+  auth_method: "approle"
+  role_id: "r"
+  opensearch_path: "secret/data/os"
+`,
+		"no secret path": `
+vault:
+  address: "https://vault:8200"
+`,
+	}
+	for name, vault := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+` + vault
+			if _, err := Load(writeTempFile(t, content)); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
+
+func writeConfigAs(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_FormatByExtension(t *testing.T) {
+	files := map[string]string{
+		"oqbridge.json": `{
+  "opensearch": {"url": "http://os:9200"},
+  "quickwit": {"url": "http://qw:7280"},
+  "retention": {"days": 14},
+  "server": {"reverse_proxy": {"flush_interval": "2s"}},
+  "migration": {"batch_size": 500}
+}`,
+		"oqbridge.toml": `
+[opensearch]
+url = "http://os:9200"
+
+[quickwit]
+url = "http://qw:7280"
+
+[retention]
+days = 14
+
+[server.reverse_proxy]
+flush_interval = "2s"
+
+[migration]
+batch_size = 500
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeConfigAs(t, name, content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.OpenSearch.URL != "http://os:9200" || cfg.Quickwit.URL != "http://qw:7280" {
+				t.Errorf("urls = %q, %q", cfg.OpenSearch.URL, cfg.Quickwit.URL)
+			}
+			if cfg.Retention.Days != 14 {
+				t.Errorf("retention.days = %d, want 14", cfg.Retention.Days)
+			}
+			if cfg.Server.ReverseProxy.FlushInterval != 2*time.Second || cfg.Migration.BatchSize != 500 {
+				t.Errorf("flush_interval = %v, batch_size = %d", cfg.Server.ReverseProxy.FlushInterval, cfg.Migration.BatchSize)
+			}
+		})
+	}
+}
+
+func TestLoad_UnsupportedFormat(t *testing.T) {
+	if _, err := Load(writeConfigAs(t, "oqbridge.ini", "[opensearch]\n")); err == nil {
+		t.Fatal("expected error for unsupported extension")
+	}
+}
+
+func TestLoad_Include(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "shared"), 0755)
+	os.WriteFile(filepath.Join(dir, "shared", "backends.yaml"), []byte(`
+opensearch:
+  url: "http://os:9200"
+  username: "svc"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "shared", "auth.json"), []byte(`{"opensearch": {"password": "pw"}}`), 0644)
+	path := filepath.Join(dir, "prod.yaml")
+	os.WriteFile(path, []byte(`
+include:
+  - shared
+retention:
+  days: 90
+migration:
+  migrate_after_days: 14
+`), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.OpenSearch.URL != "http://os:9200" || cfg.OpenSearch.Username != "svc" || cfg.OpenSearch.Password != "pw" {
+		t.Errorf("opensearch = %+v, want the included settings merged", cfg.OpenSearch)
+	}
+	if cfg.Retention.Days != 90 || cfg.Migration.MigrateAfterDays != 14 {
+		t.Errorf("retention.days = %d, migrate_after_days = %d; want the overlay values", cfg.Retention.Days, cfg.Migration.MigrateAfterDays)
+	}
+	want := []string{
+		filepath.Join(dir, "shared"),
+		filepath.Join(dir, "shared", "auth.json"),
+		filepath.Join(dir, "shared", "backends.yaml"),
+		path,
+	}
+	if !slices.Equal(cfg.Sources(), want) {
+		t.Errorf("Sources() = %v, want %v", cfg.Sources(), want)
+	}
+}
+
+func TestLoad_IncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	os.WriteFile(a, []byte("include: [b.yaml]\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("include: [a.yaml]\n"), 0644)
+	if _, err := Load(a); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("cycle: err = %v", err)
+	}
+
+	c := filepath.Join(dir, "c.yaml")
+	os.WriteFile(c, []byte("include: [missing.yaml]\n"), 0644)
+	if _, err := Load(c); err == nil {
+		t.Error("expected error for a missing include")
+	}
+
+	d := filepath.Join(dir, "d.yaml")
+	os.WriteFile(d, []byte("include: shared.yaml\n"), 0644)
+	if _, err := Load(d); err == nil {
+		t.Error("expected error for an include that is not a list")
+	}
+}
+
+func TestLoad_MigrationRules(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+migration:
+  migrate_after_days: 25
+  rules:
+    - indices: ["audit-*"]
+      migrate_after_days: 7
+      delete_after_migration: true
+      filter:
+        term:
+          event.kind: "audit"
+      transforms:
+        drop_fields: ["debug"]
+        rename_fields:
+          msg: "message"
+      target_index: "archive-{index}"
+    - indices: ["audit-*", "app-*"]
+      migrate_after_days: 14
+    - indices: ["debug-*"]
+      action: delete
+      delete_after_days: 3
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	audit := cfg.MigrationPolicyForIndex("audit-2026.01.01")
+	if audit.MigrateAfterDays != 7 || !audit.DeleteAfterMigration || audit.TargetIndex != "archive-audit-2026.01.01" {
+		t.Errorf("audit policy = %+v", audit)
+	}
+	if term, _ := audit.Filter["term"].(map[string]any); term["event.kind"] != "audit" {
+		t.Errorf("audit filter = %v, want the dotted field name kept", audit.Filter)
+	}
+	if !slices.Equal(audit.Transforms.DropFields, []string{"debug"}) || audit.Transforms.RenameFields["msg"] != "message" {
+		t.Errorf("audit transforms = %+v", audit.Transforms)
+	}
+
+	app := cfg.MigrationPolicyForIndex("app-2026.01.01")
+	if app.MigrateAfterDays != 14 || app.DeleteAfterMigration || app.Filter != nil || app.TargetIndex != "app-2026.01.01" {
+		t.Errorf("app policy = %+v", app)
+	}
+	if other := cfg.MigrationPolicyForIndex("logs-2026.01.01"); other.MigrateAfterDays != 25 || other.TargetIndex != "logs-2026.01.01" {
+		t.Errorf("policy without a rule = %+v, want the global settings", other)
+	}
+	if got := cfg.QuickwitIndexForIndex("audit-*"); got != "archive-audit-*" {
+		t.Errorf("QuickwitIndexForIndex(audit-*) = %q", got)
+	}
+	if debug := cfg.MigrationPolicyForIndex("debug-2026.01.01"); !debug.Delete || debug.DeleteAfterDays != 3 || app.Delete {
+		t.Errorf("debug policy = %+v", debug)
+	}
+	if ttl := cfg.Retention.TTL; ttl.Enabled || ttl.Schedule != "45 3 * * *" || ttl.MaxDropped != 10 {
+		t.Errorf("retention.ttl defaults = %+v", ttl)
+	}
+}
+
+func TestLoad_MigrationRules_Invalid(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+migration:
+  rules:
+`
+	for name, rule := range map[string]string{
+		"no indices":         `    - migrate_after_days: 7`,
+		"invalid pattern":    `    - indices: ["audit-["]`,
+		"after retention":    `    - {indices: ["audit-*"], migrate_after_days: 30}`,
+		"empty rename field": `    - {indices: ["audit-*"], transforms: {rename_fields: {msg: ""}}}`,
+		"unknown action":     `    - {indices: ["audit-*"], action: archive}`,
+		"delete without age": `    - {indices: ["debug-*"], action: delete}`,
+		"delete and migrate": `    - {indices: ["debug-*"], action: delete, delete_after_days: 3, target_index: "debug"}`,
+		"age without delete": `    - {indices: ["debug-*"], delete_after_days: 3}`,
+	} {
+		if _, err := Load(writeTempFile(t, base+rule+"\n")); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestLoad_MigrationRollups(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  rollups:
+`
+	cfg, err := Load(writeTempFile(t, base+`    - name: by-service
+      indices: ["logs-*"]
+      group_by: ["service"]
+      metrics: ["latency_ms"]
+    - name: errors
+      indices: ["logs-*", "audit"]
+      target: "summaries-errors"
+      interval: 15m
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if r := cfg.Migration.Rollups[0]; r.Target != "rollup-by-service" || r.Interval != time.Hour {
+		t.Errorf("defaults = %+v", r)
+	}
+	if got := cfg.RollupsForIndex("logs-2026.01.01"); len(got) != 2 {
+		t.Errorf("RollupsForIndex(logs-2026.01.01) = %d rollups, want 2", len(got))
+	}
+	if got := cfg.RollupsForIndex("audit"); len(got) != 1 || got[0].Name != "errors" {
+		t.Errorf("RollupsForIndex(audit) = %+v", got)
+	}
+	if !cfg.RollupIndex("summaries-errors") || cfg.RollupIndex("logs-2026.01.01") {
+		t.Error("RollupIndex() wrong")
+	}
+
+	for _, tt := range []struct{ rollups, want string }{
+		{"    - indices: [\"logs\"]\n", "migration.rollups[0].name is required"},
+		{"    - name: a\n", "migration.rollups[a].indices must list at least one pattern"},
+		{"    - {name: a, indices: [\"logs\"], interval: 7m}\n", "migration.rollups[a].interval must be whole seconds dividing an hour"},
+		{"    - {name: a, indices: [\"logs\"], interval: 24h}\n", "migration.rollups[a].interval must be whole seconds dividing an hour"},
+		{"    - {name: a, indices: [\"logs\"], metrics: [\"\"]}\n", "migration.rollups[a]: group_by and metrics must not contain empty field names"},
+		{"    - {name: a, indices: [\"logs\"]}\n    - {name: a, indices: [\"audit\"]}\n", `migration.rollups: duplicate name "a"`},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.rollups)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.rollups, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_MigrationSources(t *testing.T) {
+	dir := t.TempDir()
+	pwFile := filepath.Join(dir, "eu-password")
+	os.WriteFile(pwFile, []byte("s3cret\n"), 0600)
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  checkpoint_dir: "/var/lib/oqbridge"
+  sources:
+    - name: eu
+      opensearch:
+        url: "http://os-eu:9200"
+        username: "migrator"
+        password_file: "` + pwFile + `"
+      indices: ["logs-*"]
+    - name: us
+      opensearch:
+        url: "http://os-us:9200"
+      indices: ["audit-*"]
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.SourceNames(); !slices.Equal(got, []string{"eu", "us"}) {
+		t.Errorf("SourceNames() = %v", got)
+	}
+
+	eu, err := cfg.ForSource("eu")
+	if err != nil {
+		t.Fatalf("ForSource(eu) error = %v", err)
+	}
+	if eu.OpenSearch.URL != "http://os-eu:9200" || eu.OpenSearch.Password != "s3cret" {
+		t.Errorf("eu opensearch = %+v", eu.OpenSearch)
+	}
+	if eu.OpenSearch.Retry.MaxAttempts != 3 || eu.OpenSearch.SigV4.Service != "es" {
+		t.Errorf("eu opensearch defaults not applied: %+v", eu.OpenSearch)
+	}
+	if !slices.Equal(eu.Migration.Indices, []string{"logs-*"}) || eu.Migration.Sources != nil || eu.Source() != "eu" {
+		t.Errorf("eu migration = %+v, source %q", eu.Migration, eu.Source())
+	}
+	if eu.Migration.CheckpointDir != filepath.Join("/var/lib/oqbridge", "eu") {
+		t.Errorf("eu checkpoint_dir = %q", eu.Migration.CheckpointDir)
+	}
+	if cfg.OpenSearch.URL != "http://os:9200" || len(cfg.Migration.Indices) != 0 {
+		t.Error("ForSource modified the original configuration")
+	}
+
+	if _, err := cfg.ForSource("apac"); err == nil {
+		t.Error("expected error for an unknown source")
+	}
+	if self, _ := cfg.ForSource(""); self != cfg {
+		t.Error("ForSource(\"\") should return the configuration itself")
+	}
+}
+
+func TestLoad_MigrationSources_Invalid(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+migration:
+`
+	for name, migration := range map[string]string{
+		"indices with sources": "  indices: [\"logs-*\"]\n  sources: [{name: eu, opensearch: {url: \"http://os-eu:9200\"}}]",
+		"no name":              "  sources: [{opensearch: {url: \"http://os-eu:9200\"}}]",
+		"path in name":         "  sources: [{name: ../eu, opensearch: {url: \"http://os-eu:9200\"}}]",
+		"duplicate name":       "  sources: [{name: eu, opensearch: {url: \"http://a:9200\"}}, {name: eu, opensearch: {url: \"http://b:9200\"}}]",
+		"no url":               "  sources: [{name: eu}]",
+	} {
+		if _, err := Load(writeTempFile(t, base+migration+"\n")); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestLoad_QuickwitClusters(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "eu-token")
+	os.WriteFile(tokenFile, []byte("tok\n"), 0600)
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+  ingest_api: "v2"
+  search_api: "elastic"
+quickwit_clusters:
+  - name: eu
+    indices: ["eu-*", "gdpr-audit"]
+    quickwit:
+      url: "https://qw-eu:7280"
+      ca_cert: "/etc/ssl/eu-ca.pem"
+      auth:
+        bearer_token_file: "` + tokenFile + `"
+  - name: apac
+    indices: ["apac-*"]
+    quickwit:
+      url: "http://qw-apac:7280"
+      ingest_api: "v1"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for index, want := range map[string]string{"eu-logs": "eu", "gdpr-audit": "eu", "apac-logs": "apac", "us-logs": ""} {
+		if got := cfg.QuickwitClusterForIndex(index); got != want {
+			t.Errorf("QuickwitClusterForIndex(%s) = %q, want %q", index, got, want)
+		}
+	}
+
+	eu := cfg.QuickwitClusters[0].Quickwit
+	if eu.Auth.BearerToken != "tok" || eu.CACert != "/etc/ssl/eu-ca.pem" {
+		t.Errorf("eu connection = %+v", eu)
+	}
+	if eu.IngestAPI != "v2" || eu.IngestCommit != "auto" || eu.SearchAPI != "elastic" || eu.Retry.MaxAttempts != 3 {
+		t.Errorf("eu modes = %s/%s/%s retry %d, want them inherited from quickwit", eu.IngestAPI, eu.IngestCommit, eu.SearchAPI, eu.Retry.MaxAttempts)
+	}
+	if apac := cfg.QuickwitClusters[1].Quickwit; apac.IngestAPI != "v1" {
+		t.Errorf("apac ingest_api = %q, want its own setting", apac.IngestAPI)
+	}
+}
+
+func TestLoad_QuickwitClusters_Invalid(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+quickwit_clusters:
+`
+	for name, cluster := range map[string]string{
+		"no name":        `  - {indices: ["eu-*"], quickwit: {url: "http://qw-eu:7280"}}`,
+		"duplicate name": `  - {name: eu, indices: ["eu-*"], quickwit: {url: "http://a:7280"}}` + "\n" + `  - {name: eu, indices: ["x-*"], quickwit: {url: "http://b:7280"}}`,
+		"no indices":     `  - {name: eu, quickwit: {url: "http://qw-eu:7280"}}`,
+		"bad pattern":    `  - {name: eu, indices: ["eu-["], quickwit: {url: "http://qw-eu:7280"}}`,
+		"no url":         `  - {name: eu, indices: ["eu-*"]}`,
+		"bad ingest api": `  - {name: eu, indices: ["eu-*"], quickwit: {url: "http://qw-eu:7280", ingest_api: "v3"}}`,
+	} {
+		if _, err := Load(writeTempFile(t, base+cluster+"\n")); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestWriteDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDefaults(&buf); err != nil {
+		t.Fatalf("WriteDefaults() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"  # Number of parallel sliced scroll workers.\n  workers: 4\n",
+		"  temp_dir: \"\"\n",
+		"  index_cold_days: {}\n",
+		"  rules: []\n  #   - indices: []\n",
+		"  #   \"<pattern>\":\n  #     workers: 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	// The output is a valid configuration once the backend URLs are set.
+	t.Setenv("OQBRIDGE_OPENSEARCH_URL", "http://os:9200")
+	t.Setenv("OQBRIDGE_QUICKWIT_URL", "http://qw:7280")
+	cfg, err := Load(writeConfigAs(t, "defaults.yaml", out))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Defaults()
+	if cfg.Migration.Workers != want.Migration.Workers || cfg.Migration.HealthGate != want.Migration.HealthGate ||
+		cfg.Quickwit.ListCacheTTL != want.Quickwit.ListCacheTTL || cfg.Retention.Enforce != want.Retention.Enforce ||
+		!slices.Equal(cfg.OpenSearch.Retry.Methods, want.OpenSearch.Retry.Methods) {
+		t.Errorf("loaded defaults differ from Defaults(): %+v", cfg.Migration)
+	}
+}
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+  headers:
+    X-Tenant: "logs"
+quickwit:
+  url: "http://qw:7280"
+migration:
+  migrate_after_day: 3
+  rules:
+    - indices: ["logs-*"]
+      filter: {term: {level: "debug"}}
+      transfroms: {}
+  index_overrides:
+    "metrics-*":
+      worker: 2
+`
+	_, err := Load(writeTempFile(t, content))
+	if err == nil {
+		t.Fatal("expected an error for unknown keys")
+	}
+	want := "migration.index_overrides.metrics-*.worker, migration.migrate_after_day, migration.rules[0].transfroms"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want it to list %s", err, want)
+	}
+
+	cfg, err := Load(writeTempFile(t, content+"allow_unknown_keys: true\n"))
+	if err != nil {
+		t.Fatalf("with allow_unknown_keys: %v", err)
+	}
+	if cfg.Migration.Rules[0].Filter == nil {
+		t.Error("rule filter was not loaded")
+	}
+}
+
+func TestLoad_DurationStrings(t *testing.T) {
+	t.Setenv("OQBRIDGE_MIGRATION_LOCK_TTL", "1d12h")
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+  index_settings:
+    commit_timeout_secs: "1m"
+retention:
+  days: "30d"
+  cold_days: "1w"
+  index_cold_days:
+    "audit-*": "720h"
+migration:
+  migrate_after_days: 20
+  scroll_keep_alive: "45m"
+  health_gate:
+    interval: "1.5m"
+  rules:
+    - indices: ["debug-*"]
+      migrate_after_days: "2d"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Retention.Days != 30 || cfg.Retention.ColdDays != 7 || cfg.Retention.IndexColdDays["audit-*"] != 30 {
+		t.Errorf("retention = %+v", cfg.Retention)
+	}
+	if cfg.Migration.MigrateAfterDays != 20 || cfg.Migration.Rules[0].MigrateAfterDays != 2 {
+		t.Errorf("migrate_after_days = %d, rule %d", cfg.Migration.MigrateAfterDays, cfg.Migration.Rules[0].MigrateAfterDays)
+	}
+	if cfg.Quickwit.IndexSettings.CommitTimeoutSecs != 60 {
+		t.Errorf("commit_timeout_secs = %d, want 60", cfg.Quickwit.IndexSettings.CommitTimeoutSecs)
+	}
+	if cfg.Migration.LockTTL != 36*time.Hour || cfg.Migration.ScrollKeepAlive != 45*time.Minute || cfg.Migration.HealthGate.Interval != 90*time.Second {
+		t.Errorf("lock_ttl = %s, scroll_keep_alive = %s, interval = %s", cfg.Migration.LockTTL, cfg.Migration.ScrollKeepAlive, cfg.Migration.HealthGate.Interval)
+	}
+}
+
+func TestLoad_DurationStrings_Rounded(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base+"retention:\n  days: \"36h\"\nmigration:\n  migrate_after_days: 1\n"))
+	if err != nil {
+		t.Fatalf("retention.days: 36h: %v", err)
+	}
+	if cfg.Retention.Days != 2 {
+		t.Errorf("retention.days = %d, want 36h rounded to 2", cfg.Retention.Days)
+	}
+
+	cfg, err = Load(writeTempFile(t, base+"retention:\n  days: 7\nmigration:\n  migrate_after_days: \"36h\"\n  rules:\n    - indices: [\"debug-*\"]\n      migrate_after_days: \"30h\"\n  index_overrides:\n    \"logs-*\":\n      quickwit:\n        commit_timeout_secs: \"1.5s\"\n"))
+	if err != nil {
+		t.Fatalf("migrate_after_days: 36h: %v", err)
+	}
+	if cfg.Migration.MigrateAfterDays != 2 || cfg.Migration.Rules[0].MigrateAfterDays != 1 {
+		t.Errorf("migrate_after_days = %d, rule %d; want 2 and 1", cfg.Migration.MigrateAfterDays, cfg.Migration.Rules[0].MigrateAfterDays)
+	}
+	if secs := cfg.Migration.IndexOverrides["logs-*"].Quickwit.CommitTimeoutSecs; secs != 2 {
+		t.Errorf("commit_timeout_secs = %d, want 1.5s rounded to 2", secs)
+	}
+}
+
+func TestLoad_DurationStrings_Invalid(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	for name, extra := range map[string]string{
+		"under half a day":       "retention:\n  days: \"6h\"\n",
+		"under half a second":    "migration:\n  index_overrides:\n    \"logs-*\":\n      quickwit:\n        commit_timeout_secs: \"400ms\"\n",
+		"not a duration":         "migration:\n  lock_ttl: \"soon\"\n",
+		"short keep-alive":       "migration:\n  scroll_keep_alive: \"500ms\"\n",
+		"pause above keep-alive": "migration:\n  scroll_keep_alive: \"5m\"\n  health_gate:\n    enabled: true\n    max_pause: \"10m\"\n",
+	} {
+		if _, err := Load(writeTempFile(t, base+extra)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"45m":   45 * time.Minute,
+		"1.5d":  36 * time.Hour,
+		"1d12h": 36 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"-1d":   -24 * time.Hour,
+		"500ms": 500 * time.Millisecond,
+	} {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "7", "d", "7days"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	t.Setenv("OQBRIDGE_MIGRATION_WORKERS", "6")
+	t.Setenv("OQBRIDGE_MIGRATION_BATCH_SIZE", "200")
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+migration:
+  workers: 4
+  schedule: "0 * * * *"
+`
+	cfg, err := LoadWithOverrides(writeTempFile(t, content), Overrides{
+		"migration.workers":  "8",
+		"migration.schedule": "*/5 * * * *",
+		"retention.days":     "10d",
+	})
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	// Overrides win over the environment, which wins over the file.
+	if cfg.Migration.Workers != 8 || cfg.Migration.Schedule != "*/5 * * * *" || cfg.Migration.BatchSize != 200 {
+		t.Errorf("workers = %d, schedule = %q, batch_size = %d", cfg.Migration.Workers, cfg.Migration.Schedule, cfg.Migration.BatchSize)
+	}
+	// Defaults derived from an overridden key follow the override.
+	if cfg.Retention.Days != 10 || cfg.Migration.MigrateAfterDays != 5 {
+		t.Errorf("retention.days = %d, migrate_after_days = %d", cfg.Retention.Days, cfg.Migration.MigrateAfterDays)
+	}
+
+	if _, err := LoadWithOverrides(writeTempFile(t, content), Overrides{"migration.workers": "many"}); err == nil {
+		t.Error("expected an error for an invalid override")
+	}
+}
+
+func TestLoad_EnvironmentOnly(t *testing.T) {
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error without opensearch.url and quickwit.url")
+	}
+
+	t.Setenv("OQBRIDGE_OPENSEARCH_URL", "http://os:9200")
+	t.Setenv("OQBRIDGE_QUICKWIT_URL", "http://qw:7280")
+	t.Setenv("OQBRIDGE_MIGRATION_INDICES", "logs-*, audit-*")
+	cfg, err := LoadWithOverrides("", Overrides{"retention.days": "14"})
+	if err != nil {
+		t.Fatalf("Load(\"\") error = %v", err)
+	}
+	if cfg.OpenSearch.URL != "http://os:9200" || cfg.Retention.Days != 14 || !slices.Equal(cfg.Migration.Indices, []string{"logs-*", "audit-*"}) {
+		t.Errorf("opensearch.url = %q, retention.days = %d, migration.indices = %v", cfg.OpenSearch.URL, cfg.Retention.Days, cfg.Migration.Indices)
+	}
+	if len(cfg.Sources()) != 0 {
+		t.Errorf("Sources() = %v, want none", cfg.Sources())
+	}
+}
+
+func TestLoad_Profiles(t *testing.T) {
+	path := writeTempFile(t, `
+opensearch:
+  url: "http://localhost:9200"
+quickwit:
+  url: "http://localhost:7280"
+migration:
+  workers: 2
+profiles:
+  prod:
+    opensearch:
+      url: "https://os.prod:9200"
+    migration:
+      workers: 8
+  staging:
+    opensearch:
+      url: "https://os.staging:9200"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.OpenSearch.URL != "http://localhost:9200" || cfg.Migration.Workers != 2 {
+		t.Errorf("no profile: opensearch.url = %q, workers = %d", cfg.OpenSearch.URL, cfg.Migration.Workers)
+	}
+
+	t.Setenv("OQBRIDGE_PROFILE", "staging")
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Profile != "staging" || cfg.OpenSearch.URL != "https://os.staging:9200" || cfg.Migration.Workers != 2 {
+		t.Errorf("staging: profile = %q, opensearch.url = %q, workers = %d", cfg.Profile, cfg.OpenSearch.URL, cfg.Migration.Workers)
+	}
+
+	// The command line selects over the environment, and the environment
+	// overrides the profile.
+	t.Setenv("OQBRIDGE_MIGRATION_WORKERS", "4")
+	cfg, err = LoadWithOverrides(path, Overrides{"profile": "prod"})
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	if cfg.OpenSearch.URL != "https://os.prod:9200" || cfg.Migration.Workers != 4 {
+		t.Errorf("prod: opensearch.url = %q, workers = %d", cfg.OpenSearch.URL, cfg.Migration.Workers)
+	}
+
+	if _, err := LoadWithOverrides(path, Overrides{"profile": "qa"}); err == nil || !strings.Contains(err.Error(), `unknown profile "qa" (defined: prod, staging)`) {
+		t.Errorf("LoadWithOverrides(qa) error = %v, want an unknown profile", err)
+	}
+}
+
+func TestLoad_ProfilesUnknownKeys(t *testing.T) {
+	_, err := Load(writeTempFile(t, `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+profiles:
+  prod:
+    migration:
+      wokers: 8
+`))
+	if err == nil || !strings.Contains(err.Error(), "profiles.prod.migration.wokers") {
+		t.Fatalf("Load() error = %v, want the misspelled key of the unselected profile", err)
+	}
+}
+
+func TestLoad_DualWrite(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+dual_write:
+  enabled: true
+`
+	if _, err := Load(writeTempFile(t, base)); err == nil || !strings.Contains(err.Error(), "dual_write.indices") {
+		t.Errorf("Load() without indices error = %v", err)
+	}
+	if _, err := Load(writeTempFile(t, base+"  indices: [\"logs-*\"]\n  drop_policy: \"random\"\n")); err == nil || !strings.Contains(err.Error(), "dual_write.drop_policy") {
+		t.Errorf("Load() with an unknown drop_policy error = %v", err)
+	}
+
+	cfg, err := Load(writeTempFile(t, base+"  indices: [\"logs-*\"]\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DualWrite.BufferDocs != 100000 || cfg.DualWrite.BatchSize != 1000 || cfg.DualWrite.DropPolicy != "newest" {
+		t.Errorf("dual_write defaults = %+v", cfg.DualWrite)
+	}
+	if !cfg.DualWriteIndex("logs-2026.03.01") || cfg.DualWriteIndex("metrics-2026.03.01") {
+		t.Error("DualWriteIndex does not follow dual_write.indices")
+	}
+}
+
+func TestLoad_LateWrites(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+late_writes:
+  enabled: true
+`
+	if _, err := Load(writeTempFile(t, base)); err == nil || !strings.Contains(err.Error(), "late_writes.indices") {
+		t.Errorf("Load() without indices error = %v", err)
+	}
+	cfg, err := Load(writeTempFile(t, base+"  indices: [\"logs-*\"]\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.LateWriteIndex("logs-app") || cfg.LateWriteIndex("metrics-app") {
+		t.Error("LateWriteIndex does not follow late_writes.indices")
+	}
+}
+
+func TestLoad_PageCache(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  page_cache:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	pc := cfg.Server.PageCache
+	if pc.TTL != 5*time.Minute || pc.MaxEntries != 100 || pc.MaxHits != 1000 || !slices.Contains(pc.IdentityHeaders, "Authorization") {
+		t.Errorf("page_cache defaults = %+v", pc)
+	}
+	if _, err := Load(writeTempFile(t, base+"    max_hits: -1\n")); err == nil || !strings.Contains(err.Error(), "server.page_cache") {
+		t.Errorf("Load() with negative max_hits error = %v", err)
+	}
+}
+
+func TestLoad_Rehydrate(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  rehydrate:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	rh := cfg.Server.Rehydrate
+	if !slices.Equal(rh.AdminRoles, []string{"all_access"}) || rh.IndexPrefix != "rehydrated-" || rh.MaxDocs != 10000000 || rh.BatchSize != 1000 {
+		t.Errorf("rehydrate defaults = %+v", rh)
+	}
+	if _, err := Load(writeTempFile(t, base+"    index_prefix: \"Old-\"\n")); err == nil || !strings.Contains(err.Error(), "index_prefix") {
+		t.Errorf("Load() with an uppercase index_prefix error = %v", err)
+	}
+	if _, err := Load(writeTempFile(t, base+"    admin_roles: []\n")); err == nil || !strings.Contains(err.Error(), "admin_roles") {
+		t.Errorf("Load() with no admin_roles error = %v", err)
+	}
+}
+
+func TestLoad_Tenancy(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  tenancy:
+    enabled: true
+    tenants:
+      - name: payments
+        prefix: "payments-"
+        roles: ["payments_rw"]
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tn := cfg.Server.Tenancy
+	if !slices.Equal(tn.AdminRoles, []string{"all_access"}) || tn.CacheTTL != 30*time.Second || len(tn.IdentityHeaders) != 4 {
+		t.Errorf("tenancy defaults = %+v", tn)
+	}
+	for _, extra := range []string{
+		"      - name: pay\n        prefix: \"pay\"\n        users: [\"bob\"]\n",
+		"      - name: search\n        prefix: \"search-*\"\n        users: [\"bob\"]\n",
+		"      - name: search\n        prefix: \"search-\"\n",
+		"      - name: payments\n        prefix: \"search-\"\n        users: [\"bob\"]\n",
+	} {
+		if _, err := Load(writeTempFile(t, base+extra)); err == nil || !strings.Contains(err.Error(), "server.tenancy.tenants[1]") {
+			t.Errorf("Load() with %q error = %v", extra, err)
+		}
+	}
+}
+
+func TestLoad_Capture(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  capture:
+    enabled: true
+`
+	if _, err := Load(writeTempFile(t, base)); err == nil || !strings.Contains(err.Error(), "server.capture.path") {
+		t.Errorf("Load() without a path error = %v", err)
+	}
+	cfg, err := Load(writeTempFile(t, base+"    path: /var/lib/oqbridge/capture.ndjson\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c := cfg.Server.Capture; c.SampleRate != 1 || c.MaxSizeMB != 100 || c.KeepValues {
+		t.Errorf("capture defaults = %+v", c)
+	}
+	if _, err := Load(writeTempFile(t, base+"    path: c.ndjson\n    sample_rate: 1.5\n")); err == nil {
+		t.Error("Load() accepted a sample_rate above 1")
+	}
+}
+
+func TestLoad_Chaos(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+chaos:
+  enabled: true
+  cold:
+    error_rate: 0.1
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Chaos.Hot.ErrorStatus != 503 || cfg.Chaos.Cold.ErrorStatus != 503 || cfg.Chaos.Cold.ErrorRate != 0.1 {
+		t.Errorf("chaos = %+v", cfg.Chaos)
+	}
+	for _, extra := range []string{"    drop_rate: 2\n", "    error_status: 404\n"} {
+		if _, err := Load(writeTempFile(t, base+extra)); err == nil || !strings.Contains(err.Error(), "chaos.cold") {
+			t.Errorf("Load() with %q error = %v", extra, err)
+		}
+	}
+}
+
+func TestLoad_RemoteClusters(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+remote_clusters:
+  - name: europe
+    retention_days: 90
+    index_prefix: "eu-"
+`
+	cfg, err := Load(writeTempFile(t, base+"  - name: asia\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	clusters, name, ok := cfg.RemoteClustersForIndex("*:logs")
+	if !ok || name != "logs" || len(clusters) != 2 {
+		t.Errorf("RemoteClustersForIndex(*:logs) = %v, %q, %v", clusters, name, ok)
+	}
+	if _, _, ok := cfg.RemoteClustersForIndex("logs"); ok {
+		t.Error("RemoteClustersForIndex(logs) reported a remote index")
+	}
+	if got := cfg.RemoteHotDays(clusters[0]); got != 90 {
+		t.Errorf("RemoteHotDays(europe) = %d, want 90", got)
+	}
+	if got := cfg.RemoteHotDays(clusters[1]); got != 30 {
+		t.Errorf("RemoteHotDays(asia) = %d, want retention.days", got)
+	}
+
+	if _, err := Load(writeTempFile(t, base+"    quickwit_cluster: eu\n")); err == nil || !strings.Contains(err.Error(), "quickwit_clusters") {
+		t.Errorf("Load() with an unknown quickwit_cluster error = %v", err)
+	}
+	if _, err := Load(writeTempFile(t, base+"  - name: europe\n")); err == nil || !strings.Contains(err.Error(), "not unique") {
+		t.Errorf("Load() with a duplicate name error = %v", err)
+	}
+}
+
+func TestLoad_Tail(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  tail:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if tc := cfg.Server.Tail; tc.PollInterval != 2*time.Second || tc.PageSize != 500 || tc.MaxDuration != time.Hour {
+		t.Errorf("tail defaults = %+v", tc)
+	}
+	if _, err := Load(writeTempFile(t, base+"    page_size: 20000\n")); err == nil || !strings.Contains(err.Error(), "page_size") {
+		t.Errorf("Load() with page_size above max_hits error = %v", err)
+	}
+}
+
+func TestLoad_Tiers(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 7
+migration:
+  migrate_after_days: 5
+  indices: ["logs-*"]
+  rules:
+    - indices: ["logs-*"]
+      migrate_after_days: 3
+      target_index: "archive-{index}"
+tiers:
+  - name: warm
+    opensearch:
+      url: "http://os-warm:9200"
+    days: 30
+    migrate_after_days: 25
+  - name: cool
+    opensearch:
+      url: "http://os-cool:9200"
+    days: 180
+    migrate_after_days: 170
+    on_error: "fail"
+`
+	cfg, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.SourceNames(); !slices.Equal(got, []string{"", "warm", "cool"}) {
+		t.Errorf("SourceNames() = %v", got)
+	}
+	if got := cfg.TierDays("logs-a"); !slices.Equal(got, []int{7, 30, 180}) {
+		t.Errorf("TierDays() = %v", got)
+	}
+	if cfg.Tiers[0].OnError != "skip" || cfg.Tiers[0].OpenSearch.Retry.MaxAttempts != 3 {
+		t.Errorf("tier defaults not applied: %+v", cfg.Tiers[0])
+	}
+
+	hot, _ := cfg.ForSource("")
+	if next := hot.NextTier(); next == nil || next.Name != "warm" || hot.Migration.Rules != nil {
+		t.Errorf("hot: next tier %v, rules %v", next, hot.Migration.Rules)
+	}
+	warm, _ := cfg.ForSource("warm")
+	if next := warm.NextTier(); next == nil || next.Name != "cool" || warm.OpenSearch.URL != "http://os-warm:9200" {
+		t.Errorf("warm: next tier %v, opensearch %q", next, warm.OpenSearch.URL)
+	}
+	if got := warm.MigrationPolicyForIndex("logs-a"); got.MigrateAfterDays != 25 || got.TargetIndex != "logs-a" {
+		t.Errorf("warm policy = %+v", got)
+	}
+	cool, _ := cfg.ForSource("cool")
+	if cool.NextTier() != nil {
+		t.Error("the last tier should migrate into Quickwit")
+	}
+	if got := cool.MigrationPolicyForIndex("logs-a"); got.MigrateAfterDays != 170 || got.TargetIndex != "archive-logs-a" {
+		t.Errorf("cool policy = %+v", got)
+	}
+	if cfg.Migration.Rules[0].MigrateAfterDays != 3 {
+		t.Error("ForSource modified the original rules")
+	}
+}
+
+func TestLoad_Tiers_Invalid(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 7
+migration:
+  migrate_after_days: 5
+`
+	for name, tiers := range map[string]string{
+		"days within hot":     "tiers: [{name: warm, opensearch: {url: \"http://w:9200\"}, days: 7}]",
+		"days not increasing": "tiers: [{name: a, opensearch: {url: \"http://a:9200\"}, days: 30}, {name: b, opensearch: {url: \"http://b:9200\"}, days: 20}]",
+		"migrate after days":  "tiers: [{name: warm, opensearch: {url: \"http://w:9200\"}, days: 30, migrate_after_days: 30}]",
+		"unknown on_error":    "tiers: [{name: warm, opensearch: {url: \"http://w:9200\"}, days: 30, on_error: retry}]",
+		"duplicate name":      "tiers: [{name: a, opensearch: {url: \"http://a:9200\"}, days: 30}, {name: a, opensearch: {url: \"http://b:9200\"}, days: 60}]",
+		"no url":              "tiers: [{name: warm, days: 30}]",
+	} {
+		if _, err := Load(writeTempFile(t, base+tiers+"\n")); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	// An index kept longer in the hot tier than the first tier's days.
+	_, err := Load(writeTempFile(t, strings.Replace(base, "  days: 7\n", "  days: 7\n  index_days: {\"audit-*\": 40}\n", 1)+"tiers: [{name: warm, opensearch: {url: \"http://w:9200\"}, days: 30}]\n"))
+	if err == nil || !strings.Contains(err.Error(), "index_days") {
+		t.Errorf("index_days beyond the first tier: error = %v", err)
+	}
+}
+
+func TestLoad_Router(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Router.Name != "time_range" {
+		t.Errorf("server.router.name = %q, want time_range", cfg.Server.Router.Name)
+	}
+	cfg, err = Load(writeTempFile(t, base+"server:\n  router:\n    name: catalog\n    options:\n      url: http://catalog:8080\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if r := cfg.Server.Router; r.Name != "catalog" || r.Options["url"] != "http://catalog:8080" {
+		t.Errorf("server.router = %+v", r)
+	}
+}
+
+func TestLoad_RoutingRules(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+tiers:
+  - name: warm
+    days: 90
+    opensearch:
+      url: "http://warm:9200"
+routing_rules:
+`
+	cfg, err := Load(writeTempFile(t, base+"  - when: 'index matches \"audit-*\" && from < now - 1w'\n    route: warm\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c := cfg.RoutingRules[0].Condition(); c == nil || c.String() != `index matches "audit-*" && from < now - 1w` {
+		t.Errorf("Condition() = %v", c)
+	}
+
+	for _, tt := range []struct{ rules, want string }{
+		{"  - route: cold\n", "routing_rules[0].when is required"},
+		{"  - when: 'index == 1d'\n    route: cold\n", "cannot compare string == duration"},
+		{"  - name: x\n    when: 'tenant == \"a\"'\n    route: cold\n", `routing_rules[x].when: at offset 0: unknown name "tenant"`},
+		{"  - when: 'true'\n    route: lukewarm\n", "routing_rules[0].route must be"},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.rules)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.rules, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Cost(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+tiers:
+  - name: warm
+    days: 90
+    opensearch:
+      url: "http://warm:9200"
+  - name: cool
+    days: 180
+    opensearch:
+      url: "http://cool:9200"
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Cost.Currency != "USD" {
+		t.Errorf("cost.currency = %q, want USD", cfg.Cost.Currency)
+	}
+
+	cfg, err = Load(writeTempFile(t, base+"cost:\n  hot_gb_month: 0.1\n  cold_gb_month: 0.02\n  tier_gb_month:\n    warm: 0.05\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, tt := range []struct {
+		name           string
+		source, target float64
+	}{
+		{"", 0.1, 0.05},
+		{"warm", 0.05, 0.1}, // cool is not priced and costs like hot.
+		{"cool", 0.1, 0.02},
+	} {
+		sc, err := cfg.ForSource(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, tg := sc.StoragePrices(); s != tt.source || tg != tt.target {
+			t.Errorf("StoragePrices() from %q = %g, %g, want %g, %g", tt.name, s, tg, tt.source, tt.target)
+		}
+	}
+
+	for _, tt := range []struct{ cost, want string }{
+		{"cost:\n  egress_gb: -1\n", "cost.egress_gb must not be negative"},
+		{"cost:\n  tier_gb_month:\n    lukewarm: 1\n", `cost.tier_gb_month: "lukewarm" is not a tiers entry`},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.cost)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.cost, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Monitors(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  monitors:
+`
+	cfg, err := Load(writeTempFile(t, base+`    - name: errors
+      schedule: "*/5 * * * *"
+      indices: ["logs-*"]
+      webhook_url: "http://hooks:8080/errors"
+    - name: quiet
+      schedule: "@hourly"
+      indices: ["audit"]
+      value: "aggregations.users.value"
+      condition: "<= 2.5"
+      webhook_url: "http://hooks:8080/quiet"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	errs, quiet := cfg.Server.Monitors[0], cfg.Server.Monitors[1]
+	if errs.Value != "hits.total.value" || errs.Condition != "> 0" {
+		t.Errorf("defaults = %q, %q", errs.Value, errs.Condition)
+	}
+	if errs.Triggers(0) || !errs.Triggers(1) {
+		t.Errorf("%q.Triggers() wrong", errs.Condition)
+	}
+	if !quiet.Triggers(2.5) || quiet.Triggers(3) {
+		t.Errorf("%q.Triggers() wrong", quiet.Condition)
+	}
+
+	for _, tt := range []struct{ monitors, want string }{
+		{"    - schedule: \"@hourly\"\n", "server.monitors[0].name is required"},
+		{"    - name: a\n      schedule: \"@hourly\"\n      indices: [\"logs\"]\n", "server.monitors[a]: schedule, indices and webhook_url are required"},
+		{"    - name: a\n      schedule: \"@hourly\"\n      indices: [\"logs\"]\n      webhook_url: \"http://h\"\n      condition: \"~ 3\"\n", "server.monitors[a].condition must be"},
+		{"    - name: a\n      schedule: \"@hourly\"\n      indices: [\"logs\"]\n      webhook_url: \"http://h\"\n    - name: a\n", `server.monitors: duplicate name "a"`},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.monitors)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.monitors, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_ColdQuota(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  cold_quota:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base+`    queries: 500
+    windows: 3650
+    overrides:
+      - roles: ["oncall"]
+        hits: 100000
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	q := cfg.Server.ColdQuota
+	if q.Queries != 500 || q.Windows != 3650 || q.Hits != 0 {
+		t.Errorf("limits = %+v", q.ColdQuotaLimits)
+	}
+	if len(q.Overrides) != 1 || q.Overrides[0].Hits != 100000 || q.Overrides[0].Queries != 0 {
+		t.Errorf("overrides = %+v", q.Overrides)
+	}
+	if q.Index != ".oqbridge-cold-usage" || q.FlushInterval != 10*time.Second || q.CacheTTL != 30*time.Second || len(q.ExemptRoles) != 1 {
+		t.Errorf("defaults = %+v", q)
+	}
+
+	for _, tt := range []struct{ quota, want string }{
+		{"    queries: -1\n", "server.cold_quota: queries, windows and hits must not be negative"},
+		{"    overrides:\n      - hits: 5\n", "server.cold_quota.overrides[0]: users or roles is required"},
+		{"    flush_interval: -1s\n", "server.cold_quota: flush_interval and cache_ttl must be positive"},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.quota)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.quota, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Warmups(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+`
+	cfg, err := Load(writeTempFile(t, base+`  cold_cache:
+    enabled: true
+  warmups:
+    - name: dashboards
+      schedule: "45 7 * * 1-5"
+      indices: ["logs-*"]
+      query: {"query": {"range": {"@timestamp": {"gte": "now-90d/d"}}}}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c := cfg.Server.ColdCache; c.TTL != 30*time.Minute || c.MaxEntries != 1000 || c.MaxHits != 1000 {
+		t.Errorf("cold_cache defaults = %+v", c)
+	}
+	if len(cfg.Server.Warmups) != 1 || cfg.Server.Warmups[0].Query["query"] == nil {
+		t.Errorf("warmups = %+v", cfg.Server.Warmups)
+	}
+
+	for _, tt := range []struct{ server, want string }{
+		{"  cold_cache:\n    enabled: true\n    max_hits: -1\n", "server.cold_cache: ttl, max_entries and max_hits must be positive"},
+		{"  warmups:\n    - schedule: \"@daily\"\n", "server.warmups[0].name is required"},
+		{"  warmups:\n    - name: a\n      schedule: \"@daily\"\n", "server.warmups[a]: schedule and indices are required"},
+		{"  warmups:\n    - name: a\n      schedule: \"@daily\"\n      indices: [\"logs\"]\n    - name: a\n", `server.warmups: duplicate name "a"`},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.server)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%s) error = %v, want %q", tt.server, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Compat(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  compat:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base+"    version: \"7.10.2\"\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c := cfg.Server.Compat; c.Version != "7.10.2" || c.Distribution != "" || c.ProductHeader != "Elasticsearch" {
+		t.Errorf("compat = %+v", c)
+	}
+	for _, v := range []string{"7.10", "7.x.2", "v7.10.2"} {
+		if _, err := Load(writeTempFile(t, base+"    version: \""+v+"\"\n")); err == nil || !strings.Contains(err.Error(), "server.compat.version must be a version number") {
+			t.Errorf("Load(version %s) error = %v", v, err)
+		}
+	}
+}
+
+func TestLoad_Failover(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+server:
+  failover:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base+"    users_file: /etc/oqbridge/failover-users\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f := cfg.Server.Failover; f.CheckInterval != 10*time.Second || f.FailureThreshold != 3 {
+		t.Errorf("failover defaults = %+v", f)
+	}
+
+	for _, tt := range []struct{ failover, want string }{
+		{"", "server.failover.users_file is required"},
+		{"    users_file: /etc/users\n    failure_threshold: -1\n", "server.failover: check_interval and failure_threshold must be positive"},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.failover)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.failover, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_RetentionTTLRequiresDeleteRule(t *testing.T) {
+	content := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  ttl:
+    enabled: true
+`
+	if _, err := Load(writeTempFile(t, content)); err == nil || !strings.Contains(err.Error(), `retention.ttl.enabled requires a migration.rules entry with action "delete"`) {
+		t.Errorf("Load() error = %v", err)
+	}
+}
+
+func TestLoad_RetentionRuntime(t *testing.T) {
+	base := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  runtime:
+    enabled: true
+`
+	cfg, err := Load(writeTempFile(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if rt := cfg.Retention.Runtime; rt.SyncInterval != 15*time.Second || rt.Delay != time.Minute || len(rt.AdminRoles) != 1 || rt.AdminRoles[0] != "all_access" {
+		t.Errorf("retention.runtime defaults = %+v", rt)
+	}
+
+	for _, tt := range []struct{ runtime, want string }{
+		{"    admin_roles: []\n", "retention.runtime.admin_roles must not be empty"},
+		{"    sync_interval: -1s\n", "retention.runtime.sync_interval must be positive"},
+		{"    sync_interval: 1m\n    delay: 30s\n", "retention.runtime.delay (30s) must be greater than retention.runtime.sync_interval (1m0s)"},
+	} {
+		if _, err := Load(writeTempFile(t, base+tt.runtime)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.runtime, err, tt.want)
+		}
 	}
-	return path
 }
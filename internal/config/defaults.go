@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// configSource is this package's config.go, whose field comments document
+// the keys written by WriteDefaults.
+//
+//go:embed config.go
+var configSource []byte
+
+// fieldDocs maps "Type.Field" to the comment of that field in config.go.
+var fieldDocs = sync.OnceValue(func() map[string]string {
+	docs := make(map[string]string)
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return docs
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, f := range st.Fields.List {
+			text := f.Comment.Text()
+			if text == "" {
+				text = f.Doc.Text()
+			}
+			for _, name := range f.Names {
+				docs[spec.Name.Name+"."+name.Name] = strings.TrimSpace(text)
+			}
+		}
+		return false
+	})
+	return docs
+})
+
+// Defaults returns the configuration Load produces from a file that sets
+// nothing, before validation.
+func Defaults() *Config {
+	cfg := &Config{}
+	setDefaults(cfg)
+	return cfg
+}
+
+// WriteDefaults writes every configuration key as YAML with its default
+// value, preceded by the comment of its field. Lists and maps of settings
+// are written empty, followed by a commented-out entry with the defaults of
+// its keys. The output loads as a configuration file once the required
+// backend URLs are filled in.
+func WriteDefaults(w io.Writer) error {
+	// A sample entry in each list and map, filled in by setDefaults like
+	// the entries of a real file.
+	sample := &Config{
+		QuickwitClusters: []QuickwitCluster{{}},
+		Tiers:            []TierConfig{{}},
+		Migration: MigrationConfig{
+			IndexOverrides: map[string]IndexOverride{"<pattern>": {}},
+			Rules:          []MigrationRule{{}},
+			Sources:        []MigrationSource{{}},
+		},
+	}
+	setDefaults(sample)
+
+	var buf bytes.Buffer
+	buf.WriteString("# oqbridge configuration with every key at its default value.\n")
+	buf.WriteString("# opensearch.url and quickwit.url are required.\n\n")
+	writeDefaultFields(&buf, reflect.ValueOf(Defaults()).Elem(), reflect.ValueOf(sample).Elem(), "", true)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeDefaultFields writes the keys of struct v, indented by prefix. sample
+// has the same type as v and supplies the example entries of lists and maps.
+// Comments are left out of commented-out examples.
+func writeDefaultFields(buf *bytes.Buffer, v, sample reflect.Value, prefix string, docs bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("koanf"), ",")
+		if f.Anonymous && opts == "squash" {
+			writeDefaultFields(buf, v.Field(i), sample.Field(i), prefix, docs)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		if docs {
+			if doc := fieldDocs()[t.Name()+"."+f.Name]; doc != "" {
+				for _, line := range strings.Split(doc, "\n") {
+					fmt.Fprintf(buf, "%s# %s\n", prefix, line)
+				}
+			}
+		}
+
+		fv, sv := v.Field(i), sample.Field(i)
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			fmt.Fprintf(buf, "%s%s:\n", prefix, name)
+			writeDefaultFields(buf, fv, sv, prefix+"  ", docs)
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct:
+			fmt.Fprintf(buf, "%s%s: []\n", prefix, name)
+			if sv.Len() > 0 {
+				var entry bytes.Buffer
+				writeDefaultFields(&entry, sv.Index(0), sv.Index(0), "", false)
+				writeCommentedEntry(buf, prefix, "- ", entry.String())
+			}
+		case f.Type.Kind() == reflect.Map && f.Type.Elem().Kind() == reflect.Struct:
+			fmt.Fprintf(buf, "%s%s: {}\n", prefix, name)
+			for _, key := range sv.MapKeys() {
+				elem := sv.MapIndex(key)
+				var entry bytes.Buffer
+				writeDefaultFields(&entry, elem, elem, "  ", false)
+				writeCommentedEntry(buf, prefix, "", strconv.Quote(key.String())+":\n"+entry.String())
+			}
+		default:
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, name, yamlScalar(fv))
+		}
+	}
+}
+
+// writeCommentedEntry writes the lines of entry commented out below a key
+// at prefix, the first one after marker (e.g. "- " for a list item).
+func writeCommentedEntry(buf *bytes.Buffer, prefix, marker, entry string) {
+	pad := strings.Repeat(" ", len(marker))
+	for i, line := range strings.Split(strings.TrimSuffix(entry, "\n"), "\n") {
+		lead := pad
+		if i == 0 {
+			lead = marker
+		}
+		fmt.Fprintf(buf, "%s#   %s%s\n", prefix, lead, line)
+	}
+}
+
+// yamlScalar formats a default value in YAML flow style.
+func yamlScalar(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return strconv.Quote(time.Duration(v.Int()).String())
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return "null"
+		}
+		return yamlScalar(v.Elem())
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = yamlScalar(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case v.Kind() == reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = strconv.Quote(k.String()) + ": " + yamlScalar(v.MapIndex(k))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationDaysRe = regexp.MustCompile(`(\d+(?:\.\d*)?|\.\d+)([dw])`)
+
+// ParseDuration parses a Go duration string such as "90s", "45m" or "1h30m"
+// that may also use the units "d" (24 hours) and "w" (7 days), e.g. "7d" or
+// "1d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	var invalid bool
+	expanded := durationDaysRe.ReplaceAllStringFunc(s, func(part string) string {
+		n, err := strconv.ParseFloat(part[:len(part)-1], 64)
+		if err != nil {
+			invalid = true
+			return part
+		}
+		hours := 24.0
+		if part[len(part)-1] == 'w' {
+			hours = 7 * 24
+		}
+		return strconv.FormatFloat(n*hours, 'f', -1, 64) + "h"
+	})
+	d, err := time.ParseDuration(expanded)
+	if invalid || err != nil {
+		return 0, fmt.Errorf("invalid duration %q: want e.g. 90s, 45m, 36h or 7d", s)
+	}
+	return d, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnit returns the unit of a setting of type t named key that may
+// be given as a duration string: the precision of a time.Duration, or the
+// unit of an integer count of days ("*days") or seconds ("*_secs"). It
+// returns 0 for other settings.
+func durationUnit(key string, t reflect.Type) time.Duration {
+	switch {
+	case t == durationType:
+		return 1
+	case t.Kind() != reflect.Int:
+		return 0
+	case strings.HasSuffix(key, "days"):
+		return 24 * time.Hour
+	case strings.HasSuffix(key, "_secs"):
+		return time.Second
+	}
+	return 0
+}
+
+// normalizeDurations replaces the duration strings in raw, below prefix,
+// that set a duration or a count of days or seconds in struct type t with
+// the number they stand for, so "36h", "90m" and "1.5d" mean the same
+// wherever a time span is configured. Counts of days or seconds are
+// rounded to the nearest whole unit; a span rounding to zero is an error.
+func normalizeDurations(raw map[string]any, t reflect.Type, prefix string) error {
+	fields := make(map[string]reflect.Type)
+	collectFieldTypes(t, fields)
+
+	for key, value := range raw {
+		ft, ok := fields[key]
+		if !ok {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case durationUnit(key, ft) != 0:
+			n, err := parseDurationValue(path, value, durationUnit(key, ft))
+			if err != nil {
+				return err
+			}
+			raw[key] = n
+		case ft.Kind() == reflect.Struct:
+			if m, ok := value.(map[string]any); ok {
+				if err := normalizeDurations(m, ft, path); err != nil {
+					return err
+				}
+			}
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			items, _ := value.([]any)
+			for i, item := range items {
+				if m, ok := item.(map[string]any); ok {
+					if err := normalizeDurations(m, ft.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+						return err
+					}
+				}
+			}
+		case ft.Kind() == reflect.Map:
+			entries, _ := value.(map[string]any)
+			for name, entry := range entries {
+				if unit := durationUnit(key, ft.Elem()); unit != 0 {
+					n, err := parseDurationValue(path+"."+name, entry, unit)
+					if err != nil {
+						return err
+					}
+					entries[name] = n
+				} else if m, ok := entry.(map[string]any); ok && ft.Elem().Kind() == reflect.Struct {
+					if err := normalizeDurations(m, ft.Elem(), path+"."+name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseDurationValue returns value as a number of unit, rounded to the
+// nearest. Strings are parsed with ParseDuration, except plain numbers, which
+// are already in unit; other values are returned as they are.
+func parseDurationValue(path string, value any, unit time.Duration) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s, nil
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Counts are whole: "36h" of days is 2 days, "1.5s" of seconds is 2.
+	n, rem := d/unit, d%unit
+	switch {
+	case 2*rem >= unit:
+		n++
+	case 2*rem <= -unit:
+		n--
+	}
+	if n == 0 && d != 0 {
+		return nil, fmt.Errorf("%s: %q is less than half a %s", path, s, unitName(unit))
+	}
+	return int64(n), nil
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case 24 * time.Hour:
+		return "day"
+	case time.Second:
+		return "second"
+	}
+	return "nanosecond"
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptedPrefix starts secret values encrypted with EncryptSecret.
+const EncryptedPrefix = "enc:v1:"
+
+// GenerateKey returns a new random AES-256 key, base64 encoded as expected
+// in encryption.key_file.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ReadKeyFile reads the base64 AES-256 key in the file at path.
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s does not hold a base64 encoded 32-byte key", path)
+	}
+	return key, nil
+}
+
+// EncryptSecret encrypts plaintext with AES-256-GCM under key. The result
+// starts with EncryptedPrefix and can replace the secret in the config file.
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret reverses EncryptSecret.
+func decryptSecret(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt with encryption.key_file (wrong key?)")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptSecrets replaces each encrypted secret with its plaintext. The key
+// file is only read if a secret is encrypted.
+func decryptSecrets(cfg *Config) error {
+	var key []byte
+	for _, s := range secretFields(cfg) {
+		if !strings.HasPrefix(*s.value, EncryptedPrefix) {
+			continue
+		}
+		if key == nil {
+			if cfg.Encryption.KeyFile == "" {
+				return fmt.Errorf("%s is encrypted, but encryption.key_file is not set", s.key)
+			}
+			var err error
+			if key, err = ReadKeyFile(cfg.Encryption.KeyFile); err != nil {
+				return fmt.Errorf("reading encryption.key_file: %w", err)
+			}
+		}
+		plaintext, err := decryptSecret(key, *s.value)
+		if err != nil {
+			return fmt.Errorf("decrypting %s: %w", s.key, err)
+		}
+		*s.value = plaintext
+	}
+	return nil
+}
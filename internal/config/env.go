@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/providers/env/v2"
+)
+
+// EnvPrefix starts the environment variables that override configuration
+// keys. The rest of the name is the key in upper case with "." written as
+// "_", e.g. OQBRIDGE_OPENSEARCH_PASSWORD sets opensearch.password.
+const EnvPrefix = "OQBRIDGE_"
+
+// envKey is a configuration key that can be set from the environment.
+type envKey struct {
+	path string // koanf path, e.g. "migration.batch_size"
+	list bool   // the value is a comma-separated list
+}
+
+// envProvider reads OQBRIDGE_ variables that name a known configuration
+// key. Because "_" is both the separator and part of key names, variables
+// are matched against the keys of Config rather than split. Keys inside
+// maps (e.g. migration.index_overrides) cannot be set this way, and other
+// OQBRIDGE_ variables are ignored.
+func envProvider() *env.Env {
+	keys := make(map[string]envKey)
+	collectEnvKeys(reflect.TypeOf(Config{}), "", keys)
+	return env.Provider(".", env.Opt{
+		Prefix: EnvPrefix,
+		TransformFunc: func(name, value string) (string, any) {
+			key, ok := keys[strings.TrimPrefix(name, EnvPrefix)]
+			if !ok {
+				return "", nil
+			}
+			if key.list {
+				items := strings.Split(value, ",")
+				for i := range items {
+					items[i] = strings.TrimSpace(items[i])
+				}
+				return key.path, items
+			}
+			return key.path, value
+		},
+	})
+}
+
+// collectEnvKeys adds every settable key of struct type t, below prefix, to
+// keys under its environment variable name.
+func collectEnvKeys(t reflect.Type, prefix string, keys map[string]envKey) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("koanf"), ",")
+		if f.Anonymous && opts == "squash" {
+			collectEnvKeys(f.Type, prefix, keys)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			collectEnvKeys(f.Type, path, keys)
+		case reflect.Map:
+			// Map keys are user-defined, so there is no variable to look for.
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.String {
+				keys[envName(path)] = envKey{path: path, list: true}
+			}
+		default:
+			keys[envName(path)] = envKey{path: path}
+		}
+	}
+}
+
+func envName(path string) string {
+	return strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
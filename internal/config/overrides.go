@@ -0,0 +1,17 @@
+package config
+
+import "flag"
+
+// Overrides sets configuration keys from the command line, keyed by their
+// path, e.g. {"migration.workers": "8"}. Values are parsed like those of
+// environment variables, and they take precedence over the environment and
+// the files.
+type Overrides map[string]string
+
+// Flag defines a flag named name on fs that, when given, overrides key.
+func (o Overrides) Flag(fs *flag.FlagSet, name, key, usage string) {
+	fs.Func(name, usage+" (overrides "+key+")", func(value string) error {
+		o[key] = value
+		return nil
+	})
+}
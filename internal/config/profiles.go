@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+var profilesType = reflect.TypeOf(map[string]map[string]any(nil))
+
+// applyProfile merges the entry of profiles named by the profile key into
+// k. The name is taken from top (the environment and the command line) if
+// set there, else from k. A profile sets keys like the rest of the file,
+// but cannot select or define profiles itself.
+func applyProfile(k, top *koanf.Koanf) error {
+	name := top.String("profile")
+	if name == "" {
+		name = k.String("profile")
+	}
+	if name == "" {
+		return nil
+	}
+	profiles := k.MapKeys("profiles")
+	if !slices.Contains(profiles, name) {
+		if len(profiles) == 0 {
+			return fmt.Errorf("profile %q selected, but no profiles are defined", name)
+		}
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(profiles, ", "))
+	}
+	profile := k.Cut("profiles." + name)
+	if profile.Exists("profile") || profile.Exists("profiles") {
+		return fmt.Errorf("profiles.%s: a profile cannot set profile or profiles", name)
+	}
+	return k.Merge(profile)
+}
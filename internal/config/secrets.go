@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// secretFile is a secret that may be read from its *_file key.
+type secretFile struct {
+	key   string
+	value *string
+	file  string
+}
+
+// secretFields lists the secrets of cfg with their *_file keys.
+func secretFields(cfg *Config) []secretFile {
+	secrets := []secretFile{
+		{"opensearch.password", &cfg.OpenSearch.Password, cfg.OpenSearch.PasswordFile},
+		{"quickwit.password", &cfg.Quickwit.Password, cfg.Quickwit.PasswordFile},
+		{"quickwit.auth.bearer_token", &cfg.Quickwit.Auth.BearerToken, cfg.Quickwit.Auth.BearerTokenFile},
+		{"notifications.slack.webhook_url", &cfg.Notifications.Slack.WebhookURL, cfg.Notifications.Slack.WebhookURLFile},
+		{"notifications.email.password", &cfg.Notifications.Email.Password, cfg.Notifications.Email.PasswordFile},
+		{"vault.token", &cfg.Vault.Token, cfg.Vault.TokenFile},
+		{"vault.secret_id", &cfg.Vault.SecretID, cfg.Vault.SecretIDFile},
+		{"grpc.token", &cfg.GRPC.Token, cfg.GRPC.TokenFile},
+	}
+	for i := range cfg.Migration.Sources {
+		oc := &cfg.Migration.Sources[i].OpenSearch
+		secrets = append(secrets, secretFile{fmt.Sprintf("migration.sources[%d].opensearch.password", i), &oc.Password, oc.PasswordFile})
+	}
+	for i := range cfg.Server.Monitors {
+		m := &cfg.Server.Monitors[i]
+		secrets = append(secrets, secretFile{fmt.Sprintf("server.monitors[%d].webhook_url", i), &m.WebhookURL, m.WebhookURLFile})
+	}
+	for i := range cfg.Tiers {
+		oc := &cfg.Tiers[i].OpenSearch
+		secrets = append(secrets, secretFile{fmt.Sprintf("tiers[%d].opensearch.password", i), &oc.Password, oc.PasswordFile})
+	}
+	for i := range cfg.QuickwitClusters {
+		qc := &cfg.QuickwitClusters[i].Quickwit
+		key := fmt.Sprintf("quickwit_clusters[%d].quickwit", i)
+		secrets = append(secrets,
+			secretFile{key + ".password", &qc.Password, qc.PasswordFile},
+			secretFile{key + ".auth.bearer_token", &qc.Auth.BearerToken, qc.Auth.BearerTokenFile},
+		)
+	}
+	return secrets
+}
+
+// readSecretFiles fills each secret from its *_file key, so credentials can
+// come from Kubernetes or Docker secret mounts instead of the config file.
+// Files are read on every Load. A trailing newline is removed.
+func readSecretFiles(cfg *Config) error {
+	for _, s := range secretFields(cfg) {
+		if s.file == "" {
+			continue
+		}
+		if *s.value != "" {
+			return fmt.Errorf("%s and %s_file are mutually exclusive", s.key, s.key)
+		}
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return fmt.Errorf("reading %s_file: %w", s.key, err)
+		}
+		*s.value = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
+}
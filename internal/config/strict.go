@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// unknownKeys returns the paths of the keys in raw, below prefix, that do
+// not name a field of struct type t, in sorted order. Entries of lists and
+// maps of settings (e.g. migration.rules) are checked like the struct they
+// decode into; the keys of other maps (e.g. headers or a rule's filter) are
+// user-defined and accepted as-is.
+func unknownKeys(raw map[string]any, t reflect.Type, prefix string) []string {
+	fields := make(map[string]reflect.Type)
+	collectFieldTypes(t, fields)
+
+	var unknown []string
+	for key, value := range raw {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		ft, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, path)
+			continue
+		}
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft == profilesType:
+			entries, _ := value.(map[string]any)
+			for name, entry := range entries {
+				if m, ok := entry.(map[string]any); ok {
+					unknown = append(unknown, unknownKeys(m, reflect.TypeOf(Config{}), path+"."+name)...)
+				}
+			}
+		case ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}):
+			if m, ok := value.(map[string]any); ok {
+				unknown = append(unknown, unknownKeys(m, ft, path)...)
+			}
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			items, _ := value.([]any)
+			for i, item := range items {
+				if m, ok := item.(map[string]any); ok {
+					unknown = append(unknown, unknownKeys(m, ft.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+				}
+			}
+		case ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct:
+			entries, _ := value.(map[string]any)
+			for name, entry := range entries {
+				if m, ok := entry.(map[string]any); ok {
+					unknown = append(unknown, unknownKeys(m, ft.Elem(), path+"."+name)...)
+				}
+			}
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// collectFieldTypes adds the configuration keys of struct type t, including
+// those of squashed embedded structs, to fields.
+func collectFieldTypes(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("koanf"), ",")
+		if f.Anonymous && opts == "squash" {
+			collectFieldTypes(f.Type, fields)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchInterval is how often Watcher.Run checks the configuration file.
+const WatchInterval = 5 * time.Second
+
+// staticKeys are the configuration keys that are only read at startup:
+// connections, listeners, schedules and optional components. A reload keeps
+// their running values and warns if the file changed them.
+var staticKeys = []struct {
+	key   string
+	field func(*Config) any // pointer to the field
+}{
+	{"server", func(c *Config) any { return &c.Server }},
+	{"opensearch", func(c *Config) any { return &c.OpenSearch }},
+	{"quickwit", func(c *Config) any { return &c.Quickwit }},
+	{"quickwit_clusters", func(c *Config) any { return &c.QuickwitClusters }},
+	{"vault", func(c *Config) any { return &c.Vault }},
+	{"notifications", func(c *Config) any { return &c.Notifications }},
+	{"dashboard", func(c *Config) any { return &c.Dashboard }},
+	{"grpc", func(c *Config) any { return &c.GRPC }},
+	{"chaos", func(c *Config) any { return &c.Chaos }},
+	{"retention.enforce.enabled", func(c *Config) any { return &c.Retention.Enforce.Enabled }},
+	{"retention.enforce.schedule", func(c *Config) any { return &c.Retention.Enforce.Schedule }},
+	{"retention.ttl.enabled", func(c *Config) any { return &c.Retention.TTL.Enabled }},
+	{"retention.ttl.schedule", func(c *Config) any { return &c.Retention.TTL.Schedule }},
+	{"retention.runtime", func(c *Config) any { return &c.Retention.Runtime }},
+	{"dual_write.enabled", func(c *Config) any { return &c.DualWrite.Enabled }},
+	{"dual_write.buffer_docs", func(c *Config) any { return &c.DualWrite.BufferDocs }},
+	{"migration.schedule", func(c *Config) any { return &c.Migration.Schedule }},
+	{"migration.reconcile.enabled", func(c *Config) any { return &c.Migration.Reconcile.Enabled }},
+	{"migration.reconcile.schedule", func(c *Config) any { return &c.Migration.Reconcile.Schedule }},
+	{"migration.state_backup.enabled", func(c *Config) any { return &c.Migration.StateBackup.Enabled }},
+	{"migration.state_backup.schedule", func(c *Config) any { return &c.Migration.StateBackup.Schedule }},
+	{"migration.checkpoint_dir", func(c *Config) any { return &c.Migration.CheckpointDir }},
+	{"migration.metrics_listen", func(c *Config) any { return &c.Migration.MetricsListen }},
+	{"migration.grpc_listen", func(c *Config) any { return &c.Migration.GRPCListen }},
+	{"migration.dedup", func(c *Config) any { return &c.Migration.Dedup }},
+	{"migration.lock_ttl", func(c *Config) any { return &c.Migration.LockTTL }},
+	{"migration.health_gate.enabled", func(c *Config) any { return &c.Migration.HealthGate.Enabled }},
+	{"migration.snapshot", func(c *Config) any { return &c.Migration.Snapshot }},
+	{"migration.sources", func(c *Config) any { return &c.Migration.Sources }},
+	{"tiers", func(c *Config) any { return &c.Tiers }},
+	{"remote_clusters", func(c *Config) any { return &c.RemoteClusters }},
+}
+
+// Watcher reloads the configuration file while the process runs. A new
+// version is loaded and validated like at startup; if that fails, the
+// running configuration is kept. Otherwise static keys (see staticKeys)
+// keep their running values and the result replaces the configuration as a
+// whole, so readers never see a mix of old and new values.
+type Watcher struct {
+	path     string
+	current  atomic.Pointer[Config]
+	onReload []func(*Config)
+
+	mu      sync.Mutex // serializes reloads
+	loaded  Config     // file contents as of the last successful load
+	files   map[string]fileState
+	flags   Overrides // command-line overrides
+	runtime Overrides // see SetRuntimeOverrides
+}
+
+// fileState is what Watcher.Run compares to detect a changed file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFiles returns the state of each of paths that exists.
+func statFiles(paths []string) map[string]fileState {
+	files := make(map[string]fileState, len(paths))
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil {
+			files[p] = fileState{fi.ModTime(), fi.Size()}
+		}
+	}
+	return files
+}
+
+// NewWatcher returns a Watcher for path, from which cfg was just loaded.
+// Call it before cfg is adjusted at startup (user agent, Vault credentials,
+// command-line flags), so those adjustments are not reported as changes.
+func NewWatcher(path string, cfg *Config) *Watcher {
+	w := &Watcher{path: path, loaded: *cfg, files: statFiles(cfg.Sources()), flags: cfg.overrides}
+	w.current.Store(cfg)
+	return w
+}
+
+// Config returns the current configuration. It must not be modified.
+func (w *Watcher) Config() *Config {
+	return w.current.Load()
+}
+
+// OnReload registers fn to be called with each reloaded configuration, in
+// registration order, before Config returns it. Callbacks may adjust it,
+// e.g. to re-apply command-line overrides.
+func (w *Watcher) OnReload(fn func(cfg *Config)) {
+	w.onReload = append(w.onReload, fn)
+}
+
+// Reload loads the configuration file again and, if it is valid, makes it
+// the current configuration.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reload(w.runtime)
+}
+
+// SetRuntimeOverrides reloads the configuration with overrides applied on
+// top of the command-line ones, and on every reload after. They set keys
+// that are changed while the process runs, such as retention.days through
+// retention.runtime. If the result is invalid, the running configuration
+// and the previous runtime overrides are kept.
+func (w *Watcher) SetRuntimeOverrides(overrides Overrides) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.reload(overrides); err != nil {
+		return err
+	}
+	w.runtime = overrides
+	return nil
+}
+
+// CheckRuntimeOverrides reports whether the configuration file, loaded
+// with overrides as by SetRuntimeOverrides, is valid, without applying it.
+func (w *Watcher) CheckRuntimeOverrides(overrides Overrides) error {
+	_, err := LoadWithOverrides(w.path, w.withFlags(overrides))
+	return err
+}
+
+// withFlags returns the command-line overrides with runtime on top.
+func (w *Watcher) withFlags(runtime Overrides) Overrides {
+	if len(runtime) == 0 {
+		return w.flags
+	}
+	all := maps.Clone(w.flags)
+	if all == nil {
+		all = make(Overrides, len(runtime))
+	}
+	maps.Copy(all, runtime)
+	return all
+}
+
+func (w *Watcher) reload(runtime Overrides) error {
+	// Record the state first, so a broken file is not reloaded again until
+	// it changes.
+	w.files = statFiles(slices.Collect(maps.Keys(w.files)))
+	next, err := LoadWithOverrides(w.path, w.withFlags(runtime))
+	if err != nil {
+		return err
+	}
+	w.files = statFiles(next.Sources())
+	loaded := *next
+
+	cur := w.current.Load()
+	var ignored []string
+	for _, s := range staticKeys {
+		if !reflect.DeepEqual(s.field(&w.loaded), s.field(next)) {
+			ignored = append(ignored, s.key)
+		}
+		reflect.ValueOf(s.field(next)).Elem().Set(reflect.ValueOf(s.field(cur)).Elem())
+	}
+	if len(ignored) > 0 {
+		slog.Warn("configuration changes that require a restart were not applied", "keys", ignored)
+	}
+
+	for _, fn := range w.onReload {
+		fn(next)
+	}
+	w.loaded = loaded
+	w.current.Store(next)
+	return nil
+}
+
+// Run reloads the configuration until ctx is done: whenever the
+// modification time or size of the file, an included file or an included
+// directory changed, checked every WatchInterval, and whenever a signal
+// arrives on hup. Failed reloads are logged.
+func (w *Watcher) Run(ctx context.Context, hup <-chan os.Signal) {
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+		}
+		if err := w.Reload(); err != nil {
+			slog.Error("configuration reload failed, keeping the running configuration", "path", w.path, "error", err)
+			continue
+		}
+		slog.Info("configuration reloaded", "path", w.path)
+	}
+}
+
+func (w *Watcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for p, st := range w.files {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !fi.ModTime().Equal(st.modTime) || fi.Size() != st.size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watchBaseConfig = `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+migration:
+  migrate_after_days: 7
+  schedule: "0 * * * *"
+`
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	if err := os.WriteFile(path, []byte(watchBaseConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	w := NewWatcher(path, cfg)
+	cfg.OpenSearch.UserAgent = "oqbridge/test"
+
+	var reloaded *Config
+	w.OnReload(func(c *Config) { reloaded = c })
+
+	edited := strings.NewReplacer(
+		"days: 30", "days: 60",
+		"http://os:9200", "http://other:9200",
+		"0 * * * *", "*/5 * * * *",
+	).Replace(watchBaseConfig)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	got := w.Config()
+	if reloaded != got {
+		t.Error("OnReload callback not called with the new configuration")
+	}
+	if got.Retention.Days != 60 {
+		t.Errorf("retention.days = %d, want 60", got.Retention.Days)
+	}
+	// Static keys keep their running values, including startup adjustments.
+	if got.OpenSearch.URL != "http://os:9200" || got.OpenSearch.UserAgent != "oqbridge/test" {
+		t.Errorf("opensearch = %q (%q), want the running values", got.OpenSearch.URL, got.OpenSearch.UserAgent)
+	}
+	if got.Migration.Schedule != "0 * * * *" {
+		t.Errorf("migration.schedule = %q, want the running value", got.Migration.Schedule)
+	}
+}
+
+func TestWatcher_ReloadKeepsConfigOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	os.WriteFile(path, []byte(watchBaseConfig), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	w := NewWatcher(path, cfg)
+	w.OnReload(func(*Config) { t.Error("OnReload called for an invalid configuration") })
+
+	// migrate_after_days must be below retention.days.
+	os.WriteFile(path, []byte(strings.Replace(watchBaseConfig, "migrate_after_days: 7", "migrate_after_days: 45", 1)), 0o644)
+	if err := w.Reload(); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if w.Config() != cfg {
+		t.Error("running configuration replaced by an invalid one")
+	}
+}
+
+func TestWatcher_ReloadKeepsOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	if err := os.WriteFile(path, []byte(watchBaseConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadWithOverrides(path, Overrides{"retention.days": "45"})
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	w := NewWatcher(path, cfg)
+
+	edited := strings.Replace(watchBaseConfig, "migrate_after_days: 7", "migrate_after_days: 9", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := w.Config(); got.Retention.Days != 45 || got.Migration.MigrateAfterDays != 9 {
+		t.Errorf("retention.days = %d, migrate_after_days = %d; want 45 and 9", got.Retention.Days, got.Migration.MigrateAfterDays)
+	}
+}
+
+func TestWatcher_SetRuntimeOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	if err := os.WriteFile(path, []byte(watchBaseConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadWithOverrides(path, Overrides{"migration.workers": "3"})
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	w := NewWatcher(path, cfg)
+
+	// migrate_after_days must stay below retention.days.
+	if err := w.CheckRuntimeOverrides(Overrides{"retention.days": "5"}); err == nil {
+		t.Error("CheckRuntimeOverrides() accepted retention.days below migrate_after_days")
+	}
+	if err := w.SetRuntimeOverrides(Overrides{"retention.days": "5"}); err == nil || w.Config() != cfg {
+		t.Errorf("SetRuntimeOverrides() error = %v, want an error and the running configuration kept", err)
+	}
+
+	if err := w.SetRuntimeOverrides(Overrides{"retention.days": "20"}); err != nil {
+		t.Fatalf("SetRuntimeOverrides() error = %v", err)
+	}
+	if got := w.Config(); got.Retention.Days != 20 || got.Migration.Workers != 3 {
+		t.Errorf("retention.days = %d, migration.workers = %d; want 20 and 3", got.Retention.Days, got.Migration.Workers)
+	}
+
+	// Runtime overrides outlive edits of the file, until they are cleared.
+	edited := strings.Replace(watchBaseConfig, "migrate_after_days: 7", "migrate_after_days: 9", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := w.Config(); got.Retention.Days != 20 || got.Migration.MigrateAfterDays != 9 {
+		t.Errorf("retention.days = %d, migrate_after_days = %d; want 20 and 9", got.Retention.Days, got.Migration.MigrateAfterDays)
+	}
+	if err := w.SetRuntimeOverrides(nil); err != nil {
+		t.Fatalf("SetRuntimeOverrides(nil) error = %v", err)
+	}
+	if got := w.Config(); got.Retention.Days != 30 || got.Migration.Workers != 3 {
+		t.Errorf("retention.days = %d, migration.workers = %d; want 30 and 3", got.Retention.Days, got.Migration.Workers)
+	}
+}
+
+func TestWatcher_Changed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	os.WriteFile(path, []byte(watchBaseConfig), 0o644)
+	cfg, _ := Load(path)
+	w := NewWatcher(path, cfg)
+	if w.changed() {
+		t.Fatal("changed() = true for an untouched file")
+	}
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	if !w.changed() {
+		t.Fatal("changed() = false after the file was modified")
+	}
+}
+
+func TestWatcher_ChangedInclude(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.yaml")
+	os.WriteFile(shared, []byte(watchBaseConfig), 0o644)
+	path := filepath.Join(dir, "oqbridge.yaml")
+	os.WriteFile(path, []byte("include: [shared.yaml]\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	w := NewWatcher(path, cfg)
+	os.Chtimes(shared, time.Now(), time.Now().Add(time.Minute))
+	if !w.changed() {
+		t.Fatal("changed() = false after an included file was modified")
+	}
+}
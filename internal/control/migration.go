@@ -0,0 +1,269 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
+	"github.com/leonunix/oqbridge/internal/migration"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Errors returned by Runner when it does not start a run.
+var (
+	ErrPaused  = errors.New("migration is paused")
+	ErrRunning = errors.New("a migration run is in progress")
+)
+
+// Run triggers recorded by Runner.
+const (
+	TriggerSchedule = "schedule"
+	TriggerAPI      = "trigger"
+)
+
+// RunFunc migrates every source once.
+type RunFunc func(ctx context.Context) (*migration.RunReport, error)
+
+// Runner runs the migrations of a daemon one at a time, whether the
+// schedule or the API starts them, and skips them while paused.
+type Runner struct {
+	run RunFunc
+
+	mu      sync.Mutex
+	paused  bool
+	current *RunningRun
+	cancel  context.CancelFunc // of the current run
+	last    *migration.RunReport
+}
+
+// RunningRun is the run in progress.
+type RunningRun struct {
+	StartedAt time.Time
+	Trigger   string
+}
+
+// RunnerState is a snapshot of a Runner.
+type RunnerState struct {
+	Paused  bool
+	Running *RunningRun          // nil when idle
+	Last    *migration.RunReport // last finished run, nil before the first
+}
+
+// NewRunner returns a Runner that migrates with run.
+func NewRunner(run RunFunc) *Runner {
+	return &Runner{run: run}
+}
+
+// Run migrates and waits for the run to finish. It returns ErrPaused or
+// ErrRunning instead when paused or already running.
+func (r *Runner) Run(ctx context.Context, trigger string) (*migration.RunReport, error) {
+	ctx, _, err := r.begin(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+	report, err := r.run(ctx)
+	r.end(report)
+	return report, err
+}
+
+// Start is Run in the background. It returns when the run started, or
+// ErrPaused or ErrRunning.
+func (r *Runner) Start(trigger string) (time.Time, error) {
+	ctx, started, err := r.begin(context.Background(), trigger)
+	if err != nil {
+		return time.Time{}, err
+	}
+	go func() {
+		report, err := r.run(ctx)
+		r.end(report)
+		if err != nil {
+			slog.Error("migration failed", "trigger", trigger, "error", err)
+		}
+	}()
+	return started, nil
+}
+
+// begin marks a run as started and returns its context and start time.
+func (r *Runner) begin(ctx context.Context, trigger string) (context.Context, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused {
+		return nil, time.Time{}, ErrPaused
+	}
+	if r.current != nil {
+		return nil, time.Time{}, ErrRunning
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.current = &RunningRun{StartedAt: time.Now().UTC(), Trigger: trigger}
+	return ctx, r.current.StartedAt, nil
+}
+
+func (r *Runner) end(report *migration.RunReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel()
+	r.current, r.cancel = nil, nil
+	if report != nil {
+		r.last = report
+	}
+}
+
+// Pause skips runs until Resume. With cancelRunning, the run in progress
+// is cancelled too; its indices keep their checkpoints. It reports whether
+// a run was cancelled.
+func (r *Runner) Pause(cancelRunning bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = true
+	if cancelRunning && r.cancel != nil {
+		r.cancel()
+		return true
+	}
+	return false
+}
+
+// Resume lifts Pause.
+func (r *Runner) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = false
+}
+
+// State returns a snapshot of r.
+func (r *Runner) State() RunnerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := RunnerState{Paused: r.paused, Last: r.last}
+	if r.current != nil {
+		running := *r.current
+		st.Running = &running
+	}
+	return st
+}
+
+// SourceState is the stored migration state of the indices of one source.
+type SourceState struct {
+	Name    string
+	Indices []migration.IndexStatus
+	Err     error // why Indices could not be read
+}
+
+// migrationService implements oqbridgev1.MigrationServiceServer.
+type migrationService struct {
+	oqbridgev1.UnimplementedMigrationServiceServer
+	runner  *Runner
+	sources func(ctx context.Context) []SourceState
+}
+
+// NewMigrationService returns the MigrationService of a daemon that runs
+// migrations with runner and reads their stored state with sources.
+func NewMigrationService(runner *Runner, sources func(ctx context.Context) []SourceState) oqbridgev1.MigrationServiceServer {
+	return &migrationService{runner: runner, sources: sources}
+}
+
+func (s *migrationService) TriggerMigration(context.Context, *oqbridgev1.TriggerMigrationRequest) (*oqbridgev1.TriggerMigrationResponse, error) {
+	started, err := s.runner.Start(TriggerAPI)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	slog.Info("migration triggered through the API")
+	return &oqbridgev1.TriggerMigrationResponse{StartedAt: timestamppb.New(started)}, nil
+}
+
+func (s *migrationService) PauseMigration(_ context.Context, req *oqbridgev1.PauseMigrationRequest) (*oqbridgev1.PauseMigrationResponse, error) {
+	cancelled := s.runner.Pause(req.GetCancelRunning())
+	slog.Info("migration paused through the API", "cancelled_run", cancelled)
+	return &oqbridgev1.PauseMigrationResponse{CancelledRun: cancelled}, nil
+}
+
+func (s *migrationService) ResumeMigration(context.Context, *oqbridgev1.ResumeMigrationRequest) (*oqbridgev1.ResumeMigrationResponse, error) {
+	s.runner.Resume()
+	slog.Info("migration resumed through the API")
+	return &oqbridgev1.ResumeMigrationResponse{}, nil
+}
+
+func (s *migrationService) GetMigrationStatus(ctx context.Context, _ *oqbridgev1.GetMigrationStatusRequest) (*oqbridgev1.GetMigrationStatusResponse, error) {
+	st := s.runner.State()
+	resp := &oqbridgev1.GetMigrationStatusResponse{Paused: st.Paused}
+	if st.Running != nil {
+		resp.Running = &oqbridgev1.RunningMigration{StartedAt: timestamppb.New(st.Running.StartedAt), Trigger: st.Running.Trigger}
+	}
+	if st.Last != nil {
+		resp.LastRun = runToProto(st.Last)
+	}
+	for _, src := range s.sources(ctx) {
+		ps := &oqbridgev1.SourceMigrationStatus{Name: src.Name}
+		if src.Err != nil {
+			ps.Error = src.Err.Error()
+		}
+		for _, ix := range src.Indices {
+			ps.Indices = append(ps.Indices, indexToProto(ix))
+		}
+		resp.Sources = append(resp.Sources, ps)
+	}
+	return resp, nil
+}
+
+func runToProto(r *migration.RunReport) *oqbridgev1.MigrationRun {
+	run := &oqbridgev1.MigrationRun{
+		Outcome:           r.Outcome,
+		StartedAt:         timestamppb.New(r.StartedAt),
+		CompletedAt:       timestamppb.New(r.CompletedAt),
+		DocumentsMigrated: r.Migrated,
+		Error:             r.Error,
+	}
+	for _, res := range r.Indices {
+		run.Indices = append(run.Indices, &oqbridgev1.IndexRunResult{
+			Index:             res.Index,
+			Source:            res.Source,
+			Status:            res.Status,
+			Reason:            res.Reason,
+			DocumentsMigrated: res.Migrated,
+			Error:             res.Error,
+		})
+	}
+	return run
+}
+
+func indexToProto(ix migration.IndexStatus) *oqbridgev1.IndexMigrationStatus {
+	p := &oqbridgev1.IndexMigrationStatus{Index: ix.Index}
+	if ix.State != nil {
+		if wm := ix.State.Watermark; wm != nil {
+			p.MigratedBefore = timestamppb.New(wm.MigratedBefore)
+		}
+		if cp := ix.State.Checkpoint; cp != nil {
+			p.Checkpoint = &oqbridgev1.MigrationCheckpoint{
+				Completed: cp.Completed,
+				TotalDocs: cp.TotalDocs,
+				Migrated:  cp.Migrated,
+				Cutoff:    timestamppb.New(cp.CutoffTime),
+				UpdatedAt: timestamppb.New(cp.UpdatedAt),
+			}
+		}
+	}
+	if ix.Runs != nil {
+		p.TotalMigrated = ix.Runs.TotalMigrated
+		if last := ix.Runs.LastRun; last != nil {
+			p.LastRun = &oqbridgev1.RecordedRun{
+				StartedAt:         timestamppb.New(last.StartedAt),
+				Status:            last.Status,
+				DocumentsMigrated: last.DocumentsMigrated,
+				Error:             last.Error,
+			}
+		}
+	}
+	if ix.Lock != nil {
+		p.Lock = &oqbridgev1.MigrationLock{
+			Owner:      ix.Lock.Owner,
+			AcquiredAt: timestamppb.New(ix.Lock.AcquiredAt),
+			ExpiresAt:  timestamppb.New(ix.Lock.ExpiresAt),
+		}
+	}
+	return p
+}
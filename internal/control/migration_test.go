@@ -0,0 +1,139 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blockingRun returns a RunFunc that signals started and then waits for
+// release or cancellation.
+func blockingRun(started chan<- struct{}, release <-chan struct{}) RunFunc {
+	return func(ctx context.Context) (*migration.RunReport, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return &migration.RunReport{Outcome: migration.OutcomeSuccess, Migrated: 10}, nil
+		case <-ctx.Done():
+			return &migration.RunReport{Outcome: migration.OutcomeFailed}, ctx.Err()
+		}
+	}
+}
+
+// waitIdle waits until r has no run in progress.
+func waitIdle(t *testing.T, r *Runner) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for r.State().Running != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("run did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := NewRunner(blockingRun(started, release))
+
+	if _, err := r.Start(TriggerAPI); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	<-started
+	if st := r.State(); st.Running == nil || st.Running.Trigger != TriggerAPI {
+		t.Errorf("state while running = %+v", st)
+	}
+	if _, err := r.Run(context.Background(), TriggerSchedule); !errors.Is(err, ErrRunning) {
+		t.Errorf("Run() during a run = %v, want ErrRunning", err)
+	}
+	close(release)
+	waitIdle(t, r)
+	if st := r.State(); st.Last == nil || st.Last.Migrated != 10 {
+		t.Errorf("last run = %+v", st.Last)
+	}
+
+	if r.Pause(true) {
+		t.Error("Pause() cancelled a run while idle")
+	}
+	if _, err := r.Run(context.Background(), TriggerSchedule); !errors.Is(err, ErrPaused) {
+		t.Errorf("Run() while paused = %v, want ErrPaused", err)
+	}
+	r.Resume()
+	go func() { <-started }()
+	if report, err := r.Run(context.Background(), TriggerSchedule); err != nil || report.Outcome != migration.OutcomeSuccess {
+		t.Errorf("Run() after Resume() = %+v, %v", report, err)
+	}
+}
+
+func TestRunner_PauseCancelsRun(t *testing.T) {
+	started := make(chan struct{})
+	r := NewRunner(blockingRun(started, nil))
+	if _, err := r.Start(TriggerAPI); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	<-started
+	if !r.Pause(true) {
+		t.Error("Pause(true) did not report the cancelled run")
+	}
+	waitIdle(t, r)
+	if st := r.State(); !st.Paused || st.Last.Outcome != migration.OutcomeFailed {
+		t.Errorf("state after cancelling = %+v", st)
+	}
+}
+
+func TestMigrationService(t *testing.T) {
+	r := NewRunner(func(context.Context) (*migration.RunReport, error) {
+		return &migration.RunReport{Outcome: migration.OutcomeSuccess}, nil
+	})
+	watermark := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	svc := NewMigrationService(r, func(context.Context) []SourceState {
+		return []SourceState{
+			{Name: "eu", Indices: []migration.IndexStatus{{
+				Index: "logs",
+				State: &migration.IndexState{Watermark: &migration.Watermark{MigratedBefore: watermark}},
+				Lock:  &backend.LockInfo{Owner: "host-1"},
+			}}},
+			{Name: "us", Err: errors.New("connection refused")},
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := svc.PauseMigration(ctx, &oqbridgev1.PauseMigrationRequest{}); err != nil {
+		t.Fatalf("PauseMigration() error: %v", err)
+	}
+	if _, err := svc.TriggerMigration(ctx, &oqbridgev1.TriggerMigrationRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("TriggerMigration() while paused = %v, want FailedPrecondition", err)
+	}
+	resp, err := svc.GetMigrationStatus(ctx, &oqbridgev1.GetMigrationStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() error: %v", err)
+	}
+	if !resp.GetPaused() || len(resp.GetSources()) != 2 {
+		t.Fatalf("status = %v", resp)
+	}
+	ix := resp.GetSources()[0].GetIndices()[0]
+	if !ix.GetMigratedBefore().AsTime().Equal(watermark) || ix.GetLock().GetOwner() != "host-1" || ix.GetCheckpoint() != nil {
+		t.Errorf("index status = %v", ix)
+	}
+	if resp.GetSources()[1].GetError() != "connection refused" {
+		t.Errorf("source error = %q", resp.GetSources()[1].GetError())
+	}
+
+	svc.ResumeMigration(ctx, &oqbridgev1.ResumeMigrationRequest{})
+	if _, err := svc.TriggerMigration(ctx, &oqbridgev1.TriggerMigrationRequest{}); err != nil {
+		t.Fatalf("TriggerMigration() error: %v", err)
+	}
+	waitIdle(t, r)
+	resp, _ = svc.GetMigrationStatus(ctx, &oqbridgev1.GetMigrationStatusRequest{})
+	if resp.GetLastRun().GetOutcome() != migration.OutcomeSuccess {
+		t.Errorf("last run = %v", resp.GetLastRun())
+	}
+}
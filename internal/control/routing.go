@@ -0,0 +1,69 @@
+package control
+
+import (
+	"context"
+	"time"
+
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/dashboard"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SearchRouter is the part of the proxy the RoutingService answers from.
+type SearchRouter interface {
+	// Config returns the running configuration.
+	Config() *config.Config
+	// RouteSearch returns how a search of indices with body is routed and
+	// the backends it reaches.
+	RouteSearch(indices []string, body []byte) (target string, backends []string)
+}
+
+// routingService implements oqbridgev1.RoutingServiceServer.
+type routingService struct {
+	oqbridgev1.UnimplementedRoutingServiceServer
+	router SearchRouter
+}
+
+// NewRoutingService returns the RoutingService of a proxy.
+func NewRoutingService(router SearchRouter) oqbridgev1.RoutingServiceServer {
+	return &routingService{router: router}
+}
+
+func (s *routingService) GetIndexRouting(_ context.Context, req *oqbridgev1.GetIndexRoutingRequest) (*oqbridgev1.GetIndexRoutingResponse, error) {
+	index := req.GetIndex()
+	if index == "" {
+		return nil, status.Error(codes.InvalidArgument, "index is required")
+	}
+	cfg := s.router.Config()
+	qwIndex := cfg.QuickwitIndexForIndex(index)
+	resp := &oqbridgev1.GetIndexRoutingResponse{
+		Index:           index,
+		TimestampField:  cfg.TimestampFieldForIndex(index),
+		HotDays:         int32(cfg.HotDaysForIndex(index)),
+		ColdDays:        int32(cfg.ColdDaysForIndex(index)),
+		QuickwitIndex:   qwIndex,
+		QuickwitCluster: cfg.QuickwitClusterForIndex(qwIndex),
+		DualWrite:       cfg.DualWriteIndex(index),
+		LateWrites:      cfg.LateWriteIndex(index),
+	}
+	for _, seg := range dashboard.Layout(cfg, index, time.Now()).Segments {
+		ps := &oqbridgev1.RoutingSegment{Backend: seg.Backend}
+		if seg.From != nil {
+			ps.From = timestamppb.New(*seg.From)
+		}
+		if seg.To != nil {
+			ps.To = timestamppb.New(*seg.To)
+		}
+		resp.Segments = append(resp.Segments, ps)
+	}
+	return resp, nil
+}
+
+func (s *routingService) RouteSearch(_ context.Context, req *oqbridgev1.RouteSearchRequest) (*oqbridgev1.RouteSearchResponse, error) {
+	target, backends := s.router.RouteSearch(req.GetIndices(), req.GetQuery())
+	return &oqbridgev1.RouteSearchResponse{Target: target, Backends: backends}, nil
+}
@@ -0,0 +1,77 @@
+// Package control serves the gRPC control-plane API defined in
+// api/oqbridge/v1, so orchestration tools can drive the daemons with typed
+// clients: the MigrationService of oqbridge-migrate, the RoutingService of
+// the proxy, and the standard health service on both.
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns a gRPC server with the health service registered and
+// reporting SERVING. With a token, every call except health checks must
+// carry it in "authorization: Bearer <token>" metadata; probes such as
+// Kubernetes gRPC health checks cannot send one.
+func NewServer(token string) *grpc.Server {
+	auth := authorizer(token)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := auth(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := auth(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	return srv
+}
+
+// authorizer returns the check NewServer applies to each call.
+func authorizer(token string) func(ctx context.Context, method string) error {
+	return func(ctx context.Context, method string) error {
+		if token == "" || strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			got, ok := strings.CutPrefix(v, "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+	}
+}
+
+// Serve serves srv on addr in the background. Failing to listen is logged,
+// not fatal; the caller stops srv on exit.
+func Serve(addr string, srv *grpc.Server) {
+	go func() {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			slog.Error("grpc server error", "error", err)
+			return
+		}
+		slog.Info("grpc listening", "addr", addr)
+		if err := srv.Serve(lis); err != nil {
+			slog.Error("grpc server error", "error", err)
+		}
+	}()
+}
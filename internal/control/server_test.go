@@ -0,0 +1,87 @@
+package control
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	oqbridgev1 "github.com/leonunix/oqbridge/api/oqbridge/v1"
+	"github.com/leonunix/oqbridge/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeRouter struct {
+	cfg *config.Config
+}
+
+func (f fakeRouter) Config() *config.Config { return f.cfg }
+
+func (f fakeRouter) RouteSearch(indices []string, body []byte) (string, []string) {
+	return "both", []string{"opensearch", "quickwit"}
+}
+
+// dial serves srv in memory and returns a connection to it.
+func dial(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer_Token(t *testing.T) {
+	cfg := &config.Config{Retention: config.RetentionConfig{Days: 7, TimestampField: "@timestamp"}}
+	srv := NewServer("s3cret")
+	oqbridgev1.RegisterRoutingServiceServer(srv, NewRoutingService(fakeRouter{cfg}))
+	conn := dial(t, srv)
+	client := oqbridgev1.NewRoutingServiceClient(conn)
+	ctx := context.Background()
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || health.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("health check without token = %v, %v", health, err)
+	}
+
+	req := &oqbridgev1.GetIndexRoutingRequest{Index: "logs"}
+	if _, err := client.GetIndexRouting(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without token = %v, want Unauthenticated", err)
+	}
+	bad := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong")
+	if _, err := client.GetIndexRouting(bad, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call with wrong token = %v, want Unauthenticated", err)
+	}
+
+	good := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	resp, err := client.GetIndexRouting(good, req)
+	if err != nil {
+		t.Fatalf("GetIndexRouting() error: %v", err)
+	}
+	if resp.GetHotDays() != 7 || resp.GetTimestampField() != "@timestamp" || resp.GetQuickwitIndex() != "logs" {
+		t.Errorf("routing = %v", resp)
+	}
+	if segs := resp.GetSegments(); len(segs) != 2 || segs[0].GetBackend() != "opensearch" || segs[1].GetTo() == nil {
+		t.Errorf("segments = %v", segs)
+	}
+	if _, err := client.GetIndexRouting(good, &oqbridgev1.GetIndexRoutingRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("call without index = %v, want InvalidArgument", err)
+	}
+
+	route, err := client.RouteSearch(good, &oqbridgev1.RouteSearchRequest{Indices: []string{"logs"}})
+	if err != nil || route.GetTarget() != "both" || len(route.GetBackends()) != 2 {
+		t.Errorf("RouteSearch() = %v, %v", route, err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package dashboard serves a read-only web page showing the state of an
+// oqbridge daemon: where the data of each index lives, how searches were
+// routed, and the progress and history of migration.
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+//go:embed index.html
+var page []byte
+
+// Status is what a daemon shows on the dashboard. Each daemon fills the
+// sections it knows about; the page hides empty ones.
+type Status struct {
+	Service     string                      `json:"service"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+	Refresh     float64                     `json:"refresh_seconds"`
+	Layout      []IndexLayout               `json:"layout,omitempty"`
+	Routing     map[string]uint64           `json:"routing,omitempty"`
+	Sources     []SourceStatus              `json:"sources,omitempty"`
+	History     []migration.MigrationMetric `json:"history,omitempty"`
+	Errors      []string                    `json:"errors,omitempty"`
+}
+
+// SourceStatus is the migration state of the indices of one source.
+type SourceStatus struct {
+	Name    string                  `json:"name,omitempty"`
+	Indices []migration.IndexStatus `json:"indices"`
+}
+
+// IndexLayout is where the data of an index lives, youngest first.
+type IndexLayout struct {
+	Index    string    `json:"index"`
+	Segments []Segment `json:"segments"`
+}
+
+// Segment is the time range of an index's documents that one backend
+// holds. A nil To means up to now, a nil From since the oldest document.
+type Segment struct {
+	Backend string     `json:"backend"`
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+}
+
+// Layout returns where the documents of index live at now according to
+// cfg: OpenSearch, then each entry of tiers, then Quickwit down to its
+// retention period. Quickwit holds every document of dual_write indices.
+func Layout(cfg *config.Config, index string, now time.Time) IndexLayout {
+	days := cfg.TierDays(index)
+	names := []string{"opensearch"}
+	for _, t := range cfg.Tiers {
+		names = append(names, t.Name)
+	}
+
+	l := IndexLayout{Index: index}
+	var to *time.Time
+	for i, d := range days {
+		from := cfg.DaysAgo(now, d)
+		l.Segments = append(l.Segments, Segment{Backend: names[i], From: &from, To: to})
+		to = &from
+	}
+	cold := Segment{Backend: "quickwit", To: to}
+	if cfg.DualWriteIndex(index) {
+		cold.To = nil
+	}
+	if d := cfg.ColdDaysForIndex(index); d > 0 {
+		from := cfg.DaysAgo(now, d)
+		cold.From = &from
+	}
+	l.Segments = append(l.Segments, cold)
+	return l
+}
+
+// Handler serves the dashboard page at its root and the Status returned by
+// status as JSON at api/status, relative to where it is mounted; use
+// http.StripPrefix to mount it below a path.
+func Handler(cfg config.DashboardConfig, status func(ctx context.Context) *Status) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		st := status(ctx)
+		st.GeneratedAt = time.Now().UTC()
+		st.Refresh = cfg.Refresh.Seconds()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(st); err != nil {
+			slog.Debug("writing dashboard status", "error", err)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Trim(r.URL.Path, "/") != "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	return mux
+}
@@ -0,0 +1,86 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestLayout(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, 15-d, 0, 0, 0, 0, time.UTC) }
+	cfg := &config.Config{
+		Retention: config.RetentionConfig{Days: 7, ColdDays: 365, IndexDays: map[string]int{"audit-*": 30}},
+		Tiers:     []config.TierConfig{{Name: "warm", Days: 60}},
+		DualWrite: config.DualWriteConfig{Enabled: true, Indices: []string{"audit-*"}},
+	}
+
+	l := Layout(cfg, "logs", now)
+	if len(l.Segments) != 3 {
+		t.Fatalf("segments = %+v, want opensearch, warm and quickwit", l.Segments)
+	}
+	hot, warm, cold := l.Segments[0], l.Segments[1], l.Segments[2]
+	if hot.Backend != "opensearch" || hot.To != nil || !hot.From.Equal(day(7)) {
+		t.Errorf("hot segment = %s %v-%v", hot.Backend, hot.From, hot.To)
+	}
+	if warm.Backend != "warm" || !warm.To.Equal(day(7)) || !warm.From.Equal(day(60)) {
+		t.Errorf("warm segment = %s %v-%v", warm.Backend, warm.From, warm.To)
+	}
+	if cold.Backend != "quickwit" || !cold.To.Equal(day(60)) || !cold.From.Equal(day(365)) {
+		t.Errorf("cold segment = %s %v-%v", cold.Backend, cold.From, cold.To)
+	}
+
+	l = Layout(cfg, "audit-1", now)
+	if !l.Segments[0].From.Equal(day(30)) {
+		t.Errorf("audit-1 hot segment starts %v, want its index_days", l.Segments[0].From)
+	}
+	if cold := l.Segments[2]; cold.To != nil {
+		t.Errorf("dual_write cold segment ends %v, want now", cold.To)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(config.DashboardConfig{Refresh: 5 * time.Second}, func(context.Context) *Status {
+		return &Status{Service: "test", Routing: map[string]uint64{"hot": 2}}
+	})
+	srv := httptest.NewServer(http.StripPrefix("/ui", h))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ui/api/status")
+	if err != nil {
+		t.Fatalf("GET status: %v", err)
+	}
+	var st map[string]any
+	json.NewDecoder(resp.Body).Decode(&st)
+	resp.Body.Close()
+	if st["service"] != "test" || st["refresh_seconds"] != 5.0 || st["generated_at"] == nil {
+		t.Errorf("status = %v", st)
+	}
+	if _, ok := st["layout"]; ok {
+		t.Error("status includes the empty layout")
+	}
+
+	resp, err = http.Get(srv.URL + "/ui/")
+	if err != nil {
+		t.Fatalf("GET page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("page: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(srv.URL + "/ui/missing")
+	if err != nil {
+		t.Fatalf("GET missing: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown path: %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,336 @@
+// Package e2e runs the proxy and the migrator against real OpenSearch and
+// Quickwit containers, for end-to-end tests of routing, merging and
+// migration. Starting the containers needs Docker; the tests of this
+// package only run with OQBRIDGE_E2E=1.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/proxy"
+	"github.com/leonunix/oqbridge/internal/util"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Images started by Start, unless OQBRIDGE_E2E_OPENSEARCH_IMAGE or
+// OQBRIDGE_E2E_QUICKWIT_IMAGE name others.
+const (
+	OpenSearchImage = "opensearchproject/opensearch:2.19.1"
+	QuickwitImage   = "quickwit/quickwit:0.8.2"
+)
+
+// Credentials of the OpenSearch admin user, which the proxy's clients and
+// the migrator's service account both use.
+const (
+	Username = "admin"
+	Password = "Oqbridge-e2e-1"
+)
+
+// Env is a running OpenSearch and Quickwit pair.
+type Env struct {
+	OpenSearchURL string
+	QuickwitURL   string
+
+	dir        string // configuration files and checkpoints
+	containers []testcontainers.Container
+	client     *http.Client // trusts OpenSearch's demo certificate
+}
+
+// Start starts OpenSearch, with its security plugin and demo certificate,
+// and Quickwit, and waits until both are ready. Close them with Close.
+func Start(ctx context.Context) (env *Env, err error) {
+	dir, err := os.MkdirTemp("", "oqbridge-e2e-")
+	if err != nil {
+		return nil, err
+	}
+	env = &Env{
+		dir:    dir,
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}},
+	}
+	defer func() {
+		if err != nil {
+			env.Close(context.Background())
+		}
+	}()
+
+	osc, err := env.start(ctx, testcontainers.ContainerRequest{
+		Image:        image("OQBRIDGE_E2E_OPENSEARCH_IMAGE", OpenSearchImage),
+		ExposedPorts: []string{"9200/tcp"},
+		Env: map[string]string{
+			"discovery.type":                    "single-node",
+			"OPENSEARCH_INITIAL_ADMIN_PASSWORD": Password,
+			"OPENSEARCH_JAVA_OPTS":              "-Xms512m -Xmx512m",
+		},
+		WaitingFor: wait.ForHTTP("/_cluster/health?wait_for_status=yellow").
+			WithPort("9200/tcp").
+			WithTLS(true, &tls.Config{InsecureSkipVerify: true}).
+			WithBasicAuth(Username, Password).
+			WithStartupTimeout(3 * time.Minute),
+	})
+	if err != nil {
+		return env, fmt.Errorf("starting OpenSearch: %w", err)
+	}
+	if env.OpenSearchURL, err = osc.PortEndpoint(ctx, "9200/tcp", "https"); err != nil {
+		return env, err
+	}
+
+	qw, err := env.start(ctx, testcontainers.ContainerRequest{
+		Image:        image("OQBRIDGE_E2E_QUICKWIT_IMAGE", QuickwitImage),
+		ExposedPorts: []string{"7280/tcp"},
+		Cmd:          []string{"run"},
+		WaitingFor:   wait.ForHTTP("/health/readyz").WithPort("7280/tcp").WithStartupTimeout(2 * time.Minute),
+	})
+	if err != nil {
+		return env, fmt.Errorf("starting Quickwit: %w", err)
+	}
+	if env.QuickwitURL, err = qw.PortEndpoint(ctx, "7280/tcp", "http"); err != nil {
+		return env, err
+	}
+	return env, nil
+}
+
+func (e *Env) start(ctx context.Context, req testcontainers.ContainerRequest) (testcontainers.Container, error) {
+	c, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{ContainerRequest: req, Started: true})
+	if c != nil {
+		e.containers = append(e.containers, c)
+	}
+	return c, err
+}
+
+func image(env, def string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return def
+}
+
+// Close stops the containers and removes the files written by Config.
+func (e *Env) Close(ctx context.Context) error {
+	var errs []error
+	for _, c := range e.containers {
+		errs = append(errs, c.Terminate(ctx))
+	}
+	errs = append(errs, os.RemoveAll(e.dir))
+	return errors.Join(errs...)
+}
+
+// Config loads a configuration connected to the containers: the
+// opensearch and quickwit sections, followed by extra YAML for the rest.
+// Quickwit commits each ingest at once, so migrated documents are
+// searchable when Migrate returns, and migration checkpoints are kept in
+// a local directory unless extra sets migration.checkpoint_dir.
+func (e *Env) Config(extra string) (*config.Config, error) {
+	yaml := fmt.Sprintf(`
+opensearch:
+  url: %q
+  username: %q
+  password: %q
+  tls_skip_verify: true
+quickwit:
+  url: %q
+  ingest_commit: force
+`, e.OpenSearchURL, Username, Password, e.QuickwitURL)
+	path := filepath.Join(e.dir, fmt.Sprintf("oqbridge-%d.yaml", time.Now().UnixNano()))
+	if err := os.WriteFile(path, []byte(yaml+extra), 0o600); err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Migration.CheckpointDir == "" {
+		cfg.Migration.CheckpointDir = filepath.Join(e.dir, "checkpoints")
+	}
+	return cfg, nil
+}
+
+// Proxy serves a proxy configured by cfg, as the oqbridge binary would
+// build it. Close the server when done.
+func (e *Env) Proxy(cfg *config.Config) (*proxy.Proxy, *httptest.Server, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, nil, err
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	cold, err := e.quickwit(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport, err := util.NewOpenSearchTransport(cfg.OpenSearch)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := proxy.New(cfg, hot, cold, transport)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, httptest.NewServer(p), nil
+}
+
+// Migrate runs migration once with cfg, as "oqbridge-migrate --once"
+// would.
+func (e *Env) Migrate(ctx context.Context, cfg *config.Config) (*migration.RunReport, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, err
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	cold, err := e.quickwit(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cpStore, err := migration.NewLocalCheckpointStore(cfg.Migration.CheckpointDir)
+	if err != nil {
+		return nil, err
+	}
+	lock := backend.NewOpenSearchLock(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	m, err := migration.NewMigrator(cfg, hot, cold, cpStore,
+		migration.WithDistLock(lock),
+		migration.WithLockTTL(cfg.Migration.LockTTL),
+		migration.WithColdHealthCheck(cold),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m.MigrateAllWithReport(ctx)
+}
+
+func (e *Env) quickwit(cfg *config.Config) (*backend.QuickwitRouter, error) {
+	client, err := util.NewQuickwitClient(cfg.Quickwit)
+	if err != nil {
+		return nil, err
+	}
+	q := backend.NewQuickwit(cfg.Quickwit.URL, cfg.Quickwit.Username, cfg.Quickwit.Password, cfg.Migration.Compress, client)
+	q.SetIngestMode(cfg.Quickwit.IngestAPI, cfg.Quickwit.IngestCommit)
+	q.SetSearchAPI(cfg.Quickwit.SearchAPI)
+	return backend.NewQuickwitRouter(q, nil, cfg.QuickwitClusterForIndex), nil
+}
+
+// Doc is a document of Seed.
+type Doc map[string]any
+
+// Docs returns perDay documents for each of daysAgo, timestamped at noon
+// that many days before now in field, with a "day" field holding the
+// number of days.
+func Docs(field string, now time.Time, perDay int, daysAgo ...int) []Doc {
+	var docs []Doc
+	for _, d := range daysAgo {
+		day := now.UTC().AddDate(0, 0, -d)
+		ts := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+		for i := range perDay {
+			docs = append(docs, Doc{
+				field:     ts.Add(time.Duration(i) * time.Second).Format(time.RFC3339),
+				"day":     d,
+				"message": fmt.Sprintf("document %d of day %d", i, d),
+			})
+		}
+	}
+	return docs
+}
+
+// Seed creates index in OpenSearch, with field mapped as a date, and
+// indexes docs into it, searchable when Seed returns.
+func (e *Env) Seed(ctx context.Context, index, field string, docs []Doc) error {
+	mapping := fmt.Sprintf(`{"mappings":{"properties":{%q:{"type":"date"},"day":{"type":"integer"},"message":{"type":"text"}}}}`, field)
+	if _, err := e.OpenSearch(ctx, http.MethodPut, "/"+index, mapping); err != nil {
+		return err
+	}
+	var bulk strings.Builder
+	for _, doc := range docs {
+		line, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&bulk, "{\"index\":{\"_index\":%q}}\n%s\n", index, line)
+	}
+	resp, err := e.OpenSearch(ctx, http.MethodPost, "/_bulk?refresh=true", bulk.String())
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil || result.Errors {
+		return fmt.Errorf("bulk indexing into %s failed: %s", index, resp)
+	}
+	return nil
+}
+
+// Count returns the number of documents of index in OpenSearch itself.
+func (e *Env) Count(ctx context.Context, index string) (int64, error) {
+	if _, err := e.OpenSearch(ctx, http.MethodPost, "/"+index+"/_refresh", ""); err != nil {
+		return 0, err
+	}
+	resp, err := e.OpenSearch(ctx, http.MethodGet, "/"+index+"/_count", "")
+	if err != nil {
+		return 0, err
+	}
+	var count struct {
+		Count int64 `json:"count"`
+	}
+	return count.Count, json.Unmarshal(resp, &count)
+}
+
+// OpenSearch sends a request to OpenSearch as the admin user and returns
+// the response body, or an error for a status of 300 or more.
+func (e *Env) OpenSearch(ctx context.Context, method, path, body string) ([]byte, error) {
+	return e.do(ctx, method, e.OpenSearchURL+path, body)
+}
+
+// Search searches index through the proxy at url as the admin user.
+func (e *Env) Search(ctx context.Context, url, index, body string) (*backend.SearchResponse, error) {
+	resp, err := e.do(ctx, http.MethodPost, url+"/"+index+"/_search", body)
+	if err != nil {
+		return nil, err
+	}
+	var sr backend.SearchResponse
+	if err := json.Unmarshal(resp, &sr); err != nil {
+		return nil, fmt.Errorf("parsing search response %s: %w", resp, err)
+	}
+	return &sr, nil
+}
+
+func (e *Env) do(ctx context.Context, method, url, body string) ([]byte, error) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(Username, Password)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, url, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// start starts the containers for t, or skips t unless OQBRIDGE_E2E=1.
+func start(t *testing.T) *Env {
+	t.Helper()
+	if os.Getenv("OQBRIDGE_E2E") != "1" {
+		t.Skip("set OQBRIDGE_E2E=1 to run end-to-end tests against OpenSearch and Quickwit containers")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	env, err := Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { env.Close(context.Background()) })
+	return env
+}
+
+// rangeQuery searches the documents from fromDays until toDays before now.
+func rangeQuery(now time.Time, fromDays, toDays int) string {
+	return fmt.Sprintf(`{"size":100,"query":{"range":{"@timestamp":{"gte":%q,"lt":%q}}}}`,
+		now.AddDate(0, 0, -fromDays).Format(time.RFC3339), now.AddDate(0, 0, -toDays).Format(time.RFC3339))
+}
+
+func TestEndToEnd(t *testing.T) {
+	env := start(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	const index = "e2e-logs"
+
+	// Ten documents on each of five days: three within the hot retention
+	// of 30 days, two past migrate_after_days and the cutoff.
+	if err := env.Seed(ctx, index, "@timestamp", Docs("@timestamp", now, 10, 1, 5, 20, 40, 50)); err != nil {
+		t.Fatalf("Seed() error: %v", err)
+	}
+	cfg, err := env.Config(`
+retention:
+  days: 30
+  timestamp_field: "@timestamp"
+migration:
+  indices: ["e2e-*"]
+  migrate_after_days: 25
+  delete_after_migration: true
+`)
+	if err != nil {
+		t.Fatalf("Config() error: %v", err)
+	}
+
+	report, err := env.Migrate(ctx, cfg)
+	if err != nil || report.Outcome != migration.OutcomeSuccess || report.Migrated != 20 {
+		out, _ := json.Marshal(report)
+		t.Fatalf("Migrate() = %s, %v, want 20 documents migrated", out, err)
+	}
+	if n, err := env.Count(ctx, index); err != nil || n != 30 {
+		t.Fatalf("OpenSearch holds %d documents after migration (%v), want the 30 younger than 25 days", n, err)
+	}
+
+	p, srv, err := env.Proxy(cfg)
+	if err != nil {
+		t.Fatalf("Proxy() error: %v", err)
+	}
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name  string
+		body  string
+		route string
+		total int
+	}{
+		{"hot", rangeQuery(now, 10, 0), "hot_only", 20},
+		{"cold", rangeQuery(now, 60, 35), "cold_only", 20},
+		{"both", rangeQuery(now, 60, -1), "both", 50},
+	} {
+		if route, _ := p.RouteSearch([]string{index}, []byte(tt.body)); route != tt.route {
+			t.Errorf("%s: routed %s, want %s", tt.name, route, tt.route)
+		}
+		resp, err := env.Search(ctx, srv.URL, index, tt.body)
+		if err != nil {
+			t.Errorf("%s: search error: %v", tt.name, err)
+			continue
+		}
+		if resp.Hits.Total.Value != tt.total || len(resp.Hits.Hits) != tt.total {
+			t.Errorf("%s: total %d with %d hits, want %d", tt.name, resp.Hits.Total.Value, len(resp.Hits.Hits), tt.total)
+		}
+	}
+
+	// Merged hits come from both tiers.
+	resp, err := env.Search(ctx, srv.URL, index, rangeQuery(now, 60, -1))
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	days := map[int]int{}
+	for _, hit := range resp.Hits.Hits {
+		var doc struct {
+			Source struct {
+				Day int `json:"day"`
+			} `json:"_source"`
+		}
+		json.Unmarshal(hit, &doc)
+		days[doc.Source.Day]++
+	}
+	for _, d := range []int{1, 5, 20, 40, 50} {
+		if days[d] != 10 {
+			t.Errorf("merged hits per day = %v, want 10 of each seeded day", days)
+			break
+		}
+	}
+
+	// A second run finds nothing left to migrate and duplicates nothing.
+	if report, err := env.Migrate(ctx, cfg); err != nil {
+		t.Errorf("second Migrate() error: %v", err)
+	} else if report.Migrated != 0 {
+		t.Errorf("second Migrate() migrated %d documents, want 0", report.Migrated)
+	}
+	if resp, err := env.Search(ctx, srv.URL, index, rangeQuery(now, 60, -1)); err != nil || resp.Hits.Total.Value != 50 {
+		t.Errorf("search after the second run = %+v, %v, want 50 hits", resp, err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package export writes cold data from Quickwit to object storage as
+// Parquet files that query engines such as Athena and Trino can read.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// Cold is the Quickwit side of an export.
+type Cold = migration.RangeReader
+
+// Store writes exported objects.
+type Store interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// row is the Parquet schema of exported documents. Documents are kept as
+// JSON text, since Quickwit indices created by oqbridge are schemaless;
+// query them with json_extract and friends.
+type row struct {
+	Timestamp int64  `parquet:"timestamp,timestamp(millisecond)"`
+	Document  string `parquet:"document"`
+}
+
+// Manifest describes the files written by one export of an index. It is
+// stored next to them under "_manifests/", which query engines skip.
+type Manifest struct {
+	Index          string         `json:"index"`
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	ExportedAt     time.Time      `json:"exported_at"`
+	TimestampField string         `json:"timestamp_field"`
+	Compression    string         `json:"compression"`
+	Documents      int64          `json:"documents"`
+	Files          []ManifestFile `json:"files"`
+}
+
+// ManifestFile is one Parquet file of an export.
+type ManifestFile struct {
+	Key          string    `json:"key"`
+	Day          string    `json:"day"`
+	Documents    int64     `json:"documents"`
+	SizeBytes    int64     `json:"size_bytes"`
+	MinTimestamp time.Time `json:"min_timestamp"`
+	MaxTimestamp time.Time `json:"max_timestamp"`
+}
+
+// Exporter copies the documents of Quickwit indices to a Store, one table
+// directory per index with a Hive-style "dt=YYYY-MM-DD" partition per day.
+type Exporter struct {
+	cfg   *config.Config
+	cold  Cold
+	store Store
+	now   func() time.Time
+}
+
+// NewExporter creates an Exporter writing with the settings of cfg.Export.
+func NewExporter(cfg *config.Config, cold Cold, store Store) *Exporter {
+	return &Exporter{cfg: cfg, cold: cold, store: store, now: time.Now}
+}
+
+// Export writes the documents of index from the days in [from, to), both
+// rounded down to the start of their day in retention.timezone, and then
+// the manifest. Exporting a day again replaces its files.
+func (e *Exporter) Export(ctx context.Context, index string, from, to time.Time) (*Manifest, error) {
+	m := &Manifest{
+		Index:          index,
+		From:           e.cfg.DaysAgo(from, 0).UTC(),
+		To:             e.cfg.DaysAgo(to, 0).UTC(),
+		ExportedAt:     e.now().UTC(),
+		TimestampField: e.cfg.TimestampFieldForIndex(index),
+		Compression:    e.cfg.Export.Compression,
+		Files:          []ManifestFile{},
+	}
+	for day := e.cfg.DaysAgo(from, 0); day.Before(m.To); day = e.cfg.DaysAgo(day, -1) {
+		if err := e.exportDay(ctx, m, day); err != nil {
+			return nil, fmt.Errorf("exporting %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	key := path.Join(e.cfg.Export.Prefix, index, "_manifests", m.From.Format("20060102")+"-"+m.To.Format("20060102")+".json")
+	if err := e.store.Put(ctx, key, body, "application/json"); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	slog.Info("export completed", "index", index, "documents", m.Documents, "files", len(m.Files), "manifest", key)
+	return m, nil
+}
+
+// exportDay writes the documents of the day starting at day in files of at
+// most export.max_file_docs documents.
+func (e *Exporter) exportDay(ctx context.Context, m *Manifest, day time.Time) error {
+	dir := path.Join(e.cfg.Export.Prefix, m.Index, "dt="+day.Format(time.DateOnly))
+	var rows []row
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		f, err := e.writeFile(ctx, path.Join(dir, fmt.Sprintf("part-%05d.parquet", countDay(m, day))), rows)
+		if err != nil {
+			return err
+		}
+		f.Day = day.Format(time.DateOnly)
+		m.Files = append(m.Files, f)
+		m.Documents += f.Documents
+		rows = rows[:0]
+		return nil
+	}
+
+	// The range is inclusive while days are half-open.
+	last := e.cfg.DaysAgo(day, -1).Add(-time.Millisecond)
+	err := migration.ReadRange(ctx, e.cold, m.Index, m.TimestampField, day, last, func(docs []json.RawMessage) error {
+		for _, doc := range docs {
+			ts, ok := migration.DocumentTimestamp(doc, m.TimestampField)
+			if !ok {
+				return fmt.Errorf("document without a valid %s", m.TimestampField)
+			}
+			rows = append(rows, row{Timestamp: ts.UnixMilli(), Document: string(doc)})
+			if len(rows) >= e.cfg.Export.MaxFileDocs {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// countDay returns the number of files already written for day.
+func countDay(m *Manifest, day time.Time) int {
+	n := 0
+	for _, f := range m.Files {
+		if f.Day == day.Format(time.DateOnly) {
+			n++
+		}
+	}
+	return n
+}
+
+// writeFile encodes rows as a Parquet file and uploads it as key.
+func (e *Exporter) writeFile(ctx context.Context, key string, rows []row) (ManifestFile, error) {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[row](&buf, parquet.Compression(codec(e.cfg.Export.Compression)))
+	if _, err := w.Write(rows); err != nil {
+		return ManifestFile{}, fmt.Errorf("encoding parquet: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return ManifestFile{}, fmt.Errorf("encoding parquet: %w", err)
+	}
+	if err := e.store.Put(ctx, key, buf.Bytes(), "application/vnd.apache.parquet"); err != nil {
+		return ManifestFile{}, err
+	}
+
+	f := ManifestFile{Key: key, Documents: int64(len(rows)), SizeBytes: int64(buf.Len())}
+	minTS, maxTS := rows[0].Timestamp, rows[0].Timestamp
+	for _, r := range rows[1:] {
+		minTS, maxTS = min(minTS, r.Timestamp), max(maxTS, r.Timestamp)
+	}
+	f.MinTimestamp, f.MaxTimestamp = time.UnixMilli(minTS).UTC(), time.UnixMilli(maxTS).UTC()
+	slog.Debug("exported parquet file", "key", key, "documents", f.Documents, "bytes", f.SizeBytes)
+	return f, nil
+}
+
+// codec returns the Parquet codec for export.compression.
+func codec(name string) compress.Codec {
+	switch name {
+	case "gzip":
+		return &parquet.Gzip
+	case "zstd":
+		return &parquet.Zstd
+	case "none":
+		return &parquet.Uncompressed
+	}
+	return &parquet.Snappy
+}
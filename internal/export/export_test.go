@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration/migrationtest"
+)
+
+type fakeStore map[string][]byte
+
+func (s fakeStore) Put(_ context.Context, key string, body []byte, _ string) error {
+	s[key] = body
+	return nil
+}
+
+func TestExporter_Export(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cold := &migrationtest.Range{}
+	for i := range 5 {
+		cold.Times = append(cold.Times, day.Add(time.Duration(i)*time.Hour))
+	}
+	cold.Times = append(cold.Times, day.AddDate(0, 0, 1).Add(time.Hour), day.AddDate(0, 0, 2)) // the last one is outside the window
+
+	cfg := config.Defaults()
+	cfg.Export.Prefix = "archive"
+	cfg.Export.MaxFileDocs = 3
+	store := fakeStore{}
+	m, err := NewExporter(cfg, cold, store).Export(context.Background(), "logs", day.Add(6*time.Hour), day.AddDate(0, 0, 2).Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	if m.Documents != 6 || !m.From.Equal(day) || !m.To.Equal(day.AddDate(0, 0, 2)) {
+		t.Errorf("manifest = %+v, want 6 documents from 2026-01-01 to 2026-01-03", m)
+	}
+	var keys []string
+	for _, f := range m.Files {
+		keys = append(keys, f.Key)
+	}
+	want := []string{
+		"archive/logs/dt=2026-01-01/part-00000.parquet",
+		"archive/logs/dt=2026-01-01/part-00001.parquet",
+		"archive/logs/dt=2026-01-02/part-00000.parquet",
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("files = %v, want %v", keys, want)
+	}
+	if _, ok := store["archive/logs/_manifests/20260101-20260103.json"]; !ok {
+		t.Error("manifest not written")
+	}
+
+	rows, err := parquet.Read[row](bytes.NewReader(store[want[1]]), int64(len(store[want[1]])))
+	if err != nil {
+		t.Fatalf("reading parquet: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Timestamp != day.Add(3*time.Hour).UnixMilli() || !strings.Contains(rows[1].Document, `"n":4`) {
+		t.Errorf("rows = %+v", rows)
+	}
+	if f := m.Files[1]; f.Documents != 2 || !f.MinTimestamp.Equal(day.Add(3*time.Hour)) || !f.MaxTimestamp.Equal(day.Add(4*time.Hour)) {
+		t.Errorf("file = %+v", f)
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// S3 writes objects to a bucket through the S3 API.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	client    *http.Client
+}
+
+// NewS3 creates an S3 client for bucket at endpoint (e.g.
+// "https://s3.eu-west-1.amazonaws.com"). With pathStyle, objects are
+// addressed as endpoint/bucket/key, otherwise as bucket.endpoint/key.
+// httpClient is expected to sign requests, see NewS3FromConfig.
+func NewS3(endpoint, bucket string, pathStyle bool, httpClient *http.Client) (*S3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &S3{endpoint: u, bucket: bucket, pathStyle: pathStyle, client: httpClient}, nil
+}
+
+// NewS3FromConfig creates the S3 client for the export settings, signing
+// requests with credentials from the default AWS chain.
+func NewS3FromConfig(ec config.ExportConfig) (*S3, error) {
+	if ec.Bucket == "" {
+		return nil, fmt.Errorf("export.bucket is not set")
+	}
+	transport, err := util.NewSigV4Transport(config.SigV4Config{Region: ec.Region, Service: "s3", Profile: ec.Profile}, nil)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := ec.Endpoint
+	if endpoint == "" {
+		if ec.Region == "" {
+			return nil, fmt.Errorf("export.region or export.endpoint is required")
+		}
+		endpoint = "https://s3." + ec.Region + ".amazonaws.com"
+	}
+	return NewS3(endpoint, ec.Bucket, ec.PathStyle, &http.Client{Transport: transport})
+}
+
+// Put uploads body as the object key, replacing any existing object.
+func (s *S3) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &backend.HTTPStatusError{StatusCode: resp.StatusCode, URL: u.String(), Body: string(respBody)}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package export
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestS3_Put(t *testing.T) {
+	var gotPath, gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotType, gotBody = r.URL.Path, r.Header.Get("Content-Type"), string(body)
+		if strings.Contains(r.URL.Path, "denied") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewS3(srv.URL, "archive", true, nil)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	if err := s.Put(context.Background(), "logs/dt=2026-01-01/part-00000.parquet", []byte("PAR1"), "application/vnd.apache.parquet"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if gotPath != "/archive/logs/dt=2026-01-01/part-00000.parquet" || gotType != "application/vnd.apache.parquet" || gotBody != "PAR1" {
+		t.Errorf("request = %s %s %q", gotPath, gotType, gotBody)
+	}
+
+	if err := s.Put(context.Background(), "denied", nil, "application/json"); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put error = %v, want the S3 error", err)
+	}
+}
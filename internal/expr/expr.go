@@ -0,0 +1,600 @@
+// Package expr implements the small expression language of routing rules,
+// e.g.
+//
+//	index matches "audit-*" && ("auditor" in roles || header("X-Archive") == "1")
+//	from != null && from < now - 365d
+//
+// Expressions are type-checked against a Schema when compiled, so unknown
+// names and mismatched operands are configuration errors rather than
+// surprises at request time.
+package expr
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the type of a value.
+type Type int
+
+const (
+	Bool     Type = iota + 1 // bool
+	String                   // string
+	List                     // []string
+	Time                     // time.Time, or nil if unknown
+	Duration                 // time.Duration
+	null                     // the null literal
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	case List:
+		return "list"
+	case Time:
+		return "time"
+	case Duration:
+		return "duration"
+	case null:
+		return "null"
+	}
+	return "unknown"
+}
+
+// Func is the signature of a function callable from expressions.
+type Func struct {
+	Args   []Type
+	Result Type
+}
+
+// Schema declares the variables and functions expressions may use.
+type Schema struct {
+	Vars  map[string]Type
+	Funcs map[string]Func
+}
+
+// Env supplies the values of the variables and functions of a Schema, as
+// the Go types listed with Type; an unknown time is nil. Variables are
+// only computed if the expression reaches them, so an expensive one costs
+// nothing to expressions that do not use it.
+type Env struct {
+	Vars  map[string]func() any
+	Funcs map[string]func(args []any) any
+}
+
+// Expr is a compiled boolean expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the source of e.
+func (e *Expr) String() string { return e.src }
+
+// Eval evaluates e in env.
+func (e *Expr) Eval(env Env) bool {
+	v, _ := e.root.eval(env).(bool)
+	return v
+}
+
+// Compile parses src and checks it against schema; the expression must be
+// boolean.
+func Compile(src string, schema Schema) (*Expr, error) {
+	p := &parser{lex: lexer{src: src}, schema: schema}
+	p.next()
+	root, err := p.parseOr()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %s", p.tok)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if root.typ() != Bool {
+		return nil, fmt.Errorf("expression is %s, not bool", root.typ())
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// node is a type-checked expression.
+type node interface {
+	typ() Type
+	eval(env Env) any
+}
+
+type literal struct {
+	t Type
+	v any
+}
+
+func (n literal) typ() Type      { return n.t }
+func (n literal) eval(_ Env) any { return n.v }
+
+type variable struct {
+	t    Type
+	name string
+}
+
+func (n variable) typ() Type { return n.t }
+func (n variable) eval(env Env) any {
+	if f := env.Vars[n.name]; f != nil {
+		return f()
+	}
+	return nil
+}
+
+type call struct {
+	t    Type
+	name string
+	args []node
+}
+
+func (n call) typ() Type { return n.t }
+func (n call) eval(env Env) any {
+	f := env.Funcs[n.name]
+	if f == nil {
+		return nil
+	}
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		args[i] = a.eval(env)
+	}
+	return f(args)
+}
+
+type list []node
+
+func (n list) typ() Type { return List }
+func (n list) eval(env Env) any {
+	vs := make([]string, 0, len(n))
+	for _, e := range n {
+		s, _ := e.eval(env).(string)
+		vs = append(vs, s)
+	}
+	return vs
+}
+
+type not struct{ x node }
+
+func (n not) typ() Type { return Bool }
+func (n not) eval(env Env) any {
+	v, _ := n.x.eval(env).(bool)
+	return !v
+}
+
+type binary struct {
+	t    Type
+	op   string
+	l, r node
+}
+
+func (n binary) typ() Type { return n.t }
+
+func (n binary) eval(env Env) any {
+	switch n.op {
+	case "&&":
+		l, _ := n.l.eval(env).(bool)
+		if !l {
+			return false
+		}
+		r, _ := n.r.eval(env).(bool)
+		return r
+	case "||":
+		l, _ := n.l.eval(env).(bool)
+		if l {
+			return true
+		}
+		r, _ := n.r.eval(env).(bool)
+		return r
+	}
+	l, r := n.l.eval(env), n.r.eval(env)
+	switch n.op {
+	case "==":
+		return equal(l, r)
+	case "!=":
+		return !equal(l, r)
+	case "matches":
+		s, _ := l.(string)
+		pattern, _ := r.(string)
+		ok, _ := path.Match(pattern, s)
+		return ok
+	case "in":
+		s, _ := l.(string)
+		vs, _ := r.([]string)
+		return slices.Contains(vs, s)
+	case "+", "-":
+		t, ok := l.(time.Time)
+		if !ok {
+			return nil
+		}
+		d, _ := r.(time.Duration)
+		if n.op == "-" {
+			d = -d
+		}
+		return t.Add(d)
+	}
+	c, ok := compare(l, r)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+func equal(l, r any) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	if lt, ok := l.(time.Time); ok {
+		rt, ok := r.(time.Time)
+		return ok && lt.Equal(rt)
+	}
+	if lv, ok := l.([]string); ok {
+		rv, ok := r.([]string)
+		return ok && slices.Equal(lv, rv)
+	}
+	return l == r
+}
+
+// compare orders two values of the same type; a nil time is unordered.
+func compare(l, r any) (int, bool) {
+	switch lv := l.(type) {
+	case string:
+		rv, ok := r.(string)
+		return strings.Compare(lv, rv), ok
+	case time.Time:
+		rv, ok := r.(time.Time)
+		return lv.Compare(rv), ok
+	case time.Duration:
+		rv, ok := r.(time.Duration)
+		return cmp.Compare(lv, rv), ok
+	}
+	return 0, false
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "matches" | "in" ) sum ]
+//	sum     = primary { ( "+" | "-" ) primary }
+//	primary = string | duration | "true" | "false" | "null" | name | name "(" [ or { "," or } ] ")"
+//	        | "[" [ or { "," or } ] "]" | "(" or ")"
+type parser struct {
+	lex    lexer
+	tok    token
+	schema Schema
+}
+
+func (p *parser) next() { p.tok = p.lex.next() }
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at offset %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("&&", p.parseUnary)
+}
+
+func (p *parser) parseLogical(op string, operand func() (node, error)) (node, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == op {
+		if l.typ() != Bool {
+			return nil, p.errorf("%s needs bool operands, not %s", op, l.typ())
+		}
+		p.next()
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if r.typ() != Bool {
+			return nil, p.errorf("%s needs bool operands, not %s", op, r.typ())
+		}
+		l = binary{t: Bool, op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.tok.kind == tokOp && p.tok.text == "!" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != Bool {
+			return nil, p.errorf("! needs a bool operand, not %s", x.typ())
+		}
+		return not{x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op := p.tok.text
+	switch {
+	case p.tok.kind == tokOp && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, op):
+	case p.tok.kind == tokName && (op == "matches" || op == "in"):
+	default:
+		return l, nil
+	}
+	p.next()
+	r, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	lt, rt := l.typ(), r.typ()
+	switch op {
+	case "==", "!=":
+		if lt != rt && lt != null && rt != null {
+			return nil, p.errorf("cannot compare %s %s %s", lt, op, rt)
+		}
+	case "matches":
+		if lt != String || rt != String {
+			return nil, p.errorf("matches needs string operands, not %s and %s", lt, rt)
+		}
+		if lit, ok := r.(literal); ok {
+			if _, err := path.Match(lit.v.(string), ""); err != nil {
+				return nil, p.errorf("invalid pattern %q", lit.v)
+			}
+		}
+	case "in":
+		if lt != String || rt != List {
+			return nil, p.errorf("in needs a string and a list, not %s and %s", lt, rt)
+		}
+	default:
+		if lt != rt || (lt != String && lt != Time && lt != Duration) {
+			return nil, p.errorf("cannot order %s %s %s", lt, op, rt)
+		}
+	}
+	return binary{t: Bool, op: op, l: l, r: r}, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text
+		p.next()
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if l.typ() != Time || r.typ() != Duration {
+			return nil, p.errorf("%s needs a time and a duration, not %s and %s", op, l.typ(), r.typ())
+		}
+		l = binary{t: Time, op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokString:
+		p.next()
+		s, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, p.errorf("invalid string %s", tok.text)
+		}
+		return literal{t: String, v: s}, nil
+	case tokDuration:
+		p.next()
+		d, err := parseDuration(tok.text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		return literal{t: Duration, v: d}, nil
+	case tokName:
+		p.next()
+		switch tok.text {
+		case "true", "false":
+			return literal{t: Bool, v: tok.text == "true"}, nil
+		case "null":
+			return literal{t: null}, nil
+		}
+		if p.tok.kind == tokOp && p.tok.text == "(" {
+			return p.parseCall(tok)
+		}
+		t, ok := p.schema.Vars[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("at offset %d: unknown name %q", tok.pos, tok.text)
+		}
+		return variable{t: t, name: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			p.next()
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			p.next()
+			elems, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range elems {
+				if e.typ() != String {
+					return nil, p.errorf("lists hold strings, not %s", e.typ())
+				}
+			}
+			return list(elems), nil
+		}
+	}
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	f, ok := p.schema.Funcs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("at offset %d: unknown function %q", name.pos, name.text)
+	}
+	p.next()
+	args, err := p.parseArgs(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(f.Args) {
+		return nil, fmt.Errorf("at offset %d: %s takes %d arguments, not %d", name.pos, name.text, len(f.Args), len(args))
+	}
+	for i, a := range args {
+		if a.typ() != f.Args[i] {
+			return nil, fmt.Errorf("at offset %d: argument %d of %s must be %s, not %s", name.pos, i+1, name.text, f.Args[i], a.typ())
+		}
+	}
+	return call{t: f.Result, name: name.text, args: args}, nil
+}
+
+// parseArgs parses a comma-separated list of expressions up to end.
+func (p *parser) parseArgs(end string) ([]node, error) {
+	var args []node
+	for !(p.tok.kind == tokOp && p.tok.text == end) {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		a, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *parser) expect(op string) error {
+	if p.tok.kind != tokOp || p.tok.text != op {
+		return p.errorf("expected %q, found %s", op, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+// parseDuration parses a duration literal such as 90d, 2w or 30m.
+func parseDuration(s string) (time.Duration, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+	}
+	unit, ok := units[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q: units are ms, s, m, h, d and w", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokString
+	tokDuration
+	tokOp
+	tokInvalid
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokInvalid:
+		return fmt.Sprintf("invalid character %q", t.text)
+	}
+	return strconv.Quote(t.text)
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() token {
+	for l.pos < len(l.src) && strings.ContainsRune(" \t\r\n", rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"':
+		for l.pos++; l.pos < len(l.src) && l.src[l.pos] != '"'; l.pos++ {
+			if l.src[l.pos] == '\\' {
+				l.pos++
+			}
+		}
+		l.pos = min(l.pos+1, len(l.src))
+		return token{kind: tokString, text: l.src[start:l.pos], pos: start}
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && isAlnum(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokDuration, text: l.src[start:l.pos], pos: start}
+	case isAlnum(c):
+		for l.pos < len(l.src) && isAlnum(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, text: l.src[start:l.pos], pos: start}
+	}
+	for _, op := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "(", ")", "[", "]", ","} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}
+		}
+	}
+	l.pos++
+	return token{kind: tokInvalid, text: string(c), pos: start}
+}
+
+func isAlnum(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
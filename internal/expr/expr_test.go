@@ -0,0 +1,98 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	Vars: map[string]Type{"index": String, "roles": List, "from": Time, "now": Time},
+	Funcs: map[string]Func{
+		"header": {Args: []Type{String}, Result: String},
+	},
+}
+
+func TestEval(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	env := func(index string, from any) Env {
+		return Env{
+			Vars: map[string]func() any{
+				"index": func() any { return index },
+				"roles": func() any { return []string{"auditor"} },
+				"from":  func() any { return from },
+				"now":   func() any { return now },
+			},
+			Funcs: map[string]func([]any) any{
+				"header": func(args []any) any {
+					if args[0] == "X-Archive" {
+						return "1"
+					}
+					return ""
+				},
+			},
+		}
+	}
+	old := now.AddDate(-2, 0, 0)
+
+	tests := []struct {
+		src   string
+		index string
+		from  any
+		want  bool
+	}{
+		{`index == "logs"`, "logs", nil, true},
+		{`index != "logs"`, "logs", nil, false},
+		{`index matches "audit-*"`, "audit-2026", nil, true},
+		{`index matches "audit-*"`, "logs", nil, false},
+		{`index in ["a", "b"]`, "b", nil, true},
+		{`"auditor" in roles && header("X-Archive") == "1"`, "logs", nil, true},
+		{`!("admin" in roles) || false`, "logs", nil, true},
+		{`from == null`, "logs", nil, true},
+		{`from != null && from < now - 365d`, "logs", old, true},
+		{`from < now - 365d`, "logs", nil, false},
+		{`from >= now - 1w`, "logs", old, false},
+		{`now - 24h == now - 1d`, "logs", nil, true},
+		{`30m < 1h`, "logs", nil, true},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src, testSchema)
+		if err != nil {
+			t.Errorf("Compile(%s) error: %v", tt.src, err)
+			continue
+		}
+		if got := e.Eval(env(tt.index, tt.from)); got != tt.want {
+			t.Errorf("%s with index %s = %v, want %v", tt.src, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`user == "x"`, `unknown name "user"`},
+		{`lookup("x")`, `unknown function "lookup"`},
+		{`header()`, "takes 1 arguments"},
+		{`header(roles) == ""`, "argument 1 of header must be string"},
+		{`index`, "expression is string"},
+		{`index == roles`, "cannot compare string == list"},
+		{`index < now`, "cannot order"},
+		{`index matches "[a"`, "invalid pattern"},
+		{`roles in index`, "in needs a string and a list"},
+		{`now - "1d" < now`, "needs a time and a duration"},
+		{`from < now - 90y`, "units are"},
+		{`index == "logs" &&`, "unexpected end of expression"},
+		{`(index == "a"`, `expected ")"`},
+		{`index == "a" # b`, "invalid character"},
+		{`index == "a" index`, `unexpected "index"`},
+		{`!index`, "! needs a bool operand"},
+		{`index || true`, "|| needs bool operands"},
+	}
+	for _, tt := range tests {
+		if _, err := Compile(tt.src, testSchema); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%s) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
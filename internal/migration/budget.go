@@ -0,0 +1,70 @@
+package migration
+
+import (
+	"context"
+	"sync"
+)
+
+// byteBudget bounds the bytes of scrolled batches held in memory across all
+// slice workers. A batch larger than the whole budget is still admitted when
+// nothing else is buffered, so an oversized page slows the run down instead
+// of deadlocking it.
+type byteBudget struct {
+	limit int64 // <= 0 means unlimited; usage is still tracked
+
+	mu      sync.Mutex
+	used    int64
+	changed chan struct{} // closed and replaced on every release
+}
+
+func newByteBudget(limit int64) *byteBudget {
+	return &byteBudget{limit: limit, changed: make(chan struct{})}
+}
+
+// acquire blocks until n bytes fit in the budget or ctx is done.
+func (b *byteBudget) acquire(ctx context.Context, n int64) error {
+	for {
+		b.mu.Lock()
+		if b.limit <= 0 || b.used == 0 || b.used+n <= b.limit {
+			b.used += n
+			b.mu.Unlock()
+			return nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setLimit changes the limit and wakes any waiters that now fit.
+func (b *byteBudget) setLimit(limit int64) {
+	b.mu.Lock()
+	b.limit = limit
+	close(b.changed)
+	b.changed = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// release returns n bytes to the budget and wakes any waiters.
+func (b *byteBudget) release(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	close(b.changed)
+	b.changed = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// inUse returns the bytes currently held. A nil budget holds nothing.
+func (b *byteBudget) inUse() int64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
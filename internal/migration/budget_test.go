@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestByteBudget_BlocksUntilRelease(t *testing.T) {
+	b := newByteBudget(100)
+	ctx := context.Background()
+	if err := b.acquire(ctx, 80); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		b.acquire(ctx, 40)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatalf("acquire exceeded the budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	b.release(80)
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("acquire not woken by release")
+	}
+	if got := b.inUse(); got != 40 {
+		t.Fatalf("inUse=%d, want 40", got)
+	}
+}
+
+func TestByteBudget_SetLimitWakesWaiters(t *testing.T) {
+	b := newByteBudget(100)
+	ctx := context.Background()
+	b.acquire(ctx, 80)
+
+	acquired := make(chan struct{})
+	go func() {
+		b.acquire(ctx, 40)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("acquire exceeded the budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	b.setLimit(200)
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("acquire not woken by a raised limit")
+	}
+}
+
+func TestByteBudget_AdmitsOversizedBatchWhenIdle(t *testing.T) {
+	b := newByteBudget(10)
+	if err := b.acquire(context.Background(), 50); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if got := b.inUse(); got != 50 {
+		t.Fatalf("inUse=%d, want 50", got)
+	}
+}
+
+func TestByteBudget_AcquireHonoursContext(t *testing.T) {
+	b := newByteBudget(10)
+	b.acquire(context.Background(), 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.acquire(ctx, 5); err == nil {
+		t.Fatalf("expected context error")
+	}
+	if got := b.inUse(); got != 10 {
+		t.Fatalf("inUse=%d, want 10", got)
+	}
+}
+
+func TestMigrator_MigrateIndex_ReleasesBudget(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 2), makeHits(0, 2), nil},
+		1: {makeHits(1, 2), nil},
+	})
+	cfg := defaultTestConfig()
+	cfg.Migration.MaxBufferedMB = 1
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, newFakeCold(), cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	if got := m.BufferedBytes(); got != 0 {
+		t.Fatalf("BufferedBytes=%d after run, want 0", got)
+	}
+}
+
+func TestMigrator_MigrateIndex_ReleasesBudgetOnFailure(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), makeHits(0, 1), makeHits(0, 1), makeHits(0, 1), nil},
+	})
+	cold := newFakeCold()
+	failSlice := 0
+	cold.failOnSlice = &failSlice
+	m := newTestMigrator(t, hot, cold, t.TempDir())
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err == nil {
+		t.Fatalf("expected ingest failure")
+	}
+	if got := m.BufferedBytes(); got != 0 {
+		t.Fatalf("BufferedBytes=%d after failed run, want 0", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -42,6 +44,29 @@ type CheckpointStore interface {
 	SaveWatermark(wm *Watermark) error
 }
 
+// IndexState is the stored migration state for one index, as shown by the
+// checkpoint administration commands. Unlike Load, it includes completed
+// checkpoints.
+type IndexState struct {
+	Index      string      `json:"index"`
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+	Watermark  *Watermark  `json:"watermark,omitempty"`
+}
+
+// CheckpointAdmin is implemented by checkpoint stores that support
+// inspection and manual reset by operators.
+type CheckpointAdmin interface {
+	// List returns the state of every index with a checkpoint or watermark,
+	// sorted by index name.
+	List() ([]IndexState, error)
+	// State returns the state of one index, or nil if nothing is stored.
+	State(index string) (*IndexState, error)
+	// Reset deletes the index's checkpoint so the next run starts its window
+	// from scratch. With resetWatermark, the watermark is deleted too and the
+	// next run re-migrates everything older than the cutoff.
+	Reset(index string, resetWatermark bool) error
+}
+
 // LocalCheckpointStore manages checkpoint persistence on the local filesystem.
 type LocalCheckpointStore struct {
 	dir string
@@ -156,3 +181,67 @@ func (s *LocalCheckpointStore) SaveWatermark(wm *Watermark) error {
 	}
 	return nil
 }
+
+// List returns the state of every index with a checkpoint or watermark file.
+func (s *LocalCheckpointStore) List() ([]IndexState, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint dir: %w", err)
+	}
+	seen := make(map[string]bool)
+	var states []IndexState
+	for _, e := range entries {
+		name := e.Name()
+		index := strings.TrimSuffix(strings.TrimSuffix(name, ".checkpoint.json"), ".watermark.json")
+		if index == name || seen[index] {
+			continue
+		}
+		seen[index] = true
+		st, err := s.State(index)
+		if err != nil {
+			return nil, err
+		}
+		if st != nil {
+			states = append(states, *st)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Index < states[j].Index })
+	return states, nil
+}
+
+// State returns the stored checkpoint (completed or not) and watermark for index.
+func (s *LocalCheckpointStore) State(index string) (*IndexState, error) {
+	st := &IndexState{Index: index}
+	data, err := os.ReadFile(s.path(index))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	if err == nil {
+		var cp Checkpoint
+		if err := json.Unmarshal(data, &cp); err != nil {
+			return nil, fmt.Errorf("parsing checkpoint: %w", err)
+		}
+		st.Checkpoint = &cp
+	}
+	if st.Watermark, err = s.LoadWatermark(index); err != nil {
+		return nil, err
+	}
+	if st.Checkpoint == nil && st.Watermark == nil {
+		return nil, nil
+	}
+	return st, nil
+}
+
+// Reset removes the checkpoint file for index and, with resetWatermark, its
+// watermark file.
+func (s *LocalCheckpointStore) Reset(index string, resetWatermark bool) error {
+	if err := os.Remove(s.path(index)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing checkpoint: %w", err)
+	}
+	if resetWatermark {
+		if err := os.Remove(s.watermarkPath(index)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing watermark: %w", err)
+		}
+	}
+	return nil
+}
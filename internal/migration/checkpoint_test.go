@@ -1,6 +1,9 @@
 package migration
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestCheckpointStore_SaveLoadAndComplete(t *testing.T) {
 	dir := t.TempDir()
@@ -44,3 +47,58 @@ func TestCheckpointStore_SaveLoadAndComplete(t *testing.T) {
 		t.Fatalf("expected nil after completion, got %+v", loaded2)
 	}
 }
+
+func TestCheckpointStore_ListStateAndReset(t *testing.T) {
+	store, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	if err := store.Save(&Checkpoint{Index: "logs-b", Migrated: 10}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.MarkComplete("logs-b"); err != nil {
+		t.Fatalf("MarkComplete: %v", err)
+	}
+	if err := store.SaveWatermark(&Watermark{Index: "logs-b", MigratedBefore: time.Now()}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+	if err := store.SaveWatermark(&Watermark{Index: "logs-a", MigratedBefore: time.Now()}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+
+	states, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(states) != 2 || states[0].Index != "logs-a" || states[1].Index != "logs-b" {
+		t.Fatalf("List=%+v, want logs-a and logs-b", states)
+	}
+	if states[0].Checkpoint != nil || states[0].Watermark == nil {
+		t.Fatalf("logs-a state=%+v, want watermark only", states[0])
+	}
+	// Unlike Load, State includes completed checkpoints.
+	if cp := states[1].Checkpoint; cp == nil || !cp.Completed || cp.Migrated != 10 {
+		t.Fatalf("logs-b checkpoint=%+v, want completed with 10 migrated", cp)
+	}
+
+	if err := store.Reset("logs-b", false); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	st, err := store.State("logs-b")
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if st == nil || st.Checkpoint != nil || st.Watermark == nil {
+		t.Fatalf("State after reset=%+v, want watermark only", st)
+	}
+
+	if err := store.Reset("logs-b", true); err != nil {
+		t.Fatalf("Reset with watermark: %v", err)
+	}
+	if st, err := store.State("logs-b"); err != nil || st != nil {
+		t.Fatalf("State after full reset=%+v err=%v, want nil", st, err)
+	}
+	if err := store.Reset("missing", true); err != nil {
+		t.Fatalf("Reset of unknown index: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// RangeCounter counts documents whose timestamp lies in an inclusive range.
+// Dedup mode uses one for each tier to detect batches that were already
+// ingested by a previous, interrupted run.
+type RangeCounter interface {
+	CountRange(ctx context.Context, index, tsField string, from, to time.Time) (int64, error)
+}
+
+// deduper decides whether a batch can be skipped because Quickwit already
+// holds every document in the batch's time span.
+type deduper struct {
+	hot  RangeCounter
+	cold RangeCounter
+}
+
+// alreadyIngested reports whether Quickwit already holds at least as many
+// documents as OpenSearch in the inclusive time span covered by docs. Because
+// sliced scrolls interleave in time, the span is compared across all slices:
+// if the cold count has caught up, every document in it (from any slice) has
+// been ingested. A partially present span is re-ingested in full. The hot
+// side is counted on source, which differs from index when migrating from a
+// restored snapshot.
+func (d *deduper) alreadyIngested(ctx context.Context, index, source, tsField string, docs []json.RawMessage) (bool, error) {
+	from, to, ok := batchTimeSpan(docs, tsField)
+	if !ok {
+		return false, nil
+	}
+	coldCount, err := d.cold.CountRange(ctx, index, tsField, from, to)
+	if err != nil {
+		return false, fmt.Errorf("counting quickwit documents: %w", err)
+	}
+	if coldCount == 0 {
+		return false, nil
+	}
+	hotCount, err := d.hot.CountRange(ctx, source, tsField, from, to)
+	if err != nil {
+		return false, fmt.Errorf("counting opensearch documents: %w", err)
+	}
+	if coldCount < hotCount {
+		slog.Debug("batch span partially present in quickwit, re-ingesting",
+			"index", index, "from", formatBoundary(from), "to", formatBoundary(to),
+			"hot_count", hotCount, "cold_count", coldCount)
+		return false, nil
+	}
+	return true, nil
+}
+
+// batchTimeSpan returns the earliest and latest timestamps found in docs.
+// It returns ok=false if any document lacks a parseable timestamp, since the
+// span would then not cover the whole batch.
+func batchTimeSpan(docs []json.RawMessage, tsField string) (from, to time.Time, ok bool) {
+	if len(docs) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	for i, doc := range docs {
+		ts, found := DocumentTimestamp(doc, tsField)
+		if !found {
+			return time.Time{}, time.Time{}, false
+		}
+		if i == 0 || ts.Before(from) {
+			from = ts
+		}
+		if i == 0 || ts.After(to) {
+			to = ts
+		}
+	}
+	return from, to, true
+}
+
+// DocumentTimestamp extracts tsField from a document source. The field is
+// looked up as a literal key first and then as a dotted path into nested objects.
+func DocumentTimestamp(doc json.RawMessage, tsField string) (time.Time, bool) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(doc, &m); err != nil {
+		return time.Time{}, false
+	}
+	v, ok := m[tsField]
+	if !ok {
+		var cur interface{} = m
+		for _, part := range strings.Split(tsField, ".") {
+			obj, isObj := cur.(map[string]interface{})
+			if !isObj {
+				return time.Time{}, false
+			}
+			if cur, ok = obj[part]; !ok {
+				return time.Time{}, false
+			}
+		}
+		v = cur
+	}
+
+	switch val := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, val); err == nil {
+				return t.UTC(), true
+			}
+		}
+	case float64:
+		// Epoch milliseconds, matching OpenSearch's default numeric date format.
+		return time.UnixMilli(int64(val)).UTC(), true
+	}
+	return time.Time{}, false
+}
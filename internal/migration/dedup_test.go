@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCounter struct {
+	mu    sync.Mutex
+	count int64
+	calls int
+}
+
+func (f *fakeCounter) CountRange(_ context.Context, _, _ string, _, _ time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.count, nil
+}
+
+func TestDocumentTimestamp(t *testing.T) {
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		doc   string
+		field string
+		ok    bool
+	}{
+		{"rfc3339", `{"@timestamp":"2026-01-02T03:04:05Z"}`, "@timestamp", true},
+		{"epoch millis", `{"ts":1767323045000}`, "ts", true},
+		{"nested path", `{"event":{"created":"2026-01-02T03:04:05Z"}}`, "event.created", true},
+		{"literal dotted key", `{"event.created":"2026-01-02T03:04:05Z"}`, "event.created", true},
+		{"missing", `{"other":"x"}`, "@timestamp", false},
+		{"unparseable", `{"@timestamp":"yesterday"}`, "@timestamp", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DocumentTimestamp(json.RawMessage(tt.doc), tt.field)
+			if ok != tt.ok {
+				t.Fatalf("ok=%v, want %v", ok, tt.ok)
+			}
+			if ok && !got.Equal(want) {
+				t.Fatalf("timestamp=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBatchTimeSpan(t *testing.T) {
+	docs := []json.RawMessage{
+		json.RawMessage(`{"ts":"2026-01-02T00:00:00Z"}`),
+		json.RawMessage(`{"ts":"2026-01-01T00:00:00Z"}`),
+		json.RawMessage(`{"ts":"2026-01-03T00:00:00Z"}`),
+	}
+	from, to, ok := batchTimeSpan(docs, "ts")
+	if !ok {
+		t.Fatalf("expected span")
+	}
+	if from.Day() != 1 || to.Day() != 3 {
+		t.Fatalf("span=[%v, %v], want [Jan 1, Jan 3]", from, to)
+	}
+
+	docs = append(docs, json.RawMessage(`{"no_ts":true}`))
+	if _, _, ok := batchTimeSpan(docs, "ts"); ok {
+		t.Fatalf("expected no span when a document lacks the timestamp")
+	}
+}
+
+func TestDeduper_AlreadyIngested(t *testing.T) {
+	docs := []json.RawMessage{json.RawMessage(`{"ts":"2026-01-01T00:00:00Z"}`)}
+	tests := []struct {
+		name      string
+		hot, cold int64
+		want      bool
+		hotCalled bool
+	}{
+		{"nothing in cold", 5, 0, false, false},
+		{"partially present", 5, 3, false, true},
+		{"fully present", 5, 5, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hot := &fakeCounter{count: tt.hot}
+			d := &deduper{hot: hot, cold: &fakeCounter{count: tt.cold}}
+			got, err := d.alreadyIngested(context.Background(), "logs", "logs", "ts", docs)
+			if err != nil {
+				t.Fatalf("alreadyIngested: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("alreadyIngested=%v, want %v", got, tt.want)
+			}
+			if (hot.calls > 0) != tt.hotCalled {
+				t.Fatalf("hot counted=%v, want %v", hot.calls > 0, tt.hotCalled)
+			}
+		})
+	}
+}
+
+func TestMigrator_MigrateIndex_Dedup_SkipsPresentBatches(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {{json.RawMessage(`{"_source":{"@timestamp":"2025-01-01T00:00:00Z"}}`)}, nil},
+		1: {{json.RawMessage(`{"_source":{"@timestamp":"2025-01-02T00:00:00Z"}}`)}, nil},
+	})
+	cold := newFakeCold()
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(defaultTestConfig(), hot, cold, cpStore,
+		WithDedup(&fakeCounter{count: 1}, &fakeCounter{count: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	cold.mu.Lock()
+	defer cold.mu.Unlock()
+	if n := len(cold.docsByIndex["logs"]); n != 0 {
+		t.Fatalf("ingested docs=%d, want 0 (all batches already present)", n)
+	}
+}
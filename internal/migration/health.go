@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// ClusterHealthChecker reports OpenSearch cluster health so the migrator can
+// back off instead of piling sliced scrolls onto a struggling cluster.
+type ClusterHealthChecker interface {
+	ClusterHealth(ctx context.Context) (*backend.ClusterHealth, error)
+}
+
+// ColdHealthChecker verifies that Quickwit can accept ingest before a run
+// starts, so an outage surfaces as one clear error instead of a failure per slice.
+type ColdHealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// healthGate pauses migration work while the hot cluster is unhealthy.
+// Checks are rate-limited to one per interval and the result is shared by
+// all slice workers.
+type healthGate struct {
+	checker ClusterHealthChecker
+	cfg     config.HealthGateConfig
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+func newHealthGate(checker ClusterHealthChecker, cfg config.HealthGateConfig) *healthGate {
+	return &healthGate{checker: checker, cfg: cfg}
+}
+
+// setConfig replaces the thresholds; the next check uses them.
+func (g *healthGate) setConfig(cfg config.HealthGateConfig) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cfg = cfg
+	g.lastCheck = time.Time{}
+}
+
+func (g *healthGate) config() config.HealthGateConfig {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cfg
+}
+
+// check returns nil if the cluster is within the configured thresholds.
+// A cached result is returned if the last check is younger than the interval.
+func (g *healthGate) check(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.lastCheck.IsZero() && time.Since(g.lastCheck) < g.cfg.Interval {
+		return g.lastErr
+	}
+	h, err := g.checker.ClusterHealth(ctx)
+	if err != nil {
+		g.lastErr = fmt.Errorf("checking cluster health: %w", err)
+	} else {
+		g.lastErr = evaluateClusterHealth(h, g.cfg)
+	}
+	g.lastCheck = time.Now()
+	return g.lastErr
+}
+
+// wait blocks while the cluster is unhealthy, re-checking every interval.
+// It returns an error if the cluster stays unhealthy for longer than
+// MaxPause or ctx is cancelled; callers abort and keep their checkpoint.
+func (g *healthGate) wait(ctx context.Context, index string) error {
+	err := g.check(ctx)
+	if err == nil {
+		return nil
+	}
+
+	cfg := g.config()
+	slog.Warn("pausing migration, opensearch cluster unhealthy", "index", index, "reason", err, "max_pause", cfg.MaxPause.String())
+	deadline := time.Now().Add(cfg.MaxPause)
+	for {
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("opensearch cluster unhealthy for longer than %s: %w", cfg.MaxPause, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(max(cfg.Interval, time.Millisecond)):
+		}
+		if err = g.check(ctx); err == nil {
+			slog.Info("resuming migration, opensearch cluster healthy", "index", index)
+			return nil
+		}
+	}
+}
+
+// evaluateClusterHealth compares a health snapshot against the configured
+// thresholds. A red cluster is always considered unhealthy.
+func evaluateClusterHealth(h *backend.ClusterHealth, cfg config.HealthGateConfig) error {
+	if clusterStatusRank(h.Status) > clusterStatusRank(cfg.MaxStatus) {
+		return fmt.Errorf("cluster status is %s (max allowed %s)", h.Status, cfg.MaxStatus)
+	}
+	if cfg.MaxPendingTasks > 0 && h.PendingTasks > cfg.MaxPendingTasks {
+		return fmt.Errorf("cluster has %d pending tasks (max allowed %d)", h.PendingTasks, cfg.MaxPendingTasks)
+	}
+	if cfg.MaxHeapPercent > 0 && h.MaxHeapUsedPercent > cfg.MaxHeapPercent {
+		return fmt.Errorf("node heap usage is %d%% (max allowed %d%%)", h.MaxHeapUsedPercent, cfg.MaxHeapPercent)
+	}
+	return nil
+}
+
+// clusterStatusRank orders cluster statuses from best to worst. Unknown
+// statuses rank as red so that a malformed response never passes the gate.
+func clusterStatusRank(status string) int {
+	switch status {
+	case "green":
+		return 0
+	case "yellow":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,165 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+type fakeHealth struct {
+	mu      sync.Mutex
+	results []*backend.ClusterHealth // returned in order; the last one repeats
+	calls   int
+}
+
+func (f *fakeHealth) ClusterHealth(_ context.Context) (*backend.ClusterHealth, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := min(f.calls, len(f.results)-1)
+	f.calls++
+	return f.results[i], nil
+}
+
+func TestEvaluateClusterHealth(t *testing.T) {
+	cfg := config.HealthGateConfig{MaxStatus: "yellow", MaxPendingTasks: 10, MaxHeapPercent: 85}
+	tests := []struct {
+		name    string
+		health  backend.ClusterHealth
+		wantErr bool
+	}{
+		{"green", backend.ClusterHealth{Status: "green"}, false},
+		{"yellow allowed", backend.ClusterHealth{Status: "yellow"}, false},
+		{"red", backend.ClusterHealth{Status: "red"}, true},
+		{"unknown status", backend.ClusterHealth{Status: ""}, true},
+		{"pending tasks", backend.ClusterHealth{Status: "green", PendingTasks: 11}, true},
+		{"heap", backend.ClusterHealth{Status: "green", MaxHeapUsedPercent: 90}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := evaluateClusterHealth(&tt.health, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluateClusterHealth(%+v) err=%v, wantErr=%v", tt.health, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := evaluateClusterHealth(&backend.ClusterHealth{Status: "yellow"}, config.HealthGateConfig{MaxStatus: "green"}); err == nil {
+		t.Fatalf("expected yellow to fail when max_status=green")
+	}
+}
+
+func TestHealthGate_Wait_PausesUntilHealthy(t *testing.T) {
+	checker := &fakeHealth{results: []*backend.ClusterHealth{
+		{Status: "red"},
+		{Status: "red"},
+		{Status: "green"},
+	}}
+	g := newHealthGate(checker, config.HealthGateConfig{MaxStatus: "yellow", Interval: time.Millisecond, MaxPause: time.Second})
+
+	if err := g.wait(context.Background(), "logs"); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if checker.calls != 3 {
+		t.Fatalf("health checks=%d, want 3", checker.calls)
+	}
+}
+
+func TestHealthGate_Wait_AbortsAfterMaxPause(t *testing.T) {
+	checker := &fakeHealth{results: []*backend.ClusterHealth{{Status: "red"}}}
+	g := newHealthGate(checker, config.HealthGateConfig{MaxStatus: "yellow", Interval: time.Millisecond, MaxPause: 10 * time.Millisecond})
+
+	err := g.wait(context.Background(), "logs")
+	if err == nil || !strings.Contains(err.Error(), "unhealthy for longer than") {
+		t.Fatalf("expected max pause error, got %v", err)
+	}
+}
+
+func TestHealthGate_Check_CachesWithinInterval(t *testing.T) {
+	checker := &fakeHealth{results: []*backend.ClusterHealth{{Status: "green"}}}
+	g := newHealthGate(checker, config.HealthGateConfig{MaxStatus: "yellow", Interval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if err := g.check(context.Background()); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+	if checker.calls != 1 {
+		t.Fatalf("health checks=%d, want 1", checker.calls)
+	}
+}
+
+func TestMigrator_MigrateIndex_HealthGate_AbortKeepsCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), makeHits(0, 1), nil},
+		1: {makeHits(1, 1), makeHits(1, 1), nil},
+	})
+	cold := newFakeCold()
+
+	cfg := defaultTestConfig()
+	cfg.Migration.HealthGate = config.HealthGateConfig{MaxStatus: "yellow", MaxPause: 5 * time.Millisecond}
+	cpStore, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	// Healthy for the pre-run check, then red for the rest of the run.
+	checker := &fakeHealth{results: []*backend.ClusterHealth{{Status: "green"}, {Status: "red"}}}
+	m, err := NewMigrator(cfg, hot, cold, cpStore, WithClusterHealth(checker))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	err = m.MigrateIndex(context.Background(), "logs")
+	if err == nil || !strings.Contains(err.Error(), "health gate") {
+		t.Fatalf("expected health gate error, got %v", err)
+	}
+
+	cp := readCheckpoint(t, dir, "logs")
+	if cp.Completed {
+		t.Fatalf("Completed=true, want false")
+	}
+	if cp.CutoffTime.IsZero() {
+		t.Fatalf("expected checkpoint to keep the cutoff time for resume")
+	}
+}
+
+type fakeColdHealth struct {
+	err error
+}
+
+func (f *fakeColdHealth) Health(_ context.Context) error { return f.err }
+
+func TestMigrator_MigrateAll_ColdHealthFailure_AbortsEarly(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), nil},
+	})
+	cold := newFakeCold()
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(defaultTestConfig(), hot, cold, cpStore,
+		WithColdHealthCheck(&fakeColdHealth{err: errors.New("no ready quickwit node runs the indexer service")}),
+	)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	err = m.MigrateAll(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "quickwit is not ready") {
+		t.Fatalf("expected quickwit readiness error, got %v", err)
+	}
+	hot.mu.Lock()
+	defer hot.mu.Unlock()
+	if len(hot.requested) != 0 {
+		t.Fatalf("expected no slices to start, got %v", hot.requested)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"github.com/leonunix/oqbridge/internal/backend"
 )
 
-// HotClient is the subset of OpenSearch operations needed by Migrator.
+// HotClient is the subset of OpenSearch operations needed by Migrator. The
+// migrator reads source indices by scrolling, so the hot backend must be a
+// backend.Scroller.
 type HotClient interface {
-	SlicedScroll(ctx context.Context, index string, body []byte, scrollID string, slice *backend.SlicedScrollConfig) (*backend.ScrollResult, error)
-	ClearScroll(ctx context.Context, scrollID string) error
+	backend.Scroller
 	DeleteByQuery(ctx context.Context, index string, body []byte) error
-	ResolveIndices(ctx context.Context, pattern string) ([]string, error)
+	ResolveIndices(ctx context.Context, pattern string) ([]backend.IndexInfo, error)
+	ResolveAlias(ctx context.Context, alias string) ([]string, error)
 }
 
 // ColdClient is the subset of Quickwit operations needed by Migrator.
@@ -21,3 +23,13 @@ type ColdClient interface {
 	IndexExists(ctx context.Context, index string) (bool, error)
 	CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error
 }
+
+// DocCounter counts the documents of an index matching a query.
+type DocCounter interface {
+	Count(ctx context.Context, index string, body []byte) (int64, error)
+}
+
+// ColdDescriber reports the size of a Quickwit index.
+type ColdDescriber interface {
+	DescribeIndex(ctx context.Context, index string) (*backend.IndexStats, error)
+}
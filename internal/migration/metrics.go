@@ -3,11 +3,14 @@ package migration
 import (
 	"context"
 	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
 )
 
 // MigrationMetric records the outcome of a single index migration run.
 type MigrationMetric struct {
 	Timestamp         time.Time `json:"@timestamp"`
+	Source            string    `json:"source,omitempty"` // migration.sources entry, if configured
 	Index             string    `json:"index"`
 	StartedAt         time.Time `json:"started_at"`
 	CompletedAt       time.Time `json:"completed_at"`
@@ -19,6 +22,49 @@ type MigrationMetric struct {
 	Status            string    `json:"status"` // "success" or "failed"
 	Error             string    `json:"error,omitempty"`
 	CutoffTime        time.Time `json:"cutoff_time"`
+
+	// Size of the Quickwit index after the run, when cold stats are enabled.
+	ColdDocs      int64 `json:"cold_docs,omitempty"`
+	ColdSplits    int64 `json:"cold_splits,omitempty"`
+	ColdSizeBytes int64 `json:"cold_size_bytes,omitempty"`
+
+	// Size of the OpenSearch index when the run started, when the index was
+	// resolved from a pattern. MigratedBytes estimates the share of the
+	// store size taken by the migrated documents.
+	SourceDocs      int64 `json:"source_docs,omitempty"`
+	SourceSizeBytes int64 `json:"source_size_bytes,omitempty"`
+	MigratedBytes   int64 `json:"migrated_bytes,omitempty"`
+
+	// Costs estimated at the cost prices, in CostCurrency: a month of
+	// storing the OpenSearch and Quickwit indices at the sizes above, and
+	// moving the migrated bytes.
+	CostCurrency      string  `json:"cost_currency,omitempty"`
+	SourceMonthlyCost float64 `json:"source_monthly_cost,omitempty"`
+	ColdMonthlyCost   float64 `json:"cold_monthly_cost,omitempty"`
+	EgressCost        float64 `json:"egress_cost,omitempty"`
+}
+
+// setCosts estimates the costs of the sizes recorded in m from the prices
+// per GB and month of the source and target clusters, and per GB moved.
+func (m *MigrationMetric) setCosts(source, target, egress float64, currency string) {
+	const gb = 1 << 30
+	m.SourceMonthlyCost = float64(m.SourceSizeBytes) / gb * source
+	m.ColdMonthlyCost = float64(m.ColdSizeBytes) / gb * target
+	m.EgressCost = float64(m.MigratedBytes) / gb * egress
+	if m.SourceMonthlyCost > 0 || m.ColdMonthlyCost > 0 || m.EgressCost > 0 {
+		m.CostCurrency = currency
+	}
+}
+
+// setSourceStats fills the source index size from info, estimating the
+// migrated bytes from the average document size.
+func (m *MigrationMetric) setSourceStats(info backend.IndexInfo) {
+	if info.DocsCount <= 0 || info.StoreSizeBytes < 0 {
+		return
+	}
+	m.SourceDocs, m.SourceSizeBytes = info.DocsCount, info.StoreSizeBytes
+	migrated := min(m.DocumentsMigrated, info.DocsCount)
+	m.MigratedBytes = int64(float64(info.StoreSizeBytes) * float64(migrated) / float64(info.DocsCount))
 }
 
 // MetricsRecorder persists migration metrics for later analysis.
@@ -7,16 +7,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/leonunix/oqbridge/internal/backend"
 )
 
 const metricsIndex = ".oqbridge-migration-metrics"
 
 // OpenSearchMetricsStore records migration metrics into an OpenSearch index.
 type OpenSearchMetricsStore struct {
-	baseURL  string
-	username string
-	password string
-	client   *http.Client
+	baseURL string
+	creds   *backend.Credentials
+	client  *http.Client
 }
 
 // NewOpenSearchMetricsStore creates a metrics store backed by OpenSearch.
@@ -25,10 +26,9 @@ func NewOpenSearchMetricsStore(baseURL, username, password string, httpClient *h
 		httpClient = &http.Client{}
 	}
 	return &OpenSearchMetricsStore{
-		baseURL:  baseURL,
-		username: username,
-		password: password,
-		client:   httpClient,
+		baseURL: baseURL,
+		creds:   backend.NewCredentials(username, password),
+		client:  httpClient,
 	}
 }
 
@@ -37,6 +37,125 @@ func (s *OpenSearchMetricsStore) Record(ctx context.Context, metric *MigrationMe
 	return s.putDoc(ctx, metricDocID(metric), metric)
 }
 
+// IndexRunSummary aggregates the recorded runs of one index.
+type IndexRunSummary struct {
+	Index         string           `json:"index"`
+	Runs          int64            `json:"runs"`
+	TotalMigrated int64            `json:"total_migrated"`
+	EgressCost    float64          `json:"egress_cost,omitempty"` // Sum over the runs.
+	LastRun       *MigrationMetric `json:"last_run,omitempty"`
+}
+
+// Summaries returns the run count, total documents migrated, egress cost
+// and most recent run for every index with recorded metrics, keyed by index name. A missing
+// metrics index yields an empty map.
+func (s *OpenSearchMetricsStore) Summaries(ctx context.Context) (map[string]*IndexRunSummary, error) {
+	query := []byte(`{"size":0,"aggs":{"by_index":{"terms":{"field":"index","size":10000},"aggs":{` +
+		`"total":{"sum":{"field":"documents_migrated"}},` +
+		`"egress":{"sum":{"field":"egress_cost"}},` +
+		`"last":{"top_hits":{"size":1,"sort":[{"started_at":{"order":"desc"}}]}}}}}}`)
+	respBody, err := s.search(ctx, query)
+	if err != nil || respBody == nil {
+		return map[string]*IndexRunSummary{}, err
+	}
+
+	var result struct {
+		Aggregations struct {
+			ByIndex struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int64  `json:"doc_count"`
+					Total    struct {
+						Value float64 `json:"value"`
+					} `json:"total"`
+					Egress struct {
+						Value float64 `json:"value"`
+					} `json:"egress"`
+					Last struct {
+						Hits struct {
+							Hits []struct {
+								Source MigrationMetric `json:"_source"`
+							} `json:"hits"`
+						} `json:"hits"`
+					} `json:"last"`
+				} `json:"buckets"`
+			} `json:"by_index"`
+		} `json:"aggregations"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parsing metrics response: %w", err)
+	}
+
+	summaries := make(map[string]*IndexRunSummary, len(result.Aggregations.ByIndex.Buckets))
+	for _, b := range result.Aggregations.ByIndex.Buckets {
+		sum := &IndexRunSummary{Index: b.Key, Runs: b.DocCount, TotalMigrated: int64(b.Total.Value), EgressCost: b.Egress.Value}
+		if hits := b.Last.Hits.Hits; len(hits) > 0 {
+			last := hits[0].Source
+			sum.LastRun = &last
+		}
+		summaries[b.Key] = sum
+	}
+	return summaries, nil
+}
+
+// Recent returns the n most recently started runs, newest first. A missing
+// metrics index yields none.
+func (s *OpenSearchMetricsStore) Recent(ctx context.Context, n int) ([]MigrationMetric, error) {
+	query := []byte(fmt.Sprintf(`{"size":%d,"sort":[{"started_at":{"order":"desc"}}]}`, n))
+	respBody, err := s.search(ctx, query)
+	if err != nil || respBody == nil {
+		return nil, err
+	}
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source MigrationMetric `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parsing metrics response: %w", err)
+	}
+	runs := make([]MigrationMetric, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		runs = append(runs, h.Source)
+	}
+	return runs, nil
+}
+
+// search runs query against the metrics index and returns the response
+// body, or nil if the index does not exist yet.
+func (s *OpenSearchMetricsStore) search(ctx context.Context, query []byte) ([]byte, error) {
+	url := fmt.Sprintf("%s/%s/_search", s.baseURL, metricsIndex)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("creating search request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.setAuth(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing search request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("searching metrics: %w", &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
+	}
+	return respBody, nil
+}
+
 // metricDocID returns a deterministic document ID for the metric,
 // allowing safe retries without creating duplicates.
 func metricDocID(m *MigrationMetric) string {
@@ -73,7 +192,11 @@ func (s *OpenSearchMetricsStore) putDoc(ctx context.Context, id string, doc inte
 		return s.putDocDirect(ctx, id, body)
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("put metric %s failed: status=%d body=%s", id, resp.StatusCode, string(respBody))
+		return fmt.Errorf("put metric %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
@@ -96,7 +219,11 @@ func (s *OpenSearchMetricsStore) putDocDirect(ctx context.Context, id string, bo
 	respBody, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("put metric %s failed: status=%d body=%s", id, resp.StatusCode, string(respBody))
+		return fmt.Errorf("put metric %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
@@ -112,6 +239,7 @@ func (s *OpenSearchMetricsStore) ensureIndex(ctx context.Context) error {
   "mappings": {
     "properties": {
       "@timestamp":          { "type": "date" },
+      "source":              { "type": "keyword" },
       "index":               { "type": "keyword" },
       "started_at":          { "type": "date" },
       "completed_at":        { "type": "date" },
@@ -122,7 +250,17 @@ func (s *OpenSearchMetricsStore) ensureIndex(ctx context.Context) error {
       "batch_size":          { "type": "integer" },
       "status":              { "type": "keyword" },
       "error":               { "type": "text" },
-      "cutoff_time":         { "type": "date" }
+      "cutoff_time":         { "type": "date" },
+      "cold_docs":           { "type": "long" },
+      "cold_splits":         { "type": "long" },
+      "cold_size_bytes":     { "type": "long" },
+      "source_docs":         { "type": "long" },
+      "source_size_bytes":   { "type": "long" },
+      "migrated_bytes":      { "type": "long" },
+      "cost_currency":       { "type": "keyword" },
+      "source_monthly_cost": { "type": "double" },
+      "cold_monthly_cost":   { "type": "double" },
+      "egress_cost":         { "type": "double" }
     }
   }
 }`
@@ -144,15 +282,23 @@ func (s *OpenSearchMetricsStore) ensureIndex(ctx context.Context) error {
 		return nil
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("creating metrics index: status=%d body=%s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("creating metrics index: %w", &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
 
+// SetCredentials makes the store authenticate with c instead of the
+// credentials it was created with.
+func (s *OpenSearchMetricsStore) SetCredentials(c *backend.Credentials) {
+	s.creds = c
+}
+
 func (s *OpenSearchMetricsStore) setAuth(req *http.Request) {
-	if s.username != "" {
-		req.SetBasicAuth(s.username, s.password)
-	}
+	s.creds.Apply(req)
 }
 
 // Verify compile-time interface compliance.
@@ -1,6 +1,7 @@
 package migration
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -44,7 +45,7 @@ func TestOpenSearchMetricsStore_Record(t *testing.T) {
 		CutoffTime:        start.Add(-25 * 24 * time.Hour),
 	}
 
-	if err := store.Record(t.Context(), metric); err != nil {
+	if err := store.Record(context.Background(), metric); err != nil {
 		t.Fatalf("Record: %v", err)
 	}
 
@@ -100,7 +101,7 @@ func TestOpenSearchMetricsStore_Record_IndexAutoCreate(t *testing.T) {
 	store := NewOpenSearchMetricsStore(srv.URL, "", "", srv.Client())
 
 	metric := NewSuccessMetric("logs-2026.01.15", time.Now(), 100, time.Now(), 4, 5000)
-	if err := store.Record(t.Context(), metric); err != nil {
+	if err := store.Record(context.Background(), metric); err != nil {
 		t.Fatalf("Record: %v", err)
 	}
 	if !indexCreated {
@@ -124,7 +125,7 @@ func TestOpenSearchMetricsStore_Record_WithAuth(t *testing.T) {
 	store := NewOpenSearchMetricsStore(srv.URL, "admin", "secret", srv.Client())
 
 	metric := NewSuccessMetric("logs", time.Now(), 0, time.Now(), 1, 1000)
-	if err := store.Record(t.Context(), metric); err != nil {
+	if err := store.Record(context.Background(), metric); err != nil {
 		t.Fatalf("Record: %v", err)
 	}
 	if capturedAuth == "" {
@@ -142,12 +143,75 @@ func TestOpenSearchMetricsStore_Record_ServerError(t *testing.T) {
 	store := NewOpenSearchMetricsStore(srv.URL, "", "", srv.Client())
 
 	metric := NewSuccessMetric("logs", time.Now(), 0, time.Now(), 1, 1000)
-	err := store.Record(t.Context(), metric)
+	err := store.Record(context.Background(), metric)
 	if err == nil {
 		t.Fatal("expected error for 500 response")
 	}
 }
 
+func TestOpenSearchMetricsStore_Summaries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/.oqbridge-migration-metrics/_search" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"aggregations":{"by_index":{"buckets":[
+			{"key":"logs-a","doc_count":3,"total":{"value":1500},"egress":{"value":0.25},
+			 "last":{"hits":{"hits":[{"_source":{"index":"logs-a","status":"failed","documents_migrated":0,"started_at":"2026-02-09T10:00:00Z"}}]}}}
+		]}}}`))
+	}))
+	defer srv.Close()
+
+	store := NewOpenSearchMetricsStore(srv.URL, "", "", srv.Client())
+	summaries, err := store.Summaries(context.Background())
+	if err != nil {
+		t.Fatalf("Summaries: %v", err)
+	}
+	sum, ok := summaries["logs-a"]
+	if !ok || sum.Runs != 3 || sum.TotalMigrated != 1500 || sum.EgressCost != 0.25 {
+		t.Fatalf("summary=%+v, want 3 runs, 1500 migrated and 0.25 egress", sum)
+	}
+	if sum.LastRun == nil || sum.LastRun.Status != "failed" {
+		t.Fatalf("last run=%+v, want failed", sum.LastRun)
+	}
+}
+
+func TestOpenSearchMetricsStore_Summaries_IndexMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	summaries, err := NewOpenSearchMetricsStore(srv.URL, "", "", srv.Client()).Summaries(context.Background())
+	if err != nil || len(summaries) != 0 {
+		t.Fatalf("Summaries=%v err=%v, want empty", summaries, err)
+	}
+}
+
+func TestOpenSearchMetricsStore_Recent(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query = string(body)
+		w.Write([]byte(`{"hits":{"hits":[
+			{"_source":{"index":"logs-b","status":"success","started_at":"2026-02-10T10:00:00Z"}},
+			{"_source":{"index":"logs-a","status":"failed","started_at":"2026-02-09T10:00:00Z"}}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	runs, err := NewOpenSearchMetricsStore(srv.URL, "", "", srv.Client()).Recent(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Index != "logs-b" || runs[1].Status != "failed" {
+		t.Fatalf("runs=%+v", runs)
+	}
+	if query != `{"size":2,"sort":[{"started_at":{"order":"desc"}}]}` {
+		t.Fatalf("query=%s", query)
+	}
+}
+
 func TestNewSuccessMetric(t *testing.T) {
 	start := time.Now().Add(-5 * time.Minute)
 	m := NewSuccessMetric("logs-2026.01.15", start, 50000, time.Now(), 4, 5000)
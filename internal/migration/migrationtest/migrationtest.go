@@ -0,0 +1,47 @@
+// Package migrationtest provides a fake of Quickwit's time range reads
+// for the tests of packages that read cold data with migration.ReadRange.
+package migrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Range is a migration.RangeReader holding one document per entry of
+// Times, {"@timestamp":<time>,"n":<i>} for the i-th, in any index.
+type Range struct {
+	Times []time.Time
+
+	searches atomic.Int64
+}
+
+func (f *Range) inRange(from, to time.Time) []json.RawMessage {
+	var docs []json.RawMessage
+	for i, ts := range f.Times {
+		if !ts.Before(from) && !ts.After(to) {
+			docs = append(docs, json.RawMessage(fmt.Sprintf(`{"@timestamp":%q,"n":%d}`, ts.Format(time.RFC3339Nano), i)))
+		}
+	}
+	return docs
+}
+
+// CountRange counts the documents in the inclusive range [from, to].
+func (f *Range) CountRange(_ context.Context, _, _ string, from, to time.Time) (int64, error) {
+	return int64(len(f.inRange(from, to))), nil
+}
+
+// SearchRange returns the first maxHits documents in the inclusive range
+// [from, to].
+func (f *Range) SearchRange(_ context.Context, _, _ string, from, to time.Time, maxHits int) ([]json.RawMessage, error) {
+	f.searches.Add(1)
+	docs := f.inRange(from, to)
+	return docs[:min(maxHits, len(docs))], nil
+}
+
+// Searches returns how often f was searched.
+func (f *Range) Searches() int {
+	return int(f.searches.Load())
+}
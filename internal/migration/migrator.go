@@ -3,6 +3,7 @@ package migration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
@@ -19,14 +20,15 @@ import (
 // e.g. "logs-2026.02.08", "dev-syslog-2026-01-15".
 var datePattern = regexp.MustCompile(`(\d{4})[.\-](\d{2})[.\-](\d{2})$`)
 
-// parseIndexDate extracts the trailing date from an index name.
+// parseIndexDate extracts the trailing date from an index name, as the
+// start of that day in loc (retention.timezone).
 // Returns the parsed date and true if a date suffix was found.
-func parseIndexDate(index string) (time.Time, bool) {
+func parseIndexDate(index string, loc *time.Location) (time.Time, bool) {
 	m := datePattern.FindStringSubmatch(index)
 	if m == nil {
 		return time.Time{}, false
 	}
-	t, err := time.Parse("2006-01-02", m[1]+"-"+m[2]+"-"+m[3])
+	t, err := time.ParseInLocation("2006-01-02", m[1]+"-"+m[2]+"-"+m[3], loc)
 	if err != nil {
 		return time.Time{}, false
 	}
@@ -43,12 +45,21 @@ type Progress struct {
 
 // Migrator handles parallel migration of data from OpenSearch to Quickwit.
 type Migrator struct {
-	cfg              *config.Config
+	cfg              atomic.Pointer[config.Config]
 	hot              HotClient
 	cold             ColdClient
 	checkpoint       CheckpointStore
-	lock             DistLock        // optional distributed lock to prevent multi-instance duplication
-	metrics          MetricsRecorder // optional metrics recorder for migration stats
+	lock             DistLock          // optional distributed lock to prevent multi-instance duplication
+	metrics          MetricsRecorder   // optional metrics recorder for migration stats
+	coldStats        ColdDescriber     // optional source of Quickwit index size for metrics
+	counter          DocCounter        // optional pre-migration document count
+	health           *healthGate       // optional OpenSearch health gate
+	coldHealth       ColdHealthChecker // optional Quickwit pre-run probe
+	dedup            *deduper          // optional pre-ingest existence check
+	snapshot         *snapshotSource   // optional snapshot repository source
+	rollups          RollupClient      // optional writer of migration.rollups summaries
+	window           *timeWindow       // optional explicit window overriding watermark/cutoff
+	budget           *byteBudget       // bytes of scrolled batches held in memory across all workers
 	lockTTL          time.Duration
 	progressInterval time.Duration
 	running          sync.Mutex // prevents overlapping MigrateAll runs from cron
@@ -72,6 +83,61 @@ func WithMetricsRecorder(mr MetricsRecorder) MigratorOption {
 	}
 }
 
+// WithColdStats adds the Quickwit index's document count, split count and
+// storage size after each run to the recorded migration metric.
+func WithColdStats(describer ColdDescriber) MigratorOption {
+	return func(m *Migrator) {
+		m.coldStats = describer
+	}
+}
+
+// WithPreCount counts the documents of each migration window before it is
+// scrolled. The count is kept in the checkpoint, and progress logs report
+// it with an estimate of the time left.
+func WithPreCount(counter DocCounter) MigratorOption {
+	return func(m *Migrator) {
+		m.counter = counter
+	}
+}
+
+// WithClusterHealth enables health gating: migration pauses while the
+// OpenSearch cluster breaches the configured migration.health_gate thresholds
+// and aborts (keeping its checkpoint) if it stays unhealthy too long.
+func WithClusterHealth(checker ClusterHealthChecker) MigratorOption {
+	return func(m *Migrator) {
+		m.health = newHealthGate(checker, m.config().Migration.HealthGate)
+	}
+}
+
+// WithColdHealthCheck probes Quickwit before each MigrateAll run and aborts
+// early when its metastore or indexers are unavailable.
+func WithColdHealthCheck(checker ColdHealthChecker) MigratorOption {
+	return func(m *Migrator) {
+		m.coldHealth = checker
+	}
+}
+
+// WithDedup enables the pre-ingest existence check: before each batch is
+// ingested, the document counts of its time span are compared between the
+// tiers and the batch is skipped if Quickwit already has it. This makes
+// re-running a window after a crash idempotent at the cost of two count
+// queries per batch.
+func WithDedup(hot, cold RangeCounter) MigratorOption {
+	return func(m *Migrator) {
+		m.dedup = &deduper{hot: hot, cold: cold}
+	}
+}
+
+// WithSnapshotSource makes the migrator read each index from a snapshot
+// (configured under migration.snapshot) restored into a temporary index,
+// keeping scroll load off the live index. Deletion of migrated documents
+// still applies to the live index.
+func WithSnapshotSource(client SnapshotClient) MigratorOption {
+	return func(m *Migrator) {
+		m.snapshot = &snapshotSource{client: client, cfg: m.config().Migration.Snapshot}
+	}
+}
+
 // WithLockTTL sets the TTL for distributed locks. Defaults to 2 hours.
 func WithLockTTL(ttl time.Duration) MigratorOption {
 	return func(m *Migrator) {
@@ -84,23 +150,58 @@ func WithLockTTL(ttl time.Duration) MigratorOption {
 // multi-instance deployments, or LocalCheckpointStore for single-instance/testing).
 func NewMigrator(cfg *config.Config, hot HotClient, cold ColdClient, cpStore CheckpointStore, opts ...MigratorOption) (*Migrator, error) {
 	m := &Migrator{
-		cfg:              cfg,
 		hot:              hot,
 		cold:             cold,
 		checkpoint:       cpStore,
+		budget:           newByteBudget(int64(cfg.Migration.MaxBufferedMB) << 20),
 		lockTTL:          2 * time.Hour,
 		progressInterval: 10 * time.Second,
 	}
+	m.cfg.Store(cfg)
 	for _, opt := range opts {
 		opt(m)
 	}
 	return m, nil
 }
 
+// SetConfig replaces the configuration, e.g. after the configuration file
+// was reloaded. Indices whose migration starts afterwards use the new
+// settings; the memory budget and health gate thresholds apply at once.
+// Whether the optional components are enabled is fixed at construction.
+func (m *Migrator) SetConfig(cfg *config.Config) {
+	m.cfg.Store(cfg)
+	m.budget.setLimit(int64(cfg.Migration.MaxBufferedMB) << 20)
+	if m.health != nil {
+		m.health.setConfig(cfg.Migration.HealthGate)
+	}
+}
+
+func (m *Migrator) config() *config.Config {
+	return m.cfg.Load()
+}
+
 // MigrateAll migrates all configured indices.
 // Wildcard patterns (e.g., "logs-*", "*") are resolved to concrete index
 // names via the OpenSearch _cat/indices API before migration.
 func (m *Migrator) MigrateAll(ctx context.Context) error {
+	_, err := m.MigrateAllWithReport(ctx)
+	return err
+}
+
+// MigrateAllWithReport is MigrateAll that also returns a per-index summary
+// of the run. The report is always non-nil, even when an error is returned.
+func (m *Migrator) MigrateAllWithReport(ctx context.Context) (*RunReport, error) {
+	source := m.config().Source()
+	report := &RunReport{Source: source, StartedAt: time.Now().UTC(), Indices: []IndexResult{}}
+	err := m.migrateAll(ctx, report)
+	for i := range report.Indices {
+		report.Indices[i].Source = source
+	}
+	report.finish(err)
+	return report, err
+}
+
+func (m *Migrator) migrateAll(ctx context.Context, report *RunReport) error {
 	// Prevent overlapping runs when cron fires while a previous migration
 	// is still in progress.
 	if !m.running.TryLock() {
@@ -109,14 +210,27 @@ func (m *Migrator) MigrateAll(ctx context.Context) error {
 	}
 	defer m.running.Unlock()
 
-	patterns := m.cfg.Migration.Indices
+	patterns := m.config().Migration.Indices
 	if len(patterns) == 0 {
 		slog.Info("no indices configured for migration, skipping")
 		return nil
 	}
 
-	migrateDays := m.cfg.Migration.MigrateAfterDays
-	cutoffDate := time.Now().UTC().AddDate(0, 0, -migrateDays).Truncate(24 * time.Hour)
+	if m.coldHealth != nil {
+		if err := m.coldHealth.Health(ctx); err != nil {
+			return fmt.Errorf("quickwit is not ready for ingest, aborting run: %w", err)
+		}
+	}
+
+	cfg := m.config()
+	now := time.Now().UTC()
+	cutoffDate := func(index string) time.Time {
+		if m.window != nil {
+			// A daily index dated on or after the window end holds no documents in it.
+			return m.window.To
+		}
+		return cfg.DaysAgo(now, cfg.MigrationPolicyForIndex(index).MigrateAfterDays)
+	}
 
 	var allErrors []error
 	for _, pattern := range patterns {
@@ -126,19 +240,47 @@ func (m *Migrator) MigrateAll(ctx context.Context) error {
 			allErrors = append(allErrors, fmt.Errorf("resolving %q: %w", pattern, err))
 			continue
 		}
-		for _, index := range concrete {
+		for _, info := range concrete {
+			index := info.Name
+			// retention.ttl deletes these documents instead.
+			if cfg.MigrationPolicyForIndex(index).Delete {
+				slog.Debug("skipping index of a delete rule", "index", index)
+				report.Indices = append(report.Indices, IndexResult{Index: index, Status: IndexStatusSkipped, Reason: ReasonDeleteRule})
+				continue
+			}
+			// The proxy already writes these documents to Quickwit.
+			if cfg.DualWriteIndex(index) {
+				slog.Debug("skipping dual-written index", "index", index)
+				report.Indices = append(report.Indices, IndexResult{Index: index, Status: IndexStatusSkipped, Reason: ReasonDualWrite})
+				continue
+			}
 			// Skip indices whose date suffix is after the cutoff. These indices
 			// contain only recent data and cannot have any documents eligible
 			// for migration, so opening scroll contexts on them is wasteful.
-			if indexDate, ok := parseIndexDate(index); ok && !indexDate.Before(cutoffDate) {
-				slog.Debug("skipping recent index", "index", index, "index_date", indexDate.Format("2006-01-02"), "cutoff", cutoffDate.Format("2006-01-02"))
+			if indexDate, ok := parseIndexDate(index, cfg.Location()); ok && !indexDate.Before(cutoffDate(index)) {
+				slog.Debug("skipping recent index", "index", index, "index_date", indexDate.Format("2006-01-02"), "cutoff", cutoffDate(index).Format("2006-01-02"))
+				report.Indices = append(report.Indices, IndexResult{Index: index, Status: IndexStatusSkipped, Reason: ReasonRecentIndex})
 				continue
 			}
-			if err := m.MigrateIndex(ctx, index); err != nil {
+			// An empty index has nothing to migrate; skip it before taking
+			// the lock and creating its Quickwit index.
+			if info.DocsCount == 0 {
+				slog.Debug("skipping empty index", "index", index)
+				report.Indices = append(report.Indices, IndexResult{Index: index, Status: IndexStatusSkipped, Reason: ReasonEmptyIndex})
+				continue
+			}
+			start := time.Now()
+			res := IndexResult{Index: index}
+			err := m.migrateIndex(ctx, info, &res)
+			res.DurationSec = time.Since(start).Seconds()
+			if err != nil {
 				slog.Error("migration failed for index", "index", index, "error", err)
+				res.Status, res.Error = IndexStatusFailed, err.Error()
+				report.Indices = append(report.Indices, res)
 				allErrors = append(allErrors, fmt.Errorf("migrating %s: %w", index, err))
 				continue
 			}
+			report.Indices = append(report.Indices, res)
 		}
 	}
 	if len(allErrors) > 0 {
@@ -147,11 +289,21 @@ func (m *Migrator) MigrateAll(ctx context.Context) error {
 	return nil
 }
 
-// resolvePattern expands a wildcard pattern to concrete index names.
-// If the pattern contains no wildcards, it is returned as-is.
-func (m *Migrator) resolvePattern(ctx context.Context, pattern string) ([]string, error) {
+// resolvePattern expands a wildcard pattern or an alias to concrete indices
+// with their metadata. Any other name is returned as-is with unknown
+// metadata.
+func (m *Migrator) resolvePattern(ctx context.Context, pattern string) ([]backend.IndexInfo, error) {
 	if !containsWildcard(pattern) {
-		return []string{pattern}, nil
+		backing, err := m.hot.ResolveAlias(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(backing) == 0 {
+			return []backend.IndexInfo{unknownIndex(pattern)}, nil
+		}
+		// _cat/indices expands the alias itself and reports the metadata of
+		// its backing indices.
+		slog.Info("resolved index alias", "alias", pattern, "indices", strings.Join(backing, ","))
 	}
 	resolved, err := m.hot.ResolveIndices(ctx, pattern)
 	if err != nil {
@@ -161,6 +313,12 @@ func (m *Migrator) resolvePattern(ctx context.Context, pattern string) ([]string
 	return resolved, nil
 }
 
+// unknownIndex describes an index that was named directly rather than
+// resolved, so its size is not known.
+func unknownIndex(name string) backend.IndexInfo {
+	return backend.IndexInfo{Name: name, DocsCount: -1, StoreSizeBytes: -1}
+}
+
 func containsWildcard(s string) bool {
 	return strings.ContainsAny(s, "*?[]")
 }
@@ -168,6 +326,14 @@ func containsWildcard(s string) bool {
 // MigrateIndex migrates documents older than the retention threshold from
 // OpenSearch to Quickwit using parallel sliced scroll workers.
 func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
+	return m.migrateIndex(ctx, unknownIndex(index), &IndexResult{Index: index})
+}
+
+// migrateIndex implements MigrateIndex, recording the outcome in res.
+// res.Status is left for the caller to set on error.
+func (m *Migrator) migrateIndex(ctx context.Context, info backend.IndexInfo, res *IndexResult) error {
+	index := info.Name
+	res.Status = IndexStatusUpToDate
 	// Acquire distributed lock if configured, preventing multiple instances
 	// from migrating the same index concurrently.
 	if m.lock != nil {
@@ -177,6 +343,7 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		}
 		if !acquired {
 			slog.Info("skipping index, migration lock held by another instance", "index", index)
+			res.Status, res.Reason = IndexStatusSkipped, ReasonLockHeld
 			return nil
 		}
 		defer func() {
@@ -192,15 +359,26 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		}()
 	}
 
-	tsField := m.cfg.TimestampFieldForIndex(index)
+	// Don't start opening scroll contexts on a cluster that is already struggling.
+	if m.health != nil {
+		if err := m.health.wait(ctx, index); err != nil {
+			return fmt.Errorf("health gate: %w", err)
+		}
+	}
+
+	cfg := m.config()
+	tsField := cfg.TimestampFieldForIndex(index)
+	policy := cfg.MigrationPolicyForIndex(index)
+	target := policy.TargetIndex
 
 	// Ensure Quickwit index exists before migration.
-	if err := m.ensureQuickwitIndex(ctx, index, tsField); err != nil {
+	if err := m.ensureQuickwitIndex(ctx, target, tsField); err != nil {
 		return fmt.Errorf("ensuring quickwit index: %w", err)
 	}
 
-	workers := m.cfg.Migration.Workers
-	batchSize := m.cfg.Migration.BatchSize
+	settings := cfg.MigrationSettingsForIndex(index)
+	workers := settings.Workers
+	batchSize := settings.BatchSize
 
 	// Load checkpoint for resume support.
 	cp, err := m.checkpoint.Load(index)
@@ -208,8 +386,11 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		slog.Warn("failed to load checkpoint, starting fresh", "index", index, "error", err)
 	}
 
-	migrateDays := m.cfg.Migration.MigrateAfterDays
-	cutoffTime := time.Now().UTC().AddDate(0, 0, -migrateDays)
+	migrateDays := policy.MigrateAfterDays
+	cutoffTime := cfg.DaysAgo(time.Now(), migrateDays).UTC()
+	if m.window != nil {
+		cutoffTime = m.window.To
+	}
 
 	// Load watermark from last successful run for incremental migration.
 	wm, wmErr := m.checkpoint.LoadWatermark(index)
@@ -219,12 +400,15 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 
 	slog.Info("starting migration",
 		"index", index,
+		"target", target,
 		"timestamp_field", tsField,
 		"migrate_after_days", migrateDays,
-		"cutoff", cutoffTime.Format(time.RFC3339),
+		"cutoff", formatBoundary(cutoffTime),
 		"watermark", watermarkStr(wm),
 		"workers", workers,
 		"batch_size", batchSize,
+		"compress", settings.Compress,
+		"filtered", policy.Filter != nil,
 		"resuming", cp != nil,
 	)
 
@@ -244,7 +428,7 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		// When resuming, use the original cutoff time to prevent time drift.
 		// If we recalculate from time.Now(), documents in the gap between the
 		// original and new cutoff may be missed or duplicated.
-		cutoffTime = cp.CutoffTime
+		cutoffTime = cp.CutoffTime.Truncate(time.Millisecond)
 	}
 
 	// Snapshot completed slices at start to avoid data races while workers run.
@@ -256,15 +440,53 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 
 	// Build the query for the incremental time window.
 	var fromTime *time.Time
-	if wm != nil && !wm.MigratedBefore.IsZero() {
-		fromTime = &wm.MigratedBefore
+	if m.window != nil {
+		if !m.window.From.IsZero() {
+			from := m.window.From
+			fromTime = &from
+		}
+	} else if wm != nil && !wm.MigratedBefore.IsZero() {
+		from := watermarkLowerBound(wm.MigratedBefore)
+		fromTime = &from
+	}
+
+	// Scroll a restored snapshot copy instead of the live index if configured.
+	source := index
+	if m.snapshot != nil {
+		restored, err := m.snapshot.restore(ctx, index)
+		if err != nil {
+			return fmt.Errorf("snapshot source: %w", err)
+		}
+		defer m.snapshot.cleanup(restored.Name)
+		source = restored.Name
+
+		// Documents written after the snapshot started may be missing from
+		// it, so the window must not extend past the snapshot start.
+		if snapStart := restored.Snapshot.StartTime.Truncate(time.Millisecond); snapStart.Before(cutoffTime) {
+			slog.Info("capping migration window at snapshot start", "index", index, "snapshot", restored.Snapshot.Name, "cutoff", formatBoundary(snapStart))
+			cutoffTime = snapStart
+			cp.CutoffTime = cutoffTime
+		}
+		if fromTime != nil && !cutoffTime.After(*fromTime) {
+			slog.Info("snapshot predates watermark, nothing to migrate", "index", index, "snapshot", restored.Snapshot.Name, "watermark", watermarkStr(wm))
+			return nil
+		}
 	}
-	query := buildMigrationQuery(tsField, fromTime, cutoffTime, batchSize)
+
+	query := withFilter(buildMigrationQuery(tsField, fromTime, cutoffTime, batchSize), policy.Filter)
 	queryBytes, err := json.Marshal(query)
 	if err != nil {
 		return fmt.Errorf("marshaling migration query: %w", err)
 	}
 
+	// A resumed run keeps the count of the window it started.
+	if m.counter != nil && cp.TotalDocs == 0 {
+		if cp.TotalDocs, err = m.counter.Count(ctx, source, queryBytes); err != nil {
+			slog.Warn("failed to count documents to migrate", "index", index, "error", err)
+		}
+	}
+	progress.TotalDocs = max(0, cp.TotalDocs-cp.Migrated)
+
 	// Launch parallel sliced scroll workers.
 	var wg sync.WaitGroup
 	errCh := make(chan error, workers)
@@ -279,7 +501,7 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		wg.Add(1)
 		go func(sliceID int) {
 			defer wg.Done()
-			if err := m.migrateSlice(ctx, index, queryBytes, sliceID, workers, progress, cp, &cpMu); err != nil {
+			if err := m.migrateSlice(ctx, index, source, target, policy.Transforms, queryBytes, sliceID, workers, progress, cp, &cpMu); err != nil {
 				errCh <- fmt.Errorf("slice %d: %w", sliceID, err)
 			}
 		}(i)
@@ -304,15 +526,32 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 	if len(errs) > 0 {
 		// Save checkpoint for resume.
 		m.checkpoint.Save(cp)
+		res.Migrated = progress.Migrated.Load()
 		sliceErr := fmt.Errorf("migration had %d slice errors, first: %w", len(errs), errs[0])
-		m.recordMetric(index, progress, cutoffTime, sliceErr)
+		m.recordMetric(info, progress, cutoffTime, sliceErr)
 		return sliceErr
 	}
 
+	// Summarize the window while OpenSearch still holds all of it. A failure
+	// leaves the checkpoint incomplete, so the next run skips the migrated
+	// slices and summarizes again before deleting anything.
+	if m.rollups != nil {
+		if err := m.rollUp(ctx, cfg, index, tsField, policy.Filter, fromTime, cutoffTime); err != nil {
+			m.checkpoint.Save(cp)
+			res.Migrated = progress.Migrated.Load()
+			m.recordMetric(info, progress, cutoffTime, err)
+			return err
+		}
+	}
+
 	totalMigrated := progress.Migrated.Load()
+	res.Migrated = totalMigrated
+	if totalMigrated > 0 {
+		res.Status = IndexStatusMigrated
+	}
 
 	// Delete migrated data from OpenSearch if configured.
-	if m.cfg.Migration.DeleteAfterMigration && totalMigrated > 0 {
+	if policy.DeleteAfterMigration && totalMigrated > 0 {
 		// Use a safety margin: only delete documents older than
 		// (cutoff - 1 hour) to avoid deleting late-arriving documents
 		// that were written to OpenSearch after our scroll finished but
@@ -323,7 +562,7 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 			"count", totalMigrated,
 			"safe_delete_cutoff", safeDeleteCutoff.Format(time.RFC3339),
 		)
-		deleteQuery := buildMigrationDeleteQuery(tsField, fromTime, safeDeleteCutoff)
+		deleteQuery := withFilter(buildMigrationDeleteQuery(tsField, fromTime, safeDeleteCutoff), policy.Filter)
 		deleteBytes, _ := json.Marshal(deleteQuery)
 		if err := m.hot.DeleteByQuery(ctx, index, deleteBytes); err != nil {
 			return fmt.Errorf("deleting migrated documents: %w", err)
@@ -346,41 +585,156 @@ func (m *Migrator) MigrateIndex(ctx context.Context, index string) error {
 		"elapsed", elapsed.Round(time.Second).String(),
 		"docs_per_sec", float64(totalMigrated)/elapsed.Seconds(),
 	)
-	m.recordMetric(index, progress, cutoffTime, nil)
+	m.recordMetric(info, progress, cutoffTime, nil)
 	return nil
 }
 
 // recordMetric records a migration metric if a MetricsRecorder is configured.
 // It uses a detached context to avoid being cancelled by parent shutdown.
-func (m *Migrator) recordMetric(index string, progress *Progress, cutoff time.Time, migErr error) {
+func (m *Migrator) recordMetric(info backend.IndexInfo, progress *Progress, cutoff time.Time, migErr error) {
 	if m.metrics == nil {
 		return
 	}
+	index := info.Name
+	settings := m.config().MigrationSettingsForIndex(index)
 	var metric *MigrationMetric
 	if migErr == nil {
-		metric = NewSuccessMetric(index, progress.StartTime, progress.Migrated.Load(), cutoff, m.cfg.Migration.Workers, m.cfg.Migration.BatchSize)
+		metric = NewSuccessMetric(index, progress.StartTime, progress.Migrated.Load(), cutoff, settings.Workers, settings.BatchSize)
 	} else {
-		metric = NewFailureMetric(index, progress.StartTime, progress.Migrated.Load(), cutoff, m.cfg.Migration.Workers, m.cfg.Migration.BatchSize, migErr)
+		metric = NewFailureMetric(index, progress.StartTime, progress.Migrated.Load(), cutoff, settings.Workers, settings.BatchSize, migErr)
 	}
+	metric.Source = m.config().Source()
+	metric.setSourceStats(info)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	if m.coldStats != nil {
+		target := m.config().QuickwitIndexForIndex(index)
+		if stats, err := m.coldStats.DescribeIndex(ctx, target); err != nil {
+			slog.Warn("failed to describe quickwit index", "index", target, "error", err)
+		} else {
+			metric.ColdDocs, metric.ColdSplits, metric.ColdSizeBytes = stats.NumDocs, stats.NumSplits, stats.SizeBytes
+		}
+	}
+	source, target := m.config().StoragePrices()
+	metric.setCosts(source, target, m.config().Cost.EgressGB, m.config().Cost.Currency)
 	if err := m.metrics.Record(ctx, metric); err != nil {
 		slog.Warn("failed to record migration metric", "index", index, "error", err)
 	}
 }
 
-// migrateSlice processes a single sliced scroll partition.
-func (m *Migrator) migrateSlice(ctx context.Context, index string, queryBytes []byte, sliceID, sliceMax int, progress *Progress, cp *Checkpoint, cpMu *sync.Mutex) error {
+// migrateSlice processes a single sliced scroll partition. Documents are
+// scrolled from source (the index itself, or its restored snapshot copy),
+// edited by transforms and ingested into the Quickwit index target.
+//
+// The slice runs as three pipelined stages connected by bounded channels:
+// a reader fetching scroll pages, a transformer extracting document sources
+// and an ingester uploading to Quickwit. The next page is fetched while the
+// previous batch is still uploading, so neither backend sits idle waiting
+// for the other. Up to migration.pipeline_depth batches are buffered
+// between stages.
+func (m *Migrator) migrateSlice(ctx context.Context, index, source, target string, transforms config.TransformConfig, queryBytes []byte, sliceID, sliceMax int, progress *Progress, cp *Checkpoint, cpMu *sync.Mutex) error {
+	scrollKeep := "10m"
+	if keep := m.config().Migration.ScrollKeepAlive; keep >= time.Second {
+		// OpenSearch time units have no compound form like "1h30m".
+		scrollKeep = fmt.Sprintf("%ds", keep/time.Second)
+	}
 	slice := &backend.SlicedScrollConfig{
 		SliceID:    sliceID,
 		SliceMax:   sliceMax,
-		ScrollKeep: "10m",
+		ScrollKeep: scrollKeep,
 	}
 
 	slog.Info("slice worker starting", "index", index, "slice", sliceID, "max", sliceMax)
 
+	// Cancelling stops the other stages when one fails: upstream stages
+	// would otherwise block on a full channel or the memory budget.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	depth := max(1, m.config().Migration.PipelineDepth)
+	pages := make(chan sliceBatch, depth)
+	batches := make(chan sliceBatch, depth)
+
+	var wg sync.WaitGroup
+	var readErr, transformErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(pages)
+		if readErr = m.readSlice(ctx, index, source, queryBytes, slice, pages); readErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(batches)
+		if transformErr = m.transformPages(ctx, transforms, pages, batches); transformErr != nil {
+			cancel()
+		}
+	}()
+
+	sliceMigrated, ingestErr := m.ingestBatches(ctx, index, source, target, sliceID, batches, progress)
+	if ingestErr != nil {
+		cancel()
+	}
+	wg.Wait()
+
+	// Return the budget held by batches still queued when a stage failed.
+	for b := range pages {
+		m.budget.release(b.bytes)
+	}
+	for b := range batches {
+		m.budget.release(b.bytes)
+	}
+
+	// Report the stage that failed first: a failure cancels the other
+	// stages, whose resulting context errors are secondary.
+	stageErrs := []error{ingestErr, transformErr, readErr}
+	for _, err := range stageErrs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range stageErrs {
+		if err != nil {
+			return err
+		}
+	}
+
+	// Mark this slice as done in checkpoint.
+	cpMu.Lock()
+	if !cp.IsSliceDone(sliceID) {
+		cp.SlicesDone = append(cp.SlicesDone, sliceID)
+	}
+	cp.Migrated += int64(sliceMigrated)
+	saveErr := m.checkpoint.Save(cp)
+	cpMu.Unlock()
+	if saveErr != nil {
+		return fmt.Errorf("saving checkpoint: %w", saveErr)
+	}
+
+	if sliceMigrated > 0 {
+		slog.Info("slice worker completed", "index", index, "slice", sliceID, "migrated", sliceMigrated)
+	} else {
+		slog.Debug("slice worker completed with no documents", "index", index, "slice", sliceID)
+	}
+	return nil
+}
+
+// sliceBatch is a batch moving through the slice pipeline together with the
+// bytes it holds against the migrator's memory budget.
+type sliceBatch struct {
+	docs  []json.RawMessage
+	bytes int64
+}
+
+// readSlice is the reader stage of migrateSlice. It scrolls source and sends
+// each non-empty page of hits to out, clearing the scroll context on return.
+// Each page is charged to the memory budget before it is passed on, so a
+// worker stops scrolling while the budget is exhausted.
+func (m *Migrator) readSlice(ctx context.Context, index, source string, queryBytes []byte, slice *backend.SlicedScrollConfig, out chan<- sliceBatch) error {
 	// Initial scroll.
-	result, err := m.hot.SlicedScroll(ctx, index, queryBytes, "", slice)
+	result, err := m.hot.SlicedScroll(ctx, source, queryBytes, "", slice)
 	if err != nil {
 		return fmt.Errorf("initiating scroll: %w", err)
 	}
@@ -394,55 +748,116 @@ func (m *Migrator) migrateSlice(ctx context.Context, index string, queryBytes []
 			clearCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 			if err := m.hot.ClearScroll(clearCtx, activeScrollID); err != nil {
-				slog.Warn("failed to clear scroll", "slice", sliceID, "error", err)
+				slog.Warn("failed to clear scroll", "slice", slice.SliceID, "error", err)
 			}
 		}
 	}()
 
-	sliceMigrated := 0
-
 	for len(result.Hits) > 0 {
-		// Transform documents.
-		docs, err := TransformBatch(result.Hits)
-		if err != nil {
-			return fmt.Errorf("transforming batch: %w", err)
+		page := sliceBatch{docs: result.Hits, bytes: rawSize(result.Hits)}
+		if err := m.budget.acquire(ctx, page.bytes); err != nil {
+			return err
 		}
-
-		// Ingest into Quickwit.
-		if err := m.cold.BulkIngest(ctx, index, docs); err != nil {
-			return fmt.Errorf("ingesting batch: %w", err)
+		select {
+		case out <- page:
+		case <-ctx.Done():
+			m.budget.release(page.bytes)
+			return ctx.Err()
 		}
 
-		batchLen := len(docs)
-		sliceMigrated += batchLen
-		progress.Migrated.Add(int64(batchLen))
+		// Back off before requesting the next page if the cluster turned unhealthy.
+		if m.health != nil {
+			if err := m.health.wait(ctx, index); err != nil {
+				return fmt.Errorf("health gate: %w", err)
+			}
+		}
 
 		// Continue scroll.
-		result, err = m.hot.SlicedScroll(ctx, index, nil, result.ScrollID, slice)
+		result, err = m.hot.SlicedScroll(ctx, source, nil, result.ScrollID, slice)
 		if err != nil {
 			return fmt.Errorf("continuing scroll: %w", err)
 		}
 		activeScrollID = result.ScrollID
 	}
+	return nil
+}
 
-	// Mark this slice as done in checkpoint.
-	cpMu.Lock()
-	if !cp.IsSliceDone(sliceID) {
-		cp.SlicesDone = append(cp.SlicesDone, sliceID)
+// transformPages is the transform stage of migrateSlice. A batch keeps the
+// budget charged for its raw page until it has been ingested.
+func (m *Migrator) transformPages(ctx context.Context, transforms config.TransformConfig, in <-chan sliceBatch, out chan<- sliceBatch) error {
+	for page := range in {
+		docs, err := TransformBatch(page.docs)
+		if err == nil {
+			err = ApplyTransforms(docs, transforms)
+		}
+		if err != nil {
+			m.budget.release(page.bytes)
+			return fmt.Errorf("transforming batch: %w", err)
+		}
+		select {
+		case out <- sliceBatch{docs: docs, bytes: page.bytes}:
+		case <-ctx.Done():
+			m.budget.release(page.bytes)
+			return ctx.Err()
+		}
 	}
-	cp.Migrated += int64(sliceMigrated)
-	saveErr := m.checkpoint.Save(cp)
-	cpMu.Unlock()
-	if saveErr != nil {
-		return fmt.Errorf("saving checkpoint: %w", saveErr)
+	return nil
+}
+
+// rawSize returns the total encoded size of docs.
+func rawSize(docs []json.RawMessage) int64 {
+	var n int64
+	for _, d := range docs {
+		n += int64(len(d))
 	}
+	return n
+}
 
-	if sliceMigrated > 0 {
-		slog.Info("slice worker completed", "index", index, "slice", sliceID, "migrated", sliceMigrated)
-	} else {
-		slog.Debug("slice worker completed with no documents", "index", index, "slice", sliceID)
+// ingestBatches is the ingest stage of migrateSlice. It returns the number
+// of documents ingested, which excludes batches skipped by the dedup check.
+func (m *Migrator) ingestBatches(ctx context.Context, index, source, target string, sliceID int, in <-chan sliceBatch, progress *Progress) (int, error) {
+	migrated := 0
+	for b := range in {
+		n, err := m.ingestBatch(ctx, index, source, target, sliceID, b.docs)
+		m.budget.release(b.bytes)
+		if err != nil {
+			return migrated, err
+		}
+		migrated += n
+		progress.Migrated.Add(int64(n))
 	}
-	return nil
+	return migrated, nil
+}
+
+// ingestBatch uploads one batch to the Quickwit index target unless the
+// dedup check finds it already present, returning the number of documents
+// ingested.
+func (m *Migrator) ingestBatch(ctx context.Context, index, source, target string, sliceID int, docs []json.RawMessage) (int, error) {
+	skip := false
+	if m.dedup != nil {
+		var err error
+		skip, err = m.dedup.alreadyIngested(ctx, target, source, m.config().TimestampFieldForIndex(index), docs)
+		if err != nil {
+			return 0, fmt.Errorf("dedup check: %w", err)
+		}
+	}
+
+	if skip {
+		slog.Debug("skipping batch already present in quickwit", "index", index, "slice", sliceID, "docs", len(docs))
+		return 0, nil
+	}
+
+	// Ingest into Quickwit.
+	if err := m.cold.BulkIngest(ctx, target, docs); err != nil {
+		return 0, fmt.Errorf("ingesting batch: %w", err)
+	}
+	return len(docs), nil
+}
+
+// BufferedBytes returns the bytes of scrolled batches currently held in
+// memory by all slice workers, awaiting transform or ingest.
+func (m *Migrator) BufferedBytes() int64 {
+	return m.budget.inUse()
 }
 
 func (m *Migrator) reportProgress(progress *Progress, stop <-chan struct{}, tick <-chan time.Time) {
@@ -454,61 +869,108 @@ func (m *Migrator) reportProgress(progress *Progress, stop <-chan struct{}, tick
 			migrated := progress.Migrated.Load()
 			elapsed := time.Since(progress.StartTime)
 			rate := float64(migrated) / elapsed.Seconds()
-			slog.Info("migration progress",
+			attrs := []any{
 				"index", progress.Index,
 				"migrated", migrated,
 				"elapsed", elapsed.Round(time.Second).String(),
 				"docs_per_sec", int(rate),
-			)
+				"buffered_bytes", m.BufferedBytes(),
+			}
+			if progress.TotalDocs > 0 && rate > 0 {
+				left := time.Duration(float64(max(0, progress.TotalDocs-migrated)) / rate * float64(time.Second))
+				attrs = append(attrs, "total_docs", progress.TotalDocs, "eta", left.Round(time.Second).String())
+			}
+			slog.Info("migration progress", attrs...)
 		}
 	}
 }
 
+// boundaryLayout is the format used for migration window bounds. Windows are
+// half-open [from, cutoff) at millisecond precision, which matches the
+// resolution of OpenSearch date fields: a document whose timestamp equals a
+// cutoff is excluded from the run that used it as "lt" and included in the
+// next run that uses it as "gte", so it is migrated exactly once.
+const boundaryLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// formatBoundary renders a window bound in UTC at millisecond precision.
+func formatBoundary(t time.Time) string {
+	return t.UTC().Truncate(time.Millisecond).Format(boundaryLayout)
+}
+
+// watermarkLowerBound returns the inclusive lower bound for the next window.
+// Watermarks written before bounds were millisecond-aligned carry sub-millisecond
+// precision, and the run that produced them queried "lt" at second precision;
+// those are truncated to the second so that no documents fall between runs.
+func watermarkLowerBound(migratedBefore time.Time) time.Time {
+	if migratedBefore.Nanosecond()%int(time.Millisecond) != 0 {
+		return migratedBefore.Truncate(time.Second)
+	}
+	return migratedBefore
+}
+
 // buildMigrationQuery builds a scroll query for the incremental time window.
 // If fromTime is nil, it migrates all data older than cutoff (first run).
-// Otherwise it migrates data in [fromTime, cutoff).
+// Otherwise it migrates data in [fromTime, cutoff). Hits are ordered by
+// timestamp with _id as a tiebreaker so that documents sharing a timestamp
+// are always returned in the same order.
 func buildMigrationQuery(tsField string, fromTime *time.Time, cutoff time.Time, size int) map[string]interface{} {
-	rangeClause := map[string]interface{}{
-		"lt": cutoff.Format(time.RFC3339),
-	}
-	if fromTime != nil {
-		rangeClause["gte"] = fromTime.Format(time.RFC3339)
-	}
 	return map[string]interface{}{
 		"size": size,
 		"sort": []map[string]string{
 			{tsField: "asc"},
+			{"_id": "asc"},
 		},
 		"query": map[string]interface{}{
 			"range": map[string]interface{}{
-				tsField: rangeClause,
+				tsField: windowRange(fromTime, cutoff),
 			},
 		},
 	}
 }
 
-// buildMigrationDeleteQuery builds a delete-by-query for the same time window.
+// buildMigrationDeleteQuery builds a delete-by-query for the same time window,
+// using the same half-open boundary semantics as buildMigrationQuery.
 func buildMigrationDeleteQuery(tsField string, fromTime *time.Time, cutoff time.Time) map[string]interface{} {
-	rangeClause := map[string]interface{}{
-		"lt": cutoff.Format(time.RFC3339),
-	}
-	if fromTime != nil {
-		rangeClause["gte"] = fromTime.Format(time.RFC3339)
-	}
 	return map[string]interface{}{
 		"query": map[string]interface{}{
 			"range": map[string]interface{}{
-				tsField: rangeClause,
+				tsField: windowRange(fromTime, cutoff),
 			},
 		},
 	}
 }
 
+// withFilter restricts a migration or delete query to the documents that
+// also match filter, a migration.rules filter clause. A nil filter leaves
+// the query unchanged.
+func withFilter(query map[string]interface{}, filter map[string]any) map[string]interface{} {
+	if filter == nil {
+		return query
+	}
+	query["query"] = map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{query["query"], filter},
+		},
+	}
+	return query
+}
+
+// windowRange builds the range clause for the half-open window [fromTime, cutoff).
+func windowRange(fromTime *time.Time, cutoff time.Time) map[string]interface{} {
+	rangeClause := map[string]interface{}{
+		"lt": formatBoundary(cutoff),
+	}
+	if fromTime != nil {
+		rangeClause["gte"] = formatBoundary(*fromTime)
+	}
+	return rangeClause
+}
+
 func watermarkStr(wm *Watermark) string {
 	if wm == nil || wm.MigratedBefore.IsZero() {
 		return "none (first run)"
 	}
-	return wm.MigratedBefore.Format(time.RFC3339)
+	return formatBoundary(wm.MigratedBefore)
 }
 
 // ensureQuickwitIndex checks if the index exists in Quickwit and creates it if not.
@@ -521,7 +983,7 @@ func (m *Migrator) ensureQuickwitIndex(ctx context.Context, index, tsField strin
 		slog.Info("quickwit index already exists", "index", index)
 		return nil
 	}
-	coldDays := m.cfg.ColdDaysForIndex(index)
+	coldDays := m.config().ColdDaysForIndex(index)
 	slog.Info("creating quickwit index", "index", index, "timestamp_field", tsField, "retention_days", coldDays)
 	if err := m.cold.CreateIndex(ctx, index, tsField, coldDays); err != nil {
 		return fmt.Errorf("creating index: %w", err)
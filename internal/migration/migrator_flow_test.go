@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -31,8 +33,23 @@ type fakeHot struct {
 	// record whether a slice was requested.
 	requested map[int]bool
 
+	// scrolledIndices records the index named by each initial scroll.
+	scrolledIndices []string
+
+	// queries records the body of each initial scroll.
+	queries [][]byte
+
+	// scrollKeeps records the keep-alive of each initial scroll.
+	scrollKeeps []string
+
 	// resolvedIndices maps pattern → concrete index names for ResolveIndices.
 	resolvedIndices map[string][]string
+
+	// indexInfo overrides the metadata ResolveIndices reports for an index.
+	indexInfo map[string]backend.IndexInfo
+
+	// aliases maps alias → backing index names for ResolveAlias.
+	aliases map[string][]string
 }
 
 func newFakeHot(pages map[int][][]json.RawMessage) *fakeHot {
@@ -44,7 +61,7 @@ func newFakeHot(pages map[int][][]json.RawMessage) *fakeHot {
 	}
 }
 
-func (f *fakeHot) SlicedScroll(_ context.Context, _ string, body []byte, scrollID string, slice *backend.SlicedScrollConfig) (*backend.ScrollResult, error) {
+func (f *fakeHot) SlicedScroll(_ context.Context, index string, body []byte, scrollID string, slice *backend.SlicedScrollConfig) (*backend.ScrollResult, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -53,6 +70,9 @@ func (f *fakeHot) SlicedScroll(_ context.Context, _ string, body []byte, scrollI
 			return nil, fmt.Errorf("missing slice config")
 		}
 		f.requested[slice.SliceID] = true
+		f.scrolledIndices = append(f.scrolledIndices, index)
+		f.queries = append(f.queries, body)
+		f.scrollKeeps = append(f.scrollKeeps, slice.ScrollKeep)
 		if ch := f.allowStart[slice.SliceID]; ch != nil {
 			f.mu.Unlock()
 			<-ch
@@ -83,19 +103,30 @@ func (f *fakeHot) SlicedScroll(_ context.Context, _ string, body []byte, scrollI
 	}, nil
 }
 
+func (f *fakeHot) ResolveAlias(_ context.Context, alias string) ([]string, error) {
+	return f.aliases[alias], nil
+}
+
 func (f *fakeHot) ClearScroll(_ context.Context, _ string) error             { return nil }
 func (f *fakeHot) DeleteByQuery(_ context.Context, _ string, _ []byte) error { return nil }
 
 // resolvedIndices maps pattern → concrete index names for ResolveIndices.
-// If nil, defaults to returning []string{"logs"}.
-func (f *fakeHot) ResolveIndices(_ context.Context, pattern string) ([]string, error) {
+// If nil, defaults to returning "logs". Indices without an indexInfo entry
+// are reported with unknown size.
+func (f *fakeHot) ResolveIndices(_ context.Context, pattern string) ([]backend.IndexInfo, error) {
+	names := []string{"logs"}
 	if f.resolvedIndices != nil {
-		if v, ok := f.resolvedIndices[pattern]; ok {
-			return v, nil
+		names = f.resolvedIndices[pattern]
+	}
+	var out []backend.IndexInfo
+	for _, name := range names {
+		info, ok := f.indexInfo[name]
+		if !ok {
+			info = unknownIndex(name)
 		}
-		return nil, nil
+		out = append(out, info)
 	}
-	return []string{"logs"}, nil
+	return out, nil
 }
 
 type fakeCold struct {
@@ -219,6 +250,284 @@ func TestMigrator_MigrateIndex_Success_MultipleSlices(t *testing.T) {
 	}
 }
 
+func TestMigrator_MigrateIndex_PipelinesScrollAndIngest(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), makeHits(0, 1), nil},
+	})
+	cold := newFakeCold()
+
+	// Hold the first upload until the reader has fetched the second page;
+	// a sequential slice would never get there and the wait would time out.
+	overlapped := false
+	first := true
+	cold.onIngest = func(_ string, _ []json.RawMessage) {
+		if !first {
+			return
+		}
+		first = false
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			hot.mu.Lock()
+			fetched := hot.pos[0]
+			hot.mu.Unlock()
+			if fetched >= 2 {
+				overlapped = true
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	m := newTestMigrator(t, hot, cold, t.TempDir())
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	if !overlapped {
+		t.Fatalf("next scroll page was not fetched while the previous batch was ingesting")
+	}
+	cold.mu.Lock()
+	defer cold.mu.Unlock()
+	if got := len(cold.docsByIndex["logs"]); got != 2 {
+		t.Fatalf("ingested docs=%d, want 2", got)
+	}
+}
+
+func TestMigrator_MigrateSlice_TransformErrorStopsReader(t *testing.T) {
+	// The first page has a hit without _source; more pages than the pipeline
+	// holds follow, so the reader blocks unless the failure stops it.
+	pages := [][]json.RawMessage{{json.RawMessage(`{"_id":"1"}`)}}
+	for i := 0; i < 5; i++ {
+		pages = append(pages, makeHits(0, 1))
+	}
+	hot := newFakeHot(map[int][][]json.RawMessage{0: append(pages, nil)})
+	m := newTestMigrator(t, hot, newFakeCold(), t.TempDir())
+	m.config().Migration.PipelineDepth = 1
+
+	done := make(chan error, 1)
+	go func() {
+		progress := &Progress{Index: "logs", StartTime: time.Now()}
+		done <- m.migrateSlice(context.Background(), "logs", "logs", "logs", config.TransformConfig{}, nil, 0, 1, progress, &Checkpoint{}, &sync.Mutex{})
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "hit missing _source field") {
+			t.Fatalf("migrateSlice = %v, want the transform error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("migrateSlice did not return after the transform failed")
+	}
+	if got := m.BufferedBytes(); got != 0 {
+		t.Errorf("buffered bytes = %d after the slice failed, want 0", got)
+	}
+}
+
+type fakeDocCounter struct {
+	index string
+	body  []byte
+	n     int64
+}
+
+func (f *fakeDocCounter) Count(_ context.Context, index string, body []byte) (int64, error) {
+	f.index, f.body = index, body
+	return f.n, nil
+}
+
+func TestMigrator_MigrateIndex_PreCount(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 2), nil},
+		1: {makeHits(1, 1), nil},
+	})
+	counter := &fakeDocCounter{n: 3}
+	dir := t.TempDir()
+	cpStore, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(defaultTestConfig(), hot, newFakeCold(), cpStore, WithPreCount(counter))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	hot.mu.Lock()
+	query := string(hot.queries[0])
+	hot.mu.Unlock()
+	if counter.index != "logs" || string(counter.body) != query {
+		t.Errorf("counted %q with %s, want the scroll query %s", counter.index, counter.body, query)
+	}
+	if cp := readCheckpoint(t, dir, "logs"); cp.TotalDocs != 3 || cp.Migrated != 3 {
+		t.Errorf("checkpoint TotalDocs=%d Migrated=%d, want 3/3", cp.TotalDocs, cp.Migrated)
+	}
+
+	// A resumed run keeps the count of the run it resumes.
+	if err := cpStore.Save(&Checkpoint{Index: "logs", TotalDocs: 10, SlicesDone: []int{0, 1}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	counter.index = ""
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("resumed MigrateIndex: %v", err)
+	}
+	if counter.index != "" {
+		t.Errorf("resumed run counted %q again", counter.index)
+	}
+}
+
+func TestMigrator_MigrateIndex_CutoffInTimezone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	yaml := `
+opensearch:
+  url: "http://os:9200"
+quickwit:
+  url: "http://qw:7280"
+retention:
+  days: 30
+  timezone: "Asia/Shanghai"
+migration:
+  migrate_after_days: 7
+  indices: ["logs"]
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 1), nil}})
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, newFakeCold(), cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	before := cfg.DaysAgo(time.Now(), 7)
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	after := cfg.DaysAgo(time.Now(), 7)
+	var q map[string]interface{}
+	hot.mu.Lock()
+	json.Unmarshal(hot.queries[0], &q)
+	hot.mu.Unlock()
+	// The cutoff is midnight in Shanghai, where the router cuts too.
+	lt := extractRangeField(t, q, "@timestamp")["lt"]
+	if lt != formatBoundary(before) && lt != formatBoundary(after) {
+		t.Errorf("lt = %v, want %s", lt, formatBoundary(before))
+	}
+}
+
+func TestMigrator_MigrateIndex_UsesPerIndexWorkers(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), nil},
+		1: {makeHits(1, 1), nil},
+		2: {makeHits(2, 1), nil},
+	})
+	cold := newFakeCold()
+	cfg := defaultTestConfig()
+	cfg.Migration.IndexOverrides = map[string]config.IndexOverride{"lo*": {Workers: 3}}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, cold, cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	hot.mu.Lock()
+	defer hot.mu.Unlock()
+	if len(hot.requested) != 3 {
+		t.Fatalf("slices requested=%v, want 3 from the override", hot.requested)
+	}
+}
+
+func TestMigrator_MigrateIndex_UsesScrollKeepAlive(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 1), nil}})
+	cfg := defaultTestConfig()
+	cfg.Migration.Workers = 1
+	cfg.Migration.ScrollKeepAlive = 90 * time.Minute
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, newFakeCold(), cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	hot.mu.Lock()
+	defer hot.mu.Unlock()
+	if len(hot.scrollKeeps) != 1 || hot.scrollKeeps[0] != "5400s" {
+		t.Fatalf("scroll keep-alives=%v, want [5400s]", hot.scrollKeeps)
+	}
+}
+
+func TestMigrator_MigrateIndex_AppliesRule(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 2), nil},
+	})
+	cold := newFakeCold()
+	cfg := defaultTestConfig()
+	cfg.Migration.Workers = 1
+	cfg.Migration.Rules = []config.MigrationRule{{
+		Indices:     []string{"lo*"},
+		Filter:      map[string]any{"term": map[string]any{"level": "error"}},
+		Transforms:  config.TransformConfig{DropFields: []string{"slice"}, RenameFields: map[string]string{"n": "seq"}},
+		TargetIndex: "archive-{index}",
+	}}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, cold, cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+
+	hot.mu.Lock()
+	var q struct {
+		Query struct {
+			Bool struct {
+				Filter []map[string]any `json:"filter"`
+			} `json:"bool"`
+		} `json:"query"`
+	}
+	json.Unmarshal(hot.queries[0], &q)
+	hot.mu.Unlock()
+	if len(q.Query.Bool.Filter) != 2 || q.Query.Bool.Filter[1]["term"] == nil {
+		t.Errorf("scroll query = %s, want the window range and the rule filter", hot.queries[0])
+	}
+
+	cold.mu.Lock()
+	defer cold.mu.Unlock()
+	docs := cold.docsByIndex["archive-logs"]
+	if len(docs) != 2 || len(cold.docsByIndex["logs"]) != 0 {
+		t.Fatalf("ingested = %v, want 2 docs in archive-logs", cold.docsByIndex)
+	}
+	if got := string(docs[1]); got != `{"seq":1}` {
+		t.Errorf("transformed doc = %s, want {\"seq\":1}", got)
+	}
+}
+
 func TestMigrator_MigrateIndex_Resume_SkipsCompletedSlice(t *testing.T) {
 	dir := t.TempDir()
 
@@ -432,3 +741,166 @@ func TestMigrator_MigrateAll_SkipsRecentIndices(t *testing.T) {
 		t.Fatalf("recent index %q should have been skipped, but got %d docs", recentIndex, len(docs))
 	}
 }
+
+type fakeMetrics struct {
+	mu      sync.Mutex
+	metrics []*MigrationMetric
+}
+
+func (f *fakeMetrics) Record(_ context.Context, metric *MigrationMetric) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metrics = append(f.metrics, metric)
+	return nil
+}
+
+type fakeDescriber struct{}
+
+func (fakeDescriber) DescribeIndex(_ context.Context, index string) (*backend.IndexStats, error) {
+	return &backend.IndexStats{Index: index, NumDocs: 42, NumSplits: 3, SizeBytes: 1 << 20}, nil
+}
+
+func TestMigrator_MigrateIndex_RecordsColdStats(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), nil},
+		1: {nil},
+	})
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	metrics := &fakeMetrics{}
+	m, err := NewMigrator(defaultTestConfig(), hot, newFakeCold(), cpStore,
+		WithMetricsRecorder(metrics), WithColdStats(fakeDescriber{}))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	if len(metrics.metrics) != 1 {
+		t.Fatalf("recorded %d metrics, want 1", len(metrics.metrics))
+	}
+	got := metrics.metrics[0]
+	if got.ColdDocs != 42 || got.ColdSplits != 3 || got.ColdSizeBytes != 1<<20 {
+		t.Fatalf("metric cold stats=%d/%d/%d, want 42/3/%d", got.ColdDocs, got.ColdSplits, got.ColdSizeBytes, 1<<20)
+	}
+}
+
+func TestMigrator_MigrateAll_SkipsDualWrittenAndDeletedIndices(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 2), nil}})
+	hot.resolvedIndices = map[string][]string{"logs-*": {"logs-mirrored", "logs-debug"}}
+	hot.indexInfo = map[string]backend.IndexInfo{
+		"logs-mirrored": {Name: "logs-mirrored", DocsCount: 2},
+		"logs-debug":    {Name: "logs-debug", DocsCount: 2},
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"logs-*"}
+	cfg.Migration.Rules = []config.MigrationRule{{Indices: []string{"logs-debug"}, Action: "delete", DeleteAfterDays: 7}}
+	cfg.DualWrite = config.DualWriteConfig{Enabled: true, Indices: []string{"logs-mirror*"}}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	cold := newFakeCold()
+	m, err := NewMigrator(cfg, hot, cold, cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	report, err := m.MigrateAllWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateAllWithReport: %v", err)
+	}
+	if len(report.Indices) != 2 || report.Indices[0].Reason != ReasonDualWrite || report.Indices[1].Reason != ReasonDeleteRule {
+		t.Fatalf("indices=%+v, want logs-mirrored skipped for dual_write and logs-debug for its delete rule", report.Indices)
+	}
+	if len(cold.docsByIndex) != 0 {
+		t.Fatalf("skipped indices should not have been migrated: %v", cold.docsByIndex)
+	}
+}
+
+func TestMigrator_MigrateAll_SkipsEmptyAndRecordsSourceStats(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 2), nil},
+		1: {makeHits(1, 2), nil},
+	})
+	hot.resolvedIndices = map[string][]string{"logs-*": {"logs-a", "logs-empty"}}
+	hot.indexInfo = map[string]backend.IndexInfo{
+		"logs-a":     {Name: "logs-a", DocsCount: 8, StoreSizeBytes: 8000},
+		"logs-empty": {Name: "logs-empty", DocsCount: 0, StoreSizeBytes: 208},
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"logs-*"}
+	cfg.Cost = config.CostConfig{Currency: "EUR", HotGBMonth: 1 << 20, EgressGB: 1 << 19}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	metrics := &fakeMetrics{}
+	cold := newFakeCold()
+	m, err := NewMigrator(cfg, hot, cold, cpStore, WithMetricsRecorder(metrics))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	report, err := m.MigrateAllWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateAllWithReport: %v", err)
+	}
+	if len(report.Indices) != 2 {
+		t.Fatalf("indices=%+v, want 2 entries", report.Indices)
+	}
+	if got := report.Indices[1]; got.Index != "logs-empty" || got.Status != IndexStatusSkipped || got.Reason != ReasonEmptyIndex {
+		t.Fatalf("empty index result=%+v", got)
+	}
+	if _, ok := cold.docsByIndex["logs-empty"]; ok {
+		t.Fatalf("empty index should not have been migrated")
+	}
+
+	if len(metrics.metrics) != 1 {
+		t.Fatalf("recorded %d metrics, want 1", len(metrics.metrics))
+	}
+	got := metrics.metrics[0]
+	if got.SourceDocs != 8 || got.SourceSizeBytes != 8000 || got.MigratedBytes != 4000 {
+		t.Fatalf("metric source stats=%d/%d/%d, want 8/8000/4000", got.SourceDocs, got.SourceSizeBytes, got.MigratedBytes)
+	}
+	// 8000 bytes at 1 per KiB and month, 4000 moved at 0.5 per KiB.
+	if got.CostCurrency != "EUR" || math.Abs(got.SourceMonthlyCost-7.8125) > 1e-9 || math.Abs(got.EgressCost-1.953125) > 1e-9 || got.ColdMonthlyCost != 0 {
+		t.Fatalf("metric costs=%s %g/%g/%g", got.CostCurrency, got.SourceMonthlyCost, got.ColdMonthlyCost, got.EgressCost)
+	}
+}
+
+func TestMigrator_MigrateAll_ExpandsAlias(t *testing.T) {
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), nil},
+		1: {nil},
+	})
+	hot.aliases = map[string][]string{"logs": {"logs-a", "logs-b"}}
+	hot.resolvedIndices = map[string][]string{"logs": {"logs-a", "logs-b"}}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"logs", "metrics"}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, newFakeCold(), cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	report, err := m.MigrateAllWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateAllWithReport: %v", err)
+	}
+	var got []string
+	for _, res := range report.Indices {
+		got = append(got, res.Index)
+	}
+	if want := []string{"logs-a", "logs-b", "metrics"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("migrated indices %v, want %v", got, want)
+	}
+}
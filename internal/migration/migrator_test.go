@@ -14,13 +14,13 @@ func TestBuildMigrationQuery_FirstRun(t *testing.T) {
 	}
 
 	sortAny, ok := q["sort"].([]map[string]string)
-	if !ok || len(sortAny) != 1 || sortAny[0]["@timestamp"] != "asc" {
-		t.Fatalf("sort=%v, want [{@timestamp:asc}]", q["sort"])
+	if !ok || len(sortAny) != 2 || sortAny[0]["@timestamp"] != "asc" || sortAny[1]["_id"] != "asc" {
+		t.Fatalf("sort=%v, want [{@timestamp:asc} {_id:asc}]", q["sort"])
 	}
 
 	fieldAny := extractRangeField(t, q, "@timestamp")
-	if fieldAny["lt"] != "2026-01-01T00:00:00Z" {
-		t.Fatalf("lt=%v, want 2026-01-01T00:00:00Z", fieldAny["lt"])
+	if fieldAny["lt"] != "2026-01-01T00:00:00.000Z" {
+		t.Fatalf("lt=%v, want 2026-01-01T00:00:00.000Z", fieldAny["lt"])
 	}
 	if _, exists := fieldAny["gte"]; exists {
 		t.Fatalf("gte should not be set on first run, got %v", fieldAny["gte"])
@@ -33,11 +33,11 @@ func TestBuildMigrationQuery_IncrementalRun(t *testing.T) {
 	q := buildMigrationQuery("@timestamp", &from, cutoff, 5000)
 
 	fieldAny := extractRangeField(t, q, "@timestamp")
-	if fieldAny["lt"] != "2026-01-01T00:00:00Z" {
-		t.Fatalf("lt=%v, want 2026-01-01T00:00:00Z", fieldAny["lt"])
+	if fieldAny["lt"] != "2026-01-01T00:00:00.000Z" {
+		t.Fatalf("lt=%v, want 2026-01-01T00:00:00.000Z", fieldAny["lt"])
 	}
-	if fieldAny["gte"] != "2025-12-31T00:00:00Z" {
-		t.Fatalf("gte=%v, want 2025-12-31T00:00:00Z", fieldAny["gte"])
+	if fieldAny["gte"] != "2025-12-31T00:00:00.000Z" {
+		t.Fatalf("gte=%v, want 2025-12-31T00:00:00.000Z", fieldAny["gte"])
 	}
 }
 
@@ -46,8 +46,8 @@ func TestBuildMigrationDeleteQuery_FirstRun(t *testing.T) {
 	q := buildMigrationDeleteQuery("ts", nil, cutoff)
 
 	fieldAny := extractRangeField(t, q, "ts")
-	if fieldAny["lt"] != "2026-01-01T00:00:00Z" {
-		t.Fatalf("lt=%v, want 2026-01-01T00:00:00Z", fieldAny["lt"])
+	if fieldAny["lt"] != "2026-01-01T00:00:00.000Z" {
+		t.Fatalf("lt=%v, want 2026-01-01T00:00:00.000Z", fieldAny["lt"])
 	}
 	if _, exists := fieldAny["gte"]; exists {
 		t.Fatalf("gte should not be set on first run")
@@ -60,8 +60,45 @@ func TestBuildMigrationDeleteQuery_IncrementalRun(t *testing.T) {
 	q := buildMigrationDeleteQuery("ts", &from, cutoff)
 
 	fieldAny := extractRangeField(t, q, "ts")
-	if fieldAny["gte"] != "2025-12-25T00:00:00Z" {
-		t.Fatalf("gte=%v, want 2025-12-25T00:00:00Z", fieldAny["gte"])
+	if fieldAny["gte"] != "2025-12-25T00:00:00.000Z" {
+		t.Fatalf("gte=%v, want 2025-12-25T00:00:00.000Z", fieldAny["gte"])
+	}
+}
+
+func TestBuildMigrationQuery_MillisecondBoundaries(t *testing.T) {
+	from := time.Date(2025, 12, 31, 10, 0, 0, 250_000_000, time.UTC)
+	cutoff := time.Date(2026, 1, 1, 10, 0, 0, 999_999_999, time.UTC)
+	q := buildMigrationQuery("@timestamp", &from, cutoff, 10)
+	d := buildMigrationDeleteQuery("@timestamp", &from, cutoff)
+
+	for name, field := range map[string]map[string]interface{}{
+		"migration": extractRangeField(t, q, "@timestamp"),
+		"delete":    extractRangeField(t, d, "@timestamp"),
+	} {
+		if field["gte"] != "2025-12-31T10:00:00.250Z" {
+			t.Fatalf("%s gte=%v, want 2025-12-31T10:00:00.250Z", name, field["gte"])
+		}
+		if field["lt"] != "2026-01-01T10:00:00.999Z" {
+			t.Fatalf("%s lt=%v, want 2026-01-01T10:00:00.999Z", name, field["lt"])
+		}
+		if _, ok := field["lte"]; ok {
+			t.Fatalf("%s window must be half-open, got lte=%v", name, field["lte"])
+		}
+	}
+}
+
+func TestWatermarkLowerBound(t *testing.T) {
+	aligned := time.Date(2026, 1, 1, 10, 0, 0, 123_000_000, time.UTC)
+	if got := watermarkLowerBound(aligned); !got.Equal(aligned) {
+		t.Fatalf("watermarkLowerBound(%v)=%v, want unchanged", aligned, got)
+	}
+
+	// Legacy watermarks carry nanoseconds; the run that wrote them used a
+	// second-precision "lt", so the next window must start at that second.
+	legacy := time.Date(2026, 1, 1, 10, 0, 0, 123_456_789, time.UTC)
+	want := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	if got := watermarkLowerBound(legacy); !got.Equal(want) {
+		t.Fatalf("watermarkLowerBound(%v)=%v, want %v", legacy, got, want)
 	}
 }
 
@@ -81,7 +118,7 @@ func TestParseIndexDate(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.index, func(t *testing.T) {
-			got, ok := parseIndexDate(tc.index)
+			got, ok := parseIndexDate(tc.index, time.UTC)
 			if ok != tc.ok {
 				t.Fatalf("parseIndexDate(%q) ok=%v, want %v", tc.index, ok, tc.ok)
 			}
@@ -92,6 +129,14 @@ func TestParseIndexDate(t *testing.T) {
 	}
 }
 
+func TestParseIndexDate_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	got, ok := parseIndexDate("logs-2026.02.08", loc)
+	if want := time.Date(2026, 2, 7, 16, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Fatalf("parseIndexDate() = %v, %v, want %v", got, ok, want)
+	}
+}
+
 func extractRangeField(t *testing.T, q map[string]interface{}, field string) map[string]interface{} {
 	t.Helper()
 	queryAny, ok := q["query"].(map[string]interface{})
@@ -108,3 +153,21 @@ func extractRangeField(t *testing.T, q map[string]interface{}, field string) map
 	}
 	return fieldAny
 }
+
+func TestWithFilter(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := buildMigrationDeleteQuery("ts", nil, cutoff)
+	if got := withFilter(q, nil); got["query"].(map[string]interface{})["range"] == nil {
+		t.Errorf("nil filter changed the query: %v", got)
+	}
+
+	filter := map[string]any{"term": map[string]any{"level": "error"}}
+	q = withFilter(buildMigrationDeleteQuery("ts", nil, cutoff), filter)
+	clauses := q["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+	if len(clauses) != 2 {
+		t.Fatalf("filter clauses = %v, want range and term", clauses)
+	}
+	if _, ok := clauses[0].(map[string]interface{})["range"]; !ok {
+		t.Errorf("first clause = %v, want the window range", clauses[0])
+	}
+}
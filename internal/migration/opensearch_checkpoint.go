@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
 )
 
 const stateIndex = ".oqbridge-state"
@@ -17,10 +21,9 @@ const stateIndex = ".oqbridge-state"
 // This is essential for multi-instance deployments where instances need to
 // share migration progress and know what time range was already migrated.
 type OpenSearchCheckpointStore struct {
-	baseURL  string
-	username string
-	password string
-	client   *http.Client
+	baseURL string
+	creds   *backend.Credentials
+	client  *http.Client
 }
 
 // NewOpenSearchCheckpointStore creates a checkpoint store backed by OpenSearch.
@@ -29,10 +32,9 @@ func NewOpenSearchCheckpointStore(baseURL, username, password string, httpClient
 		httpClient = &http.Client{}
 	}
 	return &OpenSearchCheckpointStore{
-		baseURL:  baseURL,
-		username: username,
-		password: password,
-		client:   httpClient,
+		baseURL: baseURL,
+		creds:   backend.NewCredentials(username, password),
+		client:  httpClient,
 	}
 }
 
@@ -126,7 +128,11 @@ func (s *OpenSearchCheckpointStore) getDoc(ctx context.Context, id string) (json
 		return nil, nil
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("get doc %s failed: status=%d", id, resp.StatusCode)
+		return nil, fmt.Errorf("get doc %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 
 	var result struct {
@@ -173,7 +179,11 @@ func (s *OpenSearchCheckpointStore) putDoc(ctx context.Context, id string, doc i
 		return s.putDocDirect(ctx, id, body)
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("put doc %s failed: status=%d body=%s", id, resp.StatusCode, string(respBody))
+		return fmt.Errorf("put doc %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
@@ -196,7 +206,11 @@ func (s *OpenSearchCheckpointStore) putDocDirect(ctx context.Context, id string,
 	respBody, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("put doc %s failed: status=%d body=%s", id, resp.StatusCode, string(respBody))
+		return fmt.Errorf("put doc %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
@@ -224,13 +238,164 @@ func (s *OpenSearchCheckpointStore) ensureIndex(ctx context.Context) error {
 		return nil
 	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("creating state index: status=%d body=%s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("creating state index: %w", &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
 	}
 	return nil
 }
 
+// SetCredentials makes the store authenticate with c, so checkpoint and
+// watermark writes follow credential rotation.
+func (s *OpenSearchCheckpointStore) SetCredentials(c *backend.Credentials) {
+	s.creds = c
+}
+
 func (s *OpenSearchCheckpointStore) setAuth(req *http.Request) {
-	if s.username != "" {
-		req.SetBasicAuth(s.username, s.password)
+	s.creds.Apply(req)
+}
+
+// List returns the state of every index with a checkpoint or watermark
+// document in the state index.
+func (s *OpenSearchCheckpointStore) List() ([]IndexState, error) {
+	url := fmt.Sprintf("%s/%s/_search", s.baseURL, stateIndex)
+	body := []byte(`{"size":10000,"query":{"match_all":{}}}`)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating search request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.setAuth(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing search request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("listing state: %w", &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parsing search response: %w", err)
+	}
+
+	byIndex := make(map[string]*IndexState)
+	get := func(index string) *IndexState {
+		if st, ok := byIndex[index]; ok {
+			return st
+		}
+		st := &IndexState{Index: index}
+		byIndex[index] = st
+		return st
+	}
+	for _, hit := range result.Hits.Hits {
+		switch {
+		case strings.HasPrefix(hit.ID, "checkpoint-"):
+			var cp Checkpoint
+			if err := json.Unmarshal(hit.Source, &cp); err != nil {
+				return nil, fmt.Errorf("parsing checkpoint %s: %w", hit.ID, err)
+			}
+			get(strings.TrimPrefix(hit.ID, "checkpoint-")).Checkpoint = &cp
+		case strings.HasPrefix(hit.ID, "watermark-"):
+			var wm Watermark
+			if err := json.Unmarshal(hit.Source, &wm); err != nil {
+				return nil, fmt.Errorf("parsing watermark %s: %w", hit.ID, err)
+			}
+			get(strings.TrimPrefix(hit.ID, "watermark-")).Watermark = &wm
+		}
+	}
+
+	states := make([]IndexState, 0, len(byIndex))
+	for _, st := range byIndex {
+		states = append(states, *st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Index < states[j].Index })
+	return states, nil
+}
+
+// State returns the stored checkpoint (completed or not) and watermark for index.
+func (s *OpenSearchCheckpointStore) State(index string) (*IndexState, error) {
+	st := &IndexState{Index: index}
+	doc, err := s.getDoc(context.Background(), "checkpoint-"+index)
+	if err != nil {
+		return nil, err
+	}
+	if doc != nil {
+		var cp Checkpoint
+		if err := json.Unmarshal(doc, &cp); err != nil {
+			return nil, fmt.Errorf("parsing checkpoint: %w", err)
+		}
+		st.Checkpoint = &cp
+	}
+	if st.Watermark, err = s.LoadWatermark(index); err != nil {
+		return nil, err
+	}
+	if st.Checkpoint == nil && st.Watermark == nil {
+		return nil, nil
+	}
+	return st, nil
+}
+
+// Reset deletes the checkpoint document for index and, with resetWatermark,
+// its watermark document.
+func (s *OpenSearchCheckpointStore) Reset(index string, resetWatermark bool) error {
+	if err := s.deleteDoc(context.Background(), "checkpoint-"+index); err != nil {
+		return err
 	}
+	if resetWatermark {
+		return s.deleteDoc(context.Background(), "watermark-"+index)
+	}
+	return nil
+}
+
+// deleteDoc deletes a document by ID from the state index. A missing
+// document is not an error.
+func (s *OpenSearchCheckpointStore) deleteDoc(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s/%s/_doc/%s?refresh=true", s.baseURL, stateIndex, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating delete request: %w", err)
+	}
+	s.setAuth(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing delete request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("delete doc %s: %w", id, &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		})
+	}
+	return nil
 }
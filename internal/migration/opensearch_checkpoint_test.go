@@ -2,11 +2,14 @@ package migration
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
 )
 
 func TestOpenSearchCheckpointStore_SaveAndLoad(t *testing.T) {
@@ -317,3 +320,102 @@ func TestOpenSearchCheckpointStore_SharedBetweenInstances(t *testing.T) {
 // Verify compile-time interface compliance.
 var _ CheckpointStore = (*OpenSearchCheckpointStore)(nil)
 var _ CheckpointStore = (*LocalCheckpointStore)(nil)
+
+func TestOpenSearchCheckpointStore_ListAndReset(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/.oqbridge-state/_search":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"hits":{"hits":[
+				{"_id":"watermark-logs-b","_source":{"index":"logs-b","migrated_before":"2026-01-01T00:00:00Z"}},
+				{"_id":"checkpoint-logs-b","_source":{"index":"logs-b","migrated":42,"slices_done":[0,1]}},
+				{"_id":"watermark-logs-a","_source":{"index":"logs-a","migrated_before":"2026-01-02T00:00:00Z"}},
+				{"_id":"oqbridge-migration","_source":{"owner":"host-1"}}
+			]}}`))
+		case r.Method == http.MethodDelete:
+			id := r.URL.Path[len("/.oqbridge-state/_doc/"):]
+			deleted = append(deleted, id)
+			if id == "watermark-logs-b" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"result":"deleted"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	store := NewOpenSearchCheckpointStore(srv.URL, "", "", srv.Client())
+
+	states, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(states) != 2 || states[0].Index != "logs-a" || states[1].Index != "logs-b" {
+		t.Fatalf("List=%+v, want logs-a and logs-b", states)
+	}
+	if states[0].Checkpoint != nil || states[0].Watermark == nil {
+		t.Fatalf("logs-a state=%+v, want watermark only", states[0])
+	}
+	if cp := states[1].Checkpoint; cp == nil || cp.Migrated != 42 || len(cp.SlicesDone) != 2 {
+		t.Fatalf("logs-b checkpoint=%+v, want 42 migrated over 2 slices", cp)
+	}
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if wm := states[1].Watermark; wm == nil || !wm.MigratedBefore.Equal(want) {
+		t.Fatalf("logs-b watermark=%+v, want %v", wm, want)
+	}
+
+	if err := store.Reset("logs-b", false); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	// A missing watermark document is not an error.
+	if err := store.Reset("logs-b", true); err != nil {
+		t.Fatalf("Reset with watermark: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	wantDeleted := []string{"checkpoint-logs-b", "checkpoint-logs-b", "watermark-logs-b"}
+	if len(deleted) != len(wantDeleted) {
+		t.Fatalf("deleted=%v, want %v", deleted, wantDeleted)
+	}
+	for i := range wantDeleted {
+		if deleted[i] != wantDeleted[i] {
+			t.Fatalf("deleted=%v, want %v", deleted, wantDeleted)
+		}
+	}
+}
+
+func TestOpenSearchCheckpointStore_ListMissingIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	store := NewOpenSearchCheckpointStore(srv.URL, "", "", srv.Client())
+	states, err := store.List()
+	if err != nil || len(states) != 0 {
+		t.Fatalf("List=%v err=%v, want empty", states, err)
+	}
+}
+
+func TestOpenSearchCheckpointStore_Save_HTTPStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"rejected"}`))
+	}))
+	defer srv.Close()
+
+	store := NewOpenSearchCheckpointStore(srv.URL, "", "", srv.Client())
+	err := store.Save(&Checkpoint{Index: "logs"})
+	var httpErr *backend.HTTPStatusError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 *HTTPStatusError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RangePageHits is the most documents ReadRange reads from Quickwit per
+// search, its max_hits limit. Larger time ranges are split until each part
+// fits.
+const RangePageHits = 10000
+
+// MinRangeWindow is the shortest time range ReadRange splits a range into,
+// the precision of the timestamps it searches by. A range of a single
+// window holding more than RangePageHits documents cannot be read.
+const MinRangeWindow = time.Millisecond
+
+// RangeReader counts and reads the documents of a time range. Quickwit
+// cannot page past max_hits, so ranges are read whole.
+type RangeReader interface {
+	RangeCounter
+	SearchRange(ctx context.Context, index, tsField string, from, to time.Time, maxHits int) ([]json.RawMessage, error)
+}
+
+// ReadRange passes every document of index whose tsField lies in the
+// inclusive range [from, to] to emit, halving the range until each part
+// holds at most RangePageHits documents, down to MinRangeWindow. Parts are
+// emitted oldest first.
+func ReadRange(ctx context.Context, r RangeReader, index, tsField string, from, to time.Time, emit func([]json.RawMessage) error) error {
+	n, err := r.CountRange(ctx, index, tsField, from, to)
+	if err != nil {
+		return fmt.Errorf("counting documents: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	if n <= RangePageHits {
+		docs, err := r.SearchRange(ctx, index, tsField, from, to, int(n))
+		if err != nil {
+			return fmt.Errorf("reading documents: %w", err)
+		}
+		if int64(len(docs)) < n {
+			return fmt.Errorf("quickwit returned %d of %d documents between %s and %s", len(docs), n, from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+		}
+		return emit(docs)
+	}
+	if to.Sub(from) < MinRangeWindow {
+		return fmt.Errorf("%d documents lie within %s of %s, more than can be read at once (%d)", n, MinRangeWindow, from.Format(time.RFC3339Nano), RangePageHits)
+	}
+	mid := from.Add((to.Sub(from) / 2).Truncate(MinRangeWindow))
+	if err := ReadRange(ctx, r, index, tsField, from, mid, emit); err != nil {
+		return err
+	}
+	return ReadRange(ctx, r, index, tsField, mid.Add(MinRangeWindow), to, emit)
+}
@@ -0,0 +1,47 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/migration/migrationtest"
+)
+
+func TestReadRange_SplitsLargeRanges(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cold := &migrationtest.Range{}
+	for i := range RangePageHits + 10 {
+		cold.Times = append(cold.Times, from.Add(time.Duration(i)*time.Second))
+	}
+
+	var got []int
+	err := ReadRange(context.Background(), cold, "logs", "@timestamp", from, from.Add(24*time.Hour), func(docs []json.RawMessage) error {
+		for _, doc := range docs {
+			var d struct{ N int }
+			json.Unmarshal(doc, &d)
+			got = append(got, d.N)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if len(got) != len(cold.Times) || !slices.IsSorted(got) || cold.Searches() < 2 {
+		t.Errorf("read %d documents in %d searches, want %d in order in several", len(got), cold.Searches(), len(cold.Times))
+	}
+
+	// Documents within one window cannot be split further.
+	cold.Times = slices.Repeat([]time.Time{from.Add(time.Second)}, RangePageHits+1)
+	searches := cold.Searches()
+	err = ReadRange(context.Background(), cold, "logs", "@timestamp", from, from.Add(time.Hour), func([]json.RawMessage) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "lie within 1ms of 2026-01-01T00:00:01Z") {
+		t.Errorf("ReadRange error = %v, want too many documents within one window", err)
+	}
+	if cold.Searches() != searches {
+		t.Error("ReadRange searched a range it could not read")
+	}
+}
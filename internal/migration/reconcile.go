@@ -0,0 +1,205 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Reconciliation statuses for one day of an index.
+const (
+	ReconcileStatusOK        = "ok"
+	ReconcileStatusMissing   = "missing"   // the sink holds fewer documents than the source
+	ReconcileStatusSurplus   = "surplus"   // the sink holds more documents than the source
+	ReconcileStatusDecreased = "decreased" // the sink lost documents since the previous pass
+	ReconcileStatusError     = "error"
+)
+
+// ReconcileHot is the source side of a reconciliation.
+type ReconcileHot interface {
+	RangeCounter
+	ResolveIndices(ctx context.Context, pattern string) ([]backend.IndexInfo, error)
+}
+
+// ReconcileResult is the reconciliation of one day of an index.
+type ReconcileResult struct {
+	Source    string    `json:"source,omitempty"` // migration.sources or tiers entry, if configured
+	Index     string    `json:"index"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"` // end of the day, or the watermark for the day it falls in
+	Status    string    `json:"status"`
+	HotCount  int64     `json:"hot_count"`
+	ColdCount int64     `json:"cold_count"`
+	// ColdOnly is set when the source is not expected to match the sink
+	// (delete_after_migration or a rule filter), so only the sink count is
+	// compared with the previous pass.
+	ColdOnly      bool   `json:"cold_only,omitempty"`
+	PrevColdCount int64  `json:"prev_cold_count,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ReconcileReport is the result of one reconciliation pass.
+type ReconcileReport struct {
+	Days       []ReconcileResult `json:"days"`
+	Mismatches int               `json:"mismatches"`
+	Errors     int               `json:"errors"`
+}
+
+// Reconciler compares, day by day, the documents an index holds on both
+// sides of its watermark: in the OpenSearch cluster it is migrated from and
+// in the sink it is migrated into. Unlike Verifier it only looks at the
+// last migration.reconcile.days days before the watermark, so it is cheap
+// enough to run on a schedule. It also remembers the sink counts of the
+// previous pass and reports days whose documents disappeared from a
+// Quickwit sink before their cold retention period.
+type Reconciler struct {
+	cfg        atomic.Pointer[config.Config]
+	hot        ReconcileHot
+	sink       RangeCounter
+	checkpoint CheckpointStore
+	now        func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]int64 // sink count by index and day at the previous pass
+}
+
+// NewReconciler creates a Reconciler for the source selected in cfg (see
+// config.ForSource). The checkpoint store provides each index's watermark;
+// indices that were never migrated are skipped.
+func NewReconciler(cfg *config.Config, hot ReconcileHot, sink RangeCounter, checkpoint CheckpointStore) *Reconciler {
+	r := &Reconciler{hot: hot, sink: sink, checkpoint: checkpoint, now: time.Now, seen: make(map[string]int64)}
+	r.cfg.Store(cfg)
+	return r
+}
+
+// SetConfig replaces the configuration used by the next pass.
+func (r *Reconciler) SetConfig(cfg *config.Config) {
+	r.cfg.Store(cfg)
+}
+
+// Reconcile runs one pass over the indices matching migration.indices.
+// Per-day failures are recorded in the report; an error is returned only if
+// a pattern cannot be resolved.
+func (r *Reconciler) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	cfg := r.cfg.Load()
+	report := &ReconcileReport{Days: []ReconcileResult{}}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[string]int64)
+	for _, pattern := range cfg.Migration.Indices {
+		indices := []string{pattern}
+		if containsWildcard(pattern) {
+			resolved, err := r.hot.ResolveIndices(ctx, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("resolving pattern %q: %w", pattern, err)
+			}
+			indices = make([]string, 0, len(resolved))
+			for _, info := range resolved {
+				indices = append(indices, info.Name)
+			}
+		}
+		for _, index := range indices {
+			for _, res := range r.reconcileIndex(ctx, cfg, index, seen) {
+				switch res.Status {
+				case ReconcileStatusMissing, ReconcileStatusSurplus, ReconcileStatusDecreased:
+					report.Mismatches++
+				case ReconcileStatusError:
+					report.Errors++
+				}
+				report.Days = append(report.Days, res)
+			}
+		}
+	}
+	// Days that left the compared range are forgotten.
+	r.seen = seen
+	return report, nil
+}
+
+// reconcileIndex compares the days before the watermark of index, newest
+// first, recording the sink count of each in seen. Days older than the
+// index's cold retention period are left out, since the sink may already
+// have deleted them.
+func (r *Reconciler) reconcileIndex(ctx context.Context, cfg *config.Config, index string, seen map[string]int64) []ReconcileResult {
+	wm, err := r.checkpoint.LoadWatermark(index)
+	if err != nil {
+		return []ReconcileResult{{Source: cfg.Source(), Index: index, Status: ReconcileStatusError, Error: fmt.Sprintf("loading watermark: %v", err)}}
+	}
+	if wm == nil {
+		return nil
+	}
+
+	policy := cfg.MigrationPolicyForIndex(index)
+	coldOnly := policy.DeleteAfterMigration || policy.Filter != nil
+	// Documents only leave a Quickwit sink through retention; a tier
+	// passes them on to the next one.
+	final := cfg.NextTier() == nil
+	var expired time.Time
+	if days := cfg.ColdDaysForIndex(index); final && days > 0 {
+		expired = r.now().AddDate(0, 0, -days)
+	}
+
+	var results []ReconcileResult
+	to := wm.MigratedBefore.UTC()
+	from := cfg.DaysAgo(to, 0)
+	if from.Equal(to) {
+		from = cfg.DaysAgo(to, 1)
+	}
+	for range cfg.Migration.Reconcile.Days {
+		if from.Before(expired) {
+			break
+		}
+		res := ReconcileResult{Source: cfg.Source(), Index: index, From: from.UTC(), To: to, ColdOnly: coldOnly}
+		r.reconcileDay(ctx, cfg, &res, final, seen)
+		results = append(results, res)
+		to, from = from.UTC(), cfg.DaysAgo(from, 1)
+	}
+	return results
+}
+
+func (r *Reconciler) reconcileDay(ctx context.Context, cfg *config.Config, res *ReconcileResult, final bool, seen map[string]int64) {
+	// Counts are inclusive at both ends while the window is half-open.
+	tsField := cfg.TimestampFieldForIndex(res.Index)
+	last := res.To.Add(-time.Millisecond)
+	var err error
+	if res.ColdCount, err = r.sink.CountRange(ctx, cfg.QuickwitIndexForIndex(res.Index), tsField, res.From, last); err != nil {
+		res.Status, res.Error = ReconcileStatusError, fmt.Sprintf("counting sink documents: %v", err)
+		return
+	}
+
+	key := res.Index + "/" + res.From.Format(time.RFC3339)
+	prev, ok := r.seen[key]
+	seen[key] = res.ColdCount
+
+	res.Status = ReconcileStatusOK
+	if final && ok && res.ColdCount < prev {
+		res.Status, res.PrevColdCount = ReconcileStatusDecreased, prev
+		slog.Warn("migrated documents disappeared from quickwit", "index", res.Index, "day", res.From.Format(time.DateOnly),
+			"cold_count", res.ColdCount, "previous_cold_count", prev)
+		return
+	}
+	if res.ColdOnly {
+		return
+	}
+
+	if res.HotCount, err = r.hot.CountRange(ctx, res.Index, tsField, res.From, last); err != nil {
+		res.Status, res.Error = ReconcileStatusError, fmt.Sprintf("counting opensearch documents: %v", err)
+		return
+	}
+	switch {
+	case res.ColdCount < res.HotCount:
+		res.Status = ReconcileStatusMissing
+	case res.ColdCount > res.HotCount && !cfg.LateWriteIndex(res.Index):
+		// late_writes ingests old documents straight into Quickwit.
+		res.Status = ReconcileStatusSurplus
+	default:
+		return
+	}
+	slog.Warn("migrated document counts diverge", "index", res.Index, "day", res.From.Format(time.DateOnly),
+		"hot_count", res.HotCount, "cold_count", res.ColdCount, "status", res.Status)
+}
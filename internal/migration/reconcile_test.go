@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeDayCounter counts documents by index and the day a range starts on.
+type fakeDayCounter struct {
+	fakeVerifyHot
+	days map[string]int64 // "index/2006-01-02"
+}
+
+func (f *fakeDayCounter) CountRange(_ context.Context, index, _ string, from, _ time.Time) (int64, error) {
+	return f.days[index+"/"+from.Format(time.DateOnly)], nil
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	wm := time.Date(2026, 1, 10, 6, 0, 0, 0, time.UTC)
+	if err := cpStore.SaveWatermark(&Watermark{Index: "logs", MigratedBefore: wm}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+
+	hot := &fakeDayCounter{days: map[string]int64{"logs/2026-01-10": 5, "logs/2026-01-09": 100, "logs/2026-01-08": 100}}
+	cold := &fakeDayCounter{days: map[string]int64{"logs/2026-01-10": 5, "logs/2026-01-09": 90, "logs/2026-01-08": 101}}
+	cfg := defaultTestConfig()
+	cfg.Migration.Reconcile.Days = 3
+	r := NewReconciler(cfg, hot, cold, cpStore)
+	r.now = func() time.Time { return wm }
+
+	report, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	want := []string{ReconcileStatusOK, ReconcileStatusMissing, ReconcileStatusSurplus}
+	if len(report.Days) != len(want) {
+		t.Fatalf("days=%+v, want %d results", report.Days, len(want))
+	}
+	for i, res := range report.Days {
+		if res.Status != want[i] {
+			t.Errorf("%s status=%s, want %s", res.From.Format(time.DateOnly), res.Status, want[i])
+		}
+	}
+	// The newest day ends at the watermark.
+	if first := report.Days[0]; !first.From.Equal(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)) || !first.To.Equal(wm) {
+		t.Errorf("first day=[%v, %v), want [2026-01-10, %v)", first.From, first.To, wm)
+	}
+	if report.Mismatches != 2 || report.Errors != 0 {
+		t.Errorf("mismatches=%d errors=%d, want 2/0", report.Mismatches, report.Errors)
+	}
+}
+
+func TestReconciler_ColdDecreased(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	wm := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	if err := cpStore.SaveWatermark(&Watermark{Index: "logs", MigratedBefore: wm}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+
+	// Migrated documents were deleted from OpenSearch, so only the
+	// Quickwit counts of consecutive passes can be compared.
+	cold := &fakeDayCounter{days: map[string]int64{"logs/2026-01-09": 100, "logs/2026-01-08": 100}}
+	cfg := defaultTestConfig()
+	cfg.Migration.DeleteAfterMigration = true
+	cfg.Migration.Reconcile.Days = 2
+	cfg.Retention.ColdDays = 5
+	r := NewReconciler(cfg, &fakeDayCounter{}, cold, cpStore)
+	r.now = func() time.Time { return wm }
+
+	if report, err := r.Reconcile(context.Background()); err != nil || report.Mismatches != 0 {
+		t.Fatalf("first pass: report=%+v err=%v, want no mismatches", report, err)
+	}
+	cold.days["logs/2026-01-08"] = 40
+	report, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if res := report.Days[1]; res.Status != ReconcileStatusDecreased || res.PrevColdCount != 100 || res.ColdCount != 40 {
+		t.Errorf("2026-01-08 result=%+v, want decreased from 100 to 40", res)
+	}
+	if !report.Days[0].ColdOnly || report.Days[0].Status != ReconcileStatusOK {
+		t.Errorf("2026-01-09 result=%+v, want ok, cold only", report.Days[0])
+	}
+
+	// Days past the cold retention period are not compared.
+	r.now = func() time.Time { return wm.AddDate(0, 0, 4) }
+	if report, _ := r.Reconcile(context.Background()); len(report.Days) != 1 {
+		t.Errorf("days=%+v, want only 2026-01-09 within cold retention", report.Days)
+	}
+}
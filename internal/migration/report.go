@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Per-index statuses reported in a RunReport.
+const (
+	IndexStatusMigrated = "migrated"   // documents were copied to Quickwit
+	IndexStatusUpToDate = "up_to_date" // nothing new in the migration window
+	IndexStatusSkipped  = "skipped"    // not attempted (recent index or lock held elsewhere)
+	IndexStatusFailed   = "failed"
+)
+
+// Reasons given for skipped indices.
+const (
+	ReasonRecentIndex = "index newer than migration cutoff"
+	ReasonLockHeld    = "migration lock held by another instance"
+	ReasonEmptyIndex  = "index has no documents"
+	ReasonDualWrite   = "index mirrored to quickwit by the proxy (dual_write)"
+	ReasonDeleteRule  = "index deleted without archiving (rule action delete)"
+)
+
+// Run outcomes summarizing a RunReport.
+const (
+	OutcomeSuccess        = "success"         // at least one index migrated, none failed
+	OutcomeNothingToDo    = "nothing_to_do"   // no failures and no documents migrated
+	OutcomePartialFailure = "partial_failure" // some indices failed, others succeeded
+	OutcomeFailed         = "failed"          // every attempted index failed, or the run aborted
+)
+
+// IndexResult is the outcome of one index within a MigrateAll run.
+type IndexResult struct {
+	Source      string  `json:"source,omitempty"` // migration.sources entry, if configured
+	Index       string  `json:"index"`
+	Status      string  `json:"status"`
+	Reason      string  `json:"reason,omitempty"`
+	Migrated    int64   `json:"documents_migrated"`
+	DurationSec float64 `json:"duration_sec"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// RunReport summarizes a MigrateAll run for wrapping automation.
+type RunReport struct {
+	Source      string        `json:"source,omitempty"` // migration.sources entry, if configured
+	Outcome     string        `json:"outcome"`
+	StartedAt   time.Time     `json:"started_at"`
+	CompletedAt time.Time     `json:"completed_at"`
+	DurationSec float64       `json:"duration_sec"`
+	Migrated    int64         `json:"documents_migrated"`
+	Indices     []IndexResult `json:"indices"`
+	Error       string        `json:"error,omitempty"` // run-level failure, e.g. Quickwit not ready
+}
+
+// finish stamps the completion time and derives totals and the outcome.
+func (r *RunReport) finish(runErr error) {
+	r.CompletedAt = time.Now().UTC()
+	r.DurationSec = r.CompletedAt.Sub(r.StartedAt).Seconds()
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+
+	var attempted, failed int
+	r.Migrated = 0
+	for _, res := range r.Indices {
+		r.Migrated += res.Migrated
+		switch res.Status {
+		case IndexStatusFailed:
+			attempted++
+			failed++
+		case IndexStatusMigrated, IndexStatusUpToDate:
+			attempted++
+		}
+	}
+
+	switch {
+	case failed > 0 && failed == attempted:
+		r.Outcome = OutcomeFailed
+	case failed > 0:
+		r.Outcome = OutcomePartialFailure
+	case runErr != nil && len(r.Indices) == 0:
+		// Aborted before any index was attempted, e.g. Quickwit not ready.
+		r.Outcome = OutcomeFailed
+	case runErr != nil:
+		r.Outcome = OutcomePartialFailure
+	case r.Migrated > 0:
+		r.Outcome = OutcomeSuccess
+	default:
+		r.Outcome = OutcomeNothingToDo
+	}
+}
+
+// CombineReports merges the reports of the runs against each migration
+// source into the report of a single run, with the outcome derived from all
+// indices.
+func CombineReports(reports []*RunReport) *RunReport {
+	if len(reports) == 1 {
+		return reports[0]
+	}
+	combined := &RunReport{StartedAt: time.Now().UTC(), Indices: []IndexResult{}}
+	var errs []string
+	for _, r := range reports {
+		if r.StartedAt.Before(combined.StartedAt) {
+			combined.StartedAt = r.StartedAt
+		}
+		combined.Indices = append(combined.Indices, r.Indices...)
+		if r.Error != "" {
+			errs = append(errs, r.Source+": "+r.Error)
+		}
+	}
+	var runErr error
+	if len(errs) > 0 {
+		runErr = errors.New(strings.Join(errs, "; "))
+	}
+	combined.finish(runErr)
+	return combined
+}
@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunReport_Outcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		indices []IndexResult
+		runErr  error
+		want    string
+	}{
+		{"migrated", []IndexResult{{Status: IndexStatusMigrated, Migrated: 10}, {Status: IndexStatusUpToDate}}, nil, OutcomeSuccess},
+		{"nothing to do", []IndexResult{{Status: IndexStatusUpToDate}, {Status: IndexStatusSkipped}}, nil, OutcomeNothingToDo},
+		{"no indices", nil, nil, OutcomeNothingToDo},
+		{"partial", []IndexResult{{Status: IndexStatusMigrated, Migrated: 1}, {Status: IndexStatusFailed}}, errors.New("x"), OutcomePartialFailure},
+		{"all failed", []IndexResult{{Status: IndexStatusFailed}, {Status: IndexStatusSkipped}}, errors.New("x"), OutcomeFailed},
+		{"aborted", nil, errors.New("quickwit is not ready"), OutcomeFailed},
+		{"resolve error alongside success", []IndexResult{{Status: IndexStatusUpToDate}}, errors.New("resolving"), OutcomePartialFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RunReport{StartedAt: time.Now(), Indices: tt.indices}
+			r.finish(tt.runErr)
+			if r.Outcome != tt.want {
+				t.Fatalf("Outcome=%s, want %s", r.Outcome, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombineReports(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Minute)
+	eu := &RunReport{Source: "eu", StartedAt: start, Indices: []IndexResult{{Source: "eu", Index: "logs-1", Status: IndexStatusMigrated, Migrated: 5}}}
+	eu.finish(nil)
+	us := &RunReport{Source: "us", StartedAt: start.Add(time.Second), Indices: []IndexResult{}}
+	us.finish(errors.New("quickwit is not ready"))
+
+	if got := CombineReports([]*RunReport{eu}); got != eu {
+		t.Error("a single report should be returned unchanged")
+	}
+
+	combined := CombineReports([]*RunReport{eu, us})
+	if combined.Outcome != OutcomePartialFailure {
+		t.Errorf("Outcome=%s, want %s", combined.Outcome, OutcomePartialFailure)
+	}
+	if !combined.StartedAt.Equal(start) || combined.Migrated != 5 || len(combined.Indices) != 1 {
+		t.Errorf("combined = %+v", combined)
+	}
+	if combined.Error != "us: quickwit is not ready" {
+		t.Errorf("Error=%q", combined.Error)
+	}
+}
+
+func TestMigrator_MigrateAllWithReport(t *testing.T) {
+	oldIndex := "logs-" + time.Now().UTC().AddDate(0, 0, -60).Format("2006.01.02")
+	recentIndex := "logs-" + time.Now().UTC().Format("2006.01.02")
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 2), nil},
+		1: {makeHits(1, 1), nil},
+	})
+	hot.resolvedIndices = map[string][]string{"logs-*": {oldIndex, recentIndex}}
+
+	cfg := defaultTestConfig()
+	cfg.Migration.MigrateAfterDays = 25
+	cfg.Migration.Indices = []string{"logs-*"}
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	m, err := NewMigrator(cfg, hot, newFakeCold(), cpStore)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	report, err := m.MigrateAllWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateAllWithReport: %v", err)
+	}
+	if report.Outcome != OutcomeSuccess || report.Migrated != 3 {
+		t.Fatalf("outcome=%s migrated=%d, want success/3", report.Outcome, report.Migrated)
+	}
+	if len(report.Indices) != 2 {
+		t.Fatalf("indices=%+v, want 2 entries", report.Indices)
+	}
+	if got := report.Indices[0]; got.Index != oldIndex || got.Status != IndexStatusMigrated || got.Migrated != 3 {
+		t.Fatalf("old index result=%+v", got)
+	}
+	if got := report.Indices[1]; got.Index != recentIndex || got.Status != IndexStatusSkipped {
+		t.Fatalf("recent index result=%+v", got)
+	}
+}
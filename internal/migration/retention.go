@@ -0,0 +1,157 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// Retention enforcement actions for a single index.
+const (
+	RetentionActionNone         = "none"
+	RetentionActionDeleteIndex  = "delete_index"
+	RetentionActionDeleteSplits = "delete_splits"
+	RetentionActionError        = "error"
+)
+
+// RetentionCold is the Quickwit side of retention enforcement.
+type RetentionCold interface {
+	ListIndices(ctx context.Context) ([]string, error)
+	DeleteIndex(ctx context.Context, index string) error
+	ListSplits(ctx context.Context, index string) ([]backend.SplitInfo, error)
+	MarkSplitsForDeletion(ctx context.Context, index string, splitIDs []string) error
+}
+
+// RetentionResult is the outcome of enforcing retention on one index.
+type RetentionResult struct {
+	Index    string    `json:"index"`
+	Action   string    `json:"action"`
+	ColdDays int       `json:"cold_days"`
+	Cutoff   time.Time `json:"cutoff"`
+	Splits   int       `json:"splits,omitempty"` // splits deleted (or that would be, in a dry run)
+	Docs     int64     `json:"docs,omitempty"`   // documents in those splits
+	Error    string    `json:"error,omitempty"`
+}
+
+// RetentionReport is the result of one enforcement pass.
+type RetentionReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Indices []RetentionResult `json:"indices"`
+	Errors  int               `json:"errors"`
+}
+
+// RetentionEnforcer deletes cold data older than each index's cold retention
+// period. Indices whose name ends in a date are deleted whole once the
+// entire day has expired; other indices lose only the splits whose newest
+// document is past the cutoff, matching Quickwit's own retention policy.
+type RetentionEnforcer struct {
+	cfg  atomic.Pointer[config.Config]
+	cold RetentionCold
+	now  func() time.Time
+}
+
+// NewRetentionEnforcer creates a RetentionEnforcer. Only Quickwit indices
+// matching migration.indices are considered, so indices that oqbridge does
+// not manage are never touched.
+func NewRetentionEnforcer(cfg *config.Config, cold RetentionCold) *RetentionEnforcer {
+	e := &RetentionEnforcer{cold: cold, now: time.Now}
+	e.cfg.Store(cfg)
+	return e
+}
+
+// SetConfig replaces the configuration used by the next enforcement pass.
+func (e *RetentionEnforcer) SetConfig(cfg *config.Config) {
+	e.cfg.Store(cfg)
+}
+
+// Enforce runs one enforcement pass. Per-index failures are recorded in the
+// report; an error is returned only if the Quickwit indices cannot be listed.
+func (e *RetentionEnforcer) Enforce(ctx context.Context) (*RetentionReport, error) {
+	cfg := e.cfg.Load()
+	dryRun := cfg.Retention.Enforce.DryRun
+	report := &RetentionReport{DryRun: dryRun, Indices: []RetentionResult{}}
+
+	indices, err := e.Indices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := e.now().UTC()
+	for _, index := range indices {
+		coldDays := cfg.ColdDaysForIndex(index)
+		if coldDays <= 0 {
+			continue
+		}
+		res := RetentionResult{Index: index, ColdDays: coldDays, Cutoff: now.AddDate(0, 0, -coldDays)}
+		if err := e.enforceIndex(ctx, &res, dryRun); err != nil {
+			slog.Error("retention enforcement failed", "index", index, "error", err)
+			res.Action, res.Error = RetentionActionError, err.Error()
+			report.Errors++
+		}
+		report.Indices = append(report.Indices, res)
+	}
+	return report, nil
+}
+
+func (e *RetentionEnforcer) enforceIndex(ctx context.Context, res *RetentionResult, dryRun bool) error {
+	res.Action = RetentionActionNone
+	if day, ok := parseIndexDate(res.Index, e.cfg.Load().Location()); ok {
+		if day.AddDate(0, 0, 1).After(res.Cutoff) {
+			return nil
+		}
+		res.Action = RetentionActionDeleteIndex
+		slog.Info("deleting expired quickwit index", "index", res.Index, "cold_days", res.ColdDays, "dry_run", dryRun)
+		if dryRun {
+			return nil
+		}
+		if err := e.cold.DeleteIndex(ctx, res.Index); err != nil {
+			return fmt.Errorf("deleting index: %w", err)
+		}
+		return nil
+	}
+
+	splits, err := e.cold.ListSplits(ctx, res.Index)
+	if err != nil {
+		return err
+	}
+	var expired []string
+	for _, s := range splits {
+		if !s.TimeRangeEnd.IsZero() && s.TimeRangeEnd.Before(res.Cutoff) {
+			expired = append(expired, s.ID)
+			res.Docs += s.NumDocs
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	res.Action, res.Splits = RetentionActionDeleteSplits, len(expired)
+	slog.Info("deleting expired quickwit splits", "index", res.Index, "splits", len(expired), "docs", res.Docs,
+		"cutoff", formatBoundary(res.Cutoff), "dry_run", dryRun)
+	if dryRun {
+		return nil
+	}
+	return e.cold.MarkSplitsForDeletion(ctx, res.Index, expired)
+}
+
+// Indices returns the Quickwit indices that match migration.indices.
+func (e *RetentionEnforcer) Indices(ctx context.Context) ([]string, error) {
+	all, err := e.cold.ListIndices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing quickwit indices: %w", err)
+	}
+	var indices []string
+	for _, index := range all {
+		for _, pattern := range e.cfg.Load().Migration.Indices {
+			if pattern == index || (containsWildcard(pattern) && util.MatchWildcard(pattern, index)) {
+				indices = append(indices, index)
+				break
+			}
+		}
+	}
+	return indices, nil
+}
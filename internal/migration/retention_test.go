@@ -0,0 +1,124 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+type fakeRetentionCold struct {
+	indices []string
+	splits  map[string][]backend.SplitInfo
+	failOn  string
+
+	deleted []string
+	marked  map[string][]string
+}
+
+func (f *fakeRetentionCold) ListIndices(_ context.Context) ([]string, error) {
+	return f.indices, nil
+}
+
+func (f *fakeRetentionCold) DeleteIndex(_ context.Context, index string) error {
+	f.deleted = append(f.deleted, index)
+	return nil
+}
+
+func (f *fakeRetentionCold) ListSplits(_ context.Context, index string) ([]backend.SplitInfo, error) {
+	if index == f.failOn {
+		return nil, errors.New("metastore unavailable")
+	}
+	return f.splits[index], nil
+}
+
+func (f *fakeRetentionCold) MarkSplitsForDeletion(_ context.Context, index string, ids []string) error {
+	if f.marked == nil {
+		f.marked = map[string][]string{}
+	}
+	f.marked[index] = append(f.marked[index], ids...)
+	return nil
+}
+
+func TestRetentionEnforcer_Enforce(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cold := &fakeRetentionCold{
+		indices: []string{"logs-2026.01.01", "logs-2026.02.25", "logs", "audit", "other"},
+		splits: map[string][]backend.SplitInfo{
+			"logs": {
+				{ID: "old", NumDocs: 10, TimeRangeEnd: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+				{ID: "new", NumDocs: 20, TimeRangeEnd: time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)},
+				{ID: "untimed", NumDocs: 5},
+			},
+		},
+		failOn: "audit",
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"logs-*", "logs", "audit"}
+	cfg.Retention.ColdDays = 30
+
+	e := NewRetentionEnforcer(cfg, cold)
+	e.now = func() time.Time { return now }
+	report, err := e.Enforce(context.Background())
+	if err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+
+	if !reflect.DeepEqual(cold.deleted, []string{"logs-2026.01.01"}) {
+		t.Fatalf("deleted=%v, want [logs-2026.01.01]", cold.deleted)
+	}
+	if !reflect.DeepEqual(cold.marked["logs"], []string{"old"}) {
+		t.Fatalf("marked=%v, want [old]", cold.marked)
+	}
+
+	actions := map[string]string{}
+	for _, r := range report.Indices {
+		actions[r.Index] = r.Action
+	}
+	want := map[string]string{
+		"logs-2026.01.01": RetentionActionDeleteIndex,
+		"logs-2026.02.25": RetentionActionNone,
+		"logs":            RetentionActionDeleteSplits,
+		"audit":           RetentionActionError,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions=%v, want %v", actions, want)
+	}
+	if report.Errors != 1 {
+		t.Fatalf("errors=%d, want 1", report.Errors)
+	}
+}
+
+func TestRetentionEnforcer_Enforce_DryRunAndOverrides(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cold := &fakeRetentionCold{
+		indices: []string{"logs-2026.01.01", "keep-2020.01.01"},
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"logs-*", "keep-*"}
+	cfg.Retention.ColdDays = 30
+	cfg.Retention.IndexColdDays = map[string]int{"keep-*": 0}
+	cfg.Retention.Enforce.DryRun = true
+
+	e := NewRetentionEnforcer(cfg, cold)
+	e.now = func() time.Time { return now }
+	report, err := e.Enforce(context.Background())
+	if err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+	if len(cold.deleted) != 0 {
+		t.Fatalf("dry run deleted %v", cold.deleted)
+	}
+	var indices []string
+	for _, r := range report.Indices {
+		indices = append(indices, r.Index)
+	}
+	sort.Strings(indices)
+	if !report.DryRun || !reflect.DeepEqual(indices, []string{"logs-2026.01.01"}) {
+		t.Fatalf("report=%+v, want only logs-2026.01.01 in a dry run", report)
+	}
+}
@@ -0,0 +1,205 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// rollupPageSize is the number of buckets read per composite aggregation
+// page and written per bulk request.
+const rollupPageSize = 1000
+
+// RollupClient computes the summaries of migration.rollups with
+// aggregations on the source OpenSearch cluster and writes them there.
+type RollupClient interface {
+	Search(ctx context.Context, index string, body []byte) (*backend.SearchResponse, error)
+	BulkIndexHits(ctx context.Context, index string, hits []json.RawMessage) error
+}
+
+// WithRollups makes the migrator summarize each migrated window of the
+// indices matching a migration.rollups entry, before the window is deleted
+// from OpenSearch.
+func WithRollups(client RollupClient) MigratorOption {
+	return func(m *Migrator) {
+		m.rollups = client
+	}
+}
+
+// rollupBucket is a bucket of the composite aggregation of rollUpWindow.
+// Stats holds its stats sub-aggregations, named "m" and the position of
+// the field in metrics.
+type rollupBucket struct {
+	Key      map[string]any
+	DocCount int64
+	Stats    map[string]rollupStats
+}
+
+// rollupStats is the part of a stats aggregation kept in summaries. Min
+// and max are null in buckets without a value of the field.
+type rollupStats struct {
+	Count int64    `json:"count"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Sum   float64  `json:"sum"`
+}
+
+func (b *rollupBucket) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	b.Stats = make(map[string]rollupStats)
+	for name, v := range fields {
+		var err error
+		switch name {
+		case "key":
+			err = json.Unmarshal(v, &b.Key)
+		case "doc_count":
+			err = json.Unmarshal(v, &b.DocCount)
+		default:
+			var s rollupStats
+			err = json.Unmarshal(v, &s)
+			b.Stats[name] = s
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// rollupWindow returns the whole buckets of interval to summarize after
+// migrating [fromTime, cutoff). Buckets are aligned to interval from the
+// Unix epoch. The bucket the window ends in is left to the next window,
+// which starts in it and finds its documents still in OpenSearch: they are
+// younger than the safety margin of delete_after_migration. An explicit
+// window only covers the buckets entirely inside it, so that summaries of
+// documents outside it are not overwritten.
+func rollupWindow(fromTime *time.Time, cutoff time.Time, interval time.Duration, explicit bool) (from *time.Time, to time.Time) {
+	to = cutoff.Truncate(interval)
+	if fromTime == nil {
+		return nil, to
+	}
+	start := fromTime.Truncate(interval)
+	if explicit && start.Before(*fromTime) {
+		start = start.Add(interval)
+	}
+	return &start, to
+}
+
+// rollUp writes the summaries of each migration.rollups entry matching
+// index for the documents migrated in [fromTime, cutoff) that match filter.
+func (m *Migrator) rollUp(ctx context.Context, cfg *config.Config, index, tsField string, filter map[string]any, fromTime *time.Time, cutoff time.Time) error {
+	for _, r := range cfg.RollupsForIndex(index) {
+		from, to := rollupWindow(fromTime, cutoff, r.Interval, m.window != nil)
+		if from != nil && !to.After(*from) {
+			continue
+		}
+		n, err := m.rollUpWindow(ctx, r, index, tsField, filter, from, to)
+		if err != nil {
+			return fmt.Errorf("rollup %s: %w", r.Name, err)
+		}
+		slog.Info("rolled up migrated documents", "index", index, "rollup", r.Name, "target", r.Target, "to", formatBoundary(to), "summaries", n)
+	}
+	return nil
+}
+
+// rollUpWindow pages through a composite aggregation of index over
+// [from, to) and writes a summary document per bucket into r.Target. The
+// ID of a summary is derived from the rollup, index, bucket and group
+// values, so summarizing a window again replaces its summaries.
+func (m *Migrator) rollUpWindow(ctx context.Context, r config.RollupConfig, index, tsField string, filter map[string]any, from *time.Time, to time.Time) (int, error) {
+	interval := fmt.Sprintf("%ds", r.Interval/time.Second)
+	sources := []map[string]any{{"t": map[string]any{"date_histogram": map[string]any{"field": tsField, "fixed_interval": interval}}}}
+	for i, field := range r.GroupBy {
+		sources = append(sources, map[string]any{strconv.Itoa(i): map[string]any{"terms": map[string]any{"field": field, "missing_bucket": true}}})
+	}
+	stats := make(map[string]any, len(r.Metrics))
+	for i, field := range r.Metrics {
+		stats["m"+strconv.Itoa(i)] = map[string]any{"stats": map[string]any{"field": field}}
+	}
+	query := withFilter(buildMigrationDeleteQuery(tsField, from, to), filter)
+	query["size"] = 0
+
+	var after map[string]any
+	written := 0
+	for {
+		composite := map[string]any{"size": rollupPageSize, "sources": sources}
+		if after != nil {
+			composite["after"] = after
+		}
+		agg := map[string]any{"composite": composite}
+		if len(stats) > 0 {
+			agg["aggs"] = stats
+		}
+		query["aggs"] = map[string]any{"rollup": agg}
+		body, err := json.Marshal(query)
+		if err != nil {
+			return written, fmt.Errorf("marshaling aggregation: %w", err)
+		}
+		resp, err := m.rollups.Search(ctx, index, body)
+		if err != nil {
+			return written, fmt.Errorf("aggregating: %w", err)
+		}
+		var aggs struct {
+			Rollup struct {
+				AfterKey map[string]any `json:"after_key"`
+				Buckets  []rollupBucket `json:"buckets"`
+			} `json:"rollup"`
+		}
+		if err := json.Unmarshal(resp.Aggregations, &aggs); err != nil {
+			return written, fmt.Errorf("parsing aggregation: %w", err)
+		}
+
+		hits := make([]json.RawMessage, 0, len(aggs.Rollup.Buckets))
+		for _, b := range aggs.Rollup.Buckets {
+			millis, ok := b.Key["t"].(float64)
+			if !ok {
+				return written, fmt.Errorf("bucket without a time key: %v", b.Key)
+			}
+			start := time.UnixMilli(int64(millis)).UTC()
+			doc := map[string]any{
+				tsField:     start.Format(time.RFC3339),
+				"rollup":    r.Name,
+				"index":     index,
+				"interval":  interval,
+				"doc_count": b.DocCount,
+			}
+			groups := make([]any, len(r.GroupBy))
+			for j, field := range r.GroupBy {
+				groups[j] = b.Key[strconv.Itoa(j)]
+				doc[field] = groups[j]
+			}
+			for j, field := range r.Metrics {
+				s := b.Stats["m"+strconv.Itoa(j)]
+				doc[field] = map[string]any{"count": s.Count, "min": s.Min, "max": s.Max, "sum": s.Sum}
+			}
+			id, _ := json.Marshal([]any{r.Name, index, start.UnixMilli(), groups})
+			sum := sha256.Sum256(id)
+			hit, err := json.Marshal(map[string]any{"_id": hex.EncodeToString(sum[:16]), "_source": doc})
+			if err != nil {
+				return written, fmt.Errorf("marshaling summary: %w", err)
+			}
+			hits = append(hits, hit)
+		}
+		if len(hits) > 0 {
+			if err := m.rollups.BulkIndexHits(ctx, r.Target, hits); err != nil {
+				return written, fmt.Errorf("writing summaries: %w", err)
+			}
+			written += len(hits)
+		}
+		if len(aggs.Rollup.Buckets) < rollupPageSize || aggs.Rollup.AfterKey == nil {
+			return written, nil
+		}
+		after = aggs.Rollup.AfterKey
+	}
+}
@@ -0,0 +1,148 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestRollupWindow(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, _ := time.Parse(time.RFC3339, s)
+		return tm
+	}
+	ptr := func(tm time.Time) *time.Time { return &tm }
+	tests := []struct {
+		name     string
+		from     *time.Time
+		cutoff   time.Time
+		explicit bool
+		wantFrom *time.Time
+		wantTo   time.Time
+	}{
+		{"first run", nil, at("2026-01-10T12:34:56Z"), false, nil, at("2026-01-10T12:00:00Z")},
+		{"incremental", ptr(at("2026-01-09T12:34:56Z")), at("2026-01-10T12:34:56Z"), false, ptr(at("2026-01-09T12:00:00Z")), at("2026-01-10T12:00:00Z")},
+		{"explicit window", ptr(at("2026-01-09T12:34:56Z")), at("2026-01-10T12:34:56Z"), true, ptr(at("2026-01-09T13:00:00Z")), at("2026-01-10T12:00:00Z")},
+		{"explicit aligned", ptr(at("2026-01-09T12:00:00Z")), at("2026-01-10T00:00:00Z"), true, ptr(at("2026-01-09T12:00:00Z")), at("2026-01-10T00:00:00Z")},
+	}
+	for _, tt := range tests {
+		from, to := rollupWindow(tt.from, tt.cutoff, time.Hour, tt.explicit)
+		if (from == nil) != (tt.wantFrom == nil) || (from != nil && !from.Equal(*tt.wantFrom)) || !to.Equal(tt.wantTo) {
+			t.Errorf("%s: rollupWindow() = %v, %v, want %v, %v", tt.name, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
+
+// fakeRollups answers the composite aggregations of rollups with pages of
+// buckets and records the summaries written.
+type fakeRollups struct {
+	pages   [][]map[string]any // buckets of each page
+	err     error
+	queries []map[string]any
+	written map[string][]json.RawMessage // by target
+}
+
+func (f *fakeRollups) Search(_ context.Context, index string, body []byte) (*backend.SearchResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var q map[string]any
+	json.Unmarshal(body, &q)
+	f.queries = append(f.queries, q)
+	page := f.pages[len(f.queries)-1]
+	aggs, _ := json.Marshal(map[string]any{"rollup": map[string]any{"after_key": page[len(page)-1]["key"], "buckets": page}})
+	return &backend.SearchResponse{Aggregations: aggs}, nil
+}
+
+func (f *fakeRollups) BulkIndexHits(_ context.Context, index string, hits []json.RawMessage) error {
+	if f.written == nil {
+		f.written = make(map[string][]json.RawMessage)
+	}
+	f.written[index] = append(f.written[index], hits...)
+	return nil
+}
+
+func rollupBuckets(start time.Time, n int) []map[string]any {
+	buckets := make([]map[string]any, n)
+	for i := range buckets {
+		buckets[i] = map[string]any{
+			"key":       map[string]any{"t": start.Add(time.Duration(i) * time.Hour).UnixMilli(), "0": "api"},
+			"doc_count": 3,
+			"m0":        map[string]any{"count": 3, "min": 1.0, "max": 5.0, "sum": 9.0},
+		}
+	}
+	return buckets
+}
+
+func TestMigrator_MigrateIndex_RollsUp(t *testing.T) {
+	dir := t.TempDir()
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 2), nil}, 1: {nil}})
+	m := newTestMigrator(t, hot, newFakeCold(), dir)
+	cfg := *m.config()
+	cfg.Migration.Rollups = []config.RollupConfig{{
+		Name: "hourly", Indices: []string{"logs*"}, Target: "rollup-hourly", Interval: time.Hour,
+		GroupBy: []string{"service"}, Metrics: []string{"latency_ms"},
+	}}
+	m.SetConfig(&cfg)
+
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	rollups := &fakeRollups{err: errors.New("aggregation rejected")}
+	m.rollups = rollups
+	if err := m.MigrateIndex(context.Background(), "logs"); err == nil || !strings.Contains(err.Error(), "rollup hourly") {
+		t.Fatalf("MigrateIndex() error = %v, want the rollup failure", err)
+	}
+	if cp := readCheckpoint(t, dir, "logs"); cp.Completed || len(cp.SlicesDone) != 2 {
+		t.Fatalf("checkpoint after failed rollup = %+v, want both slices done and not completed", cp)
+	}
+
+	// The next run skips the migrated slices and summarizes again.
+	rollups.err = nil
+	rollups.pages = [][]map[string]any{rollupBuckets(start, rollupPageSize), rollupBuckets(start.Add(rollupPageSize*time.Hour), 1)}
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex() error = %v", err)
+	}
+	if cp := readCheckpoint(t, dir, "logs"); !cp.Completed {
+		t.Error("checkpoint not completed after the rollup")
+	}
+	if len(rollups.queries) != 2 {
+		t.Fatalf("ran %d aggregations, want 2 pages", len(rollups.queries))
+	}
+	composite := rollups.queries[1]["aggs"].(map[string]any)["rollup"].(map[string]any)["composite"].(map[string]any)
+	if composite["after"] == nil {
+		t.Error("second page was not requested after the first one's after_key")
+	}
+	rng := rollups.queries[0]["query"].(map[string]any)["range"].(map[string]any)["@timestamp"].(map[string]any)
+	if lt := rng["lt"].(string); !strings.HasSuffix(lt, ":00:00.000Z") {
+		t.Errorf("aggregation ends at %s, want a whole hour", lt)
+	}
+
+	written := rollups.written["rollup-hourly"]
+	if len(written) != rollupPageSize+1 {
+		t.Fatalf("wrote %d summaries, want %d", len(written), rollupPageSize+1)
+	}
+	ids := make(map[string]bool)
+	for _, raw := range written {
+		var hit struct {
+			ID     string         `json:"_id"`
+			Source map[string]any `json:"_source"`
+		}
+		json.Unmarshal(raw, &hit)
+		ids[hit.ID] = true
+		if len(ids) == 1 {
+			want := fmt.Sprintf(`map[@timestamp:%s doc_count:3 index:logs interval:3600s latency_ms:map[count:3 max:5 min:1 sum:9] rollup:hourly service:api]`, start.Format(time.RFC3339))
+			if got := fmt.Sprint(hit.Source); got != want {
+				t.Errorf("summary = %s, want %s", got, want)
+			}
+		}
+	}
+	if len(ids) != len(written) {
+		t.Errorf("%d summaries share %d IDs", len(written), len(ids))
+	}
+}
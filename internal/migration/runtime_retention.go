@@ -0,0 +1,224 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// retentionOverrideID is the state index document holding the retention
+// set through the retention API.
+const retentionOverrideID = "retention-override"
+
+// ErrInvalidRetention is returned by RetentionSync.Schedule for a change
+// that the configuration would not accept.
+var ErrInvalidRetention = errors.New("invalid retention")
+
+// RetentionOverride is a change of retention.days and
+// migration.migrate_after_days made at runtime (retention.runtime). Zero
+// values leave the configured value in place, so an override without
+// either reverts to the configuration file.
+type RetentionOverride struct {
+	Days             int       `json:"days,omitempty"`
+	MigrateAfterDays int       `json:"migrate_after_days,omitempty"`
+	EffectiveAt      time.Time `json:"effective_at"` // When every instance switches to it.
+	UpdatedBy        string    `json:"updated_by,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Overrides returns the configuration keys o sets.
+func (o *RetentionOverride) Overrides() config.Overrides {
+	overrides := config.Overrides{}
+	if o.Days > 0 {
+		overrides["retention.days"] = strconv.Itoa(o.Days)
+	}
+	if o.MigrateAfterDays > 0 {
+		overrides["migration.migrate_after_days"] = strconv.Itoa(o.MigrateAfterDays)
+	}
+	return overrides
+}
+
+// sameAs reports whether o and other are the same change.
+func (o *RetentionOverride) sameAs(other *RetentionOverride) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	return o.Days == other.Days && o.MigrateAfterDays == other.MigrateAfterDays && o.EffectiveAt.Equal(other.EffectiveAt)
+}
+
+// LoadRetentionOverride reads the retention set through the retention API.
+// Returns nil if it was never changed.
+func (s *OpenSearchCheckpointStore) LoadRetentionOverride(ctx context.Context) (*RetentionOverride, error) {
+	doc, err := s.getDoc(ctx, retentionOverrideID)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	var o RetentionOverride
+	if err := json.Unmarshal(doc, &o); err != nil {
+		return nil, fmt.Errorf("parsing retention override: %w", err)
+	}
+	return &o, nil
+}
+
+// SaveRetentionOverride stores the retention set through the retention API.
+func (s *OpenSearchCheckpointStore) SaveRetentionOverride(ctx context.Context, o *RetentionOverride) error {
+	return s.putDoc(ctx, retentionOverrideID, o)
+}
+
+// RetentionOverrideStore holds the retention set at runtime where every
+// instance reads it, e.g. an OpenSearchCheckpointStore.
+type RetentionOverrideStore interface {
+	LoadRetentionOverride(ctx context.Context) (*RetentionOverride, error)
+	SaveRetentionOverride(ctx context.Context, o *RetentionOverride) error
+}
+
+// RuntimeConfig is the configuration that runtime retention is applied to,
+// a *config.Watcher.
+type RuntimeConfig interface {
+	SetRuntimeOverrides(overrides config.Overrides) error
+	CheckRuntimeOverrides(overrides config.Overrides) error
+}
+
+// RetentionSync keeps the retention of one proxy or oqbridge-migrate
+// instance in line with the stored RetentionOverride. A change is applied
+// to the configuration at its EffectiveAt, which lies retention.runtime.delay
+// after it was made, so that every instance has read it by then and all
+// switch at the same time: the proxy routes by the new cutoff just as the
+// migration starts moving data by it.
+type RetentionSync struct {
+	store    RetentionOverrideStore
+	settings config.RetentionRuntimeConfig
+	cfg      RuntimeConfig
+	now      func() time.Time
+
+	mu      sync.Mutex
+	applied *RetentionOverride // nil until an override was applied
+	pending *RetentionOverride // waiting for its EffectiveAt
+	timer   *time.Timer        // applies pending
+}
+
+// NewRetentionSync creates a RetentionSync that applies the overrides of
+// store to cfg. Call Sync once before the configuration is first used and
+// Run to follow changes.
+func NewRetentionSync(store RetentionOverrideStore, settings config.RetentionRuntimeConfig, cfg RuntimeConfig) *RetentionSync {
+	return &RetentionSync{store: store, settings: settings, cfg: cfg, now: time.Now}
+}
+
+// Sync reads the stored override and applies it, or schedules it if it is
+// not effective yet.
+func (s *RetentionSync) Sync(ctx context.Context) error {
+	o, err := s.store.LoadRetentionOverride(ctx)
+	if err != nil {
+		return fmt.Errorf("loading retention override: %w", err)
+	}
+	if o != nil {
+		s.follow(o)
+	}
+	return nil
+}
+
+// Run calls Sync every retention.runtime.sync_interval until ctx is done.
+func (s *RetentionSync) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.settings.SyncInterval)
+	defer ticker.Stop()
+	defer s.stopTimer()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("failed to sync retention, keeping the current one", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Current returns the override in effect and the one waiting to take
+// effect. Either is nil if there is none.
+func (s *RetentionSync) Current() (applied, pending *RetentionOverride) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.applied, s.pending
+}
+
+// Schedule stores a change of retention.days and migration.migrate_after_days
+// (0 = the configured value) made by user, which every instance applies
+// retention.runtime.delay from now. A change the configuration would not
+// accept is refused with an error wrapping ErrInvalidRetention.
+func (s *RetentionSync) Schedule(ctx context.Context, days, migrateAfterDays int, user string) (*RetentionOverride, error) {
+	if days < 0 || migrateAfterDays < 0 {
+		return nil, fmt.Errorf("%w: days and migrate_after_days must not be negative", ErrInvalidRetention)
+	}
+	now := s.now().UTC().Round(0)
+	o := &RetentionOverride{
+		Days:             days,
+		MigrateAfterDays: migrateAfterDays,
+		EffectiveAt:      now.Add(s.settings.Delay),
+		UpdatedBy:        user,
+		UpdatedAt:        now,
+	}
+	if err := s.cfg.CheckRuntimeOverrides(o.Overrides()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+	}
+	if err := s.store.SaveRetentionOverride(ctx, o); err != nil {
+		return nil, fmt.Errorf("saving retention override: %w", err)
+	}
+	s.follow(o)
+	return o, nil
+}
+
+// follow applies o if it is effective and schedules it otherwise.
+func (s *RetentionSync) follow(o *RetentionOverride) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o.sameAs(s.applied) || o.sameAs(s.pending) {
+		return
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer, s.pending = nil, nil
+	}
+	wait := o.EffectiveAt.Sub(s.now())
+	if wait <= 0 {
+		s.apply(o)
+		return
+	}
+	slog.Info("retention change scheduled", "days", o.Days, "migrate_after_days", o.MigrateAfterDays, "effective_at", o.EffectiveAt, "updated_by", o.UpdatedBy)
+	s.pending = o
+	s.timer = time.AfterFunc(wait, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pending == o {
+			s.timer, s.pending = nil, nil
+			s.apply(o)
+		}
+	})
+}
+
+// apply sets the overrides of o on the configuration. s.mu must be held.
+func (s *RetentionSync) apply(o *RetentionOverride) {
+	if err := s.cfg.SetRuntimeOverrides(o.Overrides()); err != nil {
+		// E.g. the configuration file changed since the override was made.
+		slog.Error("failed to apply retention change, keeping the current retention", "days", o.Days, "migrate_after_days", o.MigrateAfterDays, "error", err)
+		return
+	}
+	slog.Info("retention changed", "days", o.Days, "migrate_after_days", o.MigrateAfterDays, "updated_by", o.UpdatedBy)
+	s.applied = o
+}
+
+func (s *RetentionSync) stopTimer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
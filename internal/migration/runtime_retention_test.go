@@ -0,0 +1,169 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+type memRetentionStore struct {
+	mu sync.Mutex
+	o  *RetentionOverride
+}
+
+func (s *memRetentionStore) LoadRetentionOverride(ctx context.Context) (*RetentionOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.o == nil {
+		return nil, nil
+	}
+	o := *s.o
+	return &o, nil
+}
+
+func (s *memRetentionStore) SaveRetentionOverride(ctx context.Context, o *RetentionOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *o
+	s.o = &stored
+	return nil
+}
+
+// fakeRuntimeConfig accepts overrides that keep migrate_after_days (7
+// unless overridden) below retention.days (30 unless overridden).
+type fakeRuntimeConfig struct {
+	mu  sync.Mutex
+	set []config.Overrides
+}
+
+func (c *fakeRuntimeConfig) CheckRuntimeOverrides(o config.Overrides) error {
+	days, after := 30, 7
+	if v, ok := o["retention.days"]; ok {
+		days, _ = strconv.Atoi(v)
+	}
+	if v, ok := o["migration.migrate_after_days"]; ok {
+		after, _ = strconv.Atoi(v)
+	}
+	if after >= days {
+		return errors.New("migration.migrate_after_days must be less than retention.days")
+	}
+	return nil
+}
+
+func (c *fakeRuntimeConfig) SetRuntimeOverrides(o config.Overrides) error {
+	if err := c.CheckRuntimeOverrides(o); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set = append(c.set, o)
+	return nil
+}
+
+func (c *fakeRuntimeConfig) last() config.Overrides {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.set) == 0 {
+		return nil
+	}
+	return c.set[len(c.set)-1]
+}
+
+func TestRetentionSync_Schedule(t *testing.T) {
+	store := &memRetentionStore{}
+	cfg := &fakeRuntimeConfig{}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewRetentionSync(store, config.RetentionRuntimeConfig{Delay: time.Hour}, cfg)
+	s.now = func() time.Time { return now }
+
+	if _, err := s.Schedule(context.Background(), 5, 0, "alice"); !errors.Is(err, ErrInvalidRetention) {
+		t.Fatalf("Schedule(5, 0) error = %v, want ErrInvalidRetention", err)
+	}
+	if store.o != nil {
+		t.Fatal("invalid change was stored")
+	}
+
+	o, err := s.Schedule(context.Background(), 20, 10, "alice")
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if !o.EffectiveAt.Equal(now.Add(time.Hour)) || o.UpdatedBy != "alice" || !o.sameAs(store.o) {
+		t.Errorf("override = %+v, stored %+v", o, store.o)
+	}
+	applied, pending := s.Current()
+	if applied != nil || pending != o || cfg.last() != nil {
+		t.Errorf("applied = %+v, pending = %+v, config %v; want the change pending", applied, pending, cfg.last())
+	}
+	s.stopTimer()
+
+	// Another instance reads it once it is effective.
+	other := NewRetentionSync(store, config.RetentionRuntimeConfig{Delay: time.Hour}, cfg)
+	other.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if err := other.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if applied, pending := other.Current(); applied == nil || pending != nil {
+		t.Errorf("applied = %+v, pending = %+v; want the change applied", applied, pending)
+	}
+	if got := cfg.last(); got["retention.days"] != "20" || got["migration.migrate_after_days"] != "10" {
+		t.Errorf("overrides = %v", got)
+	}
+}
+
+func TestRetentionSync_AppliesAtEffectiveAt(t *testing.T) {
+	store := &memRetentionStore{o: &RetentionOverride{Days: 20, EffectiveAt: time.Now().Add(50 * time.Millisecond)}}
+	cfg := &fakeRuntimeConfig{}
+	s := NewRetentionSync(store, config.RetentionRuntimeConfig{SyncInterval: 10 * time.Millisecond, Delay: time.Second}, cfg)
+
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if cfg.last() != nil {
+		t.Fatal("change applied before it is effective")
+	}
+	// Syncing the same change again does not move it.
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cfg.last() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("change not applied at its effective time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := cfg.last(); got["retention.days"] != "20" || len(got) != 1 {
+		t.Errorf("overrides = %v", got)
+	}
+	cfg.mu.Lock()
+	n := len(cfg.set)
+	cfg.mu.Unlock()
+	if n != 1 {
+		t.Errorf("applied %d times, want once", n)
+	}
+}
+
+func TestRetentionSync_Revert(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	store := &memRetentionStore{o: &RetentionOverride{Days: 20, EffectiveAt: past}}
+	cfg := &fakeRuntimeConfig{}
+	s := NewRetentionSync(store, config.RetentionRuntimeConfig{}, cfg)
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// An override without values reverts to the configuration file.
+	store.o = &RetentionOverride{EffectiveAt: past.Add(time.Second)}
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := cfg.last(); got == nil || len(got) != 0 {
+		t.Errorf("overrides = %v, want none", got)
+	}
+}
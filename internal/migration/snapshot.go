@@ -0,0 +1,90 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// SnapshotClient is the subset of OpenSearch snapshot operations needed to
+// migrate from a snapshot repository instead of the live index.
+type SnapshotClient interface {
+	Snapshot(ctx context.Context, repo, name string) (*backend.SnapshotInfo, error)
+	RestoreSnapshotIndex(ctx context.Context, repo, snapshot, index, target string, searchable bool) error
+	WaitForIndexHealth(ctx context.Context, index string, timeout time.Duration) error
+	DeleteIndex(ctx context.Context, index string) error
+}
+
+// snapshotSource restores an index from a snapshot into a temporary index
+// so the migration scroll runs against the copy rather than production data.
+type snapshotSource struct {
+	client SnapshotClient
+	cfg    config.SnapshotSourceConfig
+}
+
+// restoredIndex is a temporary copy of an index restored from a snapshot.
+type restoredIndex struct {
+	Name     string
+	Snapshot backend.SnapshotInfo
+}
+
+// restore makes index from the configured snapshot available for scrolling.
+// Any leftover copy from an interrupted run is dropped first, since restore
+// refuses to overwrite an open index.
+func (s *snapshotSource) restore(ctx context.Context, index string) (*restoredIndex, error) {
+	snap, err := s.client.Snapshot(ctx, s.cfg.Repository, s.cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	target := s.cfg.IndexPrefix + index
+
+	if err := s.client.DeleteIndex(ctx, target); err != nil {
+		return nil, fmt.Errorf("removing stale restore target %s: %w", target, err)
+	}
+
+	slog.Info("restoring index from snapshot",
+		"index", index,
+		"repository", s.cfg.Repository,
+		"snapshot", snap.Name,
+		"target", target,
+		"mode", s.cfg.Mode,
+	)
+	if err := s.client.RestoreSnapshotIndex(ctx, s.cfg.Repository, snap.Name, index, target, s.cfg.Mode == "searchable"); err != nil {
+		return nil, fmt.Errorf("restoring %s from snapshot %s: %w", index, snap.Name, err)
+	}
+
+	// Poll in short server-side waits so a long restore never outlives the
+	// HTTP client timeout.
+	deadline := time.Now().Add(s.cfg.RestoreTimeout)
+	for {
+		err := s.client.WaitForIndexHealth(ctx, target, min(30*time.Second, s.cfg.RestoreTimeout))
+		if err == nil {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			s.cleanup(target)
+			return nil, fmt.Errorf("restored index %s not ready after %s: %w", target, s.cfg.RestoreTimeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			s.cleanup(target)
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return &restoredIndex{Name: target, Snapshot: *snap}, nil
+}
+
+// cleanup drops a restored index. It uses a detached context so the copy is
+// removed even when the run was cancelled.
+func (s *snapshotSource) cleanup(target string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.client.DeleteIndex(ctx, target); err != nil {
+		slog.Warn("failed to delete restored snapshot index", "index", target, "error", err)
+	}
+}
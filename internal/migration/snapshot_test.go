@@ -0,0 +1,118 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+type fakeSnapshots struct {
+	info     backend.SnapshotInfo
+	restored []string // targets passed to RestoreSnapshotIndex
+	deleted  []string
+}
+
+func (f *fakeSnapshots) Snapshot(_ context.Context, _, _ string) (*backend.SnapshotInfo, error) {
+	info := f.info
+	return &info, nil
+}
+
+func (f *fakeSnapshots) RestoreSnapshotIndex(_ context.Context, _, _, _, target string, _ bool) error {
+	f.restored = append(f.restored, target)
+	return nil
+}
+
+func (f *fakeSnapshots) WaitForIndexHealth(_ context.Context, _ string, _ time.Duration) error {
+	return nil
+}
+
+func (f *fakeSnapshots) DeleteIndex(_ context.Context, index string) error {
+	f.deleted = append(f.deleted, index)
+	return nil
+}
+
+func snapshotTestConfig() *config.Config {
+	cfg := defaultTestConfig()
+	cfg.Migration.Workers = 1
+	cfg.Migration.Snapshot = config.SnapshotSourceConfig{
+		Enabled:        true,
+		Repository:     "archive",
+		Mode:           "restore",
+		IndexPrefix:    "oqbridge-restore-",
+		RestoreTimeout: time.Second,
+	}
+	return cfg
+}
+
+func TestMigrator_MigrateIndex_SnapshotSource(t *testing.T) {
+	dir := t.TempDir()
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 2), nil}})
+	cold := newFakeCold()
+	cpStore, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	snapStart := time.Now().UTC().AddDate(0, 0, -60).Truncate(time.Millisecond)
+	snaps := &fakeSnapshots{info: backend.SnapshotInfo{Name: "nightly", StartTime: snapStart}}
+	m, err := NewMigrator(snapshotTestConfig(), hot, cold, cpStore, WithSnapshotSource(snaps))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+
+	if len(hot.scrolledIndices) != 1 || hot.scrolledIndices[0] != "oqbridge-restore-logs" {
+		t.Fatalf("scrolled indices=%v, want [oqbridge-restore-logs]", hot.scrolledIndices)
+	}
+	if n := len(cold.docsByIndex["logs"]); n != 2 {
+		t.Fatalf("ingested into logs=%d, want 2", n)
+	}
+	// Stale copy dropped before the restore and the fresh copy after the run.
+	if len(snaps.deleted) != 2 {
+		t.Fatalf("deleted=%v, want the restore target twice", snaps.deleted)
+	}
+
+	wm, err := cpStore.LoadWatermark("logs")
+	if err != nil || wm == nil {
+		t.Fatalf("LoadWatermark: %v, %v", wm, err)
+	}
+	if !wm.MigratedBefore.Equal(snapStart) {
+		t.Fatalf("watermark=%v, want capped at snapshot start %v", wm.MigratedBefore, snapStart)
+	}
+}
+
+func TestMigrator_MigrateIndex_SnapshotOlderThanWatermark(t *testing.T) {
+	dir := t.TempDir()
+	hot := newFakeHot(map[int][][]json.RawMessage{0: {makeHits(0, 2), nil}})
+	cpStore, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	watermark := time.Now().UTC().AddDate(0, 0, -40).Truncate(time.Millisecond)
+	if err := cpStore.SaveWatermark(&Watermark{Index: "logs", MigratedBefore: watermark}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+	snaps := &fakeSnapshots{info: backend.SnapshotInfo{Name: "old", StartTime: watermark.AddDate(0, 0, -1)}}
+	m, err := NewMigrator(snapshotTestConfig(), hot, newFakeCold(), cpStore, WithSnapshotSource(snaps))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+	if len(hot.scrolledIndices) != 0 {
+		t.Fatalf("expected no scroll, got %v", hot.scrolledIndices)
+	}
+	wm, _ := cpStore.LoadWatermark("logs")
+	if !wm.MigratedBefore.Equal(watermark) {
+		t.Fatalf("watermark moved to %v, want unchanged %v", wm.MigratedBefore, watermark)
+	}
+}
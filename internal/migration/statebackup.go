@@ -0,0 +1,321 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// StateIndices are the indices in which the migrate instances of a source
+// share their state.
+var StateIndices = []string{stateIndex, backend.LockIndex, metricsIndex}
+
+// StateClient is the subset of OpenSearch operations needed to back up and
+// restore the state indices.
+type StateClient interface {
+	CreateSnapshot(ctx context.Context, repo, name string, indices []string) error
+	ListSnapshots(ctx context.Context, repo, pattern string) ([]backend.SnapshotInfo, error)
+	DeleteSnapshot(ctx context.Context, repo, name string) error
+	RestoreSnapshotIndices(ctx context.Context, repo, snapshot string, indices []string) error
+	IndexExists(ctx context.Context, index string) (bool, error)
+	IndexMappings(ctx context.Context, index string) (json.RawMessage, error)
+	CreateIndexWithMappings(ctx context.Context, index string, mappings json.RawMessage) error
+	DeleteIndex(ctx context.Context, index string) error
+	Scroll(ctx context.Context, index string, body []byte, scrollID string) (*backend.ScrollResult, error)
+	ClearScroll(ctx context.Context, scrollID string) error
+	BulkIndexHits(ctx context.Context, index string, hits []json.RawMessage) error
+}
+
+// StateBackupInfo describes one backup of the state indices. Indices is
+// only known for snapshots.
+type StateBackupInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Indices   []string  `json:"indices,omitempty"`
+}
+
+// stateFile is the content of a JSON backup.
+type stateFile struct {
+	CreatedAt time.Time                 `json:"created_at"`
+	Source    string                    `json:"source,omitempty"`
+	Indices   map[string]stateFileIndex `json:"indices"`
+}
+
+type stateFileIndex struct {
+	Mappings  json.RawMessage   `json:"mappings"`
+	Documents []json.RawMessage `json:"documents"`
+}
+
+// stateBackupLayout is the UTC timestamp that ends backup names.
+const stateBackupLayout = "20060102-150405"
+
+// stateRestoreBatch is the number of documents per bulk request of a JSON
+// restore.
+const stateRestoreBatch = 1000
+
+// StateBackup backs up the state indices of one source, as snapshots in
+// migration.state_backup.repository or as JSON files in
+// migration.state_backup.dir, and restores them. Losing them otherwise
+// means re-migrating from scratch or rebuilding watermarks by hand.
+type StateBackup struct {
+	client StateClient
+	cfg    config.StateBackupConfig
+	source string
+	now    func() time.Time
+}
+
+// NewStateBackup creates a StateBackup for the source selected in cfg.
+func NewStateBackup(cfg *config.Config, client StateClient) *StateBackup {
+	return &StateBackup{client: client, cfg: cfg.Migration.StateBackup, source: cfg.Source(), now: time.Now}
+}
+
+// prefix returns the start of the names of the source's backups, which are
+// followed by their creation time.
+func (b *StateBackup) prefix() string {
+	if b.source == "" {
+		return "oqbridge-state-"
+	}
+	return "oqbridge-state-" + strings.ToLower(b.source) + "-"
+}
+
+// parseName returns the backup named name, or false if it is not a backup
+// of this source; the prefix of the default source is a prefix of the
+// others' too.
+func (b *StateBackup) parseName(name string) (StateBackupInfo, bool) {
+	ts, ok := strings.CutPrefix(name, b.prefix())
+	if !ok {
+		return StateBackupInfo{}, false
+	}
+	created, err := time.Parse(stateBackupLayout, ts)
+	if err != nil {
+		return StateBackupInfo{}, false
+	}
+	return StateBackupInfo{Name: name, CreatedAt: created}, true
+}
+
+// Backup backs up the state indices and then deletes all but the newest
+// migration.state_backup.keep backups.
+func (b *StateBackup) Backup(ctx context.Context) (*StateBackupInfo, error) {
+	created := b.now().UTC().Truncate(time.Second)
+	info := StateBackupInfo{Name: b.prefix() + created.Format(stateBackupLayout), CreatedAt: created}
+	var err error
+	if b.cfg.Repository != "" {
+		err = b.client.CreateSnapshot(ctx, b.cfg.Repository, info.Name, StateIndices)
+	} else {
+		err = b.writeFile(ctx, info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("state indices backed up", "source", b.source, "backup", info.Name)
+
+	backups, err := b.List(ctx)
+	if err != nil {
+		return &info, fmt.Errorf("listing backups to prune: %w", err)
+	}
+	for _, old := range backups[:max(0, len(backups)-b.cfg.Keep)] {
+		if err := b.delete(ctx, old.Name); err != nil {
+			return &info, fmt.Errorf("deleting old backup %s: %w", old.Name, err)
+		}
+		slog.Info("old state backup deleted", "source", b.source, "backup", old.Name)
+	}
+	return &info, nil
+}
+
+// List returns the backups of the source, oldest first.
+func (b *StateBackup) List(ctx context.Context) ([]StateBackupInfo, error) {
+	var names []string
+	indices := make(map[string][]string)
+	if b.cfg.Repository != "" {
+		snapshots, err := b.client.ListSnapshots(ctx, b.cfg.Repository, b.prefix()+"*")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snapshots {
+			names = append(names, s.Name)
+			indices[s.Name] = s.Indices
+		}
+	} else {
+		files, err := filepath.Glob(filepath.Join(b.cfg.Dir, b.prefix()+"*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			names = append(names, strings.TrimSuffix(filepath.Base(f), ".json"))
+		}
+	}
+
+	var backups []StateBackupInfo
+	for _, name := range names {
+		if info, ok := b.parseName(name); ok {
+			info.Indices = indices[name]
+			backups = append(backups, info)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.Before(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Restore replaces the state indices with the backup named name, or the
+// newest backup if name is empty, and returns it. Indices missing from the
+// backup are left alone. Migrate instances of the source must be stopped:
+// they would recreate the indices while they are replaced.
+func (b *StateBackup) Restore(ctx context.Context, name string) (*StateBackupInfo, error) {
+	backups, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var info *StateBackupInfo
+	for i := range backups {
+		if name == "" || backups[i].Name == name {
+			info = &backups[i]
+		}
+	}
+	if info == nil {
+		if name == "" {
+			return nil, fmt.Errorf("no state backups of source %q found", b.source)
+		}
+		return nil, fmt.Errorf("state backup %s not found", name)
+	}
+
+	if b.cfg.Repository != "" {
+		var restore []string
+		for _, index := range StateIndices {
+			if !slices.Contains(info.Indices, index) {
+				continue
+			}
+			if err := b.client.DeleteIndex(ctx, index); err != nil {
+				return nil, fmt.Errorf("deleting %s: %w", index, err)
+			}
+			restore = append(restore, index)
+		}
+		if err := b.client.RestoreSnapshotIndices(ctx, b.cfg.Repository, info.Name, restore); err != nil {
+			return nil, err
+		}
+	} else if err := b.restoreFile(ctx, info.Name); err != nil {
+		return nil, err
+	}
+	slog.Info("state indices restored", "source", b.source, "backup", info.Name)
+	return info, nil
+}
+
+func (b *StateBackup) delete(ctx context.Context, name string) error {
+	if b.cfg.Repository != "" {
+		return b.client.DeleteSnapshot(ctx, b.cfg.Repository, name)
+	}
+	return os.Remove(b.path(name))
+}
+
+func (b *StateBackup) path(name string) string {
+	return filepath.Join(b.cfg.Dir, name+".json")
+}
+
+// writeFile reads every state index and writes them to the backup file,
+// through a temporary file so an interrupted backup never replaces a good
+// one.
+func (b *StateBackup) writeFile(ctx context.Context, info StateBackupInfo) error {
+	state := stateFile{CreatedAt: info.CreatedAt, Source: b.source, Indices: make(map[string]stateFileIndex)}
+	for _, index := range StateIndices {
+		exists, err := b.client.IndexExists(ctx, index)
+		if err != nil {
+			return fmt.Errorf("checking %s: %w", index, err)
+		}
+		if !exists {
+			continue
+		}
+		mappings, err := b.client.IndexMappings(ctx, index)
+		if err != nil {
+			return err
+		}
+		docs, err := b.readIndex(ctx, index)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", index, err)
+		}
+		state.Indices[index] = stateFileIndex{Mappings: mappings, Documents: docs}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshaling state backup: %w", err)
+	}
+	if err := os.MkdirAll(b.cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(b.cfg.Dir, ".tmp-"+info.Name)
+	if err != nil {
+		return fmt.Errorf("creating backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing backup file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing backup file: %w", err)
+	}
+	return os.Rename(tmp.Name(), b.path(info.Name))
+}
+
+// readIndex returns every document of index as a search hit.
+func (b *StateBackup) readIndex(ctx context.Context, index string) ([]json.RawMessage, error) {
+	body := []byte(`{"size":1000,"sort":["_doc"]}`)
+	var docs []json.RawMessage
+	scrollID := ""
+	defer func() {
+		if scrollID != "" {
+			b.client.ClearScroll(context.Background(), scrollID)
+		}
+	}()
+	for {
+		res, err := b.client.Scroll(ctx, index, body, scrollID)
+		if err != nil {
+			return nil, err
+		}
+		scrollID = res.ScrollID
+		if len(res.Hits) == 0 {
+			return docs, nil
+		}
+		docs = append(docs, res.Hits...)
+	}
+}
+
+// restoreFile recreates each index of a JSON backup with its mappings and
+// documents.
+func (b *StateBackup) restoreFile(ctx context.Context, name string) error {
+	data, err := os.ReadFile(b.path(name))
+	if err != nil {
+		return fmt.Errorf("reading backup file: %w", err)
+	}
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parsing backup file %s: %w", b.path(name), err)
+	}
+	for _, index := range StateIndices {
+		saved, ok := state.Indices[index]
+		if !ok {
+			continue
+		}
+		if err := b.client.DeleteIndex(ctx, index); err != nil {
+			return fmt.Errorf("deleting %s: %w", index, err)
+		}
+		if err := b.client.CreateIndexWithMappings(ctx, index, saved.Mappings); err != nil {
+			return err
+		}
+		for docs := range slices.Chunk(saved.Documents, stateRestoreBatch) {
+			if err := b.client.BulkIndexHits(ctx, index, docs); err != nil {
+				return fmt.Errorf("restoring %s: %w", index, err)
+			}
+		}
+	}
+	return nil
+}
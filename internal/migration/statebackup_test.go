@@ -0,0 +1,207 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// fakeStateClient holds indices as ID -> source maps, and snapshots as
+// copies of them.
+type fakeStateClient struct {
+	indices   map[string]map[string]string
+	snapshots map[string]map[string]map[string]string
+	order     []string // snapshot names, oldest first
+}
+
+func (f *fakeStateClient) CreateSnapshot(_ context.Context, _, name string, indices []string) error {
+	snap := make(map[string]map[string]string)
+	for _, index := range indices {
+		if docs, ok := f.indices[index]; ok {
+			snap[index] = maps.Clone(docs)
+		}
+	}
+	f.snapshots[name] = snap
+	f.order = append(f.order, name)
+	return nil
+}
+
+func (f *fakeStateClient) ListSnapshots(context.Context, string, string) ([]backend.SnapshotInfo, error) {
+	var infos []backend.SnapshotInfo
+	for _, name := range f.order {
+		info := backend.SnapshotInfo{Name: name}
+		for index := range f.snapshots[name] {
+			info.Indices = append(info.Indices, index)
+		}
+		infos = append(infos, info)
+	}
+	infos = append(infos, backend.SnapshotInfo{Name: "nightly-1"})
+	return infos, nil
+}
+
+func (f *fakeStateClient) DeleteSnapshot(_ context.Context, _, name string) error {
+	delete(f.snapshots, name)
+	f.order = slices.DeleteFunc(f.order, func(n string) bool { return n == name })
+	return nil
+}
+
+func (f *fakeStateClient) RestoreSnapshotIndices(_ context.Context, _, name string, indices []string) error {
+	for _, index := range indices {
+		if _, ok := f.indices[index]; ok {
+			return fmt.Errorf("index %s is open", index)
+		}
+		f.indices[index] = maps.Clone(f.snapshots[name][index])
+	}
+	return nil
+}
+
+func (f *fakeStateClient) IndexExists(_ context.Context, index string) (bool, error) {
+	_, ok := f.indices[index]
+	return ok, nil
+}
+
+func (f *fakeStateClient) IndexMappings(context.Context, string) (json.RawMessage, error) {
+	return json.RawMessage(`{"properties":{}}`), nil
+}
+
+func (f *fakeStateClient) CreateIndexWithMappings(_ context.Context, index string, _ json.RawMessage) error {
+	f.indices[index] = map[string]string{}
+	return nil
+}
+
+func (f *fakeStateClient) DeleteIndex(_ context.Context, index string) error {
+	delete(f.indices, index)
+	return nil
+}
+
+// Scroll returns all documents on the first page.
+func (f *fakeStateClient) Scroll(_ context.Context, index string, _ []byte, scrollID string) (*backend.ScrollResult, error) {
+	res := &backend.ScrollResult{ScrollID: "scroll"}
+	if scrollID == "" {
+		for id, src := range f.indices[index] {
+			res.Hits = append(res.Hits, json.RawMessage(fmt.Sprintf(`{"_index":%q,"_id":%q,"_source":%s}`, index, id, src)))
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeStateClient) ClearScroll(context.Context, string) error { return nil }
+
+func (f *fakeStateClient) BulkIndexHits(_ context.Context, index string, hits []json.RawMessage) error {
+	for _, raw := range hits {
+		var hit struct {
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		}
+		json.Unmarshal(raw, &hit)
+		f.indices[index][hit.ID] = string(hit.Source)
+	}
+	return nil
+}
+
+func newFakeState() *fakeStateClient {
+	return &fakeStateClient{
+		indices: map[string]map[string]string{
+			stateIndex:        {"watermark-logs": `{"index":"logs"}`},
+			backend.LockIndex: {"logs": `{"owner":"a"}`},
+		},
+		snapshots: make(map[string]map[string]map[string]string),
+	}
+}
+
+func TestStateBackup_File(t *testing.T) {
+	client := newFakeState()
+	cfg := &config.Config{}
+	cfg.Migration.StateBackup = config.StateBackupConfig{Dir: t.TempDir(), Keep: 2}
+	b := NewStateBackup(cfg, client)
+	now := time.Date(2026, 3, 1, 4, 15, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	var names []string
+	for range 3 {
+		info, err := b.Backup(context.Background())
+		if err != nil {
+			t.Fatalf("Backup() error: %v", err)
+		}
+		names = append(names, info.Name)
+		now = now.Add(24 * time.Hour)
+	}
+	if names[0] != "oqbridge-state-20260301-041500" {
+		t.Errorf("backup name = %s", names[0])
+	}
+	list, err := b.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != names[1] || list[1].Name != names[2] {
+		t.Errorf("backups after pruning = %+v, want the newest two", list)
+	}
+
+	client.indices[stateIndex] = map[string]string{"watermark-logs": `{"index":"logs","lost":true}`, "checkpoint-new": `{}`}
+	delete(client.indices, backend.LockIndex)
+	info, err := b.Restore(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if info.Name != names[2] {
+		t.Errorf("restored %s, want the newest backup %s", info.Name, names[2])
+	}
+	if got := client.indices[stateIndex]; len(got) != 1 || got["watermark-logs"] != `{"index":"logs"}` {
+		t.Errorf("state index after restore = %v", got)
+	}
+	if got := client.indices[backend.LockIndex]; got["logs"] != `{"owner":"a"}` {
+		t.Errorf("lock index after restore = %v", got)
+	}
+	if _, ok := client.indices[metricsIndex]; ok {
+		t.Error("restore created the metrics index missing from the backup")
+	}
+
+	if _, err := b.Restore(context.Background(), names[0]); err == nil {
+		t.Error("Restore() of a pruned backup succeeded")
+	}
+	entries, _ := os.ReadDir(cfg.Migration.StateBackup.Dir)
+	if len(entries) != 2 {
+		t.Errorf("backup directory holds %d files, want 2", len(entries))
+	}
+}
+
+func TestStateBackup_Snapshot(t *testing.T) {
+	client := newFakeState()
+	cfg := &config.Config{}
+	cfg.Migration.StateBackup = config.StateBackupConfig{Repository: "backups", Keep: 7}
+	cfg.Migration.Sources = []config.MigrationSource{{Name: "EU"}}
+	scfg, err := cfg.ForSource("EU")
+	if err != nil {
+		t.Fatalf("ForSource() error: %v", err)
+	}
+	b := NewStateBackup(scfg, client)
+	b.now = func() time.Time { return time.Date(2026, 3, 1, 4, 15, 0, 0, time.UTC) }
+
+	info, err := b.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup() error: %v", err)
+	}
+	if info.Name != "oqbridge-state-eu-20260301-041500" {
+		t.Errorf("snapshot name = %s", info.Name)
+	}
+
+	client.indices[metricsIndex] = map[string]string{"metric-logs-1": `{}`}
+	client.indices[stateIndex] = map[string]string{}
+	if _, err := b.Restore(context.Background(), info.Name); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if len(client.indices[stateIndex]) != 1 {
+		t.Errorf("state index after restore = %v", client.indices[stateIndex])
+	}
+	if len(client.indices[metricsIndex]) != 1 {
+		t.Error("restore deleted the metrics index missing from the snapshot")
+	}
+}
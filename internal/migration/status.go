@@ -0,0 +1,73 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// IndexStatus is the migration state of one index: its checkpoint and
+// watermark, recorded runs and lock.
+type IndexStatus struct {
+	Index string            `json:"index"`
+	State *IndexState       `json:"state,omitempty"`
+	Runs  *IndexRunSummary  `json:"runs,omitempty"`
+	Lock  *backend.LockInfo `json:"lock,omitempty"`
+}
+
+// RunSummarizer summarizes the recorded runs per index.
+type RunSummarizer interface {
+	Summaries(ctx context.Context) (map[string]*IndexRunSummary, error)
+}
+
+// LockLister lists the migration locks.
+type LockLister interface {
+	List(ctx context.Context) ([]backend.LockInfo, error)
+}
+
+// ReadStatus combines the stored state, run summaries and locks of every
+// index that has any of them, sorted by index name. Only the state is
+// required; failures to read runs or locks are logged and leave them out.
+func ReadStatus(ctx context.Context, states CheckpointAdmin, runs RunSummarizer, locks LockLister) ([]IndexStatus, error) {
+	rows := make(map[string]*IndexStatus)
+	row := func(index string) *IndexStatus {
+		if r, ok := rows[index]; ok {
+			return r
+		}
+		r := &IndexStatus{Index: index}
+		rows[index] = r
+		return r
+	}
+
+	list, err := states.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	for i := range list {
+		row(list[i].Index).State = &list[i]
+	}
+	summaries, err := runs.Summaries(ctx)
+	if err != nil {
+		slog.Warn("failed to read migration metrics", "error", err)
+	}
+	for index, sum := range summaries {
+		row(index).Runs = sum
+	}
+	held, err := locks.List(ctx)
+	if err != nil {
+		slog.Warn("failed to list migration locks", "error", err)
+	}
+	for i := range held {
+		row(held[i].Key).Lock = &held[i]
+	}
+
+	sorted := make([]IndexStatus, 0, len(rows))
+	for _, r := range rows {
+		sorted = append(sorted, *r)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	return sorted, nil
+}
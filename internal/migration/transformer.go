@@ -1,8 +1,14 @@
 package migration
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/config"
 )
 
 // TransformDocument extracts the _source field from an OpenSearch scroll hit
@@ -34,3 +40,68 @@ func TransformBatch(hits []json.RawMessage) ([]json.RawMessage, error) {
 	}
 	return docs, nil
 }
+
+// ApplyTransforms edits each document source in docs according to tc:
+// fields are dropped first, then renamed. A field path is looked up as a
+// literal key before its dots are followed into nested objects; renamed
+// fields are written as nested objects. Missing fields are ignored.
+func ApplyTransforms(docs []json.RawMessage, tc config.TransformConfig) error {
+	if len(tc.DropFields) == 0 && len(tc.RenameFields) == 0 {
+		return nil
+	}
+	renames := slices.Sorted(maps.Keys(tc.RenameFields))
+	for i, raw := range docs {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			return fmt.Errorf("parsing document %d: %w", i, err)
+		}
+		for _, path := range tc.DropFields {
+			removeField(doc, path)
+		}
+		for _, from := range renames {
+			if v, ok := removeField(doc, from); ok {
+				setField(doc, tc.RenameFields[from], v)
+			}
+		}
+		out, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("encoding document %d: %w", i, err)
+		}
+		docs[i] = out
+	}
+	return nil
+}
+
+// removeField deletes the field at path from doc and returns its value.
+func removeField(doc map[string]any, path string) (any, bool) {
+	if v, ok := doc[path]; ok {
+		delete(doc, path)
+		return v, true
+	}
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+	child, ok := doc[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return removeField(child, rest)
+}
+
+// setField sets the field at path in doc, creating intermediate objects.
+func setField(doc map[string]any, path string, v any) {
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		doc[path] = v
+		return
+	}
+	child, ok := doc[head].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		doc[head] = child
+	}
+	setField(child, rest, v)
+}
@@ -3,6 +3,8 @@ package migration
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
 )
 
 func TestTransformDocument(t *testing.T) {
@@ -55,3 +57,31 @@ func TestTransformBatch(t *testing.T) {
 		t.Errorf("len(docs) = %d, want 2", len(docs))
 	}
 }
+
+func TestApplyTransforms(t *testing.T) {
+	docs := []json.RawMessage{
+		json.RawMessage(`{"msg":"a","debug":{"trace":"x","id":1},"host.name":"h1","user":{"id":12345678901234567890}}`),
+	}
+	tc := config.TransformConfig{
+		DropFields:   []string{"debug.trace", "host.name"},
+		RenameFields: map[string]string{"msg": "message", "user.id": "user_id"},
+	}
+	if err := ApplyTransforms(docs, tc); err != nil {
+		t.Fatalf("ApplyTransforms() error = %v", err)
+	}
+	want := `{"debug":{"id":1},"message":"a","user":{},"user_id":12345678901234567890}`
+	if got := string(docs[0]); got != want {
+		t.Errorf("doc = %s, want %s", got, want)
+	}
+}
+
+func TestApplyTransforms_NoneConfigured(t *testing.T) {
+	doc := json.RawMessage(`{"b":1, "a":2}`)
+	docs := []json.RawMessage{doc}
+	if err := ApplyTransforms(docs, config.TransformConfig{}); err != nil {
+		t.Fatalf("ApplyTransforms() error = %v", err)
+	}
+	if string(docs[0]) != string(doc) {
+		t.Errorf("doc = %s, want it unchanged", docs[0])
+	}
+}
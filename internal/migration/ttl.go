@@ -0,0 +1,177 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// TTL actions for a single index.
+const (
+	TTLActionNone       = "none"
+	TTLActionDropIndex  = "drop_index"
+	TTLActionDeleteDocs = "delete_docs"
+	TTLActionDeferred   = "deferred" // due to be dropped, but retention.ttl.max_dropped was reached
+	TTLActionError      = "error"
+)
+
+// TTLHot is the OpenSearch side of TTL enforcement.
+type TTLHot interface {
+	ResolveIndices(ctx context.Context, pattern string) ([]backend.IndexInfo, error)
+	Count(ctx context.Context, index string, body []byte) (int64, error)
+	DeleteByQuery(ctx context.Context, index string, body []byte) error
+	DeleteIndex(ctx context.Context, index string) error
+}
+
+// TTLResult is the outcome of enforcing the TTL of one index.
+type TTLResult struct {
+	Source          string    `json:"source,omitempty"` // migration.sources or tiers entry, if configured
+	Index           string    `json:"index"`
+	Action          string    `json:"action"`
+	DeleteAfterDays int       `json:"delete_after_days"`
+	Cutoff          time.Time `json:"cutoff"`
+	Docs            int64     `json:"docs,omitempty"` // documents deleted (or that would be, in a dry run)
+	Error           string    `json:"error,omitempty"`
+}
+
+// TTLReport is the result of one TTL pass.
+type TTLReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Indices []TTLResult `json:"indices"`
+	Errors  int         `json:"errors"`
+}
+
+// TTLEnforcer deletes the documents of indices whose migration.rules entry
+// has action "delete" once they are older than its delete_after_days,
+// without migrating them. Indices whose name ends in a date are dropped
+// whole once the entire day has expired and counting confirms that they
+// hold no younger document nor one without a timestamp; other indices, and
+// daily indices that fail that check, lose only their expired documents by
+// delete_by_query.
+type TTLEnforcer struct {
+	cfg atomic.Pointer[config.Config]
+	hot TTLHot
+	now func() time.Time
+}
+
+// NewTTLEnforcer creates a TTLEnforcer for the source selected in cfg (see
+// config.ForSource). Only indices matching its migration.indices are
+// considered.
+func NewTTLEnforcer(cfg *config.Config, hot TTLHot) *TTLEnforcer {
+	e := &TTLEnforcer{hot: hot, now: time.Now}
+	e.cfg.Store(cfg)
+	return e
+}
+
+// SetConfig replaces the configuration used by the next pass.
+func (e *TTLEnforcer) SetConfig(cfg *config.Config) {
+	e.cfg.Store(cfg)
+}
+
+// Enforce runs one TTL pass. Per-index failures are recorded in the report;
+// an error is returned only if an index pattern cannot be resolved.
+func (e *TTLEnforcer) Enforce(ctx context.Context) (*TTLReport, error) {
+	cfg := e.cfg.Load()
+	ttl := cfg.Retention.TTL
+	report := &TTLReport{DryRun: ttl.DryRun, Indices: []TTLResult{}}
+	now := e.now()
+	seen := make(map[string]bool)
+	dropped := 0
+
+	for _, pattern := range cfg.Migration.Indices {
+		infos, err := e.hot.ResolveIndices(ctx, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %q: %w", pattern, err)
+		}
+		for _, info := range infos {
+			policy := cfg.MigrationPolicyForIndex(info.Name)
+			if seen[info.Name] || !policy.Delete {
+				continue
+			}
+			seen[info.Name] = true
+			res := TTLResult{
+				Source:          cfg.Source(),
+				Index:           info.Name,
+				DeleteAfterDays: policy.DeleteAfterDays,
+				Cutoff:          cfg.DaysAgo(now, policy.DeleteAfterDays).UTC(),
+			}
+			drop := ttl.MaxDropped < 0 || dropped < ttl.MaxDropped
+			if err := e.enforceIndex(ctx, cfg, info, &res, drop, ttl.DryRun); err != nil {
+				slog.Error("ttl enforcement failed", "index", info.Name, "error", err)
+				res.Action, res.Error = TTLActionError, err.Error()
+				report.Errors++
+			}
+			if res.Action == TTLActionDropIndex {
+				dropped++
+			}
+			report.Indices = append(report.Indices, res)
+		}
+	}
+	return report, nil
+}
+
+func (e *TTLEnforcer) enforceIndex(ctx context.Context, cfg *config.Config, info backend.IndexInfo, res *TTLResult, drop, dryRun bool) error {
+	res.Action = TTLActionNone
+	tsField := cfg.TimestampFieldForIndex(res.Index)
+	cutoff := res.Cutoff.Format(time.RFC3339Nano)
+
+	if day, ok := parseIndexDate(res.Index, cfg.Location()); ok && !day.AddDate(0, 0, 1).After(res.Cutoff) {
+		// The name says the index has expired; make sure its documents agree
+		// before dropping it, so that a misnamed index or late writes are
+		// not lost with it.
+		keep, _ := json.Marshal(map[string]any{"query": map[string]any{"bool": map[string]any{
+			"should": []any{
+				map[string]any{"range": map[string]any{tsField: map[string]string{"gte": cutoff}}},
+				map[string]any{"bool": map[string]any{"must_not": map[string]any{"exists": map[string]string{"field": tsField}}}},
+			},
+			"minimum_should_match": 1,
+		}}})
+		kept, err := e.hot.Count(ctx, res.Index, keep)
+		if err != nil {
+			return fmt.Errorf("counting unexpired documents: %w", err)
+		}
+		if kept == 0 {
+			res.Docs = info.DocsCount
+			if !drop {
+				res.Action = TTLActionDeferred
+				slog.Info("ttl: index expired, dropping deferred by retention.ttl.max_dropped", "index", res.Index)
+				return nil
+			}
+			res.Action = TTLActionDropIndex
+			slog.Info("ttl: dropping expired index", "index", res.Index, "delete_after_days", res.DeleteAfterDays, "docs", res.Docs, "dry_run", dryRun)
+			if dryRun {
+				return nil
+			}
+			if err := e.hot.DeleteIndex(ctx, res.Index); err != nil {
+				return fmt.Errorf("deleting index: %w", err)
+			}
+			return nil
+		}
+		slog.Warn("ttl: expired daily index holds unexpired documents or documents without a timestamp, deleting by query instead",
+			"index", res.Index, "timestamp_field", tsField, "kept", kept)
+	}
+
+	expired, _ := json.Marshal(map[string]any{"query": map[string]any{"range": map[string]any{tsField: map[string]string{"lt": cutoff}}}})
+	n, err := e.hot.Count(ctx, res.Index, expired)
+	if err != nil {
+		return fmt.Errorf("counting expired documents: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	res.Action, res.Docs = TTLActionDeleteDocs, n
+	slog.Info("ttl: deleting expired documents", "index", res.Index, "docs", n, "cutoff", formatBoundary(res.Cutoff), "dry_run", dryRun)
+	if dryRun {
+		return nil
+	}
+	if err := e.hot.DeleteByQuery(ctx, res.Index, expired); err != nil {
+		return fmt.Errorf("deleting expired documents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+type fakeTTLHot struct {
+	indices map[string][]string // by pattern
+	kept    map[string]int64    // unexpired or untimed documents by index
+	expired map[string]int64    // expired documents by index
+	failOn  string
+
+	dropped      []string
+	deletedByQry []string
+}
+
+func (f *fakeTTLHot) ResolveIndices(_ context.Context, pattern string) ([]backend.IndexInfo, error) {
+	var infos []backend.IndexInfo
+	for _, name := range f.indices[pattern] {
+		infos = append(infos, backend.IndexInfo{Name: name, DocsCount: 100})
+	}
+	return infos, nil
+}
+
+func (f *fakeTTLHot) Count(_ context.Context, index string, body []byte) (int64, error) {
+	if index == f.failOn {
+		return 0, errors.New("index_closed_exception")
+	}
+	if strings.Contains(string(body), "must_not") {
+		return f.kept[index], nil
+	}
+	return f.expired[index], nil
+}
+
+func (f *fakeTTLHot) DeleteByQuery(_ context.Context, index string, _ []byte) error {
+	f.deletedByQry = append(f.deletedByQry, index)
+	return nil
+}
+
+func (f *fakeTTLHot) DeleteIndex(_ context.Context, index string) error {
+	f.dropped = append(f.dropped, index)
+	return nil
+}
+
+func TestTTLEnforcer_Enforce(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	hot := &fakeTTLHot{
+		indices: map[string][]string{
+			"debug-*": {"debug-2026.01.01", "debug-2026.01.02", "debug-2026.01.03", "debug-2026.02.28", "debug-closed"},
+			"debug":   {"debug"},
+			"logs-*":  {"logs-2026.01.01"},
+		},
+		kept:    map[string]int64{"debug-2026.01.03": 3},
+		expired: map[string]int64{"debug-2026.01.03": 7, "debug": 5, "logs-2026.01.01": 9},
+		failOn:  "debug-closed",
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"debug-*", "debug", "logs-*"}
+	cfg.Migration.Rules = []config.MigrationRule{{Indices: []string{"debug*"}, Action: "delete", DeleteAfterDays: 7}}
+	cfg.Retention.TTL = config.TTLEnforceConfig{Enabled: true, MaxDropped: 1}
+
+	e := NewTTLEnforcer(cfg, hot)
+	e.now = func() time.Time { return now }
+	report, err := e.Enforce(context.Background())
+	if err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+
+	if !reflect.DeepEqual(hot.dropped, []string{"debug-2026.01.01"}) {
+		t.Errorf("dropped=%v, want [debug-2026.01.01]", hot.dropped)
+	}
+	if !reflect.DeepEqual(hot.deletedByQry, []string{"debug-2026.01.03", "debug"}) {
+		t.Errorf("deleted by query=%v, want [debug-2026.01.03 debug]", hot.deletedByQry)
+	}
+	actions := map[string]string{}
+	for _, r := range report.Indices {
+		actions[r.Index] = r.Action
+		if !r.Cutoff.Equal(time.Date(2026, 2, 22, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s cutoff=%v", r.Index, r.Cutoff)
+		}
+	}
+	want := map[string]string{
+		"debug-2026.01.01": TTLActionDropIndex,
+		"debug-2026.01.02": TTLActionDeferred,   // max_dropped reached
+		"debug-2026.01.03": TTLActionDeleteDocs, // holds unexpired documents
+		"debug-2026.02.28": TTLActionNone,
+		"debug-closed":     TTLActionError,
+		"debug":            TTLActionDeleteDocs,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions=%v, want %v", actions, want)
+	}
+	if report.Errors != 1 {
+		t.Errorf("errors=%d, want 1", report.Errors)
+	}
+}
+
+func TestTTLEnforcer_Enforce_DryRun(t *testing.T) {
+	hot := &fakeTTLHot{
+		indices: map[string][]string{"debug-*": {"debug-2026.01.01", "debug"}},
+		expired: map[string]int64{"debug": 5},
+	}
+	cfg := defaultTestConfig()
+	cfg.Migration.Indices = []string{"debug-*"}
+	cfg.Migration.Rules = []config.MigrationRule{{Indices: []string{"debug*"}, Action: "delete", DeleteAfterDays: 7}}
+	cfg.Retention.TTL = config.TTLEnforceConfig{Enabled: true, DryRun: true, MaxDropped: -1}
+
+	e := NewTTLEnforcer(cfg, hot)
+	e.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	report, err := e.Enforce(context.Background())
+	if err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+	if len(hot.dropped) != 0 || len(hot.deletedByQry) != 0 {
+		t.Fatalf("dry run deleted %v %v", hot.dropped, hot.deletedByQry)
+	}
+	if !report.DryRun || len(report.Indices) != 2 || report.Indices[0].Action != TTLActionDropIndex || report.Indices[1].Docs != 5 {
+		t.Fatalf("report=%+v", report)
+	}
+}
@@ -0,0 +1,227 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Verification statuses for a single index.
+const (
+	VerifyStatusOK             = "ok"
+	VerifyStatusCountMismatch  = "count_mismatch"
+	VerifyStatusSampleMismatch = "sample_mismatch"
+	VerifyStatusNotMigrated    = "not_migrated"
+	VerifyStatusError          = "error"
+)
+
+// sampleLookupHits caps the Quickwit documents fetched per sampled timestamp.
+// Documents sharing a timestamp beyond this cap may be reported as missing.
+const sampleLookupHits = 100
+
+// VerifyHot is the OpenSearch side of a verification.
+type VerifyHot interface {
+	RangeCounter
+	ResolveIndices(ctx context.Context, pattern string) ([]backend.IndexInfo, error)
+	SampleRange(ctx context.Context, index, tsField string, from, to time.Time, n int) ([]json.RawMessage, error)
+}
+
+// VerifyCold is the Quickwit side of a verification.
+type VerifyCold interface {
+	RangeCounter
+	SearchRange(ctx context.Context, index, tsField string, from, to time.Time, maxHits int) ([]json.RawMessage, error)
+}
+
+// VerifyOptions selects the window and depth of a verification. A zero From
+// starts at the Unix epoch; a zero To uses each index's watermark, so only
+// data that has already been migrated is compared.
+type VerifyOptions struct {
+	From    time.Time
+	To      time.Time
+	Samples int // documents to sample and hash per index; 0 compares counts only
+}
+
+// VerifyResult is the reconciliation of one index.
+type VerifyResult struct {
+	Index          string    `json:"index"`
+	Status         string    `json:"status"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	HotCount       int64     `json:"hot_count"`
+	ColdCount      int64     `json:"cold_count"`
+	SamplesChecked int       `json:"samples_checked,omitempty"`
+	SamplesMissing int       `json:"samples_missing,omitempty"`
+	ColdSplits     int64     `json:"cold_splits,omitempty"`
+	ColdSizeBytes  int64     `json:"cold_size_bytes,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// VerifyReport is the result of verifying a set of indices.
+type VerifyReport struct {
+	Indices    []VerifyResult `json:"indices"`
+	Mismatches int            `json:"mismatches"`
+	Errors     int            `json:"errors"`
+}
+
+// Verifier compares what OpenSearch and Quickwit hold for migrated windows.
+type Verifier struct {
+	cfg        *config.Config
+	hot        VerifyHot
+	cold       VerifyCold
+	checkpoint CheckpointStore
+}
+
+// NewVerifier creates a Verifier. The checkpoint store provides the default
+// upper bound (the watermark) of each index's window.
+func NewVerifier(cfg *config.Config, hot VerifyHot, cold VerifyCold, checkpoint CheckpointStore) *Verifier {
+	return &Verifier{cfg: cfg, hot: hot, cold: cold, checkpoint: checkpoint}
+}
+
+// Verify reconciles every index matching patterns. Per-index failures are
+// recorded in the report; an error is returned only if a pattern cannot be
+// resolved.
+func (v *Verifier) Verify(ctx context.Context, patterns []string, opts VerifyOptions) (*VerifyReport, error) {
+	report := &VerifyReport{Indices: []VerifyResult{}}
+	for _, pattern := range patterns {
+		indices := []string{pattern}
+		if containsWildcard(pattern) {
+			resolved, err := v.hot.ResolveIndices(ctx, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("resolving pattern %q: %w", pattern, err)
+			}
+			indices = make([]string, 0, len(resolved))
+			for _, info := range resolved {
+				indices = append(indices, info.Name)
+			}
+		}
+		for _, index := range indices {
+			res := v.verifyIndex(ctx, index, opts)
+			switch res.Status {
+			case VerifyStatusCountMismatch, VerifyStatusSampleMismatch:
+				report.Mismatches++
+			case VerifyStatusError:
+				report.Errors++
+			}
+			report.Indices = append(report.Indices, res)
+		}
+	}
+	return report, nil
+}
+
+func (v *Verifier) verifyIndex(ctx context.Context, index string, opts VerifyOptions) VerifyResult {
+	res := VerifyResult{Index: index, From: opts.From, To: opts.To}
+	if res.From.IsZero() {
+		res.From = time.Unix(0, 0).UTC()
+	}
+	if res.To.IsZero() {
+		wm, err := v.checkpoint.LoadWatermark(index)
+		if err != nil {
+			return verifyError(res, fmt.Errorf("loading watermark: %w", err))
+		}
+		if wm == nil {
+			res.Status = VerifyStatusNotMigrated
+			return res
+		}
+		res.To = wm.MigratedBefore.UTC()
+	}
+
+	// Counts are inclusive at both ends while the window is half-open.
+	tsField := v.cfg.TimestampFieldForIndex(index)
+	target := v.cfg.QuickwitIndexForIndex(index)
+	to := res.To.Add(-time.Millisecond)
+	var err error
+	if res.HotCount, err = v.hot.CountRange(ctx, index, tsField, res.From, to); err != nil {
+		return verifyError(res, fmt.Errorf("counting opensearch documents: %w", err))
+	}
+	if res.ColdCount, err = v.cold.CountRange(ctx, target, tsField, res.From, to); err != nil {
+		return verifyError(res, fmt.Errorf("counting quickwit documents: %w", err))
+	}
+	if describer, ok := v.cold.(ColdDescriber); ok {
+		// Size is informational; a failure does not affect the verdict.
+		if stats, err := describer.DescribeIndex(ctx, target); err != nil {
+			slog.Warn("failed to describe quickwit index", "index", target, "error", err)
+		} else {
+			res.ColdSplits, res.ColdSizeBytes = stats.NumSplits, stats.SizeBytes
+		}
+	}
+	if res.HotCount != res.ColdCount {
+		slog.Warn("document count mismatch", "index", index, "hot_count", res.HotCount, "cold_count", res.ColdCount)
+		res.Status = VerifyStatusCountMismatch
+		return res
+	}
+
+	if opts.Samples > 0 && res.HotCount > 0 {
+		if err := v.verifySamples(ctx, index, tsField, res.From, to, opts.Samples, &res); err != nil {
+			return verifyError(res, err)
+		}
+		if res.SamplesMissing > 0 {
+			res.Status = VerifyStatusSampleMismatch
+			return res
+		}
+	}
+	res.Status = VerifyStatusOK
+	return res
+}
+
+// verifySamples picks random OpenSearch documents in [from, to] and checks
+// that Quickwit holds a document with the same content at the same timestamp.
+func (v *Verifier) verifySamples(ctx context.Context, index, tsField string, from, to time.Time, n int, res *VerifyResult) error {
+	samples, err := v.hot.SampleRange(ctx, index, tsField, from, to, n)
+	if err != nil {
+		return fmt.Errorf("sampling opensearch documents: %w", err)
+	}
+	for _, doc := range samples {
+		ts, ok := DocumentTimestamp(doc, tsField)
+		if !ok {
+			continue
+		}
+		want, err := documentHash(doc, tsField)
+		if err != nil {
+			return fmt.Errorf("hashing sampled document: %w", err)
+		}
+		candidates, err := v.cold.SearchRange(ctx, v.cfg.QuickwitIndexForIndex(index), tsField, ts, ts, sampleLookupHits)
+		if err != nil {
+			return fmt.Errorf("looking up sampled document in quickwit: %w", err)
+		}
+		res.SamplesChecked++
+		found := false
+		for _, c := range candidates {
+			if got, err := documentHash(c, tsField); err == nil && got == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res.SamplesMissing++
+			slog.Debug("sampled document not found in quickwit", "index", index, "timestamp", ts.Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
+
+// documentHash hashes a document's content independently of key order and
+// number formatting. The timestamp field is excluded because Quickwit may
+// return it in a different format than it was ingested.
+func documentHash(doc json.RawMessage, tsField string) ([32]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(doc, &m); err != nil {
+		return [32]byte{}, err
+	}
+	delete(m, tsField)
+	canonical, err := json.Marshal(m) // map keys are marshaled in sorted order
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(canonical), nil
+}
+
+func verifyError(res VerifyResult, err error) VerifyResult {
+	res.Status, res.Error = VerifyStatusError, err.Error()
+	return res
+}
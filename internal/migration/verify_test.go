@@ -0,0 +1,205 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+type fakeVerifyHot struct {
+	counts   map[string]int64
+	samples  []json.RawMessage
+	resolved []string
+}
+
+func (f *fakeVerifyHot) CountRange(_ context.Context, index, _ string, _, _ time.Time) (int64, error) {
+	return f.counts[index], nil
+}
+
+func (f *fakeVerifyHot) ResolveIndices(_ context.Context, _ string) ([]backend.IndexInfo, error) {
+	var out []backend.IndexInfo
+	for _, name := range f.resolved {
+		out = append(out, backend.IndexInfo{Name: name})
+	}
+	return out, nil
+}
+
+func (f *fakeVerifyHot) SampleRange(_ context.Context, _, _ string, _, _ time.Time, n int) ([]json.RawMessage, error) {
+	return f.samples[:min(n, len(f.samples))], nil
+}
+
+type fakeVerifyCold struct {
+	counts map[string]int64
+	docs   []json.RawMessage
+	ranges [][2]time.Time
+}
+
+func (f *fakeVerifyCold) CountRange(_ context.Context, index, _ string, from, to time.Time) (int64, error) {
+	f.ranges = append(f.ranges, [2]time.Time{from, to})
+	return f.counts[index], nil
+}
+
+func (f *fakeVerifyCold) SearchRange(_ context.Context, _, _ string, _, _ time.Time, _ int) ([]json.RawMessage, error) {
+	return f.docs, nil
+}
+
+// fakeDescribedCold adds index statistics to fakeVerifyCold.
+type fakeDescribedCold struct {
+	fakeVerifyCold
+	stats map[string]*backend.IndexStats
+}
+
+func (f *fakeDescribedCold) DescribeIndex(_ context.Context, index string) (*backend.IndexStats, error) {
+	if s, ok := f.stats[index]; ok {
+		return s, nil
+	}
+	return nil, errors.New("index not found")
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	wm := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, index := range []string{"logs-a", "logs-b"} {
+		if err := cpStore.SaveWatermark(&Watermark{Index: index, MigratedBefore: wm}); err != nil {
+			t.Fatalf("SaveWatermark: %v", err)
+		}
+	}
+
+	hot := &fakeVerifyHot{
+		counts:   map[string]int64{"logs-a": 10, "logs-b": 10, "logs-c": 5},
+		resolved: []string{"logs-a", "logs-b", "logs-c"},
+	}
+	cold := &fakeVerifyCold{counts: map[string]int64{"logs-a": 10, "logs-b": 9}}
+	cfg := defaultTestConfig()
+	cfg.Retention.TimestampField = "@timestamp"
+
+	v := NewVerifier(cfg, hot, cold, cpStore)
+	report, err := v.Verify(context.Background(), []string{"logs-*"}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	want := map[string]string{
+		"logs-a": VerifyStatusOK,
+		"logs-b": VerifyStatusCountMismatch,
+		"logs-c": VerifyStatusNotMigrated,
+	}
+	if len(report.Indices) != len(want) {
+		t.Fatalf("indices=%+v, want %d results", report.Indices, len(want))
+	}
+	for _, res := range report.Indices {
+		if res.Status != want[res.Index] {
+			t.Fatalf("%s status=%s, want %s", res.Index, res.Status, want[res.Index])
+		}
+	}
+	if report.Mismatches != 1 || report.Errors != 0 {
+		t.Fatalf("mismatches=%d errors=%d, want 1/0", report.Mismatches, report.Errors)
+	}
+
+	// The watermark is an exclusive bound; counts use an inclusive range.
+	if got := cold.ranges[0][1]; !got.Equal(wm.Add(-time.Millisecond)) {
+		t.Fatalf("count upper bound=%v, want %v", got, wm.Add(-time.Millisecond))
+	}
+}
+
+func TestVerifier_Verify_ExplicitWindowIgnoresWatermark(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	hot := &fakeVerifyHot{counts: map[string]int64{"logs": 3}}
+	cold := &fakeVerifyCold{counts: map[string]int64{"logs": 3}}
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	report, err := NewVerifier(defaultTestConfig(), hot, cold, cpStore).
+		Verify(context.Background(), []string{"logs"}, VerifyOptions{From: from, To: to})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	res := report.Indices[0]
+	if res.Status != VerifyStatusOK || !res.From.Equal(from) || !res.To.Equal(to) {
+		t.Fatalf("result=%+v, want ok for [%v, %v)", res, from, to)
+	}
+}
+
+func TestVerifier_Verify_Samples(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	window := VerifyOptions{To: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Samples: 2}
+	hot := &fakeVerifyHot{
+		counts: map[string]int64{"logs": 2},
+		samples: []json.RawMessage{
+			json.RawMessage(`{"ts":"2026-01-01T10:00:00Z","msg":"a","n":1}`),
+			json.RawMessage(`{"ts":"2026-01-01T11:00:00Z","msg":"b"}`),
+		},
+	}
+	cfg := defaultTestConfig()
+	cfg.Retention.TimestampField = "ts"
+
+	// Quickwit returns the same content with a different key order, number
+	// formatting and timestamp representation.
+	cold := &fakeVerifyCold{
+		counts: map[string]int64{"logs": 2},
+		docs: []json.RawMessage{
+			json.RawMessage(`{"n":1.0,"msg":"a","ts":1767261600000}`),
+			json.RawMessage(`{"msg":"b","ts":"2026-01-01T11:00:00Z"}`),
+		},
+	}
+	report, err := NewVerifier(cfg, hot, cold, cpStore).Verify(context.Background(), []string{"logs"}, window)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if res := report.Indices[0]; res.Status != VerifyStatusOK || res.SamplesChecked != 2 || res.SamplesMissing != 0 {
+		t.Fatalf("result=%+v, want ok with 2 samples checked", res)
+	}
+
+	cold.docs = cold.docs[:1]
+	report, err = NewVerifier(cfg, hot, cold, cpStore).Verify(context.Background(), []string{"logs"}, window)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if res := report.Indices[0]; res.Status != VerifyStatusSampleMismatch || res.SamplesMissing != 1 {
+		t.Fatalf("result=%+v, want sample_mismatch with 1 missing", res)
+	}
+}
+
+func TestVerifier_Verify_ReportsColdSize(t *testing.T) {
+	cpStore, err := NewLocalCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	wm := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, index := range []string{"logs-a", "logs-b"} {
+		if err := cpStore.SaveWatermark(&Watermark{Index: index, MigratedBefore: wm}); err != nil {
+			t.Fatalf("SaveWatermark: %v", err)
+		}
+	}
+	hot := &fakeVerifyHot{counts: map[string]int64{"logs-a": 4, "logs-b": 2}}
+	cold := &fakeDescribedCold{
+		fakeVerifyCold: fakeVerifyCold{counts: map[string]int64{"logs-a": 4, "logs-b": 2}},
+		stats:          map[string]*backend.IndexStats{"logs-a": {NumSplits: 2, SizeBytes: 4096}},
+	}
+
+	report, err := NewVerifier(defaultTestConfig(), hot, cold, cpStore).Verify(context.Background(), []string{"logs-a", "logs-b"}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	a, b := report.Indices[0], report.Indices[1]
+	if a.ColdSplits != 2 || a.ColdSizeBytes != 4096 || a.Status != VerifyStatusOK {
+		t.Fatalf("logs-a=%+v, want 2 splits, 4096 bytes, ok", a)
+	}
+	// A describe failure leaves the size empty without failing verification.
+	if b.ColdSizeBytes != 0 || b.Status != VerifyStatusOK {
+		t.Fatalf("logs-b=%+v, want no size and ok", b)
+	}
+}
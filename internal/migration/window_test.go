@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMigrator_MigrateIndex_WithWindow(t *testing.T) {
+	dir := t.TempDir()
+	hot := newFakeHot(map[int][][]json.RawMessage{
+		0: {makeHits(0, 1), nil},
+		1: {makeHits(1, 1), nil},
+	})
+	cold := newFakeCold()
+	cpStore, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore: %v", err)
+	}
+	// A scheduled run's watermark must neither bound nor be moved by the backfill.
+	scheduled := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := cpStore.SaveWatermark(&Watermark{Index: "logs", MigratedBefore: scheduled}); err != nil {
+		t.Fatalf("SaveWatermark: %v", err)
+	}
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	m, err := NewMigrator(defaultTestConfig(), hot, cold, cpStore, WithWindow(from, to))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	m.progressInterval = time.Millisecond
+
+	if err := m.MigrateIndex(context.Background(), "logs"); err != nil {
+		t.Fatalf("MigrateIndex: %v", err)
+	}
+
+	var q map[string]interface{}
+	if err := json.Unmarshal(hot.queries[0], &q); err != nil {
+		t.Fatalf("unmarshal query: %v", err)
+	}
+	field := extractRangeField(t, q, "@timestamp")
+	if field["gte"] != "2025-06-01T00:00:00.000Z" || field["lt"] != "2025-06-02T00:00:00.000Z" {
+		t.Fatalf("range=%v, want [2025-06-01, 2025-06-02)", field)
+	}
+
+	wm, err := cpStore.LoadWatermark("logs")
+	if err != nil || wm == nil || !wm.MigratedBefore.Equal(scheduled) {
+		t.Fatalf("watermark=%v err=%v, want unchanged %v", wm, err, scheduled)
+	}
+	if cp, _ := cpStore.Load("logs"); cp != nil {
+		t.Fatalf("backfill wrote a persistent checkpoint: %+v", cp)
+	}
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Email sends events as plain-text mail through an SMTP server. The
+// connection is upgraded with STARTTLS when the server supports it.
+type Email struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an Email sender from configuration.
+func NewEmail(cfg config.EmailConfig) *Email {
+	e := &Email{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		from:     cfg.From,
+		to:       cfg.To,
+		sendMail: smtp.SendMail,
+	}
+	if cfg.Username != "" {
+		e.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+	return e
+}
+
+// Send mails ev to the configured recipients. net/smtp has no context
+// support, so ctx is only checked before connecting.
+func (e *Email) Send(ctx context.Context, ev Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := e.sendMail(e.addr, e.auth, e.from, e.to, e.message(ev)); err != nil {
+		return fmt.Errorf("sending mail via %s: %w", e.addr, err)
+	}
+	return nil
+}
+
+func (e *Email) message(ev Event) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: [oqbridge] %s\r\n", ev.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", ev.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	if ev.Summary != "" {
+		b.WriteString(ev.Summary + "\r\n\r\n")
+	}
+	for _, f := range ev.Fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", f.Name, f.Value)
+	}
+	fmt.Fprintf(&b, "\r\nEvent: %s\r\n", ev.Kind)
+	return []byte(b.String())
+}
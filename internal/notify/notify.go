@@ -0,0 +1,86 @@
+// Package notify sends migration alerts to Slack and email.
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Event kinds. They match the names accepted in notifications.*.events.
+const (
+	EventRunFailed         = "run_failed"
+	EventVerifyMismatch    = "verify_mismatch"
+	EventLockContention    = "lock_contention"
+	EventReconcileMismatch = "reconcile_mismatch"
+)
+
+// Field is a labelled value shown with an event.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Event is a single notification.
+type Event struct {
+	Kind    string
+	Title   string
+	Summary string
+	Fields  []Field
+	Time    time.Time
+}
+
+// Sender delivers events to one channel.
+type Sender interface {
+	Send(ctx context.Context, ev Event) error
+}
+
+type route struct {
+	name   string
+	sender Sender
+	events []string // empty means all events
+}
+
+// Notifier fans events out to the senders subscribed to their kind.
+// A nil *Notifier discards events.
+type Notifier struct {
+	routes []route
+}
+
+// New creates a Notifier from configuration. It returns nil when no channel
+// is configured.
+func New(cfg config.NotificationsConfig) *Notifier {
+	n := &Notifier{}
+	if cfg.Slack.WebhookURL != "" {
+		n.routes = append(n.routes, route{name: "slack", sender: NewSlack(cfg.Slack.WebhookURL, nil), events: cfg.Slack.Events})
+	}
+	if cfg.Email.SMTPHost != "" {
+		n.routes = append(n.routes, route{name: "email", sender: NewEmail(cfg.Email), events: cfg.Email.Events})
+	}
+	if len(n.routes) == 0 {
+		return nil
+	}
+	return n
+}
+
+// Notify sends ev to every subscribed channel. Delivery failures are logged
+// and never fail the caller.
+func (n *Notifier) Notify(ctx context.Context, ev Event) {
+	if n == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	for _, r := range n.routes {
+		if len(r.events) > 0 && !slices.Contains(r.events, ev.Kind) {
+			continue
+		}
+		if err := r.sender.Send(ctx, ev); err != nil {
+			slog.Warn("failed to send notification", "channel", r.name, "event", ev.Kind, "error", err)
+		}
+	}
+}
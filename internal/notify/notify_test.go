@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+type fakeSender struct {
+	events []Event
+}
+
+func (f *fakeSender) Send(_ context.Context, ev Event) error {
+	f.events = append(f.events, ev)
+	return nil
+}
+
+func TestNew_NothingConfigured(t *testing.T) {
+	n := New(config.NotificationsConfig{})
+	if n != nil {
+		t.Fatalf("New() = %+v, want nil", n)
+	}
+	// A nil notifier discards events.
+	n.Notify(context.Background(), Event{Kind: EventRunFailed})
+}
+
+func TestNotifier_RoutesByEvent(t *testing.T) {
+	all, failures := &fakeSender{}, &fakeSender{}
+	n := &Notifier{routes: []route{
+		{name: "all", sender: all},
+		{name: "failures", sender: failures, events: []string{EventRunFailed}},
+	}}
+
+	n.Notify(context.Background(), Event{Kind: EventRunFailed, Title: "run failed"})
+	n.Notify(context.Background(), Event{Kind: EventLockContention, Title: "lock held"})
+
+	if len(all.events) != 2 {
+		t.Fatalf("all received %d events, want 2", len(all.events))
+	}
+	if len(failures.events) != 1 || failures.events[0].Kind != EventRunFailed {
+		t.Fatalf("failures received %+v, want only run_failed", failures.events)
+	}
+	if all.events[0].Time.IsZero() {
+		t.Fatal("expected Notify to set the event time")
+	}
+}
+
+func TestSlack_Send(t *testing.T) {
+	var msg struct {
+		Text   string                   `json:"text"`
+		Blocks []map[string]interface{} `json:"blocks"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ev := Event{
+		Kind:    EventRunFailed,
+		Title:   "Migration run failed",
+		Summary: "2 of 5 indices failed",
+		Fields:  []Field{{"logs-a", "quickwit returned 503"}, {"logs-b", "timeout"}},
+		Time:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := NewSlack(srv.URL, srv.Client()).Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if msg.Text != "Migration run failed" {
+		t.Fatalf("text=%q, want the title as fallback", msg.Text)
+	}
+	var types []string
+	for _, b := range msg.Blocks {
+		types = append(types, b["type"].(string))
+	}
+	if got := strings.Join(types, ","); got != "header,section,section,context" {
+		t.Fatalf("blocks=%s, want header,section,section,context", got)
+	}
+}
+
+func TestSlack_Send_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("invalid_token"))
+	}))
+	defer srv.Close()
+
+	err := NewSlack(srv.URL, srv.Client()).Send(context.Background(), Event{Title: "x"})
+	if err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Fatalf("expected webhook error, got %v", err)
+	}
+}
+
+func TestEmail_Send(t *testing.T) {
+	e := NewEmail(config.EmailConfig{
+		SMTPHost: "smtp.example.com",
+		SMTPPort: 587,
+		Username: "user",
+		Password: "secret",
+		From:     "oqbridge@example.com",
+		To:       []string{"a@example.com", "b@example.com"},
+	})
+	var gotAddr string
+	var gotTo []string
+	var gotMsg string
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if a == nil {
+			t.Error("expected SMTP auth when a username is configured")
+		}
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+
+	ev := Event{Kind: EventVerifyMismatch, Title: "Verification mismatch", Summary: "1 index differs",
+		Fields: []Field{{"logs-a", "hot=10 cold=9"}}, Time: time.Now()}
+	if err := e.Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || len(gotTo) != 2 {
+		t.Fatalf("addr=%s to=%v", gotAddr, gotTo)
+	}
+	for _, want := range []string{"Subject: [oqbridge] Verification mismatch\r\n", "logs-a: hot=10 cold=9", "Event: verify_mismatch"} {
+		if !strings.Contains(gotMsg, want) {
+			t.Fatalf("message missing %q:\n%s", want, gotMsg)
+		}
+	}
+}
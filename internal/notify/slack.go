@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Slack posts events to a Slack incoming webhook as Block Kit messages.
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack creates a Slack sender for the given incoming webhook URL.
+func NewSlack(webhookURL string, httpClient *http.Client) *Slack {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Slack{webhookURL: webhookURL, client: httpClient}
+}
+
+// Send posts ev to the webhook.
+func (s *Slack) Send(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(slackMessage(ev))
+	if err != nil {
+		return fmt.Errorf("marshaling slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing slack request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("slack webhook failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// slackMessage renders ev as a header, a summary section, a two-column
+// field section and a context line with the event kind and time. The
+// top-level text is the fallback shown in notifications.
+func slackMessage(ev Event) map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]string{"type": "plain_text", "text": ev.Title}},
+	}
+	if ev.Summary != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": ev.Summary},
+		})
+	}
+	// Slack allows at most 10 fields per section.
+	for i := 0; i < len(ev.Fields); i += 10 {
+		var fields []map[string]string
+		for _, f := range ev.Fields[i:min(i+10, len(ev.Fields))] {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", f.Name, f.Value)})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	blocks = append(blocks, map[string]interface{}{
+		"type": "context",
+		"elements": []map[string]string{
+			{"type": "mrkdwn", "text": fmt.Sprintf("oqbridge-migrate · `%s` · %s", ev.Kind, ev.Time.Format(time.RFC3339))},
+		},
+	})
+	return map[string]interface{}{"text": ev.Title, "blocks": blocks}
+}
@@ -0,0 +1,271 @@
+// Package preflight validates a configuration file and probes everything it
+// points at, for the --validate-config mode of both binaries.
+package preflight
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+	"github.com/leonunix/oqbridge/internal/vault"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Check outcomes. Warnings do not fail the report.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// probeTimeout bounds each connectivity probe, including retries.
+const probeTimeout = 15 * time.Second
+
+// certExpiryWarning is how close to expiry a server certificate is reported
+// as a warning.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// Options selects the checks for one binary.
+type Options struct {
+	// Migration adds the checks only oqbridge-migrate needs: its cron
+	// schedules, and OpenSearch access with the service account, which the
+	// proxy does not require because it forwards client credentials.
+	Migration bool
+	UserAgent string           // User-Agent of probe requests, as set by the binary
+	Overrides config.Overrides // Command-line overrides applied on top of the configuration
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Report lists the results of all checks in the order they ran.
+type Report struct {
+	Config  string   `json:"config"`
+	Results []Result `json:"results"`
+}
+
+// OK reports whether no check failed.
+func (r *Report) OK() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(check, status, format string, args ...any) {
+	r.Results = append(r.Results, Result{Check: check, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// WriteText writes the report as a table followed by a summary line.
+func (r *Report) WriteText(w io.Writer) {
+	source := r.Config
+	if source == "" {
+		source = "(environment only)"
+	}
+	fmt.Fprintf(w, "configuration check: %s\n\n", source)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var failed, warned int
+	for _, res := range r.Results {
+		status := res.Status
+		switch res.Status {
+		case StatusFail:
+			failed++
+			status = "FAIL"
+		case StatusWarn:
+			warned++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, res.Check, res.Detail)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d checks, %d failed, %d warnings\n", len(r.Results), failed, warned)
+}
+
+// Run loads and validates the configuration at path, then probes Vault and
+// both backends with the configured TLS settings and credentials. A
+// configuration that cannot be loaded ends the report early.
+func Run(ctx context.Context, path string, opts Options) *Report {
+	r := &Report{Config: path, Results: []Result{}}
+	cfg, err := config.LoadWithOverrides(path, opts.Overrides)
+	if err != nil {
+		r.add("config", StatusFail, "%v", err)
+		return r
+	}
+	r.add("config", StatusOK, "loaded and validated")
+	if opts.UserAgent != "" {
+		cfg.SetDefaultUserAgent(opts.UserAgent)
+	}
+
+	if opts.Migration {
+		r.checkSchedule("migration.schedule", cfg.Migration.Schedule)
+		if cfg.Retention.Enforce.Enabled {
+			r.checkSchedule("retention.enforce.schedule", cfg.Retention.Enforce.Schedule)
+		}
+		if cfg.Retention.TTL.Enabled {
+			r.checkSchedule("retention.ttl.schedule", cfg.Retention.TTL.Schedule)
+		}
+	}
+
+	if cfg.Vault.Address != "" {
+		// The backends would be probed without their credentials.
+		if !r.checkTLS(ctx, "vault tls", cfg.Vault.Address, cfg.Vault.TLSConfig, config.TransportConfig{}) {
+			return r
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		_, err := vault.Load(probeCtx, cfg)
+		cancel()
+		if err != nil {
+			r.add("vault", StatusFail, "%v", err)
+			return r
+		}
+		r.add("vault", StatusOK, "logged in with %s auth and read the configured secrets", cfg.Vault.AuthMethod)
+	}
+
+	// A failed handshake already explains why the backend is unreachable.
+	if r.checkTLS(ctx, "opensearch tls", cfg.OpenSearch.URL, cfg.OpenSearch.TLSConfig, cfg.OpenSearch.Transport) {
+		r.checkOpenSearch(ctx, "opensearch", cfg.OpenSearch, opts)
+	}
+	if opts.Migration {
+		for _, src := range cfg.Migration.Sources {
+			check := "opensearch[" + src.Name + "]"
+			if r.checkTLS(ctx, check+" tls", src.OpenSearch.URL, src.OpenSearch.TLSConfig, src.OpenSearch.Transport) {
+				r.checkOpenSearch(ctx, check, src.OpenSearch, opts)
+			}
+		}
+	}
+	if r.checkTLS(ctx, "quickwit tls", cfg.Quickwit.URL, cfg.Quickwit.TLSConfig, cfg.Quickwit.Transport) {
+		r.checkQuickwit(ctx, "quickwit", cfg.Quickwit)
+	}
+	for _, c := range cfg.QuickwitClusters {
+		check := "quickwit[" + c.Name + "]"
+		if r.checkTLS(ctx, check+" tls", c.Quickwit.URL, c.Quickwit.TLSConfig, c.Quickwit.Transport) {
+			r.checkQuickwit(ctx, check, c.Quickwit)
+		}
+	}
+	return r
+}
+
+// checkSchedule parses a cron expression the way the scheduler does.
+func (r *Report) checkSchedule(key, spec string) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		r.add(key, StatusFail, "invalid cron expression %q: %v", spec, err)
+		return
+	}
+	r.add(key, StatusOK, "%q, next run %s", spec, sched.Next(time.Now()).Format(time.RFC3339))
+}
+
+// checkTLS performs a TLS handshake with the host of rawURL using the
+// client's TLS settings and reports the server certificate. Plain HTTP URLs
+// are skipped. It returns false if the handshake failed.
+func (r *Report) checkTLS(ctx context.Context, check, rawURL string, tc config.TLSConfig, pc config.TransportConfig) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return true
+	}
+	t, err := util.NewTLSTransport(tc, pc)
+	if err != nil {
+		r.add(check, StatusFail, "%v", err)
+		return false
+	}
+	tlsConfig := &tls.Config{}
+	if t != nil && t.TLSClientConfig != nil {
+		tlsConfig = t.TLSClientConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: probeTimeout}, Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		r.add(check, StatusFail, "%v", err)
+		return false
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	leaf := state.PeerCertificates[0]
+	detail := fmt.Sprintf("%s, certificate %q expires %s", tls.VersionName(state.Version), leaf.Subject.CommonName, leaf.NotAfter.Format(time.DateOnly))
+	switch {
+	case tlsConfig.InsecureSkipVerify:
+		r.add(check, StatusWarn, "%s; not verified because tls_skip_verify is set", detail)
+	case time.Until(leaf.NotAfter) < certExpiryWarning:
+		r.add(check, StatusWarn, "%s, in less than %d days", detail, int(certExpiryWarning.Hours()/24))
+	default:
+		r.add(check, StatusOK, "%s, verified", detail)
+	}
+	return true
+}
+
+func (r *Report) checkOpenSearch(ctx context.Context, check string, oc config.OpenSearchConfig, opts Options) {
+	client, err := util.NewOpenSearchClient(oc)
+	if err != nil {
+		r.add(check, StatusFail, "%v", err)
+		return
+	}
+	hot := backend.NewOpenSearch(oc.URL, oc.Username, oc.Password, client)
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	health, err := hot.ClusterHealth(ctx)
+	if err != nil {
+		var se *backend.HTTPStatusError
+		anonymous := oc.Username == "" && !oc.SigV4.Enabled
+		if !opts.Migration && anonymous && errors.As(err, &se) &&
+			(se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden) {
+			r.add(check, StatusWarn, "reachable, but requires credentials (HTTP %d); the proxy forwards those of each client", se.StatusCode)
+			return
+		}
+		r.add(check, StatusFail, "%v", err)
+		return
+	}
+	status := StatusOK
+	if health.Status != "green" {
+		status = StatusWarn
+	}
+	r.add(check, status, "cluster status %s, %d pending tasks, max heap %d%%", health.Status, health.PendingTasks, health.MaxHeapUsedPercent)
+}
+
+func (r *Report) checkQuickwit(ctx context.Context, check string, qc config.QuickwitConfig) {
+	client, err := util.NewQuickwitClient(qc)
+	if err != nil {
+		r.add(check, StatusFail, "%v", err)
+		return
+	}
+	cold := backend.NewQuickwit(qc.URL, qc.Username, qc.Password, false, client)
+	cold.SetAuth(qc.Auth.BearerToken, qc.Auth.Headers)
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	if err := cold.Health(ctx); err != nil {
+		r.add(check, StatusFail, "%v", err)
+		return
+	}
+	indices, err := cold.ListIndices(ctx)
+	if err != nil {
+		r.add(check, StatusFail, "ready, but listing indices failed: %v", err)
+		return
+	}
+	r.add(check, StatusOK, "ready, %d indices", len(indices))
+}
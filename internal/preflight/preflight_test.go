@@ -0,0 +1,176 @@
+package preflight
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newOpenSearch(t *testing.T, requireAuth bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); requireAuth && !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/_cluster/health":
+			w.Write([]byte(`{"status":"green","number_of_pending_tasks":0}`))
+		case "/_nodes/stats/jvm":
+			w.Write([]byte(`{"nodes":{"n1":{"jvm":{"mem":{"heap_used_percent":40}}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newQuickwit(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health/readyz":
+			w.Write([]byte("true"))
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs"}}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func result(t *testing.T, r *Report, check string) Result {
+	t.Helper()
+	for _, res := range r.Results {
+		if res.Check == check {
+			return res
+		}
+	}
+	t.Fatalf("no %q result in %+v", check, r.Results)
+	return Result{}
+}
+
+func TestRun_AllOK(t *testing.T) {
+	hot := newOpenSearch(t, true)
+	qw := newQuickwit(t)
+	path := writeConfig(t, `
+opensearch:
+  url: "`+hot.URL+`"
+  username: "svc"
+  password: "pw"
+quickwit:
+  url: "`+qw.URL+`"
+migration:
+  schedule: "0 2 * * *"
+`)
+
+	r := Run(context.Background(), path, Options{Migration: true})
+	if !r.OK() {
+		t.Fatalf("report not OK: %+v", r.Results)
+	}
+	for _, check := range []string{"config", "migration.schedule", "opensearch", "quickwit"} {
+		if got := result(t, r, check); got.Status != StatusOK {
+			t.Errorf("%s = %+v, want ok", check, got)
+		}
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	r := Run(context.Background(), writeConfig(t, "quickwit:\n  url: \"http://qw:7280\"\n"), Options{})
+	if r.OK() {
+		t.Fatal("expected a failed report")
+	}
+	if len(r.Results) != 1 || r.Results[0].Check != "config" {
+		t.Errorf("results = %+v, want only the config failure", r.Results)
+	}
+}
+
+func TestRun_InvalidSchedule(t *testing.T) {
+	path := writeConfig(t, `
+opensearch:
+  url: "`+newOpenSearch(t, false).URL+`"
+quickwit:
+  url: "`+newQuickwit(t).URL+`"
+migration:
+  schedule: "every night"
+`)
+	r := Run(context.Background(), path, Options{Migration: true})
+	if r.OK() {
+		t.Fatal("expected a failed report")
+	}
+	if got := result(t, r, "migration.schedule"); got.Status != StatusFail {
+		t.Errorf("migration.schedule = %+v, want fail", got)
+	}
+}
+
+func TestRun_OpenSearchWithoutServiceAccount(t *testing.T) {
+	path := writeConfig(t, `
+opensearch:
+  url: "`+newOpenSearch(t, true).URL+`"
+quickwit:
+  url: "`+newQuickwit(t).URL+`"
+`)
+	// The proxy forwards client credentials, so it only warns.
+	if got := result(t, Run(context.Background(), path, Options{}), "opensearch"); got.Status != StatusWarn {
+		t.Errorf("proxy: opensearch = %+v, want warn", got)
+	}
+	if got := result(t, Run(context.Background(), path, Options{Migration: true}), "opensearch"); got.Status != StatusFail {
+		t.Errorf("migrate: opensearch = %+v, want fail", got)
+	}
+}
+
+func TestRun_TLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	base := `
+opensearch:
+  url: "` + srv.URL + `"
+quickwit:
+  url: "` + newQuickwit(t).URL + `"
+`
+	r := Run(context.Background(), writeConfig(t, base), Options{})
+	if got := result(t, r, "opensearch tls"); got.Status != StatusFail {
+		t.Errorf("untrusted certificate: %+v, want fail", got)
+	}
+	for _, res := range r.Results {
+		if res.Check == "opensearch" {
+			t.Error("opensearch probed after a failed handshake")
+		}
+	}
+
+	insecure := strings.Replace(base, srv.URL+`"`, srv.URL+`"`+"\n  tls_skip_verify: true", 1)
+	r = Run(context.Background(), writeConfig(t, insecure), Options{})
+	if got := result(t, r, "opensearch tls"); got.Status != StatusWarn {
+		t.Errorf("tls_skip_verify: %+v, want warn", got)
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	r := &Report{Config: "oqbridge.yaml"}
+	r.add("config", StatusOK, "loaded and validated")
+	r.add("quickwit", StatusFail, "connection refused")
+	var b strings.Builder
+	r.WriteText(&b)
+	out := b.String()
+	for _, want := range []string{"configuration check: oqbridge.yaml", "FAIL", "connection refused", "2 checks, 1 failed, 0 warnings"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
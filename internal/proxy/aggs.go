@@ -0,0 +1,317 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// Searches merged across backends ask OpenSearch for typed_keys, which
+// prefixes the name of every aggregation in the response with its type,
+// e.g. "sum#total", and Quickwit's responses are typed the same way after
+// the request, so that every merge of their results, however many
+// backends and indices a search reaches, knows which aggregations it can
+// combine. MergeSearchResponsesWithOptions takes the prefixes off again
+// unless the client asked for typed_keys itself.
+
+// avgStatsPrefix names the stats aggregation planFanout adds next to each
+// top-level avg aggregation, whose count weighs the average of each
+// backend. It is never returned to the client.
+const avgStatsPrefix = "oqbridge#avg_stats#"
+
+// mergeableMetrics are the metric aggregations whose results over disjoint
+// sets of documents combine into the result over all of them.
+var mergeableMetrics = map[string]bool{
+	"sum":         true,
+	"min":         true,
+	"max":         true,
+	"avg":         true,
+	"value_count": true,
+	"stats":       true,
+}
+
+// addAvgStats adds a stats aggregation over the same values next to each
+// top-level avg aggregation of the search body m.
+func addAvgStats(m map[string]any) {
+	for _, key := range []string{"aggs", "aggregations"} {
+		aggs, ok := m[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		var avgs []string
+		for name, v := range aggs {
+			if _, typ := metricAggregation(v); typ == "avg" {
+				avgs = append(avgs, name)
+			}
+		}
+		for _, name := range avgs {
+			def, _ := metricAggregation(aggs[name])
+			aggs[avgStatsPrefix+name] = map[string]any{"stats": def["avg"]}
+		}
+	}
+}
+
+// metricAggregation returns the definition and type of v if it is a
+// mergeable metric aggregation.
+func metricAggregation(v any) (map[string]any, string) {
+	def, ok := v.(map[string]any)
+	if !ok {
+		return nil, ""
+	}
+	typ := ""
+	for key := range def {
+		switch {
+		case key == "meta":
+		case mergeableMetrics[key] && typ == "":
+			typ = key
+		default:
+			return nil, ""
+		}
+	}
+	return def, typ
+}
+
+// aggregationType returns the type of the aggregation definition v, or ""
+// if it has none.
+func aggregationType(v any) string {
+	def, _ := v.(map[string]any)
+	for key := range def {
+		switch key {
+		case "meta", "aggs", "aggregations":
+		default:
+			return key
+		}
+	}
+	return ""
+}
+
+// withTypedKeys adds typed_keys to rawQuery, the query string of a search
+// sent to OpenSearch.
+func withTypedKeys(rawQuery string) string {
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery + "&typed_keys=true"
+	}
+	q.Set("typed_keys", "true")
+	return q.Encode()
+}
+
+// wantsTypedKeys reports whether the client asked for typed_keys in r.
+func wantsTypedKeys(r *http.Request) bool {
+	v, ok := r.URL.Query()["typed_keys"]
+	return ok && v[0] != "false"
+}
+
+// typeAggregations prefixes the names of the top-level aggregations of
+// resp, Quickwit's response to the search body, with their type in body.
+func typeAggregations(body []byte, resp *backend.SearchResponse) {
+	if len(resp.Aggregations) == 0 {
+		return
+	}
+	var req map[string]any
+	var aggs map[string]json.RawMessage
+	if json.Unmarshal(body, &req) != nil || json.Unmarshal(resp.Aggregations, &aggs) != nil {
+		return
+	}
+	defs, _ := req["aggs"].(map[string]any)
+	if defs == nil {
+		defs, _ = req["aggregations"].(map[string]any)
+	}
+	typed := make(map[string]json.RawMessage, len(aggs))
+	for name, v := range aggs {
+		if typ := aggregationType(defs[name]); typ != "" {
+			name = typ + "#" + name
+		}
+		typed[name] = v
+	}
+	if b, err := json.Marshal(typed); err == nil {
+		resp.Aggregations = b
+	}
+}
+
+// splitTypedKey splits key, the name of an aggregation in a typed
+// response, into its type and name.
+func splitTypedKey(key string) (typ, name string) {
+	typ, name, ok := strings.Cut(key, "#")
+	if !ok {
+		return "", key
+	}
+	return typ, name
+}
+
+// mergeMetric combines the results of a mergeable metric aggregation of
+// type typ of two searches. avg is left to mergedAvg.
+func mergeMetric(typ string, hot, cold json.RawMessage) json.RawMessage {
+	var h, c map[string]any
+	if json.Unmarshal(hot, &h) != nil || json.Unmarshal(cold, &c) != nil {
+		return hot
+	}
+
+	switch typ {
+	case "sum", "value_count":
+		h["value"] = aggNumber(h["value"]) + aggNumber(c["value"])
+		delete(h, "value_as_string")
+	case "min":
+		if pickBound(h["value"], c["value"], -1) {
+			h["value"], h["value_as_string"] = c["value"], c["value_as_string"]
+		}
+	case "max":
+		if pickBound(h["value"], c["value"], 1) {
+			h["value"], h["value_as_string"] = c["value"], c["value_as_string"]
+		}
+	case "stats":
+		for _, bound := range []struct {
+			key  string
+			sign int
+		}{{"min", -1}, {"max", 1}} {
+			if pickBound(h[bound.key], c[bound.key], bound.sign) {
+				h[bound.key], h[bound.key+"_as_string"] = c[bound.key], c[bound.key+"_as_string"]
+			}
+		}
+		count := aggNumber(h["count"]) + aggNumber(c["count"])
+		sum := aggNumber(h["sum"]) + aggNumber(c["sum"])
+		h["count"], h["sum"], h["avg"] = count, sum, nil
+		if count > 0 {
+			h["avg"] = sum / count
+		}
+		delete(h, "sum_as_string")
+		delete(h, "avg_as_string")
+	default:
+		return hot
+	}
+	for key, v := range h {
+		// Unset string forms of the value picked from the other side.
+		if v == nil && strings.HasSuffix(key, "_as_string") {
+			delete(h, key)
+		}
+	}
+	merged, err := json.Marshal(h)
+	if err != nil {
+		return hot
+	}
+	return merged
+}
+
+// mergedAvg returns avg, an avg result, with the average of stats, the
+// merged stats aggregation added next to it by planFanout.
+func mergedAvg(avg, stats json.RawMessage) json.RawMessage {
+	var a map[string]any
+	var s struct {
+		Avg *float64 `json:"avg"`
+	}
+	if json.Unmarshal(avg, &a) != nil || json.Unmarshal(stats, &s) != nil {
+		return avg
+	}
+	a["value"] = s.Avg
+	delete(a, "value_as_string")
+	merged, err := json.Marshal(a)
+	if err != nil {
+		return avg
+	}
+	return merged
+}
+
+// pickBound reports whether cold is a better bound than hot: smaller for
+// sign -1, larger for sign 1. A backend without documents has none.
+func pickBound(hot, cold any, sign int) bool {
+	c, ok := cold.(float64)
+	if !ok {
+		return false
+	}
+	h, ok := hot.(float64)
+	return !ok || (c-h)*float64(sign) > 0
+}
+
+func aggNumber(v any) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// untypeAggregations drops the stats added by planFanout from the typed
+// aggregations aggs and, unless keep, takes the type prefixes off their
+// names and those of their sub-aggregations.
+func untypeAggregations(aggs json.RawMessage, keep bool) json.RawMessage {
+	if !bytes.Contains(aggs, []byte("#")) {
+		return aggs
+	}
+	var m map[string]json.RawMessage
+	if json.Unmarshal(aggs, &m) != nil {
+		return aggs
+	}
+	out := make(map[string]json.RawMessage, len(m))
+	for key, v := range m {
+		_, name := splitTypedKey(key)
+		switch {
+		case strings.HasPrefix(name, avgStatsPrefix):
+		case keep:
+			out[key] = v
+		default:
+			out[name] = untypeSubAggregations(v)
+		}
+	}
+	untyped, err := json.Marshal(out)
+	if err != nil {
+		return aggs
+	}
+	return untyped
+}
+
+// untypeSubAggregations takes the type prefixes off the names of the
+// sub-aggregations of agg, an aggregation result or bucket: its typed keys
+// and those of its buckets. Other fields, such as the hits of top_hits,
+// are left alone.
+func untypeSubAggregations(agg json.RawMessage) json.RawMessage {
+	if !bytes.Contains(agg, []byte("#")) {
+		return agg
+	}
+	var m map[string]json.RawMessage
+	if json.Unmarshal(agg, &m) != nil {
+		return agg
+	}
+	out := make(map[string]json.RawMessage, len(m))
+	for key, v := range m {
+		switch _, name, typed := strings.Cut(key, "#"); {
+		case typed:
+			out[name] = untypeSubAggregations(v)
+		case key == "buckets":
+			out[key] = untypeBuckets(v)
+		default:
+			out[key] = v
+		}
+	}
+	untyped, err := json.Marshal(out)
+	if err != nil {
+		return agg
+	}
+	return untyped
+}
+
+// untypeBuckets applies untypeSubAggregations to the buckets of an
+// aggregation, a list or, for keyed aggregations, an object.
+func untypeBuckets(buckets json.RawMessage) json.RawMessage {
+	var list []json.RawMessage
+	if json.Unmarshal(buckets, &list) == nil {
+		for i, b := range list {
+			list[i] = untypeSubAggregations(b)
+		}
+		if b, err := json.Marshal(list); err == nil {
+			return b
+		}
+		return buckets
+	}
+	var keyed map[string]json.RawMessage
+	if json.Unmarshal(buckets, &keyed) != nil {
+		return buckets
+	}
+	for k, b := range keyed {
+		keyed[k] = untypeSubAggregations(b)
+	}
+	if b, err := json.Marshal(keyed); err == nil {
+		return b
+	}
+	return buckets
+}
@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+func TestPlanFanout_AddsStatsForAvg(t *testing.T) {
+	plan, err := planFanout([]byte(`{"aggs":{` +
+		`"total":{"sum":{"field":"bytes"}},` +
+		`"mean":{"avg":{"field":"bytes"},"meta":{"unit":"B"}},` +
+		`"hosts":{"terms":{"field":"host"},"aggs":{"top":{"avg":{"field":"bytes"}}}},` +
+		`"uniq":{"cardinality":{"field":"host"}}}}`))
+	if err != nil {
+		t.Fatalf("planFanout() error = %v", err)
+	}
+	var m struct {
+		Aggs map[string]any `json:"aggs"`
+	}
+	if err := json.Unmarshal(plan.Body, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"total":                   map[string]any{"sum": map[string]any{"field": "bytes"}},
+		"mean":                    map[string]any{"avg": map[string]any{"field": "bytes"}, "meta": map[string]any{"unit": "B"}},
+		"oqbridge#avg_stats#mean": map[string]any{"stats": map[string]any{"field": "bytes"}},
+		// Only top-level metrics are merged.
+		"hosts": map[string]any{"terms": map[string]any{"field": "host"}, "aggs": map[string]any{"top": map[string]any{"avg": map[string]any{"field": "bytes"}}}},
+		"uniq":  map[string]any{"cardinality": map[string]any{"field": "host"}},
+	}
+	if !reflect.DeepEqual(m.Aggs, want) {
+		t.Errorf("aggs = %v, want %v", m.Aggs, want)
+	}
+}
+
+func TestTypeAggregations(t *testing.T) {
+	resp := &backend.SearchResponse{Aggregations: json.RawMessage(`{"total":{"value":1},"hosts":{"buckets":[]},"extra":{}}`)}
+	typeAggregations([]byte(`{"aggregations":{"total":{"sum":{"field":"bytes"}},"hosts":{"aggs":{},"terms":{"field":"host"}}}}`), resp)
+	var got map[string]any
+	if err := json.Unmarshal(resp.Aggregations, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"sum#total", "terms#hosts", "extra"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("aggregations = %s, want key %q", resp.Aggregations, key)
+		}
+	}
+}
+
+func TestMergeSearchResponsesWithOptions_MetricAggregations(t *testing.T) {
+	// The hot cluster answers with typed_keys, Quickwit's result is typed
+	// after the request.
+	hot := &backend.SearchResponse{Aggregations: json.RawMessage(`{
+		"sum#total":{"value":10},
+		"value_count#n":{"value":4},
+		"min#first":{"value":1700000000000,"value_as_string":"2023-11-14T22:13:20.000Z"},
+		"max#peak":{"value":null},
+		"avg#mean":{"value":2.5,"meta":{"unit":"B"}},
+		"stats#oqbridge#avg_stats#mean":{"count":4,"min":1,"max":4,"avg":2.5,"sum":10},
+		"stats#s":{"count":4,"min":1,"max":4,"avg":2.5,"sum":10},
+		"sterms#hosts":{"buckets":[{"key":"a","doc_count":4,"max#top":{"value":4}}]}}`)}
+	cold := &backend.SearchResponse{Aggregations: json.RawMessage(`{
+		"sum#total":{"value":30},
+		"value_count#n":{"value":2},
+		"min#first":{"value":1600000000000,"value_as_string":"2020-09-13T12:26:40.000Z"},
+		"max#peak":{"value":7},
+		"avg#mean":{"value":15},
+		"stats#oqbridge#avg_stats#mean":{"count":2,"min":5,"max":25,"avg":15,"sum":30},
+		"stats#s":{"count":0,"min":null,"max":null,"avg":null,"sum":0},
+		"terms#hosts":{"buckets":[{"key":"b","doc_count":2,"top":{"value":25}}]}}`)}
+
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{})
+	var got map[string]any
+	if err := json.Unmarshal(merged.Aggregations, &got); err != nil {
+		t.Fatalf("aggregations %s: %v", merged.Aggregations, err)
+	}
+	want := map[string]any{
+		"total": map[string]any{"value": 40.0},
+		"n":     map[string]any{"value": 6.0},
+		"first": map[string]any{"value": 1600000000000.0, "value_as_string": "2020-09-13T12:26:40.000Z"},
+		"peak":  map[string]any{"value": 7.0},
+		"mean":  map[string]any{"value": 40.0 / 6, "meta": map[string]any{"unit": "B"}},
+		"s":     map[string]any{"count": 4.0, "min": 1.0, "max": 4.0, "avg": 2.5, "sum": 10.0},
+		// Not mergeable: hot wins, its sub-aggregations untyped too.
+		"hosts": map[string]any{"buckets": []any{map[string]any{"key": "a", "doc_count": 4.0, "top": map[string]any{"value": 4.0}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregations = %v\nwant %v", got, want)
+	}
+
+	// Several cold indices are merged before the hot cluster's result.
+	cold2 := &backend.SearchResponse{Aggregations: json.RawMessage(`{"sum#total":{"value":5},"avg#mean":{"value":0},"stats#oqbridge#avg_stats#mean":{"count":1,"min":0,"max":0,"avg":0,"sum":0}}`)}
+	merged = MergeSearchResponsesWithOptions(hot, MergeSearchResponses(cold, cold2), MergeOptions{})
+	json.Unmarshal(merged.Aggregations, &got)
+	if got["total"].(map[string]any)["value"] != 45.0 || got["mean"].(map[string]any)["value"] != 40.0/7 {
+		t.Errorf("aggregations over three searches = %s", merged.Aggregations)
+	}
+}
+
+func TestMergeSearchResponsesWithOptions_TypedKeys(t *testing.T) {
+	hot := &backend.SearchResponse{Aggregations: json.RawMessage(`{"avg#mean":{"value":2},"stats#oqbridge#avg_stats#mean":{"count":1,"avg":2,"sum":2},"sterms#hosts":{"buckets":[{"key":"a","doc_count":1,"max#top":{"value":2}}]}}`)}
+	cold := &backend.SearchResponse{Aggregations: json.RawMessage(`{"avg#mean":{"value":4},"stats#oqbridge#avg_stats#mean":{"count":1,"avg":4,"sum":4}}`)}
+
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{TypedKeys: true})
+	var got map[string]any
+	if err := json.Unmarshal(merged.Aggregations, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"avg#mean":     map[string]any{"value": 3.0},
+		"sterms#hosts": map[string]any{"buckets": []any{map[string]any{"key": "a", "doc_count": 1.0, "max#top": map[string]any{"value": 2.0}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregations = %v\nwant %v", got, want)
+	}
+}
+
+func TestProxy_Both_TypedKeys(t *testing.T) {
+	var hotQuery string
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_plugins/_security/authinfo" {
+			w.Write([]byte(`{"user":"user"}`))
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/_search") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		hotQuery = r.URL.RawQuery
+		aggs := `{"total":{"value":10}}`
+		if r.URL.Query().Get("typed_keys") == "true" {
+			aggs = `{"sum#total":{"value":10}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]},"aggregations":` + aggs + `}`))
+	}))
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/indexes" {
+			w.Write([]byte(`[{"index_config":{"index_id":"logs"}}]`))
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]},"aggregations":{"total":{"value":30}}}`))
+	}))
+	defer qw.Close()
+	p := newTestProxy(t, os.URL, qw.URL)
+
+	old := time.Now().UTC().AddDate(0, 0, -60).Format(time.RFC3339)
+	body := `{"size":0,"query":{"range":{"@timestamp":{"gte":"` + old + `"}}},"aggs":{"total":{"sum":{"field":"bytes"}}}}`
+	for _, tc := range []struct {
+		query string
+		key   string
+	}{
+		{"", "total"},
+		{"?typed_keys=true", "sum#total"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/logs/_search"+tc.query, strings.NewReader(body))
+		req.Header.Set("Authorization", validToken)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d: %s", tc.query, w.Code, w.Body.String())
+		}
+		if hotQuery != "typed_keys=true" {
+			t.Errorf("%q: hot query = %q, want typed_keys=true", tc.query, hotQuery)
+		}
+		var resp struct {
+			Aggregations map[string]struct {
+				Value float64 `json:"value"`
+			} `json:"aggregations"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Aggregations) != 1 || resp.Aggregations[tc.key].Value != 40 {
+			t.Errorf("%q: aggregations = %s, want %s of 40", tc.query, w.Body.String(), tc.key)
+		}
+	}
+}
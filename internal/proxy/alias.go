@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// aliasCacheTTL bounds how long an alias change in OpenSearch can take to
+// reach cold searches.
+const aliasCacheTTL = 30 * time.Second
+
+// aliasLister lists OpenSearch aliases with their backing indices.
+type aliasLister interface {
+	GetAliases(ctx context.Context) (map[string][]string, error)
+}
+
+// aliasCache expands OpenSearch aliases to their backing indices for cold
+// searches, because Quickwit indices are named after the concrete indices
+// they were migrated from. The alias table is fetched with the service
+// account and refreshed at most once per ttl.
+type aliasCache struct {
+	lister aliasLister
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	aliases map[string][]string
+	fetched time.Time
+}
+
+func newAliasCache(lister aliasLister, ttl time.Duration) *aliasCache {
+	return &aliasCache{lister: lister, ttl: ttl}
+}
+
+// expand replaces each alias in indices with its backing indices, dropping
+// duplicates. Other names, including wildcard patterns, are kept as-is. If
+// the alias table cannot be fetched, the last known table is used.
+func (c *aliasCache) expand(ctx context.Context, indices []string) []string {
+	aliases := c.table(ctx)
+	if len(aliases) == 0 {
+		return indices
+	}
+	seen := make(map[string]struct{}, len(indices))
+	out := make([]string, 0, len(indices))
+	for _, idx := range indices {
+		names, ok := aliases[idx]
+		if !ok {
+			names = []string{idx}
+		}
+		for _, name := range names {
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (c *aliasCache) table(ctx context.Context) map[string][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched.IsZero() && time.Since(c.fetched) < c.ttl {
+		return c.aliases
+	}
+	// A failed fetch also waits for the next ttl, so an unreachable or
+	// forbidden alias API does not add a round trip to every search.
+	c.fetched = time.Now()
+	aliases, err := c.lister.GetAliases(ctx)
+	if err != nil {
+		slog.Warn("failed to list opensearch aliases, using last known aliases", "error", err)
+		return c.aliases
+	}
+	c.aliases = aliases
+	return aliases
+}
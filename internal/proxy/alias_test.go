@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeAliasLister struct {
+	aliases map[string][]string
+	err     error
+	calls   int
+}
+
+func (f *fakeAliasLister) GetAliases(_ context.Context) (map[string][]string, error) {
+	f.calls++
+	return f.aliases, f.err
+}
+
+func TestAliasCache_Expand(t *testing.T) {
+	lister := &fakeAliasLister{aliases: map[string][]string{
+		"logs":   {"logs-a", "logs-b"},
+		"recent": {"logs-b"},
+	}}
+	c := newAliasCache(lister, time.Minute)
+
+	got := c.expand(context.Background(), []string{"logs", "recent", "metrics", "app-*"})
+	want := []string{"logs-a", "logs-b", "metrics", "app-*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expand=%v, want %v", got, want)
+	}
+	c.expand(context.Background(), []string{"logs"})
+	if lister.calls != 1 {
+		t.Fatalf("GetAliases called %d times within ttl, want 1", lister.calls)
+	}
+}
+
+func TestAliasCache_FetchErrorKeepsLastTable(t *testing.T) {
+	lister := &fakeAliasLister{aliases: map[string][]string{"logs": {"logs-a"}}}
+	c := newAliasCache(lister, 0)
+
+	if got := c.expand(context.Background(), []string{"logs"}); !reflect.DeepEqual(got, []string{"logs-a"}) {
+		t.Fatalf("expand=%v, want [logs-a]", got)
+	}
+	lister.aliases, lister.err = nil, errors.New("forbidden")
+	if got := c.expand(context.Background(), []string{"logs"}); !reflect.DeepEqual(got, []string{"logs-a"}) {
+		t.Fatalf("expand after failed refresh=%v, want [logs-a]", got)
+	}
+	if lister.calls != 2 {
+		t.Fatalf("GetAliases called %d times, want 2", lister.calls)
+	}
+}
+
+func TestProxy_ColdOnly_AliasSearchesBackingIndices(t *testing.T) {
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_plugins/_security/authinfo":
+			w.Write([]byte(`{"user":"user"}`))
+		case "/_cat/aliases":
+			w.Write([]byte(`[{"alias":"logs","index":"logs-b"},{"alias":"logs","index":"logs-a"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer os.Close()
+
+	var mu sync.Mutex
+	var searched []string
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		index, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/search")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		searched = append(searched, index)
+		mu.Unlock()
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_source":{"msg":"cold"}}]}}`))
+	}))
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(buildColdOnlyQuery()))
+	req.Header.Set("Authorization", validToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	sort.Strings(searched)
+	if want := []string{"logs-a", "logs-b"}; !reflect.DeepEqual(searched, want) {
+		t.Fatalf("searched quickwit indices %v, want %v", searched, want)
+	}
+}
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/capture"
+)
+
+// capturedResponseBytes is how much of a search response is kept to read
+// hits.total from, which comes before the hits.
+const capturedResponseBytes = 64 << 10
+
+// captureWriter passes a search response through to the client, keeping
+// its status and the start of its body for server.capture.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	head   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if room := capturedResponseBytes - c.head.Len(); room > 0 {
+		c.head.Write(b[:min(room, len(b))])
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer.
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// captureSearch records a search served through cw that was routed to
+// route at start.
+func (p *Proxy) captureSearch(r *http.Request, indices []string, body []byte, route string, cw *captureWriter, start time.Time) {
+	rec := capture.Record{
+		Time:    start.UTC(),
+		Path:    r.URL.Path,
+		Query:   r.URL.RawQuery,
+		Indices: indices,
+		Body:    body,
+		Route:   route,
+		Status:  cw.status,
+		TookMS:  time.Since(start).Milliseconds(),
+	}
+	if total, ok := capture.Total(cw.Header(), cw.head.Bytes()); ok {
+		rec.Total = &total
+	}
+	if err := p.capture.Write(rec); err != nil {
+		slog.Warn("failed to capture search", "path", r.URL.Path, "error", err)
+	}
+}
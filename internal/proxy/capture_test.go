@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/capture"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_Capture(t *testing.T) {
+	hot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"took":2,"hits":{"total":{"value":3,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer hot.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: hot.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.Capture = config.CaptureConfig{Enabled: true, Path: path, SampleRate: 1, MaxSizeMB: 1}
+	p, err := New(cfg, backend.NewOpenSearch(hot.URL, "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search?q=user:alice", strings.NewReader(buildHotOnlyQuery()))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"value":3`) {
+		t.Fatalf("search = %d %s", w.Code, w.Body.String())
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var recs []capture.Record
+	capture.Read(bytes.NewReader(data), func(_ int, rec capture.Record) error { recs = append(recs, rec); return nil })
+	if len(recs) != 1 {
+		t.Fatalf("captured %d searches, want 1:\n%s", len(recs), data)
+	}
+	rec := recs[0]
+	if rec.Path != "/logs/_search" || rec.Query != "q=redacted" || rec.Route != "hot_only" || rec.Status != http.StatusOK || rec.Total == nil || *rec.Total != 3 {
+		t.Errorf("record = %+v", rec)
+	}
+	if route, _ := p.RouteSearchAt(rec.Indices, rec.Body, rec.Time); route != rec.Route {
+		t.Errorf("captured body routes to %s, want %s", route, rec.Route)
+	}
+}
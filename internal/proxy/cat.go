@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// catColdIndicesPath lists Quickwit indices with their size, in the style of
+// OpenSearch's _cat/indices, with the columns only Quickwit has.
+const catColdIndicesPath = "/_cat/cold_indices"
+
+// catColdIndex is one row of _cat/cold_indices in JSON format.
+type catColdIndex struct {
+	Index        string `json:"index"`
+	DocsCount    string `json:"docs.count"`
+	Splits       string `json:"splits"`
+	StoreSize    string `json:"store.size"`
+	MinTimestamp string `json:"min_timestamp"`
+	MaxTimestamp string `json:"max_timestamp"`
+}
+
+// isCatColdIndices reports whether path is _cat/cold_indices, optionally
+// followed by a comma-separated list of index names or patterns.
+func isCatColdIndices(path string) (bool, []string) {
+	p := strings.TrimSuffix(path, "/")
+	if p == catColdIndicesPath {
+		return true, nil
+	}
+	if rest, ok := strings.CutPrefix(p, catColdIndicesPath+"/"); ok && !strings.Contains(rest, "/") {
+		return true, splitIndices(rest)
+	}
+	return false, nil
+}
+
+// handleCatColdIndices serves _cat/cold_indices. Like cold searches, the
+// caller must authenticate against OpenSearch first. Supported parameters
+// are "v" (header row), "format=json" and "bytes=b" (raw byte counts).
+func (p *Proxy) handleCatColdIndices(w http.ResponseWriter, r *http.Request, patterns []string) {
+	if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for _cat/cold_indices", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+
+	all, err := p.coldBackend.ListIndices(r.Context())
+	if err != nil {
+		slog.Error("failed to list quickwit indices", "error", err)
+		http.Error(w, `{"error":"failed to list quickwit indices"}`, http.StatusBadGateway)
+		return
+	}
+	sort.Strings(all)
+
+	q := r.URL.Query()
+	rawBytes := q.Get("bytes") == "b"
+	var rows []catColdIndex
+	for _, index := range all {
+		if !matchesAny(patterns, index) {
+			continue
+		}
+		stats, err := p.coldBackend.DescribeIndex(r.Context(), index)
+		if err != nil {
+			slog.Warn("failed to describe quickwit index", "index", index, "error", err)
+			continue
+		}
+		rows = append(rows, catColdRow(stats, rawBytes))
+	}
+
+	if q.Get("format") == "json" {
+		if rows == nil {
+			rows = []catColdIndex{}
+		}
+		writeJSON(w, rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	if q.Has("v") {
+		fmt.Fprintln(tw, "index\tdocs.count\tsplits\tstore.size\tmin_timestamp\tmax_timestamp")
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", row.Index, row.DocsCount, row.Splits, row.StoreSize, row.MinTimestamp, row.MaxTimestamp)
+	}
+	tw.Flush()
+}
+
+func catColdRow(stats *backend.IndexStats, rawBytes bool) catColdIndex {
+	size := formatCatBytes(stats.SizeBytes)
+	if rawBytes {
+		size = fmt.Sprint(stats.SizeBytes)
+	}
+	ts := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	return catColdIndex{
+		Index:        stats.Index,
+		DocsCount:    fmt.Sprint(stats.NumDocs),
+		Splits:       fmt.Sprint(stats.NumSplits),
+		StoreSize:    size,
+		MinTimestamp: ts(stats.MinTimestamp),
+		MaxTimestamp: ts(stats.MaxTimestamp),
+	}
+}
+
+// formatCatBytes renders a byte count the way _cat APIs do, e.g. "1.5gb".
+func formatCatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%db", n)
+	}
+	v, suffix := float64(n), ""
+	for _, s := range []string{"kb", "mb", "gb", "tb", "pb"} {
+		v /= unit
+		suffix = s
+		if v < unit {
+			break
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(fmt.Sprintf("%.1f", v), "0"), ".") + suffix
+}
+
+func matchesAny(patterns []string, index string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == index || pattern == "_all" || util.MatchWildcard(pattern, index) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// catIndicesPath is OpenSearch's _cat/indices, whose rows the proxy
+// completes with the indices that are only in Quickwit.
+const catIndicesPath = "/_cat/indices"
+
+// catIndexColumns are the columns _cat/indices shows by default, in order,
+// with the aliases h accepts for them.
+var catIndexColumns = []struct {
+	name    string
+	aliases []string
+}{
+	{"health", []string{"h"}},
+	{"status", []string{"s"}},
+	{"index", []string{"i", "idx"}},
+	{"uuid", []string{"id"}},
+	{"pri", []string{"p", "shards.primary", "shardsPrimary"}},
+	{"rep", []string{"r", "shards.replica", "shardsReplica"}},
+	{"docs.count", []string{"dc", "docsCount"}},
+	{"docs.deleted", []string{"dd", "docsDeleted"}},
+	{"store.size", []string{"ss", "storeSize"}},
+	{"pri.store.size", nil},
+}
+
+// handleCatIndices serves _cat/indices: OpenSearch's rows, read as the
+// caller, followed by a row for each matching Quickwit index OpenSearch
+// does not have, so that indices migrated and deleted from OpenSearch stay
+// visible. Quickwit rows are green, open, one primary without replicas,
+// and are appended after OpenSearch's, whatever the s parameter.
+func (p *Proxy) handleCatIndices(w http.ResponseWriter, r *http.Request, patterns []string) {
+	ctx := r.Context()
+	failover := p.failover.active()
+	q := r.URL.Query()
+	columns := catIndexHeaders(q.Get("h"))
+	hotQuery := r.URL.Query()
+	hotQuery.Set("format", "json")
+	// The index names tell which Quickwit indices OpenSearch has too.
+	hideIndex := !slices.ContainsFunc(columns, func(col string) bool { return catIndexColumn(col) == "index" })
+	if hideIndex {
+		hotQuery.Set("h", strings.Join(append(slices.Clone(columns), "index"), ","))
+	}
+
+	var rows []map[string]any
+	hotErr := errors.New("opensearch is unavailable")
+	if !failover {
+		var body []byte
+		body, hotErr = p.hotBackend.GetAs(ctx, r.URL.Path, hotQuery.Encode(), r.Header)
+		if hotErr == nil {
+			if err := json.Unmarshal(body, &rows); err != nil {
+				http.Error(w, `{"error":"failed to decode the opensearch _cat/indices response"}`, http.StatusBadGateway)
+				return
+			}
+		}
+	}
+	switch status := httpStatus(hotErr); {
+	case hotErr == nil:
+	case status == http.StatusNotFound || failover:
+		// The indices asked for may only be in Quickwit.
+		if err := p.authenticateViaOpenSearch(ctx, r.Header); err != nil {
+			status := http.StatusBadGateway
+			if isAuthError(err) {
+				status = statusFromAuthError(err)
+			}
+			slog.Warn("auth failed for _cat/indices", "status", status, "error", err)
+			http.Error(w, `{"error":"authentication failed"}`, status)
+			return
+		}
+	default:
+		writeBackendError(w, hotErr)
+		return
+	}
+
+	hot := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		for key, v := range row {
+			if catIndexColumn(key) == "index" {
+				hot[fmt.Sprint(v)] = true
+				if hideIndex {
+					delete(row, key)
+				}
+			}
+		}
+	}
+	cold, err := p.catColdIndexRows(ctx, patterns, q.Get("bytes") == "b")
+	if err != nil {
+		slog.Error("failed to list quickwit indices for _cat/indices", "error", err)
+	}
+	for _, values := range cold {
+		if hot[values["index"]] {
+			continue
+		}
+		row := make(map[string]any, len(columns))
+		for _, col := range columns {
+			row[col] = nil
+			if v, ok := values[catIndexColumn(col)]; ok {
+				row[col] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	if hotErr != nil && len(rows) == 0 {
+		if failover {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+		writeBackendError(w, hotErr)
+		return
+	}
+
+	if q.Get("format") == "json" {
+		if rows == nil {
+			rows = []map[string]any{}
+		}
+		writeJSON(w, rows)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	if q.Has("v") {
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	}
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			if v, ok := row[col]; ok && v != nil {
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	tw.Flush()
+}
+
+// catColdIndexRows returns the _cat/indices columns of the Quickwit indices
+// matching patterns, all of them if there are none.
+func (p *Proxy) catColdIndexRows(ctx context.Context, patterns []string, rawBytes bool) ([]map[string]string, error) {
+	var indices []string
+	var err error
+	if len(patterns) == 0 || slices.Contains(patterns, "_all") {
+		indices, err = p.coldBackend.ListIndices(ctx)
+	} else {
+		indices, err = p.resolveColdIndices(ctx, patterns)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(indices)
+
+	var rows []map[string]string
+	for _, index := range indices {
+		stats, err := p.coldBackend.DescribeIndex(ctx, index)
+		if err != nil {
+			if httpStatus(err) != http.StatusNotFound {
+				slog.Warn("failed to describe quickwit index", "index", index, "error", err)
+			}
+			continue
+		}
+		// Counts and sizes read as in _cat/cold_indices.
+		row := catColdRow(stats, rawBytes)
+		rows = append(rows, map[string]string{
+			"health":         "green",
+			"status":         "open",
+			"index":          index,
+			"uuid":           "-",
+			"pri":            "1",
+			"rep":            "0",
+			"docs.count":     row.DocsCount,
+			"docs.deleted":   "0",
+			"store.size":     row.StoreSize,
+			"pri.store.size": row.StoreSize,
+		})
+	}
+	return rows, nil
+}
+
+// catIndexHeaders returns the columns asked for by the h parameter, or the
+// default ones.
+func catIndexHeaders(h string) []string {
+	if h == "" {
+		columns := make([]string, len(catIndexColumns))
+		for i, col := range catIndexColumns {
+			columns[i] = col.name
+		}
+		return columns
+	}
+	return splitIndices(h)
+}
+
+// catIndexColumn returns the name of the column called name or one of its
+// aliases.
+func catIndexColumn(name string) string {
+	for _, col := range catIndexColumns {
+		if name == col.name || slices.Contains(col.aliases, name) {
+			return col.name
+		}
+	}
+	return name
+}
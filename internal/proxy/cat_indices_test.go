@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxy_CatIndices(t *testing.T) {
+	var hotQuery string
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/_cat/indices") {
+			osHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		hotQuery = r.URL.RawQuery
+		if r.URL.Path != "/_cat/indices" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+			return
+		}
+		if h := r.URL.Query().Get("h"); h != "" {
+			w.Write([]byte(`[{"index":"logs","dc":"3"}]`))
+			return
+		}
+		w.Write([]byte(`[{"health":"yellow","status":"open","index":"logs","uuid":"u1","pri":"1","rep":"1","docs.count":"3","docs.deleted":"0","store.size":"10kb","pri.store.size":"5kb"}]`))
+	})
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs"}},{"index_config":{"index_id":"audit"}}]`))
+		case "/api/v1/indexes/audit/describe":
+			w.Write([]byte(`{"num_published_docs":7,"num_published_splits":1,"size_published_splits":2048}`))
+		case "/api/v1/indexes/logs/describe":
+			w.Write([]byte(`{"num_published_docs":100,"num_published_splits":1,"size_published_splits":4096}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	cat := func(path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := cat("/_cat/indices?v", validToken)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if rec.Code != http.StatusOK || len(lines) != 3 || !strings.HasPrefix(lines[0], "health") {
+		t.Fatalf("_cat/indices: %d\n%s", rec.Code, rec.Body)
+	}
+	// logs is in OpenSearch too; only audit is added.
+	if f := strings.Fields(lines[1]); f[2] != "logs" || f[6] != "3" {
+		t.Errorf("hot row = %q", lines[1])
+	}
+	if f := strings.Fields(lines[2]); strings.Join(f, " ") != "green open audit - 1 0 7 0 2kb 2kb" {
+		t.Errorf("cold row = %q", lines[2])
+	}
+	if !strings.Contains(hotQuery, "format=json") {
+		t.Errorf("hot query = %q, want JSON", hotQuery)
+	}
+
+	// Columns left out of h still tell the OpenSearch indices.
+	rec = cat("/_cat/indices?h=dc&format=json&bytes=b", validToken)
+	var rows []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("h=dc: %v: %s", err, rec.Body)
+	}
+	if len(rows) != 2 || rows[0]["dc"] != "3" || rows[0]["index"] != nil || rows[1]["dc"] != "7" || !strings.Contains(hotQuery, "h=dc%2Cindex") {
+		t.Errorf("h=dc rows = %v, hot query %q", rows, hotQuery)
+	}
+
+	// An index only in Quickwit is listed instead of OpenSearch's 404.
+	if rec := cat("/_cat/indices/audit?format=json&bytes=b", validToken); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"store.size":"2048"`) {
+		t.Errorf("cold-only index: %d %s", rec.Code, rec.Body)
+	}
+	if rec := cat("/_cat/indices/missing", validToken); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown index: %d, want 404", rec.Code)
+	}
+	if rec := cat("/_cat/indices", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"slices"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// coldCache keeps the responses of Quickwit indices to searches
+// (server.cold_cache). Entries expire and are evicted like those of the
+// page cache, but are keyed by index and request body only: cold data is
+// the same for every client allowed to read it.
+type coldCache struct {
+	entries *pageCache
+	maxHits int
+}
+
+func newColdCache(cfg config.ColdCacheConfig) *coldCache {
+	return &coldCache{
+		entries: newPageCache(config.PageCacheConfig{TTL: cfg.TTL, MaxEntries: cfg.MaxEntries}),
+		maxHits: cfg.MaxHits,
+	}
+}
+
+func coldCacheKey(index string, body []byte) string {
+	return index + "\x00" + string(body)
+}
+
+// get returns a copy of the response of index to body, or nil if none is
+// kept.
+func (c *coldCache) get(index string, body []byte) *backend.SearchResponse {
+	resp := c.entries.get(coldCacheKey(index, body))
+	if resp == nil {
+		return nil
+	}
+	cp := *resp
+	cp.Hits.Hits = slices.Clone(resp.Hits.Hits)
+	return &cp
+}
+
+// put keeps a copy of the response of index to body unless it has more
+// than max_hits hits. Merging appends to and sorts the hits of a
+// response, so neither the caller's nor the kept copy may share them.
+func (c *coldCache) put(index string, body []byte, resp *backend.SearchResponse) {
+	if len(resp.Hits.Hits) > c.maxHits {
+		return
+	}
+	cp := *resp
+	cp.Hits.Hits = slices.Clone(resp.Hits.Hits)
+	c.entries.put(coldCacheKey(index, body), &cp)
+}
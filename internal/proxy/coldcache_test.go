@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestColdCache(t *testing.T) {
+	c := newColdCache(config.ColdCacheConfig{TTL: time.Minute, MaxEntries: 10, MaxHits: 2})
+	resp := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{json.RawMessage(`{"_score":1}`)}}}
+	c.put("logs", []byte(`{}`), resp)
+	resp.Hits.Hits[0] = json.RawMessage(`{"_score":2}`)
+
+	got := c.get("logs", []byte(`{}`))
+	if got == nil || string(got.Hits.Hits[0]) != `{"_score":1}` {
+		t.Fatalf("get() = %+v, want the hits as put", got)
+	}
+	got.Hits.Hits = append(got.Hits.Hits[:0], json.RawMessage(`{"_score":3}`))
+	if again := c.get("logs", []byte(`{}`)); string(again.Hits.Hits[0]) != `{"_score":1}` {
+		t.Errorf("changing a returned response changed the cached one: %s", again.Hits.Hits[0])
+	}
+	if c.get("metrics", []byte(`{}`)) != nil || c.get("logs", []byte(`{"size":1}`)) != nil {
+		t.Error("get() of another index or body hit the cache")
+	}
+
+	big := &backend.SearchResponse{Hits: backend.HitsResult{Hits: make([]json.RawMessage, 3)}}
+	c.put("logs", []byte(`{"size":3}`), big)
+	if c.get("logs", []byte(`{"size":3}`)) != nil {
+		t.Error("response over max_hits was cached")
+	}
+}
+
+func TestProxy_ColdCache(t *testing.T) {
+	var coldSearches atomic.Int32
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := coldSearches.Add(1)
+		fmt.Fprintf(w, `{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_score":1,"_source":{"n":%d}}]}}`, n)
+	}))
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Quickwit:   config.QuickwitConfig{URL: qw.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.ColdCache = config.ColdCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10, MaxHits: 100}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	search := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+		req.Header.Set("Authorization", validToken)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("search = %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	first := search(buildColdOnlyQuery())
+	if second := search(buildColdOnlyQuery()); second != first || coldSearches.Load() != 1 {
+		t.Errorf("repeated search sent %d searches to Quickwit, want 1 (%s, then %s)", coldSearches.Load(), first, second)
+	}
+	search(buildBothQuery())
+	if coldSearches.Load() != 2 {
+		t.Errorf("search with another body sent %d searches to Quickwit in total, want 2", coldSearches.Load())
+	}
+}
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// quickwitMaxHits is the largest number of hits Quickwit returns for a
+// single search request; larger sizes are rejected.
+const quickwitMaxHits = 10000
+
+// coldPages splits the from/size window of a search body into requests of
+// at most pageSize hits. It returns nil if the body fits in one request or
+// is not a JSON object, in which case it is sent as is. Aggregations are
+// only requested with the first page.
+func coldPages(body []byte, pageSize int) [][]byte {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil || m == nil {
+		return nil
+	}
+	from := max(getInt(m, "from", 0), 0)
+	size := getInt(m, "size", 10)
+	if size <= pageSize {
+		return nil
+	}
+
+	var pages [][]byte
+	for offset := 0; offset < size; offset += pageSize {
+		m["from"] = from + offset
+		m["size"] = min(pageSize, size-offset)
+		if offset > 0 {
+			delete(m, "aggs")
+			delete(m, "aggregations")
+		}
+		page, err := json.Marshal(m)
+		if err != nil {
+			return nil
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// searchColdIndex searches a single cold index, issuing consecutive
+// start-offset requests when the body asks for more hits than Quickwit
+// returns at once. Totals, shards and aggregations come from the first
+// page; hits of later pages are appended in order.
+func (p *Proxy) searchColdIndex(ctx context.Context, index string, body []byte) (*backend.SearchResponse, error) {
+	return p.searchColdIndexOn(ctx, p.coldBackend, index, body)
+}
+
+// searchColdIndexOn is searchColdIndex on the Quickwit backend cold.
+// Responses of the local Quickwit cluster are served from and kept in the
+// cold cache if it is enabled.
+func (p *Proxy) searchColdIndexOn(ctx context.Context, cold ColdBackend, index string, body []byte) (*backend.SearchResponse, error) {
+	cache := p.coldCache
+	if cold != p.coldBackend {
+		cache = nil
+	}
+	if cache != nil {
+		if resp := cache.get(index, body); resp != nil {
+			return resp, nil
+		}
+	}
+	resp, err := p.fetchColdIndex(ctx, cold, index, body)
+	if err == nil && cache != nil {
+		cache.put(index, body, resp)
+	}
+	return resp, err
+}
+
+// fetchColdIndex searches index on cold, in pages of at most coldPageSize
+// hits. Aggregations are typed as with typed_keys.
+func (p *Proxy) fetchColdIndex(ctx context.Context, cold ColdBackend, index string, body []byte) (*backend.SearchResponse, error) {
+	pages := coldPages(body, p.coldPageSize)
+	if pages == nil {
+		resp, err := cold.Search(ctx, index, body)
+		if err == nil {
+			chargeColdHits(ctx, len(resp.Hits.Hits))
+			typeAggregations(body, resp)
+		}
+		return resp, err
+	}
+
+	var merged *backend.SearchResponse
+	for i, page := range pages {
+		resp, err := cold.Search(ctx, index, page)
+		if err != nil {
+			return nil, err
+		}
+		chargeColdHits(ctx, len(resp.Hits.Hits))
+		if merged == nil {
+			merged = resp
+		} else {
+			merged.Took += resp.Took
+			merged.TimedOut = merged.TimedOut || resp.TimedOut
+			merged.Hits.Hits = append(merged.Hits.Hits, resp.Hits.Hits...)
+		}
+		// A short page means the index has no more matching hits.
+		if i < len(pages)-1 && len(resp.Hits.Hits) < p.coldPageSize {
+			break
+		}
+	}
+	typeAggregations(body, merged)
+	return merged, nil
+}
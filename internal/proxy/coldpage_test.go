@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+func TestColdPages(t *testing.T) {
+	if pages := coldPages([]byte(`{"size":10}`), 10); pages != nil {
+		t.Fatalf("expected no paging for size within limit, got %d pages", len(pages))
+	}
+	if pages := coldPages([]byte(`not json`), 10); pages != nil {
+		t.Fatalf("expected no paging for non-JSON body, got %d pages", len(pages))
+	}
+
+	pages := coldPages([]byte(`{"from":5,"size":25,"aggs":{"a":{}}}`), 10)
+	want := []struct {
+		from, size int
+		aggs       bool
+	}{{5, 10, true}, {15, 10, false}, {25, 5, false}}
+	if len(pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(pages), len(want))
+	}
+	for i, page := range pages {
+		var m map[string]any
+		if err := json.Unmarshal(page, &m); err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		_, aggs := m["aggs"]
+		if getInt(m, "from", -1) != want[i].from || getInt(m, "size", -1) != want[i].size || aggs != want[i].aggs {
+			t.Errorf("page %d = %s, want from=%d size=%d aggs=%v", i, page, want[i].from, want[i].size, want[i].aggs)
+		}
+	}
+}
+
+func TestProxy_Fanout_PagesColdHitsBeyondMaxHits(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+
+	const coldDocs = 23
+	var mu sync.Mutex
+	var requests []string
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			From int `json:"from"`
+			Size int `json:"size"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%d+%d", req.From, req.Size))
+		mu.Unlock()
+
+		resp := backend.SearchResponse{Hits: backend.HitsResult{Total: backend.HitsTotal{Value: coldDocs, Relation: "eq"}}}
+		for i := req.From; i < min(req.From+req.Size, coldDocs); i++ {
+			resp.Hits.Hits = append(resp.Hits.Hits, json.RawMessage(fmt.Sprintf(`{"_id":"cold-%d","_score":%g}`, i, 0.99-0.01*float64(i))))
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+	p.coldPageSize = 10
+
+	body := fmt.Sprintf(`{"from":15,"size":5,%s`, strings.TrimPrefix(buildBothQuery(), "{"))
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+	req.Header.Set("Authorization", validToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := strings.Join(requests, ","); got != "0+10,10+10" {
+		t.Fatalf("cold requests %s, want 0+10,10+10", got)
+	}
+
+	var resp backend.SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(resp.Hits.Hits) != 5 {
+		t.Fatalf("expected 5 hits, got %d", len(resp.Hits.Hits))
+	}
+	// The hot hit scores highest, so merged position 15 is cold hit 14.
+	var first struct {
+		ID string `json:"_id"`
+	}
+	json.Unmarshal(resp.Hits.Hits[0], &first)
+	if first.ID != "cold-14" {
+		t.Fatalf("first hit %q, want cold-14", first.ID)
+	}
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// productHeader is the header Elasticsearch clients since 7.14 check
+// before accepting a server.
+const productHeader = "X-Elastic-Product"
+
+// handleRoot answers "/" for server.compat with OpenSearch's response for
+// the user, reporting the configured version instead of OpenSearch's.
+func (p *Proxy) handleRoot(w http.ResponseWriter, r *http.Request, compat config.CompatConfig) {
+	info, err := p.hotBackend.Info(r.Context(), r.Header)
+	if err != nil {
+		if isAuthError(err) {
+			http.Error(w, `{"error":"authentication failed"}`, statusFromAuthError(err))
+			return
+		}
+		slog.Error("failed to read the opensearch version", "error", err)
+		http.Error(w, `{"error":"failed to read the opensearch version"}`, http.StatusBadGateway)
+		return
+	}
+
+	version, _ := info["version"].(map[string]any)
+	if version == nil {
+		version = make(map[string]any)
+		info["version"] = version
+	}
+	if compat.Version != "" {
+		version["number"] = compat.Version
+	}
+	if compat.Distribution != "" {
+		version["distribution"] = compat.Distribution
+	}
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, info)
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_Compat(t *testing.T) {
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/" {
+			w.Write([]byte(`{"name":"os-1","cluster_name":"logs","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer osSrv.Close()
+	qwSrv := newMockQuickwit(t)
+	defer qwSrv.Close()
+
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+	cfg := *p.Config()
+	cfg.Server.Compat = config.CompatConfig{Enabled: true, Version: "7.10.2", ProductHeader: "Elasticsearch"}
+	p.SetConfig(&cfg)
+
+	serve := func(method, path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/", validToken)
+	if rec.Code != http.StatusOK || rec.Header().Get(productHeader) != "Elasticsearch" {
+		t.Fatalf("GET /: %d, %s=%q", rec.Code, productHeader, rec.Header().Get(productHeader))
+	}
+	var info struct {
+		ClusterName string `json:"cluster_name"`
+		Version     struct {
+			Number       string `json:"number"`
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if info.ClusterName != "logs" || info.Version.Number != "7.10.2" || info.Version.Distribution != "opensearch" {
+		t.Errorf("GET / = %+v", info)
+	}
+
+	if rec := serve(http.MethodHead, "/", validToken); rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("HEAD /: %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec := serve(http.MethodGet, "/", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET / without credentials: %d, want 401", rec.Code)
+	}
+
+	// Passed through and generated responses carry the header too.
+	if rec := serve(http.MethodGet, "/_cluster/health", validToken); rec.Header().Get(productHeader) != "Elasticsearch" {
+		t.Errorf("passthrough: %s=%q", productHeader, rec.Header().Get(productHeader))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(buildColdOnlyQuery()))
+	req.Header.Set("Authorization", validToken)
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get(productHeader) != "Elasticsearch" {
+		t.Errorf("cold search: %d, %s=%q", rec.Code, productHeader, rec.Header().Get(productHeader))
+	}
+}
+
+func TestProxy_CompatDisabled(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	qwSrv := newMockQuickwit(t)
+	defer qwSrv.Close()
+
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	if rec.Header().Get(productHeader) != "" || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("GET / = %s with %s=%q, want OpenSearch's response", rec.Body, productHeader, rec.Header().Get(productHeader))
+	}
+}
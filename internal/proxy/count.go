@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// handleCount answers /{index}/_count. The query is routed like a search,
+// and the counts of the tiers it reaches are summed.
+func (p *Proxy) handleCount(w http.ResponseWriter, r *http.Request, indices []string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	// Restore body for potential passthrough.
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	span := p.tiersForRequest(r, body, indices)
+	if p.failover.active() {
+		if span = withoutHot(span); !slices.Contains(span, true) {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+	}
+	p.routes.record(routeName(span))
+	slog.Debug("count routing decision", "indices", strings.Join(indices, ","), "tiers", span)
+	if !slices.Contains(span[1:], true) {
+		p.reverseProxy.ServeHTTP(w, r)
+		return
+	}
+	if p.quota != nil && span[len(span)-1] {
+		if r = p.quota.admit(w, r, 1, p.quota.windows(indices, body)); r == nil {
+			return
+		}
+	}
+
+	if span[len(span)-1] {
+		err = checkCapabilities(p.coldBackend.Capabilities(), body)
+	}
+	if err != nil {
+		if span[0] {
+			slog.Info("falling back to hot-only for unsupported cross-tier count", "indices", strings.Join(indices, ","), "reason", err.Error())
+			p.reverseProxy.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"unsupported query for cold indices","detail":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Only the hot cluster knows the client's users.
+	if !span[0] {
+		if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+			status := http.StatusBadGateway
+			if isAuthError(err) {
+				status = statusFromAuthError(err)
+			}
+			slog.Warn("auth failed for count", "indices", strings.Join(indices, ","), "status", status, "error", err)
+			http.Error(w, `{"error":"authentication failed"}`, status)
+			return
+		}
+	}
+
+	resp, err := p.countTiers(r.Context(), indices, r.URL.Path, r.URL.RawQuery, body, span, r.Header)
+	if err != nil {
+		if isAuthError(err) {
+			http.Error(w, `{"error":"authentication failed"}`, statusFromAuthError(err))
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"count failed","detail":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// countTiers counts the documents matching body in the tiers of span in
+// parallel and sums the counts, like searchTiers. The hot cluster is asked
+// as the client, at path; the response keeps its _shards.
+func (p *Proxy) countTiers(ctx context.Context, indices []string, path, rawQuery string, body []byte, span []bool, header http.Header) (*backend.CountResponse, error) {
+	results := make([]*backend.CountResponse, len(span))
+	last := len(span) - 1
+	answered, err := p.eachTier(ctx, span, header, func(i int) (err error) {
+		switch i {
+		case 0:
+			results[i], err = p.hotBackend.CountRaw(ctx, path, rawQuery, body, header)
+		case last:
+			var count int64
+			count, err = p.countColdIndices(ctx, indices, body)
+			results[i] = &backend.CountResponse{Count: count}
+		default:
+			// Indices may not have reached this tier yet. Remote
+			// indices are unknown to tiers.
+			local := slices.DeleteFunc(slices.Clone(indices), isRemoteIndex)
+			if len(local) == 0 {
+				results[i] = &backend.CountResponse{}
+				break
+			}
+			results[i], err = p.tiers[i-1].backend.CountRaw(ctx, "/"+strings.Join(local, ",")+"/_count", "ignore_unavailable=true&allow_no_indices=true", body, nil)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	total := &backend.CountResponse{}
+	for i, resp := range results {
+		if !answered[i] {
+			continue
+		}
+		total.Count += resp.Count
+		if total.Shards == nil {
+			total.Shards = resp.Shards
+		}
+	}
+	return total, nil
+}
+
+// countColdIndices sums the counts of the Quickwit indices matching indices,
+// local and of remote clusters, counting at most
+// server.cold_fanout.max_concurrency of them at once.
+func (p *Proxy) countColdIndices(ctx context.Context, indices []string, body []byte) (int64, error) {
+	type target struct {
+		cold  ColdBackend
+		index string
+	}
+	var targets []target
+	local, remote := p.splitRemote(indices)
+	if len(local) > 0 {
+		resolved, err := p.resolveColdIndices(ctx, local)
+		if err != nil {
+			return 0, err
+		}
+		for _, index := range resolved {
+			targets = append(targets, target{p.coldBackend, index})
+		}
+	}
+	for name, patterns := range remote {
+		cold, ok := p.remotes[name]
+		if !ok {
+			cold = p.coldBackend
+		}
+		resolved, err := expandColdWildcards(ctx, cold, patterns)
+		if err != nil {
+			return 0, err
+		}
+		for _, index := range resolved {
+			targets = append(targets, target{cold, index})
+		}
+	}
+
+	var slots chan struct{}
+	if n := p.live.Load().cfg.Server.ColdFanout.MaxConcurrency; n > 0 {
+		slots = make(chan struct{}, n)
+	}
+	counts := make([]int64, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		if err := p.acquireColdSlot(ctx, slots); err != nil {
+			errs[i] = err
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer p.releaseColdSlot(slots)
+			counts[i], errs[i] = t.cold.Count(ctx, t.index, body)
+		}()
+	}
+	wg.Wait()
+
+	var total int64
+	for i, count := range counts {
+		if errs[i] != nil {
+			return 0, fmt.Errorf("%s: %w", targets[i].index, errs[i])
+		}
+		total += count
+	}
+	return total, nil
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProxy_Count(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		hotPaths []string
+		coldBody map[string]any
+	)
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hotPaths = append(hotPaths, r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/_count") {
+			if r.Header.Get("Authorization") != validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"count":1,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0}}`))
+			return
+		}
+		osHandler.ServeHTTP(w, r)
+	})
+	qwSrv := newMockQuickwit(t)
+	defer qwSrv.Close()
+	qwHandler := qwSrv.Config.Handler
+	qwSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		json.Unmarshal(body, &coldBody)
+		mu.Unlock()
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		qwHandler.ServeHTTP(w, r)
+	})
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	count := func(body, auth string) *httptest.ResponseRecorder {
+		hotPaths = nil
+		req := httptest.NewRequest(http.MethodPost, "/logs/_count", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := count(buildColdOnlyQuery(), validToken)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"count":1`) {
+		t.Errorf("cold-only count: %d %s", rec.Code, rec.Body)
+	}
+	if coldBody["size"] != 0.0 || coldBody["track_total_hits"] != true || coldBody["query"] == nil {
+		t.Errorf("cold search body = %v", coldBody)
+	}
+
+	rec = count(buildBothQuery(), validToken)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"count":2`) || !strings.Contains(rec.Body.String(), `"_shards":{"total":1`) {
+		t.Errorf("cross-tier count: %d %s", rec.Code, rec.Body)
+	}
+	if len(hotPaths) != 1 || hotPaths[0] != "/logs/_count" {
+		t.Errorf("hot cluster requests = %v, want a count", hotPaths)
+	}
+
+	if rec := count(buildHotOnlyQuery(), validToken); rec.Code != http.StatusOK || len(hotPaths) != 1 || hotPaths[0] != "/logs/_count" {
+		t.Errorf("hot-only count: %d, hot cluster requests %v; want a passthrough", rec.Code, hotPaths)
+	}
+
+	if rec := count(buildColdOnlyQuery(), ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("cold-only count without credentials: %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/dashboard"
+)
+
+// routeStats counts searches by how they were routed since the proxy
+// started: a RouteTarget, "tiered" or "page_cache".
+type routeStats struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func (s *routeStats) record(route string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[string]uint64)
+	}
+	s.counts[route]++
+}
+
+func (s *routeStats) snapshot() map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.counts)
+}
+
+// DashboardStatus reports the routing statistics and the data layout of
+// every Quickwit index for the dashboard.
+func (p *Proxy) DashboardStatus(ctx context.Context) *dashboard.Status {
+	st := &dashboard.Status{Service: "oqbridge", Routing: p.routes.snapshot()}
+	indices, err := p.coldBackend.ListIndices(ctx)
+	if err != nil {
+		st.Errors = append(st.Errors, "listing quickwit indices: "+err.Error())
+	}
+	sort.Strings(indices)
+	cfg, now := p.live.Load().cfg, time.Now()
+	for _, index := range indices {
+		st.Layout = append(st.Layout, dashboard.Layout(cfg, index, now))
+	}
+	return st
+}
@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxy_DashboardStatus(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	qw := newMockQuickwitWithIndices(t, []string{"logs", "audit"})
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+	for _, body := range []string{buildHotOnlyQuery(), buildHotOnlyQuery(), buildColdOnlyQuery()} {
+		req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+		req.Header.Set("Authorization", validToken)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	st := p.DashboardStatus(context.Background())
+	if st.Routing["hot_only"] != 2 || st.Routing["cold_only"] != 1 {
+		t.Errorf("routing = %v, want 2 hot and 1 cold", st.Routing)
+	}
+	if len(st.Layout) != 2 || st.Layout[0].Index != "audit" || st.Layout[1].Index != "logs" {
+		t.Errorf("layout = %+v, want audit and logs", st.Layout)
+	}
+	if len(st.Errors) != 0 {
+		t.Errorf("errors = %v", st.Errors)
+	}
+}
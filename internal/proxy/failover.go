@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// degradedHeader marks the responses sent while failover is active.
+const degradedHeader = "X-Oqbridge-Degraded"
+
+// failoverUnavailable is the error of the requests failover does not serve.
+const failoverUnavailable = `{"error":"opensearch is unavailable","detail":"only searches of cold data are served until it is back"}`
+
+// failover watches the hot cluster and, while it is unreachable, lets the
+// proxy serve searches from the other tiers with clients authenticated
+// against server.failover.users_file (server.failover).
+type failover struct {
+	settings config.FailoverConfig
+	probe    func(ctx context.Context) error
+
+	down atomic.Bool
+
+	mu       sync.Mutex
+	users    map[string]failoverUser        // by name
+	verified map[[32]byte]*backend.AuthInfo // by hash of the credentials, to skip bcrypt
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// failoverUser is an entry of the users file.
+type failoverUser struct {
+	hash  []byte
+	roles []string
+}
+
+func newFailover(settings config.FailoverConfig, hot *backend.OpenSearch) (*failover, error) {
+	users, err := loadFailoverUsers(settings.UsersFile)
+	if err != nil {
+		return nil, fmt.Errorf("server.failover: %w", err)
+	}
+	f := &failover{
+		settings: settings,
+		probe: func(ctx context.Context) error {
+			_, err := hot.Info(ctx, nil)
+			return err
+		},
+		users:    users,
+		verified: make(map[[32]byte]*backend.AuthInfo),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go f.run()
+	return f, nil
+}
+
+// active reports whether the hot cluster is considered down. A nil
+// failover is never active.
+func (f *failover) active() bool {
+	return f != nil && f.down.Load()
+}
+
+func (f *failover) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(f.settings.CheckInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), f.settings.CheckInterval)
+			err := f.probe(ctx)
+			cancel()
+			failures = f.observe(err, failures)
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// observe records the result of a probe, given the number of consecutive
+// failures before it, and returns the new number. A response with an HTTP
+// status below 500, e.g. 401 for the missing credentials, means OpenSearch
+// is up.
+func (f *failover) observe(err error, failures int) int {
+	var httpErr *backend.HTTPStatusError
+	if err == nil || (errors.As(err, &httpErr) && httpErr.StatusCode < 500) {
+		if f.down.Swap(false) {
+			slog.Info("opensearch is reachable again, failover ended")
+		}
+		return 0
+	}
+	failures++
+	if failures >= f.settings.FailureThreshold && !f.down.Load() {
+		// Pick up users added to the file since the proxy started.
+		if users, err := loadFailoverUsers(f.settings.UsersFile); err != nil {
+			slog.Error("failed to reload failover users, keeping the previous ones", "error", err)
+		} else {
+			f.mu.Lock()
+			f.users, f.verified = users, make(map[[32]byte]*backend.AuthInfo)
+			f.mu.Unlock()
+		}
+		f.down.Store(true)
+		slog.Error("opensearch is unreachable, failover started: serving cold searches only", "failures", failures, "error", err)
+	}
+	return failures
+}
+
+// authInfo identifies the user of h by the basic auth credentials it
+// carries. Failures are reported as 401 HTTPStatusErrors, like those of
+// OpenSearch's security plugin.
+func (f *failover) authInfo(h http.Header) (*backend.AuthInfo, error) {
+	name, password, ok := (&http.Request{Header: h}).BasicAuth()
+	if !ok {
+		return nil, &backend.HTTPStatusError{StatusCode: http.StatusUnauthorized, URL: f.settings.UsersFile, Body: "basic auth credentials required during failover"}
+	}
+	key := sha256.Sum256([]byte(name + "\x00" + password))
+	f.mu.Lock()
+	info, ok := f.verified[key]
+	u, known := f.users[name]
+	f.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+	if !known || bcrypt.CompareHashAndPassword(u.hash, []byte(password)) != nil {
+		return nil, &backend.HTTPStatusError{StatusCode: http.StatusUnauthorized, URL: f.settings.UsersFile, Body: "unknown user or wrong password"}
+	}
+	info = &backend.AuthInfo{UserName: name, Roles: u.roles}
+	f.mu.Lock()
+	if len(f.verified) >= maxCachedUsers {
+		clear(f.verified)
+	}
+	f.verified[key] = info
+	f.mu.Unlock()
+	return info, nil
+}
+
+func (f *failover) close() {
+	close(f.stop)
+	<-f.done
+}
+
+// loadFailoverUsers reads a users file: one "name:bcrypt-hash" per line, as
+// written by htpasswd -B, optionally followed by ":" and the user's roles
+// separated by commas. Empty lines and lines starting with # are skipped.
+func loadFailoverUsers(path string) (map[string]failoverUser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	defer file.Close()
+
+	users := make(map[string]failoverUser)
+	sc := bufio.NewScanner(file)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%s:%d: expected name:hash", path, n)
+		}
+		if _, err := bcrypt.Cost([]byte(parts[1])); err != nil {
+			return nil, fmt.Errorf("%s:%d: password of %s is not a bcrypt hash", path, n, parts[0])
+		}
+		u := failoverUser{hash: []byte(parts[1])}
+		if len(parts) == 3 {
+			for _, role := range strings.Split(parts[2], ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					u.roles = append(u.roles, role)
+				}
+			}
+		}
+		users[parts[0]] = u
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	return users, nil
+}
+
+// withoutHot returns span without the hot cluster, for searches during
+// failover.
+func withoutHot(span []bool) []bool {
+	span = slices.Clone(span)
+	span[0] = false
+	return span
+}
@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func writeUsersFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFailoverUsers(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := loadFailoverUsers(writeUsersFile(t, "# emergency access\n\nalice:"+string(hash)+"\nbob:"+string(hash)+":ops, readers\n"))
+	if err != nil {
+		t.Fatalf("loadFailoverUsers: %v", err)
+	}
+	if len(users) != 2 || users["alice"].roles != nil || strings.Join(users["bob"].roles, ",") != "ops,readers" {
+		t.Errorf("users = %+v", users)
+	}
+
+	for content, want := range map[string]string{
+		"alice\n":                 "expected name:hash",
+		"alice:plaintext\n":       "password of alice is not a bcrypt hash",
+		":" + string(hash) + "\n": "expected name:hash",
+		"alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n": "not a bcrypt hash",
+	} {
+		if _, err := loadFailoverUsers(writeUsersFile(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadFailoverUsers(%q) error = %v, want %q", content, err, want)
+		}
+	}
+	if _, err := loadFailoverUsers(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestFailover_Observe(t *testing.T) {
+	f := &failover{settings: config.FailoverConfig{FailureThreshold: 2, UsersFile: writeUsersFile(t, "")}}
+	down := &backend.HTTPStatusError{StatusCode: http.StatusServiceUnavailable}
+
+	n := f.observe(down, 0)
+	if f.active() {
+		t.Fatal("active after one failure, threshold is 2")
+	}
+	if n = f.observe(down, n); !f.active() {
+		t.Fatal("not active after two failures")
+	}
+	// 401 to the unauthenticated probe means OpenSearch answers.
+	if n = f.observe(&backend.HTTPStatusError{StatusCode: http.StatusUnauthorized}, n); f.active() || n != 0 {
+		t.Fatalf("still active after OpenSearch answered (failures %d)", n)
+	}
+	if (*failover)(nil).active() {
+		t.Error("nil failover is active")
+	}
+}
+
+func TestProxy_Failover(t *testing.T) {
+	var osDown atomic.Bool
+	mockOS := newMockOpenSearch(t)
+	defer mockOS.Close()
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if osDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mockOS.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer osSrv.Close()
+	qwSrv := newMockQuickwit(t)
+	defer qwSrv.Close()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Server: config.ServerConfig{Failover: config.FailoverConfig{
+			Enabled:          true,
+			UsersFile:        writeUsersFile(t, "oncall:"+string(hash)+"\n"),
+			CheckInterval:    10 * time.Millisecond,
+			FailureThreshold: 1,
+		}},
+		OpenSearch: config.OpenSearchConfig{URL: osSrv.URL},
+		Quickwit:   config.QuickwitConfig{URL: qwSrv.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	p, err := New(cfg, backend.NewOpenSearch(osSrv.URL, "", "", nil), backend.NewQuickwit(qwSrv.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	waitFor := func(active bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for p.failover.active() != active {
+			if time.Now().After(deadline) {
+				t.Fatalf("failover active = %v, want %v", !active, active)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	serve := func(method, path, body string, user, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/health", "", "", ""); rec.Header().Get(degradedHeader) != "" || !strings.Contains(rec.Body.String(), `"ok"`) {
+		t.Fatalf("health before the outage: %s", rec.Body)
+	}
+
+	osDown.Store(true)
+	waitFor(true)
+
+	rec := serve(http.MethodPost, "/logs/_search", buildColdOnlyQuery(), "oncall", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get(degradedHeader) != "opensearch-unavailable" || !strings.Contains(rec.Body.String(), "cold") {
+		t.Fatalf("cold search: %d %s, %s=%q", rec.Code, rec.Body, degradedHeader, rec.Header().Get(degradedHeader))
+	}
+	if rec := serve(http.MethodPost, "/logs/_search", buildColdOnlyQuery(), "oncall", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: %d, want 401", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/logs/_search", buildColdOnlyQuery(), "user", "pass"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("OpenSearch user not in the users file: %d, want 401", rec.Code)
+	}
+
+	// A search spanning both tiers is answered from cold data alone.
+	rec = serve(http.MethodPost, "/logs/_search", buildBothQuery(), "oncall", "secret")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "hot") || !strings.Contains(rec.Body.String(), "cold") {
+		t.Errorf("search of both tiers: %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(http.MethodPost, "/logs/_search", buildHotOnlyQuery(), "oncall", "secret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("hot-only search: %d, want 503", rec.Code)
+	}
+
+	msearch := `{"index":"logs"}` + "\n" + buildColdOnlyQuery() + "\n" + `{"index":"logs"}` + "\n" + buildHotOnlyQuery() + "\n"
+	rec = serve(http.MethodPost, "/_msearch", msearch, "oncall", "secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "cold") || !strings.Contains(rec.Body.String(), `"status":503`) {
+		t.Errorf("msearch: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(http.MethodPut, "/logs/_doc/1", `{"msg":"x"}`, "oncall", "secret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("write: %d, want 503", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/health", "", "", ""); !strings.Contains(rec.Body.String(), `"degraded"`) {
+		t.Errorf("health during the outage: %s", rec.Body)
+	}
+
+	osDown.Store(false)
+	waitFor(false)
+	if rec := serve(http.MethodPost, "/logs/_search", buildColdOnlyQuery(), "oncall", "secret"); rec.Code != http.StatusUnauthorized || rec.Header().Get(degradedHeader) != "" {
+		t.Errorf("users file still used after the outage: %d", rec.Code)
+	}
+}
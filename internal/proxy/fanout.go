@@ -3,6 +3,8 @@ package proxy
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/leonunix/oqbridge/internal/backend"
 )
 
 type fanoutPlan struct {
@@ -11,7 +13,7 @@ type fanoutPlan struct {
 }
 
 // planFanout prepares a query body for fan-out merging.
-// It supports only score-based ordering (default or explicit _score sort).
+// It supports score-based ordering and sorts on fields (see parseSort).
 // For from/size pagination, it rewrites backend requests to fetch enough hits
 // (size = from+size, from = 0) so that the merged page is correct.
 func planFanout(body []byte) (fanoutPlan, error) {
@@ -39,9 +41,14 @@ func planFanout(body []byte) (fanoutPlan, error) {
 		from = 0
 	}
 
-	scoreAsc, ok := parseScoreSort(m["sort"])
-	if !ok {
-		return plan, fmt.Errorf("explicit sort is not supported (only _score)")
+	sortFields, err := parseSort(m["sort"])
+	if err != nil {
+		return plan, err
+	}
+	scoreAsc := false
+	if len(sortFields) == 1 && sortFields[0].Field == "_score" {
+		// Merged hits are in score order anyway.
+		scoreAsc, sortFields = !sortFields[0].Desc, nil
 	}
 
 	if _, exists := m["search_after"]; exists {
@@ -61,6 +68,7 @@ func planFanout(body []byte) (fanoutPlan, error) {
 
 	m["from"] = 0
 	m["size"] = need
+	addAvgStats(m)
 
 	rebuilt, err := json.Marshal(m)
 	if err != nil {
@@ -72,11 +80,38 @@ func planFanout(body []byte) (fanoutPlan, error) {
 		From:     from,
 		Size:     size,
 		ScoreAsc: scoreAsc,
+		Sort:     sortFields,
 		Paginate: true,
 	}
 	return plan, nil
 }
 
+// checkCapabilities returns an error if body relies on a search feature
+// that caps does not include, so the query is not sent to a backend that
+// would reject or silently ignore it. Bodies that are not JSON are left to
+// the backend.
+func checkCapabilities(caps backend.Capabilities, body []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil
+	}
+	if !caps.SupportsAggregations {
+		if _, ok := m["aggs"]; ok {
+			return fmt.Errorf("aggregations are not supported by the backend")
+		}
+		if _, ok := m["aggregations"]; ok {
+			return fmt.Errorf("aggregations are not supported by the backend")
+		}
+	}
+	if !caps.SupportsSort {
+		// Score order is the default, so an explicit _score sort needs no support.
+		if _, ok := parseScoreSort(m["sort"]); !ok {
+			return fmt.Errorf("explicit sort is not supported by the backend")
+		}
+	}
+	return nil
+}
+
 func getInt(m map[string]any, key string, def int) int {
 	v, ok := m[key]
 	if !ok {
@@ -141,3 +176,82 @@ func parseScoreSort(sortVal any) (scoreAsc bool, ok bool) {
 		return false, false
 	}
 }
+
+// parseSort reads the sort of a search body. Each entry names a field or
+// _score, optionally with an order (fields ascend and _score descends by
+// default) and missing "_first" or "_last". Options that only tell the
+// backends how to read the field, like Kibana's unmapped_type, are left to
+// them. Script, geo distance and _doc sorts, and options such as mode or a
+// missing value, cannot be reproduced when merging and are rejected.
+func parseSort(sortVal any) ([]SortField, error) {
+	var entries []any
+	switch s := sortVal.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		entries = s
+	default:
+		entries = []any{s}
+	}
+
+	fields := make([]SortField, 0, len(entries))
+	for _, entry := range entries {
+		var f SortField
+		var spec any
+		switch e := entry.(type) {
+		case string:
+			f.Field = e
+		case map[string]any:
+			if len(e) != 1 {
+				return nil, fmt.Errorf("sort entry must name exactly one field")
+			}
+			for name, v := range e {
+				f.Field, spec = name, v
+			}
+		default:
+			return nil, fmt.Errorf("unsupported sort entry %v", entry)
+		}
+		switch f.Field {
+		case "_doc", "_script", "_geo_distance":
+			return nil, fmt.Errorf("sort on %s is not supported for cross-tier merge", f.Field)
+		}
+
+		order := ""
+		switch sp := spec.(type) {
+		case nil:
+		case string:
+			order = sp
+		case map[string]any:
+			for key, v := range sp {
+				switch key {
+				case "order":
+					order, _ = v.(string)
+				case "missing":
+					switch v {
+					case "_first":
+						f.MissingFirst = true
+					case "_last":
+					default:
+						return nil, fmt.Errorf("sort on %s: only _first and _last are supported for missing", f.Field)
+					}
+				case "unmapped_type", "format", "numeric_type":
+				default:
+					return nil, fmt.Errorf("sort on %s: option %s is not supported for cross-tier merge", f.Field, key)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported sort entry %v", entry)
+		}
+		switch order {
+		case "":
+			f.Desc = f.Field == "_score"
+		case "asc":
+		case "desc":
+			f.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid sort order %q for %s", order, f.Field)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanFanout_Sort(t *testing.T) {
+	tests := []struct {
+		body     string
+		want     []SortField
+		scoreAsc bool
+	}{
+		{`{}`, nil, false},
+		{`{"sort":"_score"}`, nil, false},
+		{`{"sort":[{"_score":"asc"}]}`, nil, true},
+		{`{"sort":[{"@timestamp":"desc"}]}`, []SortField{{Field: "@timestamp", Desc: true}}, false},
+		// As sent by Kibana's Discover.
+		{`{"sort":[{"@timestamp":{"order":"desc","unmapped_type":"boolean"}}]}`, []SortField{{Field: "@timestamp", Desc: true}}, false},
+		{`{"sort":["host",{"bytes":{"missing":"_first"}},"_score"]}`, []SortField{{Field: "host"}, {Field: "bytes", MissingFirst: true}, {Field: "_score", Desc: true}}, false},
+	}
+	for _, tt := range tests {
+		plan, err := planFanout([]byte(tt.body))
+		if err != nil {
+			t.Errorf("planFanout(%s) error = %v", tt.body, err)
+			continue
+		}
+		if !reflect.DeepEqual(plan.Merge.Sort, tt.want) || plan.Merge.ScoreAsc != tt.scoreAsc {
+			t.Errorf("planFanout(%s) sort = %+v, score asc %v; want %+v, %v", tt.body, plan.Merge.Sort, plan.Merge.ScoreAsc, tt.want, tt.scoreAsc)
+		}
+		// The sort is passed on to the backends.
+		var m map[string]any
+		json.Unmarshal(plan.Body, &m)
+		if strings.Contains(tt.body, "sort") && m["sort"] == nil {
+			t.Errorf("planFanout(%s) dropped the sort: %s", tt.body, plan.Body)
+		}
+	}
+
+	for body, want := range map[string]string{
+		`{"sort":["_doc"]}`: "sort on _doc is not supported",
+		`{"sort":[{"_script":{"type":"number","script":"doc['a'].value"}}]}`: "sort on _script is not supported",
+		`{"sort":[{"bytes":{"mode":"avg"}}]}`:                                "option mode is not supported",
+		`{"sort":[{"bytes":{"missing":0}}]}`:                                 "only _first and _last are supported",
+		`{"sort":[{"bytes":"up"}]}`:                                          `invalid sort order "up"`,
+		`{"sort":[{"a":"asc","b":"asc"}]}`:                                   "exactly one field",
+	} {
+		if _, err := planFanout([]byte(body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("planFanout(%s) error = %v, want %q", body, err, want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// handleFieldCaps answers GET /{index}/_field_caps with the fields of the
+// hot cluster and of the Quickwit indices the index names resolve to, so
+// that index patterns built from it include fields found only in migrated
+// data. Like cold searches, cold fields are only returned once OpenSearch
+// has accepted the caller's credentials.
+func (p *Proxy) handleFieldCaps(w http.ResponseWriter, r *http.Request, indices []string) {
+	ctx := r.Context()
+	failover := p.failover.active()
+	var (
+		hot    *backend.FieldCaps
+		hotErr error
+	)
+	if failover {
+		hotErr = errors.New("opensearch is unavailable")
+	} else {
+		hot, hotErr = p.hotBackend.FieldCaps(ctx, r.URL.Path, r.URL.RawQuery, r.Header)
+	}
+	if isAuthError(hotErr) {
+		http.Error(w, `{"error":"authentication failed"}`, statusFromAuthError(hotErr))
+		return
+	}
+	// The hot cluster's answer to a bad request stands; indices it does not
+	// have, or its failure, leave the cold fields.
+	status := httpStatus(hotErr)
+	if status >= 400 && status < 500 && status != http.StatusNotFound {
+		writeBackendError(w, hotErr)
+		return
+	}
+	if hotErr != nil {
+		if err := p.authenticateViaOpenSearch(ctx, r.Header); err != nil {
+			status := http.StatusBadGateway
+			if isAuthError(err) {
+				status = statusFromAuthError(err)
+			}
+			http.Error(w, `{"error":"authentication failed"}`, status)
+			return
+		}
+	}
+
+	local, _ := p.splitRemote(indices)
+	cold, err := p.coldFieldCaps(ctx, local, splitIndices(r.URL.Query().Get("fields")))
+	if err != nil {
+		slog.Error("quickwit field caps failed", "indices", strings.Join(indices, ","), "error", err)
+	}
+	if cold == nil {
+		if failover {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+		if hotErr != nil {
+			writeBackendError(w, hotErr)
+			return
+		}
+		writeJSON(w, hot)
+		return
+	}
+	writeJSON(w, mergeFieldCaps(hot, cold))
+}
+
+// coldFieldCaps returns the capabilities of the fields matching patterns in
+// the Quickwit indices of indices, or nil if none of them is in Quickwit.
+func (p *Proxy) coldFieldCaps(ctx context.Context, indices, patterns []string) (*backend.FieldCaps, error) {
+	resolved, err := p.resolveColdIndices(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	var merged *backend.FieldCaps
+	for _, index := range resolved {
+		caps, err := p.coldBackend.FieldCaps(ctx, index)
+		if httpStatus(err) == http.StatusNotFound {
+			// Not migrated yet.
+			continue
+		}
+		if err != nil {
+			return merged, err
+		}
+		for name := range caps.Fields {
+			if !matchesAny(patterns, name) {
+				delete(caps.Fields, name)
+			}
+		}
+		merged = mergeFieldCaps(merged, caps)
+	}
+	return merged, nil
+}
+
+// dateSortFields marks the fields merge sorts by that are dates in indices,
+// so that their values are compared as instants whatever format each tier
+// returns them in: the timestamp fields of the indices, and the fields
+// whose every type in the hot cluster and in Quickwit is a date. Other
+// fields are compared as they are. The types read from the tiers are kept
+// in p.sortTypes.
+func (p *Proxy) dateSortFields(ctx context.Context, indices []string, merge MergeOptions, header http.Header) MergeOptions {
+	cfg := p.live.Load().cfg
+	sort := slices.Clone(merge.Sort)
+	var unknown []string
+	for i, f := range sort {
+		switch {
+		case f.Field == "_score":
+		case isTimestampField(cfg, indices, f.Field):
+			sort[i].Date = true
+		default:
+			if date, ok := p.sortTypes.get(indices, f.Field); ok {
+				sort[i].Date = date
+				break
+			}
+			unknown = append(unknown, f.Field)
+		}
+	}
+	if len(unknown) == 0 {
+		merge.Sort = sort
+		return merge
+	}
+
+	var hot *backend.FieldCaps
+	failed := false
+	if !p.failover.active() {
+		q := url.Values{"fields": {strings.Join(unknown, ",")}, "ignore_unavailable": {"true"}, "allow_no_indices": {"true"}}
+		var err error
+		if hot, err = p.hotBackend.FieldCaps(ctx, "/"+strings.Join(indices, ",")+"/_field_caps", q.Encode(), header); err != nil {
+			slog.Warn("failed to read the types of sort fields from opensearch", "indices", strings.Join(indices, ","), "error", err)
+			failed = true
+		}
+	}
+	local, _ := p.splitRemote(indices)
+	cold, err := p.coldFieldCaps(ctx, local, unknown)
+	if err != nil {
+		slog.Warn("failed to read the types of sort fields from quickwit", "indices", strings.Join(indices, ","), "error", err)
+		failed = true
+	}
+	caps := mergeFieldCaps(hot, cold)
+	for i, f := range sort {
+		if !slices.Contains(unknown, f.Field) {
+			continue
+		}
+		if caps != nil {
+			sort[i].Date = isDateField(caps.Fields[f.Field])
+		}
+		// Types read while a tier failed are asked for again next time.
+		if !failed {
+			p.sortTypes.put(indices, f.Field, sort[i].Date)
+		}
+	}
+	merge.Sort = sort
+	return merge
+}
+
+// sortTypeTTL bounds how long a mapping change can take to reach the
+// order of merged hits.
+const sortTypeTTL = 30 * time.Second
+
+// maxCachedSortTypes is how many fields a sortTypeCache remembers; expired
+// entries are dropped when it is full.
+const maxCachedSortTypes = 10000
+
+// sortTypeCache remembers whether the fields searches sort by are dates,
+// by index pattern, so that sorted searches, and every search of an
+// _msearch, do not read the field capabilities of both tiers each time.
+type sortTypeCache struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	fields map[string]cachedSortType // by indices and field
+}
+
+type cachedSortType struct {
+	date    bool
+	fetched time.Time
+}
+
+func newSortTypeCache(ttl time.Duration) *sortTypeCache {
+	return &sortTypeCache{ttl: ttl, fields: make(map[string]cachedSortType)}
+}
+
+func sortTypeKey(indices []string, field string) string {
+	return strings.Join(indices, ",") + "\x00" + field
+}
+
+// get reports whether field is a date in indices, if it was read less than
+// ttl ago.
+func (c *sortTypeCache) get(indices []string, field string) (date, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.fields[sortTypeKey(indices, field)]
+	if !ok || time.Since(t.fetched) >= c.ttl {
+		return false, false
+	}
+	return t.date, true
+}
+
+func (c *sortTypeCache) put(indices []string, field string, date bool) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.fields) >= maxCachedSortTypes {
+		for k, t := range c.fields {
+			if now.Sub(t.fetched) >= c.ttl {
+				delete(c.fields, k)
+			}
+		}
+		if len(c.fields) >= maxCachedSortTypes {
+			clear(c.fields)
+		}
+	}
+	c.fields[sortTypeKey(indices, field)] = cachedSortType{date: date, fetched: now}
+}
+
+// isTimestampField reports whether field is the timestamp field of every
+// index of indices.
+func isTimestampField(cfg *config.Config, indices []string, field string) bool {
+	for _, index := range indices {
+		if cfg.TimestampFieldForIndex(index) != field {
+			return false
+		}
+	}
+	return len(indices) > 0
+}
+
+// isDateField reports whether a field has only date types.
+func isDateField(types map[string]backend.FieldCapability) bool {
+	for typ := range types {
+		if typ != "date" && typ != "date_nanos" {
+			return false
+		}
+	}
+	return len(types) > 0
+}
+
+// mergeFieldCaps combines the field capabilities of two sets of indices as
+// if they had been asked for together. A field of one type is searchable or
+// aggregatable if it is in all of them; the indices it is not are listed.
+func mergeFieldCaps(a, b *backend.FieldCaps) *backend.FieldCaps {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &backend.FieldCaps{
+		Indices: mergeIndexNames(a.Indices, b.Indices),
+		Fields:  make(map[string]map[string]backend.FieldCapability, len(a.Fields)+len(b.Fields)),
+	}
+	for _, name := range fieldNames(a, b) {
+		types := map[string]backend.FieldCapability{}
+		for _, side := range []*backend.FieldCaps{a, b} {
+			for typ, c := range side.Fields[name] {
+				c.Indices = capIndices(c, side)
+				if c.MetadataField {
+					// Metadata fields are in every index.
+					c.Indices = nil
+				}
+				c.NonSearchableIndices = nonCapable(c.Searchable, c.NonSearchableIndices, c.Indices)
+				c.NonAggregatableIndices = nonCapable(c.Aggregatable, c.NonAggregatableIndices, c.Indices)
+				if prev, ok := types[typ]; ok {
+					c.Searchable = c.Searchable && prev.Searchable
+					c.Aggregatable = c.Aggregatable && prev.Aggregatable
+					c.Indices = mergeIndexNames(prev.Indices, c.Indices)
+					c.NonSearchableIndices = mergeIndexNames(prev.NonSearchableIndices, c.NonSearchableIndices)
+					c.NonAggregatableIndices = mergeIndexNames(prev.NonAggregatableIndices, c.NonAggregatableIndices)
+				}
+				types[typ] = c
+			}
+		}
+		for typ, c := range types {
+			// The indices lacking a capability are only listed if others
+			// have it.
+			if c.Searchable || sameIndexNames(c.NonSearchableIndices, c.Indices) {
+				c.NonSearchableIndices = nil
+			}
+			if c.Aggregatable || sameIndexNames(c.NonAggregatableIndices, c.Indices) {
+				c.NonAggregatableIndices = nil
+			}
+			if len(types) == 1 {
+				// Indices only tell the types of a field apart.
+				c.Indices = nil
+			}
+			types[typ] = c
+		}
+		merged.Fields[name] = types
+	}
+	return merged
+}
+
+// capIndices returns the indices of side in which a field has the type of c.
+func capIndices(c backend.FieldCapability, side *backend.FieldCaps) []string {
+	if len(c.Indices) > 0 {
+		return c.Indices
+	}
+	return side.Indices
+}
+
+// nonCapable returns the indices in which a field lacks a capability:
+// listed, or all of them if it has it in none.
+func nonCapable(capable bool, listed, all []string) []string {
+	if capable || len(listed) > 0 {
+		return listed
+	}
+	return all
+}
+
+func fieldNames(a, b *backend.FieldCaps) []string {
+	names := make([]string, 0, len(a.Fields)+len(b.Fields))
+	for name := range a.Fields {
+		names = append(names, name)
+	}
+	for name := range b.Fields {
+		if _, ok := a.Fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func mergeIndexNames(a, b []string) []string {
+	merged := slices.Clone(a)
+	for _, index := range b {
+		if !slices.Contains(merged, index) {
+			merged = append(merged, index)
+		}
+	}
+	slices.Sort(merged)
+	return merged
+}
+
+func sameIndexNames(a, b []string) bool {
+	return slices.Equal(mergeIndexNames(a, nil), mergeIndexNames(b, nil))
+}
+
+// httpStatus returns the status of a backend's *HTTPStatusError, or 0.
+func httpStatus(err error) int {
+	var httpErr *backend.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// writeBackendError passes the error response of a backend on to the
+// client, or answers 502 if there is none.
+func writeBackendError(w http.ResponseWriter, err error) {
+	var httpErr *backend.HTTPStatusError
+	if !errors.As(err, &httpErr) {
+		http.Error(w, `{"error":"opensearch request failed"}`, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.StatusCode)
+	w.Write([]byte(httpErr.Body))
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+func TestProxy_FieldCaps(t *testing.T) {
+	hotStatus := http.StatusOK
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_field_caps") {
+			osHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if hotStatus != http.StatusOK {
+			w.WriteHeader(hotStatus)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+			return
+		}
+		w.Write([]byte(`{"indices":["logs-2026.10.15"],"fields":{
+			"msg":{"text":{"type":"text","searchable":true,"aggregatable":false}},
+			"status":{"long":{"type":"long","searchable":true,"aggregatable":true}},
+			"_id":{"_id":{"type":"_id","metadata_field":true,"searchable":true,"aggregatable":false}}}}`))
+	})
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs-old"}},{"index_config":{"index_id":"metrics"}}]`))
+		case "/api/v1/_elastic/logs-old/_field_caps":
+			w.Write([]byte(`{"indices":["logs-old"],"fields":{
+				"msg":{"text":{"type":"text","searchable":true,"aggregatable":false}},
+				"status":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true}},
+				"user":{"keyword":{"type":"keyword","searchable":true,"aggregatable":false}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	fieldCaps := func(path, auth string) (*httptest.ResponseRecorder, *backend.FieldCaps) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		var caps backend.FieldCaps
+		json.Unmarshal(rec.Body.Bytes(), &caps)
+		return rec, &caps
+	}
+
+	rec, caps := fieldCaps("/logs-*/_field_caps?fields=*", validToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	want := map[string]map[string]backend.FieldCapability{
+		"msg": {"text": {Type: "text", Searchable: true}},
+		"status": {
+			"long":    {Type: "long", Searchable: true, Aggregatable: true, Indices: []string{"logs-2026.10.15"}},
+			"keyword": {Type: "keyword", Searchable: true, Aggregatable: true, Indices: []string{"logs-old"}},
+		},
+		"user": {"keyword": {Type: "keyword", Searchable: true}},
+		"_id":  {"_id": {Type: "_id", MetadataField: true, Searchable: true}},
+	}
+	if !reflect.DeepEqual(caps.Fields, want) || !reflect.DeepEqual(caps.Indices, []string{"logs-2026.10.15", "logs-old"}) {
+		t.Errorf("field caps = %s", rec.Body)
+	}
+
+	// The mock hot cluster ignores fields; Quickwit's are filtered.
+	if _, caps := fieldCaps("/logs-*/_field_caps?fields=u*", validToken); caps.Fields["user"] == nil || len(caps.Fields["status"]) != 1 {
+		t.Errorf("fields=u* = %+v, want only the cold user field", caps.Fields)
+	}
+
+	if rec, _ := fieldCaps("/logs-*/_field_caps?fields=*", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: %d, want 401", rec.Code)
+	}
+
+	// An index deleted from OpenSearch is answered from Quickwit.
+	hotStatus = http.StatusNotFound
+	if rec, caps := fieldCaps("/logs-old/_field_caps?fields=*", validToken); rec.Code != http.StatusOK || len(caps.Fields) != 3 {
+		t.Errorf("cold-only index: %d %s", rec.Code, rec.Body)
+	}
+	if rec, _ := fieldCaps("/nothing/_field_caps?fields=*", validToken); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown index: %d, want 404", rec.Code)
+	}
+}
+
+func TestProxy_DateSortFields(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	var hotCalls atomic.Int32
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logs/_field_caps" {
+			osHandler.ServeHTTP(w, r)
+			return
+		}
+		hotCalls.Add(1)
+		if fields := r.URL.Query().Get("fields"); fields != "created,batch,updated" {
+			t.Errorf("hot field caps of %q", fields)
+		}
+		w.Write([]byte(`{"indices":["logs"],"fields":{
+			"created":{"date":{"type":"date","searchable":true,"aggregatable":true}},
+			"batch":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true}},
+			"updated":{"date":{"type":"date","searchable":true,"aggregatable":true}}}}`))
+	})
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs"}}]`))
+		case "/api/v1/_elastic/logs/_field_caps":
+			w.Write([]byte(`{"indices":["logs"],"fields":{
+				"created":{"date":{"type":"date","searchable":true,"aggregatable":true}},
+				"updated":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	header := http.Header{"Authorization": {validToken}}
+	merge := MergeOptions{Sort: []SortField{{Field: "@timestamp"}, {Field: "created"}, {Field: "batch"}, {Field: "updated"}, {Field: "_score"}}}
+	var dates []bool
+	for _, f := range p.dateSortFields(context.Background(), []string{"logs"}, merge, header).Sort {
+		dates = append(dates, f.Date)
+	}
+	// updated is a keyword in Quickwit, so it is not known to be a date.
+	if want := []bool{true, true, false, false, false}; !reflect.DeepEqual(dates, want) {
+		t.Errorf("dates = %v, want %v", dates, want)
+	}
+	if merge.Sort[1].Date {
+		t.Error("dateSortFields changed the sort it was given")
+	}
+
+	// The types are kept for the next search of the same indices.
+	sorted := p.dateSortFields(context.Background(), []string{"logs"}, merge, header)
+	if n := hotCalls.Load(); n != 1 || !sorted.Sort[1].Date || sorted.Sort[3].Date {
+		t.Errorf("hot field caps requests = %d, sort = %v", n, sorted.Sort)
+	}
+}
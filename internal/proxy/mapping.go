@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// handleMapping answers GET /{index}/_mapping. OpenSearch answers for the
+// indices it has; indices it no longer has, because they were migrated
+// and deleted, get a mapping built from their Quickwit index instead of a
+// 404.
+func (p *Proxy) handleMapping(w http.ResponseWriter, r *http.Request, indices []string) {
+	ctx := r.Context()
+	failover := p.failover.active()
+	var hotErr error
+	if !failover {
+		var body []byte
+		body, hotErr = p.hotBackend.GetAs(ctx, r.URL.Path, r.URL.RawQuery, r.Header)
+		if hotErr == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+			return
+		}
+		// Patterns matching nothing are not an error for OpenSearch.
+		if httpStatus(hotErr) != http.StatusNotFound || hasWildcard(indices) || slices.ContainsFunc(indices, isRemoteIndex) {
+			writeBackendError(w, hotErr)
+			return
+		}
+	}
+
+	if err := p.authenticateViaOpenSearch(ctx, r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for cold mapping", "indices", strings.Join(indices, ","), "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+
+	// OpenSearch's mapping of an index wins over the one built from
+	// Quickwit: the 404 of a request for several indices may be for
+	// only some of them.
+	mappings := make(map[string]json.RawMessage, len(indices))
+	for _, index := range indices {
+		indexErr := hotErr
+		if !failover && len(indices) > 1 {
+			var body []byte
+			if body, indexErr = p.hotBackend.GetAs(ctx, "/"+index+"/_mapping", r.URL.RawQuery, r.Header); indexErr == nil {
+				var hot map[string]json.RawMessage
+				if err := json.Unmarshal(body, &hot); err != nil {
+					http.Error(w, `{"error":"failed to decode the opensearch mapping"}`, http.StatusBadGateway)
+					return
+				}
+				for name, m := range hot {
+					mappings[name] = m
+				}
+				continue
+			}
+			if httpStatus(indexErr) != http.StatusNotFound {
+				writeBackendError(w, indexErr)
+				return
+			}
+		}
+
+		m, err := p.coldMapping(ctx, index)
+		if err == nil {
+			mappings[index] = m
+			continue
+		}
+		if httpStatus(err) != http.StatusNotFound {
+			slog.Error("failed to read quickwit doc mapping", "index", index, "error", err)
+			http.Error(w, `{"error":"failed to read the quickwit doc mapping"}`, http.StatusBadGateway)
+			return
+		}
+		// In neither tier.
+		if failover {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+		writeBackendError(w, indexErr)
+		return
+	}
+	writeJSON(w, mappings)
+}
+
+// coldMapping builds the mapping of index, as returned by _mapping, from
+// the doc mapping of its Quickwit index. Indices in dynamic mode also get
+// the fields Quickwit reports for their data. _meta.quickwit_index tells
+// the mapping apart from one of OpenSearch.
+func (p *Proxy) coldMapping(ctx context.Context, index string) (json.RawMessage, error) {
+	target := p.Config().QuickwitIndexForIndex(index)
+	dm, err := p.coldBackend.DocMapping(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	props := esProperties(dm.FieldMappings)
+	if dm.Mode == "" || dm.Mode == "dynamic" {
+		caps, err := p.coldBackend.FieldCaps(ctx, target)
+		if err != nil {
+			slog.Warn("failed to read fields of quickwit index, mapping only has declared fields", "index", target, "error", err)
+		} else {
+			addFieldCaps(props, caps)
+		}
+	}
+	mappings := map[string]any{
+		"properties": props,
+		"_meta":      map[string]any{"quickwit_index": target},
+	}
+	switch dm.Mode {
+	case "strict":
+		mappings["dynamic"] = "strict"
+	case "lenient":
+		mappings["dynamic"] = "false"
+	}
+	return json.Marshal(map[string]any{"mappings": mappings})
+}
+
+// esProperties returns the mapping properties of Quickwit fields.
+func esProperties(fields []backend.FieldMapping) map[string]any {
+	props := make(map[string]any, len(fields))
+	for _, fm := range fields {
+		prop := map[string]any{}
+		if fm.Type == "object" {
+			prop["properties"] = esProperties(fm.FieldMappings)
+		} else {
+			prop["type"] = fm.ESType()
+			if fm.Indexed != nil && !*fm.Indexed {
+				prop["index"] = false
+			}
+		}
+		props[fm.Name] = prop
+	}
+	return props
+}
+
+// addFieldCaps adds the fields in caps that props lacks, nesting dotted
+// names in objects. A field of several types gets the first by name.
+func addFieldCaps(props map[string]any, caps *backend.FieldCaps) {
+	for name, types := range caps.Fields {
+		typs := make([]string, 0, len(types))
+		for typ, c := range types {
+			// Objects are implied by their fields.
+			if !c.MetadataField && typ != "object" {
+				typs = append(typs, typ)
+			}
+		}
+		if len(typs) == 0 || strings.HasPrefix(name, "_") {
+			continue
+		}
+		slices.Sort(typs)
+		addProperty(props, strings.Split(name, "."), typs[0])
+	}
+}
+
+func addProperty(props map[string]any, path []string, typ string) {
+	for _, name := range path[:len(path)-1] {
+		node, ok := props[name].(map[string]any)
+		if !ok {
+			node = map[string]any{}
+			props[name] = node
+		}
+		children, ok := node["properties"].(map[string]any)
+		if !ok {
+			if _, leaf := node["type"]; leaf && node["type"] != "object" {
+				return
+			}
+			delete(node, "type")
+			children = map[string]any{}
+			node["properties"] = children
+		}
+		props = children
+	}
+	if _, ok := props[path[len(path)-1]]; !ok {
+		props[path[len(path)-1]] = map[string]any{"type": typ}
+	}
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProxy_Mapping(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logs-new/_mapping":
+			w.Write([]byte(`{"logs-new":{"mappings":{"properties":{"msg":{"type":"text"}}}}}`))
+		case "/logs-both/_mapping":
+			w.Write([]byte(`{"logs-both":{"mappings":{"properties":{"msg":{"type":"keyword"}}}}}`))
+		case "/logs-old/_mapping", "/logs-old,logs-new/_mapping", "/logs-old,logs-both/_mapping", "/nothing/_mapping":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+		default:
+			osHandler.ServeHTTP(w, r)
+		}
+	})
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes/logs-both":
+			w.Write([]byte(`{"index_config":{"index_id":"logs-both","doc_mapping":{"mode":"strict","field_mappings":[{"name":"msg","type":"text"}]}}}`))
+		case "/api/v1/indexes/logs-old":
+			w.Write([]byte(`{"index_config":{"index_id":"logs-old","doc_mapping":{"mode":"dynamic","timestamp_field":"@timestamp","field_mappings":[
+				{"name":"@timestamp","type":"datetime","fast":true},
+				{"name":"http","type":"object","field_mappings":[{"name":"status","type":"u64","indexed":false}]}]}}}`))
+		case "/api/v1/_elastic/logs-old/_field_caps":
+			w.Write([]byte(`{"indices":["logs-old"],"fields":{
+				"@timestamp":{"date":{"type":"date","searchable":true,"aggregatable":true}},
+				"_id":{"_id":{"type":"_id","metadata_field":true,"searchable":true,"aggregatable":false}},
+				"http":{"object":{"type":"object","searchable":false,"aggregatable":false}},
+				"http.method":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true}},
+				"user":{"text":{"type":"text","searchable":true,"aggregatable":false}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	mapping := func(path, auth string) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		var got map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec, got
+	}
+
+	rec, got := mapping("/logs-old/_mapping", validToken)
+	want := map[string]any{"logs-old": map[string]any{"mappings": map[string]any{
+		"_meta": map[string]any{"quickwit_index": "logs-old"},
+		"properties": map[string]any{
+			"@timestamp": map[string]any{"type": "date"},
+			"http": map[string]any{"properties": map[string]any{
+				"status": map[string]any{"type": "unsigned_long", "index": false},
+				"method": map[string]any{"type": "keyword"},
+			}},
+			"user": map[string]any{"type": "text"},
+		},
+	}}}
+	if rec.Code != http.StatusOK || !reflect.DeepEqual(got, want) {
+		t.Errorf("cold-only mapping: %d %s", rec.Code, rec.Body)
+	}
+
+	rec, got = mapping("/logs-old,logs-new/_mapping", validToken)
+	if rec.Code != http.StatusOK || got["logs-old"] == nil || got["logs-new"] == nil {
+		t.Errorf("mixed mapping: %d %s", rec.Code, rec.Body)
+	}
+
+	// An index in both tiers keeps OpenSearch's mapping.
+	rec, got = mapping("/logs-old,logs-both/_mapping", validToken)
+	both, _ := json.Marshal(got["logs-both"])
+	if rec.Code != http.StatusOK || got["logs-old"] == nil || string(both) != `{"mappings":{"properties":{"msg":{"type":"keyword"}}}}` {
+		t.Errorf("mapping of an index in both tiers: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec, _ := mapping("/logs-new/_mapping", validToken); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"msg"`) {
+		t.Errorf("hot mapping: %d %s", rec.Code, rec.Body)
+	}
+	if rec, _ := mapping("/nothing/_mapping", validToken); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "index_not_found_exception") {
+		t.Errorf("unknown index: %d %s, want OpenSearch's 404", rec.Code, rec.Body)
+	}
+	if rec, _ := mapping("/logs-old/_mapping", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: %d, want 401", rec.Code)
+	}
+}
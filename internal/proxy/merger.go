@@ -1,8 +1,12 @@
 package proxy
 
 import (
+	"cmp"
 	"encoding/json"
+	"log/slog"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/leonunix/oqbridge/internal/backend"
 )
@@ -39,22 +43,37 @@ func MergeSearchResponses(hot, cold *backend.SearchResponse) *backend.SearchResp
 }
 
 type MergeOptions struct {
-	From     int
-	Size     int
-	ScoreAsc bool
-	Paginate bool
+	From      int
+	Size      int
+	ScoreAsc  bool
+	Sort      []SortField // Order of the search's sort; overrides the score order when set.
+	Paginate  bool
+	TypedKeys bool // Keep the type prefixes of aggregation names: the client asked for typed_keys.
+}
+
+// SortField is an entry of the sort of a search.
+type SortField struct {
+	Field        string // Document field, dotted for nested objects, or _score.
+	Desc         bool
+	MissingFirst bool // Hits without the field come first instead of last.
+	Date         bool // The field is a date: its strings are compared as instants.
 }
 
 // MergeSearchResponsesWithOptions merges and optionally paginates results.
-// It currently supports only score-based ordering (default) with optional asc/desc.
+// Hits are ordered by score, descending unless ScoreAsc, or by Sort.
 func MergeSearchResponsesWithOptions(hot, cold *backend.SearchResponse, opts MergeOptions) *backend.SearchResponse {
 	merged := MergeSearchResponses(hot, cold)
 	if merged == nil {
 		return nil
 	}
 
-	// Apply score order.
-	if opts.ScoreAsc {
+	merged.Aggregations = untypeAggregations(merged.Aggregations, opts.TypedKeys)
+
+	// Apply the requested order.
+	switch {
+	case len(opts.Sort) > 0:
+		sortHitsByFields(merged.Hits.Hits, opts.Sort)
+	case opts.ScoreAsc:
 		sortHitsByScoreAsc(merged.Hits.Hits)
 	}
 
@@ -78,8 +97,9 @@ func MergeSearchResponsesWithOptions(hot, cold *backend.SearchResponse, opts Mer
 			merged.Hits.Hits = merged.Hits.Hits[from:end]
 		}
 
-		// Recompute max_score for the returned page.
-		if len(merged.Hits.Hits) == 0 {
+		// Recompute max_score for the returned page. Hits sorted by a
+		// field have no score unless track_scores is set.
+		if len(merged.Hits.Hits) == 0 || (len(opts.Sort) > 0 && merged.Hits.MaxScore == nil) {
 			merged.Hits.MaxScore = nil
 		} else {
 			max := extractScore(merged.Hits.Hits[0])
@@ -132,6 +152,176 @@ func sortHitsByScoreAsc(hits []json.RawMessage) {
 	})
 }
 
+// sortHitsByFields orders hits by fields, like OpenSearch orders the hits
+// of one search. The value of a field is read from the hit's _source,
+// which is the same in every tier, and else from its sort values, which
+// the tiers may format differently, e.g. dates as epoch milliseconds or as
+// strings. Strings are only read as dates for fields marked Date. If a hit
+// cannot be read, the hits are left in the order the backends returned.
+func sortHitsByFields(hits []json.RawMessage, fields []SortField) {
+	type keyed struct {
+		hit  json.RawMessage
+		keys []sortValue
+	}
+	all := make([]keyed, len(hits))
+	for i, hit := range hits {
+		keys, err := sortKeys(hit, fields)
+		if err != nil {
+			slog.Warn("cannot read the sort values of a merged hit, keeping the backends' order", "error", err)
+			return
+		}
+		all[i] = keyed{hit: hit, keys: keys}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		for k, f := range fields {
+			if c := compareSortValues(all[i].keys[k], all[j].keys[k], f); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	for i := range all {
+		hits[i] = all[i].hit
+	}
+}
+
+// sortValue is the value of a hit for one sort field: a number, with dates
+// as epoch milliseconds, or a string. Neither is set if the hit has none.
+type sortValue struct {
+	num   float64
+	str   string
+	isNum bool
+	isStr bool
+}
+
+func sortKeys(hit json.RawMessage, fields []SortField) ([]sortValue, error) {
+	var h struct {
+		Score  *float64       `json:"_score"`
+		Source map[string]any `json:"_source"`
+		Sort   []any          `json:"sort"`
+	}
+	if err := json.Unmarshal(hit, &h); err != nil {
+		return nil, err
+	}
+	keys := make([]sortValue, len(fields))
+	for i, f := range fields {
+		var v any
+		switch {
+		case f.Field == "_score" && h.Score != nil:
+			v = *h.Score
+		case f.Field != "_score":
+			if sv, ok := sourceField(h.Source, f.Field); ok {
+				v = pickSortValue(sv, f)
+				break
+			}
+			fallthrough
+		default:
+			if len(h.Sort) == len(fields) {
+				v = h.Sort[i]
+			}
+		}
+		keys[i] = newSortValue(v, f.Date)
+	}
+	return keys, nil
+}
+
+// sourceField looks up field in a document, as a key of its own or as a
+// path through nested objects.
+func sourceField(source map[string]any, field string) (any, bool) {
+	if v, ok := source[field]; ok {
+		return v, true
+	}
+	var cur any = source
+	for _, part := range strings.Split(field, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// pickSortValue returns the value the field f with several values sorts
+// by: the smallest ascending and the largest descending, as in OpenSearch.
+func pickSortValue(v any, f SortField) any {
+	values, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	var picked any
+	var best sortValue
+	for _, value := range values {
+		sv := newSortValue(value, f.Date)
+		if !sv.isNum && !sv.isStr {
+			continue
+		}
+		if picked == nil || (compareSortValues(sv, best, SortField{}) < 0) != f.Desc {
+			picked, best = value, sv
+		}
+	}
+	return picked
+}
+
+// newSortValue returns the sort value of v. Strings of a date field are
+// read as dates if they are in a format the tiers return dates in; other
+// strings compare as they are, like keywords in OpenSearch.
+func newSortValue(v any, date bool) sortValue {
+	switch x := v.(type) {
+	case float64:
+		return sortValue{num: x, isNum: true}
+	case bool:
+		if x {
+			return sortValue{num: 1, isNum: true}
+		}
+		return sortValue{isNum: true}
+	case string:
+		if !date {
+			return sortValue{str: x, isStr: true}
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, x); err == nil {
+				return sortValue{num: float64(t.UnixNano()) / 1e6, isNum: true}
+			}
+		}
+		return sortValue{str: x, isStr: true}
+	}
+	return sortValue{}
+}
+
+// compareSortValues compares a and b in the order of f: negative if a
+// comes first. Hits without a value come last, or first with
+// MissingFirst, in either direction; numbers come before strings.
+func compareSortValues(a, b sortValue, f SortField) int {
+	aMissing, bMissing := !a.isNum && !a.isStr, !b.isNum && !b.isStr
+	switch {
+	case aMissing && bMissing:
+		return 0
+	case aMissing != bMissing:
+		if aMissing == f.MissingFirst {
+			return -1
+		}
+		return 1
+	case a.isNum != b.isNum:
+		if a.isNum {
+			return -1
+		}
+		return 1
+	}
+	var c int
+	if a.isNum {
+		c = cmp.Compare(a.num, b.num)
+	} else {
+		c = strings.Compare(a.str, b.str)
+	}
+	if f.Desc {
+		c = -c
+	}
+	return c
+}
+
 func extractScore(hit json.RawMessage) float64 {
 	var h struct {
 		Score *float64 `json:"_score"`
@@ -142,8 +332,11 @@ func extractScore(hit json.RawMessage) float64 {
 	return *h.Score
 }
 
-// mergeAggregations performs a shallow merge of aggregation results.
-// For conflicting keys, the hot backend's values take precedence.
+// mergeAggregations performs a shallow merge of aggregation results,
+// matched by name whether or not their keys are typed. For conflicting
+// names, the hot backend's values take precedence, except for the
+// mergeable metric aggregations of the same type, which are combined.
+// Metrics nested in bucket aggregations are not merged.
 func mergeAggregations(hot, cold json.RawMessage) json.RawMessage {
 	if len(hot) == 0 {
 		return cold
@@ -157,10 +350,30 @@ func mergeAggregations(hot, cold json.RawMessage) json.RawMessage {
 		return hot
 	}
 
-	// Merge cold into hot (hot wins on conflict).
+	hotKeys := make(map[string]string, len(hotMap))
+	for k := range hotMap {
+		_, name := splitTypedKey(k)
+		hotKeys[name] = k
+	}
+	var avgs []string
 	for k, v := range coldMap {
-		if _, exists := hotMap[k]; !exists {
+		typ, name := splitTypedKey(k)
+		switch hk, exists := hotKeys[name]; {
+		case !exists:
 			hotMap[k] = v
+		case hk != k || !mergeableMetrics[typ]:
+			// Hot wins.
+		case typ == "avg":
+			avgs = append(avgs, k)
+		default:
+			hotMap[k] = mergeMetric(typ, hotMap[k], v)
+		}
+	}
+	// Averages are taken from the stats merged next to them.
+	for _, k := range avgs {
+		_, name := splitTypedKey(k)
+		if stats, ok := hotMap["stats#"+avgStatsPrefix+name]; ok {
+			hotMap[k] = mergedAvg(hotMap[k], stats)
 		}
 	}
 
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/leonunix/oqbridge/internal/backend"
@@ -151,3 +152,109 @@ func TestMergeSearchResponsesWithOptions_ScoreAsc(t *testing.T) {
 		t.Fatalf("first score=%v want 1", extractScore(merged.Hits.Hits[0]))
 	}
 }
+
+func TestMergeSearchResponsesWithOptions_SortByTimestamp(t *testing.T) {
+	// OpenSearch and Quickwit format dates differently in sort values; the
+	// documents are the same in both.
+	hot := &backend.SearchResponse{
+		Hits: backend.HitsResult{
+			Total: backend.HitsTotal{Value: 2, Relation: "eq"},
+			Hits: []json.RawMessage{
+				json.RawMessage(`{"_id":"h1","_score":null,"_source":{"@timestamp":"2025-03-04T10:00:00Z"},"sort":[1741082400000]}`),
+				json.RawMessage(`{"_id":"h2","_score":null,"_source":{"@timestamp":"2025-03-02T10:00:00.5Z"},"sort":[1740909600500]}`),
+			},
+		},
+	}
+	cold := &backend.SearchResponse{
+		Hits: backend.HitsResult{
+			Total: backend.HitsTotal{Value: 3, Relation: "eq"},
+			Hits: []json.RawMessage{
+				json.RawMessage(`{"_id":"c1","_source":{"@timestamp":"2025-03-03T10:00:00Z"},"sort":[1740996000000000000]}`),
+				json.RawMessage(`{"_id":"c2","_source":{"@timestamp":1740909600000},"sort":[1740909600000000000]}`),
+				json.RawMessage(`{"_id":"c3","_source":{"msg":"no timestamp"}}`),
+			},
+		},
+	}
+
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Size: 4, Sort: []SortField{{Field: "@timestamp", Desc: true, Date: true}}, Paginate: true})
+	if got := hitIDs(merged.Hits.Hits); got != "h1,c1,h2,c2" {
+		t.Errorf("order = %s, want h1,c1,h2,c2", got)
+	}
+	if merged.Hits.MaxScore != nil {
+		t.Errorf("max_score = %v, want null", *merged.Hits.MaxScore)
+	}
+
+	merged = MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Sort: []SortField{{Field: "@timestamp", MissingFirst: true, Date: true}}})
+	if got := hitIDs(merged.Hits.Hits); got != "c3,c2,h2,c1,h1" {
+		t.Errorf("ascending order = %s, want c3,c2,h2,c1,h1", got)
+	}
+}
+
+func TestMergeSearchResponsesWithOptions_SortByDateLikeKeyword(t *testing.T) {
+	// Keywords that look like dates compare as strings, as in OpenSearch.
+	hot := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"a","_source":{"batch":"2024-01-02"}}`),
+		json.RawMessage(`{"_id":"b","_source":{"batch":"2024-01-02T10:00:00+05:00"}}`),
+	}}}
+	cold := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"c","_source":{"batch":"2023-12-31-a"}}`),
+		json.RawMessage(`{"_id":"d","_source":{"batch":"2024-01-02T06:00:00Z"}}`),
+	}}}
+
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Sort: []SortField{{Field: "batch"}}})
+	if got := hitIDs(merged.Hits.Hits); got != "c,a,d,b" {
+		t.Errorf("order = %s, want c,a,d,b", got)
+	}
+}
+
+func TestMergeSearchResponsesWithOptions_SortByFields(t *testing.T) {
+	hot := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"a","_source":{"service":{"name":"api"},"latency":[30,5]}}`),
+		json.RawMessage(`{"_id":"b","_source":{"service":{"name":"web"},"latency":10}}`),
+	}}}
+	cold := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"c","_source":{"service.name":"api","latency":20}}`),
+		// Without _source, the sort values are used.
+		json.RawMessage(`{"_id":"d","sort":["web",1]}`),
+	}}}
+
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Sort: []SortField{{Field: "service.name"}, {Field: "latency", Desc: true}}})
+	// a sorts by its largest latency, 30, descending.
+	if got := hitIDs(merged.Hits.Hits); got != "a,c,b,d" {
+		t.Errorf("order = %s, want a,c,b,d", got)
+	}
+	merged = MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Sort: []SortField{{Field: "latency"}}})
+	// a sorts by its smallest latency, 5, ascending; d has no latency in
+	// its sort values for this sort.
+	if got := hitIDs(merged.Hits.Hits); got != "a,b,c,d" {
+		t.Errorf("ascending order = %s, want a,b,c,d", got)
+	}
+}
+
+func TestMergeSearchResponsesWithOptions_SortByFields_UnreadableHit(t *testing.T) {
+	hot := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"a","_source":{"n":2}}`),
+	}}}
+	cold := &backend.SearchResponse{Hits: backend.HitsResult{Hits: []json.RawMessage{
+		json.RawMessage(`{"_id":"b","_source":{"n":1}}`),
+		json.RawMessage(`{"_id":"c","_source":"n"}`),
+	}}}
+
+	// The hits are not sorted on zero values but kept in merged order.
+	merged := MergeSearchResponsesWithOptions(hot, cold, MergeOptions{Sort: []SortField{{Field: "n"}}})
+	if got := hitIDs(merged.Hits.Hits); got != "a,b,c" {
+		t.Errorf("order = %s, want a,b,c", got)
+	}
+}
+
+func hitIDs(hits []json.RawMessage) string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		var h struct {
+			ID string `json:"_id"`
+		}
+		json.Unmarshal(hit, &h)
+		ids[i] = h.ID
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// mirrorDoc is a document OpenSearch accepted that waits to be ingested
+// into Quickwit.
+type mirrorDoc struct {
+	index string // OpenSearch index the document was written to
+	doc   json.RawMessage
+}
+
+// mirror ingests the documents written through the proxy to indices listed
+// in dual_write.indices into Quickwit as well. Documents are queued and sent
+// in batches in the background, so the write path never waits for
+// Quickwit; when the queue is full or a batch keeps failing, documents are
+// dropped and logged rather than blocking OpenSearch writes.
+type mirror struct {
+	writer *coldWriter
+	config func() *config.Config
+	queue  chan mirrorDoc
+	full   atomic.Int64 // documents dropped because the queue was full, not yet logged
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newMirror(writer *coldWriter, cfg func() *config.Config, bufferDocs int) *mirror {
+	return &mirror{
+		writer: writer,
+		config: cfg,
+		queue:  make(chan mirrorDoc, bufferDocs),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// enqueue queues d for Quickwit, applying dual_write.drop_policy when the
+// queue is full.
+func (m *mirror) enqueue(d mirrorDoc) {
+	select {
+	case m.queue <- d:
+		return
+	default:
+	}
+	if m.config().DualWrite.DropPolicy == "oldest" {
+		select {
+		case <-m.queue:
+		default:
+		}
+		select {
+		case m.queue <- d:
+		default:
+		}
+	}
+	m.full.Add(1)
+}
+
+// run sends queued documents to Quickwit until close is called, then sends
+// what is left in the queue.
+func (m *mirror) run() {
+	defer close(m.done)
+	pending := make(map[string][]json.RawMessage)
+	count := 0
+	flush := func() {
+		if n := m.full.Swap(0); n > 0 {
+			slog.Warn("dual-write queue full, documents not mirrored to quickwit", "dropped", n, "policy", m.config().DualWrite.DropPolicy)
+		}
+		for index, docs := range pending {
+			m.send(index, docs)
+		}
+		clear(pending)
+		count = 0
+	}
+
+	timer := time.NewTimer(m.config().DualWrite.FlushInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case d := <-m.queue:
+			pending[d.index] = append(pending[d.index], d.doc)
+			count++
+			if count >= m.config().DualWrite.BatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(m.config().DualWrite.FlushInterval)
+		case <-m.stop:
+			for len(m.queue) > 0 {
+				d := <-m.queue
+				pending[d.index] = append(pending[d.index], d.doc)
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// close stops accepting work for run and waits, at most until ctx is done,
+// for the queued documents to be sent.
+func (m *mirror) close(ctx context.Context) error {
+	close(m.stop)
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send ingests the documents written to the OpenSearch index into its
+// Quickwit index, edited by the transforms of its migration rule, retrying
+// with dual_write.retry_backoff. Documents that cannot be sent are dropped.
+func (m *mirror) send(index string, docs []json.RawMessage) {
+	cfg := m.config()
+	target, err := m.writer.transform(cfg, index, docs)
+	if err != nil {
+		slog.Warn("dual-write transform failed, documents not mirrored to quickwit", "index", index, "docs", len(docs), "error", err)
+		return
+	}
+
+	backoff := cfg.DualWrite.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if err = m.writer.ingest(context.Background(), cfg, index, target, docs); err == nil {
+			return
+		}
+		if attempt >= cfg.DualWrite.MaxRetries {
+			break
+		}
+		slog.Debug("dual-write ingest failed, retrying", "index", target, "attempt", attempt+1, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	slog.Warn("dual-write ingest failed, documents not mirrored to quickwit", "index", target, "docs", len(docs), "error", err)
+}
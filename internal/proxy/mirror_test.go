@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func dualWriteConfig(osURL, qwURL string) *config.Config {
+	return &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: osURL},
+		Quickwit:   config.QuickwitConfig{URL: qwURL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+		DualWrite: config.DualWriteConfig{
+			Enabled:       true,
+			Indices:       []string{"logs-*"},
+			BufferDocs:    100,
+			BatchSize:     100,
+			FlushInterval: time.Hour,
+			DropPolicy:    "newest",
+		},
+	}
+}
+
+func TestProxy_DualWrite(t *testing.T) {
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/_bulk":
+			w.Write([]byte(`{"errors":true,"items":[
+				{"index":{"_index":"logs-2026.03.01","status":201}},
+				{"index":{"_index":"logs-2026.03.01","status":200}},
+				{"create":{"_index":"metrics-2026.03.01","status":201}},
+				{"delete":{"_index":"logs-2026.03.01","status":200}},
+				{"create":{"_index":"logs-2026.03.01","status":409}},
+				{"create":{"_index":"logs-2026.03.02","status":201}}]}`))
+		case "/logs-write/_doc":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"_index":"logs-2026.03.02","result":"created"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer osSrv.Close()
+
+	var mu sync.Mutex
+	ingested := make(map[string][]string)
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if index, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/ingest"); ok {
+			sc := bufio.NewScanner(r.Body)
+			mu.Lock()
+			for sc.Scan() {
+				ingested[index] = append(ingested[index], sc.Text())
+			}
+			mu.Unlock()
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer qwSrv.Close()
+
+	p, err := New(dualWriteConfig(osSrv.URL, qwSrv.URL), backend.NewOpenSearch(osSrv.URL, "", "", nil), backend.NewQuickwit(qwSrv.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	bulk := `{"index":{"_index":"logs-2026.03.01"}}
+{"msg":"created"}
+{"index":{"_index":"logs-2026.03.01","_id":"1"}}
+{"msg":"updated"}
+{"create":{"_index":"metrics-2026.03.01"}}
+{"msg":"not mirrored"}
+{"delete":{"_index":"logs-2026.03.01","_id":"2"}}
+{"create":{"_index":"logs-2026.03.01","_id":"3"}}
+{"msg":"conflict"}
+{"create":{"_index":"logs-2026.03.02"}}
+{"msg":"created too"}
+`
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(bulk)))
+	if !strings.Contains(w.Body.String(), `"items"`) {
+		t.Fatalf("bulk response not passed through: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/logs-write/_doc", strings.NewReader(`{"msg":"single"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("_doc status = %d, want 201", w.Code)
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if got := ingested["logs-2026.03.01"]; !slices.Equal(got, []string{`{"msg":"created"}`}) {
+		t.Errorf("logs-2026.03.01 ingested %v", got)
+	}
+	if got := ingested["logs-2026.03.02"]; !slices.Equal(got, []string{`{"msg":"created too"}`, `{"msg":"single"}`}) {
+		t.Errorf("logs-2026.03.02 ingested %v", got)
+	}
+	if _, ok := ingested["metrics-2026.03.01"]; ok {
+		t.Error("metrics-2026.03.01 is not in dual_write.indices but was mirrored")
+	}
+}
+
+func TestProxy_DualWriteRoutesColdOnly(t *testing.T) {
+	cfg := dualWriteConfig("http://os:9200", "http://qw:7280")
+	p, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	from := time.Now().UTC().AddDate(0, 0, -60).Format(time.RFC3339)
+	body := []byte(`{"query":{"range":{"@timestamp":{"gte":"` + from + `"}}}}`)
+	if got := p.routeForIndices(body, []string{"logs-*"}); got != RouteColdOnly {
+		t.Errorf("dual-written index: route = %v, want cold only", got)
+	}
+	if got := p.routeForIndices(body, []string{"metrics-*"}); got != RouteBoth {
+		t.Errorf("other index: route = %v, want both", got)
+	}
+}
+
+func TestMirror_DropPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		want   string
+	}{
+		{"newest", "a"},
+		{"oldest", "b"},
+	} {
+		cfg := &config.Config{DualWrite: config.DualWriteConfig{DropPolicy: tt.policy}}
+		m := newMirror(nil, func() *config.Config { return cfg }, 1)
+		m.enqueue(mirrorDoc{index: "logs", doc: []byte("a")})
+		m.enqueue(mirrorDoc{index: "logs", doc: []byte("b")})
+		if got := string((<-m.queue).doc); got != tt.want || m.full.Load() != 1 {
+			t.Errorf("%s: queued %q with %d dropped, want %q with 1", tt.policy, got, m.full.Load(), tt.want)
+		}
+	}
+}
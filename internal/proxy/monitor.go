@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// monitorWebhookTimeout bounds a webhook delivery of a monitor.
+const monitorWebhookTimeout = 10 * time.Second
+
+// monitorEvent is the JSON body posted to the webhook of a monitor.
+type monitorEvent struct {
+	Monitor   string          `json:"monitor"`
+	Triggered bool            `json:"triggered"`
+	Value     *float64        `json:"value,omitempty"`
+	Condition string          `json:"condition"`
+	Indices   []string        `json:"indices"`
+	Time      time.Time       `json:"time"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// scheduleMonitors adds the server.monitors of cfg to p.jobs.
+func (p *Proxy) scheduleMonitors(cfg *config.Config) error {
+	for _, m := range cfg.Server.Monitors {
+		if _, err := p.jobs.AddFunc(m.Schedule, func() { p.runMonitor(context.Background(), m, time.Now()) }); err != nil {
+			return fmt.Errorf("server.monitors[%s]: invalid schedule %q: %w", m.Name, m.Schedule, err)
+		}
+	}
+	return nil
+}
+
+// runMonitor searches the indices of m through the proxy, so that the
+// search reaches every tier its time range covers, and posts the result
+// to the webhook if the condition holds, m.Always is set or the search
+// failed.
+func (p *Proxy) runMonitor(ctx context.Context, m config.MonitorConfig, now time.Time) {
+	event := monitorEvent{Monitor: m.Name, Condition: m.Condition, Indices: m.Indices, Time: now.UTC()}
+	resp, value, err := p.monitorSearch(ctx, m)
+	event.Response = resp
+	switch {
+	case err != nil:
+		event.Error = err.Error()
+		slog.Warn("monitor search failed", "monitor", m.Name, "error", err)
+	default:
+		event.Value = &value
+		event.Triggered = m.Triggers(value)
+		slog.Debug("monitor ran", "monitor", m.Name, "value", value, "triggered", event.Triggered)
+		if !event.Triggered && !m.Always {
+			return
+		}
+	}
+	if err := postMonitorEvent(ctx, m.WebhookURL, &event); err != nil {
+		slog.Error("monitor webhook failed", "monitor", m.Name, "error", err)
+	}
+}
+
+// monitorSearch runs the query of m with the service account and returns
+// the search response and the number at m.Value in it.
+func (p *Proxy) monitorSearch(ctx context.Context, m config.MonitorConfig) (json.RawMessage, float64, error) {
+	query := m.Query
+	if query == nil {
+		query = map[string]any{}
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("encoding query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/"+strings.Join(m.Indices, ",")+"/_search", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.hotBackend.Credentials().Apply(req)
+
+	rec := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	p.ServeHTTP(rec, req)
+	resp := json.RawMessage(rec.body.Bytes())
+	if !json.Valid(resp) {
+		resp = nil
+	}
+	if rec.status/100 != 2 {
+		return resp, 0, fmt.Errorf("search returned status %d", rec.status)
+	}
+	var decoded any
+	if err := json.Unmarshal(rec.body.Bytes(), &decoded); err != nil {
+		return resp, 0, fmt.Errorf("decoding search response: %w", err)
+	}
+	value, ok := lookupNumber(decoded, m.Value)
+	if !ok {
+		return resp, 0, fmt.Errorf("no number at %s in the search response", m.Value)
+	}
+	return resp, value, nil
+}
+
+// lookupNumber returns the number at the dotted path in v, e.g.
+// "aggregations.errors.doc_count".
+func lookupNumber(v any, path string) (float64, bool) {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return 0, false
+		}
+		v = obj[key]
+	}
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func postMonitorEvent(ctx context.Context, url string, event *monitorEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, monitorWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_Monitors(t *testing.T) {
+	var auth string
+	hot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/_search") {
+			auth = r.Header.Get("Authorization")
+		}
+		w.Write([]byte(`{"hits":{"total":{"value":2,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer hot.Close()
+	cold := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hits":{"total":{"value":3,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer cold.Close()
+
+	var mu sync.Mutex
+	var events []monitorEvent
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e monitorEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	yaml := fmt.Sprintf(`
+opensearch:
+  url: %[1]q
+  username: svc
+  password: secret
+quickwit:
+  url: %[2]q
+retention:
+  days: 30
+server:
+  monitors:
+    - name: both-tiers
+      schedule: "@hourly"
+      indices: ["logs"]
+      query: {"query": {"range": {"@timestamp": {"gte": "now-90d"}}}}
+      condition: "> 4"
+      webhook_url: %[3]q
+    - name: below
+      schedule: "@hourly"
+      indices: ["logs"]
+      query: {"query": {"range": {"@timestamp": {"gte": "now-90d"}}}}
+      condition: "> 10"
+      webhook_url: %[3]q
+    - name: missing-value
+      schedule: "@hourly"
+      indices: ["logs"]
+      value: "aggregations.users.value"
+      webhook_url: %[3]q
+`, hot.URL, cold.URL, hook.URL)
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	p, err := New(cfg, backend.NewOpenSearch(hot.URL, "svc", "secret", nil), backend.NewQuickwit(cold.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	now := time.Now()
+	for _, m := range cfg.Server.Monitors {
+		p.runMonitor(context.Background(), m, now)
+	}
+
+	if !strings.HasPrefix(auth, "Basic ") {
+		t.Errorf("monitor searched OpenSearch with Authorization %q, want the service account", auth)
+	}
+	if len(events) != 2 {
+		t.Fatalf("webhook got %d events, want 2: %+v", len(events), events)
+	}
+	if e := events[0]; e.Monitor != "both-tiers" || !e.Triggered || e.Value == nil || *e.Value != 5 || len(e.Response) == 0 {
+		t.Errorf("both-tiers event = %+v", e)
+	}
+	if e := events[1]; e.Monitor != "missing-value" || e.Triggered || e.Value != nil || !strings.Contains(e.Error, "aggregations.users.value") {
+		t.Errorf("missing-value event = %+v", e)
+	}
+}
+
+func TestProxy_MonitorsInvalidSchedule(t *testing.T) {
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: "http://os:9200"},
+		Server: config.ServerConfig{Monitors: []config.MonitorConfig{
+			{Name: "bad", Schedule: "every hour", Indices: []string{"logs"}, WebhookURL: "http://hooks"},
+		}},
+	}
+	if _, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), nil, nil); err == nil || !strings.Contains(err.Error(), "server.monitors[bad]: invalid schedule") {
+		t.Errorf("New() error = %v", err)
+	}
+}
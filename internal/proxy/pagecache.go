@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// pageCache keeps the merged, sorted hits of searches paged with from/size
+// across tiers (server.page_cache), so the next pages of a search are cut
+// from the same hit list instead of querying every backend again, which is
+// both cheaper and consistent between pages.
+type pageCache struct {
+	cfg config.PageCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*pageEntry
+}
+
+// pageEntry is the merged response of a search, with at most max_hits hits
+// in merge order.
+type pageEntry struct {
+	resp    *backend.SearchResponse
+	fetched time.Time
+}
+
+func newPageCache(cfg config.PageCacheConfig) *pageCache {
+	return &pageCache{cfg: cfg, entries: make(map[string]*pageEntry)}
+}
+
+// get returns the response stored under key, or nil if there is none or it
+// expired.
+func (c *pageCache) get(key string) *backend.SearchResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(e.fetched) >= c.cfg.TTL {
+		delete(c.entries, key)
+		return nil
+	}
+	return e.resp
+}
+
+// put stores resp under key, dropping expired entries and then the oldest
+// ones to stay within max_entries.
+func (c *pageCache) put(key string, resp *backend.SearchResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.Sub(e.fetched) >= c.cfg.TTL {
+			delete(c.entries, k)
+		}
+	}
+	for len(c.entries) >= c.cfg.MaxEntries {
+		var oldest string
+		for k, e := range c.entries {
+			if oldest == "" || e.fetched.Before(c.entries[oldest].fetched) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = &pageEntry{resp: resp, fetched: now}
+}
+
+// key identifies a search independently of the page requested: the path
+// and query string, the body without from and size, and the identity
+// headers of the client, so hits are never served to another client.
+// It returns false if the body is not a JSON object.
+func (c *pageCache) key(r *http.Request, body []byte) (string, bool) {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil || m == nil {
+		return "", false
+	}
+	delete(m, "from")
+	delete(m, "size")
+	query, err := json.Marshal(m)
+	if err != nil {
+		return "", false
+	}
+
+	h := sha256.New()
+	for _, part := range []string{r.URL.Path, r.URL.RawQuery, string(query)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	for _, name := range c.cfg.IdentityHeaders {
+		for _, v := range r.Header.Values(name) {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// handleCachedPage answers a search for a page after the first one that
+// reaches the tiers in span from the page cache, fetching and storing the
+// first max_hits merged hits of the search on a miss. It returns false,
+// without writing a response, if the search is not cached: the first page,
+// pages past max_hits and searches that cannot be merged across tiers take
+// the usual path.
+func (p *Proxy) handleCachedPage(w http.ResponseWriter, r *http.Request, indices []string, body []byte, span []bool) bool {
+	fanout, err := planFanout(body)
+	if err != nil || !fanout.Merge.Paginate || fanout.Merge.From == 0 || fanout.Merge.From+fanout.Merge.Size > p.pages.cfg.MaxHits {
+		return false
+	}
+	if span[len(span)-1] && checkCapabilities(p.coldBackend.Capabilities(), body) != nil {
+		return false
+	}
+	key, ok := p.pages.key(r, body)
+	if !ok {
+		return false
+	}
+
+	// Cached hits are served without asking the hot cluster, so the
+	// client's credentials are checked on every page.
+	if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return true
+	}
+
+	resp := p.pages.get(key)
+	if resp == nil {
+		full, err := planFanout(withPage(body, 0, p.pages.cfg.MaxHits))
+		if err != nil {
+			return false
+		}
+		full.Merge.TypedKeys = wantsTypedKeys(r)
+		resp, err = p.searchTiers(r.Context(), indices, r.URL.Path, r.URL.RawQuery, full, span, r.Header)
+		if err != nil {
+			if isAuthError(err) {
+				http.Error(w, `{"error":"authentication failed"}`, statusFromAuthError(err))
+				return true
+			}
+			http.Error(w, `{"error":"search failed"}`, http.StatusBadGateway)
+			return true
+		}
+		p.pages.put(key, resp)
+	}
+
+	page := *resp
+	page.Hits.Hits = slices.Clone(resp.Hits.Hits)
+	// The aggregations of resp are final already: keep them as they are.
+	writeJSON(w, MergeSearchResponsesWithOptions(&page, nil, MergeOptions{From: fanout.Merge.From, Size: fanout.Merge.Size, Paginate: true, TypedKeys: true}))
+	return true
+}
+
+// withPage returns body with its from and size replaced, or body unchanged
+// if it is not a JSON object.
+func withPage(body []byte, from, size int) []byte {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil || m == nil {
+		return body
+	}
+	m["from"] = from
+	m["size"] = size
+	out, err := json.Marshal(m)
+	if err != nil {
+		return body
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_PageCache(t *testing.T) {
+	var hotSearches, coldSearches atomic.Int32
+	hits := func(scores ...float64) backend.SearchResponse {
+		resp := backend.SearchResponse{Hits: backend.HitsResult{Total: backend.HitsTotal{Value: len(scores), Relation: "eq"}}}
+		for _, s := range scores {
+			resp.Hits.Hits = append(resp.Hits.Hits, json.RawMessage(fmt.Sprintf(`{"_score":%v}`, s)))
+		}
+		return resp
+	}
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/_search") {
+			hotSearches.Add(1)
+			json.NewEncoder(w).Encode(hits(6, 4, 2))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coldSearches.Add(1)
+		json.NewEncoder(w).Encode(hits(5, 3, 1))
+	}))
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Quickwit:   config.QuickwitConfig{URL: qw.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.PageCache = config.PageCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10, MaxHits: 100, IdentityHeaders: []string{"Authorization"}}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	query := strings.TrimPrefix(buildBothQuery(), "{")
+	page := func(from int, auth string) (int, []float64) {
+		req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(fmt.Sprintf(`{"from":%d,"size":2,%s`, from, query)))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		var resp backend.SearchResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		var scores []float64
+		for _, h := range resp.Hits.Hits {
+			scores = append(scores, extractScore(h))
+		}
+		return w.Code, scores
+	}
+
+	if _, got := page(2, validToken); fmt.Sprint(got) != "[4 3]" {
+		t.Errorf("page 2 = %v, want [4 3]", got)
+	}
+	if _, got := page(4, validToken); fmt.Sprint(got) != "[2 1]" {
+		t.Errorf("page 3 = %v, want [2 1]", got)
+	}
+	if hotSearches.Load() != 1 || coldSearches.Load() != 1 {
+		t.Errorf("backends searched %d/%d times, want once each", hotSearches.Load(), coldSearches.Load())
+	}
+	if code, _ := page(2, "Basic b3RoZXI6cGFzcw=="); code != http.StatusUnauthorized {
+		t.Errorf("another client: status %d, want 401", code)
+	}
+	// The first page is not cached.
+	if _, got := page(0, validToken); fmt.Sprint(got) != "[6 5]" || hotSearches.Load() != 2 {
+		t.Errorf("page 1 = %v after %d hot searches", got, hotSearches.Load())
+	}
+}
+
+func TestPageCache_Bounds(t *testing.T) {
+	c := newPageCache(config.PageCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	for _, key := range []string{"a", "b", "c"} {
+		c.put(key, &backend.SearchResponse{})
+	}
+	if c.get("a") != nil || c.get("b") == nil || c.get("c") == nil {
+		t.Error("the oldest entry should be dropped beyond max_entries")
+	}
+
+	c.entries["b"].fetched = time.Now().Add(-2 * time.Minute)
+	if c.get("b") != nil {
+		t.Error("an expired entry should not be served")
+	}
+}
+
+func TestPageCache_KeyIgnoresPage(t *testing.T) {
+	c := newPageCache(config.PageCacheConfig{IdentityHeaders: []string{"Authorization"}})
+	req := func(body, auth string) string {
+		r := httptest.NewRequest(http.MethodPost, "/logs/_search", nil)
+		r.Header.Set("Authorization", auth)
+		key, _ := c.key(r, []byte(body))
+		return key
+	}
+	if req(`{"from":10,"size":5,"query":{"match_all":{}}}`, "a") != req(`{"query":{"match_all":{}},"from":20}`, "a") {
+		t.Error("pages of the same search should share a key")
+	}
+	if req(`{"query":{"match_all":{}}}`, "a") == req(`{"query":{"match_all":{}}}`, "b") {
+		t.Error("different clients should not share a key")
+	}
+}
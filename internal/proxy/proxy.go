@@ -12,12 +12,19 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/capture"
 	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
 	"github.com/leonunix/oqbridge/internal/util"
+
+	"github.com/robfig/cron/v3"
 )
 
 type endpointKind int
@@ -26,56 +33,270 @@ const (
 	endpointNone endpointKind = iota
 	endpointSearch
 	endpointMSearch
+	endpointCount
+	endpointFieldCaps
+	endpointMapping
 )
 
 // Proxy is the core HTTP handler that routes requests between OpenSearch and Quickwit.
 type Proxy struct {
-	cfg          *config.Config
-	router       *Router
+	live         atomic.Pointer[liveConfig]
 	hotBackend   *backend.OpenSearch
-	coldBackend  *backend.Quickwit
+	coldBackend  ColdBackend
 	reverseProxy *httputil.ReverseProxy
+	aliases      *aliasCache
+	sortTypes    *sortTypeCache         // whether sort fields are dates, by index pattern
+	coldPageSize int                    // most hits requested from Quickwit in one search
+	writer       *coldWriter            // writes documents sent through the proxy to Quickwit
+	mirror       *mirror                // dual_write; nil if disabled
+	tiers        []tier                 // entries of tiers, youngest data first
+	pages        *pageCache             // server.page_cache; nil if disabled
+	coldCache    *coldCache             // server.cold_cache; nil if disabled
+	rehydrate    *rehydrator            // server.rehydrate; nil if disabled
+	tenancy      *tenancy               // server.tenancy; nil if disabled
+	quota        *coldQuota             // server.cold_quota; nil if disabled
+	failover     *failover              // server.failover; nil if disabled
+	coldSlots    chan struct{}          // server.cold_fanout.max_global; nil if unlimited
+	capture      *capture.Writer        // server.capture; nil if disabled
+	remotes      map[string]ColdBackend // Quickwit backends of remote_clusters with their own quickwit_cluster
+	routes       routeStats             // searches by route, for the dashboard
+	watermarks   WatermarkReader        // migration state for _oqbridge/stats; nil if unknown
+	runs         RunReader              // recorded migration runs for _oqbridge/timeline; nil if unknown
+	retention    RetentionScheduler     // retention.runtime for _oqbridge/retention; nil if disabled
+	jobs         *cron.Cron             // server.monitors and server.warmups; nil if none
+}
+
+// ColdBackend is the Quickwit side of the proxy: a single cluster
+// (*backend.Quickwit) or several behind a *backend.QuickwitRouter.
+type ColdBackend interface {
+	backend.Backend
+	backend.MultiSearcher
+	ListIndices(ctx context.Context) ([]string, error)
+	DescribeIndex(ctx context.Context, index string) (*backend.IndexStats, error)
+	Count(ctx context.Context, index string, body []byte) (int64, error)
+	FieldCaps(ctx context.Context, index string) (*backend.FieldCaps, error)
+	DocMapping(ctx context.Context, index string) (*backend.DocMapping, error)
+	IndexExists(ctx context.Context, index string) (bool, error)
+	CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error
+}
+
+// liveConfig is the configuration the proxy routes by, replaced as a whole
+// by SetConfig.
+type liveConfig struct {
+	cfg    *config.Config
+	router Router
 }
 
 // New creates a new Proxy instance.
 // If transport is non-nil it is used by the reverse proxy (e.g. for custom TLS).
-func New(cfg *config.Config, hot *backend.OpenSearch, cold *backend.Quickwit, transport http.RoundTripper) (*Proxy, error) {
+// The reverse proxy is tuned by cfg.Server.ReverseProxy.
+func New(cfg *config.Config, hot *backend.OpenSearch, cold ColdBackend, transport http.RoundTripper, opts ...Option) (*Proxy, error) {
 	osURL, err := url.Parse(cfg.OpenSearch.URL)
 	if err != nil {
 		return nil, err
 	}
 
-	rp := httputil.NewSingleHostReverseProxy(osURL)
-	if transport != nil {
-		rp.Transport = transport
-	}
-	originalDirector := rp.Director
-	rp.Director = func(req *http.Request) {
-		originalDirector(req)
-		// Do NOT override the client's auth header — let OpenSearch validate
-		// the original user credentials. The config's username/password is only
-		// used by the backend clients for internal operations.
-	}
+	rp := newReverseProxy(cfg.Server.ReverseProxy, osURL, transport)
 
-	return &Proxy{
-		cfg:          cfg,
-		router:       NewRouter(cfg.Retention.Days),
+	p := &Proxy{
 		hotBackend:   hot,
 		coldBackend:  cold,
 		reverseProxy: rp,
-	}, nil
+		aliases:      newAliasCache(hot, aliasCacheTTL),
+		sortTypes:    newSortTypeCache(sortTypeTTL),
+		coldPageSize: quickwitMaxHits,
+		writer:       &coldWriter{cold: cold},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if len(p.tiers) != len(cfg.Tiers) {
+		return nil, fmt.Errorf("%d tiers configured but %d tier backends given", len(cfg.Tiers), len(p.tiers))
+	}
+	router, err := newRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.live.Store(&liveConfig{cfg: cfg, router: router})
+	if cfg.Server.PageCache.Enabled {
+		p.pages = newPageCache(cfg.Server.PageCache)
+	}
+	if cfg.Server.ColdCache.Enabled {
+		p.coldCache = newColdCache(cfg.Server.ColdCache)
+	}
+	if cfg.Server.Rehydrate.Enabled {
+		reader, ok := cold.(migration.RangeReader)
+		if !ok {
+			return nil, fmt.Errorf("server.rehydrate: the quickwit backend cannot read time ranges")
+		}
+		p.rehydrate = newRehydrator(cfg.Server.Rehydrate, func() *config.Config { return p.live.Load().cfg }, hot, reader)
+	}
+	if cfg.Server.Failover.Enabled {
+		if p.failover, err = newFailover(cfg.Server.Failover, hot); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Server.Tenancy.Enabled {
+		p.tenancy = newTenancy(cfg.Server.Tenancy, p.authInfo)
+	}
+	if n := cfg.Server.ColdFanout.MaxGlobal; n > 0 {
+		p.coldSlots = make(chan struct{}, n)
+	}
+	if cfg.Server.ColdQuota.Enabled {
+		p.quota = newColdQuota(cfg.Server.ColdQuota, hot, p.authInfo, func() *config.Config { return p.live.Load().cfg })
+	}
+	if cfg.Server.Capture.Enabled {
+		if p.capture, err = capture.Open(cfg.Server.Capture); err != nil {
+			return nil, fmt.Errorf("server.capture: %w", err)
+		}
+	}
+	if cfg.DualWrite.Enabled {
+		p.mirror = newMirror(p.writer, func() *config.Config { return p.live.Load().cfg }, cfg.DualWrite.BufferDocs)
+		go p.mirror.run()
+	}
+	if len(cfg.Server.Monitors) > 0 || len(cfg.Server.Warmups) > 0 {
+		// A run still going when the next is due makes that one skipped.
+		p.jobs = cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+		if err := p.scheduleMonitors(cfg); err != nil {
+			return nil, err
+		}
+		if err := p.scheduleWarmups(cfg); err != nil {
+			return nil, err
+		}
+		p.jobs.Start()
+	}
+	return p, nil
+}
+
+// Close stops the monitors, warm-ups and failover checks, sends the documents still queued
+// for Quickwit by dual_write, stops running rehydrations, writes the cold
+// usage counted for server.cold_quota and closes the capture file, waiting
+// at most until ctx is done. Call it once the HTTP server has shut down.
+func (p *Proxy) Close(ctx context.Context) error {
+	var errs []error
+	if p.jobs != nil {
+		select {
+		case <-p.jobs.Stop().Done():
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("monitors or warm-ups still running: %w", ctx.Err()))
+		}
+	}
+	if p.failover != nil {
+		p.failover.close()
+	}
+	if p.mirror != nil {
+		errs = append(errs, p.mirror.close(ctx))
+	}
+	if p.rehydrate != nil {
+		errs = append(errs, p.rehydrate.close(ctx))
+	}
+	if p.quota != nil {
+		errs = append(errs, p.quota.close(ctx))
+	}
+	if p.capture != nil {
+		errs = append(errs, p.capture.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// SetConfig replaces the retention settings and timestamp fields that
+// requests are routed by, e.g. after the configuration file was reloaded,
+// and recreates the router from them. If that fails the previous router
+// is kept. Requests already being routed finish with the previous
+// configuration.
+func (p *Proxy) SetConfig(cfg *config.Config) {
+	router, err := newRouter(cfg)
+	if err != nil {
+		slog.Error("keeping the previous router", "error", err)
+		router = p.live.Load().router
+	}
+	p.live.Store(&liveConfig{cfg: cfg, router: router})
+}
+
+// Config returns the running configuration.
+func (p *Proxy) Config() *config.Config {
+	return p.live.Load().cfg
 }
 
 // ServeHTTP handles incoming HTTP requests.
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Set before anything is written, so that responses passed through from
+	// OpenSearch and those generated here carry the header alike.
+	if compat := p.live.Load().cfg.Server.Compat; compat.Enabled {
+		w.Header().Set(productHeader, compat.ProductHeader)
+		if r.URL.Path == "/" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			p.handleRoot(w, r, compat)
+			return
+		}
+	}
+
+	failover := p.failover.active()
+	if failover {
+		w.Header().Set(degradedHeader, "opensearch-unavailable")
+	}
+
 	// Health check endpoint.
 	if r.URL.Path == "/health" || r.URL.Path == "/_health" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
+		if failover {
+			w.Write([]byte(`{"status":"degraded","service":"oqbridge","opensearch":"unavailable"}`))
+			return
+		}
 		w.Write([]byte(`{"status":"ok","service":"oqbridge"}`))
 		return
 	}
 
+	// Tenant users only reach their tenant's indices; see tenancy.scope.
+	if p.tenancy != nil && !p.tenancy.scope(w, r) {
+		return
+	}
+
+	if ok, patterns := isCatColdIndices(r.URL.Path); ok && r.Method == http.MethodGet {
+		p.handleCatColdIndices(w, r, patterns)
+		return
+	}
+
+	if ok, patterns := isIndicesPath(r.URL.Path, catIndicesPath); ok && r.Method == http.MethodGet {
+		p.handleCatIndices(w, r, patterns)
+		return
+	}
+
+	if ok, patterns := isIndicesPath(r.URL.Path, resolveIndexPath); ok && len(patterns) > 0 && r.Method == http.MethodGet {
+		p.handleResolveIndex(w, r, patterns)
+		return
+	}
+
+	if ok, patterns := isIndicesPath(r.URL.Path, statsPath); ok && r.Method == http.MethodGet {
+		p.handleStats(w, r, patterns)
+		return
+	}
+
+	if ok, patterns := isIndicesPath(r.URL.Path, timelinePath); ok && r.Method == http.MethodGet {
+		p.handleTimeline(w, r, patterns)
+		return
+	}
+
+	if strings.TrimSuffix(r.URL.Path, "/") == quotaPath && r.Method == http.MethodGet && p.quota != nil {
+		p.quota.serveHTTP(w, r)
+		return
+	}
+
+	if isRetentionPath(r.URL.Path) && p.retention != nil {
+		p.handleRetention(w, r)
+		return
+	}
+
+	if ok, id := isRehydratePath(r.URL.Path); ok && p.rehydrate != nil {
+		p.rehydrate.serveHTTP(w, r, id)
+		return
+	}
+
+	if strings.TrimSuffix(r.URL.Path, "/") == tailPath && p.live.Load().cfg.Server.Tail.Enabled {
+		p.handleTail(w, r)
+		return
+	}
+
 	kind, indices := parseEndpoint(r.URL.Path)
 
 	slog.Debug("incoming request", "method", r.Method, "path", r.URL.Path, "endpoint", kind, "indices", indices)
@@ -101,6 +322,35 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		p.handleMSearch(w, r, indices)
 		return
+	case endpointCount:
+		if hasInternal(indices) {
+			p.reverseProxy.ServeHTTP(w, r)
+			return
+		}
+		p.handleCount(w, r, indices)
+		return
+	case endpointFieldCaps:
+		if r.Method == http.MethodGet && !hasInternal(indices) {
+			p.handleFieldCaps(w, r, indices)
+			return
+		}
+	case endpointMapping:
+		if r.Method == http.MethodGet && !hasInternal(indices) {
+			p.handleMapping(w, r, indices)
+			return
+		}
+	}
+
+	if failover {
+		http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+		return
+	}
+
+	if p.mirror != nil || p.live.Load().cfg.LateWrites.Enabled {
+		if kind, index := parseWriteEndpoint(r.Method, r.URL.Path); kind != writeNone {
+			p.handleWrite(w, r, kind, index)
+			return
+		}
 	}
 
 	// All other requests: passthrough to OpenSearch (OpenSearch validates auth).
@@ -118,7 +368,35 @@ func (p *Proxy) handleSearch(w http.ResponseWriter, r *http.Request, indices []s
 	// Restore body for potential passthrough.
 	r.Body = io.NopCloser(bytes.NewReader(body))
 
-	target := p.routeForIndices(body, indices)
+	span := p.tiersForRequest(r, body, indices)
+	if p.failover.active() {
+		if span = withoutHot(span); !slices.Contains(span, true) {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+	}
+	if p.quota != nil && span[len(span)-1] {
+		if r = p.quota.admit(w, r, 1, p.quota.windows(indices, body)); r == nil {
+			return
+		}
+	}
+	if p.capture != nil && p.capture.Sample() {
+		cw := &captureWriter{ResponseWriter: w}
+		w = cw
+		defer p.captureSearch(r, indices, body, routeName(span), cw, time.Now())
+	}
+	if p.pages != nil && slices.Contains(span[1:], true) && p.handleCachedPage(w, r, indices, body, span) {
+		p.routes.record("page_cache")
+		return
+	}
+	if reachesMiddleTier(span) {
+		slog.Debug("search routing decision", "indices", strings.Join(indices, ","), "tiers", span)
+		p.routes.record("tiered")
+		p.handleTieredSearch(w, r, indices, body, span)
+		return
+	}
+	target := routeTarget(span)
+	p.routes.record(target.String())
 
 	slog.Debug("search routing decision",
 		"indices", strings.Join(indices, ","),
@@ -132,8 +410,15 @@ func (p *Proxy) handleSearch(w http.ResponseWriter, r *http.Request, indices []s
 		return
 
 	case RouteColdOnly:
+		if err := checkCapabilities(p.coldBackend.Capabilities(), body); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"unsupported query for cold indices","detail":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+
 		// Single non-wildcard index: passthrough to Quickwit (no merge needed).
-		if len(indices) == 1 && !hasWildcard(indices) {
+		// An alias counts as the indices behind it.
+		coldIndices := p.aliases.expand(r.Context(), indices)
+		if len(coldIndices) == 1 && !hasWildcard(coldIndices) && !isRemoteIndex(coldIndices[0]) {
 			if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
 				status := http.StatusBadGateway
 				if isAuthError(err) {
@@ -144,18 +429,20 @@ func (p *Proxy) handleSearch(w http.ResponseWriter, r *http.Request, indices []s
 				return
 			}
 
-			resp, err := p.coldBackend.Search(r.Context(), indices[0], body)
+			resp, err := p.searchColdIndex(r.Context(), coldIndices[0], body)
 			if err != nil {
 				slog.Error("quickwit search failed", "error", err)
 				r.Body = io.NopCloser(bytes.NewReader(body))
 				p.reverseProxy.ServeHTTP(w, r)
 				return
 			}
+			resp.Aggregations = untypeAggregations(resp.Aggregations, wantsTypedKeys(r))
 			writeJSON(w, resp)
 			return
 		}
 
 		fanout, fanoutErr := planFanout(body)
+		fanout.Merge.TypedKeys = wantsTypedKeys(r)
 		if fanoutErr != nil {
 			http.Error(w, fmt.Sprintf(`{"error":"unsupported query for multi-index merge","detail":%q}`, fanoutErr.Error()), http.StatusBadRequest)
 			return
@@ -180,13 +467,18 @@ func (p *Proxy) handleSearch(w http.ResponseWriter, r *http.Request, indices []s
 			p.reverseProxy.ServeHTTP(w, r)
 			return
 		}
-		writeJSON(w, MergeSearchResponsesWithOptions(nil, resp, fanout.Merge))
+		writeJSON(w, MergeSearchResponsesWithOptions(nil, resp, p.dateSortFields(r.Context(), indices, fanout.Merge, r.Header)))
 		return
 
 	case RouteBoth:
 		fanout, fanoutErr := planFanout(body)
+		fanout.Merge.TypedKeys = wantsTypedKeys(r)
+		if fanoutErr == nil {
+			fanoutErr = checkCapabilities(p.coldBackend.Capabilities(), body)
+		}
 		if fanoutErr != nil {
-			// Query uses unsupported sort/search_after/pit for cross-tier merge.
+			// Query uses unsupported sort/search_after/pit for cross-tier merge,
+			// or a feature the cold backend lacks.
 			// Graceful degradation: return hot results only instead of 400.
 			slog.Info("falling back to hot-only for unsupported cross-tier query", "indices", strings.Join(indices, ","), "reason", fanoutErr.Error())
 			p.reverseProxy.ServeHTTP(w, r)
@@ -203,11 +495,25 @@ func (p *Proxy) handleSearch(w http.ResponseWriter, r *http.Request, indices []s
 
 // authenticateViaOpenSearch validates the client's credentials by making a
 // lightweight call to OpenSearch's security plugin. Forwards all incoming
-// headers so both basic auth and proxy auth modes work.
+// headers so both basic auth and proxy auth modes work. During failover the
+// users file stands in for the security plugin.
 func (p *Proxy) authenticateViaOpenSearch(ctx context.Context, incomingHeader http.Header) error {
+	if p.failover.active() {
+		_, err := p.failover.authInfo(incomingHeader)
+		return err
+	}
 	return p.hotBackend.Authenticate(ctx, incomingHeader)
 }
 
+// authInfo identifies the user of a request for server.tenancy and
+// server.cold_quota, like authenticateViaOpenSearch.
+func (p *Proxy) authInfo(ctx context.Context, incomingHeader http.Header) (*backend.AuthInfo, error) {
+	if p.failover.active() {
+		return p.failover.authInfo(incomingHeader)
+	}
+	return p.hotBackend.AuthInfo(ctx, incomingHeader)
+}
+
 func (p *Proxy) handleFanoutSearch(w http.ResponseWriter, ctx context.Context, index string, path string, rawQuery string, body []byte, merge MergeOptions, incomingHeader http.Header) {
 	var (
 		hotResp  *backend.SearchResponse
@@ -217,15 +523,19 @@ func (p *Proxy) handleFanoutSearch(w http.ResponseWriter, ctx context.Context, i
 		wg       sync.WaitGroup
 	)
 
-	wg.Add(2)
+	wg.Add(3)
 	go func() {
 		defer wg.Done()
-		hotResp, hotErr = p.hotBackend.SearchRaw(ctx, path, rawQuery, body, incomingHeader)
+		hotResp, hotErr = p.hotBackend.SearchRaw(ctx, path, withTypedKeys(rawQuery), body, incomingHeader)
 	}()
 	go func() {
 		defer wg.Done()
 		coldResp, coldErr = p.searchColdIndices(ctx, strings.Split(index, ","), body)
 	}()
+	go func() {
+		defer wg.Done()
+		merge = p.dateSortFields(ctx, strings.Split(index, ","), merge, incomingHeader)
+	}()
 	wg.Wait()
 
 	if hotErr != nil {
@@ -318,6 +628,12 @@ func parseEndpoint(path string) (endpointKind, []string) {
 		return endpointSearch, indices
 	case "_msearch":
 		return endpointMSearch, indices
+	case "_count":
+		return endpointCount, indices
+	case "_field_caps":
+		return endpointFieldCaps, indices
+	case "_mapping":
+		return endpointMapping, indices
 	default:
 		return endpointNone, nil
 	}
@@ -358,33 +674,31 @@ func hasWildcard(indices []string) bool {
 }
 
 func (p *Proxy) routeForIndices(body []byte, indices []string) RouteTarget {
-	if len(indices) == 0 {
-		return RouteBoth
-	}
-	target := RouteHotOnly
-	first := true
-	for _, index := range indices {
-		tsField := p.cfg.TimestampFieldForIndex(index)
-		t := p.router.Route(body, tsField)
-		if first {
-			target = t
-			first = false
-			continue
-		}
-		if target != t {
-			return RouteBoth
+	return routeTarget(p.tiersForIndices(body, indices))
+}
+
+// resolveColdIndices expands OpenSearch aliases and wildcard patterns in the
+// index list to concrete Quickwit index names. Indices migrated under
+// another name (migration.rules target_index) are renamed first; other
+// indices are returned as-is.
+func (p *Proxy) resolveColdIndices(ctx context.Context, indices []string) ([]string, error) {
+	cfg := p.live.Load().cfg
+	var targets []string
+	for _, idx := range p.aliases.expand(ctx, indices) {
+		if t := cfg.QuickwitIndexForIndex(idx); !slices.Contains(targets, t) {
+			targets = append(targets, t)
 		}
 	}
-	return target
+	return expandColdWildcards(ctx, p.coldBackend, targets)
 }
 
-// resolveColdIndices expands wildcard patterns in the index list to concrete
-// Quickwit index names. Non-wildcard indices are returned as-is.
-func (p *Proxy) resolveColdIndices(ctx context.Context, indices []string) ([]string, error) {
+// expandColdWildcards replaces the patterns among the Quickwit index names
+// with the indices of cold that match them.
+func expandColdWildcards(ctx context.Context, cold ColdBackend, indices []string) ([]string, error) {
 	if !hasWildcard(indices) {
 		return indices, nil
 	}
-	all, err := p.coldBackend.ListIndices(ctx)
+	all, err := cold.ListIndices(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("listing quickwit indices: %w", err)
 	}
@@ -412,13 +726,22 @@ func (p *Proxy) resolveColdIndices(ctx context.Context, indices []string) ([]str
 }
 
 func (p *Proxy) searchColdIndices(ctx context.Context, indices []string, body []byte) (*backend.SearchResponse, error) {
+	local, remote := p.splitRemote(indices)
+	if len(remote) > 0 {
+		return p.searchColdClusters(ctx, local, remote, body)
+	}
+
 	// Resolve wildcard patterns to concrete Quickwit index names.
-	resolved, err := p.resolveColdIndices(ctx, indices)
+	resolved, err := p.resolveColdIndices(ctx, local)
 	if err != nil {
 		return nil, err
 	}
-	indices = resolved
+	return p.searchResolvedCold(ctx, p.coldBackend, resolved, body)
+}
 
+// searchResolvedCold searches the Quickwit indices of cold and merges their
+// results.
+func (p *Proxy) searchResolvedCold(ctx context.Context, cold ColdBackend, indices []string, body []byte) (*backend.SearchResponse, error) {
 	if len(indices) == 0 {
 		// No matching Quickwit indices (e.g., migration hasn't run yet).
 		// Return empty response instead of error so fan-out can still return hot results.
@@ -429,33 +752,115 @@ func (p *Proxy) searchColdIndices(ctx context.Context, indices []string, body []
 		}, nil
 	}
 	if len(indices) == 1 {
-		return p.coldBackend.Search(ctx, indices[0], body)
+		return p.searchColdIndexOn(ctx, cold, indices[0], body)
 	}
 
-	type res struct {
-		resp *backend.SearchResponse
-		err  error
+	// Search every index in one request when the backend can and the
+	// requested hits fit in a single page.
+	if cold.Capabilities().SupportsMultiSearch && coldPages(body, p.coldPageSize) == nil {
+		if err := p.acquireColdSlot(ctx, nil); err != nil {
+			return nil, err
+		}
+		responses, err := cold.MultiSearch(ctx, indices, body)
+		p.releaseColdSlot(nil)
+		if err != nil {
+			return nil, err
+		}
+		var merged *backend.SearchResponse
+		for _, r := range responses {
+			chargeColdHits(ctx, len(r.Hits.Hits))
+			typeAggregations(body, r)
+			merged = MergeSearchResponses(merged, r)
+		}
+		return merged, nil
 	}
-	ch := make(chan res, len(indices))
-	for _, idx := range indices {
-		idx := idx
+
+	// Otherwise search the indices in parallel, at most
+	// server.cold_fanout.max_concurrency of them at once. No index is
+	// started once one has failed; the results are merged, and the errors
+	// reported, in the order of indices.
+	var local chan struct{}
+	if n := p.live.Load().cfg.Server.ColdFanout.MaxConcurrency; n > 0 {
+		local = make(chan struct{}, n)
+	}
+	responses := make([]*backend.SearchResponse, len(indices))
+	errs := make([]error, len(indices))
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	for i, idx := range indices {
+		if err := p.acquireColdSlot(ctx, local); err != nil {
+			errs[i] = err
+			break
+		}
+		if failed.Load() {
+			p.releaseColdSlot(local)
+			break
+		}
+		wg.Add(1)
 		go func() {
-			r, err := p.coldBackend.Search(ctx, idx, body)
-			ch <- res{resp: r, err: err}
+			defer wg.Done()
+			defer p.releaseColdSlot(local)
+			if responses[i], errs[i] = p.searchColdIndexOn(ctx, cold, idx, body); errs[i] != nil {
+				failed.Store(true)
+			}
 		}()
 	}
+	wg.Wait()
 
-	var merged *backend.SearchResponse
-	for i := 0; i < len(indices); i++ {
-		r := <-ch
-		if r.err != nil {
-			return nil, r.err
+	var (
+		merged   *backend.SearchResponse
+		failures []error
+	)
+	for i, resp := range responses {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", indices[i], errs[i]))
+		} else if resp != nil {
+			merged = MergeSearchResponses(merged, resp)
 		}
-		merged = MergeSearchResponses(merged, r.resp)
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
 	}
 	return merged, nil
 }
 
+// acquireColdSlot waits until the search may send one more request to
+// Quickwit: until fewer than cap(local) are in flight for it, unless local
+// is nil, and fewer than server.cold_fanout.max_global in the whole proxy.
+// It gives up when ctx is done.
+func (p *Proxy) acquireColdSlot(ctx context.Context, local chan struct{}) error {
+	if local != nil {
+		select {
+		case local <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if p.coldSlots != nil {
+		select {
+		case p.coldSlots <- struct{}{}:
+		case <-ctx.Done():
+			if local != nil {
+				<-local
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// releaseColdSlot frees the slots taken by acquireColdSlot.
+func (p *Proxy) releaseColdSlot(local chan struct{}) {
+	if p.coldSlots != nil {
+		<-p.coldSlots
+	}
+	if local != nil {
+		<-local
+	}
+}
+
 func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultIndices []string) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -486,10 +891,14 @@ func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultInd
 	}
 
 	needsCold := false
-	for _, e := range entries {
-		if p.routeForIndices(e.Body, e.Indices) != RouteHotOnly {
+	spans := make([][]bool, len(entries))
+	for i, e := range entries {
+		spans[i] = p.tiersForRequest(r, e.Body, e.Indices)
+		if p.failover.active() {
+			spans[i] = withoutHot(spans[i])
+		}
+		if slices.Contains(spans[i][1:], true) {
 			needsCold = true
-			break
 		}
 	}
 	if needsCold {
@@ -502,11 +911,52 @@ func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultInd
 			return
 		}
 	}
+	if p.quota != nil {
+		var queries, windows int64
+		for i, e := range entries {
+			if spans[i][len(spans[i])-1] {
+				queries++
+				windows += p.quota.windows(e.Indices, e.Body)
+			}
+		}
+		if queries > 0 {
+			if r = p.quota.admit(w, r, queries, windows); r == nil {
+				return
+			}
+		}
+	}
 
 	out := make([]json.RawMessage, 0, len(entries))
 
-	for _, e := range entries {
-		target := p.routeForIndices(e.Body, e.Indices)
+	for i, e := range entries {
+		if !slices.Contains(spans[i], true) {
+			// Only the hot cluster, which is down during failover.
+			out = append(out, json.RawMessage(`{"error":{"reason":"opensearch is unavailable"},"status":503}`))
+			continue
+		}
+		if reachesMiddleTier(spans[i]) {
+			p.routes.record("tiered")
+			fanout, err := planFanout(e.Body)
+			if err != nil {
+				out = append(out, json.RawMessage(fmt.Sprintf(`{"error":{"reason":%q},"status":400}`, err.Error())))
+				continue
+			}
+			fanout.Merge.TypedKeys = wantsTypedKeys(r)
+			resp, err := p.searchTiers(r.Context(), e.Indices, "/"+strings.Join(e.Indices, ",")+"/_search", "", fanout, spans[i], r.Header)
+			if err != nil {
+				status := 502
+				if isAuthError(err) {
+					status = statusFromAuthError(err)
+				}
+				out = append(out, json.RawMessage(fmt.Sprintf(`{"error":{"reason":%q},"status":%d}`, err.Error(), status)))
+				continue
+			}
+			b, _ := json.Marshal(resp)
+			out = append(out, b)
+			continue
+		}
+		target := routeTarget(spans[i])
+		p.routes.record(target.String())
 		needsMerge := target == RouteBoth || (target == RouteColdOnly && len(e.Indices) > 1)
 		fanout := fanoutPlan{Body: e.Body, Merge: MergeOptions{}}
 		var fanoutErr error
@@ -516,6 +966,7 @@ func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultInd
 				out = append(out, json.RawMessage(fmt.Sprintf(`{"error":{"reason":%q},"status":400}`, fanoutErr.Error())))
 				continue
 			}
+			fanout.Merge.TypedKeys = wantsTypedKeys(r)
 		}
 
 		switch target {
@@ -542,12 +993,14 @@ func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultInd
 				continue
 			}
 			if needsMerge {
-				resp = MergeSearchResponsesWithOptions(nil, resp, fanout.Merge)
+				resp = MergeSearchResponsesWithOptions(nil, resp, p.dateSortFields(r.Context(), e.Indices, fanout.Merge, r.Header))
+			} else {
+				resp.Aggregations = untypeAggregations(resp.Aggregations, wantsTypedKeys(r))
 			}
 			b, _ := json.Marshal(resp)
 			out = append(out, b)
 		case RouteBoth:
-			hotResp, hotErr := p.hotBackend.SearchAs(r.Context(), strings.Join(e.Indices, ","), fanout.Body, r.Header)
+			hotResp, hotErr := p.hotBackend.SearchRaw(r.Context(), "/"+strings.Join(e.Indices, ",")+"/_search", "typed_keys=true", fanout.Body, r.Header)
 			coldResp, coldErr := p.searchColdIndices(r.Context(), e.Indices, fanout.Body)
 			if hotErr != nil && coldErr != nil {
 				out = append(out, json.RawMessage(fmt.Sprintf(`{"error":{"reason":%q},"status":502}`, "both backends failed")))
@@ -557,7 +1010,7 @@ func (p *Proxy) handleMSearch(w http.ResponseWriter, r *http.Request, defaultInd
 				out = append(out, json.RawMessage(fmt.Sprintf(`{"error":{"reason":"authentication failed"},"status":%d}`, statusFromAuthError(hotErr))))
 				continue
 			}
-			merged := MergeSearchResponsesWithOptions(hotResp, coldResp, fanout.Merge)
+			merged := MergeSearchResponsesWithOptions(hotResp, coldResp, p.dateSortFields(r.Context(), e.Indices, fanout.Merge, r.Header))
 			b, _ := json.Marshal(merged)
 			out = append(out, b)
 		}
@@ -1,17 +1,22 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/leonunix/oqbridge/internal/backend"
 	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
 )
 
 const validToken = "Basic dXNlcjpwYXNz" // user:pass
@@ -278,7 +283,7 @@ func TestProxy_Both_ValidAuth_MergedResults(t *testing.T) {
 	}
 }
 
-func TestProxy_Both_ExplicitSort_FallsBackToHotOnly(t *testing.T) {
+func TestProxy_Both_UnsupportedSort_FallsBackToHotOnly(t *testing.T) {
 	os := newMockOpenSearch(t)
 	defer os.Close()
 	qw := newMockQuickwit(t)
@@ -286,8 +291,8 @@ func TestProxy_Both_ExplicitSort_FallsBackToHotOnly(t *testing.T) {
 
 	p := newTestProxy(t, os.URL, qw.URL)
 
-	// Inject an explicit sort that isn't _score — should fall back to hot-only passthrough.
-	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(fmt.Sprintf(`{"sort":[{"@timestamp":"desc"}],%s`, strings.TrimPrefix(buildBothQuery(), "{"))))
+	// Inject a sort that cannot be merged — should fall back to hot-only passthrough.
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(fmt.Sprintf(`{"sort":[{"_script":{"type":"number","script":"1"}}],%s`, strings.TrimPrefix(buildBothQuery(), "{"))))
 	req.Header.Set("Authorization", validToken)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -299,6 +304,53 @@ func TestProxy_Both_ExplicitSort_FallsBackToHotOnly(t *testing.T) {
 	}
 }
 
+func TestProxy_Both_SortByTimestamp(t *testing.T) {
+	mockOS := newMockOpenSearch(t)
+	defer mockOS.Close()
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/_search") && r.Header.Get("Authorization") == validToken {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"hits":{"total":{"value":2,"relation":"eq"},"hits":[` +
+				`{"_id":"hot-new","_score":null,"_source":{"@timestamp":"2025-03-04T10:00:00Z"},"sort":[1741082400000]},` +
+				`{"_id":"hot-old","_score":null,"_source":{"@timestamp":"2025-03-02T10:00:00Z"},"sort":[1740909600000]}]}}`))
+			return
+		}
+		mockOS.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer osSrv.Close()
+	mockQW := newMockQuickwit(t)
+	defer mockQW.Close()
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/indexes" {
+			mockQW.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[` +
+			`{"_id":"cold","_source":{"@timestamp":"2025-03-03T10:00:00Z"},"sort":[1740996000000000000]}]}}`))
+	}))
+	defer qwSrv.Close()
+
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	body := fmt.Sprintf(`{"size":2,"sort":[{"@timestamp":{"order":"desc","unmapped_type":"boolean"}}],%s`, strings.TrimPrefix(buildBothQuery(), "{"))
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+	req.Header.Set("Authorization", validToken)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp backend.SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if got := hitIDs(resp.Hits.Hits); got != "hot-new,cold" || resp.Hits.Total.Value != 3 {
+		t.Errorf("hits = %s (total %d), want hot-new,cold of 3", got, resp.Hits.Total.Value)
+	}
+}
+
 func TestProxy_MultiIndex_Both_MergedResults(t *testing.T) {
 	os := newMockOpenSearch(t)
 	defer os.Close()
@@ -354,7 +406,51 @@ func TestProxy_MultiIndex_ColdOnly_MergedResults(t *testing.T) {
 	}
 }
 
-func TestProxy_MultiIndex_ColdOnly_ExplicitSort_Unsupported(t *testing.T) {
+func TestProxy_MultiIndex_ColdOnly_ElasticBatchesMSearch(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	var msearchCalls, searchCalls int
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/_elastic/_msearch":
+			msearchCalls++
+			w.Write([]byte(`{"responses":[
+				{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_score":0.8,"_source":{"msg":"a"}}]}},
+				{"hits":{"total":{"value":2,"relation":"eq"},"hits":[{"_score":0.5,"_source":{"msg":"b"}}]}}
+			]}`))
+		default:
+			searchCalls++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+	p.coldBackend.(*backend.Quickwit).SetSearchAPI(backend.SearchAPIElastic)
+
+	req := httptest.NewRequest(http.MethodPost, "/a,b/_search", strings.NewReader(buildColdOnlyQuery()))
+	req.Header.Set("Authorization", validToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if msearchCalls != 1 || searchCalls != 0 {
+		t.Fatalf("msearch calls=%d, search calls=%d, want 1 and 0", msearchCalls, searchCalls)
+	}
+
+	var resp backend.SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Hits.Total.Value != 3 || len(resp.Hits.Hits) != 2 {
+		t.Fatalf("expected 3 total and 2 merged hits, got %d/%d", resp.Hits.Total.Value, len(resp.Hits.Hits))
+	}
+}
+
+func TestProxy_MultiIndex_ColdOnly_UnsupportedSort(t *testing.T) {
 	os := newMockOpenSearch(t)
 	defer os.Close()
 	qw := newMockQuickwit(t)
@@ -363,7 +459,7 @@ func TestProxy_MultiIndex_ColdOnly_ExplicitSort_Unsupported(t *testing.T) {
 	p := newTestProxy(t, os.URL, qw.URL)
 
 	cold := buildColdOnlyQuery()
-	body := fmt.Sprintf(`{"sort":[{"@timestamp":"desc"}],%s`, strings.TrimPrefix(cold, "{"))
+	body := fmt.Sprintf(`{"sort":[{"_script":{"type":"number","script":"1"}}],%s`, strings.TrimPrefix(cold, "{"))
 	req := httptest.NewRequest(http.MethodPost, "/a,b/_search", strings.NewReader(body))
 	req.Header.Set("Authorization", validToken)
 	req.Header.Set("Content-Type", "application/json")
@@ -587,6 +683,36 @@ func TestProxy_Both_NoAuth_DoesNotLeakCold(t *testing.T) {
 	}
 }
 
+func TestProxy_ChaosAuthFailure_DoesNotLeakCold(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	qw := newMockQuickwit(t)
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	for _, fc := range []config.FaultConfig{{DropRate: 1}, {ErrorRate: 1, ErrorStatus: 503}} {
+		client := &http.Client{Transport: util.NewFaultTransport(nil, "opensearch", fc)}
+		hot := backend.NewOpenSearch(os.URL, "", "", client)
+		p, err := New(cfg, hot, backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+		if err != nil {
+			t.Fatalf("New() error: %v", err)
+		}
+
+		for _, body := range []string{buildColdOnlyQuery(), buildBothQuery()} {
+			req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+			req.Header.Set("Authorization", validToken)
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code < 500 || strings.Contains(w.Body.String(), "cold") {
+				t.Errorf("%+v: search with an unreachable auth check = %d %s", fc, w.Code, w.Body.String())
+			}
+		}
+	}
+}
+
 func TestProxy_Both_OpenSearch500_ValidAuth_ReturnsCold(t *testing.T) {
 	t.Helper()
 
@@ -789,3 +915,291 @@ func TestProxy_WildcardIndex_Both_MergedResults(t *testing.T) {
 		t.Fatalf("expected 2 merged hits, got %d", resp.Hits.Total.Value)
 	}
 }
+
+func TestProxy_CatColdIndices(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs-b"}},{"index_config":{"index_id":"logs-a"}},{"index_config":{"index_id":"audit"}}]`))
+		case "/api/v1/indexes/logs-a/describe":
+			w.Write([]byte(`{"num_published_docs":10,"num_published_splits":2,"size_published_splits":1572864,"min_timestamp":1767225600,"max_timestamp":1767312000}`))
+		case "/api/v1/indexes/logs-b/describe":
+			w.Write([]byte(`{"num_published_docs":5,"num_published_splits":1,"size_published_splits":512}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+
+	req := httptest.NewRequest(http.MethodGet, "/_cat/cold_indices/logs-*?v", nil)
+	req.Header.Set("Authorization", validToken)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "index") {
+		t.Fatalf("unexpected output:\n%s", w.Body.String())
+	}
+	if f := strings.Fields(lines[1]); f[0] != "logs-a" || f[1] != "10" || f[2] != "2" || f[3] != "1.5mb" || f[4] != "2026-01-01T00:00:00Z" {
+		t.Fatalf("row=%q", lines[1])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/_cat/cold_indices?format=json&bytes=b", nil)
+	req.Header.Set("Authorization", validToken)
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	var rows []map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("failed to parse response: %v: %s", err, w.Body.String())
+	}
+	// audit fails to describe and is left out.
+	if len(rows) != 2 || rows[1]["index"] != "logs-b" || rows[1]["store.size"] != "512" || rows[1]["max_timestamp"] != "-" {
+		t.Fatalf("rows=%v", rows)
+	}
+}
+
+func TestProxy_CatColdIndices_RequiresAuth(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	qw := newMockQuickwit(t)
+	defer qw.Close()
+
+	p := newTestProxy(t, os.URL, qw.URL)
+	req := httptest.NewRequest(http.MethodGet, "/_cat/cold_indices", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProxy_SetConfig_ChangesRouting(t *testing.T) {
+	p := newTestProxy(t, "http://os:9200", "http://qw:7280")
+	body := []byte(buildColdOnlyQuery())
+	if got := p.routeForIndices(body, []string{"logs"}); got != RouteColdOnly {
+		t.Fatalf("route = %v, want cold only", got)
+	}
+
+	cfg := *p.live.Load().cfg
+	cfg.Retention.Days = 120
+	p.SetConfig(&cfg)
+	if got := p.routeForIndices(body, []string{"logs"}); got != RouteHotOnly {
+		t.Errorf("route after raising retention.days = %v, want hot only", got)
+	}
+}
+
+func TestProxy_RouteForIndices_PerIndexRetention(t *testing.T) {
+	p := newTestProxy(t, "http://os:9200", "http://qw:7280")
+	cfg := *p.live.Load().cfg
+	cfg.Retention.IndexDays = map[string]int{"audit-*": 120}
+	p.SetConfig(&cfg)
+
+	body := []byte(buildColdOnlyQuery())
+	if got := p.routeForIndices(body, []string{"logs"}); got != RouteColdOnly {
+		t.Errorf("logs: route = %v, want cold only", got)
+	}
+	if got := p.routeForIndices(body, []string{"audit-2026.01.01"}); got != RouteHotOnly {
+		t.Errorf("audit: route = %v, want hot only within its 120 days", got)
+	}
+	if got := p.routeForIndices(body, []string{"logs", "audit-2026.01.01"}); got != RouteBoth {
+		t.Errorf("logs and audit: route = %v, want both", got)
+	}
+}
+
+func TestProxy_ResolveColdIndices_MigrationTargetIndex(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	qwSrv := newMockQuickwitWithIndices(t, []string{"archive-audit-2026.01.01", "logs"})
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+	cfg := *p.live.Load().cfg
+	cfg.Migration.Rules = []config.MigrationRule{{Indices: []string{"audit-*"}, TargetIndex: "archive-{index}"}}
+	p.SetConfig(&cfg)
+
+	got, err := p.resolveColdIndices(context.Background(), []string{"audit-*", "logs"})
+	if err != nil {
+		t.Fatalf("resolveColdIndices() error = %v", err)
+	}
+	if want := []string{"archive-audit-2026.01.01", "logs"}; !slices.Equal(got, want) {
+		t.Errorf("resolveColdIndices() = %v, want %v", got, want)
+	}
+}
+
+func TestProxy_WildcardIndex_ColdOnly_SearchesEveryQuickwitCluster(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	qwSrv := newMockQuickwitWithIndices(t, []string{"logs-us"})
+	defer qwSrv.Close()
+	euSrv := newMockQuickwitWithIndices(t, []string{"logs-eu"})
+	defer euSrv.Close()
+
+	cfg := &config.Config{
+		OpenSearch:       config.OpenSearchConfig{URL: osSrv.URL},
+		Quickwit:         config.QuickwitConfig{URL: qwSrv.URL},
+		QuickwitClusters: []config.QuickwitCluster{{Name: "eu", Indices: []string{"logs-eu*"}, Quickwit: config.QuickwitConfig{URL: euSrv.URL}}},
+		Retention:        config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cold := backend.NewQuickwitRouter(
+		backend.NewQuickwit(qwSrv.URL, "", "", false, nil),
+		map[string]*backend.Quickwit{"eu": backend.NewQuickwit(euSrv.URL, "", "", false, nil)},
+		cfg.QuickwitClusterForIndex,
+	)
+	p, err := New(cfg, backend.NewOpenSearch(osSrv.URL, "", "", nil), cold, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/logs-*/_search", strings.NewReader(buildColdOnlyQuery()))
+	req.Header.Set("Authorization", validToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp backend.SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Hits.Total.Value != 2 {
+		t.Errorf("expected one hit from each cluster, got %d", resp.Hits.Total.Value)
+	}
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	none := backend.Capabilities{}
+	all := backend.Capabilities{SupportsAggregations: true, SupportsSort: true}
+	tests := []struct {
+		name    string
+		caps    backend.Capabilities
+		body    string
+		wantErr bool
+	}{
+		{"plain query", none, `{"query":{"match_all":{}}}`, false},
+		{"score sort", none, `{"sort":[{"_score":"asc"}]}`, false},
+		{"field sort", none, `{"sort":[{"@timestamp":"desc"}]}`, true},
+		{"aggs", none, `{"aggs":{"levels":{"terms":{"field":"level"}}}}`, true},
+		{"aggregations", none, `{"aggregations":{"levels":{"terms":{"field":"level"}}}}`, true},
+		{"supported", all, `{"sort":["@timestamp"],"aggs":{}}`, false},
+		{"not json", none, `not json`, false},
+	}
+	for _, tt := range tests {
+		if err := checkCapabilities(tt.caps, []byte(tt.body)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkCapabilities err=%v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// newSlowQuickwit fakes a Quickwit cluster with the given indices whose
+// searches take delay, recording the most searches in flight at once.
+// Searches of the indices in fail return 500 after failDelay.
+func newSlowQuickwit(t *testing.T, indices []string, delay time.Duration, fail map[string]time.Duration, maxInFlight *atomic.Int32) *httptest.Server {
+	t.Helper()
+	var inFlight atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/indexes" {
+			var entries []map[string]any
+			for _, idx := range indices {
+				entries = append(entries, map[string]any{"index_config": map[string]any{"index_id": idx}})
+			}
+			json.NewEncoder(w).Encode(entries)
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		index := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1/_elastic/"), "/api/v1/")
+		index, _, _ = strings.Cut(index, "/")
+		if d, ok := fail[index]; ok {
+			time.Sleep(d)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(delay)
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_score":1,"_source":{"msg":"cold"}}]}}`))
+	}))
+}
+
+func TestProxy_SearchColdIndices_Concurrency(t *testing.T) {
+	var indices []string
+	for i := range 20 {
+		indices = append(indices, fmt.Sprintf("logs-%02d", i))
+	}
+	var maxInFlight atomic.Int32
+	qw := newSlowQuickwit(t, indices, 20*time.Millisecond, nil, &maxInFlight)
+	defer qw.Close()
+
+	cfg := &config.Config{Retention: config.RetentionConfig{Days: 30, TimestampField: "@timestamp"}}
+	cfg.Server.ColdFanout = config.ColdFanoutConfig{MaxConcurrency: 3, MaxGlobal: 4}
+	p, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	resp, err := p.searchColdIndices(context.Background(), []string{"logs-*"}, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("searchColdIndices: %v", err)
+	}
+	if resp.Hits.Total.Value != 20 {
+		t.Errorf("total hits = %d, want one from each of 20 indices", resp.Hits.Total.Value)
+	}
+	if got := maxInFlight.Load(); got != 3 {
+		t.Errorf("most searches in flight = %d, want max_concurrency 3", got)
+	}
+
+	// Two searches at once share max_global.
+	maxInFlight.Store(0)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.searchColdIndices(context.Background(), []string{"logs-*"}, []byte(`{}`)); err != nil {
+				t.Errorf("searchColdIndices: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := maxInFlight.Load(); got > 4 {
+		t.Errorf("most searches in flight for two searches = %d, want at most max_global 4", got)
+	}
+	if len(p.coldSlots) != 0 {
+		t.Errorf("%d global slots still taken", len(p.coldSlots))
+	}
+}
+
+func TestProxy_SearchColdIndices_OrderedErrors(t *testing.T) {
+	fail := map[string]time.Duration{"logs-b": 30 * time.Millisecond, "logs-c": 0}
+	var maxInFlight atomic.Int32
+	qw := newSlowQuickwit(t, []string{"logs-a", "logs-b", "logs-c"}, 0, fail, &maxInFlight)
+	defer qw.Close()
+
+	cfg := &config.Config{Retention: config.RetentionConfig{Days: 30, TimestampField: "@timestamp"}}
+	cfg.Server.ColdFanout = config.ColdFanoutConfig{MaxConcurrency: -1, MaxGlobal: -1}
+	p, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	_, err = p.searchColdIndices(context.Background(), []string{"logs-a", "logs-b", "logs-c"}, []byte(`{}`))
+	if err == nil {
+		t.Fatal("searchColdIndices succeeded with two failing indices")
+	}
+	msg := err.Error()
+	b, c := strings.Index(msg, "logs-b: "), strings.Index(msg, "logs-c: ")
+	if b < 0 || c < 0 || b > c || strings.Contains(msg, "logs-a") {
+		t.Errorf("error = %q, want the failures of logs-b then logs-c", msg)
+	}
+}
@@ -0,0 +1,344 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// quotaPath reports the caller's cold usage of the day and its limits.
+const quotaPath = "/_oqbridge/quota"
+
+// unboundedColdDays is how many days a search without a lower time bound
+// counts against server.cold_quota.windows when its index keeps cold data
+// forever.
+const unboundedColdDays = 365
+
+// coldUsage is what one user took from Quickwit on one day, as stored in
+// server.cold_quota.index under the ID "user:day".
+type coldUsage struct {
+	User      string    `json:"user"`
+	Day       string    `json:"day"` // YYYY-MM-DD in retention.timezone
+	Queries   int64     `json:"queries"`
+	Windows   int64     `json:"windows"`
+	Hits      int64     `json:"hits"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// usageStore is the OpenSearch index holding the usage of every proxy.
+type usageStore interface {
+	GetDoc(ctx context.Context, index, id string, out any) (bool, error)
+	UpdateDoc(ctx context.Context, index, id string, body []byte, out any) error
+}
+
+// coldQuota enforces server.cold_quota. Each proxy counts the searches it
+// sends to Quickwit on top of the totals last read from the usage index
+// and adds its counts to the index every flush_interval, so the proxies
+// together can exceed a limit by what they count between two flushes.
+type coldQuota struct {
+	settings config.ColdQuotaConfig
+	users    *userCache
+	store    usageStore
+	cfg      func() *config.Config
+	now      func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*userUsage // by document ID
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// userUsage is the usage of one user on one day.
+type userUsage struct {
+	stored  coldUsage // totals of every proxy, as last read from the index
+	pending coldUsage // counted here since and not added to the index yet
+}
+
+// total is the usage of the user as this proxy knows it.
+func (u *userUsage) total() coldUsage {
+	t := u.stored
+	t.Queries += u.pending.Queries
+	t.Windows += u.pending.Windows
+	t.Hits += u.pending.Hits
+	return t
+}
+
+func newColdQuota(settings config.ColdQuotaConfig, hot *backend.OpenSearch, auth authInfoFunc, cfg func() *config.Config) *coldQuota {
+	q := &coldQuota{
+		settings: settings,
+		users:    newUserCache(auth, settings.CacheTTL, settings.IdentityHeaders),
+		store:    hot,
+		cfg:      cfg,
+		now:      time.Now,
+		usage:    make(map[string]*userUsage),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// quotaCharge adds the hits Quickwit returns for an admitted search to the
+// usage of its user.
+type quotaCharge struct {
+	q *coldQuota
+	u *userUsage
+}
+
+type quotaChargeKey struct{}
+
+// chargeColdHits adds n hits returned by Quickwit to the usage of the user
+// whose search ctx belongs to, if it is counted.
+func chargeColdHits(ctx context.Context, n int) {
+	c, ok := ctx.Value(quotaChargeKey{}).(*quotaCharge)
+	if !ok || n == 0 {
+		return
+	}
+	c.q.mu.Lock()
+	c.u.pending.Hits += int64(n)
+	c.q.mu.Unlock()
+}
+
+// admit counts the searches of r that reach Quickwit, queries of them
+// covering windows days of cold data (see windows), and returns r with a
+// context counting the hits Quickwit returns for them. If they would
+// exceed a limit of the client's user, or the user is unknown, admit
+// answers the request itself and returns nil.
+func (q *coldQuota) admit(w http.ResponseWriter, r *http.Request, queries, windows int64) *http.Request {
+	info, err := q.users.user(r.Context(), r.Header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for cold quota", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return nil
+	}
+	if hasAnyRole(info, q.settings.ExemptRoles) {
+		return r
+	}
+
+	now := q.now()
+	cfg := q.cfg()
+	limits := q.limitsFor(info)
+	u := q.userUsage(r.Context(), info.UserName, now.In(cfg.Location()).Format(time.DateOnly))
+
+	q.mu.Lock()
+	total := u.total()
+	exceeded := ""
+	switch {
+	case limits.Queries > 0 && total.Queries+queries > limits.Queries:
+		exceeded = fmt.Sprintf("%d of %d queries", total.Queries, limits.Queries)
+	case limits.Windows > 0 && total.Windows+windows > limits.Windows:
+		exceeded = fmt.Sprintf("%d of %d days of cold data, this search needs %d", total.Windows, limits.Windows, windows)
+	case limits.Hits > 0 && total.Hits >= limits.Hits:
+		exceeded = fmt.Sprintf("%d of %d hits", total.Hits, limits.Hits)
+	default:
+		u.pending.Queries += queries
+		u.pending.Windows += windows
+	}
+	q.mu.Unlock()
+
+	if exceeded != "" {
+		reset := cfg.DaysAgo(now, -1)
+		slog.Warn("cold quota exceeded", "user", info.UserName, "used", exceeded, "path", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(reset.Sub(now).Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":    "cold query quota exceeded",
+			"detail":   fmt.Sprintf("%s used %s on Quickwit today", info.UserName, exceeded),
+			"reset_at": reset,
+		})
+		return nil
+	}
+	return r.WithContext(context.WithValue(r.Context(), quotaChargeKey{}, &quotaCharge{q: q, u: u}))
+}
+
+// limitsFor returns the limits of the first override matching info, or
+// the default limits.
+func (q *coldQuota) limitsFor(info *backend.AuthInfo) config.ColdQuotaLimits {
+	for _, o := range q.settings.Overrides {
+		if slices.Contains(o.Users, info.UserName) || hasAnyRole(info, o.Roles) {
+			return o.ColdQuotaLimits
+		}
+	}
+	return q.settings.ColdQuotaLimits
+}
+
+// userUsage returns the usage of user on day, reading it from the index
+// the first time. If that fails the user starts from zero until the next
+// flush reads the totals back.
+func (q *coldQuota) userUsage(ctx context.Context, user, day string) *userUsage {
+	id := user + ":" + day
+	q.mu.Lock()
+	u, ok := q.usage[id]
+	q.mu.Unlock()
+	if ok {
+		return u
+	}
+
+	stored := coldUsage{User: user, Day: day}
+	if _, err := q.store.GetDoc(ctx, q.settings.Index, id, &stored); err != nil {
+		slog.Warn("reading cold usage failed", "user", user, "error", err)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if u, ok := q.usage[id]; ok {
+		return u
+	}
+	u = &userUsage{stored: stored, pending: coldUsage{User: user, Day: day}}
+	q.usage[id] = u
+	return u
+}
+
+// run flushes the usage every flush_interval until close.
+func (q *coldQuota) run() {
+	defer close(q.done)
+	ticker := time.NewTicker(q.settings.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.flush(context.Background())
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// flush adds the pending usage of each user to the index and keeps the
+// totals it returns, which include the usage of the other proxies. Users
+// of past days are forgotten once their usage is in the index.
+func (q *coldQuota) flush(ctx context.Context) error {
+	today := q.now().In(q.cfg().Location()).Format(time.DateOnly)
+	q.mu.Lock()
+	batch := make(map[string]coldUsage)
+	for id, u := range q.usage {
+		if u.pending.Queries != 0 || u.pending.Windows != 0 || u.pending.Hits != 0 {
+			batch[id] = u.pending
+		} else if u.stored.Day != today {
+			delete(q.usage, id)
+		}
+	}
+	q.mu.Unlock()
+
+	var failed int
+	for id, add := range batch {
+		add.UpdatedAt = q.now().UTC()
+		body, _ := json.Marshal(map[string]any{
+			"script": map[string]any{
+				"source": "ctx._source.queries += params.queries; ctx._source.windows += params.windows; " +
+					"ctx._source.hits += params.hits; ctx._source.updated_at = params.updated_at",
+				"params": add,
+			},
+			"upsert": add,
+		})
+		var stored coldUsage
+		if err := q.store.UpdateDoc(ctx, q.settings.Index, id, body, &stored); err != nil {
+			slog.Warn("writing cold usage failed", "user", add.User, "error", err)
+			failed++
+			continue
+		}
+		q.mu.Lock()
+		u := q.usage[id]
+		u.stored = stored
+		u.pending.Queries -= add.Queries
+		u.pending.Windows -= add.Windows
+		u.pending.Hits -= add.Hits
+		q.mu.Unlock()
+	}
+	if failed > 0 {
+		return fmt.Errorf("writing cold usage of %d users failed", failed)
+	}
+	return nil
+}
+
+// close stops flushing and writes the pending usage, waiting at most until
+// ctx is done.
+func (q *coldQuota) close(ctx context.Context) error {
+	close(q.stop)
+	<-q.done
+	return q.flush(ctx)
+}
+
+// serveHTTP answers GET _oqbridge/quota with the caller's usage of the day
+// and limits.
+func (q *coldQuota) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	info, err := q.users.user(r.Context(), r.Header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for _oqbridge/quota", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+	exempt := hasAnyRole(info, q.settings.ExemptRoles)
+	resp := map[string]any{"user": info.UserName, "exempt": exempt}
+	if !exempt {
+		now := q.now()
+		u := q.userUsage(r.Context(), info.UserName, now.In(q.cfg().Location()).Format(time.DateOnly))
+		q.mu.Lock()
+		total := u.total()
+		q.mu.Unlock()
+		limits := q.limitsFor(info)
+		resp["day"] = total.Day
+		resp["used"] = map[string]int64{"queries": total.Queries, "windows": total.Windows, "hits": total.Hits}
+		resp["limits"] = map[string]int64{"queries": limits.Queries, "windows": limits.Windows, "hits": limits.Hits}
+		resp["reset_at"] = q.cfg().DaysAgo(now, -1)
+	}
+	writeJSON(w, resp)
+}
+
+// windows returns how many days of cold data a search of indices with body
+// covers; see coldWindows.
+func (q *coldQuota) windows(indices []string, body []byte) int64 {
+	return coldWindows(q.cfg(), indices, body, q.now())
+}
+
+// coldWindows returns how many days of cold data a search of indices with
+// body covers: for each index, from the later of the query's lower time
+// bound and the start of the cold retention period, to the earlier of its
+// upper bound and the day data leaves the last OpenSearch tier. Partial
+// days count as whole ones.
+func coldWindows(cfg *config.Config, indices []string, body []byte, now time.Time) int64 {
+	var windows int64
+	for _, index := range indices {
+		_, name, _ := cfg.RemoteClustersForIndex(index)
+		days := cfg.TierDays(name)
+		to := cfg.DaysAgo(now, days[len(days)-1])
+		var from time.Time
+		if cold := cfg.ColdDaysForIndex(name); cold > 0 {
+			from = cfg.DaysAgo(now, cold)
+		}
+		tr := util.ExtractTimeRangeAt(body, cfg.TimestampFieldForIndex(name), now)
+		if tr != nil && tr.To != nil && tr.To.Before(to) {
+			to = *tr.To
+		}
+		if tr != nil && tr.From != nil && tr.From.After(from) {
+			from = *tr.From
+		}
+		if from.IsZero() {
+			from = to.AddDate(0, 0, -unboundedColdDays)
+		}
+		if to.After(from) {
+			windows += int64(math.Ceil(to.Sub(from).Hours() / 24))
+		}
+	}
+	return windows
+}
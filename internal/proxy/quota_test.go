@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestColdWindows(t *testing.T) {
+	cfg := &config.Config{Retention: config.RetentionConfig{
+		Days:           30,
+		ColdDays:       365,
+		TimestampField: "@timestamp",
+		IndexColdDays:  map[string]int{"forever": 0},
+	}}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	rangeBody := func(from, to string) []byte {
+		return []byte(fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lt":%q}}}}`, from, to))
+	}
+	tests := []struct {
+		name    string
+		indices []string
+		body    []byte
+		want    int64
+	}{
+		{"cold range", []string{"logs"}, rangeBody("2025-12-10T12:00:00Z", "2026-01-09T12:00:00Z"), 30},
+		{"partial day", []string{"logs"}, rangeBody("2026-01-01T00:00:00Z", "2026-01-02T06:00:00Z"), 2},
+		{"per index", []string{"logs", "metrics"}, rangeBody("2026-01-01T00:00:00Z", "2026-01-02T00:00:00Z"), 2},
+		{"hot only", []string{"logs"}, rangeBody("2026-03-01T00:00:00Z", "2026-03-10T00:00:00Z"), 0},
+		// From the start of the cold retention period to the hot cutoff.
+		{"no range", []string{"logs"}, []byte(`{}`), 335},
+		{"no range, unlimited retention", []string{"forever"}, []byte(`{}`), unboundedColdDays},
+	}
+	for _, tt := range tests {
+		if got := coldWindows(cfg, tt.indices, tt.body, now); got != tt.want {
+			t.Errorf("%s: coldWindows() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newUsageOpenSearch fakes an OpenSearch cluster that authenticates the
+// users alice, bob and admin, holds the usage documents of the cold quota
+// in docs and answers every other request with an empty search result.
+func newUsageOpenSearch(t *testing.T, mu *sync.Mutex, docs map[string]coldUsage) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/_plugins/_security/authinfo":
+			switch r.Header.Get("Authorization") {
+			case "alice":
+				w.Write([]byte(`{"user_name":"alice","roles":["analyst"]}`))
+			case "bob":
+				w.Write([]byte(`{"user_name":"bob","backend_roles":["oncall"]}`))
+			case "admin":
+				w.Write([]byte(`{"user_name":"admin","roles":["all_access"]}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		case strings.HasPrefix(r.URL.Path, "/.oqbridge-cold-usage/_doc/"):
+			doc, ok := docs[strings.TrimPrefix(r.URL.Path, "/.oqbridge-cold-usage/_doc/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"found":false}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"found": true, "_source": doc})
+		case strings.HasPrefix(r.URL.Path, "/.oqbridge-cold-usage/_update/"):
+			id := strings.TrimPrefix(r.URL.Path, "/.oqbridge-cold-usage/_update/")
+			var req struct {
+				Script struct {
+					Params coldUsage `json:"params"`
+				} `json:"script"`
+				Upsert coldUsage `json:"upsert"`
+			}
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("usage update body: %v", err)
+			}
+			doc, ok := docs[id]
+			if !ok {
+				doc = req.Upsert
+			} else {
+				doc.Queries += req.Script.Params.Queries
+				doc.Windows += req.Script.Params.Windows
+				doc.Hits += req.Script.Params.Hits
+			}
+			docs[id] = doc
+			json.NewEncoder(w).Encode(map[string]any{"get": map[string]any{"found": true, "_source": doc}})
+		default:
+			w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`))
+		}
+	}))
+}
+
+func TestProxy_ColdQuota(t *testing.T) {
+	var mu sync.Mutex
+	docs := make(map[string]coldUsage)
+	os := newUsageOpenSearch(t, &mu, docs)
+	defer os.Close()
+	qw := newMockQuickwit(t)
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.ColdQuota = config.ColdQuotaConfig{
+		Enabled:         true,
+		ColdQuotaLimits: config.ColdQuotaLimits{Queries: 2},
+		Overrides:       []config.ColdQuotaOverride{{Roles: []string{"oncall"}, ColdQuotaLimits: config.ColdQuotaLimits{Hits: 1}}},
+		ExemptRoles:     []string{"all_access"},
+		Index:           ".oqbridge-cold-usage",
+		FlushInterval:   time.Hour,
+		CacheTTL:        time.Minute,
+		IdentityHeaders: []string{"Authorization"},
+	}
+	newProxy := func() *Proxy {
+		p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+		if err != nil {
+			t.Fatalf("New() error: %v", err)
+		}
+		return p
+	}
+	p := newProxy()
+	do := func(p *Proxy, method, path, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do(p, http.MethodPost, "/logs/_search", "alice", buildColdOnlyQuery()); w.Code != http.StatusOK {
+			t.Fatalf("cold search %d = %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+	w := do(p, http.MethodPost, "/logs/_search", "alice", buildColdOnlyQuery())
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "2 of 2 queries") {
+		t.Fatalf("third cold search = %d: %s, want 429", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 response without Retry-After")
+	}
+	if w := do(p, http.MethodPost, "/logs/_search", "alice", buildHotOnlyQuery()); w.Code != http.StatusOK {
+		t.Errorf("hot search over the cold quota = %d, want 200", w.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if w := do(p, http.MethodPost, "/logs/_search", "admin", buildColdOnlyQuery()); w.Code != http.StatusOK {
+			t.Fatalf("exempt cold search = %d", w.Code)
+		}
+	}
+
+	// bob's override allows one hit; the search returning it is served, the
+	// next one is not.
+	if w := do(p, http.MethodPost, "/logs/_search", "bob", buildColdOnlyQuery()); w.Code != http.StatusOK {
+		t.Fatalf("bob's first cold search = %d", w.Code)
+	}
+	if w := do(p, http.MethodPost, "/logs/_search", "bob", buildColdOnlyQuery()); w.Code != http.StatusTooManyRequests {
+		t.Errorf("bob's second cold search = %d, want 429 after the hits limit", w.Code)
+	}
+
+	w = do(p, http.MethodGet, "/_oqbridge/quota", "alice", "")
+	var quota struct {
+		User   string           `json:"user"`
+		Used   map[string]int64 `json:"used"`
+		Limits map[string]int64 `json:"limits"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &quota); err != nil {
+		t.Fatalf("_oqbridge/quota: %v: %s", err, w.Body.String())
+	}
+	if quota.User != "alice" || quota.Used["queries"] != 2 || quota.Used["hits"] != 2 || quota.Limits["queries"] != 2 {
+		t.Errorf("_oqbridge/quota = %+v, want alice with 2 of 2 queries and 2 hits", quota)
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	mu.Lock()
+	day := time.Now().UTC().Format(time.DateOnly)
+	alice := docs["alice:"+day]
+	_, admin := docs["admin:"+day]
+	mu.Unlock()
+	if alice.User != "alice" || alice.Queries != 2 || alice.Hits != 2 || alice.Windows == 0 {
+		t.Errorf("stored usage of alice = %+v, want 2 queries, 2 hits and the days searched", alice)
+	}
+	if admin {
+		t.Error("usage of an exempt user was stored")
+	}
+
+	// Another proxy starts from the stored usage.
+	other := newProxy()
+	defer other.Close(context.Background())
+	if w := do(other, http.MethodPost, "/logs/_search", "alice", buildColdOnlyQuery()); w.Code != http.StatusTooManyRequests {
+		t.Errorf("cold search through another proxy = %d, want 429", w.Code)
+	}
+}
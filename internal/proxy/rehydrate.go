@@ -0,0 +1,377 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// rehydratePath starts and lists rehydrations; rehydratePath/{id} reports
+// one of them.
+const rehydratePath = "/_oqbridge/rehydrate"
+
+// maxRehydrateJobs is how many finished rehydrations are remembered for
+// GET requests; older ones are forgotten first.
+const maxRehydrateJobs = 100
+
+// Rehydration states.
+const (
+	rehydrateRunning   = "running"
+	rehydrateCompleted = "completed"
+	rehydrateFailed    = "failed"
+)
+
+// rehydrateTarget is the OpenSearch side of a rehydration.
+type rehydrateTarget interface {
+	BulkIngest(ctx context.Context, index string, docs []json.RawMessage) error
+	IndexExists(ctx context.Context, index string) (bool, error)
+	CreateIndex(ctx context.Context, index string, timestampField string, retentionDays int) error
+}
+
+// rehydrateRequest is the body of POST /_oqbridge/rehydrate. From and To
+// accept RFC3339 timestamps, dates and "now-30d"; the window is [from, to).
+type rehydrateRequest struct {
+	Index string `json:"index"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// rehydrateJob is the progress of one rehydration, as reported to clients.
+type rehydrateJob struct {
+	ID          string     `json:"id"`
+	Index       string     `json:"index"`
+	TargetIndex string     `json:"target_index"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	User        string     `json:"user"`
+	Status      string     `json:"status"`
+	Total       int64      `json:"total"`
+	Copied      int64      `json:"copied"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// rehydrator copies windows of Quickwit indices back into new OpenSearch
+// indices in the background, so analysts can use every OpenSearch feature
+// on old data. Jobs live in memory and are lost when the proxy restarts;
+// an interrupted rehydration leaves a partial index behind.
+type rehydrator struct {
+	settings config.RehydrateConfig
+	config   func() *config.Config
+	hot      rehydrateTarget
+	cold     migration.RangeReader
+	auth     func(ctx context.Context, h http.Header) (*backend.AuthInfo, error)
+
+	mu   sync.Mutex
+	jobs map[string]*rehydrateJob
+	seq  int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newRehydrator(settings config.RehydrateConfig, cfg func() *config.Config, hot *backend.OpenSearch, cold migration.RangeReader) *rehydrator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &rehydrator{
+		settings: settings,
+		config:   cfg,
+		hot:      hot,
+		cold:     cold,
+		auth:     hot.AuthInfo,
+		jobs:     make(map[string]*rehydrateJob),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// close stops running rehydrations and waits for them until ctx is done.
+func (rh *rehydrator) close(ctx context.Context) error {
+	rh.cancel()
+	done := make(chan struct{})
+	go func() {
+		rh.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRehydratePath reports whether path is rehydratePath, and the job ID
+// that follows it, if any.
+func isRehydratePath(path string) (bool, string) {
+	p := strings.TrimSuffix(path, "/")
+	if p == rehydratePath {
+		return true, ""
+	}
+	if id, ok := strings.CutPrefix(p, rehydratePath+"/"); ok && !strings.Contains(id, "/") {
+		return true, id
+	}
+	return false, ""
+}
+
+// serveHTTP handles the rehydrate API. Every request must come from a user
+// with one of server.rehydrate.admin_roles.
+func (rh *rehydrator) serveHTTP(w http.ResponseWriter, r *http.Request, id string) {
+	info, err := rh.auth(r.Context(), r.Header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for rehydrate", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+	if !hasAnyRole(info, rh.settings.AdminRoles) {
+		slog.Warn("rehydrate denied", "user", info.UserName)
+		http.Error(w, `{"error":"rehydrate requires an admin role"}`, http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		rh.start(w, r, info.UserName)
+	case r.Method == http.MethodGet && id == "":
+		writeJSON(w, map[string]any{"jobs": rh.list()})
+	case r.Method == http.MethodGet:
+		job, ok := rh.get(id)
+		if !ok {
+			http.Error(w, `{"error":"rehydration not found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, job)
+	default:
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// hasAnyRole reports whether the user holds one of roles, as a security
+// role or a backend role.
+func hasAnyRole(info *backend.AuthInfo, roles []string) bool {
+	for _, role := range roles {
+		if slices.Contains(info.Roles, role) || slices.Contains(info.BackendRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// start validates a rehydration, creates its index and copies the window
+// in the background. The client gets the job to poll for progress.
+func (rh *rehydrator) start(w http.ResponseWriter, r *http.Request, user string) {
+	var req rehydrateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"invalid request body","detail":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	cfg := rh.config()
+	from, to, err := parseRehydrateWindow(req, cfg.Location())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"invalid rehydrate request","detail":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	tsField := cfg.TimestampFieldForIndex(req.Index)
+	total, err := rh.cold.CountRange(r.Context(), req.Index, tsField, from, to.Add(-time.Millisecond))
+	if err != nil {
+		slog.Error("failed to count documents to rehydrate", "index", req.Index, "error", err)
+		http.Error(w, fmt.Sprintf(`{"error":"failed to count quickwit documents","detail":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	if total == 0 {
+		http.Error(w, `{"error":"no documents in the window"}`, http.StatusNotFound)
+		return
+	}
+	if total > rh.settings.MaxDocs {
+		http.Error(w, fmt.Sprintf(`{"error":"window too large","detail":"%d documents, server.rehydrate.max_docs is %d"}`, total, rh.settings.MaxDocs), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	target := rehydrateIndexName(rh.settings.IndexPrefix, req.Index, from, to)
+	exists, err := rh.hot.IndexExists(r.Context(), target)
+	if err != nil {
+		slog.Error("failed to check rehydrate index", "index", target, "error", err)
+		http.Error(w, `{"error":"failed to check the target index"}`, http.StatusBadGateway)
+		return
+	}
+	if exists {
+		http.Error(w, fmt.Sprintf(`{"error":"target index already exists","detail":"delete %s to rehydrate the window again"}`, target), http.StatusConflict)
+		return
+	}
+	if err := rh.hot.CreateIndex(r.Context(), target, tsField, 0); err != nil {
+		slog.Error("failed to create rehydrate index", "index", target, "error", err)
+		http.Error(w, fmt.Sprintf(`{"error":"failed to create the target index","detail":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	job := rh.add(&rehydrateJob{
+		Index:       req.Index,
+		TargetIndex: target,
+		From:        from.UTC(),
+		To:          to.UTC(),
+		User:        user,
+		Status:      rehydrateRunning,
+		Total:       total,
+		StartedAt:   time.Now().UTC(),
+	})
+	slog.Info("rehydration started", "id", job.ID, "index", req.Index, "target", target, "from", from, "to", to, "documents", total, "user", user)
+
+	rh.wg.Add(1)
+	go func() {
+		defer rh.wg.Done()
+		rh.run(job.ID, tsField)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// parseRehydrateWindow checks req and returns its window.
+func parseRehydrateWindow(req rehydrateRequest, loc *time.Location) (time.Time, time.Time, error) {
+	if req.Index == "" || strings.ContainsAny(req.Index, "*,") {
+		return time.Time{}, time.Time{}, errors.New("index must name one quickwit index")
+	}
+	if req.From == "" || req.To == "" {
+		return time.Time{}, time.Time{}, errors.New("from and to are required")
+	}
+	from, err := util.ParseTimeExpressionIn(req.From, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("from: %w", err)
+	}
+	to, err := util.ParseTimeExpressionIn(req.To, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("to: %w", err)
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	return from, to, nil
+}
+
+// rehydrateIndexName names the index a window is copied to, e.g.
+// "rehydrated-logs-20260101-20260108".
+func rehydrateIndexName(prefix, index string, from, to time.Time) string {
+	return strings.ToLower(prefix + index + "-" + from.Format("20060102") + "-" + to.Format("20060102"))
+}
+
+// run copies the window of a job, updating its progress after each batch.
+func (rh *rehydrator) run(id, tsField string) {
+	job, _ := rh.get(id)
+	batch := make([]json.RawMessage, 0, rh.settings.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := rh.hot.BulkIngest(rh.ctx, job.TargetIndex, batch); err != nil {
+			return fmt.Errorf("writing to %s: %w", job.TargetIndex, err)
+		}
+		rh.update(id, func(j *rehydrateJob) { j.Copied += int64(len(batch)) })
+		batch = batch[:0]
+		return nil
+	}
+
+	err := migration.ReadRange(rh.ctx, rh.cold, job.Index, tsField, job.From, job.To.Add(-time.Millisecond), func(docs []json.RawMessage) error {
+		for _, doc := range docs {
+			batch = append(batch, doc)
+			if len(batch) >= rh.settings.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	now := time.Now().UTC()
+	rh.update(id, func(j *rehydrateJob) {
+		j.FinishedAt = &now
+		j.Status = rehydrateCompleted
+		if err != nil {
+			j.Status, j.Error = rehydrateFailed, err.Error()
+		}
+	})
+	job, _ = rh.get(id)
+	if err != nil {
+		slog.Error("rehydration failed", "id", id, "index", job.Index, "target", job.TargetIndex, "copied", job.Copied, "error", err)
+		return
+	}
+	slog.Info("rehydration completed", "id", id, "index", job.Index, "target", job.TargetIndex, "copied", job.Copied)
+}
+
+// add registers job under a new ID, forgetting the oldest finished jobs
+// beyond maxRehydrateJobs, and returns a copy of it.
+func (rh *rehydrator) add(job *rehydrateJob) rehydrateJob {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.seq++
+	job.ID = strconv.Itoa(rh.seq)
+	rh.jobs[job.ID] = job
+
+	var finished []*rehydrateJob
+	for _, j := range rh.jobs {
+		if j.FinishedAt != nil {
+			finished = append(finished, j)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].FinishedAt.Before(*finished[b].FinishedAt) })
+	for _, j := range finished[:max(0, len(finished)-maxRehydrateJobs)] {
+		delete(rh.jobs, j.ID)
+	}
+	return *job
+}
+
+func (rh *rehydrator) update(id string, fn func(*rehydrateJob)) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	fn(rh.jobs[id])
+}
+
+func (rh *rehydrator) get(id string) (rehydrateJob, bool) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	j, ok := rh.jobs[id]
+	if !ok {
+		return rehydrateJob{}, false
+	}
+	return *j, true
+}
+
+// list returns the known jobs, newest first.
+func (rh *rehydrator) list() []rehydrateJob {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	jobs := make([]rehydrateJob, 0, len(rh.jobs))
+	for _, j := range rh.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		ia, _ := strconv.Atoi(jobs[a].ID)
+		ib, _ := strconv.Atoi(jobs[b].ID)
+		return ia > ib
+	})
+	return jobs
+}
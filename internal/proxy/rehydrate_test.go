@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration/migrationtest"
+)
+
+func TestProxy_Rehydrate(t *testing.T) {
+	const adminToken = "Basic YWRtaW46YWRtaW4=" // admin:admin
+	var (
+		mu      sync.Mutex
+		created []string
+		bulked  = map[string]int{}
+	)
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/_plugins/_security/authinfo":
+			switch r.Header.Get("Authorization") {
+			case adminToken:
+				w.Write([]byte(`{"user_name":"admin","roles":["own_index"],"backend_roles":["all_access"]}`))
+			case validToken:
+				w.Write([]byte(`{"user_name":"user","roles":["readall"]}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		case r.Method == http.MethodHead:
+			for _, index := range created {
+				if r.URL.Path == "/"+index {
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut:
+			created = append(created, strings.TrimPrefix(r.URL.Path, "/"))
+			w.Write([]byte(`{"acknowledged":true}`))
+		case r.URL.Path == "/_bulk":
+			sc := bufio.NewScanner(r.Body)
+			for sc.Scan() {
+				var action map[string]map[string]string
+				if json.Unmarshal(sc.Bytes(), &action) == nil && action["index"] != nil {
+					bulked[action["index"]["_index"]]++
+				}
+			}
+			w.Write([]byte(`{"errors":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer os.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.Rehydrate = config.RehydrateConfig{Enabled: true, AdminRoles: []string{"all_access"}, IndexPrefix: "rehydrated-", MaxDocs: 50, BatchSize: 10}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// One document per hour.
+	cold := &migrationtest.Range{}
+	for i := range 72 {
+		cold.Times = append(cold.Times, day.Add(time.Duration(i)*time.Hour))
+	}
+	p.rehydrate.cold = cold
+
+	do := func(method, path, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+	window := `{"index":"logs","from":"2026-01-01","to":"2026-01-02T12:00:00Z"}`
+
+	if w := do(http.MethodPost, rehydratePath, validToken, window); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin POST = %d, want 403", w.Code)
+	}
+	if w := do(http.MethodPost, rehydratePath, "bad", window); w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated POST = %d, want 401", w.Code)
+	}
+	if w := do(http.MethodPost, rehydratePath, adminToken, `{"index":"logs","from":"2026-01-01","to":"2026-01-31"}`); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST of a window above max_docs = %d, want 413", w.Code)
+	}
+	if w := do(http.MethodPost, rehydratePath, adminToken, `{"index":"logs-*","from":"2026-01-01","to":"2026-01-02"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("POST with a pattern = %d, want 400", w.Code)
+	}
+
+	w := do(http.MethodPost, rehydratePath, adminToken, window)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST = %d %s, want 202", w.Code, w.Body)
+	}
+	var job rehydrateJob
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if job.TargetIndex != "rehydrated-logs-20260101-20260102" || job.Total != 36 || job.User != "admin" {
+		t.Errorf("job = %+v", job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == rehydrateRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		json.Unmarshal(do(http.MethodGet, rehydratePath+"/"+job.ID, adminToken, "").Body.Bytes(), &job)
+	}
+	if job.Status != rehydrateCompleted || job.Copied != 36 || job.FinishedAt == nil {
+		t.Errorf("finished job = %+v", job)
+	}
+	mu.Lock()
+	if bulked[job.TargetIndex] != 36 {
+		t.Errorf("bulk-indexed %d documents into %s, want 36", bulked[job.TargetIndex], job.TargetIndex)
+	}
+	mu.Unlock()
+
+	if w := do(http.MethodPost, rehydratePath, adminToken, window); w.Code != http.StatusConflict {
+		t.Errorf("POST of the same window again = %d, want 409", w.Code)
+	}
+	var list struct{ Jobs []rehydrateJob }
+	json.Unmarshal(do(http.MethodGet, rehydratePath, adminToken, "").Body.Bytes(), &list)
+	if len(list.Jobs) != 1 || list.Jobs[0].ID != job.ID {
+		t.Errorf("GET jobs = %+v", list.Jobs)
+	}
+	if w := do(http.MethodGet, rehydratePath+"/42", adminToken, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET of an unknown job = %d, want 404", w.Code)
+	}
+}
+
+func TestRehydrateIndexName(t *testing.T) {
+	from := time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC)
+	if got := rehydrateIndexName("rehydrated-", "App-Logs", from, from.AddDate(0, 0, 3)); got != "rehydrated-app-logs-20251230-20260102" {
+		t.Errorf("rehydrateIndexName() = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// WithRemoteCluster sets the Quickwit backend holding the cold indices of
+// the remote_clusters entry name. Remote clusters without one are searched
+// on the proxy's Quickwit backend.
+func WithRemoteCluster(name string, cold ColdBackend) Option {
+	return func(p *Proxy) {
+		if p.remotes == nil {
+			p.remotes = make(map[string]ColdBackend)
+		}
+		p.remotes[name] = cold
+	}
+}
+
+// isRemoteIndex reports whether index is in cross-cluster syntax,
+// "cluster:index".
+func isRemoteIndex(index string) bool {
+	return strings.Contains(index, ":")
+}
+
+// splitRemote separates the local indices from those in cross-cluster
+// syntax, which are returned by remote_clusters entry as Quickwit index
+// names or patterns. Indices of clusters not in remote_clusters are left
+// out: only OpenSearch can search them.
+func (p *Proxy) splitRemote(indices []string) ([]string, map[string][]string) {
+	cfg := p.live.Load().cfg
+	var local []string
+	var remote map[string][]string
+	for _, index := range indices {
+		clusters, name, ok := cfg.RemoteClustersForIndex(index)
+		if !ok {
+			local = append(local, index)
+			continue
+		}
+		if len(clusters) == 0 {
+			slog.Debug("remote cluster not configured, leaving it to opensearch", "index", index)
+		}
+		for _, rc := range clusters {
+			if remote == nil {
+				remote = make(map[string][]string)
+			}
+			remote[rc.Name] = append(remote[rc.Name], rc.IndexPrefix+name)
+		}
+	}
+	return local, remote
+}
+
+// searchColdClusters searches the local Quickwit indices and those of each
+// remote cluster in parallel, each on its own Quickwit backend, and merges
+// the results.
+func (p *Proxy) searchColdClusters(ctx context.Context, local []string, remote map[string][]string, body []byte) (*backend.SearchResponse, error) {
+	type res struct {
+		resp *backend.SearchResponse
+		err  error
+	}
+	ch := make(chan res, len(remote)+1)
+	n := 0
+	if len(local) > 0 {
+		n++
+		go func() {
+			indices, err := p.resolveColdIndices(ctx, local)
+			if err != nil {
+				ch <- res{err: err}
+				return
+			}
+			r, err := p.searchResolvedCold(ctx, p.coldBackend, indices, body)
+			ch <- res{resp: r, err: err}
+		}()
+	}
+	for name, patterns := range remote {
+		cold, ok := p.remotes[name]
+		if !ok {
+			cold = p.coldBackend
+		}
+		n++
+		go func() {
+			indices, err := expandColdWildcards(ctx, cold, patterns)
+			if err != nil {
+				ch <- res{err: err}
+				return
+			}
+			r, err := p.searchResolvedCold(ctx, cold, indices, body)
+			ch <- res{resp: r, err: err}
+		}()
+	}
+
+	var merged *backend.SearchResponse
+	for range n {
+		r := <-ch
+		if r.err != nil {
+			return nil, r.err
+		}
+		merged = MergeSearchResponses(merged, r.resp)
+	}
+	return merged, nil
+}
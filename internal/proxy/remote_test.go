@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_RouteForIndices_RemoteClusters(t *testing.T) {
+	p := newTestProxy(t, "http://os:9200", "http://qw:7280")
+	cfg := *p.live.Load().cfg
+	cfg.RemoteClusters = []config.RemoteClusterConfig{{Name: "europe", RetentionDays: 120}, {Name: "emea"}}
+	p.SetConfig(&cfg)
+
+	body := []byte(buildColdOnlyQuery())
+	tests := []struct {
+		index string
+		want  RouteTarget
+	}{
+		{"europe:logs", RouteHotOnly}, // within its 120 days
+		{"emea:logs", RouteColdOnly},
+		{"asia:logs", RouteHotOnly}, // not configured: only OpenSearch knows it
+		{"e*:logs", RouteBoth},
+	}
+	for _, tt := range tests {
+		if got := p.routeForIndices(body, []string{tt.index}); got != tt.want {
+			t.Errorf("%s: route = %v, want %v", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestProxy_RemoteClusterColdSearch(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	var mu sync.Mutex
+	var searched []string
+	quickwit := func(indices ...string) *httptest.Server {
+		mock := newMockQuickwitWithIndices(t, indices)
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				mu.Lock()
+				searched = append(searched, r.URL.Path)
+				mu.Unlock()
+			}
+			mock.Config.Handler.ServeHTTP(w, r)
+		}))
+	}
+	qw := quickwit("logs", "eu-logs-1")
+	defer qw.Close()
+	eu := quickwit("eu-logs-1", "eu-logs-2")
+	defer eu.Close()
+
+	cfg := &config.Config{
+		OpenSearch:       config.OpenSearchConfig{URL: os.URL},
+		Quickwit:         config.QuickwitConfig{URL: qw.URL},
+		QuickwitClusters: []config.QuickwitCluster{{Name: "eu", Indices: []string{"eu-*"}, Quickwit: config.QuickwitConfig{URL: eu.URL}}},
+		Retention:        config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+		RemoteClusters:   []config.RemoteClusterConfig{{Name: "europe", QuickwitCluster: "eu", IndexPrefix: "eu-"}},
+	}
+	euCold := backend.NewQuickwit(eu.URL, "", "", false, nil)
+	cold := backend.NewQuickwitRouter(backend.NewQuickwit(qw.URL, "", "", false, nil), map[string]*backend.Quickwit{"eu": euCold}, cfg.QuickwitClusterForIndex)
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), cold, nil, WithRemoteCluster("europe", euCold))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/logs,europe:logs-*,asia:logs/_search", strings.NewReader(buildColdOnlyQuery()))
+	req.Header.Set("Authorization", validToken)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp backend.SearchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	// asia is not configured, so OpenSearch is searched for it.
+	if resp.Hits.Total.Value != 4 {
+		t.Errorf("total = %d, want one hit from opensearch, logs and each europe index", resp.Hits.Total.Value)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	slices.Sort(searched)
+	want := []string{"/api/v1/eu-logs-1/search", "/api/v1/eu-logs-2/search", "/api/v1/logs/search"}
+	if !slices.Equal(searched, want) {
+		t.Errorf("quickwit searches = %v, want %v", searched, want)
+	}
+}
@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// resolveIndexPath is OpenSearch's _resolve/index, to whose indices the
+// proxy adds the matching Quickwit indices.
+const resolveIndexPath = "/_resolve/index"
+
+// handleResolveIndex answers GET /_resolve/index/{patterns} with the
+// resolution of OpenSearch, read as the caller, and the Quickwit indices
+// matching the patterns that OpenSearch does not have, so that index
+// patterns of Dashboards find indices only kept in Quickwit. Quickwit
+// indices are listed as open, without aliases, under the name of the index
+// migrated into them.
+func (p *Proxy) handleResolveIndex(w http.ResponseWriter, r *http.Request, patterns []string) {
+	ctx := r.Context()
+	failover := p.failover.active()
+
+	resolved := map[string]json.RawMessage{}
+	hotErr := errors.New("opensearch is unavailable")
+	if !failover {
+		var body []byte
+		body, hotErr = p.hotBackend.GetAs(ctx, r.URL.Path, r.URL.RawQuery, r.Header)
+		if hotErr == nil {
+			if err := json.Unmarshal(body, &resolved); err != nil {
+				http.Error(w, `{"error":"failed to decode the opensearch _resolve/index response"}`, http.StatusBadGateway)
+				return
+			}
+		}
+	}
+	switch status := httpStatus(hotErr); {
+	case hotErr == nil:
+	case status == http.StatusNotFound || failover:
+		// The indices asked for may only be in Quickwit.
+		if err := p.authenticateViaOpenSearch(ctx, r.Header); err != nil {
+			status := http.StatusBadGateway
+			if isAuthError(err) {
+				status = statusFromAuthError(err)
+			}
+			slog.Warn("auth failed for _resolve/index", "status", status, "error", err)
+			http.Error(w, `{"error":"authentication failed"}`, status)
+			return
+		}
+	default:
+		writeBackendError(w, hotErr)
+		return
+	}
+
+	var indices []map[string]any
+	if raw, ok := resolved["indices"]; ok {
+		if err := json.Unmarshal(raw, &indices); err != nil {
+			http.Error(w, `{"error":"failed to decode the opensearch _resolve/index response"}`, http.StatusBadGateway)
+			return
+		}
+	}
+	names := make(map[string]bool, len(indices))
+	for _, index := range indices {
+		names[fmt.Sprint(index["name"])] = true
+	}
+
+	// Remote clusters resolve their own indices.
+	local := slices.DeleteFunc(slices.Clone(patterns), isRemoteIndex)
+	var cold []string
+	if len(local) > 0 {
+		resolved, err := p.coldIndexNames(ctx, local)
+		if err != nil {
+			slog.Error("failed to resolve quickwit indices for _resolve/index", "error", err)
+		}
+		for _, index := range resolved {
+			if !names[index] {
+				cold = append(cold, index)
+			}
+		}
+	}
+	if hotErr != nil && len(cold) == 0 {
+		if failover {
+			http.Error(w, failoverUnavailable, http.StatusServiceUnavailable)
+			return
+		}
+		writeBackendError(w, hotErr)
+		return
+	}
+
+	for _, index := range cold {
+		indices = append(indices, map[string]any{"name": index, "attributes": []string{"open"}})
+	}
+	slices.SortFunc(indices, func(a, b map[string]any) int {
+		return strings.Compare(fmt.Sprint(a["name"]), fmt.Sprint(b["name"]))
+	})
+	if indices == nil {
+		indices = []map[string]any{}
+	}
+	resolved["indices"], _ = json.Marshal(indices)
+	for _, key := range []string{"aliases", "data_streams"} {
+		if _, ok := resolved[key]; !ok {
+			resolved[key] = json.RawMessage(`[]`)
+		}
+	}
+	writeJSON(w, resolved)
+}
+
+// coldIndexNames resolves indices to the Quickwit indices holding them, like
+// resolveColdIndices, and returns those that exist under the names
+// OpenSearch knows them by: an index migrated under another name
+// (migration.rules target_index) is returned as itself. A Quickwit index
+// matched through a pattern whose indices share it keeps its own name.
+func (p *Proxy) coldIndexNames(ctx context.Context, indices []string) ([]string, error) {
+	resolved, err := p.resolveColdIndices(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	all, err := p.coldBackend.ListIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := p.live.Load().cfg
+	expanded := p.aliases.expand(ctx, indices)
+	var names []string
+	for _, qw := range resolved {
+		if !slices.Contains(all, qw) {
+			continue
+		}
+		name := qw
+		for _, index := range expanded {
+			if n, ok := openSearchIndexName(cfg, index, qw); ok {
+				name = n
+				break
+			}
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// openSearchIndexName returns the name OpenSearch knows the Quickwit index
+// qw by, and whether qw holds index or an index matching it.
+func openSearchIndexName(cfg *config.Config, index, qw string) (string, bool) {
+	target := cfg.QuickwitIndexForIndex(index)
+	if !hasWildcard([]string{index}) {
+		return index, target == qw
+	}
+	if target == index {
+		return qw, util.MatchWildcard(index, qw)
+	}
+	// A target_index with "{index}" wraps each name matching the pattern.
+	i := strings.Index(target, index)
+	if i < 0 {
+		return qw, util.MatchWildcard(target, qw)
+	}
+	prefix, suffix := target[:i], target[i+len(index):]
+	if len(qw) < len(prefix)+len(suffix) || !strings.HasPrefix(qw, prefix) || !strings.HasSuffix(qw, suffix) {
+		return "", false
+	}
+	name := qw[len(prefix) : len(qw)-len(suffix)]
+	return name, util.MatchWildcard(index, name) && cfg.QuickwitIndexForIndex(name) == qw
+}
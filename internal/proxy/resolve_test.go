@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_ResolveIndex(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, resolveIndexPath) {
+			osHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/_resolve/index/logs*" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+			return
+		}
+		w.Write([]byte(`{"indices":[{"name":"logs-2026.10.15","aliases":["logs-current"],"attributes":["open"]}],"aliases":[{"name":"logs-current","indices":["logs-2026.10.15"]}],"data_streams":[]}`))
+	})
+	qwSrv := newMockQuickwitWithIndices(t, []string{"logs-2026.10.15", "logs-archive", "audit"})
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+
+	resolve := func(path, auth string) (*httptest.ResponseRecorder, []string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		var resp struct {
+			Indices []struct {
+				Name string `json:"name"`
+			} `json:"indices"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		var names []string
+		for _, index := range resp.Indices {
+			names = append(names, index.Name)
+		}
+		return rec, names
+	}
+
+	rec, names := resolve("/_resolve/index/logs*", validToken)
+	if rec.Code != http.StatusOK || strings.Join(names, ",") != "logs-2026.10.15,logs-archive" || !strings.Contains(rec.Body.String(), `"aliases":[{"name":"logs-current"`) {
+		t.Errorf("logs*: %d %s", rec.Code, rec.Body)
+	}
+
+	// An index only in Quickwit resolves instead of OpenSearch's 404.
+	if rec, names := resolve("/_resolve/index/audit", validToken); rec.Code != http.StatusOK || strings.Join(names, ",") != "audit" || !strings.Contains(rec.Body.String(), `"data_streams":[]`) {
+		t.Errorf("audit: %d %s", rec.Code, rec.Body)
+	}
+	if rec, _ := resolve("/_resolve/index/missing", validToken); rec.Code != http.StatusNotFound {
+		t.Errorf("missing: %d, want 404", rec.Code)
+	}
+	if rec, _ := resolve("/_resolve/index/logs*", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: %d, want 401", rec.Code)
+	}
+}
+
+func TestProxy_ResolveIndex_MigrationTargetIndex(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	osHandler := osSrv.Config.Handler
+	osSrv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, resolveIndexPath) {
+			osHandler.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+	})
+	qwSrv := newMockQuickwitWithIndices(t, []string{"archive-audit-2026.01.01", "audit-2026.01.02"})
+	defer qwSrv.Close()
+	p := newTestProxy(t, osSrv.URL, qwSrv.URL)
+	cfg := *p.live.Load().cfg
+	cfg.Migration.Rules = []config.MigrationRule{{Indices: []string{"audit-*"}, TargetIndex: "archive-{index}"}}
+	p.SetConfig(&cfg)
+
+	// Indices are listed as OpenSearch knows them, not as migrated.
+	for path, want := range map[string]string{
+		"/_resolve/index/audit-*":          `[{"attributes":["open"],"name":"audit-2026.01.01"}]`,
+		"/_resolve/index/audit-2026.01.01": `[{"attributes":["open"],"name":"audit-2026.01.01"}]`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", validToken)
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		var resp map[string]json.RawMessage
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || string(resp["indices"]) != want {
+			t.Errorf("%s: %d %s, want indices %s", path, rec.Code, rec.Body, want)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/_resolve/index/audit-2026.01.02", nil)
+	req.Header.Set("Authorization", validToken)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("index not migrated to its target: %d %s, want 404", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// retentionPath shows and changes retention.days and
+// migration.migrate_after_days at runtime (retention.runtime).
+const retentionPath = "/_oqbridge/retention"
+
+// RetentionScheduler changes the retention of every instance, e.g. a
+// migration.RetentionSync.
+type RetentionScheduler interface {
+	Current() (applied, pending *migration.RetentionOverride)
+	Schedule(ctx context.Context, days, migrateAfterDays int, user string) (*migration.RetentionOverride, error)
+}
+
+// WithRetentionScheduler serves _oqbridge/retention, changing retention
+// through s.
+func WithRetentionScheduler(s RetentionScheduler) Option {
+	return func(p *Proxy) {
+		p.retention = s
+	}
+}
+
+// retentionChange is the body of PUT _oqbridge/retention. Omitted or zero
+// values keep the configured value.
+type retentionChange struct {
+	Days             int `json:"days"`
+	MigrateAfterDays int `json:"migrate_after_days"`
+}
+
+// handleRetention shows the retention in effect with GET, changes it with
+// PUT and reverts it to the configuration file with DELETE. Changes take
+// effect retention.runtime.delay later and need one of
+// retention.runtime.admin_roles.
+func (p *Proxy) handleRetention(w http.ResponseWriter, r *http.Request) {
+	var change retentionChange
+	switch r.Method {
+	case http.MethodGet:
+		cfg := p.Config()
+		applied, pending := p.retention.Current()
+		writeJSON(w, map[string]any{
+			"days":               cfg.Retention.Days,
+			"migrate_after_days": cfg.Migration.MigrateAfterDays,
+			"override":           applied,
+			"pending":            pending,
+		})
+		return
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(body, &change); err != nil {
+			http.Error(w, `{"error":"request body must be {\"days\":N,\"migrate_after_days\":N}"}`, http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := p.authInfo(r.Context(), r.Header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for retention change", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+	if !hasAnyRole(info, p.Config().Retention.Runtime.AdminRoles) {
+		slog.Warn("retention change denied", "user", info.UserName)
+		http.Error(w, `{"error":"changing retention requires an admin role"}`, http.StatusForbidden)
+		return
+	}
+
+	o, err := p.retention.Schedule(r.Context(), change.Days, change.MigrateAfterDays, info.UserName)
+	if errors.Is(err, migration.ErrInvalidRetention) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.Error("failed to change retention", "error", err)
+		http.Error(w, `{"error":"failed to store the retention change"}`, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"pending": o})
+}
+
+// isRetentionPath reports whether path is that of the retention API.
+func isRetentionPath(path string) bool {
+	return strings.TrimSuffix(path, "/") == retentionPath
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+type fakeRetentionScheduler struct {
+	applied, pending *migration.RetentionOverride
+}
+
+func (s *fakeRetentionScheduler) Current() (applied, pending *migration.RetentionOverride) {
+	return s.applied, s.pending
+}
+
+func (s *fakeRetentionScheduler) Schedule(ctx context.Context, days, migrateAfterDays int, user string) (*migration.RetentionOverride, error) {
+	if days != 0 && days <= migrateAfterDays {
+		return nil, fmt.Errorf("%w: migration.migrate_after_days must be less than retention.days", migration.ErrInvalidRetention)
+	}
+	s.pending = &migration.RetentionOverride{Days: days, MigrateAfterDays: migrateAfterDays, EffectiveAt: time.Now().Add(time.Minute), UpdatedBy: user}
+	return s.pending, nil
+}
+
+func TestProxy_Retention(t *testing.T) {
+	osSrv := newMockOpenSearch(t)
+	defer osSrv.Close()
+	qwSrv := newMockQuickwit(t)
+	defer qwSrv.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: osSrv.URL},
+		Quickwit:   config.QuickwitConfig{URL: qwSrv.URL},
+		Retention: config.RetentionConfig{
+			Days:           30,
+			TimestampField: "@timestamp",
+			Runtime:        config.RetentionRuntimeConfig{Enabled: true, AdminRoles: []string{"admin"}},
+		},
+		Migration: config.MigrationConfig{MigrateAfterDays: 7},
+	}
+	scheduler := &fakeRetentionScheduler{applied: &migration.RetentionOverride{Days: 30}}
+	p, err := New(cfg, backend.NewOpenSearch(osSrv.URL, "", "", nil), backend.NewQuickwit(qwSrv.URL, "", "", false, nil), nil, WithRetentionScheduler(scheduler))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	serve := func(method, body, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, retentionPath, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"days":30,"migrate_after_days":7,"override":{"days":30`) || !strings.Contains(rec.Body.String(), `"pending":null`) {
+		t.Errorf("GET: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(http.MethodPut, `{"days":20}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("PUT without credentials: %d, want 401", rec.Code)
+	}
+	if rec := serve(http.MethodPut, `{"days":`, validToken); rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT with a malformed body: %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodPut, `{"days":5,"migrate_after_days":10}`, validToken); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "must be less than retention.days") {
+		t.Errorf("PUT of an invalid retention: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(http.MethodPut, `{"days":20,"migrate_after_days":5}`, validToken)
+	if rec.Code != http.StatusAccepted || scheduler.pending.Days != 20 || scheduler.pending.MigrateAfterDays != 5 {
+		t.Errorf("PUT: %d %s, pending %+v", rec.Code, rec.Body, scheduler.pending)
+	}
+	if rec := serve(http.MethodDelete, "", validToken); rec.Code != http.StatusAccepted || scheduler.pending.Days != 0 {
+		t.Errorf("DELETE: %d %s, pending %+v", rec.Code, rec.Body, scheduler.pending)
+	}
+	if rec := serve(http.MethodPost, "", validToken); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: %d, want 405", rec.Code)
+	}
+
+	cfg.Retention.Runtime.AdminRoles = []string{"retention_admin"}
+	if rec := serve(http.MethodPut, `{"days":20}`, validToken); rec.Code != http.StatusForbidden {
+		t.Errorf("PUT without an admin role: %d, want 403", rec.Code)
+	}
+}
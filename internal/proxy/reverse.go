@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// statusClientClosedRequest is logged and returned when the client goes
+// away before OpenSearch answers, following the nginx convention.
+const statusClientClosedRequest = 499
+
+// newReverseProxy builds the OpenSearch passthrough proxy. Unless
+// rc.RetryNonIdempotent, it removes the idempotency keys of non-idempotent
+// requests, which OpenSearch then never sees.
+func newReverseProxy(rc config.ReverseProxyConfig, target *url.URL, transport http.RoundTripper) *httputil.ReverseProxy {
+	rp := httputil.NewSingleHostReverseProxy(target)
+	if transport != nil {
+		rp.Transport = transport
+	}
+	rp.FlushInterval = rc.FlushInterval
+	if rc.BufferSizeKB > 0 {
+		rp.BufferPool = newBufferPool(rc.BufferSizeKB << 10)
+	}
+	rp.ErrorHandler = writeUpstreamError
+
+	originalDirector := rp.Director
+	rp.Director = func(req *http.Request) {
+		originalDirector(req)
+		// Do NOT override the client's auth header — let OpenSearch validate
+		// the original user credentials. The config's username/password is only
+		// used by the backend clients for internal operations.
+
+		if !rc.RetryNonIdempotent && !isIdempotent(req.Method) {
+			// http.Transport replays a request on a broken keep-alive
+			// connection if it carries an idempotency key; a replayed
+			// _bulk or _update could apply twice.
+			req.Header.Del("Idempotency-Key")
+			req.Header.Del("X-Idempotency-Key")
+		}
+	}
+	return rp
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// writeUpstreamError answers a failed passthrough request with an
+// OpenSearch-style error body instead of httputil's empty 502.
+func writeUpstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Context().Err() != nil && errors.Is(err, context.Canceled) {
+		slog.Debug("client closed request before opensearch answered", "method", r.Method, "path", r.URL.Path)
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+
+	status, errType := http.StatusBadGateway, "bad_gateway"
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		status, errType = http.StatusGatewayTimeout, "gateway_timeout"
+	}
+	slog.Warn("opensearch passthrough failed", "method", r.Method, "path", r.URL.Path, "status", status, "error", err)
+
+	reason := "oqbridge could not reach OpenSearch: " + err.Error()
+	cause := map[string]string{"type": errType, "reason": reason}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, map[string]interface{}{
+		"error": map[string]interface{}{
+			"root_cause": []map[string]string{cause},
+			"type":       errType,
+			"reason":     reason,
+		},
+		"status": status,
+	})
+}
+
+// bufferPool reuses the buffers httputil.ReverseProxy copies response
+// bodies through, instead of allocating one per request.
+type bufferPool struct {
+	pool sync.Pool
+}
+
+func newBufferPool(size int) *bufferPool {
+	return &bufferPool{pool: sync.Pool{New: func() any { return make([]byte, size) }}}
+}
+
+func (b *bufferPool) Get() []byte    { return b.pool.Get().([]byte) }
+func (b *bufferPool) Put(buf []byte) { b.pool.Put(buf) }
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestReverseProxy_UpstreamDown_ReturnsOpenSearchStyleError(t *testing.T) {
+	// Reserve a port, then close it so connections are refused.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	target, _ := url.Parse(dead.URL)
+	dead.Close()
+
+	rp := newReverseProxy(config.ReverseProxyConfig{BufferSizeKB: 32}, target, nil)
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_cluster/health", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status=%d, want 502", rec.Code)
+	}
+	var body struct {
+		Error struct {
+			Type      string `json:"type"`
+			Reason    string `json:"reason"`
+			RootCause []struct {
+				Type string `json:"type"`
+			} `json:"root_cause"`
+		} `json:"error"`
+		Status int `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	if body.Status != 502 || body.Error.Type != "bad_gateway" || len(body.Error.RootCause) != 1 || body.Error.Reason == "" {
+		t.Fatalf("unexpected error body: %s", rec.Body.String())
+	}
+}
+
+func TestReverseProxy_StripsIdempotencyKeyFromNonIdempotentRequests(t *testing.T) {
+	got := map[string]string{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got[r.Method] = r.Header.Get("Idempotency-Key")
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	for _, rc := range []config.ReverseProxyConfig{{}, {RetryNonIdempotent: true}} {
+		got = map[string]string{}
+		rp := newReverseProxy(rc, target, nil)
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			req := httptest.NewRequest(method, "/_bulk", strings.NewReader("{}\n"))
+			req.Header.Set("Idempotency-Key", "k1")
+			rp.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		if got[http.MethodGet] != "k1" {
+			t.Fatalf("retry_non_idempotent=%v: GET key=%q, want it kept", rc.RetryNonIdempotent, got[http.MethodGet])
+		}
+		if want := map[bool]string{false: "", true: "k1"}[rc.RetryNonIdempotent]; got[http.MethodPost] != want {
+			t.Fatalf("retry_non_idempotent=%v: POST key=%q, want %q", rc.RetryNonIdempotent, got[http.MethodPost], want)
+		}
+	}
+}
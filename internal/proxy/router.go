@@ -1,8 +1,14 @@
 package proxy
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/leonunix/oqbridge/internal/config"
 	"github.com/leonunix/oqbridge/internal/util"
 )
 
@@ -28,62 +34,159 @@ func (r RouteTarget) String() string {
 	}
 }
 
-// Router determines the query routing target based on time range analysis.
-type Router struct {
+// RouteRequest is a search of one index, or of the indices matching a
+// pattern, to route.
+type RouteRequest struct {
+	Index          string    // Index, alias or pattern searched.
+	Body           []byte    // Search body, possibly empty.
+	TimestampField string    // Timestamp field of Index.
+	Days           []int     // Age in days at which data leaves each tier but the last, youngest tier first.
+	Now            time.Time // Time the search is routed as of.
+}
+
+// Router decides which tiers a search reaches. Span returns an entry for
+// each tier of req.Days, youngest first, and a last one for Quickwit.
+// Routers are shared by concurrent requests.
+type Router interface {
+	Span(req RouteRequest) []bool
+}
+
+// RouterFactory creates the Router of a configuration. It is called again
+// with the new configuration when it is reloaded.
+type RouterFactory func(cfg *config.Config) (Router, error)
+
+var (
+	routersMu sync.RWMutex
+	routers   = map[string]RouterFactory{
+		"time_range": func(cfg *config.Config) (Router, error) {
+			return NewRouterIn(cfg.Retention.Days, cfg.RoutingLocation()), nil
+		},
+	}
+)
+
+// RegisterRouter makes a Router available as server.router.name, e.g. from
+// the init function of a package linked into the proxy. It panics if name
+// is empty or already registered.
+func RegisterRouter(name string, factory RouterFactory) {
+	routersMu.Lock()
+	defer routersMu.Unlock()
+	if name == "" || factory == nil {
+		panic("proxy: RegisterRouter needs a name and a factory")
+	}
+	if _, ok := routers[name]; ok {
+		panic("proxy: router " + name + " registered twice")
+	}
+	routers[name] = factory
+}
+
+// Routers returns the names of the registered routers, in sorted order.
+func Routers() []string {
+	routersMu.RLock()
+	defer routersMu.RUnlock()
+	return slices.Sorted(maps.Keys(routers))
+}
+
+// newRouter creates the router cfg selects with server.router.name,
+// time_range if unset.
+func newRouter(cfg *config.Config) (Router, error) {
+	name := cfg.Server.Router.Name
+	if name == "" {
+		name = "time_range"
+	}
+	routersMu.RLock()
+	factory, ok := routers[name]
+	routersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("server.router: unknown router %q, registered: %s", name, strings.Join(Routers(), ", "))
+	}
+	r, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("server.router: %s: %w", name, err)
+	}
+	return r, nil
+}
+
+// TimeRangeRouter is the default Router: it compares the time range a
+// query filters on with the cutoff of each tier.
+type TimeRangeRouter struct {
 	retentionDays int
+	loc           *time.Location
+}
+
+// NewRouter creates a TimeRangeRouter with the given retention threshold,
+// whose cutoffs lie exactly that many days before the search.
+func NewRouter(retentionDays int) *TimeRangeRouter {
+	return NewRouterIn(retentionDays, nil)
+}
+
+// NewRouterIn is NewRouter with cutoffs on midnight in loc, where daily
+// indices roll over. A nil loc keeps the cutoffs of NewRouter.
+func NewRouterIn(retentionDays int, loc *time.Location) *TimeRangeRouter {
+	return &TimeRangeRouter{retentionDays: retentionDays, loc: loc}
 }
 
-// NewRouter creates a new Router with the given retention threshold.
-func NewRouter(retentionDays int) *Router {
-	return &Router{retentionDays: retentionDays}
+// Span implements Router.
+func (r *TimeRangeRouter) Span(req RouteRequest) []bool {
+	return r.TierSpanAt(req.Body, req.TimestampField, req.Days, req.Now)
 }
 
 // Route analyzes the query body and decides where to send it.
-func (r *Router) Route(body []byte, timestampField string) RouteTarget {
-	tr := util.ExtractTimeRange(body, timestampField)
-	if tr == nil {
-		// Cannot determine time range — query both backends to be safe.
+func (r *TimeRangeRouter) Route(body []byte, timestampField string) RouteTarget {
+	return r.RouteWithin(body, timestampField, r.retentionDays)
+}
+
+// RouteWithin is Route for an index that keeps retentionDays of data in
+// OpenSearch instead of the Router's default.
+func (r *TimeRangeRouter) RouteWithin(body []byte, timestampField string, retentionDays int) RouteTarget {
+	span := r.TierSpan(body, timestampField, []int{retentionDays})
+	switch {
+	case span[0] && span[1]:
 		return RouteBoth
+	case span[1]:
+		return RouteColdOnly
 	}
+	return RouteHotOnly
+}
 
-	cutoff := time.Now().UTC().AddDate(0, 0, -r.retentionDays)
-
-	hasHot := false
-	hasCold := false
+// TierSpan reports which tiers of a chain the query's time range reaches.
+// days holds the age in days at which data leaves each tier but the last,
+// youngest tier first; the result has an entry for each tier, the last one
+// for Quickwit. Without a time range every tier is reached.
+func (r *TimeRangeRouter) TierSpan(body []byte, timestampField string, days []int) []bool {
+	return r.TierSpanAt(body, timestampField, days, time.Now())
+}
 
-	// Determine if the range overlaps with hot or cold tiers.
-	if tr.From != nil && tr.To != nil {
-		if tr.To.Before(cutoff) {
-			hasCold = true
-		} else if tr.From.After(cutoff) || tr.From.Equal(cutoff) {
-			hasHot = true
-		} else {
-			hasHot = true
-			hasCold = true
+// TierSpanAt is TierSpan as of now.
+func (r *TimeRangeRouter) TierSpanAt(body []byte, timestampField string, days []int, now time.Time) []bool {
+	span := make([]bool, len(days)+1)
+	tr := util.ExtractTimeRangeAt(body, timestampField, now)
+	for i := range span {
+		if tr == nil {
+			// Cannot determine time range — query every tier to be safe.
+			span[i] = true
+			continue
 		}
-	} else if tr.From != nil {
-		// Open-ended to the future.
-		if tr.From.Before(cutoff) {
-			hasHot = true
-			hasCold = true
-		} else {
-			hasHot = true
+		// Tier i holds data from its cutoff up to the cutoff of the tier
+		// before it.
+		if i < len(days) && tr.To != nil && tr.To.Before(cutoffAt(r.loc, days[i], now)) {
+			continue
 		}
-	} else if tr.To != nil {
-		// Open-ended to the past.
-		if tr.To.Before(cutoff) {
-			hasCold = true
-		} else {
-			hasHot = true
-			hasCold = true
+		if i > 0 && tr.From != nil && !tr.From.Before(cutoffAt(r.loc, days[i-1], now)) {
+			continue
 		}
+		span[i] = true
 	}
+	return span
+}
 
-	if hasHot && hasCold {
-		return RouteBoth
-	}
-	if hasCold {
-		return RouteColdOnly
+// cutoffAt returns the start of the oldest day in loc within the given
+// number of days before now. OpenSearch drops whole daily indices, rolling
+// over at midnight in loc; without loc, the cutoff is exactly days before
+// now.
+func cutoffAt(loc *time.Location, days int, now time.Time) time.Time {
+	if loc == nil {
+		return now.AddDate(0, 0, -days)
 	}
-	return RouteHotOnly
+	y, m, d := now.In(loc).AddDate(0, 0, -days).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
 }
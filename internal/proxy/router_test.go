@@ -2,8 +2,13 @@ package proxy
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
 )
 
 func TestRouter_Route(t *testing.T) {
@@ -79,3 +84,147 @@ func TestRouteTarget_String(t *testing.T) {
 		}
 	}
 }
+
+func TestRouter_RouteWithin(t *testing.T) {
+	router := NewRouter(30)
+	from := time.Now().UTC().Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	body := []byte(fmt.Sprintf(`{"query": {"range": {"@timestamp": {"gte": "%s"}}}}`, from))
+
+	if got := router.Route(body, "@timestamp"); got != RouteHotOnly {
+		t.Errorf("Route() = %v, want hot only", got)
+	}
+	if got := router.RouteWithin(body, "@timestamp", 7); got != RouteBoth {
+		t.Errorf("RouteWithin(7 days) = %v, want both", got)
+	}
+}
+
+func TestRouter_RouteIn(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	router := NewRouterIn(30, loc)
+	y, m, d := time.Now().In(loc).AddDate(0, 0, -30).Date()
+	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name string
+		from time.Time
+		want RouteTarget
+	}{
+		{"start of the oldest hot day", startOfDay, RouteHotOnly},
+		{"just before it", startOfDay.Add(-time.Minute), RouteBoth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(fmt.Sprintf(`{"query": {"range": {"@timestamp": {"gte": "%s"}}}}`, tt.from.Format(time.RFC3339)))
+			if got := router.Route(body, "@timestamp"); got != tt.want {
+				t.Errorf("Route() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_RouteRolling(t *testing.T) {
+	// Without a time zone, the cutoff is exactly 30 days before the search.
+	router := NewRouter(30)
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, 0, -30)
+	for from, want := range map[time.Time]string{
+		cutoff:                   "[true false]",
+		cutoff.Add(-time.Minute): "[true true]",
+	} {
+		body := []byte(fmt.Sprintf(`{"query": {"range": {"@timestamp": {"gte": "%s"}}}}`, from.Format(time.RFC3339)))
+		if got := fmt.Sprint(router.TierSpanAt(body, "@timestamp", []int{30}, now)); got != want {
+			t.Errorf("from %s: TierSpanAt() = %s, want %s", from, got, want)
+		}
+	}
+}
+
+func TestRouter_TierSpan(t *testing.T) {
+	router := NewRouter(7)
+	now := time.Now().UTC()
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	rangeBody := func(from, to string) []byte {
+		return []byte(fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lte":%q}}}}`, from, to))
+	}
+
+	// hot: 7 days, warm: until 30 days, cold: older.
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"hot only", rangeBody(ago(1), ago(0)), "[true false false]"},
+		{"warm only", rangeBody(ago(20), ago(10)), "[false true false]"},
+		{"cold only", rangeBody(ago(90), ago(60)), "[false false true]"},
+		{"warm and cold", rangeBody(ago(180), ago(10)), "[false true true]"},
+		{"every tier", rangeBody(ago(180), ago(0)), "[true true true]"},
+		{"no time range", []byte(`{"query":{"match_all":{}}}`), "[true true true]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(router.TierSpan(tt.body, "@timestamp", []int{7, 30})); got != tt.want {
+			t.Errorf("%s: TierSpan() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// archiveRouter sends searches of archive-* indices to Quickwit only,
+// whatever their time range, and routes the rest by time range.
+type archiveRouter struct{ fallback Router }
+
+func (r archiveRouter) Span(req RouteRequest) []bool {
+	if strings.HasPrefix(req.Index, "archive-") {
+		span := make([]bool, len(req.Days)+1)
+		span[len(span)-1] = true
+		return span
+	}
+	return r.fallback.Span(req)
+}
+
+func init() {
+	RegisterRouter("test_archive", func(cfg *config.Config) (Router, error) {
+		if cfg.Server.Router.Options["prefix"] != "archive-" {
+			return nil, fmt.Errorf("prefix must be archive-")
+		}
+		return archiveRouter{fallback: NewRouterIn(cfg.Retention.Days, cfg.RoutingLocation())}, nil
+	})
+}
+
+func TestRegisterRouter(t *testing.T) {
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: "http://os:9200"},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+		Server: config.ServerConfig{Router: config.RouterConfig{
+			Name:    "test_archive",
+			Options: map[string]string{"prefix": "archive-"},
+		}},
+	}
+	p, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	recent := fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q}}}}`, time.Now().Add(-time.Hour).Format(time.RFC3339))
+	if route, _ := p.RouteSearch([]string{"archive-2020"}, []byte(recent)); route != "cold_only" {
+		t.Errorf("RouteSearch(archive-2020) = %s, want cold_only", route)
+	}
+	if route, _ := p.RouteSearch([]string{"logs"}, []byte(recent)); route != "hot_only" {
+		t.Errorf("RouteSearch(logs) = %s, want hot_only", route)
+	}
+
+	// A reload the router rejects keeps the previous router.
+	bad := *cfg
+	bad.Server.Router.Options = nil
+	p.SetConfig(&bad)
+	if route, _ := p.RouteSearch([]string{"archive-2020"}, []byte(recent)); route != "cold_only" {
+		t.Errorf("RouteSearch() after a rejected reload = %s, want cold_only", route)
+	}
+
+	if _, err := New(&bad, backend.NewOpenSearch("http://os:9200", "", "", nil), nil, nil); err == nil || !strings.Contains(err.Error(), "prefix must be") {
+		t.Errorf("New() with options the router rejects error = %v", err)
+	}
+	bad.Server.Router.Name = "catalog"
+	if _, err := New(&bad, backend.NewOpenSearch("http://os:9200", "", "", nil), nil, nil); err == nil || !strings.Contains(err.Error(), `unknown router "catalog"`) {
+		t.Errorf("New() with an unregistered router error = %v", err)
+	}
+	if names := Routers(); !slices.Contains(names, "time_range") || !slices.Contains(names, "test_archive") {
+		t.Errorf("Routers() = %v", names)
+	}
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/expr"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// ruleRequest is the client request routing rules are evaluated against.
+// Without one, e.g. in RouteSearch, user, roles and headers are empty.
+type ruleRequest struct {
+	ctx    context.Context
+	header http.Header
+	lookup func(ctx context.Context, h http.Header) (*backend.AuthInfo, error)
+
+	info    *backend.AuthInfo
+	fetched bool
+}
+
+// newRuleRequest returns the ruleRequest of r. The client's user is only
+// looked up if a rule refers to it, from the tenancy cache when tenancy is
+// enabled.
+func (p *Proxy) newRuleRequest(r *http.Request) *ruleRequest {
+	lookup := p.hotBackend.AuthInfo
+	if p.tenancy != nil {
+		lookup = p.tenancy.users.user
+	}
+	return &ruleRequest{ctx: r.Context(), header: r.Header, lookup: lookup}
+}
+
+// user returns the client's user, or an empty one if it is unknown.
+func (rr *ruleRequest) user() *backend.AuthInfo {
+	if rr == nil {
+		return &backend.AuthInfo{}
+	}
+	if !rr.fetched {
+		rr.fetched = true
+		info, err := rr.lookup(rr.ctx, rr.header)
+		if err != nil {
+			slog.Debug("routing rules: user unknown", "error", err)
+		}
+		rr.info = info
+	}
+	if rr.info == nil {
+		return &backend.AuthInfo{}
+	}
+	return rr.info
+}
+
+// ruleSpan returns the tiers the first routing rule matching a search of
+// index reaches, or nil if no rule matches or the rule routes by default.
+// tsField is the timestamp field of index.
+func ruleSpan(cfg *config.Config, rr *ruleRequest, index string, body []byte, tsField string, now time.Time) []bool {
+	if len(cfg.RoutingRules) == 0 {
+		return nil
+	}
+	var tr *util.TimeRange
+	timeRange := func() *util.TimeRange {
+		if tr == nil {
+			if tr = util.ExtractTimeRangeAt(body, tsField, now); tr == nil {
+				tr = &util.TimeRange{}
+			}
+		}
+		return tr
+	}
+	bound := func(t *time.Time) any {
+		if t == nil {
+			return nil
+		}
+		return *t
+	}
+	env := expr.Env{
+		Vars: map[string]func() any{
+			"index": func() any { return index },
+			"user":  func() any { return rr.user().UserName },
+			"roles": func() any { return append(slices.Clone(rr.user().Roles), rr.user().BackendRoles...) },
+			"from":  func() any { return bound(timeRange().From) },
+			"to":    func() any { return bound(timeRange().To) },
+			"now":   func() any { return now },
+		},
+		Funcs: map[string]func([]any) any{
+			"header": func(args []any) any {
+				if rr == nil {
+					return ""
+				}
+				return rr.header.Get(args[0].(string))
+			},
+		},
+	}
+
+	for _, rule := range cfg.RoutingRules {
+		if !rule.Condition().Eval(env) {
+			continue
+		}
+		slog.Debug("routing rule matched", "rule", rule.Name, "when", rule.When, "index", index, "route", rule.Route)
+		span := make([]bool, len(cfg.Tiers)+2)
+		switch rule.Route {
+		case "default":
+			return nil
+		case "hot":
+			span[0] = true
+		case "cold":
+			span[len(span)-1] = true
+		case "all":
+			for i := range span {
+				span[i] = true
+			}
+		default:
+			span[1+slices.IndexFunc(cfg.Tiers, func(t config.TierConfig) bool { return t.Name == rule.Route })] = true
+		}
+		return span
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_RoutingRules(t *testing.T) {
+	var authinfo atomic.Int32
+	hot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_plugins/_security/authinfo" {
+			authinfo.Add(1)
+			fmt.Fprintf(w, `{"user_name":%q,"backend_roles":["auditor"]}`, r.Header.Get("Authorization"))
+			return
+		}
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer hot.Close()
+
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	yaml := fmt.Sprintf(`
+opensearch:
+  url: %q
+quickwit:
+  url: "http://qw:7280"
+routing_rules:
+  - name: audit-history
+    when: 'index matches "audit-*" && "auditor" in roles && (from == null || from < now - 30d)'
+    route: cold
+  - name: pinned
+    when: 'header("X-Oqbridge-Tier") == "hot"'
+    route: hot
+  - when: 'index == "metrics" && user == "svc"'
+    route: default
+  - when: 'index matches "metrics*"'
+    route: all
+`, hot.URL)
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	p, err := New(cfg, backend.NewOpenSearch(hot.URL, "", "", nil), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	between := func(fromDays, toDays int) []byte {
+		return []byte(fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lt":%q}}}}`,
+			now.AddDate(0, 0, -fromDays).Format(time.RFC3339), now.AddDate(0, 0, -toDays).Format(time.RFC3339)))
+	}
+	request := func(user, tier string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/logs/_search", nil)
+		r.Header.Set("Authorization", user)
+		if tier != "" {
+			r.Header.Set("X-Oqbridge-Tier", tier)
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		r       *http.Request
+		index   string
+		body    []byte
+		want    string
+		lookups int32
+	}{
+		{"audit history", request("alice", ""), "audit-2026", between(90, 60), "[false true]", 1},
+		{"no time range", request("alice", ""), "audit-2026", []byte(`{}`), "[false true]", 1},
+		{"recent audit", request("alice", ""), "audit-2026", between(1, 0), "[true false]", 1},
+		{"pinned by header", request("alice", "hot"), "logs", between(90, 60), "[true false]", 0},
+		{"default route", request("svc", ""), "metrics", between(1, 0), "[true false]", 1},
+		{"all", request("bob", ""), "metrics", between(1, 0), "[true true]", 1},
+		{"no rule", request("bob", ""), "logs", between(90, 60), "[false true]", 0},
+	}
+	for _, tt := range tests {
+		authinfo.Store(0)
+		if got := fmt.Sprint(p.tiersForRequest(tt.r, tt.body, []string{tt.index})); got != tt.want {
+			t.Errorf("%s: tiers %s, want %s", tt.name, got, tt.want)
+		}
+		if n := authinfo.Load(); n != tt.lookups {
+			t.Errorf("%s: %d user lookups, want %d", tt.name, n, tt.lookups)
+		}
+	}
+
+	// Without a request there is no user or header to match.
+	if route, _ := p.RouteSearch([]string{"audit-2026"}, between(90, 60)); route != "cold_only" {
+		t.Errorf("RouteSearch() = %s, want the time-range route", route)
+	}
+	if route, _ := p.RouteSearch([]string{"metrics"}, between(1, 0)); route != "both" {
+		t.Errorf("RouteSearch() = %s, want both", route)
+	}
+}
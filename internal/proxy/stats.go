@@ -0,0 +1,245 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// statsPath reports, per logical index, how its data is split between
+// OpenSearch and Quickwit.
+const statsPath = "/_oqbridge/stats"
+
+// WatermarkReader reads how far the migration of an index has gone, e.g. a
+// migration.CheckpointStore.
+type WatermarkReader interface {
+	LoadWatermark(index string) (*migration.Watermark, error)
+}
+
+// WithWatermarks makes _oqbridge/stats report the migration watermarks
+// read from r.
+func WithWatermarks(r WatermarkReader) Option {
+	return func(p *Proxy) {
+		p.watermarks = r
+	}
+}
+
+// indexStats is one logical index of _oqbridge/stats: the OpenSearch
+// indices migrated into one Quickwit index, or a Quickwit index alone.
+type indexStats struct {
+	Index          string     `json:"index"`
+	Hot            *hotStats  `json:"hot,omitempty"`
+	Cold           *coldStats `json:"cold,omitempty"`
+	MigratedBefore *time.Time `json:"migrated_before,omitempty"`
+	LastMigration  *time.Time `json:"last_migration,omitempty"`
+	hotIndices     []backend.IndexInfo
+}
+
+type hotStats struct {
+	Indices      []string   `json:"indices"`
+	DocsCount    int64      `json:"docs_count"`
+	SizeBytes    int64      `json:"size_bytes"`
+	MinTimestamp *time.Time `json:"min_timestamp,omitempty"`
+	MaxTimestamp *time.Time `json:"max_timestamp,omitempty"`
+}
+
+type coldStats struct {
+	Index        string     `json:"index"`
+	DocsCount    int64      `json:"docs_count"`
+	SizeBytes    int64      `json:"size_bytes"`
+	Splits       int64      `json:"splits"`
+	MinTimestamp *time.Time `json:"min_timestamp,omitempty"`
+	MaxTimestamp *time.Time `json:"max_timestamp,omitempty"`
+}
+
+// statsResponse is the body of GET _oqbridge/stats. Errors lists the
+// backends that could not be read; their figures are left out.
+type statsResponse struct {
+	Indices []*indexStats `json:"indices"`
+	Errors  []string      `json:"errors,omitempty"`
+}
+
+// isIndicesPath reports whether path is base, optionally followed by a
+// comma-separated list of index names or patterns, and returns them.
+func isIndicesPath(path, base string) (bool, []string) {
+	p := strings.TrimSuffix(path, "/")
+	if p == base {
+		return true, nil
+	}
+	if rest, ok := strings.CutPrefix(p, base+"/"); ok && !strings.Contains(rest, "/") {
+		return true, splitIndices(rest)
+	}
+	return false, nil
+}
+
+// handleStats serves _oqbridge/stats. Like _cat/cold_indices, the caller
+// must authenticate against OpenSearch first; the backends are then read
+// with the service account.
+func (p *Proxy) handleStats(w http.ResponseWriter, r *http.Request, patterns []string) {
+	if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for _oqbridge/stats", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+	writeJSON(w, p.stats(r.Context(), patterns))
+}
+
+// stats collects the statistics of the indices matching patterns, or of
+// every index if there are none. OpenSearch indices are grouped by the
+// Quickwit index they are migrated into, which names the logical index.
+func (p *Proxy) stats(ctx context.Context, patterns []string) *statsResponse {
+	cfg := p.live.Load().cfg
+	resp := &statsResponse{Indices: []*indexStats{}}
+	byName := make(map[string]*indexStats)
+	row := func(name string) *indexStats {
+		s, ok := byName[name]
+		if !ok {
+			s = &indexStats{Index: name}
+			byName[name] = s
+		}
+		return s
+	}
+
+	expr := "*"
+	if len(patterns) > 0 {
+		expr = strings.Join(patterns, ",")
+	}
+	hot, err := p.hotBackend.ResolveIndices(ctx, expr)
+	if err != nil {
+		resp.Errors = append(resp.Errors, "listing opensearch indices: "+err.Error())
+	}
+	for _, info := range hot {
+		s := row(cfg.QuickwitIndexForIndex(info.Name))
+		s.hotIndices = append(s.hotIndices, info)
+	}
+
+	cold, err := p.coldBackend.ListIndices(ctx)
+	if err != nil {
+		resp.Errors = append(resp.Errors, "listing quickwit indices: "+err.Error())
+	}
+	for _, index := range cold {
+		if _, ok := byName[index]; !ok && matchesAny(patterns, index) {
+			row(index)
+		}
+	}
+	coldIndices := make(map[string]bool, len(cold))
+	for _, index := range cold {
+		coldIndices[index] = true
+	}
+
+	for _, s := range byName {
+		if len(s.hotIndices) > 0 {
+			if err := p.hotStats(ctx, s); err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("reading timestamps of %s: %v", s.Index, err))
+			}
+			if err := p.migrationStats(s); err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("reading watermarks of %s: %v", s.Index, err))
+			}
+		}
+		if coldIndices[s.Index] {
+			if d, err := p.coldBackend.DescribeIndex(ctx, s.Index); err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("describing quickwit index %s: %v", s.Index, err))
+			} else {
+				s.Cold = &coldStats{
+					Index:        d.Index,
+					DocsCount:    d.NumDocs,
+					SizeBytes:    d.SizeBytes,
+					Splits:       d.NumSplits,
+					MinTimestamp: d.MinTimestamp,
+					MaxTimestamp: d.MaxTimestamp,
+				}
+			}
+		}
+		resp.Indices = append(resp.Indices, s)
+	}
+	sort.Slice(resp.Indices, func(i, j int) bool { return resp.Indices[i].Index < resp.Indices[j].Index })
+	sort.Strings(resp.Errors)
+	return resp
+}
+
+// hotStats sums the _cat/indices figures of the OpenSearch indices of s
+// and asks them for their oldest and newest timestamps. Closed indices
+// are listed but neither counted nor searched.
+func (p *Proxy) hotStats(ctx context.Context, s *indexStats) error {
+	s.Hot = &hotStats{}
+	var open []string
+	for _, info := range s.hotIndices {
+		s.Hot.Indices = append(s.Hot.Indices, info.Name)
+		if info.Health == "" {
+			continue // closed: _cat/indices reports no figures
+		}
+		s.Hot.DocsCount += info.DocsCount
+		s.Hot.SizeBytes += info.StoreSizeBytes
+		open = append(open, info.Name)
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	field := p.live.Load().cfg.TimestampFieldForIndex(open[0])
+	body, _ := json.Marshal(map[string]any{
+		"size": 0,
+		"aggs": map[string]any{
+			"min_timestamp": map[string]any{"min": map[string]string{"field": field}},
+			"max_timestamp": map[string]any{"max": map[string]string{"field": field}},
+		},
+	})
+	res, err := p.hotBackend.Search(ctx, strings.Join(open, ","), body)
+	if err != nil {
+		return err
+	}
+	var aggs struct {
+		Min struct{ Value *float64 } `json:"min_timestamp"`
+		Max struct{ Value *float64 } `json:"max_timestamp"`
+	}
+	if len(res.Aggregations) > 0 {
+		if err := json.Unmarshal(res.Aggregations, &aggs); err != nil {
+			return fmt.Errorf("decoding aggregations: %w", err)
+		}
+	}
+	millis := func(v *float64) *time.Time {
+		if v == nil {
+			return nil
+		}
+		t := time.UnixMilli(int64(*v)).UTC()
+		return &t
+	}
+	s.Hot.MinTimestamp, s.Hot.MaxTimestamp = millis(aggs.Min.Value), millis(aggs.Max.Value)
+	return nil
+}
+
+// migrationStats sets the latest watermark of the OpenSearch indices of s,
+// if the proxy was given WithWatermarks.
+func (p *Proxy) migrationStats(s *indexStats) error {
+	if p.watermarks == nil {
+		return nil
+	}
+	for _, info := range s.hotIndices {
+		wm, err := p.watermarks.LoadWatermark(info.Name)
+		if err != nil {
+			return err
+		}
+		if wm == nil {
+			continue
+		}
+		if s.MigratedBefore == nil || wm.MigratedBefore.After(*s.MigratedBefore) {
+			s.MigratedBefore = &wm.MigratedBefore
+		}
+		if s.LastMigration == nil || wm.UpdatedAt.After(*s.LastMigration) {
+			s.LastMigration = &wm.UpdatedAt
+		}
+	}
+	return nil
+}
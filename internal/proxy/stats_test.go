@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+type fakeWatermarks map[string]*migration.Watermark
+
+func (f fakeWatermarks) LoadWatermark(index string) (*migration.Watermark, error) {
+	return f[index], nil
+}
+
+func TestProxy_Stats(t *testing.T) {
+	var searched string
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/_plugins/_security/authinfo":
+			if r.Header.Get("Authorization") != validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"user":"user"}`))
+		case strings.HasPrefix(r.URL.Path, "/_cat/indices/"):
+			w.Write([]byte(`[
+				{"index":"app-2026.10.01","health":"green","creation.date":"1759276800000","docs.count":"10","store.size":"1000"},
+				{"index":"app-2026.10.02","health":"green","creation.date":"1759363200000","docs.count":"5","store.size":"500"},
+				{"index":"app-2026.09.01","health":null,"creation.date":null,"docs.count":null,"store.size":null},
+				{"index":"metrics","health":"yellow","creation.date":"1759276800000","docs.count":"1","store.size":"10"}]`))
+		case strings.HasSuffix(r.URL.Path, "/_search"):
+			if strings.HasPrefix(r.URL.Path, "/app-") {
+				searched = r.URL.Path
+				w.Write([]byte(`{"hits":{"total":{"value":15}},"aggregations":{"min_timestamp":{"value":1759276800000},"max_timestamp":{"value":1759449599000}}}`))
+				return
+			}
+			w.Write([]byte(`{"hits":{"total":{"value":0}},"aggregations":{"min_timestamp":{"value":null},"max_timestamp":{"value":null}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"app"}},{"index_config":{"index_id":"audit"}}]`))
+		case "/api/v1/indexes/app/describe":
+			w.Write([]byte(`{"num_published_docs":100,"num_published_splits":3,"size_published_splits":2048,"min_timestamp":1756684800,"max_timestamp":1759190400}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Quickwit:   config.QuickwitConfig{URL: qw.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+		Migration: config.MigrationConfig{Rules: []config.MigrationRule{
+			{Indices: []string{"app-*"}, TargetIndex: "app"},
+		}},
+	}
+	migrated := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	ran := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil,
+		WithWatermarks(fakeWatermarks{
+			"app-2026.09.01": {Index: "app-2026.09.01", MigratedBefore: migrated.AddDate(0, 0, -1), UpdatedAt: ran.AddDate(0, 0, -1)},
+			"app-2026.10.01": {Index: "app-2026.10.01", MigratedBefore: migrated, UpdatedAt: ran},
+		}))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/_oqbridge/stats", nil)
+	req.Header.Set("Authorization", validToken)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp statsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v: %s", err, w.Body.String())
+	}
+	if len(resp.Indices) != 3 || resp.Indices[0].Index != "app" || resp.Indices[1].Index != "audit" || resp.Indices[2].Index != "metrics" {
+		t.Fatalf("indices = %s", w.Body.String())
+	}
+
+	app := resp.Indices[0]
+	if app.Hot == nil || len(app.Hot.Indices) != 3 || app.Hot.DocsCount != 15 || app.Hot.SizeBytes != 1500 {
+		t.Errorf("app hot = %+v", app.Hot)
+	}
+	// The closed index is listed but not searched.
+	if searched != "/app-2026.10.01,app-2026.10.02/_search" {
+		t.Errorf("searched %s", searched)
+	}
+	if app.Hot.MinTimestamp == nil || !app.Hot.MinTimestamp.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("app hot min_timestamp = %v", app.Hot.MinTimestamp)
+	}
+	if app.Cold == nil || app.Cold.DocsCount != 100 || app.Cold.SizeBytes != 2048 || app.Cold.Splits != 3 || app.Cold.MaxTimestamp == nil {
+		t.Errorf("app cold = %+v", app.Cold)
+	}
+	if app.MigratedBefore == nil || !app.MigratedBefore.Equal(migrated) || app.LastMigration == nil || !app.LastMigration.Equal(ran) {
+		t.Errorf("app migrated_before = %v, last_migration = %v", app.MigratedBefore, app.LastMigration)
+	}
+
+	// audit fails to describe; metrics has no cold index nor timestamps.
+	if audit := resp.Indices[1]; audit.Hot != nil || audit.Cold != nil {
+		t.Errorf("audit = %+v", audit)
+	}
+	if metrics := resp.Indices[2]; metrics.Hot == nil || metrics.Hot.MinTimestamp != nil || metrics.Cold != nil || metrics.LastMigration != nil {
+		t.Errorf("metrics = %+v", metrics)
+	}
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0], "describing quickwit index audit") {
+		t.Errorf("errors = %v", resp.Errors)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/_oqbridge/stats/audit", nil)
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d: %s", w.Code, w.Body.String())
+	}
+}
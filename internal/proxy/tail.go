@@ -0,0 +1,271 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// tailPath streams the documents matching a query, see handleTail.
+const tailPath = "/_oqbridge/tail"
+
+// tailRequest is the body of POST /_oqbridge/tail. From accepts RFC3339
+// timestamps, dates and "now-1h"; it defaults to now, so only new
+// documents are streamed.
+type tailRequest struct {
+	Index string          `json:"index"`
+	Query json.RawMessage `json:"query"`
+	From  string          `json:"from"`
+}
+
+// tailSearch runs one search of a tail on one backend.
+type tailSearch func(ctx context.Context, body []byte) (*backend.SearchResponse, error)
+
+// tailHit is the part of a hit a tail orders and deduplicates by.
+type tailHit struct {
+	raw json.RawMessage
+	ts  time.Time
+	key string
+}
+
+// tail is the position of one stream: documents are sent in timestamp
+// order, and seen holds those sent with the timestamp of cursor, which the
+// next search returns again.
+type tail struct {
+	tsField  string
+	query    json.RawMessage
+	pageSize int
+	cursor   time.Time
+	seen     map[string]bool
+	send     func(json.RawMessage) error
+}
+
+// handleTail streams the documents of req.Index matching req.Query as
+// newline-delimited JSON hits, oldest first. Documents older than the hot
+// cutoff are read once from Quickwit and the tiers; OpenSearch is then
+// searched every server.tail.poll_interval for newer ones until the client
+// disconnects or server.tail.max_duration passes. OpenSearch searches carry
+// the client's credentials, so they are checked again on every poll.
+func (p *Proxy) handleTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for tail", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+
+	live := p.live.Load()
+	settings := live.cfg.Server.Tail
+	var req tailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"invalid request body","detail":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	indices := splitIndices(req.Index)
+	if len(indices) == 0 || hasInternal(indices) {
+		http.Error(w, `{"error":"index is required and must not be a system index"}`, http.StatusBadRequest)
+		return
+	}
+	from := time.Now()
+	if req.From != "" {
+		var err error
+		if from, err = util.ParseTimeExpressionIn(req.From, live.cfg.Location()); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid from","detail":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(req.Query) == 0 {
+		req.Query = json.RawMessage(`{"match_all":{}}`)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), settings.MaxDuration)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	t := &tail{
+		tsField:  live.cfg.TimestampFieldForIndex(req.Index),
+		query:    req.Query,
+		pageSize: settings.PageSize,
+		cursor:   from,
+		seen:     make(map[string]bool),
+		send: func(line json.RawMessage) error {
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		},
+	}
+	err := p.streamTail(ctx, t, live, req.Index, indices, r.Header, settings.PollInterval)
+	if err != nil && ctx.Err() == nil {
+		slog.Warn("tail stopped", "index", req.Index, "error", err)
+		msg := "tail failed"
+		if isAuthError(err) {
+			msg = "authentication failed"
+		}
+		t.send(json.RawMessage(fmt.Sprintf(`{"error":%q,"detail":%q}`, msg, err.Error())))
+	}
+}
+
+// streamTail sends the stored documents from the oldest backend the window
+// reaches to the youngest, then polls OpenSearch.
+func (p *Proxy) streamTail(ctx context.Context, t *tail, live *liveConfig, pattern string, indices []string, header http.Header, interval time.Duration) error {
+	path := "/" + strings.Join(indices, ",") + "/_search"
+	searchOpenSearch := func(os *backend.OpenSearch) tailSearch {
+		return func(ctx context.Context, body []byte) (*backend.SearchResponse, error) {
+			return os.SearchRaw(ctx, path, "", body, header)
+		}
+	}
+	// Backends of the chain, youngest first, and the age in days at which
+	// data leaves each but the last.
+	searches := []tailSearch{searchOpenSearch(p.hotBackend)}
+	for _, tier := range p.tiers {
+		searches = append(searches, searchOpenSearch(tier.backend))
+	}
+	searches = append(searches, func(ctx context.Context, body []byte) (*backend.SearchResponse, error) {
+		return p.searchColdIndices(ctx, indices, body)
+	})
+	days := live.cfg.TierDays(pattern)
+	if live.cfg.DualWriteIndex(pattern) {
+		// Quickwit holds the documents of the other tiers as well.
+		searches, days = searches[len(searches)-1:], nil
+	}
+
+	for i := len(searches) - 1; i > 0; i-- {
+		upper := cutoffAt(live.cfg.RoutingLocation(), days[i-1], time.Now())
+		if !t.cursor.Before(upper) {
+			continue
+		}
+		if i < len(days) {
+			t.cursor = maxTime(t.cursor, cutoffAt(live.cfg.RoutingLocation(), days[i], time.Now()))
+		}
+		if err := t.drain(ctx, searches[i], upper); err != nil {
+			return err
+		}
+	}
+	if len(days) > 0 {
+		t.cursor = maxTime(t.cursor, cutoffAt(live.cfg.RoutingLocation(), days[0], time.Now()))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := t.drain(ctx, searches[0], time.Time{}); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain sends the documents from the cursor up to, not including, upper
+// (zero for no bound), one page at a time.
+func (t *tail) drain(ctx context.Context, search tailSearch, upper time.Time) error {
+	for {
+		rng := map[string]string{"gte": t.cursor.UTC().Format(tailTimeLayout), "format": "strict_date_optional_time"}
+		if !upper.IsZero() {
+			rng["lt"] = upper.UTC().Format(tailTimeLayout)
+		}
+		body, err := json.Marshal(map[string]any{
+			"size": t.pageSize,
+			"sort": []any{map[string]any{t.tsField: map[string]string{"order": "asc"}}},
+			"query": map[string]any{"bool": map[string]any{
+				"filter": []any{map[string]any{"range": map[string]any{t.tsField: rng}}},
+				"must":   []any{t.query},
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("building tail search: %w", err)
+		}
+		resp, err := search(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		hits := t.order(resp.Hits.Hits)
+		sent := 0
+		for _, h := range hits {
+			if t.seen[h.key] {
+				continue
+			}
+			if err := t.send(h.raw); err != nil {
+				return err
+			}
+			if h.ts.After(t.cursor) {
+				t.cursor = h.ts
+				clear(t.seen)
+			}
+			t.seen[h.key] = true
+			sent++
+		}
+		if len(resp.Hits.Hits) < t.pageSize {
+			return nil
+		}
+		if sent == 0 {
+			return fmt.Errorf("%d or more documents share the timestamp %s; raise server.tail.page_size", t.pageSize, t.cursor.UTC().Format(tailTimeLayout))
+		}
+	}
+}
+
+// tailTimeLayout formats cursors at the millisecond precision of date
+// fields.
+const tailTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// order returns the first pageSize hits by timestamp. Several Quickwit
+// indices are searched separately, so the merged hits are not in order and
+// only the first page of them is known to be complete.
+func (t *tail) order(raw []json.RawMessage) []tailHit {
+	hits := make([]tailHit, 0, len(raw))
+	for _, r := range raw {
+		var h struct {
+			Index  string          `json:"_index"`
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		}
+		json.Unmarshal(r, &h)
+		ts, _ := migration.DocumentTimestamp(h.Source, t.tsField)
+		key := h.Index + "/" + h.ID
+		if h.ID == "" {
+			key = string(h.Source)
+		}
+		hits = append(hits, tailHit{raw: r, ts: ts.Truncate(time.Millisecond), key: key})
+	}
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].ts.Before(hits[b].ts) })
+	return hits[:min(len(hits), t.pageSize)]
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
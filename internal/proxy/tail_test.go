@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// rangeSearcher answers tail searches from a list of documents, honoring
+// the range filter, the ascending sort and size.
+type rangeSearcher struct {
+	mu   sync.Mutex
+	docs map[string]time.Time // _id -> @timestamp
+}
+
+func (s *rangeSearcher) add(id string, ts time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[id] = ts
+}
+
+func (s *rangeSearcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/authinfo") {
+		w.Write([]byte(`{"user_name":"user"}`))
+		return
+	}
+	var body struct {
+		Size  int
+		Query struct {
+			Bool struct {
+				Filter []struct {
+					Range map[string]struct{ Gte, Lt string }
+				}
+			}
+		}
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if len(body.Query.Bool.Filter) == 0 {
+		w.Write([]byte(`{}`))
+		return
+	}
+	rng := body.Query.Bool.Filter[0].Range["@timestamp"]
+	gte, _ := time.Parse(time.RFC3339, rng.Gte)
+	lt, _ := time.Parse(time.RFC3339, rng.Lt)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, ts := range s.docs {
+		if !ts.Before(gte) && (rng.Lt == "" || ts.Before(lt)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if !s.docs[ids[a]].Equal(s.docs[ids[b]]) {
+			return s.docs[ids[a]].Before(s.docs[ids[b]])
+		}
+		return ids[a] < ids[b]
+	})
+	resp := backend.SearchResponse{}
+	for _, id := range ids[:min(body.Size, len(ids))] {
+		resp.Hits.Hits = append(resp.Hits.Hits, json.RawMessage(fmt.Sprintf(`{"_index":"logs","_id":%q,"_source":{"@timestamp":%q}}`, id, s.docs[id].Format(time.RFC3339Nano))))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestProxy_Tail(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	hot := &rangeSearcher{docs: map[string]time.Time{"h1": now.Add(-time.Hour), "h2": now.Add(-time.Minute)}}
+	cold := &rangeSearcher{docs: map[string]time.Time{
+		"c0": now.AddDate(0, 0, -6),
+		"c1": now.AddDate(0, 0, -5),
+		"c2": now.AddDate(0, 0, -4), "c3": now.AddDate(0, 0, -4), // same timestamp across a page boundary
+		"c4": now.AddDate(0, 0, -3),
+		"c5": now.AddDate(0, 0, -9), // before the tail starts
+	}}
+	os := httptest.NewServer(hot)
+	defer os.Close()
+	qw := httptest.NewServer(cold)
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Quickwit:   config.QuickwitConfig{URL: qw.URL},
+		Retention:  config.RetentionConfig{Days: 2, TimestampField: "@timestamp"},
+	}
+	cfg.Server.Tail = config.TailConfig{Enabled: true, PollInterval: 10 * time.Millisecond, PageSize: 3, MaxDuration: 5 * time.Second}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+tailPath, strings.NewReader(`{"index":"logs","from":"now-7d"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("tail request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("tail response = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	next := func() string {
+		if !sc.Scan() {
+			t.Fatalf("stream ended: %v", sc.Err())
+		}
+		var h struct {
+			ID    string `json:"_id"`
+			Error string `json:"error"`
+		}
+		json.Unmarshal(sc.Bytes(), &h)
+		if h.Error != "" {
+			t.Fatalf("stream error: %s", sc.Text())
+		}
+		return h.ID
+	}
+	var got []string
+	for range 7 {
+		got = append(got, next())
+	}
+	if fmt.Sprint(got) != "[c0 c1 c2 c3 c4 h1 h2]" {
+		t.Errorf("backfill = %v, want [c0 c1 c2 c3 c4 h1 h2]", got)
+	}
+
+	hot.add("h3", time.Now().UTC())
+	if id := next(); id != "h3" {
+		t.Errorf("live document = %s, want h3", id)
+	}
+}
+
+func TestProxy_TailRequiresAuth(t *testing.T) {
+	os := newMockOpenSearch(t)
+	defer os.Close()
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Retention:  config.RetentionConfig{Days: 2, TimestampField: "@timestamp"},
+	}
+	cfg.Server.Tail = config.TailConfig{Enabled: true, PollInterval: time.Second, PageSize: 10, MaxDuration: time.Second}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tailPath, strings.NewReader(`{"index":"logs"}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("tail without credentials = %d, want 401", w.Code)
+	}
+}
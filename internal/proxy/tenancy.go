@@ -0,0 +1,408 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// tenantIndexAPIs are the APIs tenants may call on their indices, as the
+// path segment after the index expression. The others, such as _alias or
+// _mget, could name indices of other tenants outside the path.
+var tenantIndexAPIs = []string{
+	"", "_search", "_msearch", "_count", "_doc", "_create", "_update", "_bulk",
+	"_mapping", "_mappings", "_settings", "_refresh", "_flush", "_field_caps",
+	"_stats", "_explain", "_validate", "_delete_by_query", "_update_by_query",
+	"_open", "_close",
+}
+
+// tenancy scopes the requests of tenant users to the indices of their
+// tenant (server.tenancy). Index names in paths and in the bodies of
+// _msearch, _bulk and tail requests are rewritten before the request is
+// routed, so wildcards resolve to the tenant's indices on every backend.
+type tenancy struct {
+	settings config.TenancyConfig
+	users    *userCache
+}
+
+func newTenancy(settings config.TenancyConfig, auth authInfoFunc) *tenancy {
+	return &tenancy{
+		settings: settings,
+		users:    newUserCache(auth, settings.CacheTTL, settings.IdentityHeaders),
+	}
+}
+
+// tenantError is a request a tenant may not make.
+type tenantError struct {
+	msg string
+}
+
+func (e *tenantError) Error() string { return e.msg }
+
+func denyTenant(format string, args ...any) error {
+	return &tenantError{msg: fmt.Sprintf(format, args...)}
+}
+
+// scope authenticates the client and, unless it holds one of admin_roles,
+// rewrites r to the indices of its tenant. It answers the request itself
+// and returns false if the client cannot be authenticated, belongs to no
+// tenant or names indices outside its tenant.
+func (t *tenancy) scope(w http.ResponseWriter, r *http.Request) bool {
+	info, err := t.users.user(r.Context(), r.Header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for tenancy", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return false
+	}
+	if hasAnyRole(info, t.settings.AdminRoles) {
+		return true
+	}
+	tenant := t.tenantOf(info)
+	if tenant == nil {
+		slog.Warn("tenancy denied", "user", info.UserName, "reason", "no tenant")
+		http.Error(w, `{"error":"user belongs to no tenant"}`, http.StatusForbidden)
+		return false
+	}
+
+	s := tenantScope{prefix: tenant.Prefix}
+	for _, other := range t.settings.Tenants {
+		if other.Name != tenant.Name {
+			s.others = append(s.others, other.Prefix)
+		}
+	}
+	if err := s.request(r); err != nil {
+		var te *tenantError
+		if errors.As(err, &te) {
+			slog.Warn("tenancy denied", "user", info.UserName, "tenant", tenant.Name, "path", r.URL.Path, "reason", te.msg)
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, te.msg), http.StatusForbidden)
+		} else {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid request body","detail":%q}`, err.Error()), http.StatusBadRequest)
+		}
+		return false
+	}
+	slog.Debug("request scoped to tenant", "user", info.UserName, "tenant", tenant.Name, "path", r.URL.Path)
+	return true
+}
+
+// tenantOf returns the first tenant listing the user or one of its roles.
+func (t *tenancy) tenantOf(info *backend.AuthInfo) *config.TenantConfig {
+	for i, tenant := range t.settings.Tenants {
+		if slices.Contains(tenant.Users, info.UserName) || hasAnyRole(info, tenant.Roles) {
+			return &t.settings.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// tenantScope rewrites index names to those of one tenant. Names starting
+// with the tenant's prefix are kept, names starting with the prefix of
+// another tenant are refused, and every other name gets the prefix: a
+// tenant with prefix "payments-" searching "logs-*" searches
+// "payments-logs-*".
+type tenantScope struct {
+	prefix string
+	others []string // prefixes of the other tenants
+}
+
+// request scopes the index names of r, or returns a *tenantError if r is
+// an API tenants may not call.
+func (s tenantScope) request(r *http.Request) error {
+	// Split the escaped path: date math such as <logs-{now/d}> contains "/".
+	segs := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, seg := range segs {
+		var err error
+		if segs[i], err = url.PathUnescape(seg); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(segs) == 1 && segs[0] == "":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return denyTenant("%s / is not available to tenants", r.Method)
+		}
+		return nil
+	case !strings.HasPrefix(segs[0], "_"):
+		return s.indexRequest(r, segs)
+	}
+
+	all := s.prefix + "*"
+	switch path := "/" + strings.Join(segs, "/"); {
+	case path == "/_search" || path == "/_count":
+		return s.setPath(r, append([]string{all}, segs...))
+	case path == "/_msearch":
+		if err := s.setPath(r, append([]string{all}, segs...)); err != nil {
+			return err
+		}
+		return rewriteBody(r, s.msearchBody)
+	case path == "/_bulk":
+		return rewriteBody(r, s.bulkBody)
+	case path == tailPath:
+		return rewriteBody(r, s.tailBody)
+	case path == "/_plugins/_security/authinfo", path == quotaPath:
+		return nil
+	case segs[0] == "_cat" && len(segs) <= 3 && len(segs) > 1 && slices.Contains([]string{"indices", "count", "cold_indices"}, segs[1]),
+		path == statsPath || strings.HasPrefix(path, statsPath+"/") && len(segs) == 3,
+		path == timelinePath || strings.HasPrefix(path, timelinePath+"/") && len(segs) == 3:
+		if len(segs) == 2 {
+			segs = append(segs, all)
+		}
+		expr, err := s.expression(segs[2])
+		if err != nil {
+			return err
+		}
+		segs[2] = expr
+		return s.setPath(r, segs)
+	default:
+		return denyTenant("%s is not available to tenants", path)
+	}
+}
+
+// indexRequest scopes a request whose path starts with an index expression.
+func (s tenantScope) indexRequest(r *http.Request, segs []string) error {
+	api := ""
+	if len(segs) > 1 {
+		api = segs[1]
+	}
+	if !slices.Contains(tenantIndexAPIs, api) {
+		return denyTenant("%s is not available to tenants", api)
+	}
+	expr, err := s.expression(segs[0])
+	if err != nil {
+		return err
+	}
+	segs[0] = expr
+	if err := s.setPath(r, segs); err != nil {
+		return err
+	}
+	switch api {
+	case "_msearch":
+		return rewriteBody(r, s.msearchBody)
+	case "_bulk":
+		return rewriteBody(r, s.bulkBody)
+	}
+	return nil
+}
+
+// expression scopes every index of a comma-separated index expression.
+func (s tenantScope) expression(expr string) (string, error) {
+	names := splitIndices(expr)
+	if len(names) == 0 {
+		return s.prefix + "*", nil
+	}
+	for i, name := range names {
+		scoped, err := s.index(name)
+		if err != nil {
+			return "", err
+		}
+		names[i] = scoped
+	}
+	return strings.Join(names, ","), nil
+}
+
+// index scopes one index name or pattern, including exclusions ("-name"),
+// date math ("<name-{now/d}>") and the index of cross-cluster names.
+func (s tenantScope) index(name string) (string, error) {
+	if rest, ok := strings.CutPrefix(name, "-"); ok {
+		scoped, err := s.index(rest)
+		return "-" + scoped, err
+	}
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") && len(name) > 2 {
+		scoped, err := s.index(name[1 : len(name)-1])
+		return "<" + scoped + ">", err
+	}
+	if cluster, rest, ok := strings.Cut(name, ":"); ok {
+		scoped, err := s.index(rest)
+		return cluster + ":" + scoped, err
+	}
+	if name == "_all" {
+		return s.prefix + "*", nil
+	}
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return "", denyTenant("index %q is not available to tenants", name)
+	}
+	if strings.HasPrefix(name, s.prefix) {
+		return name, nil
+	}
+	for _, other := range s.others {
+		if strings.HasPrefix(name, other) {
+			return "", denyTenant("index %q belongs to another tenant", name)
+		}
+	}
+	return s.prefix + name, nil
+}
+
+// setPath replaces the path of r with segs, whose first index expression
+// has already been scoped.
+func (s tenantScope) setPath(r *http.Request, segs []string) error {
+	escaped := make([]string, len(segs))
+	for i, seg := range segs {
+		names := strings.Split(seg, ",")
+		for j, name := range names {
+			names[j] = url.PathEscape(name)
+		}
+		escaped[i] = strings.Join(names, ",")
+	}
+	u, err := url.Parse("/" + strings.Join(escaped, "/"))
+	if err != nil {
+		return err
+	}
+	r.URL.Path, r.URL.RawPath = u.Path, u.RawPath
+	return nil
+}
+
+// msearchBody scopes the index of every header line of an _msearch body.
+// Headers without one search the path's indices, which are scoped already.
+func (s tenantScope) msearchBody(body []byte) ([]byte, error) {
+	var out bytes.Buffer
+	header := true
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if header {
+			var err error
+			if line, err = s.msearchHeader(line); err != nil {
+				return nil, err
+			}
+		}
+		header = !header
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+func (s tenantScope) msearchHeader(line []byte) ([]byte, error) {
+	var hdr map[string]json.RawMessage
+	if err := json.Unmarshal(line, &hdr); err != nil {
+		return nil, fmt.Errorf("malformed msearch header: %w", err)
+	}
+	raw, ok := hdr["index"]
+	if !ok {
+		return line, nil
+	}
+	var names []string
+	if err := json.Unmarshal(raw, &names); err != nil {
+		var expr string
+		if err := json.Unmarshal(raw, &expr); err != nil {
+			return nil, fmt.Errorf("malformed msearch header index: %w", err)
+		}
+		if expr == "" {
+			return line, nil
+		}
+		names = []string{expr}
+	}
+	for i, name := range names {
+		scoped, err := s.expression(name)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = scoped
+	}
+	if len(names) == 1 {
+		hdr["index"], _ = json.Marshal(names[0])
+	} else {
+		hdr["index"], _ = json.Marshal(names)
+	}
+	return json.Marshal(hdr)
+}
+
+// bulkBody scopes the _index of every action of a _bulk body. Actions
+// without one write to the path's index, which is scoped already.
+func (s tenantScope) bulkBody(body []byte) ([]byte, error) {
+	var out bytes.Buffer
+	lines := bytes.Split(body, []byte("\n"))
+	for i := 0; i < len(lines); i++ {
+		line := bytes.TrimSpace(lines[i])
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(line, &action); err != nil || len(action) != 1 {
+			return nil, fmt.Errorf("line %d: malformed action", i+1)
+		}
+		for op, meta := range action {
+			if raw, ok := meta["_index"]; ok {
+				var index string
+				if err := json.Unmarshal(raw, &index); err != nil {
+					return nil, fmt.Errorf("line %d: malformed _index", i+1)
+				}
+				scoped, err := s.index(index)
+				if err != nil {
+					return nil, err
+				}
+				meta["_index"], _ = json.Marshal(scoped)
+				line, _ = json.Marshal(action)
+			}
+			out.Write(line)
+			out.WriteByte('\n')
+			if op != "delete" {
+				if i++; i == len(lines) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				out.Write(bytes.TrimSpace(lines[i]))
+				out.WriteByte('\n')
+			}
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// tailBody scopes the index of a tail request.
+func (s tenantScope) tailBody(body []byte) ([]byte, error) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	var expr string
+	if raw, ok := req["index"]; ok {
+		if err := json.Unmarshal(raw, &expr); err != nil {
+			return nil, fmt.Errorf("malformed index: %w", err)
+		}
+	}
+	if expr == "" {
+		return body, nil // refused by handleTail
+	}
+	scoped, err := s.expression(expr)
+	if err != nil {
+		return nil, err
+	}
+	req["index"], _ = json.Marshal(scoped)
+	return json.Marshal(req)
+}
+
+// rewriteBody replaces the body of r with rewrite's result, decompressed.
+func rewriteBody(r *http.Request, rewrite func([]byte) ([]byte, error)) error {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	if body, err = decodeBody(r.Header, body); err != nil {
+		return err
+	}
+	if body, err = rewrite(body); err != nil {
+		return err
+	}
+	r.Header.Del("Content-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	r.ContentLength = int64(len(body))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestTenantScope_Index(t *testing.T) {
+	s := tenantScope{prefix: "payments-", others: []string{"search-"}}
+	tests := []struct {
+		name, want string
+		denied     bool
+	}{
+		{name: "logs", want: "payments-logs"},
+		{name: "payments-logs", want: "payments-logs"},
+		{name: "logs-*", want: "payments-logs-*"},
+		{name: "*", want: "payments-*"},
+		{name: "_all", want: "payments-*"},
+		{name: "-logs-debug", want: "-payments-logs-debug"},
+		{name: "<logs-{now/d}>", want: "<payments-logs-{now/d}>"},
+		{name: "eu:logs", want: "eu:payments-logs"},
+		{name: "search-logs", denied: true},
+		{name: "eu:search-logs", denied: true},
+		{name: ".kibana", denied: true},
+	}
+	for _, tt := range tests {
+		got, err := s.index(tt.name)
+		if tt.denied {
+			if _, ok := err.(*tenantError); !ok {
+				t.Errorf("index(%q) = %q, %v, want a tenantError", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("index(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestTenantScope_Bodies(t *testing.T) {
+	s := tenantScope{prefix: "payments-", others: []string{"search-"}}
+
+	msearch := "{\"index\":\"logs\"}\n{\"query\":{\"match_all\":{}}}\n{}\n{\"size\":0}\n{\"index\":[\"a\",\"payments-b\"]}\n{}\n"
+	got, err := s.msearchBody([]byte(msearch))
+	if err != nil {
+		t.Fatalf("msearchBody() error: %v", err)
+	}
+	want := "{\"index\":\"payments-logs\"}\n{\"query\":{\"match_all\":{}}}\n{}\n{\"size\":0}\n{\"index\":[\"payments-a\",\"payments-b\"]}\n{}\n"
+	if string(got) != want {
+		t.Errorf("msearchBody() = %q, want %q", got, want)
+	}
+	if _, err := s.msearchBody([]byte("{\"index\":\"search-logs\"}\n{}\n")); err == nil {
+		t.Error("msearchBody() accepted another tenant's index")
+	}
+
+	bulk := "{\"index\":{\"_index\":\"logs\",\"_id\":\"1\"}}\n{\"msg\":\"a\"}\n{\"delete\":{\"_index\":\"logs\",\"_id\":\"2\"}}\n{\"create\":{}}\n{\"msg\":\"b\"}\n"
+	got, err = s.bulkBody([]byte(bulk))
+	if err != nil {
+		t.Fatalf("bulkBody() error: %v", err)
+	}
+	want = "{\"index\":{\"_id\":\"1\",\"_index\":\"payments-logs\"}}\n{\"msg\":\"a\"}\n{\"delete\":{\"_id\":\"2\",\"_index\":\"payments-logs\"}}\n{\"create\":{}}\n{\"msg\":\"b\"}\n"
+	if string(got) != want {
+		t.Errorf("bulkBody() = %q, want %q", got, want)
+	}
+	if _, err := s.bulkBody([]byte("{\"index\":{\"_index\":\"search-logs\"}}\n{}\n")); err == nil {
+		t.Error("bulkBody() accepted another tenant's index")
+	}
+}
+
+func TestProxy_Tenancy(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		seen   []string
+		bodies []string
+		auths  int
+	)
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/_plugins/_security/authinfo" {
+			auths++
+			switch r.Header.Get("Authorization") {
+			case "alice":
+				w.Write([]byte(`{"user_name":"alice","roles":["payments_rw"]}`))
+			case "admin":
+				w.Write([]byte(`{"user_name":"admin","backend_roles":["all_access"]}`))
+			case "bob":
+				w.Write([]byte(`{"user_name":"bob","roles":["readall"]}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		seen = append(seen, r.URL.Path)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer os.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.Tenancy = config.TenancyConfig{
+		Enabled:         true,
+		AdminRoles:      []string{"all_access"},
+		CacheTTL:        time.Minute,
+		IdentityHeaders: []string{"Authorization"},
+		Tenants: []config.TenantConfig{
+			{Name: "payments", Prefix: "payments-", Roles: []string{"payments_rw"}},
+			{Name: "search", Prefix: "search-", Users: []string{"carol"}},
+		},
+	}
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	do := func(method, path, auth, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w.Code
+	}
+	last := func() (string, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen[len(seen)-1], bodies[len(bodies)-1]
+	}
+
+	if code := do(http.MethodPost, "/logs/_search", "alice", buildHotOnlyQuery()); code != http.StatusOK {
+		t.Fatalf("tenant search = %d", code)
+	}
+	if path, _ := last(); path != "/payments-logs/_search" {
+		t.Errorf("tenant search reached %q", path)
+	}
+	do(http.MethodGet, "/_cat/indices", "alice", "")
+	if path, _ := last(); path != "/_cat/indices/payments-*" {
+		t.Errorf("tenant _cat/indices reached %q", path)
+	}
+	do(http.MethodPost, "/_bulk", "alice", "{\"index\":{\"_index\":\"logs\"}}\n{\"msg\":\"a\"}\n")
+	if _, body := last(); !strings.Contains(body, `"_index":"payments-logs"`) {
+		t.Errorf("tenant _bulk body = %q", body)
+	}
+	mu.Lock()
+	if auths != 1 {
+		t.Errorf("authinfo requests = %d, want 1 for a cached client", auths)
+	}
+	mu.Unlock()
+	do(http.MethodGet, "/_oqbridge/stats", "alice", "")
+	if path, _ := last(); path != "/_cat/indices/payments-*" {
+		t.Errorf("tenant _oqbridge/stats reached %q", path)
+	}
+	do(http.MethodGet, "/_oqbridge/timeline/app-*", "alice", "")
+	if path, _ := last(); path != "/_cat/indices/payments-app-*" {
+		t.Errorf("tenant _oqbridge/timeline reached %q", path)
+	}
+
+	for _, tt := range []struct {
+		method, path, auth string
+		want               int
+	}{
+		{http.MethodPost, "/search-logs/_search", "alice", http.StatusForbidden},
+		{http.MethodPost, "/.kibana/_search", "alice", http.StatusForbidden},
+		{http.MethodGet, "/_cluster/health", "alice", http.StatusForbidden},
+		{http.MethodPost, "/logs/_alias/x", "alice", http.StatusForbidden},
+		{http.MethodPost, "/logs/_search", "bob", http.StatusForbidden},
+		{http.MethodPost, "/logs/_search", "mallory", http.StatusUnauthorized},
+		{http.MethodGet, "/_cluster/health", "admin", http.StatusOK},
+	} {
+		if code := do(tt.method, tt.path, tt.auth, "{}"); code != tt.want {
+			t.Errorf("%s %s as %s = %d, want %d", tt.method, tt.path, tt.auth, code, tt.want)
+		}
+	}
+	if path, _ := last(); path != "/_cluster/health" {
+		t.Errorf("admin request reached %q", path)
+	}
+}
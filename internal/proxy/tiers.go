@@ -0,0 +1,303 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// tier is an OpenSearch cluster between the hot cluster and Quickwit (an
+// entry of tiers). It is searched with its own service account once the
+// client's credentials have been checked against the hot cluster.
+type tier struct {
+	name    string
+	backend *backend.OpenSearch
+}
+
+// Option configures a Proxy.
+type Option func(*Proxy)
+
+// WithTier adds the backend of the next entry of tiers. Pass one for each
+// entry, in the order they are configured.
+func WithTier(name string, b *backend.OpenSearch) Option {
+	return func(p *Proxy) {
+		p.tiers = append(p.tiers, tier{name: name, backend: b})
+	}
+}
+
+// tiersForIndices reports which tiers a search of indices must reach: the
+// hot cluster first, then each entry of tiers, then Quickwit.
+func (p *Proxy) tiersForIndices(body []byte, indices []string) []bool {
+	return p.tiersAt(body, indices, time.Now(), nil)
+}
+
+// tiersForRequest is tiersForIndices for a search sent with r, which
+// routing rules may refer to.
+func (p *Proxy) tiersForRequest(r *http.Request, body []byte, indices []string) []bool {
+	return p.tiersAt(body, indices, time.Now(), p.newRuleRequest(r))
+}
+
+// tiersAt is tiersForIndices as of now, with the routing rules evaluated
+// against rr. The summaries of migration.rollups are only in the hot
+// cluster.
+func (p *Proxy) tiersAt(body []byte, indices []string, now time.Time, rr *ruleRequest) []bool {
+	live := p.live.Load()
+	span := make([]bool, len(live.cfg.Tiers)+2)
+	if len(indices) == 0 {
+		for i := range span {
+			span[i] = true
+		}
+		return span
+	}
+	for _, index := range indices {
+		if live.cfg.RollupIndex(index) {
+			span[0] = true
+			continue
+		}
+		clusters, name, remote := live.cfg.RemoteClustersForIndex(index)
+		if s := ruleSpan(live.cfg, rr, index, body, live.cfg.TimestampFieldForIndex(name), now); s != nil {
+			for i := range span {
+				span[i] = span[i] || s[i]
+			}
+			continue
+		}
+		if remote {
+			// Remote clusters have no tiers here: OpenSearch searches their
+			// recent data, Quickwit the rest. Only OpenSearch knows the
+			// remote clusters that are not configured.
+			span[0] = span[0] || len(clusters) == 0
+			for _, rc := range clusters {
+				s := live.router.Span(RouteRequest{Index: index, Body: body, TimestampField: live.cfg.TimestampFieldForIndex(name), Days: []int{live.cfg.RemoteHotDays(rc)}, Now: now})
+				span[0] = span[0] || s[0]
+				span[len(span)-1] = span[len(span)-1] || s[1]
+			}
+			continue
+		}
+		s := live.router.Span(RouteRequest{Index: index, Body: body, TimestampField: live.cfg.TimestampFieldForIndex(index), Days: live.cfg.TierDays(index), Now: now})
+		if live.cfg.DualWriteIndex(index) && slices.Contains(s[1:], true) {
+			// Quickwit holds the hot documents too; asking both would
+			// return them twice.
+			s = make([]bool, len(s))
+			s[len(s)-1] = true
+		}
+		for i := range span {
+			span[i] = span[i] || s[i]
+		}
+	}
+	return span
+}
+
+// RouteSearch returns how a search of indices with body is routed,
+// "tiered" or a RouteTarget, and the backends it reaches: "opensearch",
+// entries of tiers and "quickwit". The page cache is not considered, and
+// routing rules see no user, roles or headers.
+func (p *Proxy) RouteSearch(indices []string, body []byte) (string, []string) {
+	return p.RouteSearchAt(indices, body, time.Now())
+}
+
+// RouteSearchAt is RouteSearch as of now, e.g. to check how a captured
+// search would be routed under the current configuration.
+func (p *Proxy) RouteSearchAt(indices []string, body []byte, now time.Time) (string, []string) {
+	span := p.tiersAt(body, indices, now, nil)
+	names := []string{"opensearch"}
+	for _, t := range p.live.Load().cfg.Tiers {
+		names = append(names, t.Name)
+	}
+	names = append(names, "quickwit")
+
+	var backends []string
+	for i, ok := range span {
+		if ok {
+			backends = append(backends, names[i])
+		}
+	}
+	return routeName(span), backends
+}
+
+// routeName names the route of span: "tiered" or a RouteTarget.
+func routeName(span []bool) string {
+	if reachesMiddleTier(span) {
+		return "tiered"
+	}
+	return routeTarget(span).String()
+}
+
+// routeTarget reduces a span of tiers without middle tiers to the hot/cold
+// routing decision.
+func routeTarget(span []bool) RouteTarget {
+	hot, cold := span[0], span[len(span)-1]
+	switch {
+	case hot && cold:
+		return RouteBoth
+	case cold:
+		return RouteColdOnly
+	}
+	return RouteHotOnly
+}
+
+// reachesMiddleTier reports whether span includes an entry of tiers.
+func reachesMiddleTier(span []bool) bool {
+	return slices.Contains(span[1:len(span)-1], true)
+}
+
+// handleTieredSearch answers a search that reaches an entry of tiers by
+// searching every tier in span and merging the results.
+func (p *Proxy) handleTieredSearch(w http.ResponseWriter, r *http.Request, indices []string, body []byte, span []bool) {
+	fanout, err := planFanout(body)
+	fanout.Merge.TypedKeys = wantsTypedKeys(r)
+	if err == nil && span[len(span)-1] {
+		err = checkCapabilities(p.coldBackend.Capabilities(), body)
+	}
+	if err != nil {
+		if span[0] {
+			slog.Info("falling back to hot-only for unsupported cross-tier query", "indices", strings.Join(indices, ","), "reason", err.Error())
+			p.reverseProxy.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"unsupported query for multi-tier merge","detail":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Only the hot cluster knows the client's users.
+	if !span[0] {
+		if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+			status := http.StatusBadGateway
+			if isAuthError(err) {
+				status = statusFromAuthError(err)
+			}
+			slog.Warn("auth failed for tiered query", "indices", strings.Join(indices, ","), "status", status, "error", err)
+			http.Error(w, `{"error":"authentication failed"}`, status)
+			return
+		}
+	}
+
+	resp, err := p.searchTiers(r.Context(), indices, r.URL.Path, r.URL.RawQuery, fanout, span, r.Header)
+	if err != nil {
+		if isAuthError(err) {
+			http.Error(w, `{"error":"authentication failed"}`, statusFromAuthError(err))
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"tiered search failed","detail":%q}`, err.Error()), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// searchTiers searches the tiers in span in parallel and merges their
+// results. The hot cluster is searched as the client, at hotPath; if it
+// fails for another reason than the client's credentials, they are checked
+// before results of other tiers are returned. A failed tier is left out of
+// the results unless its on_error is "fail".
+func (p *Proxy) searchTiers(ctx context.Context, indices []string, hotPath, rawQuery string, fanout fanoutPlan, span []bool, header http.Header) (*backend.SearchResponse, error) {
+	results := make([]*backend.SearchResponse, len(span))
+	last := len(span) - 1
+	merge := fanout.Merge
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		merge = p.dateSortFields(ctx, indices, merge, header)
+	}()
+	answered, err := p.eachTier(ctx, span, header, func(i int) (err error) {
+		switch i {
+		case 0:
+			results[i], err = p.hotBackend.SearchRaw(ctx, hotPath, withTypedKeys(rawQuery), fanout.Body, header)
+		case last:
+			results[i], err = p.searchColdIndices(ctx, indices, fanout.Body)
+		default:
+			// Indices may not have reached this tier yet. Remote
+			// indices are unknown to tiers.
+			local := slices.DeleteFunc(slices.Clone(indices), isRemoteIndex)
+			if len(local) == 0 {
+				results[i] = &backend.SearchResponse{}
+				break
+			}
+			results[i], err = p.tiers[i-1].backend.SearchRaw(ctx, "/"+strings.Join(local, ",")+"/_search", "ignore_unavailable=true&allow_no_indices=true&typed_keys=true", fanout.Body, nil)
+		}
+		return err
+	})
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *backend.SearchResponse
+	for i, resp := range results {
+		if answered[i] {
+			merged = MergeSearchResponses(merged, resp)
+		}
+	}
+	return MergeSearchResponsesWithOptions(merged, nil, merge), nil
+}
+
+// eachTier calls tier for every tier of span in parallel and reports which
+// of them answered. A failed hot cluster leaves the client to be
+// authenticated; a failed tier with on_error "fail", or every tier
+// failing, fails the request.
+func (p *Proxy) eachTier(ctx context.Context, span []bool, header http.Header, tier func(i int) error) ([]bool, error) {
+	errs := make([]error, len(span))
+	var wg sync.WaitGroup
+	for i, reached := range span {
+		if !reached {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = tier(i)
+		}()
+	}
+	wg.Wait()
+
+	if hotErr := errs[0]; hotErr != nil {
+		if isAuthError(hotErr) {
+			return nil, hotErr
+		}
+		if err := p.authenticateViaOpenSearch(ctx, header); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := p.live.Load().cfg
+	last := len(span) - 1
+	answered := make([]bool, len(span))
+	ok := false
+	for i, err := range errs {
+		if !span[i] {
+			continue
+		}
+		if err != nil {
+			name := p.tierName(i, last)
+			slog.Error("tier request failed", "tier", name, "error", err)
+			if i > 0 && i < last && cfg.Tiers[i-1].OnError == "fail" {
+				return nil, fmt.Errorf("tier %s: %w", name, err)
+			}
+			continue
+		}
+		answered[i] = true
+		ok = true
+	}
+	if !ok {
+		return nil, errors.New("every tier failed")
+	}
+	return answered, nil
+}
+
+// tierName names tier i of a span whose last tier is last.
+func (p *Proxy) tierName(i, last int) string {
+	switch i {
+	case 0:
+		return "hot"
+	case last:
+		return "cold"
+	}
+	return p.tiers[i-1].name
+}
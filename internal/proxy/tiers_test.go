@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// tierServer answers searches with searchStatus and a single hit whose
+// index is the tier's name, and accepts every authentication check.
+func tierServer(t *testing.T, name string, searchStatus int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/authinfo") {
+			w.Write([]byte(`{}`))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/_search") {
+			w.WriteHeader(searchStatus)
+		}
+		fmt.Fprintf(w, `{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_index":%q,"_score":1}]}}`, name)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tieredProxy(t *testing.T, warmStatus int, onError string) *Proxy {
+	t.Helper()
+	hot := tierServer(t, "hot", http.StatusOK)
+	warm := tierServer(t, "warm", warmStatus)
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: hot.URL},
+		Quickwit:   config.QuickwitConfig{URL: "http://qw:7280"},
+		Retention:  config.RetentionConfig{Days: 7, TimestampField: "@timestamp"},
+		Tiers:      []config.TierConfig{{Name: "warm", Days: 30, OnError: onError}},
+	}
+	p, err := New(cfg, backend.NewOpenSearch(hot.URL, "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil,
+		WithTier("warm", backend.NewOpenSearch(warm.URL, "", "", nil)))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return p
+}
+
+func searchIndices(t *testing.T, p *Proxy, fromDays, toDays int) (int, []string) {
+	t.Helper()
+	now := time.Now().UTC()
+	body := fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lte":%q}}}}`,
+		now.AddDate(0, 0, -fromDays).Format(time.RFC3339), now.AddDate(0, 0, -toDays).Format(time.RFC3339))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body)))
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				Index string `json:"_index"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	var indices []string
+	for _, h := range resp.Hits.Hits {
+		indices = append(indices, h.Index)
+	}
+	return w.Code, indices
+}
+
+func TestProxy_TieredSearch(t *testing.T) {
+	p := tieredProxy(t, http.StatusOK, "skip")
+	if code, got := searchIndices(t, p, 20, 0); code != http.StatusOK || strings.Join(got, ",") != "hot,warm" {
+		t.Errorf("hot and warm: status %d, hits from %v", code, got)
+	}
+	if code, got := searchIndices(t, p, 20, 10); code != http.StatusOK || strings.Join(got, ",") != "warm" {
+		t.Errorf("warm only: status %d, hits from %v", code, got)
+	}
+	if got := p.routeForIndices([]byte(`{}`), []string{"logs"}); got != RouteBoth {
+		t.Errorf("no time range: route = %v, want both", got)
+	}
+}
+
+func TestProxy_TieredSearchOnError(t *testing.T) {
+	p := tieredProxy(t, http.StatusInternalServerError, "skip")
+	if code, got := searchIndices(t, p, 20, 0); code != http.StatusOK || strings.Join(got, ",") != "hot" {
+		t.Errorf("on_error skip: status %d, hits from %v", code, got)
+	}
+	p = tieredProxy(t, http.StatusInternalServerError, "fail")
+	if code, _ := searchIndices(t, p, 20, 0); code != http.StatusBadGateway {
+		t.Errorf("on_error fail: status %d, want 502", code)
+	}
+}
+
+func TestProxy_RouteSearch(t *testing.T) {
+	p := tieredProxy(t, http.StatusOK, "skip")
+	query := func(fromDays, toDays int) []byte {
+		now := time.Now().UTC()
+		return []byte(fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lte":%q}}}}`,
+			now.AddDate(0, 0, -fromDays).Format(time.RFC3339), now.AddDate(0, 0, -toDays).Format(time.RFC3339)))
+	}
+	for _, tc := range []struct {
+		from, to int
+		target   string
+		backends string
+	}{
+		{3, 0, "hot_only", "opensearch"},
+		{20, 0, "tiered", "opensearch,warm"},
+		{90, 60, "cold_only", "quickwit"},
+	} {
+		target, backends := p.RouteSearch([]string{"logs"}, query(tc.from, tc.to))
+		if target != tc.target || strings.Join(backends, ",") != tc.backends {
+			t.Errorf("%d-%d days ago: %s %v, want %s %s", tc.from, tc.to, target, backends, tc.target, tc.backends)
+		}
+	}
+
+	// Rollup summaries never leave the hot cluster.
+	cfg := *p.live.Load().cfg
+	cfg.Migration.Rollups = []config.RollupConfig{{Name: "hourly", Indices: []string{"logs"}, Target: "rollup-hourly"}}
+	p.SetConfig(&cfg)
+	if target, _ := p.RouteSearch([]string{"rollup-hourly"}, query(400, 0)); target != "hot_only" {
+		t.Errorf("rollup index over 400 days: %s, want hot_only", target)
+	}
+}
+
+func TestNew_TierBackendsMustMatchConfig(t *testing.T) {
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: "http://os:9200"},
+		Tiers:      []config.TierConfig{{Name: "warm", Days: 30}},
+	}
+	if _, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), backend.NewQuickwit("http://qw:7280", "", "", false, nil), nil); err == nil {
+		t.Error("expected an error for a tier without a backend")
+	}
+}
@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// timelinePath reports, per logical index, which of its documents are in
+// which stage of their lifecycle and what happened to it lately.
+const timelinePath = "/_oqbridge/timeline"
+
+// Lifecycle stages of the documents of an index, youngest first.
+const (
+	phaseCreated   = "created"   // in OpenSearch, not due for migration yet
+	phaseMigrating = "migrating" // due for migration but not below the watermark yet
+	phaseColdOnly  = "cold_only" // migrated to Quickwit
+	phaseExpired   = "expired"   // older than the Quickwit retention period
+)
+
+// RunReader reads the recorded migration runs of every index, e.g. a
+// migration.OpenSearchMetricsStore.
+type RunReader interface {
+	Summaries(ctx context.Context) (map[string]*migration.IndexRunSummary, error)
+}
+
+// WithMigrationRuns makes _oqbridge/timeline list the last migration run
+// of each index read from r.
+func WithMigrationRuns(r RunReader) Option {
+	return func(p *Proxy) {
+		p.runs = r
+	}
+}
+
+// checkpointReader is implemented by WatermarkReaders that also hold the
+// checkpoints of running migrations, such as a migration.CheckpointStore.
+type checkpointReader interface {
+	Load(index string) (*migration.Checkpoint, error)
+}
+
+// indexTimeline is one logical index of _oqbridge/timeline, as grouped by
+// _oqbridge/stats.
+type indexTimeline struct {
+	Index  string          `json:"index"`
+	Phases []timelinePhase `json:"phases"`
+	Events []timelineEvent `json:"events"`
+}
+
+// timelinePhase is the time range of documents in one lifecycle stage and
+// the backends holding them. A nil From means since the oldest document, a
+// nil To up to now.
+type timelinePhase struct {
+	Phase    string             `json:"phase"`
+	From     *time.Time         `json:"from,omitempty"`
+	To       *time.Time         `json:"to,omitempty"`
+	Backends []string           `json:"backends,omitempty"`
+	Progress *migrationProgress `json:"progress,omitempty"`
+}
+
+// migrationProgress sums the checkpoints of the migrations of an index
+// still running or interrupted.
+type migrationProgress struct {
+	Indices   []string  `json:"indices"`
+	StartedAt time.Time `json:"started_at"`
+	Migrated  int64     `json:"migrated"`
+	TotalDocs int64     `json:"total_docs"`
+}
+
+// timelineEvent is something that happened to a logical index: an
+// OpenSearch index was created, a migration started or ended.
+type timelineEvent struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Index  string    `json:"index,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// timelineResponse is the body of GET _oqbridge/timeline. Errors lists
+// the state that could not be read; it is left out of the timelines.
+type timelineResponse struct {
+	Now     time.Time        `json:"now"`
+	Indices []*indexTimeline `json:"indices"`
+	Errors  []string         `json:"errors,omitempty"`
+}
+
+// handleTimeline serves _oqbridge/timeline, authenticating the caller like
+// handleStats.
+func (p *Proxy) handleTimeline(w http.ResponseWriter, r *http.Request, patterns []string) {
+	if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for _oqbridge/timeline", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+	writeJSON(w, p.timeline(r.Context(), patterns, time.Now()))
+}
+
+// timeline builds the timelines of the indices matching patterns, or of
+// every index if there are none, from the figures of stats, the
+// checkpoints and recorded runs of their migrations, and the retention
+// settings at now.
+func (p *Proxy) timeline(ctx context.Context, patterns []string, now time.Time) *timelineResponse {
+	stats := p.stats(ctx, patterns)
+	resp := &timelineResponse{Now: now.UTC(), Indices: []*indexTimeline{}, Errors: stats.Errors}
+
+	var runs map[string]*migration.IndexRunSummary
+	if p.runs != nil {
+		var err error
+		if runs, err = p.runs.Summaries(ctx); err != nil {
+			resp.Errors = append(resp.Errors, "reading migration runs: "+err.Error())
+		}
+	}
+	for _, s := range stats.Indices {
+		t, err := p.indexTimeline(s, runs, now)
+		if err != nil {
+			resp.Errors = append(resp.Errors, fmt.Sprintf("reading checkpoints of %s: %v", s.Index, err))
+		}
+		resp.Indices = append(resp.Indices, t)
+	}
+	sort.Strings(resp.Errors)
+	return resp
+}
+
+// indexTimeline lays out the phases of s, oldest documents first: expired
+// before the Quickwit retention period, cold only below the watermark,
+// migrating up to the migration cutoff and created since. Without
+// delete_after_migration, OpenSearch keeps a copy of the migrated
+// documents until its indices are dropped.
+func (p *Proxy) indexTimeline(s *indexStats, runs map[string]*migration.IndexRunSummary, now time.Time) (*indexTimeline, error) {
+	cfg := p.live.Load().cfg
+	t := &indexTimeline{Index: s.Index, Phases: []timelinePhase{}, Events: []timelineEvent{}}
+	hotBackends := []string{"opensearch"}
+	for _, tier := range cfg.Tiers {
+		hotBackends = append(hotBackends, tier.Name)
+	}
+
+	var expiredBefore *time.Time
+	if d := cfg.ColdDaysForIndex(s.Index); d > 0 {
+		before := cfg.DaysAgo(now, d).UTC()
+		expiredBefore = &before
+		t.Phases = append(t.Phases, timelinePhase{Phase: phaseExpired, To: expiredBefore})
+	}
+
+	migratedBefore := s.MigratedBefore
+	if migratedBefore == nil && s.Cold != nil {
+		migratedBefore = s.Cold.MaxTimestamp
+	}
+	if migratedBefore != nil {
+		cold := timelinePhase{Phase: phaseColdOnly, From: expiredBefore, To: migratedBefore, Backends: []string{"quickwit"}}
+		if len(s.hotIndices) > 0 && !cfg.MigrationPolicyForIndex(s.hotIndices[0].Name).DeleteAfterMigration {
+			cold.Backends = append(cold.Backends, "opensearch")
+		}
+		t.Phases = append(t.Phases, cold)
+	}
+
+	var err error
+	if len(s.hotIndices) > 0 {
+		policy := cfg.MigrationPolicyForIndex(s.hotIndices[0].Name)
+		cutoff := cfg.DaysAgo(now, policy.MigrateAfterDays).UTC()
+		migrating := timelinePhase{Phase: phaseMigrating, From: migratedBefore, To: &cutoff, Backends: hotBackends}
+		migrating.Progress, err = p.migrationProgress(s)
+		if migratedBefore == nil || migratedBefore.Before(cutoff) || migrating.Progress != nil {
+			t.Phases = append(t.Phases, migrating)
+		}
+		created := timelinePhase{Phase: phaseCreated, From: &cutoff, Backends: hotBackends}
+		if cfg.DualWriteIndex(s.hotIndices[0].Name) {
+			created.Backends = append(created.Backends, "quickwit")
+		}
+		t.Phases = append(t.Phases, created)
+
+		for _, info := range s.hotIndices {
+			if !info.CreationDate.IsZero() {
+				t.Events = append(t.Events, timelineEvent{Time: info.CreationDate.UTC(), Event: "index_created", Index: info.Name})
+			}
+			if sum := runs[info.Name]; sum != nil && sum.LastRun != nil {
+				t.Events = append(t.Events, runEvent(sum.LastRun))
+			}
+		}
+		if migrating.Progress != nil {
+			t.Events = append(t.Events, timelineEvent{Time: migrating.Progress.StartedAt, Event: "migration_started",
+				Detail: fmt.Sprintf("%d of %d documents migrated", migrating.Progress.Migrated, migrating.Progress.TotalDocs)})
+		}
+	}
+	if s.LastMigration != nil {
+		t.Events = append(t.Events, timelineEvent{Time: *s.LastMigration, Event: "watermark_advanced",
+			Detail: "migrated before " + s.MigratedBefore.UTC().Format(time.RFC3339)})
+	}
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].Time.Before(t.Events[j].Time) })
+	return t, err
+}
+
+// migrationProgress sums the checkpoints of the OpenSearch indices of s, or
+// returns nil if none is migrating or the watermarks hold no checkpoints.
+func (p *Proxy) migrationProgress(s *indexStats) (*migrationProgress, error) {
+	checkpoints, ok := p.watermarks.(checkpointReader)
+	if !ok {
+		return nil, nil
+	}
+	var progress *migrationProgress
+	for _, info := range s.hotIndices {
+		cp, err := checkpoints.Load(info.Name)
+		if err != nil {
+			return progress, err
+		}
+		if cp == nil || cp.Completed {
+			continue
+		}
+		if progress == nil {
+			progress = &migrationProgress{StartedAt: cp.StartedAt}
+		}
+		progress.Indices = append(progress.Indices, info.Name)
+		progress.Migrated += cp.Migrated
+		progress.TotalDocs += cp.TotalDocs
+		if cp.StartedAt.Before(progress.StartedAt) {
+			progress.StartedAt = cp.StartedAt
+		}
+	}
+	return progress, nil
+}
+
+// runEvent describes a recorded migration run by its end.
+func runEvent(m *migration.MigrationMetric) timelineEvent {
+	e := timelineEvent{Time: m.CompletedAt, Event: "migration_succeeded", Index: m.Index,
+		Detail: fmt.Sprintf("%d documents migrated", m.DocumentsMigrated)}
+	if m.Status != "success" {
+		e.Event, e.Detail = "migration_failed", m.Error
+	}
+	return e
+}
@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// fakeCheckpoints adds checkpoints of running migrations to fakeWatermarks.
+type fakeCheckpoints struct {
+	fakeWatermarks
+	checkpoints map[string]*migration.Checkpoint
+}
+
+func (f fakeCheckpoints) Load(index string) (*migration.Checkpoint, error) {
+	return f.checkpoints[index], nil
+}
+
+type fakeRuns map[string]*migration.IndexRunSummary
+
+func (f fakeRuns) Summaries(context.Context) (map[string]*migration.IndexRunSummary, error) {
+	return f, nil
+}
+
+func TestProxy_Timeline(t *testing.T) {
+	os := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/_plugins/_security/authinfo":
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/_cat/indices/"):
+			w.Write([]byte(`[
+				{"index":"app-2026.10.01","health":"green","creation.date":"1759276800000","docs.count":"10","store.size":"1000"},
+				{"index":"app-2026.10.08","health":"green","creation.date":"1759881600000","docs.count":"5","store.size":"500"}]`))
+		default:
+			w.Write([]byte(`{"hits":{"total":{"value":15}},"aggregations":{"min_timestamp":{"value":1759276800000},"max_timestamp":{"value":1759967999000}}}`))
+		}
+	}))
+	defer os.Close()
+	qw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"app"}}]`))
+		case "/api/v1/indexes/app/describe":
+			w.Write([]byte(`{"num_published_docs":100,"num_published_splits":3,"size_published_splits":2048,"min_timestamp":1756684800,"max_timestamp":1759190400}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer qw.Close()
+
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: os.URL},
+		Quickwit:   config.QuickwitConfig{URL: qw.URL},
+		Retention:  config.RetentionConfig{Days: 30, ColdDays: 365, TimestampField: "@timestamp"},
+		Migration: config.MigrationConfig{MigrateAfterDays: 7, DeleteAfterMigration: true, Rules: []config.MigrationRule{
+			{Indices: []string{"app-*"}, TargetIndex: "app"},
+		}},
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	migrated := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	ran := time.Date(2026, 10, 2, 2, 0, 0, 0, time.UTC)
+	started := time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)
+	p, err := New(cfg, backend.NewOpenSearch(os.URL, "", "", nil), backend.NewQuickwit(qw.URL, "", "", false, nil), nil,
+		WithWatermarks(fakeCheckpoints{
+			fakeWatermarks: fakeWatermarks{"app-2026.10.01": {Index: "app-2026.10.01", MigratedBefore: migrated, UpdatedAt: ran}},
+			checkpoints:    map[string]*migration.Checkpoint{"app-2026.10.08": {Index: "app-2026.10.08", StartedAt: started, TotalDocs: 5, Migrated: 2}},
+		}),
+		WithMigrationRuns(fakeRuns{"app-2026.10.01": {Index: "app-2026.10.01", LastRun: &migration.MigrationMetric{
+			Index: "app-2026.10.01", CompletedAt: ran, Status: "failed", Error: "quickwit unavailable"}}}))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	resp := p.timeline(context.Background(), []string{"app*"}, now)
+	if len(resp.Errors) != 0 || len(resp.Indices) != 1 || resp.Indices[0].Index != "app" {
+		t.Fatalf("timeline = %+v", resp)
+	}
+	app := resp.Indices[0]
+	day := func(d int) string { return now.AddDate(0, 0, -d).Format("2006-01-02") }
+	want := []struct{ phase, from, to, backends string }{
+		{phaseExpired, "", day(365), ""},
+		{phaseColdOnly, day(365), "2026-10-01", "quickwit"},
+		{phaseMigrating, "2026-10-01", day(7), "opensearch"},
+		{phaseCreated, day(7), "", "opensearch"},
+	}
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	if len(app.Phases) != len(want) {
+		t.Fatalf("phases = %+v", app.Phases)
+	}
+	for i, w := range want {
+		got := app.Phases[i]
+		if got.Phase != w.phase || date(got.From) != w.from || date(got.To) != w.to || strings.Join(got.Backends, ",") != w.backends {
+			t.Errorf("phase %d = %s %s..%s %v, want %+v", i, got.Phase, date(got.From), date(got.To), got.Backends, w)
+		}
+	}
+	if pr := app.Phases[2].Progress; pr == nil || pr.Migrated != 2 || pr.TotalDocs != 5 || len(pr.Indices) != 1 || !pr.StartedAt.Equal(started) {
+		t.Errorf("progress = %+v", pr)
+	}
+
+	var events []string
+	for _, e := range app.Events {
+		events = append(events, e.Event+" "+e.Index)
+	}
+	if got := strings.Join(events, ", "); got != "index_created app-2026.10.01, index_created app-2026.10.08, migration_failed app-2026.10.01, watermark_advanced , migration_started " {
+		t.Errorf("events = %s", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/_oqbridge/timeline/app", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d: %s", w.Code, w.Body.String())
+	}
+}
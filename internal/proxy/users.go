@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+)
+
+// maxCachedUsers is how many clients a userCache remembers; expired entries
+// are dropped when it is full.
+const maxCachedUsers = 10000
+
+// authInfoFunc identifies the user of a request from its headers.
+type authInfoFunc func(ctx context.Context, h http.Header) (*backend.AuthInfo, error)
+
+// userCache remembers the OpenSearch user of each client, identified by
+// the values of some request headers, so that features acting per user
+// do not ask the security plugin on every request.
+type userCache struct {
+	auth    authInfoFunc
+	ttl     time.Duration
+	headers []string
+
+	mu    sync.Mutex
+	users map[string]cachedUser // by identity headers
+}
+
+type cachedUser struct {
+	info    *backend.AuthInfo
+	fetched time.Time
+}
+
+func newUserCache(auth authInfoFunc, ttl time.Duration, identityHeaders []string) *userCache {
+	return &userCache{
+		auth:    auth,
+		ttl:     ttl,
+		headers: identityHeaders,
+		users:   make(map[string]cachedUser),
+	}
+}
+
+// user returns the user of the client identified by h, asking OpenSearch
+// at most once per ttl.
+func (c *userCache) user(ctx context.Context, h http.Header) (*backend.AuthInfo, error) {
+	key := c.key(h)
+	now := time.Now()
+	c.mu.Lock()
+	u, ok := c.users[key]
+	c.mu.Unlock()
+	if ok && now.Sub(u.fetched) < c.ttl {
+		return u.info, nil
+	}
+
+	info, err := c.auth(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.users) >= maxCachedUsers {
+		for k, u := range c.users {
+			if now.Sub(u.fetched) >= c.ttl {
+				delete(c.users, k)
+			}
+		}
+		if len(c.users) >= maxCachedUsers {
+			clear(c.users)
+		}
+	}
+	c.users[key] = cachedUser{info: info, fetched: now}
+	return info, nil
+}
+
+func (c *userCache) key(h http.Header) string {
+	sum := sha256.New()
+	for _, name := range c.headers {
+		for _, v := range h.Values(name) {
+			sum.Write([]byte(v))
+			sum.Write([]byte{0})
+		}
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
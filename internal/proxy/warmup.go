@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// scheduleWarmups adds the server.warmups of cfg to p.jobs.
+func (p *Proxy) scheduleWarmups(cfg *config.Config) error {
+	for _, w := range cfg.Server.Warmups {
+		if _, err := p.jobs.AddFunc(w.Schedule, func() { p.runWarmup(context.Background(), w) }); err != nil {
+			return fmt.Errorf("server.warmups[%s]: invalid schedule %q: %w", w.Name, w.Schedule, err)
+		}
+	}
+	return nil
+}
+
+// runWarmup sends the search of w through the proxy with the service
+// account, like a client would, so that it takes the same route and its
+// Quickwit responses land in the cold cache under the keys the clients'
+// searches look up. The response itself is discarded.
+func (p *Proxy) runWarmup(ctx context.Context, w config.WarmupConfig) error {
+	start := time.Now()
+	query := w.Query
+	if query == nil {
+		query = map[string]any{}
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("encoding query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/"+strings.Join(w.Indices, ",")+"/_search", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.hotBackend.Credentials().Apply(req)
+
+	rec := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	p.ServeHTTP(rec, req)
+	if rec.status/100 != 2 {
+		err := fmt.Errorf("search returned status %d", rec.status)
+		slog.Warn("warm-up failed", "warmup", w.Name, "error", err)
+		return err
+	}
+	route, _ := p.RouteSearch(w.Indices, body)
+	slog.Info("warm-up ran", "warmup", w.Name, "route", route, "duration", time.Since(start))
+	return nil
+}
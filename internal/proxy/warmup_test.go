@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestProxy_Warmups(t *testing.T) {
+	var auth atomic.Value
+	hot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`))
+	}))
+	defer hot.Close()
+	var coldSearches atomic.Int32
+	cold := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coldSearches.Add(1)
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_score":1,"_source":{"msg":"cold"}}]}}`))
+	}))
+	defer cold.Close()
+
+	query := map[string]any{"query": map[string]any{"range": map[string]any{"@timestamp": map[string]any{"gte": "now-90d/d", "lt": "now-60d/d"}}}}
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: hot.URL},
+		Quickwit:   config.QuickwitConfig{URL: cold.URL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+	}
+	cfg.Server.ColdCache = config.ColdCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10, MaxHits: 100}
+	cfg.Server.Warmups = []config.WarmupConfig{{Name: "dashboards", Schedule: "0 7 * * 1-5", Indices: []string{"logs"}, Query: query}}
+	p, err := New(cfg, backend.NewOpenSearch(hot.URL, "svc", "secret", nil), backend.NewQuickwit(cold.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	if err := p.runWarmup(context.Background(), cfg.Server.Warmups[0]); err != nil {
+		t.Fatalf("runWarmup() error: %v", err)
+	}
+	if a, _ := auth.Load().(string); !strings.HasPrefix(a, "Basic ") {
+		t.Errorf("warm-up searched with Authorization %q, want the service account", a)
+	}
+	if coldSearches.Load() != 1 {
+		t.Fatalf("warm-up sent %d searches to Quickwit, want 1", coldSearches.Load())
+	}
+
+	// A client sending the same body is answered from the cache.
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search",
+		strings.NewReader(`{"query":{"range":{"@timestamp":{"gte":"now-90d/d","lt":"now-60d/d"}}}}`))
+	req.Header.Set("Authorization", validToken)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "cold") {
+		t.Fatalf("client search = %d: %s", w.Code, w.Body.String())
+	}
+	if coldSearches.Load() != 1 {
+		t.Errorf("client search after the warm-up sent %d searches to Quickwit in total, want 1", coldSearches.Load())
+	}
+}
+
+func TestProxy_WarmupsInvalidSchedule(t *testing.T) {
+	cfg := &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: "http://os:9200"},
+		Server: config.ServerConfig{Warmups: []config.WarmupConfig{
+			{Name: "bad", Schedule: "before work", Indices: []string{"logs"}},
+		}},
+	}
+	if _, err := New(cfg, backend.NewOpenSearch("http://os:9200", "", "", nil), nil, nil); err == nil || !strings.Contains(err.Error(), "server.warmups[bad]: invalid schedule") {
+		t.Errorf("New() error = %v", err)
+	}
+}
@@ -0,0 +1,462 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/migration"
+)
+
+// writeKind is a document write endpoint of OpenSearch.
+type writeKind int
+
+const (
+	writeNone writeKind = iota
+	writeBulk           // /_bulk, /{index}/_bulk
+	writeDoc            // /{index}/_doc[/{id}], /{index}/_create/{id}
+)
+
+var errBulkItems = errors.New("bulk response items do not match the request actions (filter_path?)")
+
+// parseWriteEndpoint returns the kind of a document write request and the
+// index named in its path, if any. Writes to internal indices (".") are
+// not reported.
+func parseWriteEndpoint(method, path string) (writeKind, string) {
+	if method != http.MethodPost && method != http.MethodPut {
+		return writeNone, ""
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "_bulk" {
+		return writeBulk, ""
+	}
+	if len(parts) < 2 || strings.HasPrefix(parts[0], "_") || strings.HasPrefix(parts[0], ".") {
+		return writeNone, ""
+	}
+	switch {
+	case len(parts) == 2 && parts[1] == "_bulk":
+		return writeBulk, parts[0]
+	case (len(parts) == 2 || len(parts) == 3) && parts[1] == "_doc":
+		return writeDoc, parts[0]
+	case len(parts) == 3 && parts[1] == "_create":
+		return writeDoc, parts[0]
+	}
+	return writeNone, ""
+}
+
+// handleWrite passes a document write through to OpenSearch. Documents of
+// a _bulk request that late_writes sends to Quickwit are split off first
+// (see handleLateBulk). The documents OpenSearch created in indices listed
+// in dual_write.indices are then queued for Quickwit; updates and deletes
+// are not mirrored, since Quickwit indices are append-only.
+func (p *Proxy) handleWrite(w http.ResponseWriter, r *http.Request, kind writeKind, pathIndex string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	// Let the transport negotiate and decode compression, so the response
+	// can be read here.
+	r.Header.Del("Accept-Encoding")
+
+	cfg := p.live.Load().cfg
+	// filter_path may drop the response items the late documents are
+	// merged into.
+	if kind == writeBulk && cfg.LateWrites.Enabled && !r.URL.Query().Has("filter_path") {
+		// A body that cannot be read is left for OpenSearch to reject.
+		if decoded, err := decodeBody(r.Header, body); err == nil {
+			if hot, late, total, err := splitLateDocs(cfg, decoded, pathIndex, time.Now()); err == nil && len(late) > 0 {
+				p.handleLateBulk(w, r, cfg, hot, late, total)
+				return
+			}
+		}
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	p.reverseProxy.ServeHTTP(rec, r)
+	if p.mirror == nil || rec.status/100 != 2 {
+		return
+	}
+	decoded, err := decodeBody(r.Header, body)
+	if err != nil {
+		slog.Warn("dual-write: cannot decode request body, documents not mirrored", "path", r.URL.Path, "error", err)
+		return
+	}
+	p.mirrorCreated(cfg, kind, decoded, rec.body.Bytes(), r.URL.Path)
+}
+
+// mirrorCreated queues the documents of a write request that OpenSearch
+// created, according to resp, in indices listed in dual_write.indices.
+func (p *Proxy) mirrorCreated(cfg *config.Config, kind writeKind, body, resp []byte, path string) {
+	var docs []mirrorDoc
+	var err error
+	switch kind {
+	case writeBulk:
+		docs, err = createdBulkDocs(body, resp)
+	case writeDoc:
+		docs, err = createdDoc(body, resp)
+	}
+	if err != nil {
+		slog.Warn("dual-write: cannot match the response to the request, documents not mirrored", "path", path, "error", err)
+		return
+	}
+	for _, d := range docs {
+		if cfg.DualWriteIndex(d.index) {
+			p.mirror.enqueue(d)
+		}
+	}
+}
+
+// decodeBody returns a request body without its gzip Content-Encoding.
+func decodeBody(h http.Header, body []byte) ([]byte, error) {
+	if h.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// lateDoc is a document of a _bulk request that late_writes sends to
+// Quickwit.
+type lateDoc struct {
+	pos   int    // position of its action in the request
+	op    string // "index" or "create"
+	index string // index named in the request
+	id    string
+	doc   json.RawMessage
+}
+
+// splitLateDocs takes the index and create actions whose document is older
+// than the hot retention period of its index out of a _bulk body, for
+// indices listed in late_writes.indices. It returns the remaining body, the
+// documents taken out and the number of actions in the request.
+func splitLateDocs(cfg *config.Config, body []byte, pathIndex string, now time.Time) ([]byte, []lateDoc, int, error) {
+	var (
+		hot   bytes.Buffer
+		late  []lateDoc
+		total int
+	)
+	lines := bytes.Split(body, []byte("\n"))
+	for i := 0; i < len(lines); i++ {
+		actionLine := bytes.TrimSpace(lines[i])
+		if len(actionLine) == 0 {
+			continue
+		}
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(actionLine, &action); err != nil || len(action) != 1 {
+			return nil, nil, 0, fmt.Errorf("line %d: malformed action", i+1)
+		}
+		var op string
+		for op = range action {
+		}
+		meta := action[op]
+		var source []byte
+		if op != "delete" {
+			if i++; i == len(lines) {
+				return nil, nil, 0, io.ErrUnexpectedEOF
+			}
+			if source = bytes.TrimSpace(lines[i]); len(source) == 0 {
+				return nil, nil, 0, fmt.Errorf("line %d: missing document", i+1)
+			}
+		}
+		pos := total
+		total++
+
+		index := meta.Index
+		if index == "" {
+			index = pathIndex
+		}
+		if (op == "index" || op == "create") && cfg.LateWriteIndex(index) {
+			ts, ok := migration.DocumentTimestamp(source, cfg.TimestampFieldForIndex(index))
+			if ok && ts.Before(cfg.DaysAgo(now, cfg.HotDaysForIndex(index))) {
+				late = append(late, lateDoc{pos: pos, op: op, index: index, id: meta.ID, doc: json.RawMessage(bytes.Clone(source))})
+				continue
+			}
+		}
+		hot.Write(actionLine)
+		hot.WriteByte('\n')
+		if source != nil {
+			hot.Write(source)
+			hot.WriteByte('\n')
+		}
+	}
+	return hot.Bytes(), late, total, nil
+}
+
+// handleLateBulk answers a _bulk request whose late documents were split
+// off: the rest of the body, if any, goes to OpenSearch, the late documents
+// are written to Quickwit, and the response items of both are merged in
+// request order. Without documents for OpenSearch, the client's credentials
+// are checked against OpenSearch before anything is written.
+func (p *Proxy) handleLateBulk(w http.ResponseWriter, r *http.Request, cfg *config.Config, hot []byte, late []lateDoc, total int) {
+	var hotResult struct {
+		Took   int64             `json:"took"`
+		Errors bool              `json:"errors"`
+		Items  []json.RawMessage `json:"items"`
+	}
+	if len(hot) > 0 {
+		out := r.Clone(r.Context())
+		out.Body = io.NopCloser(bytes.NewReader(hot))
+		out.ContentLength = int64(len(hot))
+		out.Header.Del("Content-Encoding")
+		out.Header.Del("Content-Length")
+		resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		p.reverseProxy.ServeHTTP(resp, out)
+		if resp.status/100 != 2 {
+			resp.copyTo(w)
+			return
+		}
+		if err := json.Unmarshal(resp.body.Bytes(), &hotResult); err != nil || len(hotResult.Items) != total-len(late) {
+			slog.Warn("late writes: unexpected _bulk response from opensearch, late documents not written", "path", r.URL.Path, "error", err)
+			http.Error(w, `{"error":"unexpected _bulk response from OpenSearch"}`, http.StatusBadGateway)
+			return
+		}
+		if p.mirror != nil {
+			p.mirrorCreated(cfg, writeBulk, hot, resp.body.Bytes(), r.URL.Path)
+		}
+	} else if err := p.authenticateViaOpenSearch(r.Context(), r.Header); err != nil {
+		status := http.StatusBadGateway
+		if isAuthError(err) {
+			status = statusFromAuthError(err)
+		}
+		slog.Warn("auth failed for late bulk write", "status", status, "error", err)
+		http.Error(w, `{"error":"authentication failed"}`, status)
+		return
+	}
+
+	start := time.Now()
+	var order []string
+	byIndex := make(map[string][]json.RawMessage)
+	for _, d := range late {
+		if _, ok := byIndex[d.index]; !ok {
+			order = append(order, d.index)
+		}
+		byIndex[d.index] = append(byIndex[d.index], d.doc)
+	}
+	failed := make(map[string]error)
+	for _, index := range order {
+		docs := byIndex[index]
+		target, err := p.writer.transform(cfg, index, docs)
+		if err == nil {
+			err = p.writer.ingest(r.Context(), cfg, index, target, docs)
+		}
+		if err != nil {
+			slog.Warn("late writes: quickwit ingest failed", "index", index, "docs", len(docs), "error", err)
+			failed[index] = err
+			continue
+		}
+		slog.Debug("late documents written to quickwit", "index", index, "target", target, "docs", len(docs))
+	}
+
+	items := make([]json.RawMessage, total)
+	errs := hotResult.Errors
+	for _, d := range late {
+		items[d.pos] = lateItem(d, failed[d.index])
+		errs = errs || failed[d.index] != nil
+	}
+	next := 0
+	for i := range items {
+		if items[i] == nil {
+			items[i] = hotResult.Items[next]
+			next++
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"took":   hotResult.Took + time.Since(start).Milliseconds(),
+		"errors": errs,
+		"items":  items,
+	})
+}
+
+// lateItem is the _bulk response item of a late document, failed with a
+// retryable status if ingestErr is set.
+func lateItem(d lateDoc, ingestErr error) json.RawMessage {
+	res := map[string]interface{}{"_index": d.index, "status": http.StatusCreated, "result": "created"}
+	if d.id != "" {
+		res["_id"] = d.id
+	}
+	if ingestErr != nil {
+		res = map[string]interface{}{
+			"_index": d.index,
+			"status": http.StatusServiceUnavailable,
+			"error": map[string]string{
+				"type":   "quickwit_ingest_exception",
+				"reason": "oqbridge could not write the document to Quickwit: " + ingestErr.Error(),
+			},
+		}
+	}
+	item, _ := json.Marshal(map[string]interface{}{d.op: res})
+	return item
+}
+
+// createdBulkDocs returns the sources of the index and create actions of a
+// _bulk request that created a document, with the index OpenSearch wrote
+// each to, as given in the response items.
+func createdBulkDocs(body, resp []byte) ([]mirrorDoc, error) {
+	var result struct {
+		Items []map[string]struct {
+			Index  string `json:"_index"`
+			Status int    `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+
+	var docs []mirrorDoc
+	item := 0
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 64<<10), len(body)+1)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]json.RawMessage
+		if err := json.Unmarshal(line, &action); err != nil {
+			return nil, err
+		}
+		var op string
+		for name := range action {
+			op = name
+		}
+		var source []byte
+		if op != "delete" {
+			if !sc.Scan() {
+				return nil, io.ErrUnexpectedEOF
+			}
+			source = bytes.TrimSpace(sc.Bytes())
+		}
+		if item >= len(result.Items) {
+			return nil, errBulkItems
+		}
+		res, ok := result.Items[item][op]
+		item++
+		if !ok || (op != "index" && op != "create") || res.Status != http.StatusCreated {
+			continue
+		}
+		docs = append(docs, mirrorDoc{index: res.Index, doc: json.RawMessage(bytes.Clone(source))})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if item != len(result.Items) {
+		return nil, errBulkItems
+	}
+	return docs, nil
+}
+
+// createdDoc returns the source of a single document write if it created
+// the document.
+func createdDoc(body, resp []byte) ([]mirrorDoc, error) {
+	var result struct {
+		Index  string `json:"_index"`
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	if result.Result != "created" {
+		return nil, nil
+	}
+	return []mirrorDoc{{index: result.Index, doc: json.RawMessage(bytes.TrimSpace(body))}}, nil
+}
+
+// bufferedResponse holds a response instead of passing it to the client.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+// copyTo sends the held response to w.
+func (b *bufferedResponse) copyTo(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
+
+// responseRecorder passes a response through to the client and keeps a
+// copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// coldWriter writes documents sent to an OpenSearch index through the proxy
+// into Quickwit the way migration would: into the Quickwit index of its
+// migration rule, edited by the rule's transforms, creating the index on
+// first use.
+type coldWriter struct {
+	cold    ColdBackend
+	created sync.Map // Quickwit indices known to exist
+}
+
+// transform edits docs, written to index, in place and returns the
+// Quickwit index they belong in.
+func (c *coldWriter) transform(cfg *config.Config, index string, docs []json.RawMessage) (string, error) {
+	policy := cfg.MigrationPolicyForIndex(index)
+	if err := migration.ApplyTransforms(docs, policy.Transforms); err != nil {
+		return "", err
+	}
+	return policy.TargetIndex, nil
+}
+
+// ingest sends docs transformed for index to the Quickwit index target.
+func (c *coldWriter) ingest(ctx context.Context, cfg *config.Config, index, target string, docs []json.RawMessage) error {
+	if _, ok := c.created.Load(target); !ok {
+		exists, err := c.cold.IndexExists(ctx, target)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			slog.Info("creating quickwit index for documents written through the proxy", "index", target)
+			if err := c.cold.CreateIndex(ctx, target, cfg.TimestampFieldForIndex(index), cfg.ColdDaysForIndex(target)); err != nil {
+				return err
+			}
+		}
+		c.created.Store(target, struct{}{})
+	}
+	return c.cold.BulkIngest(ctx, target, docs)
+}
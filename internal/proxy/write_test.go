@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func lateWritesConfig(osURL, qwURL string) *config.Config {
+	return &config.Config{
+		OpenSearch: config.OpenSearchConfig{URL: osURL},
+		Quickwit:   config.QuickwitConfig{URL: qwURL},
+		Retention:  config.RetentionConfig{Days: 30, TimestampField: "@timestamp"},
+		LateWrites: config.LateWritesConfig{Enabled: true, Indices: []string{"logs*"}},
+	}
+}
+
+func TestProxy_LateWrites(t *testing.T) {
+	var hotBody string
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		hotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"took":3,"errors":false,"items":[
+			{"index":{"_index":"logs","status":201}},
+			{"index":{"_index":"metrics","status":201}}]}`))
+	}))
+	defer osSrv.Close()
+
+	var mu sync.Mutex
+	ingested := make(map[string][]string)
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if index, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/ingest"); ok {
+			sc := bufio.NewScanner(r.Body)
+			mu.Lock()
+			for sc.Scan() {
+				ingested[index] = append(ingested[index], sc.Text())
+			}
+			mu.Unlock()
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer qwSrv.Close()
+
+	p, err := New(lateWritesConfig(osSrv.URL, qwSrv.URL), backend.NewOpenSearch(osSrv.URL, "", "", nil), backend.NewQuickwit(qwSrv.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	now := time.Now().UTC()
+	recent := now.Format(time.RFC3339)
+	old := now.AddDate(0, 0, -60).Format(time.RFC3339)
+	bulk := `{"index":{"_index":"logs","_id":"a"}}
+{"@timestamp":"` + old + `","msg":"late"}
+{"index":{}}
+{"@timestamp":"` + recent + `","msg":"recent"}
+{"index":{"_index":"metrics"}}
+{"@timestamp":"` + old + `","msg":"not a late_writes index"}
+`
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/logs/_bulk", strings.NewReader(bulk)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	if strings.Contains(hotBody, `"late"`) || !strings.Contains(hotBody, `"recent"`) || !strings.Contains(hotBody, "not a late_writes index") {
+		t.Errorf("opensearch got %q", hotBody)
+	}
+	if got := ingested["logs"]; len(got) != 1 || !strings.Contains(got[0], `"late"`) {
+		t.Errorf("quickwit ingested %v", got)
+	}
+
+	var resp struct {
+		Errors bool                                    `json:"errors"`
+		Items  []map[string]map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	var statuses []string
+	for _, item := range resp.Items {
+		statuses = append(statuses, string(item["index"]["_index"])+" "+string(item["index"]["status"]))
+	}
+	if want := []string{`"logs" 201`, `"logs" 201`, `"metrics" 201`}; resp.Errors || !slices.Equal(statuses, want) {
+		t.Errorf("items = %v (errors %v), want %v", statuses, resp.Errors, want)
+	}
+	if id := string(resp.Items[0]["index"]["_id"]); id != `"a"` {
+		t.Errorf("late item _id = %s, want \"a\"", id)
+	}
+}
+
+func TestProxy_LateWritesIngestFailure(t *testing.T) {
+	osSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_bulk" || strings.HasSuffix(r.URL.Path, "/_bulk") {
+			t.Errorf("unexpected %s: every document is late", r.URL.Path)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer osSrv.Close()
+	qwSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer qwSrv.Close()
+
+	p, err := New(lateWritesConfig(osSrv.URL, qwSrv.URL), backend.NewOpenSearch(osSrv.URL, "", "", nil), backend.NewQuickwit(qwSrv.URL, "", "", false, nil), nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	old := time.Now().UTC().AddDate(0, 0, -60).Format(time.RFC3339)
+	bulk := `{"create":{"_index":"logs"}}
+{"@timestamp":"` + old + `"}
+`
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(bulk)))
+	var resp struct {
+		Errors bool                                    `json:"errors"`
+		Items  []map[string]map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+	if !resp.Errors || len(resp.Items) != 1 || string(resp.Items[0]["create"]["status"]) != "503" {
+		t.Errorf("response = %s, want one failed create item", w.Body.String())
+	}
+}
+
+func TestSplitLateDocs_Malformed(t *testing.T) {
+	cfg := lateWritesConfig("http://os:9200", "http://qw:7280")
+	if _, _, _, err := splitLateDocs(cfg, []byte("{\"index\":{}}\n"), "logs", time.Now()); err == nil {
+		t.Error("expected an error for an action without a document")
+	}
+	if _, _, _, err := splitLateDocs(cfg, []byte("not json\n"), "logs", time.Now()); err == nil {
+		t.Error("expected an error for a malformed action")
+	}
+}
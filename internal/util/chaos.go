@@ -0,0 +1,79 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// faultTransport injects the faults of a chaos leg into requests before
+// passing them to base.
+type faultTransport struct {
+	base    http.RoundTripper
+	backend string
+	cfg     config.FaultConfig
+}
+
+// NewFaultTransport wraps base (http.DefaultTransport if nil) to delay,
+// fail or drop requests to backend as fc says. It returns base unchanged
+// when fc injects nothing.
+func NewFaultTransport(base http.RoundTripper, backend string, fc config.FaultConfig) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if (fc.LatencyRate == 0 || fc.Latency == 0) && fc.ErrorRate == 0 && fc.DropRate == 0 {
+		return base
+	}
+	return &faultTransport{base: base, backend: backend, cfg: fc}
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cfg.Latency > 0 && rand.Float64() < t.cfg.LatencyRate {
+		timer := time.NewTimer(t.cfg.Latency)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+	if rand.Float64() < t.cfg.DropRate {
+		slog.Debug("chaos: dropping request", "backend", t.backend, "method", req.Method, "path", req.URL.Path)
+		closeBody(req)
+		return nil, fmt.Errorf("chaos: connection to %s dropped: %w", t.backend, syscall.ECONNRESET)
+	}
+	if rand.Float64() < t.cfg.ErrorRate {
+		slog.Debug("chaos: failing request", "backend", t.backend, "method", req.Method, "path", req.URL.Path, "status", t.cfg.ErrorStatus)
+		closeBody(req)
+		body := fmt.Sprintf(`{"error":{"type":"chaos_injected","reason":"fault injected by oqbridge chaos"},"status":%d}`, t.cfg.ErrorStatus)
+		return &http.Response{
+			Status:        strconv.Itoa(t.cfg.ErrorStatus) + " " + http.StatusText(t.cfg.ErrorStatus),
+			StatusCode:    t.cfg.ErrorStatus,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return t.base.RoundTrip(req)
+}
+
+// closeBody closes the body of a request that is not sent, as a
+// RoundTripper must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
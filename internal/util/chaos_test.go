@@ -0,0 +1,56 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestFaultTransport(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if rt := NewFaultTransport(http.DefaultTransport, "opensearch", config.FaultConfig{ErrorStatus: 503}); rt != http.DefaultTransport {
+		t.Error("NewFaultTransport() wrapped a transport without faults")
+	}
+
+	do := func(ctx context.Context, fc config.FaultConfig) (*http.Response, error) {
+		rt := NewFaultTransport(nil, "quickwit", fc)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	resp, err := do(context.Background(), config.FaultConfig{ErrorRate: 1, ErrorStatus: 502})
+	if err != nil || resp.StatusCode != http.StatusBadGateway || calls.Load() != 0 {
+		t.Errorf("error fault = %v, %v after %d backend calls", resp, err, calls.Load())
+	}
+	if _, err := do(context.Background(), config.FaultConfig{DropRate: 1}); !errors.Is(err, syscall.ECONNRESET) || calls.Load() != 0 {
+		t.Errorf("drop fault error = %v", err)
+	}
+
+	start := time.Now()
+	resp, err = do(context.Background(), config.FaultConfig{LatencyRate: 1, Latency: 50 * time.Millisecond})
+	if err != nil || resp.StatusCode != http.StatusOK || time.Since(start) < 50*time.Millisecond || calls.Load() != 1 {
+		t.Errorf("latency fault = %v, %v after %v", resp, err, time.Since(start))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := do(ctx, config.FaultConfig{LatencyRate: 1, Latency: time.Minute}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("latency fault past the deadline error = %v", err)
+	}
+}
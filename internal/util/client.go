@@ -0,0 +1,59 @@
+package util
+
+import (
+	"net/http"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// NewOpenSearchClient builds the *http.Client used for OpenSearch: the TLS
+// and connection pool settings of oc, its User-Agent and static headers,
+// SigV4 signing when oc.SigV4 is enabled, and retries of transient failures.
+// Each attempt is recorded in DefaultHTTPMetrics.
+func NewOpenSearchClient(oc config.OpenSearchConfig) (*http.Client, error) {
+	rt, err := NewOpenSearchTransport(oc)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: NewRetryTransport(rt, oc.Retry)}, nil
+}
+
+// NewOpenSearchTransport builds the transport of the reverse proxy: the TLS,
+// connection pool, header and signing settings of oc, without retries.
+// Requests are recorded in DefaultHTTPMetrics.
+func NewOpenSearchTransport(oc config.OpenSearchConfig) (http.RoundTripper, error) {
+	var base http.RoundTripper
+	t, err := NewTLSTransport(oc.TLSConfig, oc.Transport)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil {
+		base = t
+	}
+	if oc.SigV4.Enabled {
+		// Headers are set before signing so they are covered by the signature.
+		if base, err = NewSigV4Transport(oc.SigV4, base); err != nil {
+			return nil, err
+		}
+	}
+	base = NewHeaderTransport(base, oc.UserAgent, oc.Headers)
+	return NewInstrumentedTransport(base, "opensearch", DefaultHTTPMetrics), nil
+}
+
+// NewQuickwitClient builds the *http.Client used for Quickwit: the TLS and
+// connection pool settings of qc, its User-Agent and static headers, and
+// retries of transient failures. Each attempt is recorded in
+// DefaultHTTPMetrics.
+func NewQuickwitClient(qc config.QuickwitConfig) (*http.Client, error) {
+	t, err := NewTLSTransport(qc.TLSConfig, qc.Transport)
+	if err != nil {
+		return nil, err
+	}
+	var base http.RoundTripper
+	if t != nil {
+		base = t
+	}
+	base = NewHeaderTransport(base, qc.UserAgent, qc.Headers)
+	base = NewInstrumentedTransport(base, "quickwit", DefaultHTTPMetrics)
+	return &http.Client{Transport: NewRetryTransport(base, qc.Retry)}, nil
+}
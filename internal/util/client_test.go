@@ -0,0 +1,53 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestNewOpenSearchTransport_Default(t *testing.T) {
+	rt, err := NewOpenSearchTransport(config.OpenSearchConfig{})
+	if err != nil {
+		t.Fatalf("NewOpenSearchTransport: %v", err)
+	}
+	it, ok := rt.(*instrumentedTransport)
+	if !ok || it.base != http.DefaultTransport {
+		t.Fatalf("transport=%T, want an instrumented http.DefaultTransport", rt)
+	}
+}
+
+func TestNewQuickwitClient_UserAgentAndHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client, err := NewQuickwitClient(config.QuickwitConfig{
+		UserAgent: "oqbridge-migrate/1.2.3",
+		Headers:   map[string]string{"X-Route": "search-cold"},
+	})
+	if err != nil {
+		t.Fatalf("NewQuickwitClient: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("X-Route", "client-value")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if ua := got.Get("User-Agent"); ua != "oqbridge-migrate/1.2.3" {
+		t.Fatalf("User-Agent=%q, want oqbridge-migrate/1.2.3", ua)
+	}
+	if v := got.Get("X-Route"); v != "search-cold" {
+		t.Fatalf("X-Route=%q, want the static value", v)
+	}
+	if req.Header.Get("X-Route") != "client-value" {
+		t.Fatal("the caller's request must not be modified")
+	}
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// clientCertLoader serves a mutual TLS client certificate from disk. Before
+// each handshake it checks the files' modification times and reloads the
+// pair when either changed, so rotated certificates are used by new
+// connections without a restart.
+type clientCertLoader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// newClientCertLoader loads the key pair once so misconfiguration fails at
+// startup rather than on the first request.
+func newClientCertLoader(certFile, keyFile string) (*clientCertLoader, error) {
+	l := &clientCertLoader{certFile: certFile, keyFile: keyFile}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate. If a
+// changed pair cannot be loaded (e.g. the files are mid-rotation), the
+// previous certificate keeps being used.
+func (l *clientCertLoader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.changed() {
+		if err := l.reload(); err != nil {
+			slog.Warn("failed to reload client certificate, keeping the previous one", "cert", l.certFile, "error", err)
+		} else {
+			slog.Info("reloaded client certificate", "cert", l.certFile)
+		}
+	}
+	return l.cert, nil
+}
+
+func (l *clientCertLoader) changed() bool {
+	certMod, keyMod, err := l.modTimes()
+	return err == nil && (!certMod.Equal(l.certMod) || !keyMod.Equal(l.keyMod))
+}
+
+func (l *clientCertLoader) reload() error {
+	certMod, keyMod, err := l.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading client certificate %s: %w", l.certFile, err)
+	}
+	l.cert, l.certMod, l.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func (l *clientCertLoader) modTimes() (certMod, keyMod time.Time, err error) {
+	ci, err := os.Stat(l.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reading client certificate: %w", err)
+	}
+	ki, err := os.Stat(l.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reading client key: %w", err)
+	}
+	return ci.ModTime(), ki.ModTime(), nil
+}
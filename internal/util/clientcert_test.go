@@ -0,0 +1,120 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// writeClientCert writes a self-signed certificate with the given common
+// name and its key to dir, returning their paths.
+func writeClientCert(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewHTTPClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCert(t, dir, "oqbridge")
+
+	var gotCN string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCN = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client, err := NewHTTPClient(config.TLSConfig{SkipVerify: true, ClientCert: certFile, ClientKey: keyFile}, config.TransportConfig{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if gotCN != "oqbridge" {
+		t.Fatalf("client certificate CN=%q, want oqbridge", gotCN)
+	}
+}
+
+func TestClientCertLoader_ReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCert(t, dir, "first")
+	l, err := newClientCertLoader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newClientCertLoader: %v", err)
+	}
+
+	writeClientCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	os.Chtimes(keyFile, future, future)
+
+	cert, err := l.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetClientCertificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Subject.CommonName != "second" {
+		t.Fatalf("CN=%q after rotation, want second", leaf.Subject.CommonName)
+	}
+
+	// A broken pair mid-rotation keeps the previous certificate.
+	os.WriteFile(keyFile, []byte("garbage"), 0600)
+	later := future.Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	if again, err := l.GetClientCertificate(nil); err != nil || again != cert {
+		t.Fatalf("GetClientCertificate after bad rotation = %v, %v; want previous certificate", again, err)
+	}
+}
+
+func TestNewHTTPClient_MissingClientCert(t *testing.T) {
+	_, err := NewHTTPClient(config.TLSConfig{ClientCert: "/nonexistent.crt", ClientKey: "/nonexistent.key"}, config.TransportConfig{})
+	if err == nil {
+		t.Fatal("expected error for missing client certificate")
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import "net/http"
+
+// headerTransport sets a User-Agent and static headers on every request.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+// NewHeaderTransport wraps base (http.DefaultTransport if nil) so every
+// request carries userAgent, when non-empty, and headers. Static headers
+// replace any value already set on the request.
+func NewHeaderTransport(base http.RoundTripper, userAgent string, headers map[string]string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if userAgent == "" && len(headers) == 0 {
+		return base
+	}
+	return &headerTransport{base: base, userAgent: userAgent, headers: headers}
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if t.userAgent != "" {
+		r.Header.Set("User-Agent", t.userAgent)
+	}
+	for name, value := range t.headers {
+		r.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,280 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the backend request
+// latency histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// DefaultHTTPMetrics collects every instrumented backend call of the process.
+var DefaultHTTPMetrics = NewHTTPMetrics()
+
+// HTTPMetrics aggregates backend HTTP calls per backend, method and endpoint
+// and renders them in the Prometheus text format.
+type HTTPMetrics struct {
+	mu     sync.Mutex
+	series map[seriesKey]*httpSeries
+}
+
+type seriesKey struct {
+	backend, method, endpoint string
+}
+
+type httpSeries struct {
+	buckets  []uint64 // cumulative counts per latencyBuckets entry
+	count    uint64
+	sum      float64
+	codes    map[string]uint64 // status code, or "error" for transport errors
+	retries  uint64
+	bytesOut int64
+	bytesIn  int64
+}
+
+// NewHTTPMetrics returns an empty collector.
+func NewHTTPMetrics() *HTTPMetrics {
+	return &HTTPMetrics{series: make(map[seriesKey]*httpSeries)}
+}
+
+func (m *HTTPMetrics) get(key seriesKey) *httpSeries {
+	s := m.series[key]
+	if s == nil {
+		s = &httpSeries{buckets: make([]uint64, len(latencyBuckets)), codes: make(map[string]uint64)}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (m *HTTPMetrics) observe(key seriesKey, code string, d time.Duration, retry bool, bytesOut int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.get(key)
+	secs := d.Seconds()
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += secs
+	s.codes[code]++
+	if retry {
+		s.retries++
+	}
+	s.bytesOut += bytesOut
+}
+
+func (m *HTTPMetrics) addBytesIn(key seriesKey, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(key).bytesIn += n
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text format.
+func (m *HTTPMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the collected metrics to w in the Prometheus text
+// format, with series in a stable order.
+func (m *HTTPMetrics) WritePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]seriesKey, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.backend != b.backend {
+			return a.backend < b.backend
+		}
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		return a.method < b.method
+	})
+	labels := func(k seriesKey) string {
+		return fmt.Sprintf(`backend=%q,method=%q,endpoint=%q`, k.backend, k.method, k.endpoint)
+	}
+
+	fmt.Fprintln(w, "# HELP oqbridge_backend_request_duration_seconds Time until response headers were received from a backend.")
+	fmt.Fprintln(w, "# TYPE oqbridge_backend_request_duration_seconds histogram")
+	for _, k := range keys {
+		s := m.series[k]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "oqbridge_backend_request_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels(k), le, s.buckets[i])
+		}
+		fmt.Fprintf(w, "oqbridge_backend_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels(k), s.count)
+		fmt.Fprintf(w, "oqbridge_backend_request_duration_seconds_sum{%s} %g\n", labels(k), s.sum)
+		fmt.Fprintf(w, "oqbridge_backend_request_duration_seconds_count{%s} %d\n", labels(k), s.count)
+	}
+
+	fmt.Fprintln(w, "# HELP oqbridge_backend_requests_total Backend requests by status code; code is \"error\" when no response was received.")
+	fmt.Fprintln(w, "# TYPE oqbridge_backend_requests_total counter")
+	for _, k := range keys {
+		s := m.series[k]
+		codes := make([]string, 0, len(s.codes))
+		for c := range s.codes {
+			codes = append(codes, c)
+		}
+		sort.Strings(codes)
+		for _, c := range codes {
+			fmt.Fprintf(w, "oqbridge_backend_requests_total{%s,code=%q} %d\n", labels(k), c, s.codes[c])
+		}
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(*httpSeries) int64
+	}{
+		{"oqbridge_backend_retries_total", "Backend requests that were retries of a failed attempt.", func(s *httpSeries) int64 { return int64(s.retries) }},
+		{"oqbridge_backend_request_bytes_total", "Request body bytes sent to a backend, for requests whose length is known up front.", func(s *httpSeries) int64 { return s.bytesOut }},
+		{"oqbridge_backend_response_bytes_total", "Response body bytes read from a backend.", func(s *httpSeries) int64 { return s.bytesIn }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s{%s} %d\n", c.name, labels(k), c.value(m.series[k]))
+		}
+	}
+}
+
+// retryAttemptKey marks a request as a retry in its context; the value is
+// the attempt number.
+type retryAttemptKey struct{}
+
+func withRetryAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, attempt)
+}
+
+func retryAttempt(ctx context.Context) int {
+	if n, ok := ctx.Value(retryAttemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// instrumentedTransport records every request in metrics and logs it at
+// debug level once its response body is closed.
+type instrumentedTransport struct {
+	base    http.RoundTripper
+	backend string
+	metrics *HTTPMetrics
+}
+
+// NewInstrumentedTransport wraps base (http.DefaultTransport if nil) so each
+// request to backend is recorded in m: latency, status code, whether it was
+// a retry, and request and response body bytes. Placed inside a retry
+// transport, each attempt is recorded separately.
+func NewInstrumentedTransport(base http.RoundTripper, backend string, m *HTTPMetrics) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base: base, backend: backend, metrics: m}
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := seriesKey{backend: t.backend, method: req.Method, endpoint: endpointLabel(req.URL.Path)}
+	attempt := retryAttempt(req.Context())
+	bytesOut := max(req.ContentLength, 0)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.metrics.observe(key, "error", elapsed, attempt > 1, bytesOut)
+		slog.Debug("backend request failed", "backend", t.backend, "method", req.Method, "endpoint", key.endpoint,
+			"attempt", attempt, "duration", elapsed, "error", err)
+		return nil, err
+	}
+	t.metrics.observe(key, fmt.Sprint(resp.StatusCode), elapsed, attempt > 1, bytesOut)
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// The reverse proxy needs the upgraded body's io.ReadWriteCloser.
+		return resp, nil
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64) {
+		t.metrics.addBytesIn(key, n)
+		slog.Debug("backend request", "backend", t.backend, "method", req.Method, "endpoint", key.endpoint,
+			"status", resp.StatusCode, "attempt", attempt, "duration", elapsed, "bytes_out", bytesOut, "bytes_in", n)
+	}}
+	return resp, nil
+}
+
+// countingBody counts the bytes read from a response body and reports the
+// total once, when the body is closed.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
+
+// endpointKeywords are path segments kept verbatim in endpoint labels. Any
+// other segment that does not start with "_" is an index, document, task or
+// repository name and is replaced by a placeholder to bound cardinality.
+var endpointKeywords = map[string]bool{
+	"api": true, "v1": true, "v2": true, "indexes": true, "search": true, "ingest": true,
+	"describe": true, "splits": true, "mark-splits-for-deletion": true, "cluster": true,
+	"health": true, "livez": true, "readyz": true, "version": true,
+	"indices": true, "aliases": true, "stats": true, "jvm": true, "authinfo": true,
+}
+
+// endpointLabel reduces a request path to a low-cardinality label, e.g.
+// "/logs-2026.01.01/_search" becomes "/{name}/_search".
+func endpointLabel(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) == 1 && segs[0] == "" {
+		return "/"
+	}
+	for i, s := range segs {
+		if !strings.HasPrefix(s, "_") && !endpointKeywords[s] {
+			segs[i] = "{name}"
+		}
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+// ServeMetrics serves DefaultHTTPMetrics at /metrics on addr in the
+// background, along with extra, keyed by ServeMux pattern. Failing to
+// listen is logged, not fatal; the caller shuts the returned server down on
+// exit.
+func ServeMetrics(addr string, extra map[string]http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", DefaultHTTPMetrics)
+	for pattern, h := range extra {
+		mux.Handle(pattern, h)
+	}
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		slog.Info("metrics listening", "addr", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server error", "error", err)
+		}
+	}()
+	return server
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	tests := map[string]string{
+		"":                                      "/",
+		"/logs-2026.01.01/_search":              "/{name}/_search",
+		"/_cat/indices/logs-*":                  "/_cat/indices/{name}",
+		"/_tasks/node1:42/_cancel":              "/_tasks/{name}/_cancel",
+		"/api/v1/logs/search":                   "/api/v1/{name}/search",
+		"/api/v1/indexes/logs/describe":         "/api/v1/indexes/{name}/describe",
+		"/api/v1/_elastic/logs/_search":         "/api/v1/_elastic/{name}/_search",
+		"/_plugins/_security/authinfo":          "/_plugins/_security/authinfo",
+		"/.oqbridge-checkpoints/_doc/logs-2026": "/{name}/_doc/{name}",
+	}
+	for path, want := range tests {
+		if got := endpointLabel(path); got != want {
+			t.Errorf("endpointLabel(%q)=%q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestInstrumentedTransport_RecordsAttempts(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"count":1}`))
+	}))
+	defer srv.Close()
+
+	m := NewHTTPMetrics()
+	client := &http.Client{Transport: NewRetryTransport(NewInstrumentedTransport(nil, "opensearch", m), testRetry)}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/logs/_doc/1", strings.NewReader(`{"a":1}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	var out bytes.Buffer
+	m.WritePrometheus(&out)
+	labels := `backend="opensearch",method="PUT",endpoint="/{name}/_doc/{name}"`
+	for _, want := range []string{
+		`oqbridge_backend_request_duration_seconds_count{` + labels + `} 2`,
+		`oqbridge_backend_request_duration_seconds_bucket{` + labels + `,le="+Inf"} 2`,
+		`oqbridge_backend_requests_total{` + labels + `,code="200"} 1`,
+		`oqbridge_backend_requests_total{` + labels + `,code="503"} 1`,
+		`oqbridge_backend_retries_total{` + labels + `} 1`,
+		`oqbridge_backend_request_bytes_total{` + labels + `} 14`,
+		`oqbridge_backend_response_bytes_total{` + labels + `} 11`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestInstrumentedTransport_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	m := NewHTTPMetrics()
+	client := &http.Client{Transport: NewInstrumentedTransport(nil, "quickwit", m)}
+	if _, err := client.Get(url + "/api/v1/logs/search"); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	var out bytes.Buffer
+	m.WritePrometheus(&out)
+	want := `oqbridge_backend_requests_total{backend="quickwit",method="GET",endpoint="/api/v1/{name}/search",code="error"} 1`
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("metrics missing %q:\n%s", want, out.String())
+	}
+}
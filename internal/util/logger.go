@@ -1,6 +1,7 @@
 package util
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -8,6 +9,12 @@ import (
 
 // SetupLogger initializes the default slog logger with the given level string.
 func SetupLogger(level string) {
+	SetupLoggerOutput(level, os.Stdout)
+}
+
+// SetupLoggerOutput is SetupLogger writing to w, for commands that reserve
+// stdout for machine-readable output.
+func SetupLoggerOutput(level string, w io.Writer) {
 	var l slog.Level
 	switch strings.ToLower(level) {
 	case "debug":
@@ -20,7 +27,7 @@ func SetupLogger(level string) {
 		l = slog.LevelInfo
 	}
 
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
 		Level: l,
 	})
 	slog.SetDefault(slog.New(handler))
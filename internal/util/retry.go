@@ -0,0 +1,131 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// retryTransport retries requests that fail with a transport error or a
+// transient status (429, 502, 503, 504), waiting a jittered exponential
+// backoff between attempts.
+type retryTransport struct {
+	base    http.RoundTripper
+	cfg     config.RetryConfig
+	methods map[string]bool
+}
+
+// NewRetryTransport wraps base (http.DefaultTransport if nil) with retries
+// configured by rc. Only requests whose method is listed in rc.Methods and
+// whose body can be replayed (http.Request.GetBody is set) are retried. It
+// returns base unchanged when rc allows a single attempt.
+func NewRetryTransport(base http.RoundTripper, rc config.RetryConfig) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if rc.MaxAttempts <= 1 {
+		return base
+	}
+	methods := make(map[string]bool, len(rc.Methods))
+	for _, m := range rc.Methods {
+		methods[strings.ToUpper(m)] = true
+	}
+	return &retryTransport{base: base, cfg: rc, methods: methods}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if !t.methods[req.Method] || (hasBody && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 {
+			r = req.Clone(withRetryAttempt(ctx, attempt))
+			if hasBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= t.cfg.MaxAttempts || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		attrs := []any{"method", req.Method, "url", req.URL.Redacted(), "attempt", attempt, "delay", delay}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		slog.Warn("retrying backend request", attrs...)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed attempt is worth repeating.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the retry that follows attempt: half of
+// the exponential step plus a random share of the other half, raised to the
+// server's Retry-After if that is longer, and capped at MaxBackoff.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	d := t.cfg.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > t.cfg.MaxBackoff {
+		d = t.cfg.MaxBackoff
+	}
+	d = d/2 + rand.N(d/2+1)
+	if resp != nil {
+		if ra := retryAfter(resp.Header.Get("Retry-After")); ra > d {
+			d = ra
+		}
+	}
+	return min(d, t.cfg.MaxBackoff)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
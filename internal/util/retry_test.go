@@ -0,0 +1,121 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+var testRetry = config.RetryConfig{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+	Methods:        []string{"GET", "PUT"},
+}
+
+func TestRetryTransport_RetriesTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetry)}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/doc", strings.NewReader(`{"a":1}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status=%d calls=%d, want 200 after 3 calls", resp.StatusCode, calls.Load())
+	}
+	for i, b := range bodies {
+		if b != `{"a":1}` {
+			t.Fatalf("attempt %d body=%q, want it replayed", i+1, b)
+		}
+	}
+}
+
+func TestRetryTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetry)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 3 {
+		t.Fatalf("status=%d calls=%d, want final 503 after 3 calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryTransport_SkipsNonIdempotentAndPermanentErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetry)}
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("POST calls=%d, want 1", calls.Load())
+	}
+
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 2 {
+		t.Fatalf("calls=%d, want a 400 not to be retried", calls.Load())
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	rt := NewRetryTransport(nil, config.RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}).(*retryTransport)
+
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: time.Second} {
+		if d := rt.backoff(attempt, nil); d < max/2 || d > max {
+			t.Errorf("backoff(%d)=%v, want in [%v, %v]", attempt, d, max/2, max)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	if d := rt.backoff(1, resp); d != time.Second {
+		t.Errorf("backoff with Retry-After 3s=%v, want capped at max_backoff", d)
+	}
+}
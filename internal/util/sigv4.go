@@ -0,0 +1,101 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// sigV4Transport signs every request with AWS Signature Version 4 before
+// passing it to base.
+type sigV4Transport struct {
+	base    http.RoundTripper
+	signer  *v4.Signer
+	creds   aws.CredentialsProvider
+	region  string
+	service string
+}
+
+// NewSigV4Transport wraps base (http.DefaultTransport if nil) so requests
+// are signed for sc.Service in sc.Region. Credentials are resolved through
+// the default AWS chain and cached until they expire. Request bodies are
+// buffered in memory because the signature covers their hash.
+func NewSigV4Transport(sc config.SigV4Config, base http.RoundTripper) (http.RoundTripper, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if sc.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(sc.Region))
+	}
+	if sc.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(sc.Profile))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured: set opensearch.sigv4.region or AWS_REGION")
+	}
+	if awsCfg.Credentials == nil {
+		return nil, fmt.Errorf("no AWS credentials provider available")
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &sigV4Transport{
+		base:    base,
+		signer:  v4.NewSigner(),
+		creds:   awsCfg.Credentials,
+		region:  awsCfg.Region,
+		service: sc.Service,
+	}, nil
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body for signing: %w", err)
+		}
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	signed := req.Clone(req.Context())
+	signed.ContentLength = int64(len(body))
+	if len(body) > 0 {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	} else {
+		signed.Body, signed.GetBody = http.NoBody, nil
+	}
+	// The reverse proxy keeps the client's Host header and credentials;
+	// AWS expects the domain's host and only the signature.
+	signed.Host = signed.URL.Host
+	signed.Header.Del("Authorization")
+	signed.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := t.creds.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("retrieving AWS credentials: %w", err)
+	}
+	if err := t.signer.SignHTTP(req.Context(), creds, signed, payloadHash, t.service, t.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+	return t.base.RoundTrip(signed)
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestSigV4Transport_SignsRequests(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	var gotAuth, gotHash, gotBody, gotHost string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotHash = r.Header.Get("X-Amz-Content-Sha256")
+		gotHost = r.Host
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := NewOpenSearchClient(config.OpenSearchConfig{
+		SigV4: config.SigV4Config{Enabled: true, Region: "eu-west-1", Service: "es"},
+	})
+	if err != nil {
+		t.Fatalf("NewOpenSearchClient: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/logs/_search", strings.NewReader(`{"size":0}`))
+	req.Host = "proxy.example.com"
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(gotAuth, "/eu-west-1/es/aws4_request") {
+		t.Fatalf("Authorization=%q, want SigV4 for eu-west-1/es", gotAuth)
+	}
+	if sum := sha256.Sum256([]byte(`{"size":0}`)); gotHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("X-Amz-Content-Sha256=%q, want payload hash", gotHash)
+	}
+	if gotBody != `{"size":0}` {
+		t.Fatalf("body=%q, want it forwarded unchanged", gotBody)
+	}
+	if gotHost != strings.TrimPrefix(srv.URL, "http://") {
+		t.Fatalf("Host=%q, want the OpenSearch host", gotHost)
+	}
+}
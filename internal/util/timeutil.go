@@ -2,6 +2,7 @@ package util
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"strconv"
 	"time"
@@ -17,6 +18,12 @@ type TimeRange struct {
 // on the given timestamp field. It looks for "range" clauses in "query.bool.filter",
 // "query.bool.must", and top-level "query.range".
 func ExtractTimeRange(body []byte, timestampField string) *TimeRange {
+	return ExtractTimeRangeAt(body, timestampField, time.Now())
+}
+
+// ExtractTimeRangeAt is ExtractTimeRange with "now" in date math taken to
+// be now, e.g. to route a search again as it was routed in the past.
+func ExtractTimeRangeAt(body []byte, timestampField string, now time.Time) *TimeRange {
 	var query map[string]json.RawMessage
 	if err := json.Unmarshal(body, &query); err != nil {
 		return nil
@@ -34,7 +41,7 @@ func ExtractTimeRange(body []byte, timestampField string) *TimeRange {
 
 	// Try top-level "range" directly under "query".
 	if rangeRaw, ok := q["range"]; ok {
-		if tr := parseRangeClause(rangeRaw, timestampField); tr != nil {
+		if tr := parseRangeClause(rangeRaw, timestampField, now); tr != nil {
 			return tr
 		}
 	}
@@ -70,7 +77,7 @@ func ExtractTimeRange(body []byte, timestampField string) *TimeRange {
 				continue
 			}
 			if rangeRaw, ok := c["range"]; ok {
-				if tr := parseRangeClause(rangeRaw, timestampField); tr != nil {
+				if tr := parseRangeClause(rangeRaw, timestampField, now); tr != nil {
 					return tr
 				}
 			}
@@ -80,7 +87,7 @@ func ExtractTimeRange(body []byte, timestampField string) *TimeRange {
 	return nil
 }
 
-func parseRangeClause(rangeRaw json.RawMessage, timestampField string) *TimeRange {
+func parseRangeClause(rangeRaw json.RawMessage, timestampField string, now time.Time) *TimeRange {
 	var rangeMap map[string]json.RawMessage
 	if err := json.Unmarshal(rangeRaw, &rangeMap); err != nil {
 		return nil
@@ -99,7 +106,7 @@ func parseRangeClause(rangeRaw json.RawMessage, timestampField string) *TimeRang
 	tr := &TimeRange{}
 	for _, key := range []string{"gte", "gt", "from"} {
 		if v, ok := bounds[key]; ok {
-			if t := parseTimeValue(v); t != nil {
+			if t := parseTimeValue(v, now); t != nil {
 				tr.From = t
 				break
 			}
@@ -107,7 +114,7 @@ func parseRangeClause(rangeRaw json.RawMessage, timestampField string) *TimeRang
 	}
 	for _, key := range []string{"lte", "lt", "to"} {
 		if v, ok := bounds[key]; ok {
-			if t := parseTimeValue(v); t != nil {
+			if t := parseTimeValue(v, now); t != nil {
 				tr.To = t
 				break
 			}
@@ -120,10 +127,38 @@ func parseRangeClause(rangeRaw json.RawMessage, timestampField string) *TimeRang
 	return tr
 }
 
-func parseTimeValue(v interface{}) *time.Time {
+// ParseTimeExpression parses an RFC3339 timestamp, a plain date
+// (2006-01-02) or a "now" date math expression such as "now-30d".
+func ParseTimeExpression(s string) (time.Time, error) {
+	return ParseTimeExpressionIn(s, time.UTC)
+}
+
+// ParseTimeExpressionIn is ParseTimeExpression with times that carry no
+// offset, such as plain dates, taken to be in loc.
+func ParseTimeExpressionIn(s string, loc *time.Location) (time.Time, error) {
+	now := time.Now()
+	t := parseNowDateMath(s, now)
+	if t == nil {
+		for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02"} {
+			if v, err := time.ParseInLocation(layout, s, loc); err == nil {
+				t = &v
+				break
+			}
+		}
+	}
+	if t == nil {
+		t = parseTimeValue(s, now)
+	}
+	if t == nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC3339, YYYY-MM-DD or now[+-]N[smhdwMy]", s)
+	}
+	return t.UTC(), nil
+}
+
+func parseTimeValue(v interface{}, now time.Time) *time.Time {
 	switch val := v.(type) {
 	case string:
-		if t := parseNowDateMath(val); t != nil {
+		if t := parseNowDateMath(val, now); t != nil {
 			return t
 		}
 		// Try common time formats.
@@ -153,13 +188,13 @@ var nowDateMathRe = regexp.MustCompile(`^now(?:(?P<op>[+-])(?P<num>\d+)(?P<unit>
 // - now+<N>[s|m|h|d|w]
 // - now-<N>[M|y] (months/years via AddDate)
 // Any unsupported expression returns nil so callers can fall back to "unknown".
-func parseNowDateMath(s string) *time.Time {
+func parseNowDateMath(s string, now time.Time) *time.Time {
 	m := nowDateMathRe.FindStringSubmatch(s)
 	if m == nil {
 		return nil
 	}
 
-	now := time.Now().UTC()
+	now = now.UTC()
 	op := m[nowDateMathRe.SubexpIndex("op")]
 	if op == "" {
 		return &now
@@ -212,3 +212,32 @@ func TestExtractTimeRange_NowDateMath_WithRoundingSuffix(t *testing.T) {
 		t.Fatalf("From=%v not within [%v, %v]", tr.From, startFrom, endFrom)
 	}
 }
+
+func TestParseTimeExpression(t *testing.T) {
+	if got, err := ParseTimeExpression("2026-01-02"); err != nil || !got.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date: got %v, %v", got, err)
+	}
+	if got, err := ParseTimeExpression("2026-01-02T03:04:05+02:00"); err != nil || !got.Equal(time.Date(2026, 1, 2, 1, 4, 5, 0, time.UTC)) {
+		t.Fatalf("rfc3339: got %v, %v", got, err)
+	}
+	got, err := ParseTimeExpression("now-30d")
+	if err != nil {
+		t.Fatalf("date math: %v", err)
+	}
+	if d := time.Since(got) - 30*24*time.Hour; d < 0 || d > time.Minute {
+		t.Fatalf("now-30d = %v, off by %v", got, d)
+	}
+	if _, err := ParseTimeExpression("last tuesday"); err == nil {
+		t.Fatalf("expected error for unparseable time")
+	}
+}
+
+func TestParseTimeExpressionIn(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	if got, err := ParseTimeExpressionIn("2026-01-02", loc); err != nil || !got.Equal(time.Date(2026, 1, 1, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date: got %v, %v", got, err)
+	}
+	if got, err := ParseTimeExpressionIn("2026-01-02T03:04:05Z", loc); err != nil || !got.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("rfc3339: got %v, %v", got, err)
+	}
+}
@@ -4,70 +4,94 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/leonunix/oqbridge/internal/config"
 )
 
-// NewHTTPClient builds an *http.Client with TLS settings from the given config.
-// If neither SkipVerify nor CACert is set, it returns a default client.
-func NewHTTPClient(tc config.TLSConfig) (*http.Client, error) {
-	if !tc.SkipVerify && tc.CACert == "" {
-		return &http.Client{}, nil
+// NewHTTPClient builds an *http.Client with TLS and connection pool settings
+// from the given config. If none are set, it returns a default client.
+func NewHTTPClient(tc config.TLSConfig, pc config.TransportConfig) (*http.Client, error) {
+	t, err := NewTLSTransport(tc, pc)
+	if err != nil {
+		return nil, err
 	}
-
-	tlsConfig := &tls.Config{}
-
-	if tc.SkipVerify {
-		tlsConfig.InsecureSkipVerify = true
-	}
-
-	if tc.CACert != "" {
-		caCert, err := os.ReadFile(tc.CACert)
-		if err != nil {
-			return nil, fmt.Errorf("reading CA certificate %s: %w", tc.CACert, err)
-		}
-		pool := x509.NewCertPool()
-		if !pool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to parse CA certificate %s", tc.CACert)
-		}
-		tlsConfig.RootCAs = pool
+	if t == nil {
+		return &http.Client{}, nil
 	}
-
-	return &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
-	}, nil
+	return &http.Client{Transport: t}, nil
 }
 
-// NewTLSTransport builds an *http.Transport with TLS settings from the given config.
+// NewTLSTransport builds an *http.Transport with TLS and connection pool
+// settings from the given config, starting from http.DefaultTransport so
+// unset fields keep Go's defaults. It returns nil if nothing is configured.
 // Used by the reverse proxy which needs a Transport rather than an http.Client.
-func NewTLSTransport(tc config.TLSConfig) (*http.Transport, error) {
-	if !tc.SkipVerify && tc.CACert == "" {
+func NewTLSTransport(tc config.TLSConfig, pc config.TransportConfig) (*http.Transport, error) {
+	useTLS := tc.SkipVerify || tc.CACert != "" || tc.ClientCert != "" ||
+		len(tc.CACertPEM) > 0 || tc.GetClientCertificate != nil
+	if !useTLS && pc == (config.TransportConfig{}) {
 		return nil, nil
 	}
 
-	tlsConfig := &tls.Config{}
+	t := http.DefaultTransport.(*http.Transport).Clone()
 
-	if tc.SkipVerify {
-		tlsConfig.InsecureSkipVerify = true
-	}
+	if useTLS {
+		tlsConfig := &tls.Config{}
 
-	if tc.CACert != "" {
-		caCert, err := os.ReadFile(tc.CACert)
-		if err != nil {
-			return nil, fmt.Errorf("reading CA certificate %s: %w", tc.CACert, err)
+		if tc.SkipVerify {
+			tlsConfig.InsecureSkipVerify = true
 		}
-		pool := x509.NewCertPool()
-		if !pool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to parse CA certificate %s", tc.CACert)
+
+		if tc.CACert != "" || len(tc.CACertPEM) > 0 {
+			pool := x509.NewCertPool()
+			if tc.CACert != "" {
+				caCert, err := os.ReadFile(tc.CACert)
+				if err != nil {
+					return nil, fmt.Errorf("reading CA certificate %s: %w", tc.CACert, err)
+				}
+				if !pool.AppendCertsFromPEM(caCert) {
+					return nil, fmt.Errorf("failed to parse CA certificate %s", tc.CACert)
+				}
+			}
+			if len(tc.CACertPEM) > 0 && !pool.AppendCertsFromPEM(tc.CACertPEM) {
+				return nil, fmt.Errorf("failed to parse CA certificate from Vault")
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		if tc.GetClientCertificate != nil {
+			tlsConfig.GetClientCertificate = tc.GetClientCertificate
+		} else if tc.ClientCert != "" {
+			loader, err := newClientCertLoader(tc.ClientCert, tc.ClientKey)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.GetClientCertificate = loader.GetClientCertificate
 		}
-		tlsConfig.RootCAs = pool
+		t.TLSClientConfig = tlsConfig
 	}
 
-	return &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}, nil
+	if pc.MaxIdleConns > 0 {
+		t.MaxIdleConns = pc.MaxIdleConns
+	}
+	if pc.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = pc.MaxIdleConnsPerHost
+	}
+	if pc.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = pc.MaxConnsPerHost
+	}
+	if pc.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = pc.IdleConnTimeout
+	}
+	if pc.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = pc.TLSHandshakeTimeout
+	}
+	if pc.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: pc.DialTimeout, KeepAlive: 30 * time.Second}
+		t.DialContext = dialer.DialContext
+	}
+	return t, nil
 }
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func TestNewHTTPClient_Default(t *testing.T) {
+	client, err := NewHTTPClient(config.TLSConfig{}, config.TransportConfig{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	if client.Transport != nil {
+		t.Fatalf("transport=%T, want nil so the default transport is used", client.Transport)
+	}
+}
+
+func TestNewHTTPClient_PoolSettings(t *testing.T) {
+	client, err := NewHTTPClient(config.TLSConfig{}, config.TransportConfig{
+		MaxIdleConnsPerHost: 64,
+		MaxConnsPerHost:     128,
+		IdleConnTimeout:     2 * time.Minute,
+		DialTimeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	tr, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport=%T, want *http.Transport", client.Transport)
+	}
+	if tr.MaxIdleConnsPerHost != 64 || tr.MaxConnsPerHost != 128 || tr.IdleConnTimeout != 2*time.Minute {
+		t.Fatalf("pool settings not applied: per_host_idle=%d per_host=%d idle_timeout=%v",
+			tr.MaxIdleConnsPerHost, tr.MaxConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr.DialContext == nil {
+		t.Fatal("expected a custom dialer for dial_timeout")
+	}
+	// Unset fields keep Go's defaults.
+	def := http.DefaultTransport.(*http.Transport)
+	if tr.MaxIdleConns != def.MaxIdleConns || tr.TLSHandshakeTimeout != def.TLSHandshakeTimeout {
+		t.Fatalf("unset fields changed: max_idle=%d tls_handshake=%v", tr.MaxIdleConns, tr.TLSHandshakeTimeout)
+	}
+	if tr.TLSClientConfig != nil && tr.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("TLS verification must stay enabled")
+	}
+}
@@ -0,0 +1,249 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// Client is a minimal HashiCorp Vault HTTP API client: it logs in with the
+// configured auth method, reads secrets and renews its token and secret
+// leases.
+type Client struct {
+	cfg    config.VaultConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	token     string
+	tokenTTL  time.Duration // 0 for tokens that never expire
+	renewable bool
+}
+
+// Secret is a secret read from Vault. Values that are not strings are
+// rendered with fmt.Sprint.
+type Secret struct {
+	Data          map[string]string
+	LeaseID       string        // empty for KV secrets
+	LeaseDuration time.Duration // 0 if the secret does not expire
+	Renewable     bool
+}
+
+// New creates a client for vc. Call Login before reading secrets.
+func New(vc config.VaultConfig) (*Client, error) {
+	hc, err := util.NewHTTPClient(vc.TLSConfig, config.TransportConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("creating vault HTTP client: %w", err)
+	}
+	return &Client{cfg: vc, client: hc}, nil
+}
+
+// authResponse is the part of a login or token renewal response that
+// describes the issued token.
+type authResponse struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// Login obtains a token with the configured auth method. For the token
+// method the configured token, or VAULT_TOKEN, is looked up to learn its
+// TTL.
+func (c *Client) Login(ctx context.Context) error {
+	var body map[string]string
+	switch c.cfg.AuthMethod {
+	case "token":
+		token := c.cfg.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		if token == "" {
+			return fmt.Errorf("vault token auth: neither vault.token nor VAULT_TOKEN is set")
+		}
+		c.setToken(token, 0, false)
+		var resp struct {
+			Data struct {
+				TTL       int64 `json:"ttl"`
+				Renewable bool  `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, &resp); err != nil {
+			return fmt.Errorf("looking up vault token: %w", err)
+		}
+		c.setToken(token, time.Duration(resp.Data.TTL)*time.Second, resp.Data.Renewable)
+		return nil
+	case "kubernetes":
+		jwt, err := os.ReadFile(c.cfg.ServiceAccountTokenFile)
+		if err != nil {
+			return fmt.Errorf("reading service account token: %w", err)
+		}
+		body = map[string]string{"role": c.cfg.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case "approle":
+		body = map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID}
+	default:
+		return fmt.Errorf("unsupported vault auth method %q", c.cfg.AuthMethod)
+	}
+
+	var resp authResponse
+	if err := c.do(ctx, http.MethodPost, "auth/"+c.cfg.AuthMount+"/login", body, &resp); err != nil {
+		return fmt.Errorf("vault %s login: %w", c.cfg.AuthMethod, err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault %s login: response has no token", c.cfg.AuthMethod)
+	}
+	c.setToken(resp.Auth.ClientToken, time.Duration(resp.Auth.LeaseDuration)*time.Second, resp.Auth.Renewable)
+	return nil
+}
+
+// RenewToken extends the token's TTL. Tokens that are not renewable are
+// replaced by logging in again.
+func (c *Client) RenewToken(ctx context.Context) error {
+	c.mu.Lock()
+	renewable := c.renewable
+	c.mu.Unlock()
+	if !renewable {
+		return c.Login(ctx)
+	}
+
+	var resp authResponse
+	if err := c.do(ctx, http.MethodPost, "auth/token/renew-self", map[string]string{}, &resp); err != nil {
+		return fmt.Errorf("renewing vault token: %w", err)
+	}
+	if resp.Auth == nil {
+		return fmt.Errorf("renewing vault token: response has no auth block")
+	}
+	c.mu.Lock()
+	c.tokenTTL = time.Duration(resp.Auth.LeaseDuration) * time.Second
+	c.renewable = resp.Auth.Renewable
+	c.mu.Unlock()
+	return nil
+}
+
+// TokenTTL returns the remaining TTL of the token as of the last login or
+// renewal, or 0 if it does not expire.
+func (c *Client) TokenTTL() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokenTTL
+}
+
+// Read reads the secret at path, e.g. "secret/data/oqbridge/opensearch".
+// KV version 2 responses are unwrapped to the secret's own data.
+func (c *Client) Read(ctx context.Context, path string) (*Secret, error) {
+	var resp struct {
+		LeaseID       string                 `json:"lease_id"`
+		LeaseDuration int64                  `json:"lease_duration"`
+		Renewable     bool                   `json:"renewable"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, strings.Trim(path, "/"), nil, &resp); err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", path, err)
+	}
+
+	data := resp.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, kv2 := data["metadata"]; kv2 {
+			data = inner
+		}
+	}
+	secret := &Secret{
+		Data:          make(map[string]string, len(data)),
+		LeaseID:       resp.LeaseID,
+		LeaseDuration: time.Duration(resp.LeaseDuration) * time.Second,
+		Renewable:     resp.Renewable,
+	}
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			secret.Data[k] = s
+		} else if v != nil {
+			secret.Data[k] = fmt.Sprint(v)
+		}
+	}
+	return secret, nil
+}
+
+// RenewLease extends the lease of a dynamic secret and returns its new
+// duration.
+func (c *Client) RenewLease(ctx context.Context, leaseID string) (time.Duration, error) {
+	var resp struct {
+		LeaseDuration int64 `json:"lease_duration"`
+	}
+	if err := c.do(ctx, http.MethodPut, "sys/leases/renew", map[string]string{"lease_id": leaseID}, &resp); err != nil {
+		return 0, fmt.Errorf("renewing vault lease %s: %w", leaseID, err)
+	}
+	return time.Duration(resp.LeaseDuration) * time.Second, nil
+}
+
+func (c *Client) setToken(token string, ttl time.Duration, renewable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.tokenTTL = ttl
+	c.renewable = renewable
+}
+
+// do sends a request to /v1/<path> and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	url := strings.TrimRight(c.cfg.Address, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &backend.HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Body:       string(respBody),
+		}
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+func newTestClient(t *testing.T, vc config.VaultConfig, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	vc.Address = srv.URL
+	c, err := New(vc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestClient_AppRoleLogin(t *testing.T) {
+	c := newTestClient(t, config.VaultConfig{AuthMethod: "approle", AuthMount: "approle", RoleID: "r", SecretID: "s", Namespace: "team"},
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/auth/approle/login" || r.Method != http.MethodPost {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			if got := r.Header.Get("X-Vault-Namespace"); got != "team" {
+				t.Errorf("namespace header = %q", got)
+			}
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "r" || body["secret_id"] != "s" {
+				t.Errorf("login body = %v", body)
+			}
+			w.Write([]byte(`{"auth":{"client_token":"tok","lease_duration":3600,"renewable":true}}`))
+		})
+
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if c.token != "tok" || !c.renewable {
+		t.Errorf("token = %q, renewable = %v", c.token, c.renewable)
+	}
+	if c.TokenTTL() != time.Hour {
+		t.Errorf("TokenTTL() = %v, want 1h", c.TokenTTL())
+	}
+}
+
+func TestClient_TokenLoginLooksUpTTL(t *testing.T) {
+	c := newTestClient(t, config.VaultConfig{AuthMethod: "token", Token: "root"},
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/auth/token/lookup-self" {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			if got := r.Header.Get("X-Vault-Token"); got != "root" {
+				t.Errorf("token header = %q", got)
+			}
+			w.Write([]byte(`{"data":{"ttl":60,"renewable":false}}`))
+		})
+
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if c.TokenTTL() != time.Minute || c.renewable {
+		t.Errorf("TokenTTL() = %v, renewable = %v", c.TokenTTL(), c.renewable)
+	}
+}
+
+func TestClient_ReadKVv2(t *testing.T) {
+	c := newTestClient(t, config.VaultConfig{AuthMethod: "token", Token: "t"},
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/secret/data/oqbridge" {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			w.Write([]byte(`{"data":{"data":{"username":"svc","password":"pw","port":9200},"metadata":{"version":3}}}`))
+		})
+	c.setToken("t", 0, false)
+
+	secret, err := c.Read(context.Background(), "/secret/data/oqbridge")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := map[string]string{"username": "svc", "password": "pw", "port": "9200"}
+	for k, v := range want {
+		if secret.Data[k] != v {
+			t.Errorf("Data[%q] = %q, want %q", k, secret.Data[k], v)
+		}
+	}
+	if len(secret.Data) != len(want) {
+		t.Errorf("Data = %v, want only the KV v2 secret data", secret.Data)
+	}
+}
+
+func TestClient_ReadDynamicSecret(t *testing.T) {
+	c := newTestClient(t, config.VaultConfig{AuthMethod: "token", Token: "t"},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"lease_id":"database/creds/ro/abc","lease_duration":600,"renewable":true,"data":{"username":"v-ro","password":"x"}}`))
+		})
+	c.setToken("t", 0, false)
+
+	secret, err := c.Read(context.Background(), "database/creds/ro")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if secret.LeaseID != "database/creds/ro/abc" || secret.LeaseDuration != 10*time.Minute || !secret.Renewable {
+		t.Errorf("lease = %q %v renewable=%v", secret.LeaseID, secret.LeaseDuration, secret.Renewable)
+	}
+	if secret.Data["username"] != "v-ro" {
+		t.Errorf("username = %q", secret.Data["username"])
+	}
+}
+
+func TestClient_ErrorStatus(t *testing.T) {
+	c := newTestClient(t, config.VaultConfig{AuthMethod: "token", Token: "t"},
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		})
+	c.setToken("t", 0, false)
+
+	if _, err := c.Read(context.Background(), "secret/data/x"); err == nil {
+		t.Fatal("expected error for 403 response")
+	}
+}
@@ -0,0 +1,268 @@
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// Secret fields read from the OpenSearch and Quickwit paths. All are
+// optional; bearer_token is only used for Quickwit.
+const (
+	fieldUsername    = "username"
+	fieldPassword    = "password"
+	fieldBearerToken = "bearer_token"
+	fieldClientCert  = "client_cert" // PEM certificate for mutual TLS
+	fieldClientKey   = "client_key"  // PEM private key of client_cert
+	fieldCACert      = "ca_cert"     // PEM CA certificates to trust
+)
+
+// retryInterval is how soon a failed renewal or read is retried.
+const retryInterval = 30 * time.Second
+
+// Source keeps the service account credentials of a running process in
+// sync with Vault. Nothing is written to disk: credentials are shared with
+// the backend clients through backend.Credentials and client certificates
+// are served from memory.
+type Source struct {
+	client   *Client
+	interval time.Duration
+	failed   bool // the last refresh failed; retry after retryInterval
+
+	// OpenSearch and Quickwit are the credentials to share with every
+	// client of that backend; nil if no secret path is configured for it.
+	OpenSearch *backend.Credentials
+	Quickwit   *backend.Credentials
+
+	targets []*target
+}
+
+// target is one secret path and where its values go.
+type target struct {
+	name   string // "opensearch" or "quickwit", for logs
+	path   string
+	creds  *backend.Credentials
+	cert   *certHolder
+	secret *Secret
+	read   time.Time // when secret was read or its lease last renewed
+}
+
+// certHolder serves the current client certificate to TLS handshakes.
+type certHolder struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func (h *certHolder) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.cert, nil
+}
+
+func (h *certHolder) set(cert *tls.Certificate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cert = cert
+}
+
+// Load logs in to Vault, reads the configured secrets and applies them to
+// cfg, so that clients built from cfg afterwards authenticate with them. It
+// returns nil if Vault is not configured. Long-running processes call Run
+// and share the Source's credentials with their clients to follow rotation.
+func Load(ctx context.Context, cfg *config.Config) (*Source, error) {
+	if cfg.Vault.Address == "" {
+		return nil, nil
+	}
+	client, err := New(cfg.Vault)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Login(ctx); err != nil {
+		return nil, err
+	}
+	s := &Source{client: client, interval: cfg.Vault.RefreshInterval}
+
+	if path := cfg.Vault.OpenSearchPath; path != "" {
+		t, err := s.load(ctx, "opensearch", path, &cfg.OpenSearch.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OpenSearch.Username = t.secret.Data[fieldUsername]
+		cfg.OpenSearch.Password = t.secret.Data[fieldPassword]
+		s.OpenSearch = t.creds
+	}
+	if path := cfg.Vault.QuickwitPath; path != "" {
+		t, err := s.load(ctx, "quickwit", path, &cfg.Quickwit.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Quickwit.Username = t.secret.Data[fieldUsername]
+		cfg.Quickwit.Password = t.secret.Data[fieldPassword]
+		cfg.Quickwit.Auth.BearerToken = t.secret.Data[fieldBearerToken]
+		s.Quickwit = t.creds
+	}
+	return s, nil
+}
+
+// load reads the secret at path and points tc at its TLS material.
+func (s *Source) load(ctx context.Context, name, path string, tc *config.TLSConfig) (*target, error) {
+	secret, err := s.client.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	t := &target{name: name, path: path, creds: backend.NewCredentials("", ""), secret: secret, read: time.Now()}
+	applyCredentials(t.creds, secret)
+
+	if pem := secret.Data[fieldCACert]; pem != "" {
+		tc.CACertPEM = []byte(pem)
+	}
+	if secret.Data[fieldClientCert] != "" {
+		cert, err := tls.X509KeyPair([]byte(secret.Data[fieldClientCert]), []byte(secret.Data[fieldClientKey]))
+		if err != nil {
+			return nil, fmt.Errorf("loading %s client certificate from vault: %w", name, err)
+		}
+		t.cert = &certHolder{cert: &cert}
+		tc.GetClientCertificate = t.cert.get
+	}
+	s.targets = append(s.targets, t)
+	slog.Info("loaded credentials from vault", "backend", name, "path", path, "lease_duration", secret.LeaseDuration)
+	return t, nil
+}
+
+// CredentialSetter is implemented by every client that authenticates with
+// a service account.
+type CredentialSetter interface {
+	SetCredentials(c *backend.Credentials)
+}
+
+// ShareOpenSearch makes clients authenticate with the OpenSearch
+// credentials from Vault. It does nothing if s is nil or has none.
+func (s *Source) ShareOpenSearch(clients ...CredentialSetter) {
+	if s == nil || s.OpenSearch == nil {
+		return
+	}
+	for _, c := range clients {
+		c.SetCredentials(s.OpenSearch)
+	}
+}
+
+// ShareQuickwit makes clients authenticate with the Quickwit credentials
+// from Vault. It does nothing if s is nil or has none.
+func (s *Source) ShareQuickwit(clients ...CredentialSetter) {
+	if s == nil || s.Quickwit == nil {
+		return
+	}
+	for _, c := range clients {
+		c.SetCredentials(s.Quickwit)
+	}
+}
+
+func applyCredentials(creds *backend.Credentials, secret *Secret) {
+	creds.SetBasic(secret.Data[fieldUsername], secret.Data[fieldPassword])
+	creds.SetBearerToken(secret.Data[fieldBearerToken])
+}
+
+// Run keeps the token and secrets current until ctx is done. The token is
+// renewed, or re-obtained by logging in again, before it expires. Leased
+// secrets are renewed before their lease ends and read again once it can
+// no longer be extended; other secrets are re-read each refresh interval.
+// Changed credentials and client certificates take effect for the next
+// request.
+func (s *Source) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(s.nextRefresh())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.refresh(ctx)
+	}
+}
+
+// nextRefresh returns how long to wait before the next refresh: the
+// refresh interval, shortened to two thirds of the token TTL or of any
+// secret's remaining lease.
+func (s *Source) nextRefresh() time.Duration {
+	wait := s.interval
+	if s.failed {
+		wait = min(wait, retryInterval)
+	}
+	if ttl := s.client.TokenTTL(); ttl > 0 {
+		wait = min(wait, ttl*2/3)
+	}
+	for _, t := range s.targets {
+		if t.secret.LeaseDuration > 0 {
+			wait = min(wait, time.Until(t.read.Add(t.secret.LeaseDuration*2/3)))
+		}
+	}
+	return max(wait, time.Second)
+}
+
+func (s *Source) refresh(ctx context.Context) {
+	s.failed = false
+	if err := s.client.RenewToken(ctx); err != nil {
+		slog.Warn("failed to renew vault token, logging in again", "error", err)
+		if err := s.client.Login(ctx); err != nil {
+			slog.Error("vault login failed, keeping current credentials", "error", err)
+			s.failed = true
+			return
+		}
+	}
+	for _, t := range s.targets {
+		if err := s.refreshTarget(ctx, t); err != nil {
+			slog.Error("failed to refresh vault secret, keeping current credentials", "backend", t.name, "path", t.path, "error", err)
+			s.failed = true
+		}
+	}
+}
+
+func (s *Source) refreshTarget(ctx context.Context, t *target) error {
+	// Renewing a lease keeps the current credentials valid. Once the lease
+	// cannot be extended, or nearly reached its maximum TTL, the secret is
+	// read again, which for dynamic secrets issues new credentials.
+	if t.secret.LeaseID != "" && t.secret.Renewable {
+		d, err := s.client.RenewLease(ctx, t.secret.LeaseID)
+		if err == nil && d >= t.secret.LeaseDuration/2 {
+			t.secret.LeaseDuration = d
+			t.read = time.Now()
+			return nil
+		}
+		if err != nil {
+			slog.Warn("failed to renew vault lease, reading the secret again", "backend", t.name, "error", err)
+		}
+	}
+
+	secret, err := s.client.Read(ctx, t.path)
+	if err != nil {
+		return err
+	}
+	t.read = time.Now()
+	old := t.secret
+	t.secret = secret
+	if maps.Equal(old.Data, secret.Data) {
+		return nil
+	}
+
+	applyCredentials(t.creds, secret)
+	if secret.Data[fieldCACert] != old.Data[fieldCACert] {
+		slog.Warn("vault CA certificate changed; restart to trust the new one", "backend", t.name)
+	}
+	if t.cert != nil && (secret.Data[fieldClientCert] != old.Data[fieldClientCert] || secret.Data[fieldClientKey] != old.Data[fieldClientKey]) {
+		cert, err := tls.X509KeyPair([]byte(secret.Data[fieldClientCert]), []byte(secret.Data[fieldClientKey]))
+		if err != nil {
+			return fmt.Errorf("loading rotated client certificate: %w", err)
+		}
+		t.cert.set(&cert)
+	}
+	slog.Info("rotated credentials from vault", "backend", t.name, "path", t.path)
+	return nil
+}
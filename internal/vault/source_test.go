@@ -0,0 +1,158 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/config"
+)
+
+// fakeVault serves an approle login and a KV v2 secret per path.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]string // path -> JSON object of the secret data
+}
+
+func (f *fakeVault) set(path, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.secrets[path] = data
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		w.Write([]byte(`{"auth":{"client_token":"tok","lease_duration":3600,"renewable":true}}`))
+	case "/v1/auth/token/renew-self":
+		w.Write([]byte(`{"auth":{"client_token":"tok","lease_duration":3600,"renewable":true}}`))
+	default:
+		data, ok := f.secrets[r.URL.Path[len("/v1/"):]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"data":%s,"metadata":{}}}`, data)
+	}
+}
+
+func newVaultConfig(t *testing.T, f *fakeVault) *config.Config {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return &config.Config{Vault: config.VaultConfig{
+		Address:         srv.URL,
+		AuthMethod:      "approle",
+		AuthMount:       "approle",
+		RoleID:          "r",
+		SecretID:        "s",
+		OpenSearchPath:  "secret/data/opensearch",
+		QuickwitPath:    "secret/data/quickwit",
+		RefreshInterval: time.Minute,
+	}}
+}
+
+func TestLoad_NotConfigured(t *testing.T) {
+	s, err := Load(context.Background(), &config.Config{})
+	if err != nil || s != nil {
+		t.Fatalf("Load() = %v, %v; want nil, nil", s, err)
+	}
+}
+
+func TestLoad_AppliesCredentials(t *testing.T) {
+	f := &fakeVault{secrets: map[string]string{
+		"secret/data/opensearch": `{"username":"os","password":"os-pw"}`,
+		"secret/data/quickwit":   `{"bearer_token":"qw-token"}`,
+	}}
+	cfg := newVaultConfig(t, f)
+
+	s, err := Load(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.OpenSearch.Username != "os" || cfg.OpenSearch.Password != "os-pw" {
+		t.Errorf("opensearch credentials = %q/%q", cfg.OpenSearch.Username, cfg.OpenSearch.Password)
+	}
+	if cfg.Quickwit.Auth.BearerToken != "qw-token" {
+		t.Errorf("quickwit bearer token = %q", cfg.Quickwit.Auth.BearerToken)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.OpenSearch.Apply(req)
+	if u, p, _ := req.BasicAuth(); u != "os" || p != "os-pw" {
+		t.Errorf("OpenSearch basic auth = %q/%q", u, p)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	s.Quickwit.Apply(req)
+	if got := req.Header.Get("Authorization"); got != "Bearer qw-token" {
+		t.Errorf("Quickwit Authorization = %q", got)
+	}
+}
+
+func TestLoad_MissingSecret(t *testing.T) {
+	f := &fakeVault{secrets: map[string]string{}}
+	if _, err := Load(context.Background(), newVaultConfig(t, f)); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+}
+
+func TestSource_RefreshRotatesCredentials(t *testing.T) {
+	f := &fakeVault{secrets: map[string]string{
+		"secret/data/opensearch": `{"username":"os","password":"old"}`,
+		"secret/data/quickwit":   `{"bearer_token":"t1"}`,
+	}}
+	cfg := newVaultConfig(t, f)
+	s, err := Load(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	f.set("secret/data/opensearch", `{"username":"os","password":"new"}`)
+	s.refresh(context.Background())
+	if s.failed {
+		t.Fatal("refresh failed")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.OpenSearch.Apply(req)
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("os:new"))
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization after rotation = %q, want %q", got, want)
+	}
+}
+
+func TestSource_RefreshKeepsCredentialsOnError(t *testing.T) {
+	f := &fakeVault{secrets: map[string]string{
+		"secret/data/opensearch": `{"username":"os","password":"pw"}`,
+		"secret/data/quickwit":   `{"bearer_token":"t1"}`,
+	}}
+	cfg := newVaultConfig(t, f)
+	s, err := Load(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	f.mu.Lock()
+	delete(f.secrets, "secret/data/opensearch")
+	f.mu.Unlock()
+	s.refresh(context.Background())
+	if !s.failed {
+		t.Error("expected refresh to be marked failed")
+	}
+	if got := s.nextRefresh(); got > retryInterval {
+		t.Errorf("nextRefresh() = %v, want at most %v after a failure", got, retryInterval)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.OpenSearch.Apply(req)
+	if u, p, _ := req.BasicAuth(); u != "os" || p != "pw" {
+		t.Errorf("credentials after failed refresh = %q/%q, want the previous ones", u, p)
+	}
+}
@@ -0,0 +1,169 @@
+package oqbridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// Migration types.
+type (
+	// Migrator moves documents past migrate_after_days from OpenSearch
+	// into Quickwit, or into the next entry of tiers.
+	Migrator = migration.Migrator
+	// MigratorOption configures a Migrator.
+	MigratorOption = migration.MigratorOption
+	// RunReport summarizes a migration run.
+	RunReport = migration.RunReport
+	// HotClient reads the documents to migrate.
+	HotClient = migration.HotClient
+	// ColdClient receives migrated documents.
+	ColdClient = migration.ColdClient
+)
+
+// Outcomes of a migration run, in RunReport.Outcome.
+const (
+	OutcomeSuccess        = migration.OutcomeSuccess
+	OutcomeNothingToDo    = migration.OutcomeNothingToDo
+	OutcomePartialFailure = migration.OutcomePartialFailure
+	OutcomeFailed         = migration.OutcomeFailed
+)
+
+// Checkpoint stores.
+type (
+	// CheckpointStore keeps the progress of interrupted migrations and the
+	// watermark each index has been migrated up to.
+	CheckpointStore = migration.CheckpointStore
+	// Checkpoint is the progress of the migration of an index.
+	Checkpoint = migration.Checkpoint
+	// Watermark is the time before which an index has been migrated.
+	Watermark = migration.Watermark
+	// LocalCheckpointStore keeps checkpoints as files in a directory.
+	LocalCheckpointStore = migration.LocalCheckpointStore
+	// OpenSearchCheckpointStore keeps checkpoints in the .oqbridge-state
+	// index of OpenSearch.
+	OpenSearchCheckpointStore = migration.OpenSearchCheckpointStore
+)
+
+// NewLocalCheckpointStore keeps checkpoints in dir, creating it if needed.
+func NewLocalCheckpointStore(dir string) (*LocalCheckpointStore, error) {
+	return migration.NewLocalCheckpointStore(dir)
+}
+
+// NewOpenSearchCheckpointStore keeps checkpoints in the OpenSearch cluster
+// oc, so that any worker can resume a migration.
+func NewOpenSearchCheckpointStore(oc OpenSearchConfig) (*OpenSearchCheckpointStore, error) {
+	client, err := util.NewOpenSearchClient(oc)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	return migration.NewOpenSearchCheckpointStore(oc.URL, oc.Username, oc.Password, client), nil
+}
+
+// NewMigrator creates the migrator configured by cfg, as oqbridge-migrate
+// does for a single source: it keeps checkpoints in
+// migration.checkpoint_dir, or in OpenSearch if unset, and holds the
+// OpenSearch migration lock while running. opts are applied after the
+// options cfg implies. With migration.sources, pass each source's
+// configuration from cfg.ForSource.
+func NewMigrator(cfg *Config, opts ...MigratorOption) (*Migrator, error) {
+	osClient, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	hot := backend.NewOpenSearch(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+
+	var store CheckpointStore
+	if cfg.Migration.CheckpointDir != "" {
+		if store, err = migration.NewLocalCheckpointStore(cfg.Migration.CheckpointDir); err != nil {
+			return nil, fmt.Errorf("opening checkpoint store: %w", err)
+		}
+	} else {
+		store = migration.NewOpenSearchCheckpointStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)
+	}
+
+	all := []MigratorOption{
+		migration.WithDistLock(backend.NewOpenSearchLock(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, osClient)),
+		migration.WithLockTTL(cfg.Migration.LockTTL),
+	}
+	var sink ColdClient
+	var counter migration.RangeCounter
+	if next := cfg.NextTier(); next != nil {
+		tier, err := NewOpenSearch(next.OpenSearch)
+		if err != nil {
+			return nil, fmt.Errorf("tier %s: %w", next.Name, err)
+		}
+		sink, counter = tier, tier
+	} else {
+		def, clusters, err := newQuickwitClusters(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range clusters {
+			setupIngest(q, cfg)
+		}
+		setupIngest(def, cfg)
+		cold := backend.NewQuickwitRouter(def, clusters, cfg.QuickwitClusterForIndex)
+		sink, counter = cold, cold
+		all = append(all, migration.WithColdHealthCheck(cold), migration.WithColdStats(cold))
+	}
+	if cfg.Migration.HealthGate.Enabled {
+		all = append(all, migration.WithClusterHealth(hot))
+	}
+	if cfg.Migration.Dedup {
+		all = append(all, migration.WithDedup(hot, counter))
+	}
+	if cfg.Migration.Snapshot.Enabled {
+		all = append(all, migration.WithSnapshotSource(hot))
+	}
+	return migration.NewMigrator(cfg, hot, sink, store, append(all, opts...)...)
+}
+
+// NewMigratorWith creates a migrator configured by cfg that moves
+// documents from hot into cold and keeps checkpoints in store, for
+// callers that bring their own clients. It holds no migration lock unless
+// opts include WithDistLock.
+func NewMigratorWith(cfg *Config, hot HotClient, cold ColdClient, store CheckpointStore, opts ...MigratorOption) (*Migrator, error) {
+	return migration.NewMigrator(cfg, hot, cold, store, opts...)
+}
+
+// DistLock keeps migrators of the same indices from running at once.
+type DistLock = migration.DistLock
+
+// WithDistLock makes the migrator hold lock while it runs.
+func WithDistLock(lock DistLock) MigratorOption {
+	return migration.WithDistLock(lock)
+}
+
+// WithWindow migrates documents from from up to to instead of from the
+// watermark up to migrate_after_days, without touching the watermarks and
+// checkpoints of scheduled runs.
+func WithWindow(from, to time.Time) MigratorOption {
+	return migration.WithWindow(from, to)
+}
+
+// setupIngest makes q ingest and create indices with the per-index
+// migration and quickwit_index_settings of cfg.
+func setupIngest(q *Quickwit, cfg *Config) {
+	q.SetIngestOptions(func(index string) backend.IngestOptions {
+		s := cfg.MigrationSettingsForIndex(index)
+		return backend.IngestOptions{Compress: s.Compress, TempDir: s.TempDir}
+	})
+	q.SetIndexSettings(func(index string) backend.IndexSettings {
+		s := cfg.QuickwitIndexSettingsForIndex(index)
+		return backend.IndexSettings{
+			CommitTimeoutSecs:  s.CommitTimeoutSecs,
+			SplitNumDocsTarget: s.SplitNumDocsTarget,
+			MergePolicy: backend.MergePolicy{
+				Type:             s.MergePolicy.Type,
+				MergeFactor:      s.MergePolicy.MergeFactor,
+				MaxMergeFactor:   s.MergePolicy.MaxMergeFactor,
+				MaturationPeriod: s.MergePolicy.MaturationPeriod,
+			},
+			DefaultSearchFields: s.DefaultSearchFields,
+		}
+	})
+}
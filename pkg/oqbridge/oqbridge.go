@@ -0,0 +1,102 @@
+// Package oqbridge lets Go services embed oqbridge instead of running its
+// binaries: route queries between OpenSearch and Quickwit, merge their
+// results, serve the proxy as an http.Handler or run migrations.
+//
+// The types are aliases of those the oqbridge and oqbridge-migrate
+// binaries use, and the constructors build them from a Config the same
+// way. Everything this package exports follows semantic versioning; the
+// internal packages behind it do not.
+package oqbridge
+
+import (
+	"fmt"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/config"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// Config is the oqbridge configuration, as read from oqbridge.yaml.
+type Config = config.Config
+
+// OpenSearchConfig and QuickwitConfig configure the connection to a
+// backend cluster.
+type (
+	OpenSearchConfig = config.OpenSearchConfig
+	QuickwitConfig   = config.QuickwitConfig
+)
+
+// LoadConfig reads the configuration file at path (.yaml, .json or .toml),
+// applies OQBRIDGE_* environment variables and defaults, and validates it.
+// An empty path configures from the environment only.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// Backend clients.
+type (
+	// OpenSearch is a client of an OpenSearch cluster.
+	OpenSearch = backend.OpenSearch
+	// Quickwit is a client of a Quickwit cluster.
+	Quickwit = backend.Quickwit
+	// QuickwitRouter sends each index to the Quickwit cluster configured
+	// for it in quickwit_clusters.
+	QuickwitRouter = backend.QuickwitRouter
+	// SearchResponse is a search response in the OpenSearch format.
+	SearchResponse = backend.SearchResponse
+)
+
+// NewOpenSearch creates a client of the OpenSearch cluster oc, with its
+// TLS, authentication and retry settings.
+func NewOpenSearch(oc OpenSearchConfig) (*OpenSearch, error) {
+	client, err := util.NewOpenSearchClient(oc)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	return backend.NewOpenSearch(oc.URL, oc.Username, oc.Password, client), nil
+}
+
+// NewQuickwit creates a client of the Quickwit cluster qc, with its TLS,
+// authentication, search and ingest settings.
+func NewQuickwit(qc QuickwitConfig) (*Quickwit, error) {
+	client, err := util.NewQuickwitClient(qc)
+	if err != nil {
+		return nil, fmt.Errorf("creating Quickwit HTTP client: %w", err)
+	}
+	q := backend.NewQuickwit(qc.URL, qc.Username, qc.Password, false, client)
+	q.SetAuth(qc.Auth.BearerToken, qc.Auth.Headers)
+	q.SetSearchAPI(qc.SearchAPI)
+	q.SetIngestMode(qc.IngestAPI, qc.IngestCommit)
+	if qc.ListCacheTTL > 0 {
+		q.SetListCacheTTL(qc.ListCacheTTL)
+	}
+	return q, nil
+}
+
+// NewColdBackend creates the clients of the quickwit cluster and each
+// quickwit_clusters entry of cfg, routing indices between them. Call its
+// WatchFeatures method for search_api and ingest_api "auto" to take
+// effect.
+func NewColdBackend(cfg *Config) (*QuickwitRouter, error) {
+	def, clusters, err := newQuickwitClusters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return backend.NewQuickwitRouter(def, clusters, cfg.QuickwitClusterForIndex), nil
+}
+
+// newQuickwitClusters creates the clients of the quickwit cluster and of
+// the quickwit_clusters entries by name.
+func newQuickwitClusters(cfg *Config) (*Quickwit, map[string]*Quickwit, error) {
+	def, err := NewQuickwit(cfg.Quickwit)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusters := make(map[string]*Quickwit, len(cfg.QuickwitClusters))
+	for _, c := range cfg.QuickwitClusters {
+		if clusters[c.Name], err = NewQuickwit(c.Quickwit); err != nil {
+			return nil, nil, fmt.Errorf("quickwit cluster %s: %w", c.Name, err)
+		}
+	}
+	return def, clusters, nil
+}
@@ -0,0 +1,116 @@
+package oqbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBackend serves searches with a single hit carrying msg, as OpenSearch
+// or Quickwit, and a Quickwit index list with logs.
+func newBackend(t *testing.T, msg string, score float64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/indexes":
+			w.Write([]byte(`[{"index_config":{"index_id":"logs"}}]`))
+		case strings.HasSuffix(r.URL.Path, "search"):
+			fmt.Fprintf(w, `{"took":1,"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_score":%g,"_source":{"msg":%q}}]}}`, score, msg)
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func loadConfig(t *testing.T, yaml string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "oqbridge.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	return cfg
+}
+
+func TestNewProxy(t *testing.T) {
+	hot := newBackend(t, "hot", 1)
+	cold := newBackend(t, "cold", 0.5)
+	cfg := loadConfig(t, fmt.Sprintf("opensearch:\n  url: %q\nquickwit:\n  url: %q\nretention:\n  days: 30\n", hot.URL, cold.URL))
+
+	p, err := NewProxy(cfg)
+	if err != nil {
+		t.Fatalf("NewProxy() error: %v", err)
+	}
+	defer p.Close(context.Background())
+
+	now := time.Now().UTC()
+	body := fmt.Sprintf(`{"query":{"range":{"@timestamp":{"gte":%q,"lte":%q}}}}`,
+		now.AddDate(0, 0, -60).Format(time.RFC3339), now.Format(time.RFC3339))
+	if route := NewRouterFor(cfg).Route([]byte(body), cfg.Retention.TimestampField); route != RouteBoth {
+		t.Errorf("Route() = %v, want both", route)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/logs/_search", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	var resp SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("search = %d %s", rec.Code, rec.Body)
+	}
+	if resp.Hits.Total.Value != 2 || len(resp.Hits.Hits) != 2 {
+		t.Errorf("search = %s, want both tiers merged", rec.Body)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	parse := func(s string) *SearchResponse {
+		var r SearchResponse
+		if err := json.Unmarshal([]byte(s), &r); err != nil {
+			t.Fatal(err)
+		}
+		return &r
+	}
+	hot := parse(`{"hits":{"total":{"value":1},"hits":[{"_id":"h","_score":1}]}}`)
+	cold := parse(`{"hits":{"total":{"value":2},"hits":[{"_id":"c1","_score":2},{"_id":"c2","_score":0.5}]}}`)
+
+	merged := MergeWithOptions(hot, cold, MergeOptions{Size: 2, Paginate: true})
+	if merged.Hits.Total.Value != 3 || len(merged.Hits.Hits) != 2 || !strings.Contains(string(merged.Hits.Hits[0]), `"c1"`) {
+		t.Errorf("MergeWithOptions() = total %d, hits %s", merged.Hits.Total.Value, merged.Hits.Hits)
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	hot := newBackend(t, "hot", 1)
+	cold := newBackend(t, "cold", 1)
+	dir := t.TempDir()
+	cfg := loadConfig(t, fmt.Sprintf("opensearch:\n  url: %q\nquickwit:\n  url: %q\nmigration:\n  checkpoint_dir: %q\n", hot.URL, cold.URL, dir))
+
+	if _, err := NewMigrator(cfg, WithWindow(time.Now().AddDate(0, 0, -2), time.Now().AddDate(0, 0, -1))); err != nil {
+		t.Fatalf("NewMigrator() error: %v", err)
+	}
+
+	store, err := NewLocalCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalCheckpointStore() error: %v", err)
+	}
+	if err := store.SaveWatermark(&Watermark{Index: "logs", MigratedBefore: time.Now().UTC().Truncate(time.Millisecond)}); err != nil {
+		t.Fatalf("SaveWatermark() error: %v", err)
+	}
+	if wm, err := store.LoadWatermark("logs"); err != nil || wm == nil {
+		t.Errorf("LoadWatermark() = %v, %v", wm, err)
+	}
+}
@@ -0,0 +1,143 @@
+package oqbridge
+
+import (
+	"fmt"
+
+	"github.com/leonunix/oqbridge/internal/backend"
+	"github.com/leonunix/oqbridge/internal/migration"
+	"github.com/leonunix/oqbridge/internal/proxy"
+	"github.com/leonunix/oqbridge/internal/util"
+)
+
+// Route is where a search goes: the hot tier, the cold tier or both.
+type Route = proxy.RouteTarget
+
+const (
+	RouteHotOnly  = proxy.RouteHotOnly
+	RouteColdOnly = proxy.RouteColdOnly
+	RouteBoth     = proxy.RouteBoth
+)
+
+// Routing strategies.
+type (
+	// Router decides which tiers a search reaches.
+	Router = proxy.Router
+	// RouteRequest is what a Router decides on.
+	RouteRequest = proxy.RouteRequest
+	// RouterFactory creates a Router from the configuration.
+	RouterFactory = proxy.RouterFactory
+	// TimeRangeRouter is the default Router, routing by the time range
+	// a query filters on.
+	TimeRangeRouter = proxy.TimeRangeRouter
+)
+
+// RegisterRouter makes the Routers of factory available to proxies
+// configured with server.router.name set to name. Call it before creating
+// them, e.g. from an init function; it panics if name is taken.
+func RegisterRouter(name string, factory RouterFactory) {
+	proxy.RegisterRouter(name, factory)
+}
+
+// NewRouter creates a TimeRangeRouter keeping retentionDays in the hot
+// tier, with cutoffs exactly retentionDays before the search.
+func NewRouter(retentionDays int) *TimeRangeRouter {
+	return proxy.NewRouter(retentionDays)
+}
+
+// NewRouterFor creates the TimeRangeRouter of cfg, with its retention and
+// time zone, e.g. for a custom Router to fall back on.
+func NewRouterFor(cfg *Config) *TimeRangeRouter {
+	return proxy.NewRouterIn(cfg.Retention.Days, cfg.RoutingLocation())
+}
+
+// MergeOptions controls the order and page of merged results.
+type MergeOptions = proxy.MergeOptions
+
+// SortField is an entry of MergeOptions.Sort, the sort of the search.
+type SortField = proxy.SortField
+
+// Merge combines the responses of both tiers to one search, summing
+// totals and aggregations and ordering hits by score.
+func Merge(hot, cold *SearchResponse) *SearchResponse {
+	return proxy.MergeSearchResponses(hot, cold)
+}
+
+// MergeWithOptions is Merge followed by the ordering and page of opts.
+func MergeWithOptions(hot, cold *SearchResponse, opts MergeOptions) *SearchResponse {
+	return proxy.MergeSearchResponsesWithOptions(hot, cold, opts)
+}
+
+// Proxy is the oqbridge proxy, an http.Handler serving the OpenSearch API.
+type Proxy = proxy.Proxy
+
+// ProxyOption configures a Proxy created by NewProxyWith.
+type ProxyOption = proxy.Option
+
+// NewProxy creates the proxy configured by cfg, with clients of its
+// opensearch, quickwit, quickwit_clusters, tiers and remote_clusters
+// clusters, as the oqbridge binary does. Close it to flush dual-write
+// queues.
+func NewProxy(cfg *Config) (*Proxy, error) {
+	hot, err := NewOpenSearch(cfg.OpenSearch)
+	if err != nil {
+		return nil, err
+	}
+	def, clusters, err := newQuickwitClusters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cold := backend.NewQuickwitRouter(def, clusters, cfg.QuickwitClusterForIndex)
+	transport, err := util.NewOpenSearchTransport(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch transport: %w", err)
+	}
+	var opts []ProxyOption
+	for _, t := range cfg.Tiers {
+		tier, err := NewOpenSearch(t.OpenSearch)
+		if err != nil {
+			return nil, fmt.Errorf("tier %s: %w", t.Name, err)
+		}
+		opts = append(opts, proxy.WithTier(t.Name, tier))
+	}
+	for _, rc := range cfg.RemoteClusters {
+		if rc.QuickwitCluster != "" {
+			opts = append(opts, proxy.WithRemoteCluster(rc.Name, clusters[rc.QuickwitCluster]))
+		}
+	}
+	if cfg.Migration.CheckpointDir == "" {
+		store, err := NewOpenSearchCheckpointStore(cfg.OpenSearch)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, proxy.WithWatermarks(store))
+	}
+	client, err := util.NewOpenSearchClient(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch HTTP client: %w", err)
+	}
+	opts = append(opts, proxy.WithMigrationRuns(migration.NewOpenSearchMetricsStore(cfg.OpenSearch.URL, cfg.OpenSearch.Username, cfg.OpenSearch.Password, client)))
+	return proxy.New(cfg, hot, cold, transport, opts...)
+}
+
+// NewProxyWith creates a proxy configured by cfg that searches hot and
+// cold instead of clients built from cfg, e.g. to share them with the
+// rest of a service. Pass WithTier for each tiers entry.
+func NewProxyWith(cfg *Config, hot *OpenSearch, cold *QuickwitRouter, opts ...ProxyOption) (*Proxy, error) {
+	transport, err := util.NewOpenSearchTransport(cfg.OpenSearch)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenSearch transport: %w", err)
+	}
+	return proxy.New(cfg, hot, cold, transport, opts...)
+}
+
+// WithWatermarks makes GET /_oqbridge/stats and /_oqbridge/timeline report
+// how far the migration of each index has gone, as recorded in store.
+func WithWatermarks(store CheckpointStore) ProxyOption {
+	return proxy.WithWatermarks(store)
+}
+
+// WithTier searches b as the next entry of tiers, called name. Pass one
+// for each entry, in the order they are configured.
+func WithTier(name string, b *OpenSearch) ProxyOption {
+	return proxy.WithTier(name, b)
+}